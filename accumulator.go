@@ -40,6 +40,11 @@ type Accumulator interface {
 		tags map[string]string,
 		t ...time.Time)
 
+	// AddMetric adds a metric to the accumulator, such as one made with a
+	// metric.Builder, without the maps of the other methods.  The metric is
+	// owned by the accumulator afterwards.
+	AddMetric(m Metric)
+
 	SetPrecision(precision, interval time.Duration)
 
 	AddError(err error)
@@ -21,6 +21,7 @@ type MetricMaker interface {
 		mType telegraf.ValueType,
 		t time.Time,
 	) telegraf.Metric
+	MakeMetricFrom(m telegraf.Metric) telegraf.Metric
 }
 
 func NewAccumulator(
@@ -98,6 +99,13 @@ func (ac *accumulator) AddHistogram(
 	}
 }
 
+func (ac *accumulator) AddMetric(m telegraf.Metric) {
+	m.SetTime(m.Time().Round(ac.precision))
+	if m := ac.maker.MakeMetricFrom(m); m != nil {
+		ac.metrics <- m
+	}
+}
+
 // AddError passes a runtime error to the accumulator.
 // The error will be tagged with the plugin name and written to the log.
 func (ac *accumulator) AddError(err error) {
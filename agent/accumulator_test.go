@@ -47,6 +47,28 @@ func TestAddFields(t *testing.T) {
 	require.Equal(t, telegraf.Counter, tp)
 }
 
+func TestAddMetric(t *testing.T) {
+	metrics := make(chan telegraf.Metric, 10)
+	defer close(metrics)
+	a := NewAccumulator(&TestMetricMaker{}, metrics)
+	a.SetPrecision(time.Second, 0)
+
+	b := metric.NewBuilder()
+	b.SetName("acctest")
+	b.AddTag("foo", "bar")
+	b.AddField("usage", float64(99))
+	b.SetTime(time.Unix(1500000000, 600000000))
+	m, err := b.Metric()
+	require.NoError(t, err)
+	a.AddMetric(m)
+
+	testm := <-metrics
+	require.Equal(t, "acctest", testm.Name())
+	require.Equal(t, map[string]string{"foo": "bar"}, testm.Tags())
+	require.Equal(t, map[string]interface{}{"usage": float64(99)}, testm.Fields())
+	require.Equal(t, time.Unix(1500000001, 0), testm.Time())
+}
+
 func TestAccAddError(t *testing.T) {
 	errBuf := bytes.NewBuffer(nil)
 	log.SetOutput(errBuf)
@@ -157,3 +179,7 @@ func (tm *TestMetricMaker) MakeMetric(
 	}
 	return nil
 }
+
+func (tm *TestMetricMaker) MakeMetricFrom(m telegraf.Metric) telegraf.Metric {
+	return m
+}
@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"crypto/rand"
 	"errors"
+	"fmt"
 	"log"
 	"math/big"
 	"os"
@@ -63,6 +64,58 @@ func (d *Duration) UnmarshalTOML(b []byte) error {
 	return nil
 }
 
+// Size just wraps an int64
+type Size struct {
+	Size int64
+}
+
+// Multipliers of the units of the sizes, the decimal units being powers of
+// 1000 and the binary units powers of 1024.
+var sizeUnits = map[string]int64{
+	"":    1,
+	"b":   1,
+	"kb":  1000,
+	"mb":  1000 * 1000,
+	"gb":  1000 * 1000 * 1000,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+}
+
+// UnmarshalTOML parses the size from the TOML config file, as an integer
+// number of bytes or a string such as "64MB" or "1GiB"
+func (s *Size) UnmarshalTOML(b []byte) error {
+	b = bytes.Trim(b, `'`)
+
+	// First try parsing as integer bytes
+	if i, err := strconv.ParseInt(string(b), 10, 64); err == nil {
+		s.Size = i
+		return nil
+	}
+
+	uq, err := strconv.Unquote(string(b))
+	if err != nil {
+		uq = string(b)
+	}
+	uq = strings.TrimSpace(uq)
+	i := strings.IndexFunc(uq, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(uq)
+	}
+	value, err := strconv.ParseFloat(uq[:i], 64)
+	if err != nil {
+		return fmt.Errorf("invalid size %q", uq)
+	}
+	unit, ok := sizeUnits[strings.ToLower(strings.TrimSpace(uq[i:]))]
+	if !ok {
+		return fmt.Errorf("invalid unit of size %q", uq)
+	}
+	s.Size = int64(value * float64(unit))
+	return nil
+}
+
 // ReadLines reads contents from a file and splits them by new lines.
 // A convenience wrapper to ReadLinesOffsetN(filename, 0, -1).
 func ReadLines(filename string) ([]string, error) {
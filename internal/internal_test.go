@@ -162,3 +162,25 @@ func TestDuration(t *testing.T) {
 	d.UnmarshalTOML([]byte(`1.5`))
 	assert.Equal(t, time.Second, d.Duration)
 }
+
+func TestSize(t *testing.T) {
+	var s Size
+
+	assert.NoError(t, s.UnmarshalTOML([]byte(`1024`)))
+	assert.Equal(t, int64(1024), s.Size)
+
+	s = Size{}
+	assert.NoError(t, s.UnmarshalTOML([]byte(`"64MB"`)))
+	assert.Equal(t, int64(64000000), s.Size)
+
+	s = Size{}
+	assert.NoError(t, s.UnmarshalTOML([]byte(`'1GiB'`)))
+	assert.Equal(t, int64(1<<30), s.Size)
+
+	s = Size{}
+	assert.NoError(t, s.UnmarshalTOML([]byte(`"1.5 kb"`)))
+	assert.Equal(t, int64(1500), s.Size)
+
+	s = Size{}
+	assert.Error(t, s.UnmarshalTOML([]byte(`"10 parsecs"`)))
+}
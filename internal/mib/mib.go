@@ -0,0 +1,367 @@
+// Package mib parses SMIv1 and SMIv2 MIB modules to translate between the
+// names and the numbers of OIDs, without the net-snmp tools.
+package mib
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// The roots of the OID tree, which are not defined by any module.
+var roots = map[string]int{
+	"ccitt":           0,
+	"iso":             1,
+	"joint-iso-ccitt": 2,
+}
+
+type arc struct {
+	name     string
+	num      int
+	numbered bool
+}
+
+// definition is an OID assignment of a module, before its OID is resolved.
+type definition struct {
+	name     string
+	oid      []arc
+	syntax   string
+	access   string
+	index    []string
+	augments string
+}
+
+type module struct {
+	name    string
+	imports map[string]string
+	defs    map[string]*definition
+	order   []*definition
+}
+
+func newModule(name string) *module {
+	return &module{
+		name:    name,
+		imports: map[string]string{},
+		defs:    map[string]*definition{},
+	}
+}
+
+func (m *module) add(def *definition) {
+	if _, ok := m.defs[def.name]; !ok {
+		m.order = append(m.order, def)
+	}
+	m.defs[def.name] = def
+}
+
+// Node is an object of the OID tree defined by a module.
+type Node struct {
+	Module string
+	Name   string
+	// OID is the numeric OID, with a leading dot.
+	OID string
+	// Syntax is the type of an OBJECT-TYPE, like "INTEGER" or the name of a
+	// textual convention.
+	Syntax string
+	// Access is the MAX-ACCESS, or the ACCESS of SMIv1, of an OBJECT-TYPE.
+	Access string
+	// Index are the names of the columns indexing the rows of a table entry.
+	Index []string
+	// Augments is the name of the table entry of which the rows are augmented
+	// by this one, sharing its index.
+	Augments string
+
+	parent    *Node
+	children  []*Node
+	num       int
+	anonymous bool
+}
+
+// Children returns the child nodes ordered by OID.
+func (n *Node) Children() []*Node {
+	return n.children
+}
+
+// Parent returns the parent node, or nil for the nodes without a known parent.
+func (n *Node) Parent() *Node {
+	return n.parent
+}
+
+// MIB is a set of modules of which the OIDs are resolved.
+type MIB struct {
+	modules map[string]*module
+	nodes   map[string]*Node
+	// names holds the nodes by module, then by name.
+	names map[string]map[string]*Node
+	// order are the names of the modules, sorted to resolve the names in a
+	// deterministic order.
+	order []string
+}
+
+// New returns an empty MIB.
+func New() *MIB {
+	return &MIB{
+		modules: map[string]*module{},
+		nodes:   map[string]*Node{},
+		names:   map[string]map[string]*Node{},
+	}
+}
+
+// Load loads the modules of all the files of the directories, recursively.
+// The missing directories and the files which are not valid modules are
+// skipped.
+func Load(paths []string) (*MIB, error) {
+	m := New()
+	for _, path := range paths {
+		err := filepath.Walk(path, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			if err := m.parseFile(path); err != nil {
+				log.Printf("D! Skipping MIB file %s: %s", path, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	m.resolve()
+	return m, nil
+}
+
+func (m *MIB) parseFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	modules, err := parse(f)
+	if err != nil {
+		return err
+	}
+	for _, mod := range modules {
+		// The first definition of a module wins, like with net-snmp
+		if _, ok := m.modules[mod.name]; !ok {
+			m.modules[mod.name] = mod
+		}
+	}
+	return nil
+}
+
+// resolve computes the OIDs of all the definitions, and builds the tree of
+// nodes.  Definitions of which the parent is unknown are dropped.
+func (m *MIB) resolve() {
+	m.order = make([]string, 0, len(m.modules))
+	for name := range m.modules {
+		m.order = append(m.order, name)
+	}
+	sort.Strings(m.order)
+
+	resolved := map[*definition]*Node{}
+	for _, name := range m.order {
+		mod := m.modules[name]
+		for _, def := range mod.order {
+			m.resolveDefinition(mod, def, resolved, map[*definition]bool{})
+		}
+	}
+
+	for oid, n := range m.nodes {
+		i := strings.LastIndex(oid, ".")
+		n.num, _ = strconv.Atoi(oid[i+1:])
+		if parent, ok := m.nodes[oid[:i]]; ok {
+			n.parent = parent
+			parent.children = append(parent.children, n)
+		}
+	}
+	for _, n := range m.nodes {
+		sort.Slice(n.children, func(i, j int) bool {
+			return n.children[i].num < n.children[j].num
+		})
+	}
+}
+
+func (m *MIB) resolveDefinition(mod *module, def *definition, resolved map[*definition]*Node, visiting map[*definition]bool) *Node {
+	if n, ok := resolved[def]; ok {
+		return n
+	}
+	if visiting[def] {
+		return nil
+	}
+	visiting[def] = true
+
+	var parent *Node
+	first := def.oid[0]
+	if num, ok := roots[first.name]; ok && !first.numbered {
+		first.num = num
+		first.numbered = true
+	}
+	if first.numbered {
+		parent = m.intermediate(mod, first.name, "."+strconv.Itoa(first.num))
+	} else {
+		pmod, pdef := m.lookupDefinition(mod, first.name)
+		if pdef != nil {
+			parent = m.resolveDefinition(pmod, pdef, resolved, visiting)
+		}
+		if parent == nil {
+			resolved[def] = nil
+			return nil
+		}
+	}
+	oid := parent.OID
+
+	rest := def.oid[1:]
+	if len(rest) == 0 {
+		// An alias of another node, like "{ iso }"
+		resolved[def] = parent
+		return parent
+	}
+	for _, a := range rest[:len(rest)-1] {
+		if !a.numbered {
+			resolved[def] = nil
+			return nil
+		}
+		oid += "." + strconv.Itoa(a.num)
+		parent = m.intermediate(mod, a.name, oid)
+	}
+	last := rest[len(rest)-1]
+	if !last.numbered {
+		resolved[def] = nil
+		return nil
+	}
+	oid += "." + strconv.Itoa(last.num)
+
+	n := &Node{
+		Module:   mod.name,
+		Name:     def.name,
+		OID:      oid,
+		Syntax:   def.syntax,
+		Access:   def.access,
+		Index:    def.index,
+		Augments: def.augments,
+	}
+	if existing, ok := m.nodes[oid]; !ok || existing.anonymous {
+		m.nodes[oid] = n
+	}
+	m.addName(n)
+	resolved[def] = n
+	return n
+}
+
+// intermediate returns the node of the OID, adding an anonymous one for the
+// OIDs which are not defined, like "dod(6)" in "{ iso org(3) dod(6) 1 }".
+func (m *MIB) intermediate(mod *module, name string, oid string) *Node {
+	if n, ok := m.nodes[oid]; ok {
+		return n
+	}
+	n := &Node{Name: name, OID: oid, anonymous: true}
+	if name == "" {
+		n.Name = oid[strings.LastIndex(oid, ".")+1:]
+	} else if _, ok := roots[name]; !ok {
+		n.Module = mod.name
+		m.addName(n)
+	}
+	m.nodes[oid] = n
+	return n
+}
+
+func (m *MIB) addName(n *Node) {
+	names, ok := m.names[n.Module]
+	if !ok {
+		names = map[string]*Node{}
+		m.names[n.Module] = names
+	}
+	if _, ok := names[n.Name]; !ok {
+		names[n.Name] = n
+	}
+}
+
+// lookupDefinition finds the definition of a name used by a module, defined
+// by the module itself, imported, or else defined by any other module.
+func (m *MIB) lookupDefinition(mod *module, name string) (*module, *definition) {
+	if def, ok := mod.defs[name]; ok {
+		return mod, def
+	}
+	if from, ok := mod.imports[name]; ok {
+		if imod, ok := m.modules[from]; ok {
+			if def, ok := imod.defs[name]; ok {
+				return imod, def
+			}
+		}
+	}
+	for _, other := range m.order {
+		if def, ok := m.modules[other].defs[name]; ok {
+			return m.modules[other], def
+		}
+	}
+	return nil, nil
+}
+
+// Lookup resolves an OID given by name, like "IF-MIB::ifDescr.1", "ifDescr"
+// or ".iso.3.6", or by number, like ".1.3.6.1.2.1.2.2.1.2.1".  It returns the
+// node of the longest known prefix and the remaining numeric suffix, like
+// ".1".  The returned node is nil for numeric OIDs of which no prefix is
+// known.
+func (m *MIB) Lookup(oid string) (*Node, string, error) {
+	var moduleName string
+	if i := strings.Index(oid, "::"); i != -1 {
+		moduleName = oid[:i]
+		oid = oid[i+2:]
+		if _, ok := m.names[moduleName]; !ok {
+			return nil, "", fmt.Errorf("unknown module %s", moduleName)
+		}
+	}
+	oid = strings.TrimPrefix(oid, ".")
+
+	parts := strings.Split(oid, ".")
+	for _, part := range parts[1:] {
+		if _, err := strconv.ParseUint(part, 10, 32); err != nil {
+			return nil, "", fmt.Errorf("invalid OID %s", oid)
+		}
+	}
+
+	if num, ok := roots[parts[0]]; ok && moduleName == "" {
+		parts[0] = strconv.Itoa(num)
+	} else if _, err := strconv.ParseUint(parts[0], 10, 32); err != nil || moduleName != "" {
+		n := m.lookupName(moduleName, parts[0])
+		if n == nil {
+			return nil, "", fmt.Errorf("unknown object %s", parts[0])
+		}
+		parts[0] = strings.TrimPrefix(n.OID, ".")
+	}
+
+	for i := len(parts); i > 0; i-- {
+		// The roots and the numeric arcs are not objects of any module
+		if n, ok := m.nodes["."+strings.Join(parts[:i], ".")]; ok && n.Module != "" {
+			var suffix string
+			if i < len(parts) {
+				suffix = "." + strings.Join(parts[i:], ".")
+			}
+			return n, suffix, nil
+		}
+	}
+	return nil, "." + strings.Join(parts, "."), nil
+}
+
+func (m *MIB) lookupName(moduleName string, name string) *Node {
+	if moduleName != "" {
+		return m.names[moduleName][name]
+	}
+
+	for _, module := range m.order {
+		if n, ok := m.names[module][name]; ok {
+			return n
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package mib
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func load(t *testing.T) *MIB {
+	m, err := Load([]string{"testdata", "testdata/missing"})
+	require.NoError(t, err)
+	return m
+}
+
+func TestLookup(t *testing.T) {
+	m := load(t)
+
+	tests := []struct {
+		oid    string
+		module string
+		name   string
+		suffix string
+	}{
+		{"TEST-MIB::testName", "TEST-MIB", "testName", ""},
+		{"TEST-MIB::testName.0", "TEST-MIB", "testName", ".0"},
+		{"testName.0", "TEST-MIB", "testName", ".0"},
+		{".1.3.6.1.4.1.99999.1.1.0", "TEST-MIB", "testName", ".0"},
+		{"1.3.6.1.4.1.99999.1.2.1.4.5", "TEST-MIB", "testPortAddress", ".5"},
+		{".iso.3.6.1.4.1.99999.1", "TEST-MIB", "testObjects", ""},
+		{"SNMPv2-SMI::enterprises.99999", "TEST-MIB", "testMIB", ""},
+		{"RFC1213-MIB::sysDescr.0", "RFC1213-MIB", "sysDescr", ".0"},
+		{"RFC1213-MIB::dod", "SNMPv2-SMI", "dod", ""},
+	}
+	for _, tt := range tests {
+		n, suffix, err := m.Lookup(tt.oid)
+		require.NoError(t, err, tt.oid)
+		require.NotNil(t, n, tt.oid)
+		assert.Equal(t, tt.module, n.Module, tt.oid)
+		assert.Equal(t, tt.name, n.Name, tt.oid)
+		assert.Equal(t, tt.suffix, suffix, tt.oid)
+	}
+
+	n, _, err := m.Lookup("TEST-MIB::testPortAddress")
+	require.NoError(t, err)
+	assert.Equal(t, ".1.3.6.1.4.1.99999.1.2.1.4", n.OID)
+	assert.Equal(t, "MacAddress", n.Syntax)
+	assert.Equal(t, "read-only", n.Access)
+
+	n, _, err = m.Lookup("RFC1213-MIB::sysDescr")
+	require.NoError(t, err)
+	assert.Equal(t, ".1.3.6.1.2.1.1.1", n.OID)
+	assert.Equal(t, "DisplayString", n.Syntax)
+	assert.Equal(t, "read-only", n.Access)
+}
+
+func TestLookup_unknown(t *testing.T) {
+	m := load(t)
+
+	n, suffix, err := m.Lookup(".1.2.3")
+	require.NoError(t, err)
+	assert.Nil(t, n)
+	assert.Equal(t, ".1.2.3", suffix)
+
+	n, suffix, err = m.Lookup(".iso.2.3")
+	require.NoError(t, err)
+	assert.Nil(t, n)
+	assert.Equal(t, ".1.2.3", suffix)
+
+	_, _, err = m.Lookup("FOO-MIB::testName")
+	assert.Error(t, err)
+	_, _, err = m.Lookup("TEST-MIB::foo")
+	assert.Error(t, err)
+	_, _, err = m.Lookup("TEST-MIB::testName.foo")
+	assert.Error(t, err)
+}
+
+func TestTable(t *testing.T) {
+	m := load(t)
+
+	table, _, err := m.Lookup("TEST-MIB::testPortTable")
+	require.NoError(t, err)
+	require.Len(t, table.Children(), 1)
+	entry := table.Children()[0]
+	assert.Equal(t, "testPortEntry", entry.Name)
+	assert.Equal(t, []string{"testPortIndex", "testPortName"}, entry.Index)
+	assert.Equal(t, table, entry.Parent())
+
+	var columns []string
+	for _, c := range entry.Children() {
+		columns = append(columns, c.Name)
+	}
+	assert.Equal(t, []string{"testPortIndex", "testPortName", "testPortEnabled", "testPortAddress"}, columns)
+
+	stats, _, err := m.Lookup("TEST-MIB::testPortStatsEntry")
+	require.NoError(t, err)
+	assert.Equal(t, "testPortEntry", stats.Augments)
+}
+
+func TestParse_invalid(t *testing.T) {
+	tests := []string{
+		"TEST-MIB DEFINITIONS ::= BEGIN\n",
+		"TEST-MIB DEFINITIONS ::= BEGIN\ntest OBJECT IDENTIFIER ::= { foo 1\nEND\n",
+		"TEST-MIB DEFINITIONS ::= BEGIN\ntest OBJECT IDENTIFIER\nEND\n",
+		"TEST-MIB DEFINITIONS ::= BEGIN\ntest OBJECT-TYPE DESCRIPTION \"foo ::= { test 1 }\nEND\n",
+		"TEST-MIB ::= BEGIN\nEND\n",
+	}
+	for _, src := range tests {
+		_, err := parse(strings.NewReader(src))
+		assert.Error(t, err, src)
+	}
+}
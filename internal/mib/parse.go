@@ -0,0 +1,415 @@
+package mib
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+)
+
+type token struct {
+	text string
+	line int
+}
+
+// tokenize splits the source of a MIB into ASN.1 tokens, dropping the
+// comments.  Quoted strings are a single token.
+func tokenize(src []byte) ([]token, error) {
+	var tokens []token
+	line := 1
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+		case c == ' ' || c == '\t' || c == '\r' || c == '\f':
+			i++
+		case c == '-' && i+1 < len(src) && src[i+1] == '-':
+			// A comment ends at the end of the line or at the next "--"
+			i += 2
+			for i < len(src) && src[i] != '\n' {
+				if src[i] == '-' && i+1 < len(src) && src[i+1] == '-' {
+					i += 2
+					break
+				}
+				i++
+			}
+		case c == '"':
+			start, startLine := i, line
+			for i++; i < len(src) && src[i] != '"'; i++ {
+				if src[i] == '\n' {
+					line++
+				}
+			}
+			if i == len(src) {
+				return nil, fmt.Errorf("line %d: unterminated string", startLine)
+			}
+			i++
+			tokens = append(tokens, token{text: string(src[start:i]), line: startLine})
+		case c == '\'':
+			// Binary or hexadecimal string, like 'ff'H
+			start := i
+			for i++; i < len(src) && src[i] != '\''; i++ {
+			}
+			if i+1 >= len(src) {
+				return nil, fmt.Errorf("line %d: unterminated string", line)
+			}
+			i += 2
+			tokens = append(tokens, token{text: string(src[start:i]), line: line})
+		case c == ':' && i+2 < len(src) && src[i+1] == ':' && src[i+2] == '=':
+			tokens = append(tokens, token{text: "::=", line: line})
+			i += 3
+		case c == '.' && i+1 < len(src) && src[i+1] == '.':
+			tokens = append(tokens, token{text: "..", line: line})
+			i += 2
+		case isLetter(c) || isDigit(c) || c == '-':
+			start := i
+			for i++; i < len(src) && isIdentifier(src, i); i++ {
+			}
+			tokens = append(tokens, token{text: string(src[start:i]), line: line})
+		default:
+			tokens = append(tokens, token{text: string(c), line: line})
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+func isLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isIdentifier returns whether the character at i continues an identifier or
+// a number, a hyphen followed by another one starting a comment.
+func isIdentifier(src []byte, i int) bool {
+	c := src[i]
+	if c == '-' {
+		return i+1 < len(src) && src[i+1] != '-'
+	}
+	return isLetter(c) || isDigit(c) || c == '_'
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) eof() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *parser) peek() string {
+	if p.eof() {
+		return ""
+	}
+	return p.tokens[p.pos].text
+}
+
+func (p *parser) next() string {
+	s := p.peek()
+	p.pos++
+	return s
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	line := 0
+	if p.pos < len(p.tokens) {
+		line = p.tokens[p.pos].line
+	} else if len(p.tokens) > 0 {
+		line = p.tokens[len(p.tokens)-1].line
+	}
+	return fmt.Errorf("line %d: %s", line, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) expect(text string) error {
+	if s := p.next(); s != text {
+		return p.errorf("expected %q, got %q", text, s)
+	}
+	return nil
+}
+
+// skipBlock skips the block starting at the current brace, parenthesis or
+// bracket, including the nested ones, and returns the tokens in between.
+func (p *parser) skipBlock() ([]token, error) {
+	start := p.pos
+	depth := 0
+	for !p.eof() {
+		switch p.next() {
+		case "{", "(", "[":
+			depth++
+		case "}", ")", "]":
+			depth--
+		}
+		if depth == 0 {
+			return p.tokens[start+1 : p.pos-1], nil
+		}
+	}
+	return nil, p.errorf("unterminated block")
+}
+
+// parse parses all the modules of the source.
+func parse(r io.Reader) ([]*module, error) {
+	src, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	var modules []*module
+	for !p.eof() {
+		m, err := p.parseModule()
+		if err != nil {
+			return nil, err
+		}
+		modules = append(modules, m)
+	}
+	return modules, nil
+}
+
+// parseModule parses "NAME DEFINITIONS ::= BEGIN ... END".
+func (p *parser) parseModule() (*module, error) {
+	m := newModule(p.next())
+	if p.peek() == "{" {
+		if _, err := p.skipBlock(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect("DEFINITIONS"); err != nil {
+		return nil, err
+	}
+	for !p.eof() && p.peek() != "::=" {
+		p.next()
+	}
+	if err := p.expect("::="); err != nil {
+		return nil, err
+	}
+	if err := p.expect("BEGIN"); err != nil {
+		return nil, err
+	}
+
+	for {
+		switch s := p.peek(); s {
+		case "":
+			return nil, p.errorf("missing END of module %s", m.name)
+		case "END":
+			p.next()
+			return m, nil
+		case "IMPORTS":
+			p.next()
+			if err := p.parseImports(m); err != nil {
+				return nil, err
+			}
+		case "EXPORTS":
+			for !p.eof() && p.next() != ";" {
+			}
+		default:
+			if !isLetter(s[0]) {
+				return nil, p.errorf("unexpected %q", s)
+			}
+			if err := p.parseAssignment(m); err != nil {
+				return nil, err
+			}
+		}
+	}
+}
+
+// parseImports parses "name, ... FROM MODULE ... ;".
+func (p *parser) parseImports(m *module) error {
+	var names []string
+	for {
+		switch s := p.next(); s {
+		case "":
+			return p.errorf("unterminated IMPORTS")
+		case ";":
+			return nil
+		case ",":
+		case "FROM":
+			from := p.next()
+			for _, name := range names {
+				m.imports[name] = from
+			}
+			names = names[:0]
+			if p.peek() == "{" {
+				if _, err := p.skipBlock(); err != nil {
+					return err
+				}
+			}
+		default:
+			names = append(names, s)
+		}
+	}
+}
+
+// parseAssignment parses a macro definition, a type assignment or a value
+// assignment, of which only the ones with an OID value are kept.
+func (p *parser) parseAssignment(m *module) error {
+	name := p.next()
+	if p.peek() == "MACRO" {
+		for !p.eof() && p.next() != "END" {
+		}
+		return nil
+	}
+
+	// The macro clauses before the value, like the SYNTAX of an OBJECT-TYPE
+	start := p.pos
+	for p.peek() != "::=" {
+		switch p.peek() {
+		case "", "END":
+			return p.errorf("missing value of %s", name)
+		case "{", "(", "[":
+			if _, err := p.skipBlock(); err != nil {
+				return err
+			}
+		default:
+			p.next()
+		}
+	}
+	header := p.tokens[start:p.pos]
+	p.next()
+
+	if name[0] >= 'A' && name[0] <= 'Z' {
+		return p.parseType()
+	}
+
+	if p.peek() != "{" {
+		// A value other than an OID, like the number of a TRAP-TYPE
+		p.next()
+		return nil
+	}
+	value, err := p.skipBlock()
+	if err != nil {
+		return err
+	}
+	oid, err := parseOID(value)
+	if err != nil {
+		return p.errorf("%s: %s", name, err)
+	}
+	def := &definition{name: name, oid: oid}
+	parseClauses(def, header)
+	m.add(def)
+	return nil
+}
+
+// parseType parses the type of a type assignment, like a textual convention,
+// only to find where it ends.
+func (p *parser) parseType() error {
+	if p.peek() == "TEXTUAL-CONVENTION" {
+		for p.peek() != "SYNTAX" {
+			if p.eof() {
+				return p.errorf("missing SYNTAX of TEXTUAL-CONVENTION")
+			}
+			p.next()
+		}
+		p.next()
+	}
+
+	if p.peek() == "[" {
+		if _, err := p.skipBlock(); err != nil {
+			return err
+		}
+	}
+	if p.peek() == "IMPLICIT" || p.peek() == "EXPLICIT" {
+		p.next()
+	}
+
+	switch p.next() {
+	case "OCTET", "OBJECT":
+		p.next()
+	case "SEQUENCE", "SET":
+		if p.peek() == "(" {
+			if _, err := p.skipBlock(); err != nil {
+				return err
+			}
+		}
+		if p.peek() == "OF" {
+			p.next()
+			return p.parseType()
+		}
+	case "":
+		return p.errorf("missing type")
+	default:
+		// A type of another module, like SNMPv2-TC.DisplayString
+		if p.peek() == "." {
+			p.next()
+			p.next()
+		}
+	}
+
+	for p.peek() == "{" || p.peek() == "(" {
+		if _, err := p.skipBlock(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseOID parses the components of an OID value, like
+// "{ iso org(3) dod(6) 1 }".
+func parseOID(tokens []token) ([]arc, error) {
+	var arcs []arc
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i].text
+		if n, err := strconv.ParseUint(t, 10, 32); err == nil {
+			arcs = append(arcs, arc{num: int(n), numbered: true})
+			continue
+		}
+		if !isLetter(t[0]) {
+			return nil, fmt.Errorf("invalid OID component %q", t)
+		}
+		a := arc{name: t}
+		if i+3 < len(tokens) && tokens[i+1].text == "(" && tokens[i+3].text == ")" {
+			n, err := strconv.ParseUint(tokens[i+2].text, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid OID component %q", tokens[i+2].text)
+			}
+			a.num = int(n)
+			a.numbered = true
+			i += 3
+		}
+		arcs = append(arcs, a)
+	}
+	if len(arcs) == 0 {
+		return nil, fmt.Errorf("empty OID")
+	}
+	return arcs, nil
+}
+
+// parseClauses reads the SYNTAX, MAX-ACCESS, INDEX and AUGMENTS clauses of an
+// OBJECT-TYPE.
+func parseClauses(def *definition, header []token) {
+	for i := 0; i < len(header)-1; i++ {
+		switch clause := header[i].text; clause {
+		case "SYNTAX":
+			def.syntax = header[i+1].text
+			if (def.syntax == "OCTET" || def.syntax == "OBJECT") && i+2 < len(header) {
+				def.syntax += " " + header[i+2].text
+			}
+		case "MAX-ACCESS", "ACCESS":
+			def.access = header[i+1].text
+		case "INDEX", "AUGMENTS":
+			if header[i+1].text != "{" {
+				break
+			}
+			var names []string
+			for i += 2; i < len(header) && header[i].text != "}"; i++ {
+				if t := header[i].text; t != "," && t != "IMPLIED" {
+					names = append(names, t)
+				}
+			}
+			if clause == "INDEX" {
+				def.index = names
+			} else if len(names) > 0 {
+				def.augments = names[0]
+			}
+		}
+	}
+}
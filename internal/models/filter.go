@@ -3,6 +3,7 @@ package models
 import (
 	"fmt"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/filter"
 )
 
@@ -132,6 +133,46 @@ func (f *Filter) Apply(
 	return true
 }
 
+// ApplyMetric is Apply for a metric, which is modified in place.
+func (f *Filter) ApplyMetric(m telegraf.Metric) bool {
+	if !f.isActive {
+		return true
+	}
+
+	if !f.shouldNamePass(m.Name()) {
+		return false
+	}
+
+	if !f.shouldTagsPassFunc(m.GetTag) {
+		return false
+	}
+
+	// The lists are walked backwards, removing an item only moving the
+	// items after it.
+	fields := m.FieldList()
+	for i := len(fields) - 1; i >= 0; i-- {
+		if !f.shouldFieldPass(fields[i].Key) {
+			m.RemoveField(fields[i].Key)
+		}
+	}
+	if len(m.FieldList()) == 0 {
+		return false
+	}
+
+	if f.tagInclude != nil || f.tagExclude != nil {
+		tags := m.TagList()
+		for i := len(tags) - 1; i >= 0; i-- {
+			key := tags[i].Key
+			if (f.tagInclude != nil && !f.tagInclude.Match(key)) ||
+				(f.tagExclude != nil && f.tagExclude.Match(key)) {
+				m.RemoveTag(key)
+			}
+		}
+	}
+
+	return true
+}
+
 // IsActive checking if filter is active
 func (f *Filter) IsActive() bool {
 	return f.isActive
@@ -198,13 +239,21 @@ func (f *Filter) shouldFieldPass(key string) bool {
 // shouldTagsPass returns true if the metric should pass, false if should drop
 // based on the tagdrop/tagpass filter parameters
 func (f *Filter) shouldTagsPass(tags map[string]string) bool {
+	return f.shouldTagsPassFunc(func(key string) (string, bool) {
+		value, ok := tags[key]
+		return value, ok
+	})
+}
+
+// shouldTagsPassFunc is shouldTagsPass for the tags returned by getTag.
+func (f *Filter) shouldTagsPassFunc(getTag func(key string) (string, bool)) bool {
 
 	pass := func(f *Filter) bool {
 		for _, pat := range f.TagPass {
 			if pat.filter == nil {
 				continue
 			}
-			if tagval, ok := tags[pat.Name]; ok {
+			if tagval, ok := getTag(pat.Name); ok {
 				if pat.filter.Match(tagval) {
 					return true
 				}
@@ -218,7 +267,7 @@ func (f *Filter) shouldTagsPass(tags map[string]string) bool {
 			if pat.filter == nil {
 				continue
 			}
-			if tagval, ok := tags[pat.Name]; ok {
+			if tagval, ok := getTag(pat.Name); ok {
 				if pat.filter.Match(tagval) {
 					return false
 				}
@@ -2,6 +2,9 @@ package models
 
 import (
 	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/metric"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -59,6 +62,43 @@ func TestFilter_ApplyDeleteAllFields(t *testing.T) {
 	assert.False(t, f.Apply("m", fields, nil))
 }
 
+func TestFilter_ApplyMetric(t *testing.T) {
+	f := Filter{
+		FieldDrop:  []string{"value"},
+		TagExclude: []string{"ho*"},
+		TagDrop: []TagFilter{
+			TagFilter{
+				Name:   "cpu",
+				Filter: []string{"cpu-*"},
+			},
+		},
+	}
+	require.NoError(t, f.Compile())
+
+	m, err := metric.New("m",
+		map[string]string{"host": "localhost", "mytag": "foobar"},
+		map[string]interface{}{"value": int64(1), "value2": int64(2)},
+		time.Now())
+	require.NoError(t, err)
+	assert.True(t, f.ApplyMetric(m))
+	assert.Equal(t, map[string]string{"mytag": "foobar"}, m.Tags())
+	assert.Equal(t, map[string]interface{}{"value2": int64(2)}, m.Fields())
+
+	m, err = metric.New("m",
+		map[string]string{"cpu": "cpu-total"},
+		map[string]interface{}{"value2": int64(2)},
+		time.Now())
+	require.NoError(t, err)
+	assert.False(t, f.ApplyMetric(m))
+
+	m, err = metric.New("m",
+		map[string]string{},
+		map[string]interface{}{"value": int64(1)},
+		time.Now())
+	require.NoError(t, err)
+	assert.False(t, f.ApplyMetric(m))
+}
+
 func TestFilter_Empty(t *testing.T) {
 	f := Filter{}
 
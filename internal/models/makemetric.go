@@ -84,3 +84,49 @@ func makemetric(
 
 	return m
 }
+
+// makemetricFrom applies the same changes as makemetric to a metric already
+// made, modifying it in place, and returns nil if the metric is dropped.
+func makemetricFrom(
+	m telegraf.Metric,
+	nameOverride string,
+	namePrefix string,
+	nameSuffix string,
+	pluginTags map[string]string,
+	daemonTags map[string]string,
+	filter Filter,
+	applyFilter bool,
+) telegraf.Metric {
+	if len(m.FieldList()) == 0 || len(m.Name()) == 0 {
+		return nil
+	}
+
+	if len(nameOverride) != 0 {
+		m.SetName(nameOverride)
+	}
+	if len(namePrefix) != 0 {
+		m.AddPrefix(namePrefix)
+	}
+	if len(nameSuffix) != 0 {
+		m.AddSuffix(nameSuffix)
+	}
+
+	for k, v := range pluginTags {
+		if !m.HasTag(k) {
+			m.AddTag(k, v)
+		}
+	}
+	for k, v := range daemonTags {
+		if !m.HasTag(k) {
+			m.AddTag(k, v)
+		}
+	}
+
+	if applyFilter {
+		if ok := filter.ApplyMetric(m); !ok {
+			return nil
+		}
+	}
+
+	return m
+}
@@ -77,6 +77,27 @@ func (r *RunningAggregator) MakeMetric(
 	return m
 }
 
+// MakeMetricFrom is MakeMetric for a metric made by the aggregator, which is
+// modified in place.
+func (r *RunningAggregator) MakeMetricFrom(metric telegraf.Metric) telegraf.Metric {
+	m := makemetricFrom(
+		metric,
+		r.Config.NameOverride,
+		r.Config.MeasurementPrefix,
+		r.Config.MeasurementSuffix,
+		r.Config.Tags,
+		nil,
+		r.Config.Filter,
+		false,
+	)
+
+	if m != nil {
+		m.SetAggregate(true)
+	}
+
+	return m
+}
+
 // Add applies the given metric to the aggregator.
 // Before applying to the plugin, it will run any defined filters on the metric.
 // Apply returns true if the original metric should be dropped.
@@ -75,6 +75,30 @@ func (r *RunningInput) MakeMetric(
 		t,
 	)
 
+	r.gathered(m)
+	return m
+}
+
+// MakeMetricFrom is MakeMetric for a metric made by the input, which is
+// modified in place.
+func (r *RunningInput) MakeMetricFrom(metric telegraf.Metric) telegraf.Metric {
+	m := makemetricFrom(
+		metric,
+		r.Config.NameOverride,
+		r.Config.MeasurementPrefix,
+		r.Config.MeasurementSuffix,
+		r.Config.Tags,
+		r.defaultTags,
+		r.Config.Filter,
+		true,
+	)
+
+	r.gathered(m)
+	return m
+}
+
+// gathered traces and counts a metric made by the input.
+func (r *RunningInput) gathered(m telegraf.Metric) {
 	if r.trace && m != nil {
 		s := influx.NewSerializer()
 		s.SetFieldSortOrder(influx.SortFields)
@@ -86,7 +110,6 @@ func (r *RunningInput) MakeMetric(
 
 	r.MetricsGathered.Incr(1)
 	GlobalMetricsGathered.Incr(1)
-	return m
 }
 
 func (r *RunningInput) Trace() bool {
@@ -57,6 +57,53 @@ func TestMakeMetricNilFields(t *testing.T) {
 	require.Equal(t, expected, m)
 }
 
+func TestMakeMetricFrom(t *testing.T) {
+	now := time.Now()
+	ri := NewRunningInput(&testInput{}, &InputConfig{
+		Name:              "TestRunningInput",
+		MeasurementPrefix: "foo_",
+		Tags: map[string]string{
+			"foo": "bar",
+		},
+		Filter: Filter{
+			FieldDrop: []string{"nope"},
+		},
+	})
+	require.NoError(t, ri.Config.Filter.Compile())
+	ri.SetDefaultTags(map[string]string{"host": "localhost", "foo": "baz"})
+
+	b := metric.NewBuilder()
+	b.SetName("RITest")
+	b.AddTag("host", "myhost")
+	b.AddField("value", int64(101))
+	b.AddField("nope", int64(1))
+	b.SetTime(now)
+	m, err := b.Metric()
+	require.NoError(t, err)
+
+	m = ri.MakeMetricFrom(m)
+	expected, err := metric.New("foo_RITest",
+		map[string]string{
+			"host": "myhost",
+			"foo":  "bar",
+		},
+		map[string]interface{}{
+			"value": int64(101),
+		},
+		now,
+	)
+	require.NoError(t, err)
+	require.Equal(t, expected, m)
+
+	// The metrics with all the fields filtered out are dropped
+	b.Reset()
+	b.SetName("RITest")
+	b.AddField("nope", int64(1))
+	m, err = b.Metric()
+	require.NoError(t, err)
+	assert.Nil(t, ri.MakeMetricFrom(m))
+}
+
 func TestMakeMetricWithPluginTags(t *testing.T) {
 	now := time.Now()
 	ri := NewRunningInput(&testInput{}, &InputConfig{
@@ -0,0 +1,68 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// OAuth2Config represents the standard client OAuth2 config, using the client
+// credentials grant.
+type OAuth2Config struct {
+	ClientID     string   `toml:"client_id"`
+	ClientSecret string   `toml:"client_secret"`
+	TokenURL     string   `toml:"token_url"`
+	Audience     string   `toml:"audience"`
+	Scopes       []string `toml:"scopes"`
+}
+
+// Configured returns true if OAuth2 is configured.
+func (c *OAuth2Config) Configured() bool {
+	return c.ClientID != "" || c.ClientSecret != "" || c.TokenURL != ""
+}
+
+// TokenSource returns a source of access tokens requested from the token URL
+// with client, the token being cached until it expires.
+func (c *OAuth2Config) TokenSource(client *http.Client) (oauth2.TokenSource, error) {
+	if c.ClientID == "" || c.TokenURL == "" {
+		return nil, errors.New("oauth2: client_id and token_url are required")
+	}
+
+	config := clientcredentials.Config{
+		ClientID:     c.ClientID,
+		ClientSecret: c.ClientSecret,
+		TokenURL:     c.TokenURL,
+		Scopes:       c.Scopes,
+	}
+	if c.Audience != "" {
+		config.EndpointParams = url.Values{"audience": {c.Audience}}
+	}
+
+	ctx := context.WithValue(context.Background(), oauth2.HTTPClient, client)
+	return config.TokenSource(ctx), nil
+}
+
+// Client returns a http.Client adding an access token to the requests made
+// with client, or client itself if OAuth2 is not configured.  The token is
+// requested from the token URL with client, and is cached until it expires.
+func (c *OAuth2Config) Client(client *http.Client) (*http.Client, error) {
+	if !c.Configured() {
+		return client, nil
+	}
+
+	source, err := c.TokenSource(client)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{
+		Transport: &oauth2.Transport{
+			Source: source,
+			Base:   client.Transport,
+		},
+		Timeout: client.Timeout,
+	}, nil
+}
@@ -0,0 +1,72 @@
+package oauth
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestClient_notConfigured(t *testing.T) {
+	client := &http.Client{}
+	config := OAuth2Config{}
+
+	c, err := config.Client(client)
+	require.NoError(t, err)
+	require.True(t, c == client)
+}
+
+func TestClient_invalid(t *testing.T) {
+	config := OAuth2Config{ClientSecret: "secret"}
+
+	_, err := config.Client(&http.Client{})
+	require.Error(t, err)
+}
+
+func TestClient(t *testing.T) {
+	var tokenRequests int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			tokenRequests++
+			require.NoError(t, r.ParseForm())
+			require.Equal(t, "client_credentials", r.Form.Get("grant_type"))
+			require.Equal(t, "metrics", r.Form.Get("audience"))
+			require.Equal(t, "read write", r.Form.Get("scope"))
+			user, password, _ := r.BasicAuth()
+			require.Equal(t, "telegraf", user)
+			require.Equal(t, "secret", password)
+
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"access_token":"token%d","token_type":"bearer","expires_in":3600}`, tokenRequests)
+		case "/metrics":
+			require.Equal(t, "Bearer token1", r.Header.Get("Authorization"))
+			w.WriteHeader(http.StatusOK)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	config := OAuth2Config{
+		ClientID:     "telegraf",
+		ClientSecret: "secret",
+		TokenURL:     ts.URL + "/token",
+		Audience:     "metrics",
+		Scopes:       []string{"read", "write"},
+	}
+	client, err := config.Client(&http.Client{Timeout: 5 * time.Second})
+	require.NoError(t, err)
+	require.Equal(t, 5*time.Second, client.Timeout)
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(ts.URL + "/metrics")
+		require.NoError(t, err)
+		resp.Body.Close()
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+	require.Equal(t, 1, tokenRequests)
+}
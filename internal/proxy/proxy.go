@@ -0,0 +1,108 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	netproxy "golang.org/x/net/proxy"
+)
+
+// HTTPProxy represents the standard proxy config of the HTTP clients.
+type HTTPProxy struct {
+	HTTPProxyURL string `toml:"http_proxy_url"`
+}
+
+// Proxy returns the proxy function of the http.Transport of the clients.  The
+// credentials of the proxy are the user info of its URL, and its scheme is
+// either http, https or socks5.  When unset, the proxy is read from the
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+func (p *HTTPProxy) Proxy() (func(*http.Request) (*url.URL, error), error) {
+	if p.HTTPProxyURL == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+
+	u, err := url.Parse(p.HTTPProxyURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid http_proxy_url %q: %v", p.HTTPProxyURL, err)
+	}
+	switch u.Scheme {
+	case "http", "https", "socks5":
+	default:
+		return nil, fmt.Errorf("invalid http_proxy_url %q, expected an http, https or socks5 URL", p.HTTPProxyURL)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid http_proxy_url %q without host", p.HTTPProxyURL)
+	}
+	return http.ProxyURL(u), nil
+}
+
+// Socks5ProxyConfig represents the standard SOCKS5 proxy config of the TCP
+// clients.
+type Socks5ProxyConfig struct {
+	Socks5ProxyAddress  string `toml:"socks5_proxy_address"`
+	Socks5ProxyUsername string `toml:"socks5_proxy_username"`
+	Socks5ProxyPassword string `toml:"socks5_proxy_password"`
+}
+
+// Configured returns true if a SOCKS5 proxy is configured.
+func (c *Socks5ProxyConfig) Configured() bool {
+	return c.Socks5ProxyAddress != ""
+}
+
+// Dialer returns the dialer of the TCP connections, connecting through the
+// SOCKS5 proxy if configured, directly otherwise.  The timeout is the one of
+// the connections to the proxy or to the servers.
+func (c *Socks5ProxyConfig) Dialer(timeout time.Duration) (netproxy.Dialer, error) {
+	direct := &net.Dialer{Timeout: timeout}
+	if !c.Configured() {
+		return direct, nil
+	}
+
+	var auth *netproxy.Auth
+	if c.Socks5ProxyUsername != "" || c.Socks5ProxyPassword != "" {
+		auth = &netproxy.Auth{
+			User:     c.Socks5ProxyUsername,
+			Password: c.Socks5ProxyPassword,
+		}
+	}
+	dialer, err := netproxy.SOCKS5("tcp", c.Socks5ProxyAddress, auth, direct)
+	if err != nil {
+		return nil, fmt.Errorf("invalid socks5_proxy_address %q: %v", c.Socks5ProxyAddress, err)
+	}
+	return dialer, nil
+}
+
+// DialTLS connects to the address with the dialer, starting TLS on the
+// connection if the TLS config is set.  The server name of the TLS config
+// defaults to the host of the address, and the handshake must complete within
+// the timeout unless zero.
+func DialTLS(dialer netproxy.Dialer, network, address string, tlsConfig *tls.Config, timeout time.Duration) (net.Conn, error) {
+	conn, err := dialer.Dial(network, address)
+	if err != nil || tlsConfig == nil {
+		return conn, err
+	}
+
+	if tlsConfig.ServerName == "" {
+		host, _, err := net.SplitHostPort(address)
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+		tlsConfig = tlsConfig.Clone()
+		tlsConfig.ServerName = host
+	}
+	tlsConn := tls.Client(conn, tlsConfig)
+	if timeout > 0 {
+		tlsConn.SetDeadline(time.Now().Add(timeout))
+	}
+	if err := tlsConn.Handshake(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	tlsConn.SetDeadline(time.Time{})
+	return tlsConn, nil
+}
@@ -0,0 +1,162 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHTTPProxy(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests <- r
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	p := &HTTPProxy{HTTPProxyURL: "http://user:secret@" + proxy.Listener.Addr().String()}
+	proxyFunc, err := p.Proxy()
+	require.NoError(t, err)
+	client := &http.Client{Transport: &http.Transport{Proxy: proxyFunc}}
+
+	resp, err := client.Get("http://example.org/metrics")
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	r := <-requests
+	require.Equal(t, "http://example.org/metrics", r.RequestURI)
+	require.Equal(t, "Basic "+base64.StdEncoding.EncodeToString([]byte("user:secret")),
+		r.Header.Get("Proxy-Authorization"))
+}
+
+func TestHTTPProxyInvalid(t *testing.T) {
+	for _, u := range []string{"ftp://proxy:21", "http://", "://proxy"} {
+		p := &HTTPProxy{HTTPProxyURL: u}
+		_, err := p.Proxy()
+		require.Error(t, err, u)
+	}
+
+	p := &HTTPProxy{}
+	proxyFunc, err := p.Proxy()
+	require.NoError(t, err)
+	require.NotNil(t, proxyFunc)
+}
+
+func TestSocks5Dialer(t *testing.T) {
+	target, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer target.Close()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go testutil.ServeSocks5(t, l, "user", "secret")
+
+	c := &Socks5ProxyConfig{
+		Socks5ProxyAddress:  l.Addr().String(),
+		Socks5ProxyUsername: "user",
+		Socks5ProxyPassword: "secret",
+	}
+	require.True(t, c.Configured())
+	dialer, err := c.Dialer(5 * time.Second)
+	require.NoError(t, err)
+
+	// The host names are resolved by the proxy
+	_, port, err := net.SplitHostPort(target.Addr().String())
+	require.NoError(t, err)
+	conn, err := dialer.Dial("tcp", "localhost:"+port)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("cpu usage=42.5"))
+	require.NoError(t, err)
+
+	tc, err := target.Accept()
+	require.NoError(t, err)
+	defer tc.Close()
+	b := make([]byte, 14)
+	_, err = io.ReadFull(tc, b)
+	require.NoError(t, err)
+	require.Equal(t, "cpu usage=42.5", string(b))
+}
+
+func TestSocks5DialerInvalidCredentials(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	go testutil.ServeSocks5(t, l, "user", "secret")
+
+	c := &Socks5ProxyConfig{
+		Socks5ProxyAddress:  l.Addr().String(),
+		Socks5ProxyUsername: "user",
+		Socks5ProxyPassword: "invalid",
+	}
+	dialer, err := c.Dialer(5 * time.Second)
+	require.NoError(t, err)
+	_, err = dialer.Dial("tcp", "metrics.example.org:2003")
+	require.Error(t, err)
+}
+
+func TestDirectDialer(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	c := &Socks5ProxyConfig{}
+	require.False(t, c.Configured())
+	dialer, err := c.Dialer(5 * time.Second)
+	require.NoError(t, err)
+	conn, err := dialer.Dial("tcp", l.Addr().String())
+	require.NoError(t, err)
+	conn.Close()
+}
+
+func TestDialTLS(t *testing.T) {
+	pki := testutil.NewPKI("../../testutil/pki")
+	serverConfig, err := pki.TLSServerConfig().TLSConfig()
+	require.NoError(t, err)
+	clientConfig, err := pki.TLSClientConfig().TLSConfig()
+	require.NoError(t, err)
+
+	l, err := tls.Listen("tcp", "127.0.0.1:0", serverConfig)
+	require.NoError(t, err)
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		conn.Write([]byte("ok"))
+	}()
+
+	// The server name is the host of the address
+	_, port, err := net.SplitHostPort(l.Addr().String())
+	require.NoError(t, err)
+	conn, err := DialTLS(&net.Dialer{}, "tcp", "localhost:"+port, clientConfig, 5*time.Second)
+	require.NoError(t, err)
+	defer conn.Close()
+	require.Equal(t, "localhost", conn.(*tls.Conn).ConnectionState().ServerName)
+	b := make([]byte, 2)
+	_, err = io.ReadFull(conn, b)
+	require.NoError(t, err)
+	require.Equal(t, "ok", string(b))
+}
+
+func TestDialTLSWithoutConfig(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	conn, err := DialTLS(&net.Dialer{}, "tcp", l.Addr().String(), nil, 0)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, ok := conn.(*tls.Conn)
+	require.False(t, ok)
+}
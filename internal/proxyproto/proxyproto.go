@@ -0,0 +1,224 @@
+// Package proxyproto reads the PROXY protocol header sent by load balancers
+// and proxies at the start of the connections, to get the address of the
+// original client, see
+// https://www.haproxy.org/download/1.8/doc/proxy-protocol.txt
+package proxyproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultTimeout is the time to wait for the header of a connection.
+const DefaultTimeout = 5 * time.Second
+
+const (
+	// v1MaxLength is the maximum length of a version 1 header, including the
+	// CRLF
+	v1MaxLength = 107
+	v2HeaderLen = 16
+)
+
+var (
+	v1Prefix    = []byte("PROXY ")
+	v2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+	ErrNoHeader = errors.New("missing PROXY protocol header")
+)
+
+// Conn is a connection starting with a PROXY protocol header, of which the
+// addresses are the ones of the original client and server.
+type Conn struct {
+	net.Conn
+
+	reader  *bufio.Reader
+	timeout time.Duration
+	once    sync.Once
+	err     error
+	source  net.Addr
+	dest    net.Addr
+}
+
+// NewConn returns a connection reading the header within the timeout, or the
+// default timeout when 0.
+func NewConn(c net.Conn, timeout time.Duration) *Conn {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Conn{
+		Conn:    c,
+		reader:  bufio.NewReader(c),
+		timeout: timeout,
+	}
+}
+
+// ReadHeader reads the header, if it was not read yet, and returns the error
+// reading it.
+func (c *Conn) ReadHeader() error {
+	c.once.Do(func() {
+		c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+		c.source, c.dest, c.err = readHeader(c.reader)
+		c.Conn.SetReadDeadline(time.Time{})
+	})
+	return c.err
+}
+
+func (c *Conn) Read(b []byte) (int, error) {
+	if err := c.ReadHeader(); err != nil {
+		return 0, err
+	}
+	return c.reader.Read(b)
+}
+
+// RemoteAddr returns the address of the original client, or the address of
+// the peer when the header does not hold the addresses.
+func (c *Conn) RemoteAddr() net.Addr {
+	if c.ReadHeader() == nil && c.source != nil {
+		return c.source
+	}
+	return c.Conn.RemoteAddr()
+}
+
+// LocalAddr returns the address the original client connected to, or the
+// local address when the header does not hold the addresses.
+func (c *Conn) LocalAddr() net.Addr {
+	if c.ReadHeader() == nil && c.dest != nil {
+		return c.dest
+	}
+	return c.Conn.LocalAddr()
+}
+
+// readHeader reads a version 1 or 2 header.  The addresses are nil when the
+// header does not hold them, e.g. for health checks of the proxy.
+func readHeader(r *bufio.Reader) (net.Addr, net.Addr, error) {
+	b, err := r.Peek(1)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch b[0] {
+	case v1Prefix[0]:
+		return readV1(r)
+	case v2Signature[0]:
+		return readV2(r)
+	}
+	return nil, nil, ErrNoHeader
+}
+
+// readV1 reads a header like "PROXY TCP4 192.0.2.1 192.0.2.2 56324 443\r\n".
+func readV1(r *bufio.Reader) (net.Addr, net.Addr, error) {
+	var line []byte
+	for len(line) < v1MaxLength {
+		b, err := r.ReadByte()
+		if err != nil {
+			return nil, nil, err
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+	if !bytes.HasPrefix(line, v1Prefix) {
+		return nil, nil, ErrNoHeader
+	}
+	if !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, nil, errors.New("invalid PROXY protocol header: missing CRLF")
+	}
+
+	parts := strings.Split(string(line[len(v1Prefix):len(line)-2]), " ")
+	switch parts[0] {
+	case "UNKNOWN":
+		return nil, nil, nil
+	case "TCP4", "TCP6":
+	default:
+		return nil, nil, fmt.Errorf("invalid PROXY protocol header: unknown protocol %q", parts[0])
+	}
+	if len(parts) != 5 {
+		return nil, nil, errors.New("invalid PROXY protocol header: wrong number of fields")
+	}
+
+	source, err := v1Addr(parts[1], parts[3])
+	if err != nil {
+		return nil, nil, err
+	}
+	dest, err := v1Addr(parts[2], parts[4])
+	if err != nil {
+		return nil, nil, err
+	}
+	return source, dest, nil
+}
+
+func v1Addr(ip, port string) (net.Addr, error) {
+	addr := &net.TCPAddr{IP: net.ParseIP(ip)}
+	if addr.IP == nil {
+		return nil, fmt.Errorf("invalid PROXY protocol header: invalid address %q", ip)
+	}
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PROXY protocol header: invalid port %q", port)
+	}
+	addr.Port = int(p)
+	return addr, nil
+}
+
+// readV2 reads a binary header, the TLVs following the addresses are
+// ignored.
+func readV2(r *bufio.Reader) (net.Addr, net.Addr, error) {
+	header := make([]byte, v2HeaderLen)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, nil, err
+	}
+	if !bytes.Equal(header[:len(v2Signature)], v2Signature) {
+		return nil, nil, ErrNoHeader
+	}
+	if header[12]>>4 != 2 {
+		return nil, nil, fmt.Errorf("invalid PROXY protocol header: unknown version %d", header[12]>>4)
+	}
+
+	payload := make([]byte, binary.BigEndian.Uint16(header[14:16]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, nil, err
+	}
+
+	switch header[12] & 0xf {
+	case 0x0:
+		// LOCAL command, sent by the proxy itself
+		return nil, nil, nil
+	case 0x1:
+		// PROXY command
+	default:
+		return nil, nil, fmt.Errorf("invalid PROXY protocol header: unknown command %d", header[12]&0xf)
+	}
+
+	var ipLen int
+	switch header[13] >> 4 {
+	case 0x1:
+		ipLen = net.IPv4len
+	case 0x2:
+		ipLen = net.IPv6len
+	default:
+		// Unspecified or unix addresses
+		return nil, nil, nil
+	}
+	if len(payload) < 2*ipLen+4 {
+		return nil, nil, errors.New("invalid PROXY protocol header: addresses too short")
+	}
+
+	sourceIP := net.IP(payload[:ipLen])
+	destIP := net.IP(payload[ipLen : 2*ipLen])
+	sourcePort := int(binary.BigEndian.Uint16(payload[2*ipLen:]))
+	destPort := int(binary.BigEndian.Uint16(payload[2*ipLen+2:]))
+
+	if header[13]&0xf == 0x2 {
+		return &net.UDPAddr{IP: sourceIP, Port: sourcePort}, &net.UDPAddr{IP: destIP, Port: destPort}, nil
+	}
+	return &net.TCPAddr{IP: sourceIP, Port: sourcePort}, &net.TCPAddr{IP: destIP, Port: destPort}, nil
+}
@@ -0,0 +1,114 @@
+package proxyproto
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// dial returns a proxied connection of which the peer wrote data.
+func dial(t *testing.T, data []byte) *Conn {
+	server, client := net.Pipe()
+	go func() {
+		client.Write(data)
+		client.Close()
+	}()
+	return NewConn(server, time.Second)
+}
+
+func v2Header(command byte, family byte, payload []byte) []byte {
+	header := append([]byte{}, v2Signature...)
+	header = append(header, 0x20|command, family, 0, 0)
+	binary.BigEndian.PutUint16(header[14:], uint16(len(payload)))
+	return append(header, payload...)
+}
+
+func TestV1(t *testing.T) {
+	c := dial(t, []byte("PROXY TCP4 192.0.2.1 192.0.2.2 56324 443\r\ncpu value=1\n"))
+	defer c.Close()
+
+	assert.Equal(t, "192.0.2.1:56324", c.RemoteAddr().String())
+	assert.Equal(t, "192.0.2.2:443", c.LocalAddr().String())
+	data, err := ioutil.ReadAll(c)
+	require.NoError(t, err)
+	assert.Equal(t, "cpu value=1\n", string(data))
+
+	c = dial(t, []byte("PROXY TCP6 2001:db8::1 2001:db8::2 56324 443\r\n"))
+	assert.Equal(t, "[2001:db8::1]:56324", c.RemoteAddr().String())
+}
+
+func TestV1Unknown(t *testing.T) {
+	c := dial(t, []byte("PROXY UNKNOWN\r\ncpu value=1\n"))
+	defer c.Close()
+
+	require.NoError(t, c.ReadHeader())
+	assert.Equal(t, c.Conn.RemoteAddr(), c.RemoteAddr())
+	data, err := ioutil.ReadAll(c)
+	require.NoError(t, err)
+	assert.Equal(t, "cpu value=1\n", string(data))
+}
+
+func TestV2(t *testing.T) {
+	payload := []byte{192, 0, 2, 1, 192, 0, 2, 2, 0xdc, 0x04, 0x01, 0xbb}
+	// A TLV, which is ignored
+	payload = append(payload, 0x01, 0x00, 0x02, 'h', '2')
+	c := dial(t, append(v2Header(0x1, 0x11, payload), []byte("cpu value=1\n")...))
+	defer c.Close()
+
+	assert.Equal(t, "192.0.2.1:56324", c.RemoteAddr().String())
+	assert.Equal(t, "192.0.2.2:443", c.LocalAddr().String())
+	data, err := ioutil.ReadAll(c)
+	require.NoError(t, err)
+	assert.Equal(t, "cpu value=1\n", string(data))
+
+	payload = make([]byte, 36)
+	copy(payload, net.ParseIP("2001:db8::1"))
+	copy(payload[16:], net.ParseIP("2001:db8::2"))
+	binary.BigEndian.PutUint16(payload[32:], 56324)
+	binary.BigEndian.PutUint16(payload[34:], 443)
+	c = dial(t, v2Header(0x1, 0x21, payload))
+	assert.Equal(t, "[2001:db8::1]:56324", c.RemoteAddr().String())
+
+	// The LOCAL command keeps the addresses of the connection
+	c = dial(t, v2Header(0x0, 0x00, nil))
+	require.NoError(t, c.ReadHeader())
+	assert.Equal(t, c.Conn.RemoteAddr(), c.RemoteAddr())
+}
+
+func TestInvalidHeader(t *testing.T) {
+	tests := []string{
+		"cpu value=1\n",
+		"PROXY TCP4 192.0.2.1 192.0.2.2 56324\r\n",
+		"PROXY TCP4 192.0.2.1 192.0.2.2 56324 443\n",
+		"PROXY TCP4 192.0.2.1 invalid 56324 443\r\n",
+		"PROXY TCP4 192.0.2.1 192.0.2.2 56324 99999\r\n",
+		"PROXY UDP4 192.0.2.1 192.0.2.2 56324 443\r\n",
+		string(v2Header(0x1, 0x11, []byte{192, 0, 2, 1})),
+		string(v2Header(0x2, 0x11, nil)),
+	}
+	for _, header := range tests {
+		c := dial(t, []byte(header))
+		assert.Error(t, c.ReadHeader(), header)
+		_, err := c.Read(make([]byte, 1))
+		assert.Error(t, err, header)
+		c.Close()
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	server, client := net.Pipe()
+	defer client.Close()
+	c := NewConn(server, 10*time.Millisecond)
+	defer c.Close()
+
+	err := c.ReadHeader()
+	require.Error(t, err)
+	netErr, ok := err.(net.Error)
+	require.True(t, ok)
+	assert.True(t, netErr.Timeout())
+}
@@ -0,0 +1,57 @@
+package proxyproto
+
+import (
+	"net"
+	"sync"
+)
+
+// SourceAddress returns the IP of the address, or the path of a unix socket.
+func SourceAddress(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	if host, _, err := net.SplitHostPort(addr.String()); err == nil {
+		return host
+	}
+	return addr.String()
+}
+
+// SourceCounter counts the connections of each source address, so that the
+// connections of a source can be limited.
+type SourceCounter struct {
+	max int
+
+	mu      sync.Mutex
+	sources map[string]int
+}
+
+// NewSourceCounter returns a counter allowing max connections per source, or
+// any number when 0.
+func NewSourceCounter(max int) *SourceCounter {
+	return &SourceCounter{
+		max:     max,
+		sources: make(map[string]int),
+	}
+}
+
+// Add counts a connection of the source, unless the source has reached the
+// maximum number of connections.
+func (c *SourceCounter) Add(source string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.max > 0 && c.sources[source] >= c.max {
+		return false
+	}
+	c.sources[source]++
+	return true
+}
+
+// Remove forgets a connection of the source.
+func (c *SourceCounter) Remove(source string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sources[source]--
+	if c.sources[source] <= 0 {
+		delete(c.sources, source)
+	}
+}
@@ -0,0 +1,35 @@
+package proxyproto
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSourceAddress(t *testing.T) {
+	assert.Equal(t, "", SourceAddress(nil))
+	assert.Equal(t, "10.0.0.1", SourceAddress(&net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 5000}))
+	assert.Equal(t, "::1", SourceAddress(&net.TCPAddr{IP: net.IPv6loopback, Port: 5000}))
+	assert.Equal(t, "/tmp/sock", SourceAddress(&net.UnixAddr{Name: "/tmp/sock", Net: "unix"}))
+}
+
+func TestSourceCounter(t *testing.T) {
+	c := NewSourceCounter(2)
+	assert.True(t, c.Add("10.0.0.1"))
+	assert.True(t, c.Add("10.0.0.1"))
+	assert.False(t, c.Add("10.0.0.1"))
+	assert.True(t, c.Add("10.0.0.2"))
+
+	c.Remove("10.0.0.1")
+	assert.True(t, c.Add("10.0.0.1"))
+
+	c.Remove("10.0.0.2")
+	assert.Empty(t, c.sources["10.0.0.2"])
+
+	// Unlimited
+	c = NewSourceCounter(0)
+	for i := 0; i < 10; i++ {
+		assert.True(t, c.Add("10.0.0.1"))
+	}
+}
@@ -0,0 +1,281 @@
+package rotate
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// archiveTimeFormat is the format of the time of rotation in the names of
+// the archives, sorting in the order of the rotations.
+const archiveTimeFormat = "20060102T150405"
+
+// FileWriter writes to a file rotated once it is older than the interval or
+// larger than the maximum size, the rotated files being kept as archives
+// next to it.
+//
+// The name of the file is a template in which the date specifiers such as %Y
+// are replaced by the current time, a new file being written to whenever the
+// name changes.
+type FileWriter struct {
+	template    string
+	interval    time.Duration
+	maxSize     int64
+	maxArchives int
+	compress    bool
+
+	current  *os.File
+	filename string
+	size     int64
+	expire   time.Time
+
+	now func() time.Time
+}
+
+// NewFileWriter opens the file of the template for writing, appending to it
+// when it exists.  The file is rotated after the interval and when exceeding
+// the maximum size when they are not zero, the oldest archives being removed
+// beyond maxArchives unless it is negative.  The archives are compressed with
+// gzip when compress is set.
+func NewFileWriter(template string, interval time.Duration, maxSize int64, maxArchives int, compress bool) (*FileWriter, error) {
+	return newFileWriter(template, interval, maxSize, maxArchives, compress, time.Now)
+}
+
+func newFileWriter(template string, interval time.Duration, maxSize int64, maxArchives int, compress bool, now func() time.Time) (*FileWriter, error) {
+	w := &FileWriter{
+		template:    template,
+		interval:    interval,
+		maxSize:     maxSize,
+		maxArchives: maxArchives,
+		compress:    compress,
+		now:         now,
+	}
+	if err := w.open(w.expand()); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// expand returns the name of the file at the current time.
+func (w *FileWriter) expand() string {
+	t := w.now()
+	return strings.NewReplacer(
+		"%Y", t.Format("2006"),
+		"%y", t.Format("06"),
+		"%m", t.Format("01"),
+		"%d", t.Format("02"),
+		"%H", t.Format("15"),
+		"%M", t.Format("04"),
+		"%S", t.Format("05"),
+	).Replace(w.template)
+}
+
+func (w *FileWriter) open(filename string) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	w.current = f
+	w.filename = filename
+	w.size = info.Size()
+	w.expire = w.now().Add(w.interval)
+	return nil
+}
+
+// Write writes to the file, switching to the file of the current time and
+// rotating it first if needed.
+func (w *FileWriter) Write(p []byte) (int, error) {
+	if w.current == nil {
+		if err := w.open(w.expand()); err != nil {
+			return 0, err
+		}
+	}
+
+	if filename := w.expand(); filename != w.filename {
+		if err := w.current.Close(); err != nil {
+			return 0, err
+		}
+		w.current = nil
+		if err := w.open(filename); err != nil {
+			return 0, err
+		}
+	} else if w.expired(len(p)) {
+		if err := w.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := w.current.Write(p)
+	w.size += int64(n)
+	return n, err
+}
+
+// expired returns whether the file has to be rotated before writing n bytes
+// to it.  A file is never rotated while empty.
+func (w *FileWriter) expired(n int) bool {
+	if w.size == 0 {
+		return false
+	}
+	if w.interval > 0 && !w.now().Before(w.expire) {
+		return true
+	}
+	return w.maxSize > 0 && w.size+int64(n) > w.maxSize
+}
+
+// rotate moves the file to an archive and opens it again, empty.
+func (w *FileWriter) rotate() error {
+	if err := w.current.Close(); err != nil {
+		return err
+	}
+	w.current = nil
+
+	ext := filepath.Ext(w.filename)
+	base := strings.TrimSuffix(w.filename, ext)
+	rotated := w.now().Format(archiveTimeFormat)
+	archive := base + "." + rotated + ext
+	for i := 1; exists(archive) || exists(archive+".gz"); i++ {
+		archive = fmt.Sprintf("%s.%s-%d%s", base, rotated, i, ext)
+	}
+	if err := os.Rename(w.filename, archive); err != nil {
+		return err
+	}
+	if w.compress {
+		if err := compressFile(archive); err != nil {
+			return err
+		}
+	}
+	if err := w.removeArchives(base, ext); err != nil {
+		return err
+	}
+
+	return w.open(w.filename)
+}
+
+// removeArchives removes the oldest archives of the file beyond the maximum
+// number of archives.
+func (w *FileWriter) removeArchives(base, ext string) error {
+	if w.maxArchives < 0 {
+		return nil
+	}
+
+	matches, err := filepath.Glob(globEscape(base) + ".*")
+	if err != nil {
+		return err
+	}
+	type archive struct {
+		filename string
+		rotated  time.Time
+		seq      int
+	}
+	var archives []archive
+	for _, match := range matches {
+		rotated, seq, ok := parseArchive(strings.TrimPrefix(match, base+"."), ext)
+		if ok {
+			archives = append(archives, archive{match, rotated, seq})
+		}
+	}
+	if len(archives) <= w.maxArchives {
+		return nil
+	}
+
+	sort.Slice(archives, func(i, j int) bool {
+		if !archives[i].rotated.Equal(archives[j].rotated) {
+			return archives[i].rotated.Before(archives[j].rotated)
+		}
+		return archives[i].seq < archives[j].seq
+	})
+	for _, archive := range archives[:len(archives)-w.maxArchives] {
+		if err := os.Remove(archive.filename); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseArchive returns the time of rotation and the sequence number of an
+// archive with the extension from the suffix of its name, after the base name
+// and the dot.
+func parseArchive(suffix, ext string) (time.Time, int, bool) {
+	suffix = strings.TrimSuffix(suffix, ".gz")
+	if !strings.HasSuffix(suffix, ext) {
+		return time.Time{}, 0, false
+	}
+	suffix = strings.TrimSuffix(suffix, ext)
+
+	seq := 0
+	if i := strings.IndexByte(suffix, '-'); i >= 0 {
+		var err error
+		if seq, err = strconv.Atoi(suffix[i+1:]); err != nil || seq < 1 {
+			return time.Time{}, 0, false
+		}
+		suffix = suffix[:i]
+	}
+	rotated, err := time.Parse(archiveTimeFormat, suffix)
+	if err != nil {
+		return time.Time{}, 0, false
+	}
+	return rotated, seq, true
+}
+
+func globEscape(pattern string) string {
+	return strings.NewReplacer(`*`, `\*`, `?`, `\?`, `[`, `\[`).Replace(pattern)
+}
+
+func exists(filename string) bool {
+	_, err := os.Stat(filename)
+	return err == nil
+}
+
+// compressFile replaces a file by its gzip compressed version.
+func compressFile(filename string) error {
+	in, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(filename+".gz", os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		out.Close()
+		os.Remove(filename + ".gz")
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		os.Remove(filename + ".gz")
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Remove(filename)
+}
+
+// Close closes the file.
+func (w *FileWriter) Close() error {
+	if w.current == nil {
+		return nil
+	}
+	err := w.current.Close()
+	w.current = nil
+	return err
+}
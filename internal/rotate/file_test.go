@@ -0,0 +1,168 @@
+package rotate
+
+import (
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "rotate")
+	require.NoError(t, err)
+	return dir
+}
+
+func listFiles(t *testing.T, dir string) []string {
+	infos, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func readFile(t *testing.T, filename string) string {
+	b, err := ioutil.ReadFile(filename)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestFileWriterAppend(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, "metrics.out")
+	require.NoError(t, ioutil.WriteFile(filename, []byte("a\n"), 0644))
+
+	w, err := NewFileWriter(filename, 0, 0, -1, false)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("b\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	require.Equal(t, "a\nb\n", readFile(t, filename))
+}
+
+func TestFileWriterRotateSize(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	filename := filepath.Join(dir, "metrics.out")
+
+	w, err := newFileWriter(filename, 0, 4, -1, false, func() time.Time { return now })
+	require.NoError(t, err)
+	defer w.Close()
+
+	// The writes larger than the maximum size are not split, the file being
+	// rotated before the write exceeding the size
+	for _, line := range []string{"a\n", "b\n", "c\n", "ddddd\n"} {
+		_, err = w.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	require.Equal(t, []string{
+		"metrics.20200102T030405-1.out",
+		"metrics.20200102T030405.out",
+		"metrics.out",
+	}, listFiles(t, dir))
+	require.Equal(t, "a\nb\n", readFile(t, filepath.Join(dir, "metrics.20200102T030405.out")))
+	require.Equal(t, "c\n", readFile(t, filepath.Join(dir, "metrics.20200102T030405-1.out")))
+	require.Equal(t, "ddddd\n", readFile(t, filename))
+}
+
+func TestFileWriterRotateInterval(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	filename := filepath.Join(dir, "metrics.out")
+
+	w, err := newFileWriter(filename, time.Hour, 0, -1, false, func() time.Time { return now })
+	require.NoError(t, err)
+	defer w.Close()
+
+	_, err = w.Write([]byte("a\n"))
+	require.NoError(t, err)
+	now = now.Add(59 * time.Minute)
+	_, err = w.Write([]byte("b\n"))
+	require.NoError(t, err)
+	now = now.Add(time.Minute)
+	_, err = w.Write([]byte("c\n"))
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"metrics.20200102T040405.out", "metrics.out"}, listFiles(t, dir))
+	require.Equal(t, "a\nb\n", readFile(t, filepath.Join(dir, "metrics.20200102T040405.out")))
+	require.Equal(t, "c\n", readFile(t, filename))
+}
+
+func TestFileWriterMaxArchivesCompress(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	filename := filepath.Join(dir, "metrics.out")
+
+	w, err := newFileWriter(filename, 0, 2, 2, true, func() time.Time { return now })
+	require.NoError(t, err)
+	defer w.Close()
+
+	for _, line := range []string{"a\n", "b\n", "c\n", "d\n"} {
+		_, err = w.Write([]byte(line))
+		require.NoError(t, err)
+		now = now.Add(time.Second)
+	}
+
+	// The oldest archive is removed
+	require.Equal(t, []string{
+		"metrics.20200102T030407.out.gz",
+		"metrics.20200102T030408.out.gz",
+		"metrics.out",
+	}, listFiles(t, dir))
+
+	f, err := os.Open(filepath.Join(dir, "metrics.20200102T030408.out.gz"))
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	b, err := ioutil.ReadAll(gz)
+	require.NoError(t, err)
+	require.Equal(t, "c\n", string(b))
+}
+
+func TestFileWriterTemplate(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	w, err := newFileWriter(filepath.Join(dir, "%Y-%m-%d", "metrics-%H.out"), 0, 0, -1, false,
+		func() time.Time { return now })
+	require.NoError(t, err)
+	defer w.Close()
+
+	_, err = w.Write([]byte("a\n"))
+	require.NoError(t, err)
+	now = now.Add(time.Hour)
+	_, err = w.Write([]byte("b\n"))
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"metrics-03.out", "metrics-04.out"}, listFiles(t, filepath.Join(dir, "2020-01-02")))
+	require.Equal(t, "a\n", readFile(t, filepath.Join(dir, "2020-01-02", "metrics-03.out")))
+	require.Equal(t, "b\n", readFile(t, filepath.Join(dir, "2020-01-02", "metrics-04.out")))
+}
+
+func TestParseArchive(t *testing.T) {
+	rotated, seq, ok := parseArchive("20200102T030405-2.out.gz", ".out")
+	require.True(t, ok)
+	require.Equal(t, time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC), rotated)
+	require.Equal(t, 2, seq)
+
+	_, _, ok = parseArchive("out", ".out")
+	require.False(t, ok)
+	_, _, ok = parseArchive("20200102T030405-x.out", ".out")
+	require.False(t, ok)
+}
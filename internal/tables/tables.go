@@ -0,0 +1,281 @@
+package tables
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/influxdata/telegraf"
+)
+
+// Batch is the metrics written to a table.
+type Batch struct {
+	Table   string
+	Metrics []telegraf.Metric
+}
+
+// Group groups the metrics by the name of their table, keeping the order of
+// the tables.
+func Group(metrics []telegraf.Metric, table func(telegraf.Metric) string) []*Batch {
+	var batches []*Batch
+	byTable := make(map[string]*Batch)
+	for _, metric := range metrics {
+		name := table(metric)
+		b, ok := byTable[name]
+		if !ok {
+			b = &Batch{Table: name}
+			byTable[name] = b
+			batches = append(batches, b)
+		}
+		b.Metrics = append(b.Metrics, metric)
+	}
+	return batches
+}
+
+// Kind is the kind of the values of a column.
+type Kind int
+
+const (
+	Unknown Kind = iota
+	Float
+	Int
+	Uint
+	Bool
+	String
+)
+
+// ValueKind returns the kind of a field value, or Unknown if the value cannot
+// be stored.
+func ValueKind(value interface{}) Kind {
+	switch value.(type) {
+	case float64:
+		return Float
+	case int64:
+		return Int
+	case uint64:
+		return Uint
+	case bool:
+		return Bool
+	case string:
+		return String
+	}
+	return Unknown
+}
+
+// Convert converts the value to a kind, returning nil if it cannot be
+// converted.  The unsigned integers larger than the largest signed integer
+// are converted to the largest signed integer.
+func Convert(value interface{}, kind Kind) interface{} {
+	switch kind {
+	case String:
+		if v, ok := value.(string); ok {
+			return v
+		}
+		return fmt.Sprint(value)
+	case Float:
+		switch v := value.(type) {
+		case float64:
+			return v
+		case int64:
+			return float64(v)
+		case uint64:
+			return float64(v)
+		}
+	case Int:
+		switch v := value.(type) {
+		case int64:
+			return v
+		case uint64:
+			if v > math.MaxInt64 {
+				return int64(math.MaxInt64)
+			}
+			return int64(v)
+		case float64:
+			if v >= math.MinInt64 && v <= math.MaxInt64 {
+				return int64(v)
+			}
+		case bool:
+			if v {
+				return int64(1)
+			}
+			return int64(0)
+		}
+	case Uint:
+		switch v := value.(type) {
+		case uint64:
+			return v
+		case int64:
+			if v >= 0 {
+				return uint64(v)
+			}
+		case float64:
+			if v >= 0 && v <= math.MaxUint64 {
+				return uint64(v)
+			}
+		case bool:
+			if v {
+				return uint64(1)
+			}
+			return uint64(0)
+		}
+	case Bool:
+		if v, ok := value.(bool); ok {
+			return v
+		}
+	}
+	return nil
+}
+
+// Column is a column of a table, with its type.
+type Column struct {
+	Name string
+	Type string
+}
+
+// Schema makes the columns of a table from the tags and fields of its
+// metrics, the types of the columns being the ones of the database.
+type Schema struct {
+	// Types are the types of the columns by kind of field value, the fields
+	// of the kinds without a type are not stored.
+	Types map[Kind]string
+	// Reserved are the columns which are neither tags nor fields, such as
+	// the time.
+	Reserved []string
+	// Name returns the column of a tag or field key, the key if nil.
+	Name func(key string) string
+}
+
+// Type returns the type of the column of a field value, or an empty string if
+// the value cannot be stored.
+func (s *Schema) Type(value interface{}) string {
+	return s.Types[ValueKind(value)]
+}
+
+// Columns returns the names of the tag columns and the field columns of the
+// metrics, both sorted by name.  Tags and fields named as a reserved column,
+// and fields named as a tag, are ignored.  A field column has the type of
+// the first value of the field which can be stored, the NaN and infinite
+// floats not deciding the type as some databases cannot store them.
+func (s *Schema) Columns(metrics []telegraf.Metric) ([]string, []Column) {
+	reserved := make(map[string]bool, len(s.Reserved))
+	for _, name := range s.Reserved {
+		reserved[name] = true
+	}
+
+	tags := make(map[string]bool)
+	for _, metric := range metrics {
+		for _, tag := range metric.TagList() {
+			if name := s.name(tag.Key); !reserved[name] {
+				tags[name] = true
+			}
+		}
+	}
+
+	types := make(map[string]string)
+	for _, metric := range metrics {
+		for _, field := range metric.FieldList() {
+			name := s.name(field.Key)
+			if _, ok := types[name]; ok || reserved[name] || tags[name] {
+				continue
+			}
+			if v, ok := field.Value.(float64); ok && (math.IsNaN(v) || math.IsInf(v, 0)) {
+				continue
+			}
+			if typ := s.Type(field.Value); typ != "" {
+				types[name] = typ
+			}
+		}
+	}
+
+	tagNames := make([]string, 0, len(tags))
+	for name := range tags {
+		tagNames = append(tagNames, name)
+	}
+	sort.Strings(tagNames)
+
+	fields := make([]Column, 0, len(types))
+	for name, typ := range types {
+		fields = append(fields, Column{name, typ})
+	}
+	sort.Slice(fields, func(i, j int) bool { return fields[i].Name < fields[j].Name })
+	return tagNames, fields
+}
+
+func (s *Schema) name(key string) string {
+	if s.Name == nil {
+		return key
+	}
+	return s.Name(key)
+}
+
+// Database creates the tables and adds their columns.
+type Database interface {
+	// CreateTable creates the table with the columns, if it does not exist.
+	CreateTable(ctx context.Context, table string, columns []Column) error
+	// Columns returns the types of the columns of the table by name, or an
+	// empty map if the table does not exist.
+	Columns(ctx context.Context, table string) (map[string]string, error)
+	// AddColumn adds the column to the table, if it does not exist.
+	AddColumn(ctx context.Context, table string, column Column) error
+}
+
+// Cache keeps the types of the columns of the tables, read once from the
+// database unless forgotten.
+type Cache struct {
+	create bool
+	tables map[string]map[string]string
+}
+
+// NewCache returns a cache of the tables, creating the tables and adding
+// their missing columns when create is true.
+func NewCache(create bool) *Cache {
+	return &Cache{
+		create: create,
+		tables: make(map[string]map[string]string),
+	}
+}
+
+// Prepare returns the types of the columns of the table, creating the table
+// and adding the missing columns if needed.
+func (c *Cache) Prepare(ctx context.Context, db Database, table string, columns []Column) (map[string]string, error) {
+	types, ok := c.tables[table]
+	if !ok {
+		if c.create {
+			if err := db.CreateTable(ctx, table, columns); err != nil {
+				return nil, err
+			}
+		}
+
+		var err error
+		types, err = db.Columns(ctx, table)
+		if err != nil {
+			return nil, fmt.Errorf("reading the columns of table %s failed: %v", table, err)
+		}
+		if len(types) == 0 {
+			return nil, fmt.Errorf("table %s does not exist", table)
+		}
+		c.tables[table] = types
+	}
+
+	if !c.create {
+		return types, nil
+	}
+
+	for _, column := range columns {
+		if _, ok := types[column.Name]; ok {
+			continue
+		}
+		if err := db.AddColumn(ctx, table, column); err != nil {
+			return nil, fmt.Errorf("adding column %s to table %s failed: %v", column.Name, table, err)
+		}
+		types[column.Name] = column.Type
+	}
+	return types, nil
+}
+
+// Forget forgets the columns of the table, to read them again when the table
+// could have been changed.
+func (c *Cache) Forget(table string) {
+	delete(c.tables, table)
+}
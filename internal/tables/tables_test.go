@@ -0,0 +1,158 @@
+package tables
+
+import (
+	"context"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGroup(t *testing.T) {
+	metrics := []telegraf.Metric{
+		testutil.MustMetric("mem", nil, map[string]interface{}{"used": 1.0}, time.Unix(0, 0)),
+		testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 1.0}, time.Unix(0, 0)),
+		testutil.MustMetric("mem", nil, map[string]interface{}{"used": 2.0}, time.Unix(0, 0)),
+	}
+
+	batches := Group(metrics, telegraf.Metric.Name)
+	require.Len(t, batches, 2)
+	require.Equal(t, "mem", batches[0].Table)
+	require.Equal(t, []telegraf.Metric{metrics[0], metrics[2]}, batches[0].Metrics)
+	require.Equal(t, "cpu", batches[1].Table)
+	require.Equal(t, []telegraf.Metric{metrics[1]}, batches[1].Metrics)
+}
+
+func TestColumns(t *testing.T) {
+	s := Schema{
+		Types: map[Kind]string{
+			Float:  "REAL",
+			Int:    "INTEGER",
+			Bool:   "BOOLEAN",
+			String: "TEXT",
+		},
+		Reserved: []string{"time"},
+		Name:     strings.ToLower,
+	}
+
+	tags, fields := s.Columns([]telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"Host": "a", "time": "now"},
+			map[string]interface{}{"usage": math.NaN(), "count": uint64(1), "host": "b", "time": 1.0},
+			time.Unix(0, 0)),
+		testutil.MustMetric("cpu",
+			map[string]string{"cpu": "cpu0"},
+			map[string]interface{}{"usage": int64(42), "ok": true},
+			time.Unix(0, 0)),
+		testutil.MustMetric("cpu", nil, map[string]interface{}{"Usage": 1.0}, time.Unix(0, 0)),
+	})
+
+	require.Equal(t, []string{"cpu", "host"}, tags)
+	require.Equal(t, []Column{
+		{Name: "ok", Type: "BOOLEAN"},
+		{Name: "usage", Type: "INTEGER"},
+	}, fields)
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		kind     Kind
+		expected interface{}
+	}{
+		{42.5, Float, 42.5},
+		{int64(42), Float, 42.0},
+		{uint64(42), Float, 42.0},
+		{uint64(42), Int, int64(42)},
+		{uint64(math.MaxUint64), Int, int64(math.MaxInt64)},
+		{42.0, Int, int64(42)},
+		{math.NaN(), Int, nil},
+		{true, Int, int64(1)},
+		{int64(42), Uint, uint64(42)},
+		{int64(-1), Uint, nil},
+		{true, Bool, true},
+		{"true", Bool, nil},
+		{int64(42), String, "42"},
+		{"a", Float, nil},
+		{42.0, Unknown, nil},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, Convert(tt.value, tt.kind), "%v as %v", tt.value, tt.kind)
+	}
+}
+
+// fakeDatabase records the statements, keeping the columns of the tables.
+type fakeDatabase struct {
+	tables     map[string]map[string]string
+	statements []string
+}
+
+func (d *fakeDatabase) CreateTable(ctx context.Context, table string, columns []Column) error {
+	d.statements = append(d.statements, "CREATE "+table)
+	if _, ok := d.tables[table]; !ok {
+		d.tables[table] = make(map[string]string)
+		for _, c := range columns {
+			d.tables[table][c.Name] = c.Type
+		}
+	}
+	return nil
+}
+
+func (d *fakeDatabase) Columns(ctx context.Context, table string) (map[string]string, error) {
+	d.statements = append(d.statements, "COLUMNS "+table)
+	columns := make(map[string]string)
+	for k, v := range d.tables[table] {
+		columns[k] = v
+	}
+	return columns, nil
+}
+
+func (d *fakeDatabase) AddColumn(ctx context.Context, table string, column Column) error {
+	d.statements = append(d.statements, "ADD "+table+" "+column.Name)
+	d.tables[table][column.Name] = column.Type
+	return nil
+}
+
+func TestCache(t *testing.T) {
+	db := &fakeDatabase{tables: map[string]map[string]string{}}
+	c := NewCache(true)
+	ctx := context.Background()
+
+	types, err := c.Prepare(ctx, db, "cpu", []Column{{Name: "time", Type: "TIMESTAMP"}})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"time": "TIMESTAMP"}, types)
+	require.Equal(t, []string{"CREATE cpu", "COLUMNS cpu"}, db.statements)
+
+	// The known tables only get their missing columns
+	db.statements = nil
+	types, err = c.Prepare(ctx, db, "cpu", []Column{{Name: "time", Type: "TIMESTAMP"}, {Name: "usage", Type: "REAL"}})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"time": "TIMESTAMP", "usage": "REAL"}, types)
+	require.Equal(t, []string{"ADD cpu usage"}, db.statements)
+
+	// The forgotten tables are read again
+	db.statements = nil
+	c.Forget("cpu")
+	_, err = c.Prepare(ctx, db, "cpu", []Column{{Name: "time", Type: "TIMESTAMP"}})
+	require.NoError(t, err)
+	require.Equal(t, []string{"CREATE cpu", "COLUMNS cpu"}, db.statements)
+}
+
+func TestCacheWithoutCreate(t *testing.T) {
+	db := &fakeDatabase{tables: map[string]map[string]string{"cpu": {"time": "TIMESTAMP"}}}
+	c := NewCache(false)
+	ctx := context.Background()
+
+	types, err := c.Prepare(ctx, db, "cpu", []Column{{Name: "time", Type: "TIMESTAMP"}, {Name: "usage", Type: "REAL"}})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"time": "TIMESTAMP"}, types)
+	require.Equal(t, []string{"COLUMNS cpu"}, db.statements)
+
+	_, err = c.Prepare(ctx, db, "mem", []Column{{Name: "time", Type: "TIMESTAMP"}})
+	require.Error(t, err)
+}
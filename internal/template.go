@@ -0,0 +1,40 @@
+package internal
+
+import (
+	"bytes"
+	"text/template"
+
+	"github.com/influxdata/telegraf"
+)
+
+// TemplateMetric is the data of the templates of the outputs using the name
+// and the tags of a metric, such as the templates of topics or URLs.
+type TemplateMetric struct {
+	metric telegraf.Metric
+}
+
+// NewTemplateMetric returns the template data of a metric.
+func NewTemplateMetric(metric telegraf.Metric) TemplateMetric {
+	return TemplateMetric{metric: metric}
+}
+
+// Name returns the name of the metric.
+func (m TemplateMetric) Name() string {
+	return m.metric.Name()
+}
+
+// Tag returns the value of a tag of the metric, empty if it is missing.
+func (m TemplateMetric) Tag(key string) string {
+	value, _ := m.metric.GetTag(key)
+	return value
+}
+
+// ExecuteTemplate executes a template with the name and the tags of a
+// metric, returning the text.
+func ExecuteTemplate(t *template.Template, metric telegraf.Metric) (string, error) {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, NewTemplateMetric(metric)); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,23 @@
+package internal
+
+import (
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExecuteTemplate(t *testing.T) {
+	m, err := metric.New("cpu",
+		map[string]string{"host": "server01"},
+		map[string]interface{}{"usage": 42.0},
+		time.Unix(0, 0))
+	require.NoError(t, err)
+
+	tmpl := template.Must(template.New("").Parse(`{{ .Name }}/{{ .Tag "host" }}/{{ .Tag "missing" }}`))
+	text, err := ExecuteTemplate(tmpl, m)
+	require.NoError(t, err)
+	require.Equal(t, "cpu/server01/", text)
+}
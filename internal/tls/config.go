@@ -3,8 +3,13 @@ package tls
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"io/ioutil"
+	"strings"
+
+	"github.com/youmark/pkcs8"
+	"software.sslmate.com/src/go-pkcs12"
 )
 
 // ClientConfig represents the standard client TLS config.
@@ -12,8 +17,14 @@ type ClientConfig struct {
 	TLSCA              string `toml:"tls_ca"`
 	TLSCert            string `toml:"tls_cert"`
 	TLSKey             string `toml:"tls_key"`
+	TLSKeyPwd          string `toml:"tls_key_pwd"`
+	TLSPKCS12          string `toml:"tls_pkcs12"`
 	InsecureSkipVerify bool   `toml:"insecure_skip_verify"`
 
+	TLSMinVersion   string   `toml:"tls_min_version"`
+	TLSMaxVersion   string   `toml:"tls_max_version"`
+	TLSCipherSuites []string `toml:"tls_cipher_suites"`
+
 	// Deprecated in 1.7; use TLS variables above
 	SSLCA   string `toml:"ssl_ca"`
 	SSLCert string `toml:"ssl_cert"`
@@ -24,7 +35,12 @@ type ClientConfig struct {
 type ServerConfig struct {
 	TLSCert           string   `toml:"tls_cert"`
 	TLSKey            string   `toml:"tls_key"`
+	TLSKeyPwd         string   `toml:"tls_key_pwd"`
+	TLSPKCS12         string   `toml:"tls_pkcs12"`
 	TLSAllowedCACerts []string `toml:"tls_allowed_cacerts"`
+	TLSMinVersion     string   `toml:"tls_min_version"`
+	TLSMaxVersion     string   `toml:"tls_max_version"`
+	TLSCipherSuites   []string `toml:"tls_cipher_suites"`
 }
 
 // TLSConfig returns a tls.Config, may be nil without error if TLS is not
@@ -45,7 +61,8 @@ func (c *ClientConfig) TLSConfig() (*tls.Config, error) {
 	// want TLS, this will require using another option to determine.  In the
 	// case of an HTTP plugin, you could use `https`.  Other plugins may need
 	// the dedicated option `TLSEnable`.
-	if c.TLSCA == "" && c.TLSKey == "" && c.TLSCert == "" && !c.InsecureSkipVerify {
+	if c.TLSCA == "" && c.TLSKey == "" && c.TLSCert == "" && c.TLSPKCS12 == "" && !c.InsecureSkipVerify &&
+		c.TLSMinVersion == "" && c.TLSMaxVersion == "" && len(c.TLSCipherSuites) == 0 {
 		return nil, nil
 	}
 
@@ -54,6 +71,14 @@ func (c *ClientConfig) TLSConfig() (*tls.Config, error) {
 		Renegotiation:      tls.RenegotiateNever,
 	}
 
+	err := setVersionsAndCiphers(tlsConfig, c.TLSMinVersion, c.TLSMaxVersion, c.TLSCipherSuites)
+	if err != nil {
+		return nil, err
+	}
+
+	// The CA certificates are read when the config is built, the servers
+	// being verified by the handshake with the CA certificates of the
+	// config, so a client picks the modified ones when building it again.
 	if c.TLSCA != "" {
 		pool, err := makeCertPool([]string{c.TLSCA})
 		if err != nil {
@@ -62,10 +87,16 @@ func (c *ClientConfig) TLSConfig() (*tls.Config, error) {
 		tlsConfig.RootCAs = pool
 	}
 
-	if c.TLSCert != "" && c.TLSKey != "" {
-		err := loadCertificate(tlsConfig, c.TLSCert, c.TLSKey)
-		if err != nil {
-			return nil, err
+	keyPair, err := newKeyPairReloader(c.TLSCert, c.TLSKey, c.TLSKeyPwd, c.TLSPKCS12)
+	if err != nil {
+		return nil, err
+	}
+	if keyPair != nil {
+		// The certificate is also set for the libraries copying the fields
+		// of the config, the callback presenting the reloaded one.
+		tlsConfig.Certificates = []tls.Certificate{*keyPair.value.(*tls.Certificate)}
+		tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return keyPair.get().(*tls.Certificate), nil
 		}
 	}
 
@@ -75,25 +106,50 @@ func (c *ClientConfig) TLSConfig() (*tls.Config, error) {
 // TLSConfig returns a tls.Config, may be nil without error if TLS is not
 // configured.
 func (c *ServerConfig) TLSConfig() (*tls.Config, error) {
-	if c.TLSCert == "" && c.TLSKey == "" && len(c.TLSAllowedCACerts) == 0 {
+	if c.TLSCert == "" && c.TLSKey == "" && c.TLSPKCS12 == "" && len(c.TLSAllowedCACerts) == 0 {
 		return nil, nil
 	}
 
 	tlsConfig := &tls.Config{}
 
+	err := setVersionsAndCiphers(tlsConfig, c.TLSMinVersion, c.TLSMaxVersion, c.TLSCipherSuites)
+	if err != nil {
+		return nil, err
+	}
+
 	if len(c.TLSAllowedCACerts) != 0 {
-		pool, err := makeCertPool(c.TLSAllowedCACerts)
+		caFiles := append([]string(nil), c.TLSAllowedCACerts...)
+		cas, err := newReloader(strings.Join(caFiles, ", "), caFiles, func() (interface{}, error) {
+			return makeCertPool(caFiles)
+		})
 		if err != nil {
 			return nil, err
 		}
-		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientCAs = cas.value.(*x509.CertPool)
 		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+
+		// Once the CA certificates are reloaded, the handshakes use a copy
+		// of the config verifying the clients with them.
+		tlsConfig.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			pool := cas.get().(*x509.CertPool)
+			if pool == tlsConfig.ClientCAs {
+				return nil, nil
+			}
+			config := tlsConfig.Clone()
+			config.ClientCAs = pool
+			return config, nil
+		}
 	}
 
-	if c.TLSCert != "" && c.TLSKey != "" {
-		err := loadCertificate(tlsConfig, c.TLSCert, c.TLSKey)
-		if err != nil {
-			return nil, err
+	keyPair, err := newKeyPairReloader(c.TLSCert, c.TLSKey, c.TLSKeyPwd, c.TLSPKCS12)
+	if err != nil {
+		return nil, err
+	}
+	if keyPair != nil {
+		// Without certificates, the callback is called for every handshake
+		// and serves the reloaded certificate.
+		tlsConfig.GetCertificate = func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return keyPair.get().(*tls.Certificate), nil
 		}
 	}
 
@@ -117,14 +173,109 @@ func makeCertPool(certFiles []string) (*x509.CertPool, error) {
 	return pool, nil
 }
 
-func loadCertificate(config *tls.Config, certFile, keyFile string) error {
-	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+// newKeyPairReloader returns the reloader of the keypair of the PEM files or
+// of the PKCS#12 bundle, nil if no keypair is configured.
+func newKeyPairReloader(certFile, keyFile, keyPwd, p12File string) (*reloader, error) {
+	if p12File != "" {
+		if certFile != "" || keyFile != "" {
+			return nil, fmt.Errorf("tls_pkcs12 cannot be used with tls_cert and tls_key")
+		}
+		return newReloader(p12File, []string{p12File}, func() (interface{}, error) {
+			return loadPKCS12(p12File, keyPwd)
+		})
+	}
+
+	if certFile == "" || keyFile == "" {
+		return nil, nil
+	}
+	return newReloader(certFile, []string{certFile, keyFile}, func() (interface{}, error) {
+		return loadCertificate(certFile, keyFile, keyPwd)
+	})
+}
+
+// loadCertificate loads the keypair of the PEM files, the key being
+// decrypted with the passphrase if it is encrypted.
+func loadCertificate(certFile, keyFile, keyPwd string) (*tls.Certificate, error) {
+	certPEM, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"could not read certificate %q: %v", certFile, err)
+	}
+	keyPEM, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"could not read key %q: %v", keyFile, err)
+	}
+
+	if keyPwd != "" {
+		keyPEM, err = decryptKey(keyPEM, keyPwd)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"could not decrypt key %q: %v", keyFile, err)
+		}
+	}
+
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
 	if err != nil {
-		return fmt.Errorf(
+		return nil, fmt.Errorf(
 			"could not load keypair %s:%s: %v", certFile, keyFile, err)
 	}
+	return &cert, nil
+}
 
-	config.Certificates = []tls.Certificate{cert}
-	config.BuildNameToCertificate()
-	return nil
+// decryptKey returns the unencrypted PEM of a key encrypted either as a
+// PKCS#8 "ENCRYPTED PRIVATE KEY" or with the legacy "Proc-Type: 4,ENCRYPTED"
+// headers.  The keys not encrypted are returned as is.
+func decryptKey(keyPEM []byte, keyPwd string) ([]byte, error) {
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found")
+	}
+
+	switch {
+	case block.Type == "ENCRYPTED PRIVATE KEY":
+		key, err := pkcs8.ParsePKCS8PrivateKey(block.Bytes, []byte(keyPwd))
+		if err != nil {
+			return nil, err
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+	case x509.IsEncryptedPEMBlock(block):
+		der, err := x509.DecryptPEMBlock(block, []byte(keyPwd))
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: der}), nil
+	default:
+		return keyPEM, nil
+	}
+}
+
+// loadPKCS12 loads the keypair of a PKCS#12 bundle, its CA certificates being
+// sent as the chain of the certificate.
+func loadPKCS12(p12File, pwd string) (*tls.Certificate, error) {
+	data, err := ioutil.ReadFile(p12File)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"could not read PKCS#12 bundle %q: %v", p12File, err)
+	}
+
+	key, leaf, caCerts, err := pkcs12.DecodeChain(data, pwd)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"could not decode PKCS#12 bundle %q: %v", p12File, err)
+	}
+
+	cert := &tls.Certificate{
+		Certificate: [][]byte{leaf.Raw},
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}
+	for _, caCert := range caCerts {
+		cert.Certificate = append(cert.Certificate, caCert.Raw)
+	}
+	return cert, nil
 }
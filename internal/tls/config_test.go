@@ -1,8 +1,14 @@
 package tls_test
 
 import (
+	cryptotls "crypto/tls"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -78,6 +84,99 @@ func TestClientConfig(t *testing.T) {
 			expNil: false,
 			expErr: false,
 		},
+		{
+			name: "encrypted key",
+			client: tls.ClientConfig{
+				TLSCA:     pki.CACertPath(),
+				TLSCert:   pki.ClientCertPath(),
+				TLSKey:    pki.ClientEncKeyPath(),
+				TLSKeyPwd: pki.KeyPwd(),
+			},
+		},
+		{
+			name: "encrypted key with invalid passphrase",
+			client: tls.ClientConfig{
+				TLSCA:     pki.CACertPath(),
+				TLSCert:   pki.ClientCertPath(),
+				TLSKey:    pki.ClientEncKeyPath(),
+				TLSKeyPwd: "invalid",
+			},
+			expNil: true,
+			expErr: true,
+		},
+		{
+			name: "encrypted key without passphrase",
+			client: tls.ClientConfig{
+				TLSCA:   pki.CACertPath(),
+				TLSCert: pki.ClientCertPath(),
+				TLSKey:  pki.ClientEncKeyPath(),
+			},
+			expNil: true,
+			expErr: true,
+		},
+		{
+			name: "pkcs12",
+			client: tls.ClientConfig{
+				TLSCA:     pki.CACertPath(),
+				TLSPKCS12: pki.ClientPKCS12Path(),
+				TLSKeyPwd: pki.KeyPwd(),
+			},
+		},
+		{
+			name: "pkcs12 with invalid passphrase",
+			client: tls.ClientConfig{
+				TLSCA:     pki.CACertPath(),
+				TLSPKCS12: pki.ClientPKCS12Path(),
+				TLSKeyPwd: "invalid",
+			},
+			expNil: true,
+			expErr: true,
+		},
+		{
+			name: "pkcs12 with cert",
+			client: tls.ClientConfig{
+				TLSCA:     pki.CACertPath(),
+				TLSCert:   pki.ClientCertPath(),
+				TLSKey:    pki.ClientKeyPath(),
+				TLSPKCS12: pki.ClientPKCS12Path(),
+				TLSKeyPwd: pki.KeyPwd(),
+			},
+			expNil: true,
+			expErr: true,
+		},
+		{
+			name: "versions and cipher suites",
+			client: tls.ClientConfig{
+				TLSMinVersion:   "TLS11",
+				TLSMaxVersion:   "TLS12",
+				TLSCipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+			},
+		},
+		{
+			name: "invalid version",
+			client: tls.ClientConfig{
+				TLSMinVersion: "TLS1.2",
+			},
+			expNil: true,
+			expErr: true,
+		},
+		{
+			name: "min version greater than max version",
+			client: tls.ClientConfig{
+				TLSMinVersion: "TLS13",
+				TLSMaxVersion: "TLS12",
+			},
+			expNil: true,
+			expErr: true,
+		},
+		{
+			name: "invalid cipher suite",
+			client: tls.ClientConfig{
+				TLSCipherSuites: []string{"TLS_RSA_WITH_NULL_SHA"},
+			},
+			expNil: true,
+			expErr: true,
+		},
 		{
 			name: "support deprecated ssl field names",
 			client: tls.ClientConfig{
@@ -163,6 +262,53 @@ func TestServerConfig(t *testing.T) {
 			expNil: true,
 			expErr: true,
 		},
+		{
+			name: "encrypted key",
+			server: tls.ServerConfig{
+				TLSCert:           pki.ServerCertPath(),
+				TLSKey:            pki.ServerEncKeyPath(),
+				TLSKeyPwd:         pki.KeyPwd(),
+				TLSAllowedCACerts: []string{pki.CACertPath()},
+			},
+		},
+		{
+			name: "encrypted key with invalid passphrase",
+			server: tls.ServerConfig{
+				TLSCert:           pki.ServerCertPath(),
+				TLSKey:            pki.ServerEncKeyPath(),
+				TLSKeyPwd:         "invalid",
+				TLSAllowedCACerts: []string{pki.CACertPath()},
+			},
+			expNil: true,
+			expErr: true,
+		},
+		{
+			name: "pkcs12",
+			server: tls.ServerConfig{
+				TLSPKCS12:         pki.ServerPKCS12Path(),
+				TLSKeyPwd:         pki.KeyPwd(),
+				TLSAllowedCACerts: []string{pki.CACertPath()},
+			},
+		},
+		{
+			name: "versions and cipher suites",
+			server: tls.ServerConfig{
+				TLSCert:         pki.ServerCertPath(),
+				TLSKey:          pki.ServerKeyPath(),
+				TLSMinVersion:   "TLS12",
+				TLSCipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"},
+			},
+		},
+		{
+			name: "invalid max version",
+			server: tls.ServerConfig{
+				TLSCert:       pki.ServerCertPath(),
+				TLSKey:        pki.ServerKeyPath(),
+				TLSMaxVersion: "SSL30",
+			},
+			expNil: true,
+			expErr: true,
+		},
 		{
 			name: "missing key",
 			server: tls.ServerConfig{
@@ -199,15 +345,60 @@ func TestConnect(t *testing.T) {
 		TLSAllowedCACerts: []string{pki.CACertPath()},
 	}
 
+	connect(t, clientConfig, serverConfig)
+}
+
+func TestConnectPKCS12(t *testing.T) {
+	clientConfig := tls.ClientConfig{
+		TLSCA:     pki.CACertPath(),
+		TLSPKCS12: pki.ClientPKCS12Path(),
+		TLSKeyPwd: pki.KeyPwd(),
+	}
+
+	serverConfig := tls.ServerConfig{
+		TLSCert:           pki.ServerCertPath(),
+		TLSKey:            pki.ServerEncKeyPath(),
+		TLSKeyPwd:         pki.KeyPwd(),
+		TLSAllowedCACerts: []string{pki.CACertPath()},
+	}
+
+	connect(t, clientConfig, serverConfig)
+}
+
+func TestConnectVersions(t *testing.T) {
+	clientConfig := *pki.TLSClientConfig()
+	clientConfig.TLSMaxVersion = "TLS12"
+	clientTLSConfig, err := clientConfig.TLSConfig()
+	require.NoError(t, err)
+
+	serverConfig := *pki.TLSServerConfig()
+	serverConfig.TLSMinVersion = "TLS13"
 	serverTLSConfig, err := serverConfig.TLSConfig()
 	require.NoError(t, err)
 
-	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	ts := newTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
-	}))
-	ts.TLS = serverTLSConfig
+	}), serverTLSConfig)
+	defer ts.Close()
 
-	ts.StartTLS()
+	client := http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: clientTLSConfig,
+		},
+		Timeout: 10 * time.Second,
+	}
+	_, err = client.Get(ts.URL)
+	require.Error(t, err)
+}
+
+func connect(t *testing.T, clientConfig tls.ClientConfig, serverConfig tls.ServerConfig) {
+
+	serverTLSConfig, err := serverConfig.TLSConfig()
+	require.NoError(t, err)
+
+	ts := newTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), serverTLSConfig)
 	defer ts.Close()
 
 	clientTLSConfig, err := clientConfig.TLSConfig()
@@ -224,3 +415,184 @@ func TestConnect(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, 200, resp.StatusCode)
 }
+
+// newTLSServer starts a server using the config as is, httptest adding its own
+// certificate to configs without certificates.
+func newTLSServer(handler http.Handler, tlsConfig *cryptotls.Config) *httptest.Server {
+	ts := httptest.NewUnstartedServer(handler)
+	ts.Listener = cryptotls.NewListener(ts.Listener, tlsConfig)
+	ts.Start()
+	ts.URL = strings.Replace(ts.URL, "http://", "https://", 1)
+	return ts
+}
+
+// copyFile replaces the content of dst with the one of src, moving its
+// modification time forward as the content may have the same size.
+func copyFile(t *testing.T, dst, src string) {
+	b, err := ioutil.ReadFile(src)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(dst, b, 0600))
+
+	info, err := os.Stat(dst)
+	require.NoError(t, err)
+	modTime := info.ModTime().Add(time.Minute)
+	require.NoError(t, os.Chtimes(dst, modTime, modTime))
+}
+
+func TestReloadServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-tls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	serverConfig := tls.ServerConfig{
+		TLSCert:           filepath.Join(dir, "cert.pem"),
+		TLSKey:            filepath.Join(dir, "key.pem"),
+		TLSAllowedCACerts: []string{filepath.Join(dir, "ca.pem")},
+	}
+	copyFile(t, serverConfig.TLSCert, pki.ClientCertPath())
+	copyFile(t, serverConfig.TLSKey, pki.ClientKeyPath())
+	// The CA is initially not the one of the client certificate
+	copyFile(t, serverConfig.TLSAllowedCACerts[0], pki.ServerCertPath())
+
+	serverTLSConfig, err := serverConfig.TLSConfig()
+	require.NoError(t, err)
+	listener, err := cryptotls.Listen("tcp", "127.0.0.1:0", serverTLSConfig)
+	require.NoError(t, err)
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.(*cryptotls.Conn).Handshake()
+			conn.Close()
+		}
+	}()
+
+	clientTLSConfig, err := pki.TLSClientConfig().TLSConfig()
+	require.NoError(t, err)
+	clientTLSConfig.InsecureSkipVerify = true
+	dial := func() (string, error) {
+		conn, err := cryptotls.Dial("tcp", listener.Addr().String(), clientTLSConfig)
+		if err != nil {
+			return "", err
+		}
+		defer conn.Close()
+		// The client certificate is verified after the handshake of the
+		// client, the server closing the connection if rejected.
+		if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+			return "", err
+		}
+		return conn.ConnectionState().PeerCertificates[0].Subject.CommonName, nil
+	}
+
+	_, err = dial()
+	require.Error(t, err)
+
+	copyFile(t, serverConfig.TLSCert, pki.ServerCertPath())
+	copyFile(t, serverConfig.TLSKey, pki.ServerKeyPath())
+	copyFile(t, serverConfig.TLSAllowedCACerts[0], pki.CACertPath())
+	name, err := dial()
+	require.NoError(t, err)
+	require.Equal(t, "server.localdomain", name)
+
+	// The previous certificate is kept while the files are invalid
+	copyFile(t, serverConfig.TLSKey, pki.ClientKeyPath())
+	name, err = dial()
+	require.NoError(t, err)
+	require.Equal(t, "server.localdomain", name)
+}
+
+func TestReloadClient(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-tls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	clientConfig := tls.ClientConfig{
+		TLSCA:     pki.CACertPath(),
+		TLSPKCS12: filepath.Join(dir, "cert.p12"),
+		TLSKeyPwd: pki.KeyPwd(),
+	}
+	copyFile(t, clientConfig.TLSPKCS12, pki.ClientPKCS12Path())
+
+	names := make(chan string, 2)
+	serverTLSConfig, err := pki.TLSServerConfig().TLSConfig()
+	require.NoError(t, err)
+	// The client certificates are only requested, to be presented whatever
+	// their usage
+	serverTLSConfig.ClientAuth = cryptotls.RequireAnyClientCert
+	ts := newTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		names <- r.TLS.PeerCertificates[0].Subject.CommonName
+		w.WriteHeader(http.StatusOK)
+	}), serverTLSConfig)
+	defer ts.Close()
+
+	clientTLSConfig, err := clientConfig.TLSConfig()
+	require.NoError(t, err)
+	get := func() string {
+		client := http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig:   clientTLSConfig,
+				DisableKeepAlives: true,
+			},
+			Timeout: 10 * time.Second,
+		}
+		resp, err := client.Get(ts.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+		return <-names
+	}
+
+	require.Equal(t, "client.localdomain", get())
+	copyFile(t, clientConfig.TLSPKCS12, pki.ServerPKCS12Path())
+	require.Equal(t, "server.localdomain", get())
+}
+
+func TestClientCAModified(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-tls")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	clientConfig := tls.ClientConfig{
+		TLSCA: filepath.Join(dir, "ca.pem"),
+	}
+	// The CA is initially not the one of the server certificate
+	copyFile(t, clientConfig.TLSCA, pki.ClientCertPath())
+
+	serverTLSConfig, err := pki.TLSServerConfig().TLSConfig()
+	require.NoError(t, err)
+	serverTLSConfig.ClientAuth = cryptotls.NoClientCert
+	ts := newTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), serverTLSConfig)
+	defer ts.Close()
+
+	get := func(serverName string) error {
+		config, err := clientConfig.TLSConfig()
+		require.NoError(t, err)
+		config.ServerName = serverName
+		client := http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig:   config,
+				DisableKeepAlives: true,
+			},
+			Timeout: 10 * time.Second,
+		}
+		resp, err := client.Get(ts.URL)
+		if err != nil {
+			return err
+		}
+		return resp.Body.Close()
+	}
+
+	require.Error(t, get(""))
+
+	// The config built again verifies the server with the modified CA, the
+	// server addressed by IP being verified with its IP address
+	copyFile(t, clientConfig.TLSCA, pki.CACertPath())
+	require.NoError(t, get(""))
+
+	// The name of the server is still verified
+	require.Error(t, get("invalid.localdomain"))
+}
@@ -0,0 +1,93 @@
+package tls
+
+import (
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// reloader holds the material loaded from files, loading it again when the
+// files are modified so that the certificates rotated on disk are used
+// without restarting.  The files are checked on every handshake.
+type reloader struct {
+	name  string
+	files []string
+	load  func() (interface{}, error)
+
+	mu    sync.Mutex
+	value interface{}
+	// stats are the stats of the files of the value, failed the ones of the
+	// last files which could not be loaded, the error being only logged once.
+	stats  []fileStat
+	failed []fileStat
+}
+
+type fileStat struct {
+	modTime time.Time
+	size    int64
+}
+
+func newReloader(name string, files []string, load func() (interface{}, error)) (*reloader, error) {
+	// The files are stated before being loaded, a modification while loading
+	// them being loaded with the next handshake.
+	stats, _ := statFiles(files)
+	value, err := load()
+	if err != nil {
+		return nil, err
+	}
+	return &reloader{
+		name:  name,
+		files: files,
+		load:  load,
+		value: value,
+		stats: stats,
+	}, nil
+}
+
+// get returns the value, loaded again if the files were modified.  When the
+// files cannot be loaded, for instance while they are being replaced, the
+// previous value is kept.
+func (r *reloader) get() interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stats, err := statFiles(r.files)
+	if err != nil || equalStats(stats, r.stats) || equalStats(stats, r.failed) {
+		return r.value
+	}
+
+	value, err := r.load()
+	if err != nil {
+		log.Printf("E! [tls] could not reload %s, keeping the previous one: %v", r.name, err)
+		r.failed = stats
+		return r.value
+	}
+	log.Printf("I! [tls] reloaded %s", r.name)
+	r.value, r.stats, r.failed = value, stats, nil
+	return r.value
+}
+
+func statFiles(files []string) ([]fileStat, error) {
+	stats := make([]fileStat, 0, len(files))
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		stats = append(stats, fileStat{modTime: info.ModTime(), size: info.Size()})
+	}
+	return stats, nil
+}
+
+func equalStats(a, b []fileStat) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].modTime.Equal(b[i].modTime) || a[i].size != b[i].size {
+			return false
+		}
+	}
+	return true
+}
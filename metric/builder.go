@@ -1,6 +1,7 @@
 package metric
 
 import (
+	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -8,11 +9,20 @@ import (
 
 type TimeFunc func() time.Time
 
+// Builder makes metrics from their name, tags and fields added one by one.
+// The tags and fields are collected in buffers kept from one metric to the
+// next, each metric being allocated at once when made, so that a Builder
+// should be reused, or taken from the pool with GetBuilder, by the inputs
+// making many metrics.
 type Builder struct {
 	TimeFunc
 	TimePrecision time.Duration
 
-	*metric
+	name   string
+	tags   []telegraf.Tag
+	fields []telegraf.Field
+	tm     time.Time
+	tp     telegraf.ValueType
 }
 
 func NewBuilder() *Builder {
@@ -24,30 +34,102 @@ func NewBuilder() *Builder {
 	return b
 }
 
+var builderPool = sync.Pool{
+	New: func() interface{} {
+		return NewBuilder()
+	},
+}
+
+// GetBuilder returns a reset Builder from the pool, to be returned with
+// PutBuilder once the metric is made.
+func GetBuilder() *Builder {
+	return builderPool.Get().(*Builder)
+}
+
+// PutBuilder resets the builder and returns it to the pool.  The metrics
+// made by the builder are not affected.
+func PutBuilder(b *Builder) {
+	b.TimeFunc = time.Now
+	b.TimePrecision = 1 * time.Nanosecond
+	b.Reset()
+	builderPool.Put(b)
+}
+
 func (b *Builder) SetName(name string) {
 	b.name = name
 }
 
+// AddTag adds a tag, replacing the value of an existing tag of the key.
 func (b *Builder) AddTag(key string, value string) {
-	b.metric.AddTag(key, value)
+	for i := range b.tags {
+		if key > b.tags[i].Key {
+			continue
+		}
+
+		if key == b.tags[i].Key {
+			b.tags[i].Value = value
+			return
+		}
+
+		b.tags = append(b.tags, telegraf.Tag{})
+		copy(b.tags[i+1:], b.tags[i:])
+		b.tags[i] = telegraf.Tag{Key: key, Value: value}
+		return
+	}
+
+	b.tags = append(b.tags, telegraf.Tag{Key: key, Value: value})
 }
 
+// AddField adds a field, replacing the value of an existing field of the
+// key.  The fields of unsupported types are ignored.
 func (b *Builder) AddField(key string, value interface{}) {
-	b.metric.AddField(key, value)
+	value = convertField(value)
+	if value == nil {
+		return
+	}
+
+	for i := range b.fields {
+		if key == b.fields[i].Key {
+			b.fields[i].Value = value
+			return
+		}
+	}
+
+	b.fields = append(b.fields, telegraf.Field{Key: key, Value: value})
 }
 
 func (b *Builder) SetTime(tm time.Time) {
 	b.tm = tm
 }
 
+// SetType sets the value type of the metric, untyped by default.
+func (b *Builder) SetType(tp telegraf.ValueType) {
+	b.tp = tp
+}
+
+// Reset clears the builder for the next metric, keeping its buffers.
 func (b *Builder) Reset() {
-	b.metric = &metric{}
+	b.name = ""
+	for i := range b.tags {
+		b.tags[i] = telegraf.Tag{}
+	}
+	b.tags = b.tags[:0]
+	for i := range b.fields {
+		b.fields[i] = telegraf.Field{}
+	}
+	b.fields = b.fields[:0]
+	b.tm = time.Time{}
+	b.tp = telegraf.Untyped
 }
 
+// Metric returns the metric made of the name, tags and fields added since
+// the last Reset, with the current time if no time was set.  The metric does
+// not share memory with the builder.
 func (b *Builder) Metric() (telegraf.Metric, error) {
-	if b.tm.IsZero() {
-		b.tm = b.TimeFunc().Truncate(b.TimePrecision)
+	tm := b.tm
+	if tm.IsZero() {
+		tm = b.TimeFunc().Truncate(b.TimePrecision)
 	}
 
-	return b.metric, nil
+	return newMetric(b.name, b.tags, b.fields, tm, b.tp), nil
 }
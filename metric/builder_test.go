@@ -0,0 +1,80 @@
+package metric
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuilder(t *testing.T) {
+	now := time.Now()
+	b := NewBuilder()
+	b.SetName("cpu")
+	b.AddTag("host", "localhost")
+	b.AddTag("cpu", "cpu0")
+	b.AddTag("host", "remote")
+	b.AddField("usage", 42)
+	b.AddField("usage", 43.5)
+	b.AddField("unsupported", struct{}{})
+	b.SetType(telegraf.Gauge)
+	b.SetTime(now)
+
+	m, err := b.Metric()
+	require.NoError(t, err)
+	expected, err := New("cpu",
+		map[string]string{"host": "remote", "cpu": "cpu0"},
+		map[string]interface{}{"usage": 43.5},
+		now,
+		telegraf.Gauge,
+	)
+	require.NoError(t, err)
+	require.Equal(t, expected, m)
+
+	// The metric does not share memory with the builder
+	b.Reset()
+	b.SetName("mem")
+	b.AddTag("cpu", "cpu1")
+	b.AddField("used", int64(1))
+	m2, err := b.Metric()
+	require.NoError(t, err)
+	require.Equal(t, expected, m)
+	require.Equal(t, "mem", m2.Name())
+	require.Equal(t, telegraf.Untyped, m2.Type())
+	require.Equal(t, map[string]string{"cpu": "cpu1"}, m2.Tags())
+}
+
+func TestBuilderTime(t *testing.T) {
+	now := time.Unix(1500000000, 123456789)
+	b := NewBuilder()
+	b.TimeFunc = func() time.Time { return now }
+	b.TimePrecision = time.Second
+	b.SetName("cpu")
+	b.AddField("usage", 42.5)
+
+	m, err := b.Metric()
+	require.NoError(t, err)
+	require.Equal(t, time.Unix(1500000000, 0), m.Time())
+	require.Nil(t, m.TagList())
+}
+
+func TestBuilderPool(t *testing.T) {
+	b := GetBuilder()
+	b.TimePrecision = time.Second
+	b.SetName("cpu")
+	b.AddTag("host", "localhost")
+	b.AddField("usage", 42.5)
+	PutBuilder(b)
+
+	b = GetBuilder()
+	defer PutBuilder(b)
+	require.Equal(t, time.Nanosecond, b.TimePrecision)
+	b.SetName("mem")
+	b.AddField("used", int64(1))
+	m, err := b.Metric()
+	require.NoError(t, err)
+	require.Equal(t, "mem", m.Name())
+	require.Empty(t, m.TagList())
+	require.Equal(t, map[string]interface{}{"used": int64(1)}, m.Fields())
+}
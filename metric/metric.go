@@ -34,34 +34,94 @@ func New(
 	}
 
 	m := &metric{
-		name:   name,
-		tags:   nil,
-		fields: nil,
-		tm:     tm,
-		tp:     vtype,
+		name: name,
+		tm:   tm,
+		tp:   vtype,
 	}
 
+	// The tags and fields are allocated together instead of one by one, as
+	// making the metrics is most of the allocations of the busy inputs.
 	if len(tags) > 0 {
-		m.tags = make([]*telegraf.Tag, 0, len(tags))
+		ts := make([]telegraf.Tag, 0, len(tags))
 		for k, v := range tags {
-			m.tags = append(m.tags,
-				&telegraf.Tag{Key: k, Value: v})
+			ts = append(ts, telegraf.Tag{Key: k, Value: v})
 		}
-		sort.Slice(m.tags, func(i, j int) bool { return m.tags[i].Key < m.tags[j].Key })
+		sortTags(ts)
+		m.tags = tagPointers(ts)
 	}
 
-	m.fields = make([]*telegraf.Field, 0, len(fields))
+	fs := make([]telegraf.Field, 0, len(fields))
 	for k, v := range fields {
 		v := convertField(v)
 		if v == nil {
 			continue
 		}
-		m.AddField(k, v)
+		fs = append(fs, telegraf.Field{Key: k, Value: v})
 	}
+	m.fields = fieldPointers(fs)
 
 	return m, nil
 }
 
+// newMetric makes a metric of copies of the tags, already sorted by key, and
+// of the fields.
+func newMetric(
+	name string,
+	tags []telegraf.Tag,
+	fields []telegraf.Field,
+	tm time.Time,
+	tp telegraf.ValueType,
+) *metric {
+	m := &metric{
+		name: name,
+		tm:   tm,
+		tp:   tp,
+	}
+	if len(tags) > 0 {
+		m.tags = tagPointers(append([]telegraf.Tag(nil), tags...))
+	}
+	m.fields = fieldPointers(append(make([]telegraf.Field, 0, len(fields)), fields...))
+	return m
+}
+
+// tagPointers returns the pointers to the tags, in a single allocation.
+func tagPointers(tags []telegraf.Tag) []*telegraf.Tag {
+	ptrs := make([]*telegraf.Tag, len(tags))
+	for i := range tags {
+		ptrs[i] = &tags[i]
+	}
+	return ptrs
+}
+
+// fieldPointers returns the pointers to the fields, in a single allocation.
+func fieldPointers(fields []telegraf.Field) []*telegraf.Field {
+	ptrs := make([]*telegraf.Field, len(fields))
+	for i := range fields {
+		ptrs[i] = &fields[i]
+	}
+	return ptrs
+}
+
+type tagsByKey []telegraf.Tag
+
+func (t tagsByKey) Len() int           { return len(t) }
+func (t tagsByKey) Less(i, j int) bool { return t[i].Key < t[j].Key }
+func (t tagsByKey) Swap(i, j int)      { t[i], t[j] = t[j], t[i] }
+
+// sortTags sorts the tags by key, with an insertion sort for the few tags of
+// most metrics as it is faster and does not allocate.
+func sortTags(tags []telegraf.Tag) {
+	if len(tags) > 12 {
+		sort.Sort(tagsByKey(tags))
+		return
+	}
+	for i := 1; i < len(tags); i++ {
+		for j := i; j > 0 && tags[j].Key < tags[j-1].Key; j-- {
+			tags[j], tags[j-1] = tags[j-1], tags[j]
+		}
+	}
+}
+
 func (m *metric) String() string {
 	return fmt.Sprintf("%s %v %v %d", m.name, m.Tags(), m.Fields(), m.tm.UnixNano())
 }
@@ -167,7 +227,8 @@ func (m *metric) RemoveTag(key string) {
 func (m *metric) AddField(key string, value interface{}) {
 	for i, field := range m.fields {
 		if key == field.Key {
-			m.fields[i] = &telegraf.Field{Key: key, Value: convertField(value)}
+			m.fields[i].Value = convertField(value)
+			return
 		}
 	}
 	m.fields = append(m.fields, &telegraf.Field{Key: key, Value: convertField(value)})
@@ -209,19 +270,26 @@ func (m *metric) SetTime(t time.Time) {
 func (m *metric) Copy() telegraf.Metric {
 	m2 := &metric{
 		name:      m.name,
-		tags:      make([]*telegraf.Tag, len(m.tags)),
-		fields:    make([]*telegraf.Field, len(m.fields)),
 		tm:        m.tm,
 		tp:        m.tp,
 		aggregate: m.aggregate,
 	}
 
-	for i, tag := range m.tags {
-		m2.tags[i] = tag
+	// The tags and fields are copied, as they are modified in place.
+	if m.tags != nil {
+		tags := make([]telegraf.Tag, len(m.tags))
+		for i, tag := range m.tags {
+			tags[i] = *tag
+		}
+		m2.tags = tagPointers(tags)
 	}
 
-	for i, field := range m.fields {
-		m2.fields[i] = field
+	if m.fields != nil {
+		fields := make([]telegraf.Field, len(m.fields))
+		for i, field := range m.fields {
+			fields[i] = *field
+		}
+		m2.fields = fieldPointers(fields)
 	}
 	return m2
 }
@@ -249,37 +317,30 @@ func (m *metric) HashID() uint64 {
 
 // Convert field to a supported type or nil if unconvertible
 func convertField(v interface{}) interface{} {
-	switch v := v.(type) {
-	case float64:
-		return v
-	case int64:
-		return v
-	case string:
-		return v
-	case bool:
+	switch fv := v.(type) {
+	case float64, int64, string, bool, uint64:
+		// Returning the value as is does not allocate it again
 		return v
 	case int:
-		return int64(v)
+		return int64(fv)
 	case uint:
-		return uint64(v)
-	case uint64:
-		return uint64(v)
+		return uint64(fv)
 	case []byte:
-		return string(v)
+		return string(fv)
 	case int32:
-		return int64(v)
+		return int64(fv)
 	case int16:
-		return int64(v)
+		return int64(fv)
 	case int8:
-		return int64(v)
+		return int64(fv)
 	case uint32:
-		return uint64(v)
+		return uint64(fv)
 	case uint16:
-		return uint64(v)
+		return uint64(fv)
 	case uint8:
-		return uint64(v)
+		return uint64(fv)
 	case float32:
-		return float64(v)
+		return float64(fv)
 	default:
 		return nil
 	}
@@ -1,6 +1,7 @@
 package metric
 
 import (
+	"fmt"
 	"testing"
 	"time"
 
@@ -114,6 +115,7 @@ func TestAddFieldOverwrites(t *testing.T) {
 	value, ok := m.GetField("value")
 	require.True(t, ok)
 	require.Equal(t, 42.0, value)
+	require.Len(t, m.FieldList(), 1)
 }
 
 func TestAddFieldChangesType(t *testing.T) {
@@ -335,3 +337,97 @@ func TestCopyAggreate(t *testing.T) {
 	m2 := m1.Copy()
 	assert.True(t, m2.IsAggregate())
 }
+
+func TestCopyDeep(t *testing.T) {
+	m1 := baseMetric()
+	m1.AddTag("host", "localhost")
+	m2 := m1.Copy()
+
+	m2.AddTag("host", "remote")
+	m2.AddField("value", float64(2))
+	value, _ := m1.GetTag("host")
+	assert.Equal(t, "localhost", value)
+	field, _ := m1.GetField("value")
+	assert.Equal(t, float64(1), field)
+}
+
+func TestNewManyTags(t *testing.T) {
+	tags := make(map[string]string)
+	for i := 0; i < 20; i++ {
+		tags[fmt.Sprintf("tag%02d", i)] = "value"
+	}
+	m, err := New("cpu", tags, map[string]interface{}{"value": float64(1)}, time.Now())
+	require.NoError(t, err)
+
+	taglist := m.TagList()
+	require.Len(t, taglist, 20)
+	for i := range taglist {
+		require.Equal(t, fmt.Sprintf("tag%02d", i), taglist[i].Key)
+	}
+}
+
+var benchTags = map[string]string{
+	"host":       "localhost",
+	"datacenter": "us-east-1",
+	"cpu":        "cpu0",
+	"rack":       "a1",
+}
+
+var benchFields = map[string]interface{}{
+	"usage_idle":   float64(99),
+	"usage_busy":   float64(1),
+	"usage_system": float64(0.5),
+	"usage_user":   float64(0.5),
+}
+
+func BenchmarkNew(b *testing.B) {
+	now := time.Now()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		New("cpu", benchTags, benchFields, now)
+	}
+}
+
+func BenchmarkBuilder(b *testing.B) {
+	now := time.Now()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		builder := GetBuilder()
+		builder.SetName("cpu")
+		for k, v := range benchTags {
+			builder.AddTag(k, v)
+		}
+		for k, v := range benchFields {
+			builder.AddField(k, v)
+		}
+		builder.SetTime(now)
+		builder.Metric()
+		PutBuilder(builder)
+	}
+}
+
+func BenchmarkCopy(b *testing.B) {
+	m, _ := New("cpu", benchTags, benchFields, time.Now())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		m.Copy()
+	}
+}
+
+func BenchmarkHashID(b *testing.B) {
+	m, _ := New("cpu", benchTags, benchFields, time.Now())
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		m.HashID()
+	}
+}
+
+func BenchmarkAddTag(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		m, _ := New("cpu", nil, benchFields, time.Time{})
+		for k, v := range benchTags {
+			m.AddTag(k, v)
+		}
+	}
+}
@@ -0,0 +1,431 @@
+package activemq
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+type ActiveMQ struct {
+	URL             string            `toml:"url"`
+	API             string            `toml:"api"`
+	Username        string            `toml:"username"`
+	Password        string            `toml:"password"`
+	Webadmin        string            `toml:"webadmin"`
+	ResponseTimeout internal.Duration `toml:"response_timeout"`
+	tls.ClientConfig
+
+	client  *http.Client
+	baseURL *url.URL
+}
+
+const (
+	apiConsole = "console"
+	apiJolokia = "jolokia"
+)
+
+// Topics is the XML of the topics page of the console
+type Topics struct {
+	XMLName    xml.Name `xml:"topics"`
+	TopicItems []Topic  `xml:"topic"`
+}
+
+type Topic struct {
+	XMLName xml.Name `xml:"topic"`
+	Name    string   `xml:"name,attr"`
+	Stats   Stats    `xml:"stats"`
+}
+
+// Subscribers is the XML of the subscribers page of the console
+type Subscribers struct {
+	XMLName         xml.Name     `xml:"subscribers"`
+	SubscriberItems []Subscriber `xml:"subscriber"`
+}
+
+type Subscriber struct {
+	XMLName          xml.Name `xml:"subscriber"`
+	ClientId         string   `xml:"clientId,attr"`
+	SubscriptionName string   `xml:"subscriptionName,attr"`
+	ConnectionId     string   `xml:"connectionId,attr"`
+	DestinationName  string   `xml:"destinationName,attr"`
+	Selector         string   `xml:"selector,attr"`
+	Active           string   `xml:"active,attr"`
+	Stats            Stats    `xml:"stats"`
+}
+
+// Queues is the XML of the queues page of the console
+type Queues struct {
+	XMLName    xml.Name `xml:"queues"`
+	QueueItems []Queue  `xml:"queue"`
+}
+
+type Queue struct {
+	XMLName xml.Name `xml:"queue"`
+	Name    string   `xml:"name,attr"`
+	Stats   Stats    `xml:"stats"`
+}
+
+type Stats struct {
+	XMLName             xml.Name `xml:"stats"`
+	Size                int      `xml:"size,attr"`
+	ConsumerCount       int      `xml:"consumerCount,attr"`
+	EnqueueCount        int      `xml:"enqueueCount,attr"`
+	DequeueCount        int      `xml:"dequeueCount,attr"`
+	PendingQueueSize    int      `xml:"pendingQueueSize,attr"`
+	DispatchedQueueSize int      `xml:"dispatchedQueueSize,attr"`
+	DispatchedCounter   int      `xml:"dispatchedCounter,attr"`
+	EnqueueCounter      int      `xml:"enqueueCounter,attr"`
+	DequeueCounter      int      `xml:"dequeueCounter,attr"`
+}
+
+// JolokiaResponse is the response of a Jolokia read request of an MBean
+// pattern, the attributes of the matching MBeans keyed by their name.
+type JolokiaResponse struct {
+	Status int                                   `json:"status"`
+	Error  string                                `json:"error"`
+	Value  map[string]map[string]json.RawMessage `json:"value"`
+}
+
+const (
+	queuesMBean      = "org.apache.activemq:type=Broker,brokerName=*,destinationType=Queue,destinationName=*"
+	topicsMBean      = "org.apache.activemq:type=Broker,brokerName=*,destinationType=Topic,destinationName=*"
+	subscribersMBean = "org.apache.activemq:type=Broker,brokerName=*,destinationType=*,destinationName=*,endpoint=Consumer,clientId=*,consumerId=*"
+)
+
+var destinationAttributes = []string{
+	"Name",
+	"QueueSize",
+	"ConsumerCount",
+	"EnqueueCount",
+	"DequeueCount",
+	"MemoryPercentUsage",
+	"MemoryUsageByteCount",
+}
+
+var subscriberAttributes = []string{
+	"ClientId",
+	"SubscriptionName",
+	"ConnectionId",
+	"DestinationName",
+	"Selector",
+	"Active",
+	"PendingQueueSize",
+	"DispatchedQueueSize",
+	"DispatchedCounter",
+	"EnqueueCounter",
+	"DequeueCounter",
+}
+
+var sampleConfig = `
+  ## ActiveMQ WebConsole URL
+  url = "http://127.0.0.1:8161"
+
+  ## API used to read the statistics, either "console" for the XML pages of
+  ## the web console or "jolokia" for the Jolokia REST API, which also
+  ## provides the memory usage of the destinations.
+  # api = "console"
+
+  ## Credentials for basic HTTP authentication
+  # username = "admin"
+  # password = "admin"
+
+  ## Required ActiveMQ webadmin root path
+  # webadmin = "admin"
+
+  ## Maximum time to receive response.
+  # response_timeout = "5s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+func (a *ActiveMQ) Description() string {
+	return "Gather ActiveMQ metrics"
+}
+
+func (a *ActiveMQ) SampleConfig() string {
+	return sampleConfig
+}
+
+func (a *ActiveMQ) createHttpClient() (*http.Client, error) {
+	tlsCfg, err := a.ClientConfig.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsCfg,
+		},
+		Timeout: a.ResponseTimeout.Duration,
+	}
+
+	return client, nil
+}
+
+func (a *ActiveMQ) init() error {
+	if a.ResponseTimeout.Duration < time.Second {
+		a.ResponseTimeout.Duration = time.Second * 5
+	}
+
+	switch a.API {
+	case "":
+		a.API = apiConsole
+	case apiConsole, apiJolokia:
+	default:
+		return fmt.Errorf("invalid api %q, must be %q or %q", a.API, apiConsole, apiJolokia)
+	}
+
+	var err error
+	a.baseURL, err = url.Parse(a.URL)
+	if err != nil {
+		return err
+	}
+
+	a.client, err = a.createHttpClient()
+	return err
+}
+
+func (a *ActiveMQ) Gather(acc telegraf.Accumulator) error {
+	if a.client == nil {
+		if err := a.init(); err != nil {
+			return err
+		}
+	}
+
+	if a.API == apiJolokia {
+		return a.gatherJolokia(acc)
+	}
+	return a.gatherConsole(acc)
+}
+
+func (a *ActiveMQ) gatherConsole(acc telegraf.Accumulator) error {
+	dataQueues, err := a.get(a.consoleURL("queues.jsp"))
+	if err != nil {
+		return err
+	}
+	queues := Queues{}
+	err = xml.Unmarshal(dataQueues, &queues)
+	if err != nil {
+		return fmt.Errorf("queues XML unmarshal error: %s", err)
+	}
+
+	dataTopics, err := a.get(a.consoleURL("topics.jsp"))
+	if err != nil {
+		return err
+	}
+	topics := Topics{}
+	err = xml.Unmarshal(dataTopics, &topics)
+	if err != nil {
+		return fmt.Errorf("topics XML unmarshal error: %s", err)
+	}
+
+	dataSubscribers, err := a.get(a.consoleURL("subscribers.jsp"))
+	if err != nil {
+		return err
+	}
+	subscribers := Subscribers{}
+	err = xml.Unmarshal(dataSubscribers, &subscribers)
+	if err != nil {
+		return fmt.Errorf("subscribers XML unmarshal error: %s", err)
+	}
+
+	for _, queue := range queues.QueueItems {
+		tags := a.tags()
+		tags["name"] = strings.TrimSpace(queue.Name)
+		acc.AddFields("activemq_queues", map[string]interface{}{
+			"size":           queue.Stats.Size,
+			"consumer_count": queue.Stats.ConsumerCount,
+			"enqueue_count":  queue.Stats.EnqueueCount,
+			"dequeue_count":  queue.Stats.DequeueCount,
+		}, tags)
+	}
+
+	for _, topic := range topics.TopicItems {
+		tags := a.tags()
+		tags["name"] = strings.TrimSpace(topic.Name)
+		acc.AddFields("activemq_topics", map[string]interface{}{
+			"size":           topic.Stats.Size,
+			"consumer_count": topic.Stats.ConsumerCount,
+			"enqueue_count":  topic.Stats.EnqueueCount,
+			"dequeue_count":  topic.Stats.DequeueCount,
+		}, tags)
+	}
+
+	for _, subscriber := range subscribers.SubscriberItems {
+		tags := a.tags()
+		tags["client_id"] = subscriber.ClientId
+		tags["subscription_name"] = subscriber.SubscriptionName
+		tags["connection_id"] = subscriber.ConnectionId
+		tags["destination_name"] = subscriber.DestinationName
+		tags["selector"] = subscriber.Selector
+		tags["active"] = subscriber.Active
+		acc.AddFields("activemq_subscribers", map[string]interface{}{
+			"pending_queue_size":    subscriber.Stats.PendingQueueSize,
+			"dispatched_queue_size": subscriber.Stats.DispatchedQueueSize,
+			"dispatched_counter":    subscriber.Stats.DispatchedCounter,
+			"enqueue_counter":       subscriber.Stats.EnqueueCounter,
+			"dequeue_counter":       subscriber.Stats.DequeueCounter,
+		}, tags)
+	}
+
+	return nil
+}
+
+func (a *ActiveMQ) gatherJolokia(acc telegraf.Accumulator) error {
+	for _, d := range []struct {
+		measurement string
+		mbean       string
+	}{
+		{"activemq_queues", queuesMBean},
+		{"activemq_topics", topicsMBean},
+	} {
+		mbeans, err := a.readMBeans(d.mbean, destinationAttributes)
+		if err != nil {
+			return err
+		}
+		for _, attrs := range mbeans {
+			tags := a.tags()
+			tags["name"] = strings.TrimSpace(stringAttribute(attrs, "Name"))
+			acc.AddFields(d.measurement, map[string]interface{}{
+				"size":                 intAttribute(attrs, "QueueSize"),
+				"consumer_count":       intAttribute(attrs, "ConsumerCount"),
+				"enqueue_count":        intAttribute(attrs, "EnqueueCount"),
+				"dequeue_count":        intAttribute(attrs, "DequeueCount"),
+				"memory_percent_usage": intAttribute(attrs, "MemoryPercentUsage"),
+				"memory_usage":         intAttribute(attrs, "MemoryUsageByteCount"),
+			}, tags)
+		}
+	}
+
+	mbeans, err := a.readMBeans(subscribersMBean, subscriberAttributes)
+	if err != nil {
+		return err
+	}
+	for _, attrs := range mbeans {
+		tags := a.tags()
+		tags["client_id"] = stringAttribute(attrs, "ClientId")
+		tags["subscription_name"] = stringAttribute(attrs, "SubscriptionName")
+		tags["connection_id"] = stringAttribute(attrs, "ConnectionId")
+		tags["destination_name"] = stringAttribute(attrs, "DestinationName")
+		tags["selector"] = stringAttribute(attrs, "Selector")
+		tags["active"] = stringAttribute(attrs, "Active")
+		acc.AddFields("activemq_subscribers", map[string]interface{}{
+			"pending_queue_size":    intAttribute(attrs, "PendingQueueSize"),
+			"dispatched_queue_size": intAttribute(attrs, "DispatchedQueueSize"),
+			"dispatched_counter":    intAttribute(attrs, "DispatchedCounter"),
+			"enqueue_counter":       intAttribute(attrs, "EnqueueCounter"),
+			"dequeue_counter":       intAttribute(attrs, "DequeueCounter"),
+		}, tags)
+	}
+
+	return nil
+}
+
+// readMBeans reads the attributes of the MBeans matching the pattern.  A
+// pattern matching no MBean, such as the subscribers without consumers, is
+// answered with a 404 status by Jolokia.
+func (a *ActiveMQ) readMBeans(mbean string, attributes []string) (map[string]map[string]json.RawMessage, error) {
+	u := a.jolokiaURL(mbean, attributes)
+	data, err := a.get(u)
+	if err != nil {
+		return nil, err
+	}
+
+	var resp JolokiaResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("Jolokia JSON unmarshal error: %s", err)
+	}
+
+	switch resp.Status {
+	case http.StatusOK:
+		return resp.Value, nil
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("Jolokia read of %s returned status %d: %s", mbean, resp.Status, resp.Error)
+	}
+}
+
+func (a *ActiveMQ) consoleURL(page string) string {
+	u := *a.baseURL
+	u.Path = path.Join("/", a.Webadmin, "xml", page)
+	return u.String()
+}
+
+func (a *ActiveMQ) jolokiaURL(mbean string, attributes []string) string {
+	u := *a.baseURL
+	u.Path = path.Join("/api/jolokia/read", mbean, strings.Join(attributes, ","))
+	return u.String()
+}
+
+func (a *ActiveMQ) tags() map[string]string {
+	host, port, err := net.SplitHostPort(a.baseURL.Host)
+	if err != nil {
+		host = a.baseURL.Host
+		port = ""
+	}
+	return map[string]string{"source": host, "port": port}
+}
+
+func (a *ActiveMQ) get(u string) ([]byte, error) {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.Username != "" || a.Password != "" {
+		req.SetBasicAuth(a.Username, a.Password)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("GET %s returned status %q", u, resp.Status)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+func stringAttribute(attrs map[string]json.RawMessage, name string) string {
+	var s interface{}
+	if err := json.Unmarshal(attrs[name], &s); err != nil || s == nil {
+		return ""
+	}
+	return fmt.Sprint(s)
+}
+
+func intAttribute(attrs map[string]json.RawMessage, name string) int64 {
+	v, _ := strconv.ParseInt(string(attrs[name]), 10, 64)
+	return v
+}
+
+func init() {
+	inputs.Add("activemq", func() telegraf.Input {
+		return &ActiveMQ{
+			Webadmin:        "admin",
+			ResponseTimeout: internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
@@ -0,0 +1,221 @@
+package activemq
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const queuesXML = `<queues>
+<queue name="sandra">
+<stats size="0" consumerCount="0" enqueueCount="0" dequeueCount="0"/>
+<feed>
+<atom>queueBrowse/sandra?view=rss&amp;feedType=atom_1.0</atom>
+<rss>queueBrowse/sandra?view=rss&amp;feedType=rss_2.0</rss>
+</feed>
+</queue>
+<queue name="Test">
+<stats size="1" consumerCount="2" enqueueCount="3" dequeueCount="4"/>
+<feed>
+<atom>queueBrowse/Test?view=rss&amp;feedType=atom_1.0</atom>
+<rss>queueBrowse/Test?view=rss&amp;feedType=rss_2.0</rss>
+</feed>
+</queue>
+</queues>`
+
+const topicsXML = `<topics>
+<topic name="ActiveMQ.Advisory.MasterBroker ">
+<stats size="0" consumerCount="0" enqueueCount="1" dequeueCount="0"/>
+</topic>
+<topic name="AAA ">
+<stats size="0" consumerCount="1" enqueueCount="0" dequeueCount="0"/>
+</topic>
+</topics>`
+
+const subscribersXML = `<subscribers>
+<subscriber clientId="AAA" subscriptionName="AAA" connectionId="NOTSET" destinationName="AAA" selector="AA" active="no">
+<stats pendingQueueSize="0" dispatchedQueueSize="0" dispatchedCounter="0" enqueueCounter="0" dequeueCounter="0"/>
+</subscriber>
+</subscribers>`
+
+const jolokiaQueues = `{
+  "request": {"mbean": "org.apache.activemq:brokerName=*,destinationName=*,destinationType=Queue,type=Broker", "type": "read"},
+  "value": {
+    "org.apache.activemq:brokerName=localhost,destinationName=Test,destinationType=Queue,type=Broker": {
+      "Name": "Test",
+      "QueueSize": 1,
+      "ConsumerCount": 2,
+      "EnqueueCount": 3,
+      "DequeueCount": 4,
+      "MemoryPercentUsage": 5,
+      "MemoryUsageByteCount": 1024
+    }
+  },
+  "timestamp": 1531390385,
+  "status": 200
+}`
+
+const jolokiaTopics = `{
+  "request": {"mbean": "org.apache.activemq:brokerName=*,destinationName=*,destinationType=Topic,type=Broker", "type": "read"},
+  "value": {
+    "org.apache.activemq:brokerName=localhost,destinationName=AAA,destinationType=Topic,type=Broker": {
+      "Name": "AAA",
+      "QueueSize": 0,
+      "ConsumerCount": 1,
+      "EnqueueCount": 0,
+      "DequeueCount": 0,
+      "MemoryPercentUsage": 0,
+      "MemoryUsageByteCount": 0
+    }
+  },
+  "timestamp": 1531390385,
+  "status": 200
+}`
+
+const jolokiaNoSubscribers = `{
+  "request": {"type": "read"},
+  "error_type": "javax.management.InstanceNotFoundException",
+  "error": "javax.management.InstanceNotFoundException : No MBean found",
+  "status": 404
+}`
+
+func TestGatherConsole(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ := r.BasicAuth()
+		assert.Equal(t, "admin", username)
+		assert.Equal(t, "secret", password)
+
+		switch r.URL.Path {
+		case "/admin/xml/queues.jsp":
+			fmt.Fprint(w, queuesXML)
+		case "/admin/xml/topics.jsp":
+			fmt.Fprint(w, topicsXML)
+		case "/admin/xml/subscribers.jsp":
+			fmt.Fprint(w, subscribersXML)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	a := &ActiveMQ{
+		URL:      ts.URL,
+		Username: "admin",
+		Password: "secret",
+		Webadmin: "admin",
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(a.Gather))
+
+	host, port, err := net.SplitHostPort(ts.Listener.Addr().String())
+	require.NoError(t, err)
+
+	acc.AssertContainsTaggedFields(t, "activemq_queues",
+		map[string]interface{}{
+			"size":           1,
+			"consumer_count": 2,
+			"enqueue_count":  3,
+			"dequeue_count":  4,
+		},
+		map[string]string{"name": "Test", "source": host, "port": port})
+
+	acc.AssertContainsTaggedFields(t, "activemq_topics",
+		map[string]interface{}{
+			"size":           0,
+			"consumer_count": 1,
+			"enqueue_count":  0,
+			"dequeue_count":  0,
+		},
+		map[string]string{"name": "AAA", "source": host, "port": port})
+
+	acc.AssertContainsTaggedFields(t, "activemq_subscribers",
+		map[string]interface{}{
+			"pending_queue_size":    0,
+			"dispatched_queue_size": 0,
+			"dispatched_counter":    0,
+			"enqueue_counter":       0,
+			"dequeue_counter":       0,
+		},
+		map[string]string{
+			"client_id":         "AAA",
+			"subscription_name": "AAA",
+			"connection_id":     "NOTSET",
+			"destination_name":  "AAA",
+			"selector":          "AA",
+			"active":            "no",
+			"source":            host,
+			"port":              port,
+		})
+
+	assert.Len(t, acc.Metrics, 5)
+}
+
+func TestGatherJolokia(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case strings.Contains(r.URL.Path, "endpoint=Consumer"):
+			fmt.Fprint(w, jolokiaNoSubscribers)
+		case strings.Contains(r.URL.Path, "destinationType=Queue"):
+			fmt.Fprint(w, jolokiaQueues)
+		case strings.Contains(r.URL.Path, "destinationType=Topic"):
+			fmt.Fprint(w, jolokiaTopics)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	a := &ActiveMQ{
+		URL: ts.URL,
+		API: "jolokia",
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(a.Gather))
+
+	host, port, err := net.SplitHostPort(ts.Listener.Addr().String())
+	require.NoError(t, err)
+
+	acc.AssertContainsTaggedFields(t, "activemq_queues",
+		map[string]interface{}{
+			"size":                 int64(1),
+			"consumer_count":       int64(2),
+			"enqueue_count":        int64(3),
+			"dequeue_count":        int64(4),
+			"memory_percent_usage": int64(5),
+			"memory_usage":         int64(1024),
+		},
+		map[string]string{"name": "Test", "source": host, "port": port})
+
+	acc.AssertContainsTaggedFields(t, "activemq_topics",
+		map[string]interface{}{
+			"size":                 int64(0),
+			"consumer_count":       int64(1),
+			"enqueue_count":        int64(0),
+			"dequeue_count":        int64(0),
+			"memory_percent_usage": int64(0),
+			"memory_usage":         int64(0),
+		},
+		map[string]string{"name": "AAA", "source": host, "port": port})
+
+	assert.False(t, acc.HasMeasurement("activemq_subscribers"))
+}
+
+func TestInvalidAPI(t *testing.T) {
+	a := &ActiveMQ{
+		URL: "http://localhost:8161",
+		API: "rest",
+	}
+
+	var acc testutil.Accumulator
+	require.Error(t, acc.GatherError(a.Gather))
+}
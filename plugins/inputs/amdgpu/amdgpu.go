@@ -0,0 +1,234 @@
+package amdgpu
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+// default host sys path
+const defaultHostSys = "/sys"
+
+// env host sys variable name
+const envSys = "HOST_SYS"
+
+// PCI vendor ID of AMD
+const amdVendorID = "0x1002"
+
+type AMDGPU struct {
+	HostSys string   `toml:"host_sys"`
+	Cards   []string `toml:"cards"`
+}
+
+var sampleConfig = `
+  ## Sets 'sys' directory path
+  ## If not specified, then default is /sys
+  # host_sys = "/sys"
+
+  ## By default, telegraf gathers stats for all AMD GPUs handled by the
+  ## amdgpu driver. Setting cards will restrict the stats to the specified
+  ## DRM cards.
+  # cards = ["card0"]
+`
+
+func (a *AMDGPU) Description() string {
+	return "Read metrics about AMD GPUs from the amdgpu driver"
+}
+
+func (a *AMDGPU) SampleConfig() string {
+	return sampleConfig
+}
+
+func (a *AMDGPU) Gather(acc telegraf.Accumulator) error {
+	if a.HostSys == "" {
+		a.HostSys = sys(envSys, defaultHostSys)
+	}
+
+	cards, err := a.listCards()
+	if err != nil {
+		return err
+	}
+
+	for _, card := range cards {
+		if err := a.gatherCard(acc, card); err != nil {
+			acc.AddError(fmt.Errorf("error reading %s: %s", card, err))
+		}
+	}
+	return nil
+}
+
+// listCards returns the DRM cards driven by amdgpu, connectors such as
+// card0-DP-1 are skipped.
+func (a *AMDGPU) listCards() ([]string, error) {
+	if len(a.Cards) > 0 {
+		return a.Cards, nil
+	}
+
+	paths, err := filepath.Glob(filepath.Join(a.HostSys, "class", "drm", "card*"))
+	if err != nil {
+		return nil, err
+	}
+
+	var cards []string
+	for _, p := range paths {
+		card := filepath.Base(p)
+		if strings.Contains(card, "-") {
+			continue
+		}
+		vendor, err := readString(filepath.Join(p, "device", "vendor"))
+		if err != nil || vendor != amdVendorID {
+			continue
+		}
+		cards = append(cards, card)
+	}
+	return cards, nil
+}
+
+func (a *AMDGPU) gatherCard(acc telegraf.Accumulator, card string) error {
+	device := filepath.Join(a.HostSys, "class", "drm", card, "device")
+	if _, err := os.Stat(device); err != nil {
+		return err
+	}
+
+	tags := map[string]string{
+		"card": card,
+	}
+	if name, err := readString(filepath.Join(device, "product_name")); err == nil && name != "" {
+		tags["name"] = name
+	} else if id, err := readString(filepath.Join(device, "device")); err == nil {
+		tags["name"] = id
+	}
+	if pci, err := os.Readlink(device); err == nil {
+		tags["pci_bus"] = filepath.Base(pci)
+	}
+
+	fields := make(map[string]interface{})
+	setInt(fields, "utilization_gpu", filepath.Join(device, "gpu_busy_percent"))
+	setInt(fields, "utilization_memory", filepath.Join(device, "mem_busy_percent"))
+	setInt(fields, "memory_vram_total", filepath.Join(device, "mem_info_vram_total"))
+	setInt(fields, "memory_vram_used", filepath.Join(device, "mem_info_vram_used"))
+	setInt(fields, "memory_gtt_total", filepath.Join(device, "mem_info_gtt_total"))
+	setInt(fields, "memory_gtt_used", filepath.Join(device, "mem_info_gtt_used"))
+
+	if clock, err := currentClock(filepath.Join(device, "pp_dpm_sclk")); err == nil {
+		fields["clocks_current_sclk"] = clock
+	}
+	if clock, err := currentClock(filepath.Join(device, "pp_dpm_mclk")); err == nil {
+		fields["clocks_current_mclk"] = clock
+	}
+
+	hwmons, _ := filepath.Glob(filepath.Join(device, "hwmon", "hwmon*"))
+	for _, hwmon := range hwmons {
+		gatherHwmon(fields, hwmon)
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+	acc.AddFields("amdgpu", fields, tags)
+	return nil
+}
+
+// gatherHwmon reads the temperatures, fan and power of the GPU.
+func gatherHwmon(fields map[string]interface{}, hwmon string) {
+	temps, _ := filepath.Glob(filepath.Join(hwmon, "temp*_input"))
+	for _, temp := range temps {
+		value, err := readInt(temp)
+		if err != nil {
+			continue
+		}
+
+		label, err := readString(strings.TrimSuffix(temp, "_input") + "_label")
+		if err != nil || label == "" {
+			// Older kernels only report the edge temperature
+			label = "edge"
+		}
+		fields["temperature_"+strings.ToLower(label)] = float64(value) / 1000
+	}
+
+	if rpm, err := readInt(filepath.Join(hwmon, "fan1_input")); err == nil {
+		fields["fan_speed_rpm"] = rpm
+	}
+
+	if pwm, err := readInt(filepath.Join(hwmon, "pwm1")); err == nil {
+		pwmMax, err := readInt(filepath.Join(hwmon, "pwm1_max"))
+		if err != nil || pwmMax == 0 {
+			pwmMax = 255
+		}
+		fields["fan_speed"] = float64(pwm) * 100 / float64(pwmMax)
+	}
+
+	// Power is reported in microwatts
+	if power, err := readInt(filepath.Join(hwmon, "power1_average")); err == nil {
+		fields["power_draw"] = float64(power) / 1e6
+	}
+	if power, err := readInt(filepath.Join(hwmon, "power1_cap")); err == nil {
+		fields["power_limit"] = float64(power) / 1e6
+	}
+}
+
+// currentClock returns the current frequency in MHz from a power play
+// table, where the current level is marked with a star: "1: 1138Mhz *".
+func currentClock(path string) (int64, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		if !strings.HasSuffix(strings.TrimSpace(line), "*") {
+			continue
+		}
+		parts := strings.Fields(line)
+		if len(parts) < 2 {
+			break
+		}
+		mhz := strings.TrimSuffix(strings.ToLower(parts[1]), "mhz")
+		return strconv.ParseInt(mhz, 10, 64)
+	}
+	return 0, fmt.Errorf("no current level in %s", path)
+}
+
+func setInt(fields map[string]interface{}, key, path string) {
+	if value, err := readInt(path); err == nil {
+		fields[key] = value
+	}
+}
+
+func readString(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func readInt(path string) (int64, error) {
+	value, err := readString(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
+
+// sys can be used to read file paths from env
+func sys(env, path string) string {
+	// try to read full file path
+	if p := os.Getenv(env); p != "" {
+		return p
+	}
+	// return default path
+	return path
+}
+
+func init() {
+	inputs.Add("amdgpu", func() telegraf.Input {
+		return &AMDGPU{}
+	})
+}
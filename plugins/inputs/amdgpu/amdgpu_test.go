@@ -0,0 +1,109 @@
+package amdgpu
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeSysfs(t *testing.T) (string, func()) {
+	dir, cleanup := testutil.TempDir(t, "amdgpu")
+
+	testutil.WriteFiles(t, filepath.Join(dir, "devices", "pci0000:00", "0000:03:00.0"), map[string]string{
+		"vendor":              "0x1002\n",
+		"device":              "0x687f\n",
+		"product_name":        "Radeon RX Vega\n",
+		"gpu_busy_percent":    "42\n",
+		"mem_busy_percent":    "7\n",
+		"mem_info_vram_total": "8573157376\n",
+		"mem_info_vram_used":  "1342177280\n",
+		"pp_dpm_sclk":         "0: 852Mhz \n1: 991Mhz \n2: 1138Mhz *\n3: 1269Mhz \n",
+		"pp_dpm_mclk":         "0: 167Mhz \n1: 500Mhz \n2: 945Mhz *\n",
+
+		"hwmon/hwmon2/temp1_input":    "45000\n",
+		"hwmon/hwmon2/temp1_label":    "edge\n",
+		"hwmon/hwmon2/temp2_input":    "52500\n",
+		"hwmon/hwmon2/temp2_label":    "junction\n",
+		"hwmon/hwmon2/fan1_input":     "1450\n",
+		"hwmon/hwmon2/pwm1":           "102\n",
+		"hwmon/hwmon2/pwm1_max":       "255\n",
+		"hwmon/hwmon2/power1_average": "95000000\n",
+		"hwmon/hwmon2/power1_cap":     "220000000\n",
+	})
+
+	// An Intel GPU handled by i915
+	testutil.WriteFiles(t, filepath.Join(dir, "devices", "pci0000:00", "0000:00:02.0"), map[string]string{
+		"vendor": "0x8086\n",
+	})
+
+	drm := filepath.Join(dir, "class", "drm")
+	require.NoError(t, os.MkdirAll(filepath.Join(drm, "card0-DP-1"), 0755))
+	for card, pci := range map[string]string{"card0": "0000:03:00.0", "card1": "0000:00:02.0"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(drm, card), 0755))
+		require.NoError(t, os.Symlink(
+			filepath.Join(dir, "devices", "pci0000:00", pci),
+			filepath.Join(drm, card, "device")))
+	}
+
+	return dir, cleanup
+}
+
+func TestGather(t *testing.T) {
+	dir, cleanup := makeSysfs(t)
+	defer cleanup()
+
+	a := &AMDGPU{HostSys: dir}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(a.Gather))
+
+	require.Len(t, acc.Metrics, 1)
+	acc.AssertContainsTaggedFields(t, "amdgpu",
+		map[string]interface{}{
+			"utilization_gpu":      int64(42),
+			"utilization_memory":   int64(7),
+			"memory_vram_total":    int64(8573157376),
+			"memory_vram_used":     int64(1342177280),
+			"clocks_current_sclk":  int64(1138),
+			"clocks_current_mclk":  int64(945),
+			"temperature_edge":     45.0,
+			"temperature_junction": 52.5,
+			"fan_speed_rpm":        int64(1450),
+			"fan_speed":            40.0,
+			"power_draw":           95.0,
+			"power_limit":          220.0,
+		},
+		map[string]string{
+			"card":    "card0",
+			"name":    "Radeon RX Vega",
+			"pci_bus": "0000:03:00.0",
+		})
+}
+
+func TestGatherCards(t *testing.T) {
+	dir, cleanup := makeSysfs(t)
+	defer cleanup()
+
+	a := &AMDGPU{HostSys: dir, Cards: []string{"card2"}}
+
+	var acc testutil.Accumulator
+	require.NoError(t, a.Gather(&acc))
+	assert.Len(t, acc.Metrics, 0)
+	assert.Len(t, acc.Errors, 1)
+}
+
+func TestCurrentClock(t *testing.T) {
+	dir, cleanup := testutil.TempDir(t, "amdgpu")
+	defer cleanup()
+
+	testutil.WriteFiles(t, dir, map[string]string{
+		"pp_dpm_sclk": "0: 300Mhz\n1: 1000Mhz\n",
+	})
+
+	_, err := currentClock(filepath.Join(dir, "pp_dpm_sclk"))
+	assert.Error(t, err)
+}
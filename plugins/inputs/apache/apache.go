@@ -3,9 +3,11 @@ package apache
 import (
 	"bufio"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
 	"sync"
@@ -22,6 +24,7 @@ type Apache struct {
 	Username        string
 	Password        string
 	ResponseTimeout internal.Duration
+	GatherVhosts    bool `toml:"gather_vhosts"`
 	tls.ClientConfig
 
 	client *http.Client
@@ -40,6 +43,10 @@ var sampleConfig = `
   ## Maximum time to receive response.
   # response_timeout = "5s"
 
+  ## Gather the state of the workers per virtual host, from the worker table
+  ## of the human readable status page, which requires ExtendedStatus On.
+  # gather_vhosts = false
+
   ## Optional TLS Config
   # tls_ca = "/etc/telegraf/ca.pem"
   # tls_cert = "/etc/telegraf/cert.pem"
@@ -108,10 +115,10 @@ func (n *Apache) createHttpClient() (*http.Client, error) {
 	return client, nil
 }
 
-func (n *Apache) gatherUrl(addr *url.URL, acc telegraf.Accumulator) error {
+func (n *Apache) get(addr *url.URL) (*http.Response, error) {
 	req, err := http.NewRequest("GET", addr.String(), nil)
 	if err != nil {
-		return fmt.Errorf("error on new request to %s : %s\n", addr.String(), err)
+		return nil, fmt.Errorf("error on new request to %s : %s\n", addr.String(), err)
 	}
 
 	if len(n.Username) != 0 && len(n.Password) != 0 {
@@ -120,13 +127,22 @@ func (n *Apache) gatherUrl(addr *url.URL, acc telegraf.Accumulator) error {
 
 	resp, err := n.client.Do(req)
 	if err != nil {
-		return fmt.Errorf("error on request to %s : %s\n", addr.String(), err)
+		return nil, fmt.Errorf("error on request to %s : %s\n", addr.String(), err)
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("%s returned HTTP status %s", addr.String(), resp.Status)
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s returned HTTP status %s", addr.String(), resp.Status)
+	}
+	return resp, nil
+}
+
+func (n *Apache) gatherUrl(addr *url.URL, acc telegraf.Accumulator) error {
+	resp, err := n.get(addr)
+	if err != nil {
+		return err
 	}
+	defer resp.Body.Close()
 
 	tags := getTags(addr)
 
@@ -154,6 +170,87 @@ func (n *Apache) gatherUrl(addr *url.URL, acc telegraf.Accumulator) error {
 	}
 	acc.AddFields("apache", fields, tags)
 
+	if n.GatherVhosts {
+		return n.gatherVhosts(addr, acc)
+	}
+	return nil
+}
+
+var (
+	tableRowRe  = regexp.MustCompile(`(?is)<tr[^>]*>(.*?)</tr>`)
+	tableCellRe = regexp.MustCompile(`(?is)<t[dh][^>]*>(.*?)</t[dh]>`)
+	htmlTagRe   = regexp.MustCompile(`<[^>]*>`)
+)
+
+// gatherVhosts reads the worker table of the human readable status page,
+// the URL without the auto query string, and counts the workers of each
+// virtual host by mode of operation, the virtual host of an idle worker
+// being the one of its last request.
+func (n *Apache) gatherVhosts(addr *url.URL, acc telegraf.Accumulator) error {
+	htmlAddr := *addr
+	query := htmlAddr.Query()
+	query.Del("auto")
+	htmlAddr.RawQuery = query.Encode()
+
+	resp, err := n.get(&htmlAddr)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	modeCol, vhostCol := -1, -1
+	modes := make(map[string]string)
+	for _, row := range tableRowRe.FindAllStringSubmatch(string(body), -1) {
+		var cells []string
+		for _, cell := range tableCellRe.FindAllStringSubmatch(row[1], -1) {
+			cells = append(cells, strings.TrimSpace(htmlTagRe.ReplaceAllString(cell[1], "")))
+		}
+
+		if vhostCol < 0 {
+			// Header of the worker table
+			for i, cell := range cells {
+				switch cell {
+				case "M":
+					modeCol = i
+				case "VHost":
+					vhostCol = i
+				}
+			}
+			if modeCol < 0 || vhostCol < 0 {
+				modeCol, vhostCol = -1, -1
+			}
+			continue
+		}
+
+		if len(cells) <= vhostCol || len(cells) <= modeCol {
+			// End of the worker table
+			break
+		}
+
+		vhost := cells[vhostCol]
+		if vhost == "" {
+			continue
+		}
+		modes[vhost] += cells[modeCol]
+	}
+
+	if vhostCol < 0 {
+		return fmt.Errorf("%s has no worker table, ExtendedStatus might be off", htmlAddr.String())
+	}
+
+	for vhost, mode := range modes {
+		tags := getTags(addr)
+		tags["vhost"] = vhost
+
+		fields := n.gatherScores(mode)
+		fields["workers"] = float64(len(mode))
+		acc.AddFields("apache_vhost", fields, tags)
+	}
 	return nil
 }
 
@@ -2,6 +2,7 @@ package apache
 
 import (
 	"fmt"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -72,3 +73,123 @@ func TestHTTPApache(t *testing.T) {
 	}
 	acc.AssertContainsFields(t, "apache", fields)
 }
+
+var apacheStatusHTML = `<html><head>
+<title>Apache Status</title>
+</head><body>
+<h1>Apache Server Status for localhost (via 127.0.0.1)</h1>
+<pre>_W__K...........................................................</pre>
+<table border="0"><tr><th>Srv</th><th>PID</th><th>Acc</th><th>M</th><th>CPU
+</th><th>SS</th><th>Req</th><th>Dur</th><th>Conn</th><th>Child</th><th>Slot</th><th>Client</th><th>Protocol</th><th>VHost</th><th>Request</th></tr>
+
+<tr><td><b>0-0</b></td><td>1234</td><td>0/12/12</td><td>_
+</td><td>0.01</td><td>3</td><td>0</td><td>4</td><td>0.0</td><td>0.02</td><td>0.02
+</td><td>127.0.0.1</td><td>http/1.1</td><td nowrap>www.example.com:80</td><td nowrap>GET / HTTP/1.1</td></tr>
+
+<tr><td><b>0-0</b></td><td>1234</td><td>1/3/3</td><td><b>W</b>
+</td><td>0.00</td><td>0</td><td>0</td><td>0</td><td>0.0</td><td>0.01</td><td>0.01
+</td><td>127.0.0.1</td><td>http/1.1</td><td nowrap>localhost:80</td><td nowrap>GET /server-status HTTP/1.1</td></tr>
+
+<tr><td><b>0-0</b></td><td>1234</td><td>0/7/7</td><td>_
+</td><td>0.00</td><td>12</td><td>1</td><td>1</td><td>0.0</td><td>0.01</td><td>0.01
+</td><td>127.0.0.1</td><td>http/1.1</td><td nowrap>www.example.com:80</td><td nowrap>GET /index.html HTTP/1.1</td></tr>
+
+<tr><td><b>0-0</b></td><td>1234</td><td>1/1/1</td><td><b>K</b>
+</td><td>0.00</td><td>1</td><td>0</td><td>0</td><td>0.0</td><td>0.00</td><td>0.00
+</td><td>127.0.0.1</td><td>http/1.1</td><td nowrap>www.example.com:80</td><td nowrap>GET /favicon.ico HTTP/1.1</td></tr>
+
+</table>
+<hr /> <table>
+ <tr><th>Srv</th><td>Child Server number - generation</td></tr>
+ <tr><th>PID</th><td>OS process ID</td></tr>
+</table>
+</body></html>
+`
+
+func TestHTTPApacheVhosts(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ := r.BasicAuth()
+		if username != "user" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		if _, ok := r.URL.Query()["auto"]; ok {
+			fmt.Fprint(w, apacheStatus)
+		} else {
+			fmt.Fprint(w, apacheStatusHTML)
+		}
+	}))
+	defer ts.Close()
+
+	a := Apache{
+		Urls:         []string{ts.URL + "/server-status?auto"},
+		Username:     "user",
+		Password:     "secret",
+		GatherVhosts: true,
+	}
+
+	var acc testutil.Accumulator
+	err := acc.GatherError(a.Gather)
+	require.NoError(t, err)
+
+	host, port, err := net.SplitHostPort(ts.Listener.Addr().String())
+	require.NoError(t, err)
+
+	acc.AssertContainsTaggedFields(t, "apache_vhost",
+		map[string]interface{}{
+			"workers":              float64(3),
+			"scboard_waiting":      float64(2),
+			"scboard_starting":     float64(0),
+			"scboard_reading":      float64(0),
+			"scboard_sending":      float64(0),
+			"scboard_keepalive":    float64(1),
+			"scboard_dnslookup":    float64(0),
+			"scboard_closing":      float64(0),
+			"scboard_logging":      float64(0),
+			"scboard_finishing":    float64(0),
+			"scboard_idle_cleanup": float64(0),
+			"scboard_open":         float64(0),
+		},
+		map[string]string{"server": host, "port": port, "vhost": "www.example.com:80"})
+
+	acc.AssertContainsTaggedFields(t, "apache_vhost",
+		map[string]interface{}{
+			"workers":              float64(1),
+			"scboard_waiting":      float64(0),
+			"scboard_starting":     float64(0),
+			"scboard_reading":      float64(0),
+			"scboard_sending":      float64(1),
+			"scboard_keepalive":    float64(0),
+			"scboard_dnslookup":    float64(0),
+			"scboard_closing":      float64(0),
+			"scboard_logging":      float64(0),
+			"scboard_finishing":    float64(0),
+			"scboard_idle_cleanup": float64(0),
+			"scboard_open":         float64(0),
+		},
+		map[string]string{"server": host, "port": port, "vhost": "localhost:80"})
+}
+
+func TestHTTPApacheVhostsExtendedStatusOff(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		if _, ok := r.URL.Query()["auto"]; ok {
+			fmt.Fprint(w, apacheStatus)
+		} else {
+			fmt.Fprint(w, "<html><body><pre>_W__K</pre></body></html>")
+		}
+	}))
+	defer ts.Close()
+
+	a := Apache{
+		Urls:         []string{ts.URL + "/server-status?auto"},
+		GatherVhosts: true,
+	}
+
+	var acc testutil.Accumulator
+	err := acc.GatherError(a.Gather)
+	require.Error(t, err)
+	require.True(t, acc.HasMeasurement("apache"))
+}
@@ -0,0 +1,196 @@
+package apcupsd
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const defaultAddress = "127.0.0.1:3551"
+
+// The keys of the status gathered as fields by default, and the name and the
+// scale of their field.
+var defaultKeys = map[string]struct {
+	field string
+	scale float64
+}{
+	"BCHARGE":   {"battery_charge_percent", 1},
+	"TIMELEFT":  {"battery_runtime", 60},
+	"BATTV":     {"battery_voltage", 1},
+	"LINEV":     {"input_voltage", 1},
+	"LINEFREQ":  {"input_frequency", 1},
+	"OUTPUTV":   {"output_voltage", 1},
+	"LOADPCT":   {"load_percent", 1},
+	"NOMPOWER":  {"nominal_power", 1},
+	"ITEMP":     {"internal_temp", 1},
+	"TONBATT":   {"time_on_battery", 1},
+	"CUMONBATT": {"cumulative_time_on_battery", 1},
+}
+
+type Apcupsd struct {
+	Servers   []string
+	Timeout   internal.Duration
+	Variables []string
+
+	variableFilter filter.Filter
+	initialized    bool
+}
+
+var sampleConfig = `
+  ## apcupsd NIS servers to connect to, as "host:port".
+  # servers = ["127.0.0.1:3551"]
+
+  ## Timeout of the queries to a server.
+  # timeout = "5s"
+
+  ## Additional keys of the status to gather as fields, such as "BATTDATE"
+  ## or "LASTXFER", globs are supported.  The field names are the keys in
+  ## lower case.
+  # variables = []
+`
+
+func (a *Apcupsd) Description() string {
+	return "Monitor APC UPS using the NIS server of apcupsd"
+}
+
+func (a *Apcupsd) SampleConfig() string {
+	return sampleConfig
+}
+
+func (a *Apcupsd) Gather(acc telegraf.Accumulator) error {
+	if !a.initialized {
+		var err error
+		if a.variableFilter, err = filter.Compile(a.Variables); err != nil {
+			return err
+		}
+		a.initialized = true
+	}
+
+	servers := a.Servers
+	if len(servers) == 0 {
+		servers = []string{defaultAddress}
+	}
+
+	var wg sync.WaitGroup
+	for _, server := range servers {
+		wg.Add(1)
+		go func(server string) {
+			defer wg.Done()
+			status, err := fetchStatus(server, a.Timeout.Duration)
+			if err != nil {
+				acc.AddError(fmt.Errorf("apcupsd %s: %s", server, err))
+				return
+			}
+			a.addStatus(acc, server, status)
+		}(server)
+	}
+	wg.Wait()
+	return nil
+}
+
+func (a *Apcupsd) addStatus(acc telegraf.Accumulator, server string, status map[string]string) {
+	tags := map[string]string{"server": server}
+	for key, tag := range map[string]string{"UPSNAME": "ups_name", "MODEL": "model", "SERIALNO": "serial"} {
+		if value := status[key]; value != "" {
+			tags[tag] = value
+		}
+	}
+
+	fields := make(map[string]interface{})
+	for key, value := range status {
+		if a.variableFilter != nil && a.variableFilter.Match(key) {
+			if v, err := parseNumber(value); err == nil {
+				fields[strings.ToLower(key)] = v
+			} else {
+				fields[strings.ToLower(key)] = value
+			}
+		}
+	}
+	for key, f := range defaultKeys {
+		if v, err := parseNumber(status[key]); err == nil {
+			fields[f.field] = v * f.scale
+		}
+	}
+	if v, ok := status["STATUS"]; ok {
+		fields["ups_status"] = v
+	}
+	if v, err := strconv.ParseUint(strings.TrimPrefix(status["STATFLAG"], "0x"), 16, 64); err == nil {
+		fields["status_flags"] = v
+	}
+	if v, err := strconv.ParseInt(status["NUMXFERS"], 10, 64); err == nil {
+		fields["transfers"] = v
+	}
+
+	acc.AddFields("apcupsd", fields, tags)
+}
+
+// parseNumber parses the number of a value followed by its unit, such as
+// "230.0 Volts".
+func parseNumber(value string) (float64, error) {
+	if i := strings.IndexByte(value, ' '); i != -1 {
+		value = value[:i]
+	}
+	return strconv.ParseFloat(value, 64)
+}
+
+// fetchStatus sends the status command to the NIS server, and returns the
+// keys and values of the status.  The command and the lines of the response
+// are prefixed by their length as a 16 bits integer, the response ending
+// with an empty line.
+func fetchStatus(address string, timeout time.Duration) (map[string]string, error) {
+	c, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+	if timeout > 0 {
+		c.SetDeadline(time.Now().Add(timeout))
+	}
+
+	command := []byte{0, 6, 's', 't', 'a', 't', 'u', 's'}
+	if _, err := c.Write(command); err != nil {
+		return nil, err
+	}
+
+	status := make(map[string]string)
+	r := bufio.NewReader(c)
+	for {
+		var length uint16
+		if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+			return nil, err
+		}
+		if length == 0 {
+			return status, nil
+		}
+
+		line := make([]byte, length)
+		if _, err := io.ReadFull(r, line); err != nil {
+			return nil, err
+		}
+		// APC      : 001,036,0879
+		parts := strings.SplitN(string(line), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		status[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+}
+
+func init() {
+	inputs.Add("apcupsd", func() telegraf.Input {
+		return &Apcupsd{
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
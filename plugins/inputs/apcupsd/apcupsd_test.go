@@ -0,0 +1,115 @@
+package apcupsd
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var status = []string{
+	"APC      : 001,036,0879\n",
+	"DATE     : 2018-07-12 10:25:04 +0200  \n",
+	"HOSTNAME : server01\n",
+	"UPSNAME  : rack\n",
+	"MODEL    : Smart-UPS 1500 \n",
+	"STATUS   : ONLINE \n",
+	"LINEV    : 231.0 Volts\n",
+	"LOADPCT  : 17.0 Percent\n",
+	"BCHARGE  : 100.0 Percent\n",
+	"TIMELEFT : 32.5 Minutes\n",
+	"OUTPUTV  : 230.0 Volts\n",
+	"ITEMP    : 29.2 C\n",
+	"BATTV    : 27.3 Volts\n",
+	"LINEFREQ : 50.0 Hz\n",
+	"LASTXFER : Automatic or explicit self test\n",
+	"NUMXFERS : 2\n",
+	"TONBATT  : 0 Seconds\n",
+	"CUMONBATT: 24 Seconds\n",
+	"BATTDATE : 2017-05-24\n",
+	"NOMPOWER : 980 Watts\n",
+	"STATFLAG : 0x05000008\n",
+	"SERIALNO : AS1234567890\n",
+	"END APC  : 2018-07-12 10:25:43 +0200  \n",
+}
+
+// serve answers the status command of a connection.
+func serve(t *testing.T, lines []string) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+
+		command := make([]byte, 8)
+		if _, err := io.ReadFull(c, command); err != nil || string(command[2:]) != "status" {
+			return
+		}
+		for _, line := range lines {
+			binary.Write(c, binary.BigEndian, uint16(len(line)))
+			c.Write([]byte(line))
+		}
+		c.Write([]byte{0, 0})
+	}()
+	return l
+}
+
+func TestGather(t *testing.T) {
+	l := serve(t, status)
+	defer l.Close()
+
+	a := &Apcupsd{
+		Servers:   []string{l.Addr().String()},
+		Variables: []string{"LASTXFER", "BATT*"},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(a.Gather))
+
+	acc.AssertContainsTaggedFields(t, "apcupsd",
+		map[string]interface{}{
+			"battery_charge_percent":     100.0,
+			"battery_runtime":            1950.0,
+			"battery_voltage":            27.3,
+			"input_voltage":              231.0,
+			"input_frequency":            50.0,
+			"output_voltage":             230.0,
+			"load_percent":               17.0,
+			"nominal_power":              980.0,
+			"internal_temp":              29.2,
+			"time_on_battery":            0.0,
+			"cumulative_time_on_battery": 24.0,
+			"ups_status":                 "ONLINE",
+			"status_flags":               uint64(0x05000008),
+			"transfers":                  int64(2),
+			"lastxfer":                   "Automatic or explicit self test",
+			"battdate":                   "2017-05-24",
+			"battv":                      27.3,
+		},
+		map[string]string{
+			"server":   l.Addr().String(),
+			"ups_name": "rack",
+			"model":    "Smart-UPS 1500",
+			"serial":   "AS1234567890",
+		})
+}
+
+func TestGather_error(t *testing.T) {
+	l := serve(t, []string{"UPSNAME  : rack\n"})
+	addr := l.Addr().String()
+	l.Close()
+
+	a := &Apcupsd{Servers: []string{addr}}
+
+	var acc testutil.Accumulator
+	assert.Error(t, acc.GatherError(a.Gather))
+	assert.Empty(t, acc.Metrics)
+}
@@ -0,0 +1,187 @@
+package beanstalkd
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"gopkg.in/yaml.v2"
+)
+
+const sampleConfig = `
+  ## Server to collect data from
+  server = "localhost:11300"
+
+  ## List of tubes to gather stats about.
+  ## If no tubes specified then data gathered for each tube on server reported by list-tubes command
+  tubes = ["notifications"]
+
+  ## Maximum time to wait for a response of the server
+  # timeout = "5s"
+`
+
+// Beanstalkd gathers the stats of a beanstalkd server and of its tubes
+type Beanstalkd struct {
+	Server  string            `toml:"server"`
+	Tubes   []string          `toml:"tubes"`
+	Timeout internal.Duration `toml:"timeout"`
+}
+
+func (b *Beanstalkd) Description() string {
+	return "Collects Beanstalkd server and tubes stats"
+}
+
+func (b *Beanstalkd) SampleConfig() string {
+	return sampleConfig
+}
+
+func (b *Beanstalkd) Gather(acc telegraf.Accumulator) error {
+	conn, err := net.DialTimeout("tcp", b.Server, b.Timeout.Duration)
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(b.Timeout.Duration))
+
+	connection := textproto.NewConn(conn)
+	defer connection.Close()
+
+	tubes := b.Tubes
+	if len(tubes) == 0 {
+		err = runQuery(connection, "list-tubes", &tubes)
+		if err != nil {
+			acc.AddError(err)
+		}
+	}
+
+	var wg sync.WaitGroup
+
+	wg.Add(1)
+	go func() {
+		err := b.gatherServerStats(connection, acc)
+		if err != nil {
+			acc.AddError(err)
+		}
+		wg.Done()
+	}()
+
+	for _, tube := range tubes {
+		wg.Add(1)
+		go func(tube string) {
+			b.gatherTubeStats(connection, tube, acc)
+			wg.Done()
+		}(tube)
+	}
+
+	wg.Wait()
+
+	return nil
+}
+
+func (b *Beanstalkd) gatherServerStats(connection *textproto.Conn, acc telegraf.Accumulator) error {
+	stats := make(map[string]interface{})
+	err := runQuery(connection, "stats", &stats)
+	if err != nil {
+		return err
+	}
+
+	tags := map[string]string{
+		"server":  b.Server,
+		"version": fmt.Sprint(stats["version"]),
+	}
+	acc.AddFields("beanstalkd_overview", numericFields(stats), tags)
+	return nil
+}
+
+func (b *Beanstalkd) gatherTubeStats(connection *textproto.Conn, tube string, acc telegraf.Accumulator) {
+	stats := make(map[string]interface{})
+	err := runQuery(connection, "stats-tube "+tube, &stats)
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+
+	tags := map[string]string{
+		"server": b.Server,
+		"name":   tube,
+	}
+	acc.AddFields("beanstalkd_tube", numericFields(stats), tags)
+}
+
+// runQuery sends a command and decodes the YAML body of its OK response.
+// The requests and the responses are serialized by the pipeline of the
+// connection, so commands can be run concurrently.
+func runQuery(connection *textproto.Conn, cmd string, result interface{}) error {
+	requestID, err := connection.Cmd("%s", cmd)
+	if err != nil {
+		return err
+	}
+
+	connection.StartResponse(requestID)
+	defer connection.EndResponse(requestID)
+
+	status, err := connection.ReadLine()
+	if err != nil {
+		return err
+	}
+
+	size := 0
+	if _, err = fmt.Sscanf(status, "OK %d", &size); err != nil {
+		return fmt.Errorf("%s command failed: %s", cmd, status)
+	}
+
+	body := make([]byte, size+2) // the body is followed by \r\n
+	if _, err = io.ReadFull(connection.R, body); err != nil {
+		return err
+	}
+
+	return yaml.Unmarshal(body[:size], result)
+}
+
+// Stats which are not gathered as fields, the version may look like a number
+var skipStats = map[string]bool{
+	"name":     true,
+	"version":  true,
+	"id":       true,
+	"hostname": true,
+	"os":       true,
+	"platform": true,
+}
+
+// numericFields returns the numeric stats, with the dashes of their names
+// replaced by underscores
+func numericFields(stats map[string]interface{}) map[string]interface{} {
+	fields := make(map[string]interface{})
+	for key, value := range stats {
+		if skipStats[key] {
+			continue
+		}
+		name := strings.Replace(key, "-", "_", -1)
+		switch v := value.(type) {
+		case int:
+			fields[name] = int64(v)
+		case int64:
+			fields[name] = v
+		case uint64:
+			fields[name] = v
+		case float64:
+			fields[name] = v
+		}
+	}
+	return fields
+}
+
+func init() {
+	inputs.Add("beanstalkd", func() telegraf.Input {
+		return &Beanstalkd{
+			Server:  "localhost:11300",
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
@@ -0,0 +1,206 @@
+package beanstalkd
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBeanstalkd(t *testing.T) {
+	tests := []struct {
+		name           string
+		tubes          []string
+		expectedTubes  []string
+		expectedErrors int
+	}{
+		{
+			name:          "all tubes",
+			expectedTubes: []string{"default", "test"},
+		},
+		{
+			name:          "specified tubes",
+			tubes:         []string{"test"},
+			expectedTubes: []string{"test"},
+		},
+		{
+			name:           "unknown tube",
+			tubes:          []string{"unknown"},
+			expectedErrors: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, err := startTestServer(t)
+			require.NoError(t, err)
+			defer server.Close()
+
+			plugin := &Beanstalkd{
+				Server:  server.Addr().String(),
+				Tubes:   tt.tubes,
+				Timeout: internal.Duration{Duration: 5 * time.Second},
+			}
+
+			var acc testutil.Accumulator
+			require.NoError(t, plugin.Gather(&acc))
+			require.Len(t, acc.Errors, tt.expectedErrors)
+
+			acc.AssertContainsTaggedFields(t, "beanstalkd_overview",
+				overviewFields,
+				map[string]string{
+					"server":  server.Addr().String(),
+					"version": "1.10",
+				})
+
+			for _, tube := range tt.expectedTubes {
+				acc.AssertContainsTaggedFields(t, "beanstalkd_tube",
+					tubeFields,
+					map[string]string{
+						"server": server.Addr().String(),
+						"name":   tube,
+					})
+			}
+			require.Len(t, acc.Metrics, 1+len(tt.expectedTubes))
+		})
+	}
+}
+
+// startTestServer answers the commands of a connection with the responses
+func startTestServer(t *testing.T) (net.Listener, error) {
+	server, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		conn, err := server.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			body, ok := responses[strings.TrimSpace(line)]
+			if !ok {
+				fmt.Fprint(conn, "NOT_FOUND\r\n")
+				continue
+			}
+			fmt.Fprintf(conn, "OK %d\r\n%s\r\n", len(body), body)
+		}
+	}()
+
+	return server, nil
+}
+
+var responses = map[string]string{
+	"list-tubes":         listTubesResponse,
+	"stats":              statsResponse,
+	"stats-tube default": statsTubeResponse("default"),
+	"stats-tube test":    statsTubeResponse("test"),
+}
+
+const listTubesResponse = `---
+- default
+- test
+`
+
+const statsResponse = `---
+current-jobs-urgent: 5
+current-jobs-ready: 5
+current-jobs-reserved: 0
+current-jobs-delayed: 1
+current-jobs-buried: 0
+cmd-put: 6
+cmd-reserve: 0
+cmd-delete: 1
+job-timeouts: 0
+total-jobs: 6
+max-job-size: 65535
+current-tubes: 2
+current-connections: 2
+current-producers: 0
+current-workers: 1
+current-waiting: 1
+total-connections: 2
+pid: 6
+version: "1.10"
+rusage-utime: 0.000000
+rusage-stime: 0.000000
+uptime: 20
+binlog-current-index: 0
+id: bba7546657efdd4c
+hostname: 2873efd3e88c
+`
+
+func statsTubeResponse(name string) string {
+	return `---
+name: ` + name + `
+current-jobs-urgent: 5
+current-jobs-ready: 5
+current-jobs-reserved: 0
+current-jobs-delayed: 1
+current-jobs-buried: 0
+total-jobs: 6
+current-using: 2
+current-watching: 2
+current-waiting: 1
+cmd-delete: 1
+cmd-pause-tube: 0
+pause: 0
+pause-time-left: 0
+`
+}
+
+var overviewFields = map[string]interface{}{
+	"current_jobs_urgent":   int64(5),
+	"current_jobs_ready":    int64(5),
+	"current_jobs_reserved": int64(0),
+	"current_jobs_delayed":  int64(1),
+	"current_jobs_buried":   int64(0),
+	"cmd_put":               int64(6),
+	"cmd_reserve":           int64(0),
+	"cmd_delete":            int64(1),
+	"job_timeouts":          int64(0),
+	"total_jobs":            int64(6),
+	"max_job_size":          int64(65535),
+	"current_tubes":         int64(2),
+	"current_connections":   int64(2),
+	"current_producers":     int64(0),
+	"current_workers":       int64(1),
+	"current_waiting":       int64(1),
+	"total_connections":     int64(2),
+	"pid":                   int64(6),
+	"rusage_utime":          float64(0),
+	"rusage_stime":          float64(0),
+	"uptime":                int64(20),
+	"binlog_current_index":  int64(0),
+}
+
+var tubeFields = map[string]interface{}{
+	"current_jobs_urgent":   int64(5),
+	"current_jobs_ready":    int64(5),
+	"current_jobs_reserved": int64(0),
+	"current_jobs_delayed":  int64(1),
+	"current_jobs_buried":   int64(0),
+	"total_jobs":            int64(6),
+	"current_using":         int64(2),
+	"current_watching":      int64(2),
+	"current_waiting":       int64(1),
+	"cmd_delete":            int64(1),
+	"cmd_pause_tube":        int64(0),
+	"pause":                 int64(0),
+	"pause_time_left":       int64(0),
+}
@@ -0,0 +1,210 @@
+package bind
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+type Bind struct {
+	Urls                 []string
+	GatherMemory         bool `toml:"gather_memory"`
+	GatherMemoryContexts bool `toml:"gather_memory_contexts"`
+	GatherViews          bool `toml:"gather_views"`
+	Timeout              internal.Duration
+
+	client *http.Client
+}
+
+var sampleConfig = `
+  ## An array of BIND XML statistics URI to gather stats.
+  ## The format is selected by the path of the URL: /xml/v3 for the XML v3
+  ## statistics of BIND 9.10+, /json/v1 for the JSON statistics.
+  ## Default is "http://localhost:8053/xml/v3".
+  # urls = ["http://localhost:8053/xml/v3"]
+
+  ## Report the memory usage summary.
+  # gather_memory = true
+
+  ## Report the usage of each memory context.  There are usually several
+  ## hundred of them, so only enable this when needed.
+  # gather_memory_contexts = false
+
+  ## Report the resolver and cache statistics of each view.
+  # gather_views = false
+
+  ## Timeout for the HTTP requests.
+  # timeout = "4s"
+`
+
+func (b *Bind) SampleConfig() string {
+	return sampleConfig
+}
+
+func (b *Bind) Description() string {
+	return "Read BIND nameserver XML or JSON statistics"
+}
+
+func (b *Bind) Gather(acc telegraf.Accumulator) error {
+	var wg sync.WaitGroup
+
+	if b.client == nil {
+		b.client = &http.Client{
+			Timeout: b.Timeout.Duration,
+		}
+	}
+
+	if len(b.Urls) == 0 {
+		b.Urls = []string{"http://localhost:8053/xml/v3"}
+	}
+
+	for _, u := range b.Urls {
+		addr, err := url.Parse(u)
+		if err != nil {
+			acc.AddError(fmt.Errorf("Unable to parse address '%s': %s", u, err))
+			continue
+		}
+
+		wg.Add(1)
+		go func(addr *url.URL) {
+			defer wg.Done()
+			acc.AddError(b.gatherUrl(addr, acc))
+		}(addr)
+	}
+
+	wg.Wait()
+	return nil
+}
+
+func (b *Bind) gatherUrl(addr *url.URL, acc telegraf.Accumulator) error {
+	switch strings.TrimRight(addr.Path, "/") {
+	case "", "/xml/v3":
+		return b.readStatsXMLv3(addr, acc)
+	case "/json/v1":
+		return b.readStatsJSON(addr, acc)
+	default:
+		return fmt.Errorf("unsupported statistics URL %s, the path must be /xml/v3 or /json/v1", addr)
+	}
+}
+
+// sections returns the statistics sections to request, the server section
+// holding the counters of the server and of its views, and the mem section
+// the memory usage.
+func (b *Bind) sections() []string {
+	sections := []string{"server"}
+	if b.GatherMemory || b.GatherMemoryContexts {
+		sections = append(sections, "mem")
+	}
+	return sections
+}
+
+// sectionURL returns the URL of a statistics section, defaulting to the
+// XML v3 statistics when the URL has no path.
+func sectionURL(addr *url.URL, format string, section string) string {
+	u := *addr
+	path := strings.TrimRight(u.Path, "/")
+	if path == "" {
+		path = "/" + format
+	}
+	u.Path = path + "/" + section
+	return u.String()
+}
+
+func (b *Bind) get(u string) (*http.Response, error) {
+	resp, err := b.client.Get(u)
+	if err != nil {
+		return nil, fmt.Errorf("error making HTTP request to %s: %s", u, err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s returned HTTP status %s", u, resp.Status)
+	}
+	return resp, nil
+}
+
+// addCounters adds the counters of a statistics group, skipping empty
+// groups.
+func addCounters(acc telegraf.Accumulator, counters map[string]int64, tags map[string]string) {
+	if len(counters) == 0 {
+		return
+	}
+
+	fields := make(map[string]interface{}, len(counters))
+	for name, value := range counters {
+		fields[name] = value
+	}
+	acc.AddCounter("bind_counter", fields, tags)
+}
+
+// memorySummary is the memory usage summary, named alike in both formats.
+type memorySummary struct {
+	TotalUse    int64
+	InUse       int64
+	BlockSize   int64
+	ContextSize int64
+	Lost        int64
+}
+
+type memoryContext struct {
+	ID    string `xml:"id" json:"id"`
+	Name  string `xml:"name" json:"name"`
+	Total int64  `xml:"total" json:"total"`
+	InUse int64  `xml:"inuse" json:"inuse"`
+}
+
+func (b *Bind) addMemory(acc telegraf.Accumulator, addr *url.URL, summary memorySummary, contexts []memoryContext) {
+	if b.GatherMemory {
+		acc.AddGauge("bind_memory",
+			map[string]interface{}{
+				"total_use":    summary.TotalUse,
+				"in_use":       summary.InUse,
+				"block_size":   summary.BlockSize,
+				"context_size": summary.ContextSize,
+				"lost":         summary.Lost,
+			},
+			map[string]string{"url": addr.Host})
+	}
+
+	if b.GatherMemoryContexts {
+		for _, context := range contexts {
+			acc.AddGauge("bind_memory_context",
+				map[string]interface{}{
+					"total":  context.Total,
+					"in_use": context.InUse,
+				},
+				map[string]string{
+					"url":  addr.Host,
+					"id":   context.ID,
+					"name": context.Name,
+				})
+		}
+	}
+}
+
+func counterTags(addr *url.URL, typ string, view string) map[string]string {
+	tags := map[string]string{
+		"url":  addr.Host,
+		"type": typ,
+	}
+	if view != "" {
+		tags["view"] = view
+	}
+	return tags
+}
+
+func init() {
+	inputs.Add("bind", func() telegraf.Input {
+		return &Bind{
+			GatherMemory: true,
+			Timeout:      internal.Duration{Duration: 4 * time.Second},
+		}
+	})
+}
@@ -0,0 +1,141 @@
+package bind
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newServer() *httptest.Server {
+	return httptest.NewServer(http.FileServer(http.Dir("testdata")))
+}
+
+func assertServerCounters(t *testing.T, acc *testutil.Accumulator, url string) {
+	tests := []struct {
+		typ    string
+		fields map[string]interface{}
+	}{
+		{"opcode", map[string]interface{}{"QUERY": int64(13), "NOTIFY": int64(0)}},
+		{"rcode", map[string]interface{}{"NOERROR": int64(11), "NXDOMAIN": int64(2)}},
+		{"qtype", map[string]interface{}{"A": int64(9), "AAAA": int64(4)}},
+		{"nsstat", map[string]interface{}{"Requestv4": int64(13), "XfrReqDone": int64(1)}},
+		{"zonestat", map[string]interface{}{"AXFRReqv4": int64(1), "XfrSuccess": int64(1), "XfrFail": int64(0)}},
+		{"sockstat", map[string]interface{}{"UDP4Open": int64(30)}},
+	}
+
+	for _, tt := range tests {
+		acc.AssertContainsTaggedFields(t, "bind_counter", tt.fields,
+			map[string]string{"url": url, "type": tt.typ})
+	}
+}
+
+func assertViewCounters(t *testing.T, acc *testutil.Accumulator, url string) {
+	tests := []struct {
+		typ    string
+		fields map[string]interface{}
+	}{
+		{"resqtype", map[string]interface{}{"A": int64(4)}},
+		{"resstats", map[string]interface{}{"Queryv4": int64(5), "NXDOMAIN": int64(1)}},
+		{"cache", map[string]interface{}{"A": int64(6), "!AAAA": int64(1)}},
+		{"cachestats", map[string]interface{}{"CacheHits": int64(12), "CacheMisses": int64(7)}},
+	}
+
+	for _, tt := range tests {
+		acc.AssertContainsTaggedFields(t, "bind_counter", tt.fields,
+			map[string]string{"url": url, "type": tt.typ, "view": "_default"})
+	}
+}
+
+func assertMemory(t *testing.T, acc *testutil.Accumulator, url string) {
+	acc.AssertContainsTaggedFields(t, "bind_memory",
+		map[string]interface{}{
+			"total_use":    int64(18206566),
+			"in_use":       int64(6000640),
+			"block_size":   int64(11272192),
+			"context_size": int64(1186144),
+			"lost":         int64(0),
+		},
+		map[string]string{"url": url})
+}
+
+func TestBindXmlStatsV3(t *testing.T) {
+	ts := newServer()
+	defer ts.Close()
+
+	b := Bind{
+		Urls:         []string{ts.URL + "/xml/v3"},
+		GatherMemory: true,
+		GatherViews:  true,
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(b.Gather))
+
+	url := ts.Listener.Addr().String()
+	assertServerCounters(t, &acc, url)
+	assertViewCounters(t, &acc, url)
+	assertMemory(t, &acc, url)
+	assert.False(t, acc.HasMeasurement("bind_memory_context"))
+}
+
+func TestBindJsonStats(t *testing.T) {
+	ts := newServer()
+	defer ts.Close()
+
+	b := Bind{
+		Urls:         []string{ts.URL + "/json/v1"},
+		GatherMemory: true,
+		GatherViews:  true,
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(b.Gather))
+
+	url := ts.Listener.Addr().String()
+	assertServerCounters(t, &acc, url)
+	assertViewCounters(t, &acc, url)
+	assertMemory(t, &acc, url)
+}
+
+func TestBindMemoryContexts(t *testing.T) {
+	ts := newServer()
+	defer ts.Close()
+
+	for _, path := range []string{"/xml/v3", "/json/v1"} {
+		b := Bind{
+			Urls:                 []string{ts.URL + path},
+			GatherMemoryContexts: true,
+		}
+
+		var acc testutil.Accumulator
+		require.NoError(t, acc.GatherError(b.Gather))
+
+		acc.AssertContainsTaggedFields(t, "bind_memory_context",
+			map[string]interface{}{
+				"total":  int64(2693003),
+				"in_use": int64(1454904),
+			},
+			map[string]string{
+				"url":  ts.Listener.Addr().String(),
+				"id":   "0x55fb2e042de0",
+				"name": "main",
+			})
+		assert.False(t, acc.HasMeasurement("bind_memory"))
+		for _, m := range acc.Metrics {
+			assert.NotContains(t, m.Tags, "view")
+		}
+	}
+}
+
+func TestBindUnsupportedURL(t *testing.T) {
+	b := Bind{
+		Urls: []string{"http://localhost:8053/xml/v2"},
+	}
+
+	var acc testutil.Accumulator
+	require.Error(t, acc.GatherError(b.Gather))
+}
@@ -0,0 +1,79 @@
+package bind
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/influxdata/telegraf"
+)
+
+// jsonStats is the JSON statistics document, of which the server and mem
+// sections fill different parts.
+type jsonStats struct {
+	OpCodes   map[string]int64    `json:"opcodes"`
+	RCodes    map[string]int64    `json:"rcodes"`
+	QTypes    map[string]int64    `json:"qtypes"`
+	NSStats   map[string]int64    `json:"nsstats"`
+	ZoneStats map[string]int64    `json:"zonestats"`
+	ResStats  map[string]int64    `json:"resstats"`
+	SockStats map[string]int64    `json:"sockstats"`
+	Views     map[string]jsonView `json:"views"`
+	Memory    jsonMemory          `json:"memory"`
+}
+
+type jsonView struct {
+	Resolver struct {
+		Stats      map[string]int64 `json:"stats"`
+		QTypes     map[string]int64 `json:"qtypes"`
+		Cache      map[string]int64 `json:"cache"`
+		CacheStats map[string]int64 `json:"cachestats"`
+		ADB        map[string]int64 `json:"adb"`
+	} `json:"resolver"`
+}
+
+type jsonMemory struct {
+	memorySummary
+	Contexts []memoryContext `json:"contexts"`
+}
+
+func (b *Bind) readStatsJSON(addr *url.URL, acc telegraf.Accumulator) error {
+	var stats jsonStats
+
+	for _, section := range b.sections() {
+		u := sectionURL(addr, "json/v1", section)
+		resp, err := b.get(u)
+		if err != nil {
+			return err
+		}
+
+		err = json.NewDecoder(resp.Body).Decode(&stats)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("Unable to decode JSON document from %s: %s", u, err)
+		}
+	}
+
+	// The counter groups are reported with the type names of the XML
+	// statistics, so that both formats produce the same metrics.
+	addCounters(acc, stats.OpCodes, counterTags(addr, "opcode", ""))
+	addCounters(acc, stats.RCodes, counterTags(addr, "rcode", ""))
+	addCounters(acc, stats.QTypes, counterTags(addr, "qtype", ""))
+	addCounters(acc, stats.NSStats, counterTags(addr, "nsstat", ""))
+	addCounters(acc, stats.ZoneStats, counterTags(addr, "zonestat", ""))
+	addCounters(acc, stats.ResStats, counterTags(addr, "resstat", ""))
+	addCounters(acc, stats.SockStats, counterTags(addr, "sockstat", ""))
+
+	if b.GatherViews {
+		for name, view := range stats.Views {
+			addCounters(acc, view.Resolver.Stats, counterTags(addr, "resstats", name))
+			addCounters(acc, view.Resolver.QTypes, counterTags(addr, "resqtype", name))
+			addCounters(acc, view.Resolver.Cache, counterTags(addr, "cache", name))
+			addCounters(acc, view.Resolver.CacheStats, counterTags(addr, "cachestats", name))
+			addCounters(acc, view.Resolver.ADB, counterTags(addr, "adbstat", name))
+		}
+	}
+
+	b.addMemory(acc, addr, stats.Memory.memorySummary, stats.Memory.Contexts)
+	return nil
+}
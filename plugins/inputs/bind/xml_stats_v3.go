@@ -0,0 +1,99 @@
+package bind
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net/url"
+
+	"github.com/influxdata/telegraf"
+)
+
+// v3Stats is the XML v3 statistics document, of which the server and mem
+// sections fill different parts.
+type v3Stats struct {
+	Server v3Server `xml:"server"`
+	Views  []v3View `xml:"views>view"`
+	Memory v3Memory `xml:"memory"`
+}
+
+type v3Server struct {
+	CounterGroups []v3CounterGroup `xml:"counters"`
+}
+
+type v3View struct {
+	Name          string           `xml:"name,attr"`
+	CounterGroups []v3CounterGroup `xml:"counters"`
+	Caches        []v3Cache        `xml:"cache"`
+}
+
+type v3CounterGroup struct {
+	Type     string      `xml:"type,attr"`
+	Counters []v3Counter `xml:"counter"`
+}
+
+type v3Counter struct {
+	Name  string `xml:"name,attr"`
+	Value int64  `xml:",chardata"`
+}
+
+type v3Cache struct {
+	Name   string `xml:"name,attr"`
+	RRSets []struct {
+		Name  string `xml:"name"`
+		Value int64  `xml:"counter"`
+	} `xml:"rrset"`
+}
+
+type v3Memory struct {
+	Contexts []memoryContext `xml:"contexts>context"`
+	Summary  memorySummary   `xml:"summary"`
+}
+
+func (b *Bind) readStatsXMLv3(addr *url.URL, acc telegraf.Accumulator) error {
+	var stats v3Stats
+
+	for _, section := range b.sections() {
+		u := sectionURL(addr, "xml/v3", section)
+		resp, err := b.get(u)
+		if err != nil {
+			return err
+		}
+
+		err = xml.NewDecoder(resp.Body).Decode(&stats)
+		resp.Body.Close()
+		if err != nil {
+			return fmt.Errorf("Unable to decode XML document from %s: %s", u, err)
+		}
+	}
+
+	for _, group := range stats.Server.CounterGroups {
+		addCounters(acc, group.counters(), counterTags(addr, group.Type, ""))
+	}
+
+	if b.GatherViews {
+		for _, view := range stats.Views {
+			for _, group := range view.CounterGroups {
+				addCounters(acc, group.counters(), counterTags(addr, group.Type, view.Name))
+			}
+
+			for _, cache := range view.Caches {
+				counters := make(map[string]int64, len(cache.RRSets))
+				for _, rrset := range cache.RRSets {
+					counters[rrset.Name] = rrset.Value
+				}
+				addCounters(acc, counters, counterTags(addr, "cache", view.Name))
+			}
+		}
+	}
+
+	b.addMemory(acc, addr, stats.Memory.Summary, stats.Memory.Contexts)
+	return nil
+}
+
+func (g v3CounterGroup) counters() map[string]int64 {
+	counters := make(map[string]int64, len(g.Counters))
+	for _, c := range g.Counters {
+		counters[c.Name] = c.Value
+	}
+	return counters
+}
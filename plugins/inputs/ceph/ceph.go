@@ -6,11 +6,17 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"net/http"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -31,10 +37,39 @@ type Ceph struct {
 	SocketSuffix           string
 	CephUser               string
 	CephConfig             string
+	CephKeyring            string
 	GatherAdminSocketStats bool
 	GatherClusterStats     bool
+	ClusterSections        []string
+
+	MgrURL      string `toml:"mgr_url"`
+	MgrUsername string
+	MgrApiKey   string
+	MgrTimeout  internal.Duration
+	tls.ClientConfig
+
+	client *http.Client
+}
+
+// clusterJobs lists the ceph commands run for each of the cluster_sections,
+// with the function decoding their JSON output.
+var clusterJobs = []struct {
+	section string
+	command string
+	parser  func(telegraf.Accumulator, string) error
+}{
+	{"status", "status", decodeStatus},
+	{"df", "df", decodeDf},
+	{"osd_pool_stats", "osd pool stats", decodeOsdPoolStats},
+	{"osd_perf", "osd perf", decodeOsdPerf},
 }
 
+// infValue matches the infinite values ceph writes in its JSON output, but
+// not keys such as osd_perf_infos.
+var infValue = regexp.MustCompile(`([:\[,]\s*)-?inf\b`)
+
+var defaultClusterSections = []string{"status", "df", "osd_pool_stats"}
+
 func (c *Ceph) Description() string {
 	return "Collects performance metrics from the MON and OSD nodes in a Ceph storage cluster."
 }
@@ -65,11 +100,38 @@ var sampleConfig = `
   ## Ceph configuration to use to locate the cluster
   ceph_config = "/etc/ceph/ceph.conf"
 
+  ## Keyring of the ceph user, by default ceph searches the keyring from the
+  ## ceph configuration
+  # ceph_keyring = "/etc/ceph/ceph.client.admin.keyring"
+
   ## Whether to gather statistics via the admin socket
   gather_admin_socket_stats = true
 
   ## Whether to gather statistics via ceph commands
   gather_cluster_stats = false
+
+  ## Cluster statistics to gather, the osd_perf section is not gathered by
+  ## default.  Available sections are:
+  ##   status         - health, osdmap, pgmap and pg states, from "ceph status"
+  ##   df             - cluster and pool usage, from "ceph df"
+  ##   osd_pool_stats - pool io and recovery rates, from "ceph osd pool stats"
+  ##   osd_perf       - commit and apply latency of the osds, from "ceph osd perf"
+  # cluster_sections = ["status", "df", "osd_pool_stats"]
+
+  ## Run the cluster commands through the restful module of the ceph manager
+  ## instead of the ceph binary, for hosts without the ceph client.  The key
+  ## is created with "ceph restful create-key <username>".
+  # mgr_url = "https://localhost:8003"
+  # mgr_username = "telegraf"
+  # mgr_api_key = ""
+  # mgr_timeout = "5s"
+
+  ## Optional TLS Config for the manager
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
 `
 
 func (c *Ceph) SampleConfig() string {
@@ -119,30 +181,50 @@ func (c *Ceph) gatherAdminSocketStats(acc telegraf.Accumulator) error {
 }
 
 func (c *Ceph) gatherClusterStats(acc telegraf.Accumulator) error {
-	jobs := []struct {
-		command string
-		parser  func(telegraf.Accumulator, string) error
-	}{
-		{"status", decodeStatus},
-		{"df", decodeDf},
-		{"osd pool stats", decodeOsdPoolStats},
-	}
-
-	// For each job, execute against the cluster, parse and accumulate the data points
-	for _, job := range jobs {
-		output, err := c.exec(job.command)
+	sections := c.ClusterSections
+	if len(sections) == 0 {
+		sections = defaultClusterSections
+	}
+
+	known := make([]string, 0, len(clusterJobs))
+	for _, job := range clusterJobs {
+		known = append(known, job.section)
+	}
+	for _, section := range sections {
+		if !choice(section, known) {
+			return fmt.Errorf("unknown cluster section '%s'", section)
+		}
+	}
+
+	// For each selected job, execute against the cluster, parse and
+	// accumulate the data points
+	for _, job := range clusterJobs {
+		if !choice(job.section, sections) {
+			continue
+		}
+		output, err := c.command(job.command)
 		if err != nil {
-			return fmt.Errorf("error executing command: %v", err)
+			acc.AddError(fmt.Errorf("error executing command: %v", err))
+			continue
 		}
 		err = job.parser(acc, output)
 		if err != nil {
-			return fmt.Errorf("error parsing output: %v", err)
+			acc.AddError(fmt.Errorf("error parsing output: %v", err))
 		}
 	}
 
 	return nil
 }
 
+func choice(s string, choices []string) bool {
+	for _, c := range choices {
+		if c == s {
+			return true
+		}
+	}
+	return false
+}
+
 func init() {
 	c := Ceph{
 		CephBinary:             "/usr/bin/ceph",
@@ -154,6 +236,7 @@ func init() {
 		CephConfig:             "/etc/ceph/ceph.conf",
 		GatherAdminSocketStats: true,
 		GatherClusterStats:     false,
+		MgrTimeout:             internal.Duration{Duration: 5 * time.Second},
 	}
 
 	inputs.Add(measurement, func() telegraf.Input { return &c })
@@ -294,8 +377,32 @@ func flatten(data interface{}) []*metric {
 	return metrics
 }
 
+// command runs a ceph command against the cluster, with the ceph binary or
+// through the manager when mgr_url is set, and returns its JSON output.
+func (c *Ceph) command(command string) (string, error) {
+	var output string
+	var err error
+	if c.MgrURL != "" {
+		output, err = c.mgrRequest(command)
+	} else {
+		output, err = c.exec(command)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// Ceph doesn't sanitize its output, and may return invalid JSON.  Patch this
+	// up for them, as having some inaccurate data is better than none.
+	output = infValue.ReplaceAllString(output, "${1}0")
+
+	return output, nil
+}
+
 func (c *Ceph) exec(command string) (string, error) {
 	cmdArgs := []string{"--conf", c.CephConfig, "--name", c.CephUser, "--format", "json"}
+	if c.CephKeyring != "" {
+		cmdArgs = append(cmdArgs, "--keyring", c.CephKeyring)
+	}
 	cmdArgs = append(cmdArgs, strings.Split(command, " ")...)
 
 	cmd := exec.Command(c.CephBinary, cmdArgs...)
@@ -307,14 +414,76 @@ func (c *Ceph) exec(command string) (string, error) {
 		return "", fmt.Errorf("error running ceph %v: %s", command, err)
 	}
 
-	output := out.String()
+	return out.String(), nil
+}
 
-	// Ceph doesn't sanitize its output, and may return invalid JSON.  Patch this
-	// up for them, as having some inaccurate data is better than none.
-	output = strings.Replace(output, "-inf", "0", -1)
-	output = strings.Replace(output, "inf", "0", -1)
+// mgrResponse is the result of a request to the restful module of the
+// manager, the output of each command is in outb.
+type mgrResponse struct {
+	HasFailed bool `json:"has_failed"`
+	Failed    []struct {
+		Outs string `json:"outs"`
+	} `json:"failed"`
+	Finished []struct {
+		Outb string `json:"outb"`
+	} `json:"finished"`
+}
 
-	return output, nil
+func (c *Ceph) mgrRequest(command string) (string, error) {
+	if c.client == nil {
+		tlsCfg, err := c.ClientConfig.TLSConfig()
+		if err != nil {
+			return "", err
+		}
+		c.client = &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: tlsCfg,
+			},
+			Timeout: c.MgrTimeout.Duration,
+		}
+	}
+
+	body, err := json.Marshal(map[string]string{"prefix": command, "format": "json"})
+	if err != nil {
+		return "", err
+	}
+
+	url := strings.TrimRight(c.MgrURL, "/") + "/request?wait=1"
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.MgrUsername != "" || c.MgrApiKey != "" {
+		req.SetBasicAuth(c.MgrUsername, c.MgrApiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("error requesting ceph %v from %s: %s", command, c.MgrURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("error requesting ceph %v from %s: %s", command, c.MgrURL, resp.Status)
+	}
+
+	var result mgrResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode manager response for ceph %v: %v", command, err)
+	}
+	if result.HasFailed || len(result.Failed) > 0 {
+		msg := "unknown error"
+		if len(result.Failed) > 0 && result.Failed[0].Outs != "" {
+			msg = result.Failed[0].Outs
+		}
+		return "", fmt.Errorf("ceph %v failed: %s", command, msg)
+	}
+	if len(result.Finished) == 0 {
+		return "", fmt.Errorf("ceph %v did not finish", command)
+	}
+
+	return result.Finished[0].Outb, nil
 }
 
 func decodeStatus(acc telegraf.Accumulator, input string) error {
@@ -339,6 +508,69 @@ func decodeStatus(acc telegraf.Accumulator, input string) error {
 		return err
 	}
 
+	err = decodeStatusHealth(acc, data)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// healthStatusCodes orders the health status of the cluster by severity.
+var healthStatusCodes = map[string]int{
+	"HEALTH_OK":   0,
+	"HEALTH_WARN": 1,
+	"HEALTH_ERR":  2,
+}
+
+func decodeStatusHealth(acc telegraf.Accumulator, data map[string]interface{}) error {
+	health, ok := data["health"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	// Luminous reports the health in status, older releases in overall_status
+	status, ok := health["status"].(string)
+	if !ok {
+		status, ok = health["overall_status"].(string)
+		if !ok {
+			return nil
+		}
+	}
+
+	code, ok := healthStatusCodes[status]
+	if !ok {
+		code = 3
+	}
+
+	checks, _ := health["checks"].(map[string]interface{})
+	for name, check := range checks {
+		checkMap, ok := check.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("WARNING %s - unable to decode health check", measurement)
+		}
+		severity, _ := checkMap["severity"].(string)
+		var message string
+		if summary, ok := checkMap["summary"].(map[string]interface{}); ok {
+			message, _ = summary["message"].(string)
+		}
+
+		tags := map[string]string{
+			"check":    name,
+			"severity": severity,
+		}
+		fields := map[string]interface{}{
+			"message": message,
+		}
+		acc.AddFields("ceph_health_check", fields, tags)
+	}
+
+	fields := map[string]interface{}{
+		"status":      status,
+		"status_code": code,
+		"checks":      len(checks),
+	}
+	acc.AddFields("ceph_health", fields, map[string]string{})
 	return nil
 }
 
@@ -499,3 +731,51 @@ func decodeOsdPoolStats(acc telegraf.Accumulator, input string) error {
 
 	return nil
 }
+
+func decodeOsdPerf(acc telegraf.Accumulator, input string) error {
+	data := make(map[string]interface{})
+	err := json.Unmarshal([]byte(input), &data)
+	if err != nil {
+		return fmt.Errorf("failed to parse json: '%s': %v", input, err)
+	}
+
+	// Nautilus and later nest the infos in osdstats
+	if osdstats, ok := data["osdstats"].(map[string]interface{}); ok {
+		data = osdstats
+	}
+
+	infos, ok := data["osd_perf_infos"].([]interface{})
+	if !ok {
+		return fmt.Errorf("WARNING %s - unable to decode osd perf infos", measurement)
+	}
+
+	// ceph.osd.perf: records the commit and apply latency per osd
+	for _, info := range infos {
+		infoMap, ok := info.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("WARNING %s - unable to decode osd perf info", measurement)
+		}
+		id, ok := infoMap["id"].(float64)
+		if !ok {
+			return fmt.Errorf("WARNING %s - unable to decode osd perf id", measurement)
+		}
+		perfStats, ok := infoMap["perf_stats"].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("WARNING %s - unable to decode osd perf stats", measurement)
+		}
+
+		fields := make(map[string]interface{})
+		for key, value := range perfStats {
+			switch value.(type) {
+			case float64:
+				fields[key] = value
+			}
+		}
+		tags := map[string]string{
+			"id": strconv.Itoa(int(id)),
+		}
+		acc.AddFields("ceph_osd_perf", fields, tags)
+	}
+
+	return nil
+}
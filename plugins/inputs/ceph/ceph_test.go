@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path"
 	"strconv"
@@ -12,6 +14,7 @@ import (
 
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 const (
@@ -60,6 +63,126 @@ func TestDecodeStatusPgmapState(t *testing.T) {
 	}
 }
 
+func TestDecodeStatusHealth(t *testing.T) {
+	data := make(map[string]interface{})
+	err := json.Unmarshal([]byte(clusterHealthDump), &data)
+	assert.NoError(t, err)
+
+	acc := &testutil.Accumulator{}
+	err = decodeStatusHealth(acc, data)
+	assert.NoError(t, err)
+
+	acc.AssertContainsTaggedFields(t, "ceph_health",
+		map[string]interface{}{"status": "HEALTH_WARN", "status_code": 1, "checks": 2},
+		map[string]string{})
+	acc.AssertContainsTaggedFields(t, "ceph_health_check",
+		map[string]interface{}{"message": "1 osds down"},
+		map[string]string{"check": "OSD_DOWN", "severity": "HEALTH_WARN"})
+	acc.AssertContainsTaggedFields(t, "ceph_health_check",
+		map[string]interface{}{"message": "Degraded data redundancy: 12 pgs degraded"},
+		map[string]string{"check": "PG_DEGRADED", "severity": "HEALTH_WARN"})
+}
+
+func TestDecodeStatusHealthLegacy(t *testing.T) {
+	data := make(map[string]interface{})
+	err := json.Unmarshal([]byte(clusterStatusDump), &data)
+	assert.NoError(t, err)
+
+	acc := &testutil.Accumulator{}
+	err = decodeStatusHealth(acc, data)
+	assert.NoError(t, err)
+
+	acc.AssertContainsTaggedFields(t, "ceph_health",
+		map[string]interface{}{"status": "HEALTH_OK", "status_code": 0, "checks": 0},
+		map[string]string{})
+}
+
+func TestDecodeOsdPerf(t *testing.T) {
+	for _, dump := range []string{osdPerfLuminousDump, osdPerfNautilusDump} {
+		acc := &testutil.Accumulator{}
+		err := decodeOsdPerf(acc, dump)
+		assert.NoError(t, err)
+
+		acc.AssertContainsTaggedFields(t, "ceph_osd_perf",
+			map[string]interface{}{"commit_latency_ms": float64(3), "apply_latency_ms": float64(5)},
+			map[string]string{"id": "0"})
+		acc.AssertContainsTaggedFields(t, "ceph_osd_perf",
+			map[string]interface{}{"commit_latency_ms": float64(12), "apply_latency_ms": float64(14)},
+			map[string]string{"id": "1"})
+	}
+}
+
+func TestGatherClusterStatsMgr(t *testing.T) {
+	outputs := map[string]string{
+		"status":   clusterHealthDump,
+		"osd perf": osdPerfNautilusDump,
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, key, _ := r.BasicAuth()
+		assert.Equal(t, "telegraf", username)
+		assert.Equal(t, "secret", key)
+		assert.Equal(t, "/request", r.URL.Path)
+		assert.Equal(t, "1", r.URL.Query().Get("wait"))
+
+		var body map[string]string
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, "json", body["format"])
+
+		output, ok := outputs[body["prefix"]]
+		if !ok {
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"has_failed": true,
+				"failed":     []map[string]string{{"outs": "unexpected command"}},
+			})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"has_failed": false,
+			"finished":   []map[string]string{{"outb": output}},
+		})
+	}))
+	defer ts.Close()
+
+	c := &Ceph{
+		GatherClusterStats: true,
+		ClusterSections:    []string{"status", "osd_perf"},
+		MgrURL:             ts.URL,
+		MgrUsername:        "telegraf",
+		MgrApiKey:          "secret",
+	}
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, acc.GatherError(c.Gather))
+
+	assert.True(t, acc.HasMeasurement("ceph_osdmap"))
+	assert.True(t, acc.HasMeasurement("ceph_health"))
+	assert.True(t, acc.HasMeasurement("ceph_osd_perf"))
+	assert.False(t, acc.HasMeasurement("ceph_usage"))
+	assert.False(t, acc.HasMeasurement("ceph_pool_stats"))
+
+	// A failed command is reported without stopping the other sections
+	c.ClusterSections = []string{"df", "osd_perf"}
+	acc = &testutil.Accumulator{}
+	assert.Error(t, acc.GatherError(c.Gather))
+	assert.True(t, acc.HasMeasurement("ceph_osd_perf"))
+}
+
+func TestInfValue(t *testing.T) {
+	input := `{"osd_perf_infos": [{"latency": inf, "ratio": -inf, "values": [inf, 1]}]}`
+	expected := `{"osd_perf_infos": [{"latency": 0, "ratio": 0, "values": [0, 1]}]}`
+	assert.Equal(t, expected, infValue.ReplaceAllString(input, "${1}0"))
+}
+
+func TestGatherClusterStatsUnknownSection(t *testing.T) {
+	c := &Ceph{
+		GatherClusterStats: true,
+		ClusterSections:    []string{"status", "pg_dump"},
+	}
+
+	acc := &testutil.Accumulator{}
+	assert.Error(t, acc.GatherError(c.Gather))
+}
+
 func TestGather(t *testing.T) {
 	saveFind := findSockets
 	saveDump := perfDump
@@ -834,3 +957,98 @@ var clusterStatusDump = `
   }
 }
 `
+
+var clusterHealthDump = `
+{
+  "fsid": "01234567-abcd-9876-0123-ffeeddccbbaa",
+  "health": {
+    "checks": {
+      "OSD_DOWN": {
+        "severity": "HEALTH_WARN",
+        "summary": {
+          "message": "1 osds down"
+        }
+      },
+      "PG_DEGRADED": {
+        "severity": "HEALTH_WARN",
+        "summary": {
+          "message": "Degraded data redundancy: 12 pgs degraded"
+        }
+      }
+    },
+    "status": "HEALTH_WARN"
+  },
+  "osdmap": {
+    "osdmap": {
+      "epoch": 42,
+      "num_osds": 2,
+      "num_up_osds": 1,
+      "num_in_osds": 2,
+      "full": false,
+      "nearfull": false,
+      "num_remapped_pgs": 0
+    }
+  },
+  "pgmap": {
+    "pgs_by_state": [
+      {
+        "state_name": "active+clean",
+        "count": 52
+      },
+      {
+        "state_name": "active+undersized+degraded",
+        "count": 12
+      }
+    ],
+    "num_pgs": 64,
+    "data_bytes": 1024,
+    "bytes_used": 2048,
+    "bytes_avail": 4096,
+    "bytes_total": 6144
+  }
+}
+`
+
+var osdPerfLuminousDump = `
+{
+  "osd_perf_infos": [
+    {
+      "id": 1,
+      "perf_stats": {
+        "commit_latency_ms": 12,
+        "apply_latency_ms": 14
+      }
+    },
+    {
+      "id": 0,
+      "perf_stats": {
+        "commit_latency_ms": 3,
+        "apply_latency_ms": 5
+      }
+    }
+  ]
+}
+`
+
+var osdPerfNautilusDump = `
+{
+  "osdstats": {
+    "osd_perf_infos": [
+      {
+        "id": 1,
+        "perf_stats": {
+          "commit_latency_ms": 12,
+          "apply_latency_ms": 14
+        }
+      },
+      {
+        "id": 0,
+        "perf_stats": {
+          "commit_latency_ms": 3,
+          "apply_latency_ms": 5
+        }
+      }
+    ]
+  }
+}
+`
@@ -0,0 +1,416 @@
+package clickhouse
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const sampleConfig = `
+  ## HTTP interfaces of the ClickHouse servers, one per node of the cluster.
+  servers = ["http://127.0.0.1:8123"]
+
+  ## Credentials of a user allowed to read the system tables.
+  # username = "default"
+  # password = ""
+
+  ## Clusters used to tag the metrics with the cluster, shard and replica of
+  ## the servers, from the system.clusters table.  The first matching cluster
+  ## a server is part of is used, all the clusters are considered if empty.
+  # cluster_include = []
+  # cluster_exclude = []
+
+  ## Amount of time allowed to complete the HTTP requests
+  # timeout = "5s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+type ClickHouse struct {
+	Servers        []string          `toml:"servers"`
+	Username       string            `toml:"username"`
+	Password       string            `toml:"password"`
+	ClusterInclude []string          `toml:"cluster_include"`
+	ClusterExclude []string          `toml:"cluster_exclude"`
+	Timeout        internal.Duration `toml:"timeout"`
+	tls.ClientConfig
+
+	client        *http.Client
+	clusterFilter filter.Filter
+}
+
+func (ch *ClickHouse) SampleConfig() string {
+	return sampleConfig
+}
+
+func (ch *ClickHouse) Description() string {
+	return "Read metrics from the system tables of ClickHouse servers"
+}
+
+func (ch *ClickHouse) init() error {
+	tlsCfg, err := ch.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	ch.clusterFilter, err = filter.NewIncludeExcludeFilter(ch.ClusterInclude, ch.ClusterExclude)
+	if err != nil {
+		return err
+	}
+
+	ch.client = &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsCfg,
+		},
+		Timeout: ch.Timeout.Duration,
+	}
+	return nil
+}
+
+func (ch *ClickHouse) Gather(acc telegraf.Accumulator) error {
+	if ch.client == nil {
+		if err := ch.init(); err != nil {
+			return err
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, server := range ch.Servers {
+		u, err := url.Parse(server)
+		if err != nil {
+			acc.AddError(fmt.Errorf("unable to parse server address %q: %v", server, err))
+			continue
+		}
+
+		wg.Add(1)
+		go func(u *url.URL) {
+			defer wg.Done()
+			ch.gatherServer(acc, u)
+		}(u)
+	}
+	wg.Wait()
+	return nil
+}
+
+func (ch *ClickHouse) gatherServer(acc telegraf.Accumulator, u *url.URL) {
+	tags := map[string]string{
+		"source": u.Hostname(),
+	}
+	if err := ch.clusterTags(u, tags); err != nil {
+		acc.AddError(err)
+		return
+	}
+
+	gatherers := []func(telegraf.Accumulator, *url.URL, map[string]string) error{
+		ch.metrics,
+		ch.events,
+		ch.asynchronousMetrics,
+		ch.tables,
+		ch.replicas,
+		ch.mutations,
+		ch.disks,
+	}
+	for _, gather := range gatherers {
+		if err := gather(acc, u, tags); err != nil {
+			acc.AddError(err)
+		}
+	}
+}
+
+// clusterTags adds the cluster, shard and replica of the server.
+func (ch *ClickHouse) clusterTags(u *url.URL, tags map[string]string) error {
+	var clusters []struct {
+		Cluster    string   `json:"cluster"`
+		ShardNum   chUInt64 `json:"shard_num"`
+		ReplicaNum chUInt64 `json:"replica_num"`
+	}
+	err := ch.execQuery(u, "SELECT cluster, shard_num, replica_num FROM system.clusters WHERE is_local = 1", &clusters)
+	if err != nil {
+		return err
+	}
+
+	for _, c := range clusters {
+		if !ch.clusterFilter.Match(c.Cluster) {
+			continue
+		}
+		tags["cluster"] = c.Cluster
+		tags["shard_num"] = strconv.FormatUint(uint64(c.ShardNum), 10)
+		tags["replica_num"] = strconv.FormatUint(uint64(c.ReplicaNum), 10)
+		break
+	}
+	return nil
+}
+
+func (ch *ClickHouse) metrics(acc telegraf.Accumulator, u *url.URL, tags map[string]string) error {
+	var rows []struct {
+		Metric string  `json:"metric"`
+		Value  chInt64 `json:"value"`
+	}
+	if err := ch.execQuery(u, "SELECT metric, value FROM system.metrics", &rows); err != nil {
+		return err
+	}
+
+	fields := make(map[string]interface{})
+	for _, r := range rows {
+		fields[internal.SnakeCase(r.Metric)] = int64(r.Value)
+	}
+	acc.AddGauge("clickhouse_metrics", fields, copyTags(tags))
+	return nil
+}
+
+func (ch *ClickHouse) events(acc telegraf.Accumulator, u *url.URL, tags map[string]string) error {
+	var rows []struct {
+		Event string   `json:"event"`
+		Value chUInt64 `json:"value"`
+	}
+	if err := ch.execQuery(u, "SELECT event, value FROM system.events", &rows); err != nil {
+		return err
+	}
+
+	fields := make(map[string]interface{})
+	for _, r := range rows {
+		fields[internal.SnakeCase(r.Event)] = uint64(r.Value)
+	}
+	acc.AddCounter("clickhouse_events", fields, copyTags(tags))
+	return nil
+}
+
+func (ch *ClickHouse) asynchronousMetrics(acc telegraf.Accumulator, u *url.URL, tags map[string]string) error {
+	var rows []struct {
+		Metric string  `json:"metric"`
+		Value  float64 `json:"value"`
+	}
+	if err := ch.execQuery(u, "SELECT metric, value FROM system.asynchronous_metrics", &rows); err != nil {
+		return err
+	}
+
+	fields := make(map[string]interface{})
+	for _, r := range rows {
+		fields[internal.SnakeCase(r.Metric)] = r.Value
+	}
+	acc.AddGauge("clickhouse_asynchronous_metrics", fields, copyTags(tags))
+	return nil
+}
+
+func (ch *ClickHouse) tables(acc telegraf.Accumulator, u *url.URL, tags map[string]string) error {
+	var rows []struct {
+		Database string   `json:"database"`
+		Table    string   `json:"table"`
+		Bytes    chUInt64 `json:"bytes"`
+		Parts    chUInt64 `json:"parts"`
+		Rows     chUInt64 `json:"rows"`
+	}
+	query := "SELECT database, table, SUM(bytes_on_disk) AS bytes, COUNT(*) AS parts, SUM(rows) AS rows " +
+		"FROM system.parts WHERE active = 1 GROUP BY database, table"
+	if err := ch.execQuery(u, query, &rows); err != nil {
+		return err
+	}
+
+	for _, r := range rows {
+		t := copyTags(tags)
+		t["database"] = r.Database
+		t["table"] = r.Table
+		acc.AddFields("clickhouse_tables",
+			map[string]interface{}{
+				"bytes": uint64(r.Bytes),
+				"parts": uint64(r.Parts),
+				"rows":  uint64(r.Rows),
+			}, t)
+	}
+	return nil
+}
+
+func (ch *ClickHouse) replicas(acc telegraf.Accumulator, u *url.URL, tags map[string]string) error {
+	var rows []struct {
+		Database         string   `json:"database"`
+		Table            string   `json:"table"`
+		IsLeader         chUInt64 `json:"is_leader"`
+		IsReadonly       chUInt64 `json:"is_readonly"`
+		IsSessionExpired chUInt64 `json:"is_session_expired"`
+		QueueSize        chUInt64 `json:"queue_size"`
+		InsertsInQueue   chUInt64 `json:"inserts_in_queue"`
+		MergesInQueue    chUInt64 `json:"merges_in_queue"`
+		LogPointerLag    chInt64  `json:"log_pointer_lag"`
+		AbsoluteDelay    chUInt64 `json:"absolute_delay"`
+		TotalReplicas    chUInt64 `json:"total_replicas"`
+		ActiveReplicas   chUInt64 `json:"active_replicas"`
+	}
+	query := "SELECT database, table, is_leader, is_readonly, is_session_expired, queue_size, " +
+		"inserts_in_queue, merges_in_queue, toInt64(log_max_index) - toInt64(log_pointer) AS log_pointer_lag, " +
+		"absolute_delay, total_replicas, active_replicas FROM system.replicas"
+	if err := ch.execQuery(u, query, &rows); err != nil {
+		return err
+	}
+
+	for _, r := range rows {
+		t := copyTags(tags)
+		t["database"] = r.Database
+		t["table"] = r.Table
+		acc.AddFields("clickhouse_replicas",
+			map[string]interface{}{
+				"is_leader":          r.IsLeader != 0,
+				"is_readonly":        r.IsReadonly != 0,
+				"is_session_expired": r.IsSessionExpired != 0,
+				"queue_size":         uint64(r.QueueSize),
+				"inserts_in_queue":   uint64(r.InsertsInQueue),
+				"merges_in_queue":    uint64(r.MergesInQueue),
+				"log_pointer_lag":    int64(r.LogPointerLag),
+				"absolute_delay":     uint64(r.AbsoluteDelay),
+				"total_replicas":     uint64(r.TotalReplicas),
+				"active_replicas":    uint64(r.ActiveReplicas),
+			}, t)
+	}
+	return nil
+}
+
+func (ch *ClickHouse) mutations(acc telegraf.Accumulator, u *url.URL, tags map[string]string) error {
+	var rows []struct {
+		Running   chUInt64 `json:"running"`
+		Failed    chUInt64 `json:"failed"`
+		Completed chUInt64 `json:"completed"`
+	}
+	query := "SELECT countIf(is_done = 0) AS running, countIf(is_done = 0 AND latest_fail_reason != '') AS failed, " +
+		"countIf(is_done = 1) AS completed FROM system.mutations"
+	if err := ch.execQuery(u, query, &rows); err != nil {
+		return err
+	}
+
+	for _, r := range rows {
+		acc.AddFields("clickhouse_mutations",
+			map[string]interface{}{
+				"running":   uint64(r.Running),
+				"failed":    uint64(r.Failed),
+				"completed": uint64(r.Completed),
+			}, copyTags(tags))
+	}
+	return nil
+}
+
+func (ch *ClickHouse) disks(acc telegraf.Accumulator, u *url.URL, tags map[string]string) error {
+	var rows []struct {
+		Name          string   `json:"name"`
+		Path          string   `json:"path"`
+		FreeSpace     chUInt64 `json:"free_space"`
+		TotalSpace    chUInt64 `json:"total_space"`
+		KeepFreeSpace chUInt64 `json:"keep_free_space"`
+	}
+	query := "SELECT name, path, free_space, total_space, keep_free_space FROM system.disks"
+	if err := ch.execQuery(u, query, &rows); err != nil {
+		return err
+	}
+
+	for _, r := range rows {
+		t := copyTags(tags)
+		t["disk"] = r.Name
+		t["path"] = r.Path
+		acc.AddFields("clickhouse_disks",
+			map[string]interface{}{
+				"free_space":      uint64(r.FreeSpace),
+				"total_space":     uint64(r.TotalSpace),
+				"keep_free_space": uint64(r.KeepFreeSpace),
+			}, t)
+	}
+	return nil
+}
+
+// execQuery runs a query and decodes the rows of the JSON result into the
+// slice pointed to by i.
+func (ch *ClickHouse) execQuery(u *url.URL, query string, i interface{}) error {
+	q := u.Query()
+	q.Set("query", query+" FORMAT JSON")
+	reqURL := *u
+	reqURL.RawQuery = q.Encode()
+
+	req, err := http.NewRequest("GET", reqURL.String(), nil)
+	if err != nil {
+		return err
+	}
+	if ch.Username != "" {
+		req.Header.Set("X-ClickHouse-User", ch.Username)
+	}
+	if ch.Password != "" {
+		req.Header.Set("X-ClickHouse-Key", ch.Password)
+	}
+
+	resp, err := ch.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned HTTP status %s: %s", u.Host, resp.Status,
+			strings.TrimSpace(string(bytes.SplitN(body, []byte("\n"), 2)[0])))
+	}
+
+	var result struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return err
+	}
+	return json.Unmarshal(result.Data, i)
+}
+
+func copyTags(tags map[string]string) map[string]string {
+	t := make(map[string]string, len(tags))
+	for k, v := range tags {
+		t[k] = v
+	}
+	return t
+}
+
+// chUInt64 is an UInt64 of the JSON output, quoted by default.
+type chUInt64 uint64
+
+func (i *chUInt64) UnmarshalJSON(b []byte) error {
+	v, err := strconv.ParseUint(string(bytes.Trim(b, `"`)), 10, 64)
+	if err != nil {
+		return err
+	}
+	*i = chUInt64(v)
+	return nil
+}
+
+// chInt64 is an Int64 of the JSON output, quoted by default.
+type chInt64 int64
+
+func (i *chInt64) UnmarshalJSON(b []byte) error {
+	v, err := strconv.ParseInt(string(bytes.Trim(b, `"`)), 10, 64)
+	if err != nil {
+		return err
+	}
+	*i = chInt64(v)
+	return nil
+}
+
+func init() {
+	inputs.Add("clickhouse", func() telegraf.Input {
+		return &ClickHouse{
+			Username: "default",
+			Timeout:  internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
@@ -0,0 +1,176 @@
+package clickhouse
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var responses = map[string]string{
+	"system.clusters": `[
+		{"cluster": "test_shard_localhost", "shard_num": 1, "replica_num": 1},
+		{"cluster": "events", "shard_num": 2, "replica_num": 1}
+	]`,
+	"system.metrics": `[
+		{"metric": "Query", "value": "1"},
+		{"metric": "TCPConnection", "value": "3"}
+	]`,
+	"system.events": `[
+		{"event": "SelectQuery", "value": "220"},
+		{"event": "InsertedRows", "value": "18250"}
+	]`,
+	"system.asynchronous_metrics": `[
+		{"metric": "Uptime", "value": 3600},
+		{"metric": "MarkCacheBytes", "value": 1024.5}
+	]`,
+	"system.parts": `[
+		{"database": "default", "table": "hits", "bytes": "104857600", "parts": "12", "rows": "1000000"}
+	]`,
+	"system.replicas": `[
+		{"database": "default", "table": "hits", "is_leader": 1, "is_readonly": 0,
+		 "is_session_expired": 0, "queue_size": 2, "inserts_in_queue": 1, "merges_in_queue": 1,
+		 "log_pointer_lag": "3", "absolute_delay": "5", "total_replicas": 2, "active_replicas": 2}
+	]`,
+	"system.mutations": `[
+		{"running": "1", "failed": "1", "completed": "4"}
+	]`,
+	"system.disks": `[
+		{"name": "default", "path": "/var/lib/clickhouse/", "free_space": "53687091200",
+		 "total_space": "107374182400", "keep_free_space": "0"}
+	]`,
+}
+
+func newServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "telegraf", r.Header.Get("X-ClickHouse-User"))
+		assert.Equal(t, "secret", r.Header.Get("X-ClickHouse-Key"))
+
+		query := r.URL.Query().Get("query")
+		assert.True(t, strings.HasSuffix(query, " FORMAT JSON"))
+		for table, data := range responses {
+			if strings.Contains(query, "FROM "+table) {
+				fmt.Fprintf(w, `{"meta": [], "data": %s, "rows": 1}`, data)
+				return
+			}
+		}
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintln(w, "Code: 60, e.displayText() = DB::Exception: Table does not exist")
+	}))
+}
+
+func TestGather(t *testing.T) {
+	ts := newServer(t)
+	defer ts.Close()
+
+	ch := &ClickHouse{
+		Servers:        []string{ts.URL},
+		Username:       "telegraf",
+		Password:       "secret",
+		ClusterExclude: []string{"test_*"},
+		Timeout:        internal.Duration{Duration: time.Second},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(ch.Gather))
+
+	tags := map[string]string{
+		"source":      "127.0.0.1",
+		"cluster":     "events",
+		"shard_num":   "2",
+		"replica_num": "1",
+	}
+	tableTags := map[string]string{
+		"source":      "127.0.0.1",
+		"cluster":     "events",
+		"shard_num":   "2",
+		"replica_num": "1",
+		"database":    "default",
+		"table":       "hits",
+	}
+
+	acc.AssertContainsTaggedFields(t, "clickhouse_metrics",
+		map[string]interface{}{
+			"query":          int64(1),
+			"tcp_connection": int64(3),
+		}, tags)
+	acc.AssertContainsTaggedFields(t, "clickhouse_events",
+		map[string]interface{}{
+			"select_query":  uint64(220),
+			"inserted_rows": uint64(18250),
+		}, tags)
+	acc.AssertContainsTaggedFields(t, "clickhouse_asynchronous_metrics",
+		map[string]interface{}{
+			"uptime":           3600.0,
+			"mark_cache_bytes": 1024.5,
+		}, tags)
+	acc.AssertContainsTaggedFields(t, "clickhouse_tables",
+		map[string]interface{}{
+			"bytes": uint64(104857600),
+			"parts": uint64(12),
+			"rows":  uint64(1000000),
+		}, tableTags)
+	acc.AssertContainsTaggedFields(t, "clickhouse_replicas",
+		map[string]interface{}{
+			"is_leader":          true,
+			"is_readonly":        false,
+			"is_session_expired": false,
+			"queue_size":         uint64(2),
+			"inserts_in_queue":   uint64(1),
+			"merges_in_queue":    uint64(1),
+			"log_pointer_lag":    int64(3),
+			"absolute_delay":     uint64(5),
+			"total_replicas":     uint64(2),
+			"active_replicas":    uint64(2),
+		}, tableTags)
+	acc.AssertContainsTaggedFields(t, "clickhouse_mutations",
+		map[string]interface{}{
+			"running":   uint64(1),
+			"failed":    uint64(1),
+			"completed": uint64(4),
+		}, tags)
+	acc.AssertContainsTaggedFields(t, "clickhouse_disks",
+		map[string]interface{}{
+			"free_space":      uint64(53687091200),
+			"total_space":     uint64(107374182400),
+			"keep_free_space": uint64(0),
+		}, map[string]string{
+			"source":      "127.0.0.1",
+			"cluster":     "events",
+			"shard_num":   "2",
+			"replica_num": "1",
+			"disk":        "default",
+			"path":        "/var/lib/clickhouse/",
+		})
+}
+
+func TestGatherMissingTable(t *testing.T) {
+	ts := newServer(t)
+	defer ts.Close()
+
+	disks := responses["system.disks"]
+	delete(responses, "system.disks")
+	defer func() { responses["system.disks"] = disks }()
+
+	ch := &ClickHouse{
+		Servers:  []string{ts.URL},
+		Username: "telegraf",
+		Password: "secret",
+		Timeout:  internal.Duration{Duration: time.Second},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, ch.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+	assert.Contains(t, acc.Errors[0].Error(), "Table does not exist")
+	assert.False(t, acc.HasMeasurement("clickhouse_disks"))
+	assert.True(t, acc.HasMeasurement("clickhouse_metrics"))
+	assert.Equal(t, "test_shard_localhost", acc.Metrics[0].Tags["cluster"])
+}
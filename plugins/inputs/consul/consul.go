@@ -3,6 +3,7 @@ package consul
 import (
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/consul/api"
 	"github.com/influxdata/telegraf"
@@ -19,6 +20,8 @@ type Consul struct {
 	Datacentre string
 	tls.ClientConfig
 	TagDelimiter string
+	Catalog      bool
+	Autopilot    bool
 
 	// client used to connect to Consul agnet
 	client *api.Client
@@ -52,6 +55,14 @@ var sampleConfig = `
   # When tags are formatted like "key:value" with ":" as a delimiter then
   # they will be splitted and reported as proper key:value in Telegraf
   # tag_delimiter = ":"
+
+  ## Gather the number of nodes and services, and of instances of each
+  ## service, registered in the catalog
+  # catalog = false
+
+  ## Gather the health of the Raft peers as seen by Autopilot, this requires
+  ## the operator:read ACL
+  # autopilot = false
 `
 
 func (c *Consul) Description() string {
@@ -154,9 +165,85 @@ func (c *Consul) Gather(acc telegraf.Accumulator) error {
 
 	c.GatherHealthCheck(acc, checks)
 
+	if c.Catalog {
+		if err := c.gatherCatalog(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+
+	if c.Autopilot {
+		health, err := c.client.Operator().AutopilotServerHealth(nil)
+		if err != nil {
+			acc.AddError(err)
+		} else {
+			c.GatherAutopilot(acc, health)
+		}
+	}
+
+	return nil
+}
+
+func (c *Consul) gatherCatalog(acc telegraf.Accumulator) error {
+	catalog := c.client.Catalog()
+
+	nodes, _, err := catalog.Nodes(nil)
+	if err != nil {
+		return err
+	}
+
+	services, _, err := catalog.Services(nil)
+	if err != nil {
+		return err
+	}
+
+	acc.AddFields("consul_catalog", map[string]interface{}{
+		"nodes":    len(nodes),
+		"services": len(services),
+	}, map[string]string{})
+
+	for name := range services {
+		instances, _, err := catalog.Service(name, "", nil)
+		if err != nil {
+			acc.AddError(err)
+			continue
+		}
+
+		acc.AddFields("consul_catalog_services", map[string]interface{}{
+			"instances": len(instances),
+		}, map[string]string{"service_name": name})
+	}
+
 	return nil
 }
 
+func (c *Consul) GatherAutopilot(acc telegraf.Accumulator, health *api.OperatorHealthReply) {
+	acc.AddFields("consul_autopilot", map[string]interface{}{
+		"healthy":           health.Healthy,
+		"failure_tolerance": health.FailureTolerance,
+	}, map[string]string{})
+
+	for _, server := range health.Servers {
+		record := map[string]interface{}{
+			"healthy":         server.Healthy,
+			"leader":          server.Leader,
+			"voter":           server.Voter,
+			"serf_status":     server.SerfStatus,
+			"version":         server.Version,
+			"last_contact_ms": float64(server.LastContact.Duration()) / float64(time.Millisecond),
+			"last_term":       server.LastTerm,
+			"last_index":      server.LastIndex,
+		}
+
+		tags := map[string]string{
+			"server_id":   server.ID,
+			"server_name": server.Name,
+			"address":     server.Address,
+		}
+
+		acc.AddFields("consul_autopilot_servers", record, tags)
+	}
+}
+
 func init() {
 	inputs.Add("consul", func() telegraf.Input {
 		return &Consul{}
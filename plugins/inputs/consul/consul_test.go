@@ -1,10 +1,16 @@
 package consul
 
 import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/consul/api"
 	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
 )
 
 var sampleChecks = []*api.HealthCheck{
@@ -76,3 +82,98 @@ func TestGatherHealthCheckWithDelimitedTags(t *testing.T) {
 
 	acc.AssertContainsTaggedFields(t, "consul_health_checks", expectedFields, expectedTags)
 }
+
+func TestGatherAutopilot(t *testing.T) {
+	health := &api.OperatorHealthReply{
+		Healthy:          true,
+		FailureTolerance: 1,
+		Servers: []api.ServerHealth{
+			{
+				ID:          "e349749b-3303-3ddf-959c-b5885a0e1f6e",
+				Name:        "node1",
+				Address:     "127.0.0.1:8300",
+				SerfStatus:  "alive",
+				Version:     "1.1.0",
+				Leader:      true,
+				LastContact: api.NewReadableDuration(1500 * time.Microsecond),
+				LastTerm:    2,
+				LastIndex:   46,
+				Healthy:     true,
+				Voter:       true,
+			},
+		},
+	}
+
+	var acc testutil.Accumulator
+
+	consul := &Consul{}
+	consul.GatherAutopilot(&acc, health)
+
+	acc.AssertContainsTaggedFields(t, "consul_autopilot",
+		map[string]interface{}{
+			"healthy":           true,
+			"failure_tolerance": 1,
+		},
+		map[string]string{})
+
+	acc.AssertContainsTaggedFields(t, "consul_autopilot_servers",
+		map[string]interface{}{
+			"healthy":         true,
+			"leader":          true,
+			"voter":           true,
+			"serf_status":     "alive",
+			"version":         "1.1.0",
+			"last_contact_ms": 1.5,
+			"last_term":       uint64(2),
+			"last_index":      uint64(46),
+		},
+		map[string]string{
+			"server_id":   "e349749b-3303-3ddf-959c-b5885a0e1f6e",
+			"server_name": "node1",
+			"address":     "127.0.0.1:8300",
+		})
+}
+
+func TestGatherCatalog(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/health/state/any":
+			fmt.Fprint(w, `[]`)
+		case "/v1/catalog/nodes":
+			fmt.Fprint(w, `[{"Node": "node1", "Address": "10.0.0.1"}, {"Node": "node2", "Address": "10.0.0.2"}]`)
+		case "/v1/catalog/services":
+			fmt.Fprint(w, `{"consul": [], "web": ["v1"]}`)
+		case "/v1/catalog/service/consul":
+			fmt.Fprint(w, `[{"Node": "node1", "ServiceName": "consul"}]`)
+		case "/v1/catalog/service/web":
+			fmt.Fprint(w, `[{"Node": "node1", "ServiceName": "web"}, {"Node": "node2", "ServiceName": "web"}]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+
+	var acc testutil.Accumulator
+
+	consul := &Consul{
+		Address: strings.TrimPrefix(ts.URL, "http://"),
+		Catalog: true,
+	}
+	require.NoError(t, consul.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	acc.AssertContainsTaggedFields(t, "consul_catalog",
+		map[string]interface{}{
+			"nodes":    2,
+			"services": 2,
+		},
+		map[string]string{})
+
+	acc.AssertContainsTaggedFields(t, "consul_catalog_services",
+		map[string]interface{}{"instances": 1},
+		map[string]string{"service_name": "consul"})
+
+	acc.AssertContainsTaggedFields(t, "consul_catalog_services",
+		map[string]interface{}{"instances": 2},
+		map[string]string{"service_name": "web"})
+}
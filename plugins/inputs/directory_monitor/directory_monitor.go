@@ -0,0 +1,328 @@
+package directory_monitor
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+const (
+	parseLineByLine = "line-by-line"
+	parseAtOnce     = "at-once"
+
+	defaultMaxConcurrentFiles = 1
+	defaultFileQueueSize      = 100
+	defaultMaxLineSize        = 64 * 1024
+)
+
+var sampleConfig = `
+  ## The directory to monitor and read files from.
+  directory = ""
+
+  ## The directory to move the files to once they are successfully parsed.
+  finished_directory = ""
+
+  ## The directory to move the files to when they fail to be read or parsed.
+  error_directory = ""
+
+  ## Glob patterns of the file names to read, by default all the files of the
+  ## directory are read.  Files ending in .gz are decompressed.
+  # files_to_monitor = ["*.txt", "*.txt.gz"]
+
+  ## Glob patterns of the file names to ignore.
+  # files_to_ignore = [".*"]
+
+  ## Only read the files which have not been modified for this long, to not
+  ## read files which are still being written or transferred.
+  # directory_duration_threshold = "50ms"
+
+  ## Maximum number of files read and parsed at the same time.
+  # max_concurrent_files = 1
+
+  ## Maximum number of files waiting to be read, new files are left in the
+  ## directory for the next interval once the queue is full.
+  # file_queue_size = 100
+
+  ## Parse the files line by line or at once.  Data formats spanning several
+  ## lines, such as json, must be parsed at once.
+  # parse_method = "line-by-line"
+
+  ## Maximum size of a line when parsing line by line.
+  # max_line_size = 65536
+
+  ## The name of a tag holding the name of the file the metrics are read from.
+  # file_tag = ""
+
+  ## Data format to consume.
+  ## Each data format has its own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_INPUT.md
+  data_format = "influx"
+`
+
+// DirectoryMonitor reads the files dropped in a directory, and moves them
+// once read.
+type DirectoryMonitor struct {
+	Directory                  string
+	FinishedDirectory          string
+	ErrorDirectory             string
+	FilesToMonitor             []string
+	FilesToIgnore              []string
+	DirectoryDurationThreshold internal.Duration
+	MaxConcurrentFiles         int
+	FileQueueSize              int
+	ParseMethod                string
+	MaxLineSize                int
+	FileTag                    string
+
+	parser  parsers.Parser
+	monitor filter.Filter
+	ignore  filter.Filter
+	acc     telegraf.Accumulator
+	files   chan string
+	wg      sync.WaitGroup
+
+	// queued holds the files waiting or being read, to not queue them twice
+	queued map[string]bool
+	sync.Mutex
+}
+
+func (d *DirectoryMonitor) SampleConfig() string {
+	return sampleConfig
+}
+
+func (d *DirectoryMonitor) Description() string {
+	return "Ingests the files dropped in a directory, and moves them once read"
+}
+
+func (d *DirectoryMonitor) SetParser(parser parsers.Parser) {
+	d.parser = parser
+}
+
+func (d *DirectoryMonitor) Start(acc telegraf.Accumulator) error {
+	if d.Directory == "" || d.FinishedDirectory == "" || d.ErrorDirectory == "" {
+		return errors.New("directory, finished_directory and error_directory are required")
+	}
+	switch d.ParseMethod {
+	case "":
+		d.ParseMethod = parseLineByLine
+	case parseLineByLine, parseAtOnce:
+	default:
+		return fmt.Errorf("unknown parse_method %q", d.ParseMethod)
+	}
+	if d.MaxConcurrentFiles <= 0 {
+		d.MaxConcurrentFiles = defaultMaxConcurrentFiles
+	}
+	if d.FileQueueSize <= 0 {
+		d.FileQueueSize = defaultFileQueueSize
+	}
+	if d.MaxLineSize <= 0 {
+		d.MaxLineSize = defaultMaxLineSize
+	}
+
+	var err error
+	if len(d.FilesToMonitor) > 0 {
+		if d.monitor, err = filter.Compile(d.FilesToMonitor); err != nil {
+			return fmt.Errorf("invalid files_to_monitor: %s", err)
+		}
+	}
+	if d.ignore, err = filter.Compile(d.FilesToIgnore); err != nil {
+		return fmt.Errorf("invalid files_to_ignore: %s", err)
+	}
+
+	for _, dir := range []string{d.FinishedDirectory, d.ErrorDirectory} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	d.acc = acc
+	d.queued = make(map[string]bool)
+	d.files = make(chan string, d.FileQueueSize)
+	for i := 0; i < d.MaxConcurrentFiles; i++ {
+		d.wg.Add(1)
+		go d.worker()
+	}
+
+	return nil
+}
+
+func (d *DirectoryMonitor) Stop() {
+	close(d.files)
+	d.wg.Wait()
+}
+
+// Gather queues the files of the directory to be read by the workers.
+func (d *DirectoryMonitor) Gather(acc telegraf.Accumulator) error {
+	infos, err := ioutil.ReadDir(d.Directory)
+	if err != nil {
+		return err
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	now := time.Now()
+	for _, info := range infos {
+		name := info.Name()
+		if !info.Mode().IsRegular() || d.queued[name] {
+			continue
+		}
+		if d.monitor != nil && !d.monitor.Match(name) {
+			continue
+		}
+		if d.ignore != nil && d.ignore.Match(name) {
+			continue
+		}
+		if now.Sub(info.ModTime()) < d.DirectoryDurationThreshold.Duration {
+			continue
+		}
+
+		select {
+		case d.files <- name:
+			d.queued[name] = true
+		default:
+			// The queue is full, the file is queued on a later interval
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (d *DirectoryMonitor) worker() {
+	defer d.wg.Done()
+
+	for name := range d.files {
+		path := filepath.Join(d.Directory, name)
+		dest := d.FinishedDirectory
+		if err := d.readFile(path); err != nil {
+			d.acc.AddError(fmt.Errorf("E! [inputs.directory_monitor] error reading %s: %s", path, err))
+			dest = d.ErrorDirectory
+		}
+
+		if err := moveFile(path, filepath.Join(dest, name)); err != nil {
+			d.acc.AddError(fmt.Errorf("E! [inputs.directory_monitor] error moving %s to %s: %s", path, dest, err))
+		}
+
+		d.Lock()
+		delete(d.queued, name)
+		d.Unlock()
+	}
+}
+
+func (d *DirectoryMonitor) readFile(path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var reader io.Reader = file
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	var tags map[string]string
+	if d.FileTag != "" {
+		tags = map[string]string{d.FileTag: filepath.Base(path)}
+	}
+
+	if d.ParseMethod == parseAtOnce {
+		buf, err := ioutil.ReadAll(reader)
+		if err != nil {
+			return err
+		}
+		metrics, err := d.parser.Parse(buf)
+		if err != nil {
+			return err
+		}
+		d.addMetrics(metrics, tags)
+		return nil
+	}
+
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 0, 4096), d.MaxLineSize)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		m, err := d.parser.ParseLine(line)
+		if err != nil {
+			return err
+		}
+		d.addMetrics([]telegraf.Metric{m}, tags)
+	}
+	return scanner.Err()
+}
+
+func (d *DirectoryMonitor) addMetrics(metrics []telegraf.Metric, tags map[string]string) {
+	for _, m := range metrics {
+		for k, v := range tags {
+			m.AddTag(k, v)
+		}
+		d.acc.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
+	}
+}
+
+// moveFile renames the file, or copies it when the destination is on another
+// file system.
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+
+	log.Printf("D! [inputs.directory_monitor] copied %s to %s", src, dst)
+	return os.Remove(src)
+}
+
+func init() {
+	inputs.Add("directory_monitor", func() telegraf.Input {
+		return &DirectoryMonitor{
+			DirectoryDurationThreshold: internal.Duration{Duration: 50 * time.Millisecond},
+			MaxConcurrentFiles:         defaultMaxConcurrentFiles,
+			FileQueueSize:              defaultFileQueueSize,
+			ParseMethod:                parseLineByLine,
+			MaxLineSize:                defaultMaxLineSize,
+		}
+	})
+}
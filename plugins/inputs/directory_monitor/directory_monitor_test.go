@@ -0,0 +1,175 @@
+package directory_monitor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDirectoryMonitor(t *testing.T, dir string) *DirectoryMonitor {
+	parser, err := parsers.NewInfluxParser()
+	require.NoError(t, err)
+
+	d := &DirectoryMonitor{
+		Directory:         filepath.Join(dir, "in"),
+		FinishedDirectory: filepath.Join(dir, "finished"),
+		ErrorDirectory:    filepath.Join(dir, "error"),
+	}
+	d.SetParser(parser)
+	require.NoError(t, os.MkdirAll(d.Directory, 0755))
+	return d
+}
+
+func writeFile(t *testing.T, path string, data []byte) {
+	require.NoError(t, ioutil.WriteFile(path, data, 0644))
+}
+
+func assertExists(t *testing.T, path string) {
+	_, err := os.Stat(path)
+	assert.NoError(t, err, "%s does not exist", path)
+}
+
+func TestReadFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "directory_monitor")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	d := newDirectoryMonitor(t, dir)
+	d.FilesToIgnore = []string{"*.tmp"}
+	d.FileTag = "file"
+
+	writeFile(t, filepath.Join(d.Directory, "a.txt"), []byte("cpu,host=a usage=1 1500000000000000000\n\ncpu,host=b usage=2 1500000000000000000\n"))
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err = gz.Write([]byte("mem,host=a used=42i 1500000000000000000\n"))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	writeFile(t, filepath.Join(d.Directory, "b.txt.gz"), buf.Bytes())
+
+	writeFile(t, filepath.Join(d.Directory, "c.tmp"), []byte("cpu usage=3\n"))
+	writeFile(t, filepath.Join(d.Directory, "d.txt"), []byte("not line protocol\n"))
+
+	var acc testutil.Accumulator
+	require.NoError(t, d.Start(&acc))
+	require.NoError(t, d.Gather(&acc))
+	d.Stop()
+
+	ts := time.Unix(0, 1500000000000000000)
+	acc.AssertContainsTaggedFields(t, "cpu",
+		map[string]interface{}{"usage": 1.0},
+		map[string]string{"host": "a", "file": "a.txt"})
+	acc.AssertContainsTaggedFields(t, "cpu",
+		map[string]interface{}{"usage": 2.0},
+		map[string]string{"host": "b", "file": "a.txt"})
+	acc.AssertContainsTaggedFields(t, "mem",
+		map[string]interface{}{"used": int64(42)},
+		map[string]string{"host": "a", "file": "b.txt.gz"})
+	for _, m := range acc.Metrics {
+		assert.Equal(t, ts, m.Time)
+	}
+	assert.Len(t, acc.Metrics, 3)
+	assert.Len(t, acc.Errors, 1)
+
+	assertExists(t, filepath.Join(d.FinishedDirectory, "a.txt"))
+	assertExists(t, filepath.Join(d.FinishedDirectory, "b.txt.gz"))
+	assertExists(t, filepath.Join(d.ErrorDirectory, "d.txt"))
+	assertExists(t, filepath.Join(d.Directory, "c.tmp"))
+}
+
+func TestReadAtOnce(t *testing.T) {
+	dir, err := ioutil.TempDir("", "directory_monitor")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	parser, err := parsers.NewParser(&parsers.Config{
+		DataFormat: "json",
+		MetricName: "drop",
+	})
+	require.NoError(t, err)
+
+	d := newDirectoryMonitor(t, dir)
+	d.SetParser(parser)
+	d.ParseMethod = "at-once"
+	d.FilesToMonitor = []string{"*.json"}
+
+	writeFile(t, filepath.Join(d.Directory, "a.json"), []byte("{\n  \"value\": 1\n}\n"))
+	writeFile(t, filepath.Join(d.Directory, "b.txt"), []byte("cpu usage=3\n"))
+
+	var acc testutil.Accumulator
+	require.NoError(t, d.Start(&acc))
+	require.NoError(t, d.Gather(&acc))
+	d.Stop()
+
+	require.Empty(t, acc.Errors)
+	acc.AssertContainsFields(t, "drop", map[string]interface{}{"value": 1.0})
+	assert.Len(t, acc.Metrics, 1)
+
+	assertExists(t, filepath.Join(d.FinishedDirectory, "a.json"))
+	assertExists(t, filepath.Join(d.Directory, "b.txt"))
+}
+
+func TestDurationThreshold(t *testing.T) {
+	dir, err := ioutil.TempDir("", "directory_monitor")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	d := newDirectoryMonitor(t, dir)
+	d.DirectoryDurationThreshold = internal.Duration{Duration: time.Hour}
+
+	path := filepath.Join(d.Directory, "a.txt")
+	writeFile(t, path, []byte("cpu usage=1\n"))
+
+	var acc testutil.Accumulator
+	require.NoError(t, d.Start(&acc))
+	require.NoError(t, d.Gather(&acc))
+
+	// The file is too recent to be read
+	assertExists(t, path)
+
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(path, old, old))
+	require.NoError(t, d.Gather(&acc))
+	d.Stop()
+
+	acc.AssertContainsFields(t, "cpu", map[string]interface{}{"usage": 1.0})
+	assertExists(t, filepath.Join(d.FinishedDirectory, "a.txt"))
+}
+
+func TestQueueFull(t *testing.T) {
+	dir, err := ioutil.TempDir("", "directory_monitor")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	d := newDirectoryMonitor(t, dir)
+	writeFile(t, filepath.Join(d.Directory, "a.txt"), []byte("cpu usage=1\n"))
+	writeFile(t, filepath.Join(d.Directory, "b.txt"), []byte("cpu usage=2\n"))
+
+	// Queue the files without workers to read them
+	d.FileQueueSize = 1
+	d.files = make(chan string, d.FileQueueSize)
+	d.queued = make(map[string]bool)
+
+	var acc testutil.Accumulator
+	require.NoError(t, d.Gather(&acc))
+	require.NoError(t, d.Gather(&acc))
+	assert.Equal(t, map[string]bool{"a.txt": true}, d.queued)
+	assert.Len(t, d.files, 1)
+}
+
+func TestRequiredDirectories(t *testing.T) {
+	d := &DirectoryMonitor{Directory: "/tmp"}
+
+	var acc testutil.Accumulator
+	assert.Error(t, d.Start(&acc))
+}
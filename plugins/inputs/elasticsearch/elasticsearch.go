@@ -6,11 +6,13 @@ import (
 	"io/ioutil"
 	"net/http"
 	"regexp"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
@@ -75,6 +77,17 @@ type clusterStats struct {
 	Nodes       interface{} `json:"nodes"`
 }
 
+type pendingTask struct {
+	Priority          string `json:"priority"`
+	TimeInQueueMillis int64  `json:"time_in_queue_millis"`
+}
+
+type indexStat struct {
+	Primaries interface{}              `json:"primaries"`
+	Total     interface{}              `json:"total"`
+	Shards    map[string][]interface{} `json:"shards"`
+}
+
 type catMaster struct {
 	NodeID   string `json:"id"`
 	NodeIP   string `json:"ip"`
@@ -104,15 +117,50 @@ const sampleConfig = `
   ##  - cluster
   # cluster_health_level = "indices"
 
-  ## Set cluster_stats to true when you want to also obtain cluster stats from the
-  ## Master node.
+  ## Set cluster_stats to true when you want to also obtain cluster stats.
   cluster_stats = false
 
+  ## Only gather cluster_stats from the master node, this requires local = true.
+  cluster_stats_only_from_master = true
+
+  ## Set cluster_pending_tasks to true when you want to also obtain the
+  ## number of pending cluster tasks and the time they are waiting for.
+  # cluster_pending_tasks = false
+
+  ## Set shard_allocation to true when you want to also obtain the number of
+  ## shards and the disk usage of each node, only for the local node when
+  ## local is true.
+  # shard_allocation = false
+
+  ## Set indices_stats to true when you want to also obtain per index stats.
+  # indices_stats = false
+
+  ## Indices to gather the stats of, the names can contain wildcards, all
+  ## indices are gathered if empty.
+  # indices_include = ["logstash-*"]
+  # indices_exclude = [".*"]
+
+  ## Adjust indices_level to also obtain per shard stats, only for the shards
+  ## of the local node when local is true.  The options are
+  ##  - indices (default)
+  ##  - shards
+  # indices_level = "indices"
+
+  ## When indices_aggregate is true, the stats of the indices matching a
+  ## pattern of indices_include with a wildcard are summed and reported with
+  ## the pattern as index name, to limit the number of series of time based
+  ## indices.
+  # indices_aggregate = false
+
   ## node_stats is a list of sub-stats that you want to have gathered. Valid options
   ## are "indices", "os", "process", "jvm", "thread_pool", "fs", "transport", "http",
   ## "breaker". Per default, all stats are gathered.
   # node_stats = ["jvm", "http"]
 
+  ## Optional API key, the base64 encoding of the key id and the key joined
+  ## by a colon, as returned by the create API key API.
+  # api_key = ""
+
   ## Optional TLS Config
   # tls_ca = "/etc/telegraf/ca.pem"
   # tls_cert = "/etc/telegraf/cert.pem"
@@ -124,25 +172,43 @@ const sampleConfig = `
 // Elasticsearch is a plugin to read stats from one or many Elasticsearch
 // servers.
 type Elasticsearch struct {
-	Local              bool
-	Servers            []string
-	HttpTimeout        internal.Duration
-	ClusterHealth      bool
-	ClusterHealthLevel string
-	ClusterStats       bool
-	NodeStats          []string
+	Local                      bool
+	Servers                    []string
+	HttpTimeout                internal.Duration
+	ClusterHealth              bool
+	ClusterHealthLevel         string
+	ClusterStats               bool
+	ClusterStatsOnlyFromMaster bool
+	ClusterPendingTasks        bool
+	ShardAllocation            bool
+	IndicesStats               bool
+	IndicesInclude             []string
+	IndicesExclude             []string
+	IndicesLevel               string
+	IndicesAggregate           bool
+	NodeStats                  []string
+	ApiKey                     string
 	tls.ClientConfig
 
 	client                  *http.Client
 	catMasterResponseTokens []string
 	isMaster                bool
+	indexFilter             filter.Filter
+	aggregateFilters        []indexPattern
+}
+
+type indexPattern struct {
+	pattern string
+	filter  filter.Filter
 }
 
 // NewElasticsearch return a new instance of Elasticsearch
 func NewElasticsearch() *Elasticsearch {
 	return &Elasticsearch{
-		HttpTimeout:        internal.Duration{Duration: time.Second * 5},
-		ClusterHealthLevel: "indices",
+		HttpTimeout:                internal.Duration{Duration: time.Second * 5},
+		ClusterHealthLevel:         "indices",
+		ClusterStatsOnlyFromMaster: true,
+		IndicesLevel:               "indices",
 	}
 }
 
@@ -181,6 +247,12 @@ func (e *Elasticsearch) Gather(acc telegraf.Accumulator) error {
 		e.client = client
 	}
 
+	if e.IndicesStats && e.indexFilter == nil {
+		if err := e.compileIndexFilters(); err != nil {
+			return err
+		}
+	}
+
 	var wg sync.WaitGroup
 	wg.Add(len(e.Servers))
 
@@ -190,7 +262,7 @@ func (e *Elasticsearch) Gather(acc telegraf.Accumulator) error {
 			url := e.nodeStatsUrl(s)
 			e.isMaster = false
 
-			if e.ClusterStats {
+			if e.ClusterStats && e.ClusterStatsOnlyFromMaster {
 				// get cat/master information here so NodeStats can determine
 				// whether this node is the Master
 				if err := e.setCatMaster(s + "/_cat/master"); err != nil {
@@ -216,12 +288,48 @@ func (e *Elasticsearch) Gather(acc telegraf.Accumulator) error {
 				}
 			}
 
-			if e.ClusterStats && e.isMaster {
+			if e.ClusterStats && (e.isMaster || !e.ClusterStatsOnlyFromMaster) {
 				if err := e.gatherClusterStats(s+"/_cluster/stats", acc); err != nil {
 					acc.AddError(fmt.Errorf(mask.ReplaceAllString(err.Error(), "http(s)://XXX:XXX@")))
 					return
 				}
 			}
+
+			if e.ClusterPendingTasks {
+				if err := e.gatherPendingTasks(s+"/_cluster/pending_tasks", acc); err != nil {
+					acc.AddError(fmt.Errorf(mask.ReplaceAllString(err.Error(), "http(s)://XXX:XXX@")))
+					return
+				}
+			}
+
+			if e.ShardAllocation {
+				url = s + "/_cat/allocation?format=json&bytes=b"
+				if e.Local {
+					url = s + "/_cat/allocation/_local?format=json&bytes=b"
+				}
+				if err := e.gatherShardAllocation(url, acc); err != nil {
+					acc.AddError(fmt.Errorf(mask.ReplaceAllString(err.Error(), "http(s)://XXX:XXX@")))
+					return
+				}
+			}
+
+			if e.IndicesStats {
+				var localNodeID string
+				if e.Local && e.IndicesLevel == "shards" {
+					id, err := e.localNodeID(s + "/_nodes/_local?filter_path=nodes.*.name")
+					if err != nil {
+						acc.AddError(fmt.Errorf(mask.ReplaceAllString(err.Error(), "http(s)://XXX:XXX@")))
+						return
+					}
+					localNodeID = id
+				}
+
+				url = s + "/_stats?level=" + e.IndicesLevel
+				if err := e.gatherIndicesStats(url, localNodeID, acc); err != nil {
+					acc.AddError(fmt.Errorf(mask.ReplaceAllString(err.Error(), "http(s)://XXX:XXX@")))
+					return
+				}
+			}
 		}(serv, acc)
 	}
 
@@ -279,7 +387,7 @@ func (e *Elasticsearch) gatherNodeStats(url string, acc telegraf.Accumulator) er
 			"cluster_name": nodeStats.ClusterName,
 		}
 
-		if e.ClusterStats {
+		if e.ClusterStats && e.ClusterStatsOnlyFromMaster {
 			// check for master
 			e.isMaster = (id == e.catMasterResponseTokens[0])
 		}
@@ -399,8 +507,282 @@ func (e *Elasticsearch) gatherClusterStats(url string, acc telegraf.Accumulator)
 	return nil
 }
 
+func (e *Elasticsearch) gatherPendingTasks(url string, acc telegraf.Accumulator) error {
+	pendingTasks := &struct {
+		Tasks []pendingTask `json:"tasks"`
+	}{}
+	if err := e.gatherJsonData(url, pendingTasks); err != nil {
+		return err
+	}
+
+	fields := map[string]interface{}{
+		"count":                    len(pendingTasks.Tasks),
+		"max_time_in_queue_millis": int64(0),
+		"priority_immediate":       0,
+		"priority_urgent":          0,
+		"priority_high":            0,
+		"priority_normal":          0,
+		"priority_low":             0,
+		"priority_languid":         0,
+	}
+	for _, task := range pendingTasks.Tasks {
+		if task.TimeInQueueMillis > fields["max_time_in_queue_millis"].(int64) {
+			fields["max_time_in_queue_millis"] = task.TimeInQueueMillis
+		}
+		key := "priority_" + strings.ToLower(task.Priority)
+		if count, ok := fields[key].(int); ok {
+			fields[key] = count + 1
+		}
+	}
+	acc.AddFields("elasticsearch_cluster_pending_tasks", fields, map[string]string{})
+	return nil
+}
+
+func (e *Elasticsearch) gatherShardAllocation(url string, acc telegraf.Accumulator) error {
+	var allocations []map[string]interface{}
+	if err := e.gatherJsonData(url, &allocations); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, allocation := range allocations {
+		tags := map[string]string{}
+		for _, key := range []string{"node", "host"} {
+			if v, ok := allocation[key].(string); ok {
+				tags[key] = v
+			}
+		}
+
+		fields := make(map[string]interface{})
+		for _, key := range []string{"shards", "disk.indices", "disk.used", "disk.avail", "disk.total", "disk.percent"} {
+			v, ok := allocation[key].(string)
+			if !ok {
+				continue
+			}
+			if iv, err := strconv.ParseInt(v, 10, 64); err == nil {
+				fields[strings.Replace(key, ".", "_", -1)] = iv
+			}
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		acc.AddFields("elasticsearch_shard_allocation", fields, tags, now)
+	}
+	return nil
+}
+
+func (e *Elasticsearch) localNodeID(url string) (string, error) {
+	nodes := &struct {
+		Nodes map[string]interface{} `json:"nodes"`
+	}{}
+	if err := e.gatherJsonData(url, nodes); err != nil {
+		return "", err
+	}
+	for id := range nodes.Nodes {
+		return id, nil
+	}
+	return "", fmt.Errorf("elasticsearch: Unable to retrieve local node id")
+}
+
+func (e *Elasticsearch) compileIndexFilters() error {
+	indexFilter, err := filter.NewIncludeExcludeFilter(e.IndicesInclude, e.IndicesExclude)
+	if err != nil {
+		return err
+	}
+
+	var aggregateFilters []indexPattern
+	if e.IndicesAggregate {
+		for _, pattern := range e.IndicesInclude {
+			if !strings.ContainsAny(pattern, "*?[") {
+				continue
+			}
+			f, err := filter.Compile([]string{pattern})
+			if err != nil {
+				return err
+			}
+			aggregateFilters = append(aggregateFilters, indexPattern{pattern, f})
+		}
+	}
+
+	e.indexFilter = indexFilter
+	e.aggregateFilters = aggregateFilters
+	return nil
+}
+
+// indexName returns the name the stats of an index are reported with, the
+// matching pattern when the indices are aggregated.
+func (e *Elasticsearch) indexName(name string) string {
+	for _, p := range e.aggregateFilters {
+		if p.filter.Match(name) {
+			return p.pattern
+		}
+	}
+	return name
+}
+
+func (e *Elasticsearch) gatherIndicesStats(url string, localNodeID string, acc telegraf.Accumulator) error {
+	indicesStats := &struct {
+		All     indexStat            `json:"_all"`
+		Indices map[string]indexStat `json:"indices"`
+	}{}
+	if err := e.gatherJsonData(url, indicesStats); err != nil {
+		return err
+	}
+	now := time.Now()
+
+	// the stats of all the indices are only reported when they are not
+	// filtered
+	if len(e.IndicesInclude) == 0 && len(e.IndicesExclude) == 0 {
+		if err := addIndexStats("_all", indicesStats.All, now, acc); err != nil {
+			return err
+		}
+	}
+
+	aggregated := make(map[string]map[string]map[string]interface{})
+	for name, stats := range indicesStats.Indices {
+		if e.indexFilter != nil && !e.indexFilter.Match(name) {
+			continue
+		}
+
+		indexName := e.indexName(name)
+		if indexName == name {
+			if err := addIndexStats(name, stats, now, acc); err != nil {
+				return err
+			}
+
+			for shardNumber, shards := range stats.Shards {
+				for _, shard := range shards {
+					if err := addShardStats(name, shardNumber, shard, localNodeID, now, acc); err != nil {
+						return err
+					}
+				}
+			}
+		} else {
+			// the shards of the aggregated indices are not reported
+			if aggregated[indexName] == nil {
+				aggregated[indexName] = make(map[string]map[string]interface{})
+			}
+			for p, s := range map[string]interface{}{"primaries": stats.Primaries, "total": stats.Total} {
+				f := jsonparser.JSONFlattener{}
+				if err := f.FlattenJSON("", s); err != nil {
+					return err
+				}
+				if aggregated[indexName][p] == nil {
+					aggregated[indexName][p] = make(map[string]interface{})
+				}
+				sumFields(aggregated[indexName][p], f.Fields)
+			}
+		}
+	}
+
+	for name, stats := range aggregated {
+		for p, fields := range stats {
+			acc.AddFields("elasticsearch_indices_stats_"+p, fields, map[string]string{"index_name": name}, now)
+		}
+	}
+	return nil
+}
+
+func addIndexStats(name string, stats indexStat, now time.Time, acc telegraf.Accumulator) error {
+	for p, s := range map[string]interface{}{"primaries": stats.Primaries, "total": stats.Total} {
+		if s == nil {
+			continue
+		}
+		f := jsonparser.JSONFlattener{}
+		// parse Json, ignoring strings and bools
+		if err := f.FlattenJSON("", s); err != nil {
+			return err
+		}
+		acc.AddFields("elasticsearch_indices_stats_"+p, f.Fields, map[string]string{"index_name": name}, now)
+	}
+	return nil
+}
+
+func addShardStats(
+	indexName string,
+	shardNumber string,
+	shard interface{},
+	localNodeID string,
+	now time.Time,
+	acc telegraf.Accumulator,
+) error {
+	stats, ok := shard.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+
+	routing, _ := stats["routing"].(map[string]interface{})
+	nodeID, _ := routing["node"].(string)
+	if localNodeID != "" && nodeID != localNodeID {
+		return nil
+	}
+	state, _ := routing["state"].(string)
+	shardType := "replica"
+	if primary, _ := routing["primary"].(bool); primary {
+		shardType = "primary"
+	}
+	delete(stats, "routing")
+
+	f := jsonparser.JSONFlattener{}
+	// parse Json, ignoring strings and bools
+	if err := f.FlattenJSON("", stats); err != nil {
+		return err
+	}
+	f.Fields["routing_state"] = mapShardStatusToCode(state)
+
+	tags := map[string]string{
+		"index_name": indexName,
+		"shard_name": shardNumber,
+		"node_id":    nodeID,
+		"type":       shardType,
+	}
+	acc.AddFields("elasticsearch_indices_stats_shards", f.Fields, tags, now)
+	return nil
+}
+
+// sumFields adds the values of fields to sum.
+func sumFields(sum map[string]interface{}, fields map[string]interface{}) {
+	for k, v := range fields {
+		fv, ok := v.(float64)
+		if !ok {
+			continue
+		}
+		if current, ok := sum[k].(float64); ok {
+			sum[k] = current + fv
+		} else {
+			sum[k] = fv
+		}
+	}
+}
+
+// perform shard routing state mapping
+func mapShardStatusToCode(s string) int {
+	switch strings.ToUpper(s) {
+	case "UNASSIGNED":
+		return 1
+	case "INITIALIZING":
+		return 2
+	case "STARTED":
+		return 3
+	case "RELOCATING":
+		return 4
+	}
+	return 0
+}
+
+func (e *Elasticsearch) get(url string) (*http.Response, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if e.ApiKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+e.ApiKey)
+	}
+	return e.client.Do(req)
+}
+
 func (e *Elasticsearch) setCatMaster(url string) error {
-	r, err := e.client.Get(url)
+	r, err := e.get(url)
 	if err != nil {
 		return err
 	}
@@ -423,7 +805,7 @@ func (e *Elasticsearch) setCatMaster(url string) error {
 }
 
 func (e *Elasticsearch) gatherJsonData(url string, v interface{}) error {
-	r, err := e.client.Get(url)
+	r, err := e.get(url)
 	if err != nil {
 		return err
 	}
@@ -272,3 +272,117 @@ func newElasticsearchWithClient() *Elasticsearch {
 	es.client = &http.Client{}
 	return es
 }
+
+func TestGatherPendingTasks(t *testing.T) {
+	es := newElasticsearchWithClient()
+	es.client.Transport = newTransportMock(http.StatusOK, clusterPendingTasksResponse)
+
+	var acc testutil.Accumulator
+	require.NoError(t, es.gatherPendingTasks("junk", &acc))
+
+	acc.AssertContainsTaggedFields(t, "elasticsearch_cluster_pending_tasks",
+		clusterPendingTasksExpected, map[string]string{})
+}
+
+func TestGatherShardAllocation(t *testing.T) {
+	es := newElasticsearchWithClient()
+	es.client.Transport = newTransportMock(http.StatusOK, shardAllocationResponse)
+
+	var acc testutil.Accumulator
+	require.NoError(t, es.gatherShardAllocation("junk", &acc))
+
+	acc.AssertContainsTaggedFields(t, "elasticsearch_shard_allocation",
+		shardAllocationExpected,
+		map[string]string{"node": "test.host.com", "host": "127.0.0.1"})
+	acc.AssertContainsTaggedFields(t, "elasticsearch_shard_allocation",
+		map[string]interface{}{"shards": int64(5)},
+		map[string]string{"node": "UNASSIGNED"})
+}
+
+func TestGatherIndicesStats(t *testing.T) {
+	es := newElasticsearchWithClient()
+	es.IndicesStats = true
+	es.client.Transport = newTransportMock(http.StatusOK, indicesStatsResponse)
+	require.NoError(t, es.compileIndexFilters())
+
+	var acc testutil.Accumulator
+	require.NoError(t, es.gatherIndicesStats("junk", "", &acc))
+
+	acc.AssertContainsTaggedFields(t, "elasticsearch_indices_stats_primaries",
+		map[string]interface{}{
+			"docs_count":          float64(30),
+			"docs_deleted":        float64(0),
+			"store_size_in_bytes": float64(3000),
+		},
+		map[string]string{"index_name": "_all"})
+	acc.AssertContainsTaggedFields(t, "elasticsearch_indices_stats_primaries",
+		twitterPrimariesExpected,
+		map[string]string{"index_name": "twitter"})
+	acc.AssertContainsTaggedFields(t, "elasticsearch_indices_stats_shards",
+		twitterShardExpected,
+		map[string]string{
+			"index_name": "twitter",
+			"shard_name": "0",
+			"node_id":    "SDFsfSDFsdfFSDSDfSFDSDF",
+			"type":       "primary",
+		})
+	assert.True(t, acc.HasTag("elasticsearch_indices_stats_shards", "type"))
+	assert.Len(t, acc.Metrics, 10)
+}
+
+func TestGatherIndicesStatsLocalShards(t *testing.T) {
+	es := newElasticsearchWithClient()
+	es.IndicesStats = true
+	es.IndicesInclude = []string{"twitter"}
+	es.client.Transport = newTransportMock(http.StatusOK, indicesStatsResponse)
+	require.NoError(t, es.compileIndexFilters())
+
+	var acc testutil.Accumulator
+	require.NoError(t, es.gatherIndicesStats("junk", "SDFsfSDFsdfFSDSDfSFDSDF", &acc))
+
+	// the primaries and total of twitter and its local shard
+	assert.Len(t, acc.Metrics, 3)
+	for _, m := range acc.Metrics {
+		assert.Equal(t, "twitter", m.Tags["index_name"])
+		if m.Measurement == "elasticsearch_indices_stats_shards" {
+			assert.Equal(t, "primary", m.Tags["type"])
+		}
+	}
+}
+
+func TestGatherIndicesStatsAggregate(t *testing.T) {
+	es := newElasticsearchWithClient()
+	es.IndicesStats = true
+	es.IndicesInclude = []string{"logstash-*"}
+	es.IndicesAggregate = true
+	es.client.Transport = newTransportMock(http.StatusOK, indicesStatsResponse)
+	require.NoError(t, es.compileIndexFilters())
+
+	var acc testutil.Accumulator
+	require.NoError(t, es.gatherIndicesStats("junk", "", &acc))
+
+	assert.Len(t, acc.Metrics, 2)
+	acc.AssertContainsTaggedFields(t, "elasticsearch_indices_stats_primaries",
+		logstashPrimariesExpected,
+		map[string]string{"index_name": "logstash-*"})
+}
+
+func TestGatherApiKey(t *testing.T) {
+	es := newElasticsearchWithClient()
+	es.ApiKey = "VnVhQ2ZHY0JDZGJrUW0tZTVhT3g6dWkybHAyYXhUTm1zeWFrdzl0dk5udw=="
+	transport := &apiKeyTransportMock{}
+	es.client.Transport = transport
+
+	var acc testutil.Accumulator
+	require.NoError(t, es.gatherPendingTasks("junk", &acc))
+	assert.Equal(t, "ApiKey "+es.ApiKey, transport.authorization)
+}
+
+type apiKeyTransportMock struct {
+	authorization string
+}
+
+func (t *apiKeyTransportMock) RoundTrip(r *http.Request) (*http.Response, error) {
+	t.authorization = r.Header.Get("Authorization")
+	return newTransportMock(http.StatusOK, clusterPendingTasksResponse).RoundTrip(r)
+}
@@ -1154,3 +1154,170 @@ var clusterstatsNodesExpected = map[string]interface{}{
 const IsMasterResult = "SDFsfSDFsdfFSDSDfSFDSDF 10.206.124.66 10.206.124.66 test.host.com "
 
 const IsNotMasterResult = "junk 10.206.124.66 10.206.124.66 test.junk.com "
+
+const clusterPendingTasksResponse = `
+{
+  "tasks": [
+    {
+      "insert_order": 101,
+      "priority": "URGENT",
+      "source": "create-index [foo_9], cause [api]",
+      "executing": true,
+      "time_in_queue_millis": 86,
+      "time_in_queue": "86ms"
+    },
+    {
+      "insert_order": 46,
+      "priority": "HIGH",
+      "source": "shard-started ([foo_2][1], node[tMTocMvQQgGCkj7QDHl3OA], [P], s[INITIALIZING]), reason [after recovery from shard_store]",
+      "executing": false,
+      "time_in_queue_millis": 842,
+      "time_in_queue": "842ms"
+    },
+    {
+      "insert_order": 45,
+      "priority": "HIGH",
+      "source": "shard-started ([foo_2][0], node[tMTocMvQQgGCkj7QDHl3OA], [P], s[INITIALIZING]), reason [after recovery from shard_store]",
+      "executing": false,
+      "time_in_queue_millis": 858,
+      "time_in_queue": "858ms"
+    }
+  ]
+}
+`
+
+var clusterPendingTasksExpected = map[string]interface{}{
+	"count":                    3,
+	"max_time_in_queue_millis": int64(858),
+	"priority_immediate":       0,
+	"priority_urgent":          1,
+	"priority_high":            2,
+	"priority_normal":          0,
+	"priority_low":             0,
+	"priority_languid":         0,
+}
+
+const shardAllocationResponse = `
+[
+  {
+    "shards": "5",
+    "disk.indices": "260",
+    "disk.used": "47588257792",
+    "disk.avail": "43218345984",
+    "disk.total": "90806603776",
+    "disk.percent": "52",
+    "host": "127.0.0.1",
+    "ip": "127.0.0.1",
+    "node": "test.host.com"
+  },
+  {
+    "shards": "5",
+    "disk.indices": null,
+    "disk.used": null,
+    "disk.avail": null,
+    "disk.total": null,
+    "disk.percent": null,
+    "host": null,
+    "ip": null,
+    "node": "UNASSIGNED"
+  }
+]
+`
+
+var shardAllocationExpected = map[string]interface{}{
+	"shards":       int64(5),
+	"disk_indices": int64(260),
+	"disk_used":    int64(47588257792),
+	"disk_avail":   int64(43218345984),
+	"disk_total":   int64(90806603776),
+	"disk_percent": int64(52),
+}
+
+const indicesStatsResponse = `
+{
+  "_shards": {
+    "total": 4,
+    "successful": 4,
+    "failed": 0
+  },
+  "_all": {
+    "primaries": {
+      "docs": {"count": 30, "deleted": 0},
+      "store": {"size_in_bytes": 3000}
+    },
+    "total": {
+      "docs": {"count": 60, "deleted": 0},
+      "store": {"size_in_bytes": 6000}
+    }
+  },
+  "indices": {
+    "twitter": {
+      "primaries": {
+        "docs": {"count": 10, "deleted": 0},
+        "store": {"size_in_bytes": 1000}
+      },
+      "total": {
+        "docs": {"count": 20, "deleted": 0},
+        "store": {"size_in_bytes": 2000}
+      },
+      "shards": {
+        "0": [
+          {
+            "routing": {"state": "STARTED", "primary": true, "node": "SDFsfSDFsdfFSDSDfSFDSDF", "relocating_node": null},
+            "docs": {"count": 10, "deleted": 0},
+            "store": {"size_in_bytes": 1000},
+            "commit": {"id": "8hvnmsRuOQ7RgR1Kl0Wkfg==", "generation": 3, "num_docs": 10}
+          },
+          {
+            "routing": {"state": "STARTED", "primary": false, "node": "kQbqLlAQQkm4f-8Oi8RDhQ", "relocating_node": null},
+            "docs": {"count": 10, "deleted": 0},
+            "store": {"size_in_bytes": 1000},
+            "commit": {"id": "8hvnmsRuOQ7RgR1Kl0Wkfg==", "generation": 3, "num_docs": 10}
+          }
+        ]
+      }
+    },
+    "logstash-2018.07.11": {
+      "primaries": {
+        "docs": {"count": 5, "deleted": 0},
+        "store": {"size_in_bytes": 500}
+      },
+      "total": {
+        "docs": {"count": 10, "deleted": 0},
+        "store": {"size_in_bytes": 1000}
+      }
+    },
+    "logstash-2018.07.12": {
+      "primaries": {
+        "docs": {"count": 15, "deleted": 0},
+        "store": {"size_in_bytes": 1500}
+      },
+      "total": {
+        "docs": {"count": 30, "deleted": 0},
+        "store": {"size_in_bytes": 3000}
+      }
+    }
+  }
+}
+`
+
+var twitterPrimariesExpected = map[string]interface{}{
+	"docs_count":          float64(10),
+	"docs_deleted":        float64(0),
+	"store_size_in_bytes": float64(1000),
+}
+
+var logstashPrimariesExpected = map[string]interface{}{
+	"docs_count":          float64(20),
+	"docs_deleted":        float64(0),
+	"store_size_in_bytes": float64(2000),
+}
+
+var twitterShardExpected = map[string]interface{}{
+	"docs_count":          float64(10),
+	"docs_deleted":        float64(0),
+	"store_size_in_bytes": float64(1000),
+	"commit_generation":   float64(3),
+	"commit_num_docs":     float64(10),
+	"routing_state":       3,
+}
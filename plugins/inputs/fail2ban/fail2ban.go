@@ -1,14 +1,17 @@
 package fail2ban
 
 import (
+	"database/sql"
 	"errors"
 	"fmt"
 	"os/exec"
 	"strings"
+	"time"
 
 	"strconv"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -17,13 +20,24 @@ var (
 )
 
 type Fail2ban struct {
-	path    string
-	UseSudo bool
+	path     string
+	UseSudo  bool
+	Database string            `toml:"database"`
+	BanTime  internal.Duration `toml:"ban_time"`
 }
 
 var sampleConfig = `
   ## Use sudo to run fail2ban-client
   use_sudo = false
+
+  ## Read the bans from the sqlite database of fail2ban instead of running
+  ## fail2ban-client.  The currently failed count is not stored in the
+  ## database, so only the ban counts are gathered.
+  # database = "/var/lib/fail2ban/fail2ban.sqlite3"
+
+  ## Duration of the bans, used with databases of fail2ban before 0.11 which
+  ## do not store it.
+  # ban_time = "10m"
 `
 
 var metricsTargets = []struct {
@@ -49,6 +63,10 @@ func (f *Fail2ban) SampleConfig() string {
 }
 
 func (f *Fail2ban) Gather(acc telegraf.Accumulator) error {
+	if f.Database != "" {
+		return f.gatherDatabase(acc)
+	}
+
 	if len(f.path) == 0 {
 		return errors.New("fail2ban-client not found: verify that fail2ban is installed and that fail2ban-client is in your PATH")
 	}
@@ -102,6 +120,106 @@ func (f *Fail2ban) Gather(acc telegraf.Accumulator) error {
 	return nil
 }
 
+// gatherDatabase counts the bans of each enabled jail in the sqlite database
+// of fail2ban.  The bans are current until their time of ban plus the ban
+// time, a negative ban time being permanent.
+func (f *Fail2ban) gatherDatabase(acc telegraf.Accumulator) error {
+	db, err := sql.Open("sqlite3", "file:"+f.Database+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("failed to open database %s: %s", f.Database, err)
+	}
+	defer db.Close()
+
+	hasBanTime, err := hasColumn(db, "bans", "bantime")
+	if err != nil {
+		return fmt.Errorf("failed to read database %s: %s", f.Database, err)
+	}
+
+	fields := make(map[string]map[string]interface{})
+	rows, err := db.Query("SELECT name FROM jails WHERE enabled = 1")
+	if err != nil {
+		return fmt.Errorf("failed to query jails of database %s: %s", f.Database, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var jail string
+		if err := rows.Scan(&jail); err != nil {
+			return err
+		}
+		fields[jail] = map[string]interface{}{"banned": 0, "total_banned": 0}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	now := time.Now().Unix()
+	query := `SELECT jail, COUNT(*),
+		SUM(CASE WHEN timeofban + ? > ? THEN 1 ELSE 0 END)
+		FROM bans GROUP BY jail`
+	args := []interface{}{int64(f.BanTime.Duration.Seconds()), now}
+	if hasBanTime {
+		query = `SELECT jail, COUNT(*),
+			SUM(CASE WHEN bantime < 0 OR timeofban + bantime > ? THEN 1 ELSE 0 END)
+			FROM bans GROUP BY jail`
+		args = []interface{}{now}
+	}
+
+	bans, err := db.Query(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query bans of database %s: %s", f.Database, err)
+	}
+	defer bans.Close()
+	for bans.Next() {
+		var jail string
+		var total, banned int
+		if err := bans.Scan(&jail, &total, &banned); err != nil {
+			return err
+		}
+		if _, ok := fields[jail]; !ok {
+			continue
+		}
+		fields[jail]["banned"] = banned
+		fields[jail]["total_banned"] = total
+	}
+	if err := bans.Err(); err != nil {
+		return err
+	}
+
+	for jail, jailFields := range fields {
+		acc.AddFields("fail2ban", jailFields, map[string]string{"jail": jail})
+	}
+	return nil
+}
+
+func hasColumn(db *sql.DB, table, column string) (bool, error) {
+	rows, err := db.Query("PRAGMA table_info(" + table + ")")
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return false, err
+	}
+	for rows.Next() {
+		values := make([]interface{}, len(columns))
+		names := make([]sql.RawBytes, len(columns))
+		for i := range values {
+			values[i] = &names[i]
+		}
+		if err := rows.Scan(values...); err != nil {
+			return false, err
+		}
+		for i, c := range columns {
+			if c == "name" && string(names[i]) == column {
+				return true, nil
+			}
+		}
+	}
+	return false, rows.Err()
+}
+
 func extractCount(line string) (string, int) {
 	for _, metricsTarget := range metricsTargets {
 		idx := strings.LastIndex(line, metricsTarget.target)
@@ -119,7 +237,9 @@ func extractCount(line string) (string, int) {
 }
 
 func init() {
-	f := Fail2ban{}
+	f := Fail2ban{
+		BanTime: internal.Duration{Duration: 10 * time.Minute},
+	}
 	path, _ := exec.LookPath("fail2ban-client")
 	if len(path) > 0 {
 		f.path = path
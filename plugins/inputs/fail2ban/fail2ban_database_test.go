@@ -0,0 +1,96 @@
+// +build cgo
+
+package fail2ban
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func createDatabase(t *testing.T, withBanTime bool) string {
+	dir, err := ioutil.TempDir("", "fail2ban")
+	require.NoError(t, err)
+	path := filepath.Join(dir, "fail2ban.sqlite3")
+
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	bans := "CREATE TABLE bans(jail TEXT NOT NULL, ip TEXT, timeofban INTEGER NOT NULL, data JSON)"
+	if withBanTime {
+		bans = "CREATE TABLE bans(jail TEXT NOT NULL, ip TEXT, timeofban INTEGER NOT NULL, bantime INTEGER NOT NULL, bancount INTEGER NOT NULL default 1, data JSON)"
+	}
+
+	now := time.Now().Unix()
+	statements := []string{
+		"CREATE TABLE jails(name TEXT NOT NULL UNIQUE, enabled INTEGER NOT NULL DEFAULT 1)",
+		bans,
+		"INSERT INTO jails VALUES ('sshd', 1), ('postfix', 1), ('disabled', 0)",
+	}
+	for _, statement := range statements {
+		_, err := db.Exec(statement)
+		require.NoError(t, err)
+	}
+
+	insert := "INSERT INTO bans(jail, ip, timeofban) VALUES (?, ?, ?)"
+	if withBanTime {
+		insert = "INSERT INTO bans(jail, ip, timeofban, bantime) VALUES (?, ?, ?, 600)"
+	}
+	for _, ban := range []struct {
+		jail      string
+		ip        string
+		timeofban int64
+	}{
+		{"sshd", "192.168.0.1", now - 60},
+		{"sshd", "192.168.0.2", now - 120},
+		{"sshd", "192.168.0.3", now - 3600},
+		{"disabled", "192.168.0.4", now},
+	} {
+		_, err := db.Exec(insert, ban.jail, ban.ip, ban.timeofban)
+		require.NoError(t, err)
+	}
+
+	if withBanTime {
+		_, err := db.Exec("INSERT INTO bans(jail, ip, timeofban, bantime) VALUES ('postfix', '192.168.0.5', ?, -1)", now-86400)
+		require.NoError(t, err)
+	}
+
+	return path
+}
+
+func TestGatherDatabase(t *testing.T) {
+	for _, withBanTime := range []bool{false, true} {
+		path := createDatabase(t, withBanTime)
+		defer os.RemoveAll(filepath.Dir(path))
+
+		f := &Fail2ban{
+			Database: path,
+			BanTime:  internal.Duration{Duration: 10 * time.Minute},
+		}
+
+		var acc testutil.Accumulator
+		require.NoError(t, acc.GatherError(f.Gather))
+
+		acc.AssertContainsTaggedFields(t, "fail2ban",
+			map[string]interface{}{"banned": 2, "total_banned": 3},
+			map[string]string{"jail": "sshd"})
+
+		postfix := map[string]interface{}{"banned": 0, "total_banned": 0}
+		if withBanTime {
+			// The ban of postfix is permanent
+			postfix = map[string]interface{}{"banned": 1, "total_banned": 1}
+		}
+		acc.AssertContainsTaggedFields(t, "fail2ban", postfix,
+			map[string]string{"jail": "postfix"})
+
+		require.Len(t, acc.Metrics, 2)
+	}
+}
@@ -0,0 +1,8 @@
+// +build cgo
+
+package fail2ban
+
+import (
+	// Register the sqlite3 driver used to read the database of fail2ban
+	_ "github.com/mattn/go-sqlite3"
+)
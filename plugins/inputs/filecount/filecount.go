@@ -0,0 +1,198 @@
+package filecount
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/gobwas/glob"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/globpath"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const sampleConfig = `
+  ## Directories to gather stats about.
+  ## These accept standard unix glob matching rules, but with the addition of
+  ## ** as a "super asterisk". ie:
+  ##   /var/log/**    -> recursively find all directories in /var/log and count files in each directory
+  ##   /var/log/*/*   -> find all directories with a parent dir in /var/log and count files in each directory
+  ##   /var/log       -> count all files in /var/log and all of its subdirectories
+  directories = ["/var/spool/postfix/deferred"]
+
+  ## Only count files whose name matches this glob pattern.
+  name = "*"
+
+  ## Only count files whose name matches this regular expression, in
+  ## addition to the name pattern.
+  # regex = ""
+
+  ## Count files in subdirectories of the directories.
+  recursive = true
+
+  ## Only count regular files.  Defaults to true.
+  regular_only = true
+
+  ## Only count files that are at least this size in bytes.  If size is
+  ## a negative number, only count files that are smaller than the
+  ## absolute value of size.
+  size = 0
+
+  ## Only count files that have not been touched for at least this
+  ## duration.  If mtime is negative, only count files that have been
+  ## touched in this duration.
+  mtime = "0s"
+`
+
+type FileCount struct {
+	Directories []string
+	Name        string
+	Regex       string
+	Recursive   bool
+	RegularOnly bool
+	Size        int64
+	MTime       internal.Duration `toml:"mtime"`
+
+	name  glob.Glob
+	regex *regexp.Regexp
+	globs map[string]*globpath.GlobPath
+}
+
+func (_ *FileCount) Description() string {
+	return "Count files in a directory"
+}
+
+func (_ *FileCount) SampleConfig() string { return sampleConfig }
+
+// match returns whether the file is counted.
+func (fc *FileCount) match(info os.FileInfo, now time.Time) bool {
+	if fc.RegularOnly && !info.Mode().IsRegular() {
+		return false
+	}
+	if fc.name != nil && !fc.name.Match(info.Name()) {
+		return false
+	}
+	if fc.regex != nil && !fc.regex.MatchString(info.Name()) {
+		return false
+	}
+
+	if fc.Size < 0 {
+		if info.Size() >= -fc.Size {
+			return false
+		}
+	} else if info.Size() < fc.Size {
+		return false
+	}
+
+	age := now.Sub(info.ModTime())
+	if fc.MTime.Duration < 0 {
+		if age > -fc.MTime.Duration {
+			return false
+		}
+	} else if age < fc.MTime.Duration {
+		return false
+	}
+
+	return true
+}
+
+func (fc *FileCount) count(acc telegraf.Accumulator, directory string, now time.Time) {
+	// Walk does not follow the symlink of the root directory
+	root, err := filepath.EvalSymlinks(directory)
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+
+	var count, size int64
+	walkfn := func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			acc.AddError(err)
+			return nil
+		}
+		if path == root {
+			return nil
+		}
+		if fc.match(info, now) {
+			count++
+			size += info.Size()
+		}
+		if info.IsDir() && !fc.Recursive {
+			return filepath.SkipDir
+		}
+		return nil
+	}
+	filepath.Walk(root, walkfn)
+
+	acc.AddFields("filecount",
+		map[string]interface{}{
+			"count":      count,
+			"size_bytes": size,
+		},
+		map[string]string{
+			"directory": directory,
+		})
+}
+
+func (fc *FileCount) Gather(acc telegraf.Accumulator) error {
+	if fc.name == nil && fc.Name != "" && fc.Name != "*" {
+		name, err := glob.Compile(fc.Name)
+		if err != nil {
+			return err
+		}
+		fc.name = name
+	}
+	if fc.regex == nil && fc.Regex != "" {
+		regex, err := regexp.Compile(fc.Regex)
+		if err != nil {
+			return err
+		}
+		fc.regex = regex
+	}
+	if fc.globs == nil {
+		fc.globs = make(map[string]*globpath.GlobPath)
+	}
+
+	now := time.Now()
+	for _, dir := range fc.Directories {
+		g, ok := fc.globs[dir]
+		if !ok {
+			var err error
+			if g, err = globpath.Compile(dir); err != nil {
+				acc.AddError(err)
+				continue
+			}
+			fc.globs[dir] = g
+		}
+
+		matches := g.Match()
+		if len(matches) == 0 {
+			acc.AddError(fmt.Errorf("no directory matches %s", dir))
+			continue
+		}
+		for path, info := range matches {
+			if info.IsDir() {
+				fc.count(acc, path, now)
+			}
+		}
+	}
+
+	return nil
+}
+
+func NewFileCount() *FileCount {
+	return &FileCount{
+		Name:        "*",
+		Recursive:   true,
+		RegularOnly: true,
+	}
+}
+
+func init() {
+	inputs.Add("filecount", func() telegraf.Input {
+		return NewFileCount()
+	})
+}
@@ -0,0 +1,133 @@
+package filecount
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// createTestDir creates a directory with the files foo (10 bytes, 2 hours
+// old), bar.log (100 bytes), baz.log (1000 bytes) and subdir/qux.log (10000
+// bytes).
+func createTestDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "filecount")
+	require.NoError(t, err)
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "subdir"), 0755))
+
+	files := map[string]int{
+		"foo":            10,
+		"bar.log":        100,
+		"baz.log":        1000,
+		"subdir/qux.log": 10000,
+	}
+	for name, size := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, ioutil.WriteFile(path, make([]byte, size), 0644))
+	}
+
+	old := time.Now().Add(-2 * time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "foo"), old, old))
+	return dir
+}
+
+func gather(t *testing.T, fc *FileCount, dir string, count, size int64) {
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(fc.Gather))
+	acc.AssertContainsTaggedFields(t, "filecount",
+		map[string]interface{}{"count": count, "size_bytes": size},
+		map[string]string{"directory": dir})
+}
+
+func TestCount(t *testing.T) {
+	dir := createTestDir(t)
+	defer os.RemoveAll(dir)
+
+	fc := NewFileCount()
+	fc.Directories = []string{dir}
+	gather(t, fc, dir, 4, 11110)
+}
+
+func TestNotRecursive(t *testing.T) {
+	dir := createTestDir(t)
+	defer os.RemoveAll(dir)
+
+	fc := NewFileCount()
+	fc.Directories = []string{dir}
+	fc.Recursive = false
+	gather(t, fc, dir, 3, 1110)
+}
+
+func TestNotRegularOnly(t *testing.T) {
+	dir := createTestDir(t)
+	defer os.RemoveAll(dir)
+
+	fc := NewFileCount()
+	fc.Directories = []string{dir}
+	fc.Recursive = false
+	fc.RegularOnly = false
+
+	info, err := os.Stat(filepath.Join(dir, "subdir"))
+	require.NoError(t, err)
+	gather(t, fc, dir, 4, 1110+info.Size())
+}
+
+func TestNameFilters(t *testing.T) {
+	dir := createTestDir(t)
+	defer os.RemoveAll(dir)
+
+	fc := NewFileCount()
+	fc.Directories = []string{dir}
+	fc.Name = "*.log"
+	gather(t, fc, dir, 3, 11100)
+
+	fc = NewFileCount()
+	fc.Directories = []string{dir}
+	fc.Name = "*.log"
+	fc.Regex = "^ba"
+	gather(t, fc, dir, 2, 1100)
+}
+
+func TestSizeFilter(t *testing.T) {
+	dir := createTestDir(t)
+	defer os.RemoveAll(dir)
+
+	fc := NewFileCount()
+	fc.Directories = []string{dir}
+	fc.Size = 1000
+	gather(t, fc, dir, 2, 11000)
+
+	fc.Size = -1000
+	gather(t, fc, dir, 2, 110)
+}
+
+func TestMTimeFilter(t *testing.T) {
+	dir := createTestDir(t)
+	defer os.RemoveAll(dir)
+
+	fc := NewFileCount()
+	fc.Directories = []string{dir}
+	fc.MTime = internal.Duration{Duration: time.Hour}
+	gather(t, fc, dir, 1, 10)
+
+	fc.MTime = internal.Duration{Duration: -time.Hour}
+	gather(t, fc, dir, 3, 11100)
+}
+
+func TestDirectoryGlob(t *testing.T) {
+	dir := createTestDir(t)
+	defer os.RemoveAll(dir)
+
+	fc := NewFileCount()
+	fc.Directories = []string{filepath.Join(dir, "sub*")}
+	gather(t, fc, filepath.Join(dir, "subdir"), 1, 10000)
+
+	fc.Directories = []string{filepath.Join(dir, "missing")}
+	var acc testutil.Accumulator
+	require.Error(t, acc.GatherError(fc.Gather))
+}
@@ -0,0 +1,273 @@
+package glusterfs
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+type runner func(binary string, useSudo bool, timeout time.Duration, args ...string) ([]byte, error)
+
+// GlusterFS gathers the statistics of the volumes and bricks of a GlusterFS
+// cluster from the XML output of the gluster command.
+type GlusterFS struct {
+	Binary        string
+	UseSudo       bool
+	Timeout       internal.Duration
+	Volumes       []string
+	GatherOpenFds bool `toml:"gather_open_fds"`
+	GatherProfile bool
+	GatherHeal    bool
+
+	run runner
+}
+
+var sampleConfig = `
+  ## Path of the gluster binary
+  # binary = "/usr/sbin/gluster"
+
+  ## The gluster command requires root access, setting use_sudo to true runs
+  ## it with sudo.  Sudo must be configured to allow the telegraf user to
+  ## run gluster without password.
+  # use_sudo = false
+
+  ## Timeout for each gluster command to complete
+  # timeout = "5s"
+
+  ## Volumes to gather, by default all the started volumes are gathered
+  # volumes = []
+
+  ## Gather the current and maximum number of open fds of the bricks, from
+  ## "gluster volume top <volume> open"
+  # gather_open_fds = true
+
+  ## Gather the throughput of the bricks and the latency of each file
+  ## operation, from "gluster volume profile <volume> info cumulative".
+  ## Profiling must be enabled with "gluster volume profile <volume> start".
+  # gather_profile = false
+
+  ## Gather the number of entries pending heal of the bricks of replicated and
+  ## dispersed volumes, from "gluster volume heal <volume> info summary"
+  # gather_heal = true
+`
+
+func (g *GlusterFS) SampleConfig() string {
+	return sampleConfig
+}
+
+func (g *GlusterFS) Description() string {
+	return "Gather the statistics of GlusterFS volumes and bricks"
+}
+
+// Gather runs the gluster commands for each volume and adds the statistics
+// of the volumes and their bricks to the accumulator.
+func (g *GlusterFS) Gather(acc telegraf.Accumulator) error {
+	var info volInfoOutput
+	if err := g.command(&info, "volume", "info"); err != nil {
+		return err
+	}
+
+	for _, volume := range info.Volumes {
+		if len(g.Volumes) > 0 && !choice(volume.Name, g.Volumes) {
+			continue
+		}
+
+		acc.AddFields("glusterfs_volume",
+			map[string]interface{}{
+				"status":      volume.StatusStr,
+				"brick_count": volume.BrickCount,
+			},
+			map[string]string{
+				"volume": volume.Name,
+				"type":   volume.TypeStr,
+			})
+
+		// The other commands fail on stopped volumes
+		if volume.StatusStr != "Started" {
+			continue
+		}
+		g.gatherVolume(acc, volume)
+	}
+
+	return nil
+}
+
+func (g *GlusterFS) gatherVolume(acc telegraf.Accumulator, volume volume) {
+	// The fields of the bricks, by brick name, from the different commands
+	bricks := make(map[string]map[string]interface{})
+	brickFields := func(name string) map[string]interface{} {
+		fields, ok := bricks[name]
+		if !ok {
+			fields = make(map[string]interface{})
+			bricks[name] = fields
+		}
+		return fields
+	}
+
+	var status volStatusOutput
+	if err := g.command(&status, "volume", "status", volume.Name, "detail"); err != nil {
+		acc.AddError(err)
+	}
+	for _, v := range status.Volumes {
+		for _, node := range v.Nodes {
+			// Skip the daemons such as the self-heal daemon
+			if !strings.HasPrefix(node.Path, "/") {
+				continue
+			}
+			fields := brickFields(node.Hostname + ":" + node.Path)
+			fields["online"] = node.Status == 1
+			// The usage of offline bricks is unknown
+			if node.Status != 1 {
+				continue
+			}
+			fields["size_total"] = node.SizeTotal
+			fields["size_free"] = node.SizeFree
+			fields["inodes_total"] = node.InodesTotal
+			fields["inodes_free"] = node.InodesFree
+		}
+	}
+
+	if g.GatherOpenFds {
+		var top volTopOutput
+		if err := g.command(&top, "volume", "top", volume.Name, "open"); err != nil {
+			acc.AddError(err)
+		}
+		for _, brick := range top.Bricks {
+			fields := brickFields(brick.Name)
+			fields["open_fds"] = brick.CurrentOpen
+			fields["max_open_fds"] = brick.MaxOpen
+		}
+	}
+
+	if g.GatherProfile {
+		var profile volProfileOutput
+		if err := g.command(&profile, "volume", "profile", volume.Name, "info", "cumulative"); err != nil {
+			acc.AddError(err)
+		}
+		for _, brick := range profile.Bricks {
+			stats := brick.CumulativeStats
+			fields := brickFields(brick.BrickName)
+			fields["read_bytes"] = stats.TotalRead
+			fields["write_bytes"] = stats.TotalWrite
+			fields["profile_duration_seconds"] = stats.Duration
+
+			for _, fop := range stats.Fops {
+				acc.AddFields("glusterfs_brick_fop",
+					map[string]interface{}{
+						"hits":           fop.Hits,
+						"avg_latency_us": fop.AvgLatency,
+						"min_latency_us": fop.MinLatency,
+						"max_latency_us": fop.MaxLatency,
+					},
+					map[string]string{
+						"volume": volume.Name,
+						"brick":  brick.BrickName,
+						"fop":    strings.ToLower(fop.Name),
+					})
+			}
+		}
+	}
+
+	if g.GatherHeal && volume.healable() {
+		var heal healInfoOutput
+		if err := g.command(&heal, "volume", "heal", volume.Name, "info", "summary"); err != nil {
+			acc.AddError(err)
+		}
+		for _, brick := range heal.Bricks {
+			fields := brickFields(brick.Name)
+			// The counts are "-" when the brick is not connected
+			for name, value := range map[string]string{
+				"heal_entries":                  brick.TotalNumberOfEntries,
+				"heal_pending_entries":          brick.NumberOfEntriesInHealPending,
+				"heal_split_brain_entries":      brick.NumberOfEntriesInSplitBrain,
+				"heal_possibly_healing_entries": brick.NumberOfEntriesPossiblyHealing,
+			} {
+				if count, err := strconv.ParseInt(value, 10, 64); err == nil {
+					fields[name] = count
+				}
+			}
+		}
+	}
+
+	for name, fields := range bricks {
+		if len(fields) == 0 {
+			continue
+		}
+		acc.AddFields("glusterfs_brick", fields, map[string]string{
+			"volume": volume.Name,
+			"brick":  name,
+		})
+	}
+}
+
+// command runs gluster with the given arguments and decodes its XML output
+// into out.
+func (g *GlusterFS) command(out interface{}, args ...string) error {
+	args = append(args, "--xml")
+	data, err := g.run(g.Binary, g.UseSudo, g.Timeout.Duration, args...)
+	if err != nil {
+		return fmt.Errorf("failed to run gluster %s: %s", strings.Join(args, " "), err)
+	}
+
+	var result struct {
+		OpRet    int    `xml:"opRet"`
+		OpErrstr string `xml:"opErrstr"`
+	}
+	if err := xml.Unmarshal(data, &result); err != nil {
+		return fmt.Errorf("failed to parse the output of gluster %s: %s", strings.Join(args, " "), err)
+	}
+	if result.OpRet != 0 {
+		return fmt.Errorf("gluster %s failed: %s", strings.Join(args, " "), result.OpErrstr)
+	}
+
+	if err := xml.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to parse the output of gluster %s: %s", strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+func runGluster(binary string, useSudo bool, timeout time.Duration, args ...string) ([]byte, error) {
+	cmd := exec.Command(binary, args...)
+	if useSudo {
+		cmd = exec.Command("sudo", append([]string{"-n", binary}, args...)...)
+	}
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	err := internal.RunTimeout(cmd, timeout)
+	// gluster exits with an error when the operation fails, the reason is then
+	// in the XML output
+	if _, ok := err.(*exec.ExitError); ok && out.Len() > 0 {
+		err = nil
+	}
+	return out.Bytes(), err
+}
+
+func choice(s string, choices []string) bool {
+	for _, c := range choices {
+		if c == s {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	inputs.Add("glusterfs", func() telegraf.Input {
+		return &GlusterFS{
+			Binary:        "/usr/sbin/gluster",
+			Timeout:       internal.Duration{Duration: 5 * time.Second},
+			GatherOpenFds: true,
+			GatherHeal:    true,
+			run:           runGluster,
+		}
+	})
+}
@@ -0,0 +1,422 @@
+package glusterfs
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fakeGluster(outputs map[string]string) runner {
+	return func(binary string, useSudo bool, timeout time.Duration, args ...string) ([]byte, error) {
+		output, ok := outputs[strings.Join(args, " ")]
+		if !ok {
+			return nil, fmt.Errorf("unexpected command %v", args)
+		}
+		return []byte(output), nil
+	}
+}
+
+func TestGather(t *testing.T) {
+	g := &GlusterFS{
+		GatherOpenFds: true,
+		GatherProfile: true,
+		GatherHeal:    true,
+		run: fakeGluster(map[string]string{
+			"volume info --xml":                            volInfo,
+			"volume status gv0 detail --xml":               volStatusDetail,
+			"volume top gv0 open --xml":                    volTopOpen,
+			"volume profile gv0 info cumulative --xml":     volProfile,
+			"volume heal gv0 info summary --xml":           healInfoSummary,
+			"volume status scratch detail --xml":           volStatusScratch,
+			"volume top scratch open --xml":                volTopScratch,
+			"volume profile scratch info cumulative --xml": profileNotStarted,
+		}),
+	}
+
+	var acc testutil.Accumulator
+	require.Error(t, acc.GatherError(g.Gather))
+	require.Len(t, acc.Errors, 1)
+	assert.Contains(t, acc.Errors[0].Error(), "Profile on Volume scratch is not started")
+
+	acc.AssertContainsTaggedFields(t, "glusterfs_volume",
+		map[string]interface{}{"status": "Started", "brick_count": int64(2)},
+		map[string]string{"volume": "gv0", "type": "Replicate"})
+	acc.AssertContainsTaggedFields(t, "glusterfs_volume",
+		map[string]interface{}{"status": "Started", "brick_count": int64(1)},
+		map[string]string{"volume": "scratch", "type": "Distribute"})
+	acc.AssertContainsTaggedFields(t, "glusterfs_volume",
+		map[string]interface{}{"status": "Stopped", "brick_count": int64(1)},
+		map[string]string{"volume": "old", "type": "Distribute"})
+
+	acc.AssertContainsTaggedFields(t, "glusterfs_brick",
+		map[string]interface{}{
+			"online":                        true,
+			"size_total":                    int64(10725883904),
+			"size_free":                     int64(10691207168),
+			"inodes_total":                  int64(5240320),
+			"inodes_free":                   int64(5240249),
+			"open_fds":                      int64(3),
+			"max_open_fds":                  int64(12),
+			"read_bytes":                    int64(4096),
+			"write_bytes":                   int64(1048576),
+			"profile_duration_seconds":      int64(3600),
+			"heal_entries":                  int64(4),
+			"heal_pending_entries":          int64(3),
+			"heal_split_brain_entries":      int64(1),
+			"heal_possibly_healing_entries": int64(0),
+		},
+		map[string]string{"volume": "gv0", "brick": "server1:/data/brick1/gv0"})
+
+	// The usage and heal counts are not reported for a disconnected brick
+	acc.AssertContainsTaggedFields(t, "glusterfs_brick",
+		map[string]interface{}{
+			"online":                   false,
+			"open_fds":                 int64(0),
+			"max_open_fds":             int64(0),
+			"read_bytes":               int64(0),
+			"write_bytes":              int64(0),
+			"profile_duration_seconds": int64(0),
+		},
+		map[string]string{"volume": "gv0", "brick": "server2:/data/brick1/gv0"})
+
+	acc.AssertContainsTaggedFields(t, "glusterfs_brick",
+		map[string]interface{}{
+			"online":       true,
+			"size_total":   int64(2000),
+			"size_free":    int64(1000),
+			"inodes_total": int64(200),
+			"inodes_free":  int64(100),
+			"open_fds":     int64(1),
+			"max_open_fds": int64(1),
+		},
+		map[string]string{"volume": "scratch", "brick": "server1:/data/scratch"})
+
+	acc.AssertContainsTaggedFields(t, "glusterfs_brick_fop",
+		map[string]interface{}{
+			"hits":           int64(16),
+			"avg_latency_us": 114.5,
+			"min_latency_us": 90.0,
+			"max_latency_us": 210.25,
+		},
+		map[string]string{"volume": "gv0", "brick": "server1:/data/brick1/gv0", "fop": "write"})
+	acc.AssertContainsTaggedFields(t, "glusterfs_brick_fop",
+		map[string]interface{}{
+			"hits":           int64(2),
+			"avg_latency_us": 40.0,
+			"min_latency_us": 30.0,
+			"max_latency_us": 50.0,
+		},
+		map[string]string{"volume": "gv0", "brick": "server1:/data/brick1/gv0", "fop": "lookup"})
+}
+
+func TestGatherVolumes(t *testing.T) {
+	g := &GlusterFS{
+		Volumes: []string{"scratch"},
+		run: fakeGluster(map[string]string{
+			"volume info --xml":                  volInfo,
+			"volume status scratch detail --xml": volStatusScratch,
+		}),
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(g.Gather))
+
+	for _, m := range acc.Metrics {
+		assert.Equal(t, "scratch", m.Tags["volume"])
+	}
+	assert.Len(t, acc.Metrics, 2)
+}
+
+func TestGatherError(t *testing.T) {
+	g := &GlusterFS{
+		run: fakeGluster(map[string]string{
+			"volume info --xml": noGlusterd,
+		}),
+	}
+
+	var acc testutil.Accumulator
+	err := acc.GatherError(g.Gather)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Connection failed")
+}
+
+const volInfo = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cliOutput>
+  <opRet>0</opRet>
+  <opErrno>0</opErrno>
+  <opErrstr/>
+  <volInfo>
+    <volumes>
+      <volume>
+        <name>gv0</name>
+        <id>1a2b3c4d-0000-4000-8000-000000000001</id>
+        <status>1</status>
+        <statusStr>Started</statusStr>
+        <snapshotCount>0</snapshotCount>
+        <brickCount>2</brickCount>
+        <distCount>2</distCount>
+        <replicaCount>2</replicaCount>
+        <type>2</type>
+        <typeStr>Replicate</typeStr>
+        <transport>0</transport>
+        <bricks>
+          <brick uuid="9d3c4d7e-0000-4000-8000-000000000001">server1:/data/brick1/gv0<name>server1:/data/brick1/gv0</name><hostUuid>9d3c4d7e-0000-4000-8000-000000000001</hostUuid><isArbiter>0</isArbiter></brick>
+          <brick uuid="9d3c4d7e-0000-4000-8000-000000000002">server2:/data/brick1/gv0<name>server2:/data/brick1/gv0</name><hostUuid>9d3c4d7e-0000-4000-8000-000000000002</hostUuid><isArbiter>0</isArbiter></brick>
+        </bricks>
+        <optCount>1</optCount>
+        <options>
+          <option>
+            <name>diagnostics.count-fop-hits</name>
+            <value>on</value>
+          </option>
+        </options>
+      </volume>
+      <volume>
+        <name>scratch</name>
+        <status>1</status>
+        <statusStr>Started</statusStr>
+        <brickCount>1</brickCount>
+        <type>0</type>
+        <typeStr>Distribute</typeStr>
+      </volume>
+      <volume>
+        <name>old</name>
+        <status>2</status>
+        <statusStr>Stopped</statusStr>
+        <brickCount>1</brickCount>
+        <type>0</type>
+        <typeStr>Distribute</typeStr>
+      </volume>
+      <count>3</count>
+    </volumes>
+  </volInfo>
+</cliOutput>
+`
+
+const volStatusDetail = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cliOutput>
+  <opRet>0</opRet>
+  <opErrno>0</opErrno>
+  <opErrstr/>
+  <volStatus>
+    <volumes>
+      <volume>
+        <volName>gv0</volName>
+        <nodeCount>2</nodeCount>
+        <node>
+          <hostname>server1</hostname>
+          <path>/data/brick1/gv0</path>
+          <peerid>9d3c4d7e-0000-4000-8000-000000000001</peerid>
+          <status>1</status>
+          <port>49152</port>
+          <ports>
+            <tcp>49152</tcp>
+            <rdma>N/A</rdma>
+          </ports>
+          <pid>2143</pid>
+          <sizeTotal>10725883904</sizeTotal>
+          <sizeFree>10691207168</sizeFree>
+          <device>/dev/vdb1</device>
+          <blockSize>4096</blockSize>
+          <mntOptions>rw,seclabel,relatime,attr2,inode64,noquota</mntOptions>
+          <fsName>xfs</fsName>
+          <inodeSize>xfs</inodeSize>
+          <inodesTotal>5240320</inodesTotal>
+          <inodesFree>5240249</inodesFree>
+        </node>
+        <node>
+          <hostname>server2</hostname>
+          <path>/data/brick1/gv0</path>
+          <peerid>9d3c4d7e-0000-4000-8000-000000000002</peerid>
+          <status>0</status>
+          <port>N/A</port>
+          <pid>-1</pid>
+        </node>
+        <node>
+          <hostname>Self-heal Daemon</hostname>
+          <path>server1</path>
+          <peerid>9d3c4d7e-0000-4000-8000-000000000001</peerid>
+          <status>1</status>
+          <port>N/A</port>
+          <pid>2164</pid>
+        </node>
+      </volume>
+    </volumes>
+  </volStatus>
+</cliOutput>
+`
+
+const volStatusScratch = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cliOutput>
+  <opRet>0</opRet>
+  <opErrno>0</opErrno>
+  <opErrstr/>
+  <volStatus>
+    <volumes>
+      <volume>
+        <volName>scratch</volName>
+        <nodeCount>1</nodeCount>
+        <node>
+          <hostname>server1</hostname>
+          <path>/data/scratch</path>
+          <status>1</status>
+          <sizeTotal>2000</sizeTotal>
+          <sizeFree>1000</sizeFree>
+          <inodesTotal>200</inodesTotal>
+          <inodesFree>100</inodesFree>
+        </node>
+      </volume>
+    </volumes>
+  </volStatus>
+</cliOutput>
+`
+
+const volTopOpen = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cliOutput>
+  <opRet>0</opRet>
+  <opErrno>0</opErrno>
+  <opErrstr/>
+  <volTop>
+    <brickCount>2</brickCount>
+    <topOp>1</topOp>
+    <brick>
+      <name>server1:/data/brick1/gv0</name>
+      <currentOpen>3</currentOpen>
+      <maxOpen>12</maxOpen>
+      <maxOpenTime>2018-07-12 10:13:05.123456</maxOpenTime>
+      <members>1</members>
+      <file>
+        <count>4</count>
+        <filename>/report.csv</filename>
+      </file>
+    </brick>
+    <brick>
+      <name>server2:/data/brick1/gv0</name>
+      <currentOpen>0</currentOpen>
+      <maxOpen>0</maxOpen>
+      <members>0</members>
+    </brick>
+  </volTop>
+</cliOutput>
+`
+
+const volTopScratch = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cliOutput>
+  <opRet>0</opRet>
+  <opErrno>0</opErrno>
+  <opErrstr/>
+  <volTop>
+    <brickCount>1</brickCount>
+    <topOp>1</topOp>
+    <brick>
+      <name>server1:/data/scratch</name>
+      <currentOpen>1</currentOpen>
+      <maxOpen>1</maxOpen>
+      <members>0</members>
+    </brick>
+  </volTop>
+</cliOutput>
+`
+
+const volProfile = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cliOutput>
+  <opRet>0</opRet>
+  <opErrno>0</opErrno>
+  <opErrstr/>
+  <volProfile>
+    <volname>gv0</volname>
+    <profileOp>3</profileOp>
+    <brickCount>2</brickCount>
+    <brick>
+      <brickName>server1:/data/brick1/gv0</brickName>
+      <cumulativeStats>
+        <blockStats>
+          <block>
+            <size>4096</size>
+            <reads>1</reads>
+            <writes>256</writes>
+          </block>
+        </blockStats>
+        <fopStats>
+          <fop>
+            <name>WRITE</name>
+            <hits>16</hits>
+            <avgLatency>114.50</avgLatency>
+            <minLatency>90.00</minLatency>
+            <maxLatency>210.25</maxLatency>
+          </fop>
+          <fop>
+            <name>LOOKUP</name>
+            <hits>2</hits>
+            <avgLatency>40.00</avgLatency>
+            <minLatency>30.00</minLatency>
+            <maxLatency>50.00</maxLatency>
+          </fop>
+        </fopStats>
+        <duration>3600</duration>
+        <totalRead>4096</totalRead>
+        <totalWrite>1048576</totalWrite>
+      </cumulativeStats>
+    </brick>
+    <brick>
+      <brickName>server2:/data/brick1/gv0</brickName>
+      <cumulativeStats>
+        <blockStats/>
+        <fopStats/>
+        <duration>0</duration>
+        <totalRead>0</totalRead>
+        <totalWrite>0</totalWrite>
+      </cumulativeStats>
+    </brick>
+  </volProfile>
+</cliOutput>
+`
+
+const profileNotStarted = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cliOutput>
+  <opRet>-1</opRet>
+  <opErrno>30802</opErrno>
+  <opErrstr>Profile on Volume scratch is not started</opErrstr>
+</cliOutput>
+`
+
+const healInfoSummary = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cliOutput>
+  <healInfo>
+    <bricks>
+      <brick hostUuid="9d3c4d7e-0000-4000-8000-000000000001">
+        <name>server1:/data/brick1/gv0</name>
+        <status>Connected</status>
+        <totalNumberOfEntries>4</totalNumberOfEntries>
+        <numberOfEntriesInHealPending>3</numberOfEntriesInHealPending>
+        <numberOfEntriesInSplitBrain>1</numberOfEntriesInSplitBrain>
+        <numberOfEntriesPossiblyHealing>0</numberOfEntriesPossiblyHealing>
+      </brick>
+      <brick hostUuid="9d3c4d7e-0000-4000-8000-000000000002">
+        <name>server2:/data/brick1/gv0</name>
+        <status>Transport endpoint is not connected</status>
+        <totalNumberOfEntries>-</totalNumberOfEntries>
+        <numberOfEntriesInHealPending>-</numberOfEntriesInHealPending>
+        <numberOfEntriesInSplitBrain>-</numberOfEntriesInSplitBrain>
+        <numberOfEntriesPossiblyHealing>-</numberOfEntriesPossiblyHealing>
+      </brick>
+    </bricks>
+  </healInfo>
+  <opRet>0</opRet>
+  <opErrno>0</opErrno>
+  <opErrstr/>
+</cliOutput>
+`
+
+const noGlusterd = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cliOutput>
+  <opRet>-1</opRet>
+  <opErrno>107</opErrno>
+  <opErrstr>Connection failed. Please check if gluster daemon is operational.</opErrstr>
+</cliOutput>
+`
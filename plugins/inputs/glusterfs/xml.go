@@ -0,0 +1,83 @@
+package glusterfs
+
+import "strings"
+
+// The types below decode the parts of the XML output of the gluster
+// commands used by the plugin, all of them wrapped in a cliOutput element.
+
+// volInfoOutput is the output of "gluster volume info --xml"
+type volInfoOutput struct {
+	Volumes []volume `xml:"volInfo>volumes>volume"`
+}
+
+type volume struct {
+	Name       string `xml:"name"`
+	StatusStr  string `xml:"statusStr"`
+	BrickCount int64  `xml:"brickCount"`
+	TypeStr    string `xml:"typeStr"`
+}
+
+// healable returns whether the volume keeps redundant copies, as heal
+// commands fail for the other volume types.
+func (v volume) healable() bool {
+	t := strings.ToLower(v.TypeStr)
+	return strings.Contains(t, "replicate") || strings.Contains(t, "disperse")
+}
+
+// volStatusOutput is the output of "gluster volume status <volume> detail --xml"
+type volStatusOutput struct {
+	Volumes []struct {
+		VolName string `xml:"volName"`
+		Nodes   []struct {
+			Hostname    string `xml:"hostname"`
+			Path        string `xml:"path"`
+			Status      int    `xml:"status"`
+			SizeTotal   int64  `xml:"sizeTotal"`
+			SizeFree    int64  `xml:"sizeFree"`
+			InodesTotal int64  `xml:"inodesTotal"`
+			InodesFree  int64  `xml:"inodesFree"`
+		} `xml:"node"`
+	} `xml:"volStatus>volumes>volume"`
+}
+
+// volTopOutput is the output of "gluster volume top <volume> open --xml"
+type volTopOutput struct {
+	Bricks []struct {
+		Name        string `xml:"name"`
+		CurrentOpen int64  `xml:"currentOpen"`
+		MaxOpen     int64  `xml:"maxOpen"`
+	} `xml:"volTop>brick"`
+}
+
+// volProfileOutput is the output of
+// "gluster volume profile <volume> info cumulative --xml"
+type volProfileOutput struct {
+	Bricks []struct {
+		BrickName       string `xml:"brickName"`
+		CumulativeStats struct {
+			Fops []struct {
+				Name       string  `xml:"name"`
+				Hits       int64   `xml:"hits"`
+				AvgLatency float64 `xml:"avgLatency"`
+				MinLatency float64 `xml:"minLatency"`
+				MaxLatency float64 `xml:"maxLatency"`
+			} `xml:"fopStats>fop"`
+			Duration   int64 `xml:"duration"`
+			TotalRead  int64 `xml:"totalRead"`
+			TotalWrite int64 `xml:"totalWrite"`
+		} `xml:"cumulativeStats"`
+	} `xml:"volProfile>brick"`
+}
+
+// healInfoOutput is the output of
+// "gluster volume heal <volume> info summary --xml"
+type healInfoOutput struct {
+	Bricks []struct {
+		Name                           string `xml:"name"`
+		Status                         string `xml:"status"`
+		TotalNumberOfEntries           string `xml:"totalNumberOfEntries"`
+		NumberOfEntriesInHealPending   string `xml:"numberOfEntriesInHealPending"`
+		NumberOfEntriesInSplitBrain    string `xml:"numberOfEntriesInSplitBrain"`
+		NumberOfEntriesPossiblyHealing string `xml:"numberOfEntriesPossiblyHealing"`
+	} `xml:"healInfo>bricks>brick"`
+}
@@ -1,6 +1,7 @@
 package haproxy
 
 import (
+	"bufio"
 	"encoding/csv"
 	"fmt"
 	"io"
@@ -14,6 +15,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
@@ -23,6 +25,7 @@ import (
 type haproxy struct {
 	Servers        []string
 	KeepFieldNames bool
+	GatherInfo     bool `toml:"gather_info"`
 	tls.ClientConfig
 
 	client *http.Client
@@ -47,6 +50,10 @@ var sampleConfig = `
   ## field names.
   # keep_field_names = false
 
+  ## Also gather the process information of 'show info', in the haproxy_info
+  ## measurement.  Only available with the stats socket.
+  # gather_info = false
+
   ## Optional TLS Config
   # tls_ca = "/etc/telegraf/ca.pem"
   # tls_cert = "/etc/telegraf/cert.pem"
@@ -126,7 +133,60 @@ func (g *haproxy) gatherServerSocket(addr string, acc telegraf.Accumulator) erro
 		return fmt.Errorf("Could not write to socket '%s': %s", addr, errw)
 	}
 
-	return g.importCsvResult(c, acc, socketPath)
+	err = g.importCsvResult(c, acc, socketPath)
+	c.Close()
+	if err != nil {
+		return err
+	}
+
+	if g.GatherInfo {
+		return gatherServerSocketInfo(socketPath, acc)
+	}
+	return nil
+}
+
+// gatherServerSocketInfo reads the process information of the show info
+// command, on a connection of its own as the socket closes the connection
+// after each command.
+func gatherServerSocketInfo(socketPath string, acc telegraf.Accumulator) error {
+	c, err := net.Dial("unix", socketPath)
+	if err != nil {
+		return fmt.Errorf("Could not connect to socket '%s': %s", socketPath, err)
+	}
+	defer c.Close()
+
+	if _, err := c.Write([]byte("show info\n")); err != nil {
+		return fmt.Errorf("Could not write to socket '%s': %s", socketPath, err)
+	}
+
+	return importInfoResult(c, acc, socketPath)
+}
+
+// importInfoResult parses the "Name: value" lines of show info, the names
+// being converted to snake case.
+func importInfoResult(r io.Reader, acc telegraf.Accumulator, host string) error {
+	fields := make(map[string]interface{})
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		name := strings.ToLower(internal.SnakeCase(strings.Replace(strings.TrimSpace(parts[0]), "-", "_", -1)))
+		value := strings.TrimSpace(parts[1])
+		if name == "" || value == "" {
+			continue
+		}
+		fields[name] = parseValue(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	acc.AddFields("haproxy_info", fields, map[string]string{"server": host})
+	return nil
 }
 
 func (g *haproxy) gatherServer(addr string, acc telegraf.Accumulator) error {
@@ -264,7 +324,10 @@ func (g *haproxy) importCsvResult(r io.Reader, acc telegraf.Accumulator, host st
 				tags[fieldName] = typeNames[vi]
 			case "check_desc", "agent_desc":
 				// do nothing. These fields are just a more verbose description of the check_status & agent_status fields
-			case "status", "check_status", "last_chk", "mode", "tracked", "agent_status", "last_agt", "addr", "cookie":
+			case "status":
+				fields[fieldName] = v
+				fields["status_code"] = statusCode(v)
+			case "check_status", "last_chk", "mode", "tracked", "agent_status", "last_agt", "addr", "cookie", "algo":
 				// these are string fields
 				fields[fieldName] = v
 			case "lastsess":
@@ -275,8 +338,8 @@ func (g *haproxy) importCsvResult(r io.Reader, acc telegraf.Accumulator, host st
 				}
 				fields[fieldName] = vi
 			default:
-				vi, err := strconv.ParseUint(v, 10, 64)
-				if err != nil {
+				vi := parseValue(v)
+				if _, ok := vi.(string); ok {
 					//TODO log the error. And just once (per column) so we don't spam the log
 					continue
 				}
@@ -288,6 +351,50 @@ func (g *haproxy) importCsvResult(r io.Reader, acc telegraf.Accumulator, host st
 	return err
 }
 
+// parseValue returns the value as an unsigned or signed integer or as a
+// float when it is numeric, and as a string otherwise.
+func parseValue(v string) interface{} {
+	if vi, err := strconv.ParseUint(v, 10, 64); err == nil {
+		return vi
+	}
+	if vi, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return vi
+	}
+	if vf, err := strconv.ParseFloat(v, 64); err == nil {
+		return vf
+	}
+	return v
+}
+
+// Status codes of the status_code field, ordered by severity so that alerts
+// can be set on a threshold.
+const (
+	statusCodeUp          = 0 // UP, OPEN or no check
+	statusCodeTransition  = 1 // UP or DOWN but going to the other state
+	statusCodeNoLB        = 2 // NOLB, DRAIN or FULL
+	statusCodeMaintenance = 3 // MAINT, including MAINT(via) and MAINT(resolution)
+	statusCodeDown        = 4 // DOWN
+	statusCodeUnknown     = 5
+)
+
+// statusCode maps the status of a proxy or server to its status code.
+func statusCode(status string) int64 {
+	switch {
+	case status == "UP", status == "OPEN", status == "no check":
+		return statusCodeUp
+	case strings.HasPrefix(status, "UP "), strings.HasPrefix(status, "DOWN "):
+		return statusCodeTransition
+	case status == "NOLB", status == "DRAIN", status == "FULL":
+		return statusCodeNoLB
+	case strings.HasPrefix(status, "MAINT"):
+		return statusCodeMaintenance
+	case status == "DOWN":
+		return statusCodeDown
+	default:
+		return statusCodeUnknown
+	}
+}
+
 func init() {
 	inputs.Add("haproxy", func() telegraf.Input {
 		return &haproxy{}
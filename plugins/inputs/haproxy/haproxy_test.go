@@ -29,10 +29,13 @@ func (s statServer) serverSocket(l net.Listener) {
 			n, _ := c.Read(buf)
 
 			data := buf[:n]
-			if string(data) == "show stat\n" {
+			switch string(data) {
+			case "show stat\n":
 				c.Write([]byte(csvOutputSample))
-				c.Close()
+			case "show info\n":
+				c.Write([]byte(infoOutputSample))
 			}
+			c.Close()
 		}(conn)
 	}
 }
@@ -162,8 +165,8 @@ func TestHaproxyGeneratesMetricsUsingSocket(t *testing.T) {
 	require.NotEmpty(t, acc.Errors)
 }
 
-//When not passing server config, we default to localhost
-//We just want to make sure we did request stat from localhost
+// When not passing server config, we default to localhost
+// We just want to make sure we did request stat from localhost
 func TestHaproxyDefaultGetFromLocalhost(t *testing.T) {
 	r := &haproxy{}
 
@@ -248,34 +251,131 @@ func HaproxyGetFieldValues() map[string]interface{} {
 		"http_response.4xx":   uint64(140),
 		"http_response.5xx":   uint64(0),
 		"http_response.other": uint64(0),
-		"iid":       uint64(4),
-		"last_chk":  "OK",
-		"lastchg":   uint64(1036557),
-		"lastsess":  int64(1342),
-		"lbtot":     uint64(9481),
-		"mode":      "http",
-		"pid":       uint64(1),
-		"qcur":      uint64(0),
-		"qmax":      uint64(0),
-		"qtime":     uint64(1268),
-		"rate":      uint64(0),
-		"rate_max":  uint64(2),
-		"rtime":     uint64(2908),
-		"sid":       uint64(1),
-		"scur":      uint64(0),
-		"slim":      uint64(2),
-		"smax":      uint64(2),
-		"srv_abort": uint64(0),
-		"status":    "UP",
-		"stot":      uint64(14539),
-		"ttime":     uint64(4500),
-		"weight":    uint64(1),
-		"wredis":    uint64(0),
-		"wretr":     uint64(0),
+		"iid":                 uint64(4),
+		"last_chk":            "OK",
+		"lastchg":             uint64(1036557),
+		"lastsess":            int64(1342),
+		"lbtot":               uint64(9481),
+		"mode":                "http",
+		"pid":                 uint64(1),
+		"qcur":                uint64(0),
+		"qmax":                uint64(0),
+		"qtime":               uint64(1268),
+		"rate":                uint64(0),
+		"rate_max":            uint64(2),
+		"rtime":               uint64(2908),
+		"sid":                 uint64(1),
+		"scur":                uint64(0),
+		"slim":                uint64(2),
+		"smax":                uint64(2),
+		"srv_abort":           uint64(0),
+		"status":              "UP",
+		"status_code":         int64(0),
+		"stot":                uint64(14539),
+		"ttime":               uint64(4500),
+		"weight":              uint64(1),
+		"wredis":              uint64(0),
+		"wretr":               uint64(0),
 	}
 	return fields
 }
 
+func TestHaproxyGatherInfoUsingSocket(t *testing.T) {
+	var randomNumber int64
+	binary.Read(rand.Reader, binary.LittleEndian, &randomNumber)
+	sockname := fmt.Sprintf("/tmp/test-haproxy-info%d.sock", randomNumber)
+
+	sock, err := net.Listen("unix", sockname)
+	require.NoError(t, err)
+	defer sock.Close()
+
+	s := statServer{}
+	go s.serverSocket(sock)
+
+	r := &haproxy{
+		Servers:    []string{sockname},
+		GatherInfo: true,
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(r.Gather))
+
+	acc.AssertContainsTaggedFields(t, "haproxy_info",
+		map[string]interface{}{
+			"name":                           "HAProxy",
+			"version":                        "1.8.8",
+			"release_date":                   "2018/04/19",
+			"nbproc":                         uint64(1),
+			"process_num":                    uint64(1),
+			"pid":                            uint64(3158),
+			"uptime":                         "0d 0h02m17s",
+			"uptime_sec":                     uint64(137),
+			"memmax_mb":                      uint64(0),
+			"ulimit_n":                       uint64(4030),
+			"maxconn":                        uint64(2000),
+			"curr_conns":                     uint64(3),
+			"cum_conns":                      uint64(42),
+			"conn_rate":                      uint64(1),
+			"ssl_frontend_session_reuse_pct": uint64(0),
+			"idle_pct":                       uint64(100),
+			"node":                           "lb1",
+		},
+		map[string]string{"server": sockname})
+	assert.True(t, acc.HasMeasurement("haproxy"))
+}
+
+func TestHaproxyStatusCode(t *testing.T) {
+	tests := []struct {
+		status string
+		code   int64
+	}{
+		{"UP", 0},
+		{"OPEN", 0},
+		{"no check", 0},
+		{"UP 1/3", 1},
+		{"DOWN 1/2", 1},
+		{"NOLB", 2},
+		{"DRAIN", 2},
+		{"FULL", 2},
+		{"MAINT", 3},
+		{"MAINT(via)", 3},
+		{"MAINT(resolution)", 3},
+		{"DOWN", 4},
+		{"STOP", 5},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.code, statusCode(tt.status), tt.status)
+	}
+}
+
+func TestHaproxyParseValue(t *testing.T) {
+	assert.Equal(t, uint64(12), parseValue("12"))
+	assert.Equal(t, int64(-1), parseValue("-1"))
+	assert.Equal(t, 0.5, parseValue("0.5"))
+	assert.Equal(t, "roundrobin", parseValue("roundrobin"))
+}
+
+const infoOutputSample = `Name: HAProxy
+Version: 1.8.8
+Release_date: 2018/04/19
+Nbproc: 1
+Process_num: 1
+Pid: 3158
+Uptime: 0d 0h02m17s
+Uptime_sec: 137
+Memmax_MB: 0
+Ulimit-n: 4030
+Maxconn: 2000
+CurrConns: 3
+CumConns: 42
+ConnRate: 1
+SslFrontendSessionReuse_pct: 0
+Idle_pct: 100
+node: lb1
+description:
+`
+
 // Can obtain from official haproxy demo: 'http://demo.haproxy.org/;csv'
 const csvOutputSample = `
 # pxname,svname,qcur,qmax,scur,smax,slim,stot,bin,bout,dreq,dresp,ereq,econ,eresp,wretr,wredis,status,weight,act,bck,chkfail,chkdown,lastchg,downtime,qlimit,pid,iid,sid,throttle,lbtot,tracked,type,rate,rate_lim,rate_max,check_status,check_code,check_duration,hrsp_1xx,hrsp_2xx,hrsp_3xx,hrsp_4xx,hrsp_5xx,hrsp_other,hanafail,req_rate,req_rate_max,req_tot,cli_abrt,srv_abrt,comp_in,comp_out,comp_byp,comp_rsp,lastsess,last_chk,last_agt,qtime,ctime,rtime,ttime,agent_status,agent_code,agent_duration,check_desc,agent_desc,check_rise,check_fall,check_health,agent_rise,agent_fall,agent_health,addr,cookie,mode,algo,conn_rate,conn_rate_max,conn_tot,intercepted,dcon,dses,
@@ -0,0 +1,271 @@
+package hwmon
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+// default host sys path
+const defaultHostSys = "/sys"
+
+// env host sys variable name
+const envSys = "HOST_SYS"
+
+// temp1_input, fan2_min, in0_crit_alarm
+var attributeRe = regexp.MustCompile(`^(temp|in|fan|curr|power|energy|humidity)(\d+)_(\w+)$`)
+
+// scales converts the values of the channel types to base units: degrees
+// Celsius, volts, amperes, watts, joules and percent.
+var scales = map[string]float64{
+	"temp":     1000,
+	"in":       1000,
+	"fan":      1,
+	"curr":     1000,
+	"power":    1000000,
+	"energy":   1000000,
+	"humidity": 1000,
+}
+
+type Hwmon struct {
+	HostSys        string   `toml:"host_sys"`
+	ChipInclude    []string `toml:"chip_include"`
+	ChipExclude    []string `toml:"chip_exclude"`
+	FeatureInclude []string `toml:"feature_include"`
+	FeatureExclude []string `toml:"feature_exclude"`
+
+	chipFilter     filter.Filter
+	featureInclude filter.Filter
+	featureExclude filter.Filter
+	initialized    bool
+}
+
+var sampleConfig = `
+  ## Sets 'sys' directory path
+  ## If not specified, then default is /sys
+  # host_sys = "/sys"
+
+  ## Chips to gather, matched against the name of the chip such as
+  ## "coretemp" or "nct6775", globs are supported.
+  # chip_include = []
+  # chip_exclude = []
+
+  ## Features to gather, matched against the feature such as "temp1" and
+  ## against its label such as "Package id 0", globs are supported.
+  # feature_include = []
+  # feature_exclude = ["in*"]
+`
+
+func (h *Hwmon) Description() string {
+	return "Monitor hardware sensors using the hwmon sysfs interface"
+}
+
+func (h *Hwmon) SampleConfig() string {
+	return sampleConfig
+}
+
+func (h *Hwmon) init() error {
+	if h.HostSys == "" {
+		h.HostSys = sys(envSys, defaultHostSys)
+	}
+
+	var err error
+	h.chipFilter, err = filter.NewIncludeExcludeFilter(h.ChipInclude, h.ChipExclude)
+	if err != nil {
+		return err
+	}
+	h.featureInclude, err = filter.Compile(h.FeatureInclude)
+	if err != nil {
+		return err
+	}
+	h.featureExclude, err = filter.Compile(h.FeatureExclude)
+	if err != nil {
+		return err
+	}
+
+	h.initialized = true
+	return nil
+}
+
+func (h *Hwmon) Gather(acc telegraf.Accumulator) error {
+	if !h.initialized {
+		if err := h.init(); err != nil {
+			return err
+		}
+	}
+
+	chips, err := filepath.Glob(filepath.Join(h.HostSys, "class", "hwmon", "hwmon*"))
+	if err != nil {
+		return err
+	}
+
+	for _, chip := range chips {
+		h.gatherChip(acc, chip)
+	}
+	return nil
+}
+
+// feature holds the attributes of a sensor channel, such as temp1.
+type feature struct {
+	label  string
+	fields map[string]interface{}
+}
+
+func (h *Hwmon) gatherChip(acc telegraf.Accumulator, chip string) {
+	// Older drivers put the attributes in the device directory
+	dir := chip
+	name, err := readString(filepath.Join(dir, "name"))
+	if err != nil {
+		dir = filepath.Join(chip, "device")
+		if name, err = readString(filepath.Join(dir, "name")); err != nil {
+			return
+		}
+	}
+
+	if !h.chipFilter.Match(name) {
+		return
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+
+	features := make(map[string]*feature)
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		m := attributeRe.FindStringSubmatch(file.Name())
+		if m == nil {
+			continue
+		}
+		kind, index, attr := m[1], m[2], m[3]
+
+		value, err := readString(filepath.Join(dir, file.Name()))
+		if err != nil {
+			// Some attributes are write only or fail when the sensor
+			// is not connected.
+			continue
+		}
+
+		f, ok := features[kind+index]
+		if !ok {
+			f = &feature{fields: make(map[string]interface{})}
+			features[kind+index] = f
+		}
+
+		switch {
+		case attr == "label":
+			f.label = value
+		case attr == "type" || attr == "enable":
+			continue
+		case isFlag(attr):
+			if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+				f.fields[kind+"_"+attr] = v != 0
+			}
+		case strings.HasSuffix(attr, "interval"):
+			// Intervals are reported in milliseconds
+			if v, err := strconv.ParseFloat(value, 64); err == nil {
+				f.fields[kind+"_"+attr] = v / 1000
+			}
+		default:
+			if v, err := strconv.ParseFloat(value, 64); err == nil {
+				f.fields[kind+"_"+attr] = v / scales[kind]
+			}
+		}
+	}
+
+	device := ""
+	if target, err := os.Readlink(filepath.Join(chip, "device")); err == nil {
+		device = filepath.Base(target)
+	}
+
+	for id, f := range features {
+		if len(f.fields) == 0 {
+			continue
+		}
+		if !h.matchFeature(id, f.label) {
+			continue
+		}
+
+		tags := map[string]string{
+			"chip":    name,
+			"feature": id,
+		}
+		if device != "" {
+			tags["device"] = device
+		}
+		if f.label != "" {
+			tags["label"] = f.label
+		}
+		acc.AddFields("hwmon", f.fields, tags)
+	}
+}
+
+// matchFeature returns true if either the feature or its label is included
+// and neither is excluded.
+func (h *Hwmon) matchFeature(id, label string) bool {
+	names := []string{id}
+	if label != "" {
+		names = append(names, label)
+	}
+
+	if h.featureExclude != nil {
+		for _, name := range names {
+			if h.featureExclude.Match(name) {
+				return false
+			}
+		}
+	}
+
+	if h.featureInclude == nil {
+		return true
+	}
+	for _, name := range names {
+		if h.featureInclude.Match(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// isFlag returns true for the boolean attributes such as alarm, fault and
+// beep, including limit alarms like max_alarm.
+func isFlag(attr string) bool {
+	return attr == "fault" || attr == "beep" ||
+		attr == "alarm" || strings.HasSuffix(attr, "_alarm")
+}
+
+func readString(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// sys can be used to read file paths from env
+func sys(env, path string) string {
+	// try to read full file path
+	if p := os.Getenv(env); p != "" {
+		return p
+	}
+	// return default path
+	return path
+}
+
+func init() {
+	inputs.Add("hwmon", func() telegraf.Input {
+		return &Hwmon{}
+	})
+}
@@ -0,0 +1,154 @@
+package hwmon
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeSysfs(t *testing.T) (string, func()) {
+	dir, cleanup := testutil.TempDir(t, "hwmon")
+
+	testutil.WriteFiles(t, filepath.Join(dir, "class", "hwmon"), map[string]string{
+		"hwmon0/name":             "coretemp\n",
+		"hwmon0/temp1_label":      "Package id 0\n",
+		"hwmon0/temp1_input":      "45000\n",
+		"hwmon0/temp1_max":        "80000\n",
+		"hwmon0/temp1_crit":       "100000\n",
+		"hwmon0/temp1_crit_alarm": "0\n",
+		"hwmon0/temp2_label":      "Core 0\n",
+		"hwmon0/temp2_input":      "43500\n",
+
+		"hwmon1/name":            "nct6775\n",
+		"hwmon1/in0_input":       "1128\n",
+		"hwmon1/in0_min":         "0\n",
+		"hwmon1/in0_alarm":       "1\n",
+		"hwmon1/in0_beep":        "0\n",
+		"hwmon1/fan2_input":      "1230\n",
+		"hwmon1/fan2_min":        "300\n",
+		"hwmon1/fan2_alarm":      "0\n",
+		"hwmon1/temp7_type":      "4\n",
+		"hwmon1/temp7_input":     "31000\n",
+		"hwmon1/temp7_fault":     "0\n",
+		"hwmon1/pwm2":            "128\n",
+		"hwmon1/intrusion0_beep": "0\n",
+
+		// Attributes in the device directory
+		"hwmon2/device/name":                    "power_meter\n",
+		"hwmon2/device/power1_average":          "5400000\n",
+		"hwmon2/device/power1_average_interval": "300000\n",
+		"hwmon2/device/curr1_input":             "1500\n",
+	})
+
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "devices", "platform", "coretemp.0"), 0755))
+	require.NoError(t, os.Symlink(
+		filepath.Join(dir, "devices", "platform", "coretemp.0"),
+		filepath.Join(dir, "class", "hwmon", "hwmon0", "device")))
+
+	return dir, cleanup
+}
+
+func TestGather(t *testing.T) {
+	dir, cleanup := makeSysfs(t)
+	defer cleanup()
+
+	h := &Hwmon{HostSys: dir}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(h.Gather))
+
+	acc.AssertContainsTaggedFields(t, "hwmon",
+		map[string]interface{}{
+			"temp_input":      45.0,
+			"temp_max":        80.0,
+			"temp_crit":       100.0,
+			"temp_crit_alarm": false,
+		},
+		map[string]string{
+			"chip":    "coretemp",
+			"device":  "coretemp.0",
+			"feature": "temp1",
+			"label":   "Package id 0",
+		})
+
+	acc.AssertContainsTaggedFields(t, "hwmon",
+		map[string]interface{}{
+			"in_input": 1.128,
+			"in_min":   0.0,
+			"in_alarm": true,
+			"in_beep":  false,
+		},
+		map[string]string{
+			"chip":    "nct6775",
+			"feature": "in0",
+		})
+
+	acc.AssertContainsTaggedFields(t, "hwmon",
+		map[string]interface{}{
+			"fan_input": 1230.0,
+			"fan_min":   300.0,
+			"fan_alarm": false,
+		},
+		map[string]string{
+			"chip":    "nct6775",
+			"feature": "fan2",
+		})
+
+	acc.AssertContainsTaggedFields(t, "hwmon",
+		map[string]interface{}{
+			"temp_input": 31.0,
+			"temp_fault": false,
+		},
+		map[string]string{
+			"chip":    "nct6775",
+			"feature": "temp7",
+		})
+
+	acc.AssertContainsTaggedFields(t, "hwmon",
+		map[string]interface{}{
+			"power_average":          5.4,
+			"power_average_interval": 300.0,
+		},
+		map[string]string{
+			"chip":    "power_meter",
+			"feature": "power1",
+		})
+
+	acc.AssertContainsTaggedFields(t, "hwmon",
+		map[string]interface{}{
+			"curr_input": 1.5,
+		},
+		map[string]string{
+			"chip":    "power_meter",
+			"feature": "curr1",
+		})
+
+	assert.Len(t, acc.Metrics, 7)
+}
+
+func TestGatherFilters(t *testing.T) {
+	dir, cleanup := makeSysfs(t)
+	defer cleanup()
+
+	h := &Hwmon{
+		HostSys:        dir,
+		ChipExclude:    []string{"power_*"},
+		FeatureInclude: []string{"Package*", "in*", "fan*"},
+		FeatureExclude: []string{"in0"},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(h.Gather))
+
+	features := []string{}
+	for _, m := range acc.Metrics {
+		features = append(features, m.Tags["chip"]+"/"+m.Tags["feature"])
+	}
+	sort.Strings(features)
+	assert.Equal(t, []string{"coretemp/temp1", "nct6775/fan2"}, features)
+}
@@ -0,0 +1,166 @@
+package infiniband
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+// default host sys path
+const defaultHostSys = "/sys"
+
+// env host sys variable name
+const envSys = "HOST_SYS"
+
+// counterDirs are the directories of the counters of a port: the standard
+// counters of the InfiniBand specification, and the counters specific to the
+// hardware, such as the congestion counters of RoCE devices.
+var counterDirs = []string{"counters", "hw_counters"}
+
+type Infiniband struct {
+	HostSys        string   `toml:"host_sys"`
+	DeviceInclude  []string `toml:"device_include"`
+	DeviceExclude  []string `toml:"device_exclude"`
+	CounterInclude []string `toml:"counter_include"`
+	CounterExclude []string `toml:"counter_exclude"`
+
+	deviceFilter  filter.Filter
+	counterFilter filter.Filter
+	initialized   bool
+}
+
+var sampleConfig = `
+  ## Sets 'sys' directory path
+  ## If not specified, then default is /sys
+  # host_sys = "/sys"
+
+  ## Devices to gather, matched against the name of the device such as
+  ## "mlx5_0", globs are supported.
+  # device_include = []
+  # device_exclude = []
+
+  ## Counters to gather, matched against the name of the counter such as
+  ## "port_rcv_data" or "np_cnp_sent", globs are supported.
+  # counter_include = []
+  # counter_exclude = []
+`
+
+func (ib *Infiniband) Description() string {
+	return "Gather the port counters of InfiniBand and RoCE devices"
+}
+
+func (ib *Infiniband) SampleConfig() string {
+	return sampleConfig
+}
+
+func (ib *Infiniband) init() error {
+	if ib.HostSys == "" {
+		ib.HostSys = sys(envSys, defaultHostSys)
+	}
+
+	var err error
+	ib.deviceFilter, err = filter.NewIncludeExcludeFilter(ib.DeviceInclude, ib.DeviceExclude)
+	if err != nil {
+		return err
+	}
+	ib.counterFilter, err = filter.NewIncludeExcludeFilter(ib.CounterInclude, ib.CounterExclude)
+	if err != nil {
+		return err
+	}
+
+	ib.initialized = true
+	return nil
+}
+
+func (ib *Infiniband) Gather(acc telegraf.Accumulator) error {
+	if !ib.initialized {
+		if err := ib.init(); err != nil {
+			return err
+		}
+	}
+
+	ports, err := filepath.Glob(filepath.Join(ib.HostSys, "class", "infiniband", "*", "ports", "*"))
+	if err != nil {
+		return err
+	}
+
+	for _, port := range ports {
+		device := filepath.Base(filepath.Dir(filepath.Dir(port)))
+		if !ib.deviceFilter.Match(device) {
+			continue
+		}
+		ib.gatherPort(acc, device, port)
+	}
+	return nil
+}
+
+func (ib *Infiniband) gatherPort(acc telegraf.Accumulator, device, port string) {
+	fields := make(map[string]interface{})
+	for _, dir := range counterDirs {
+		files, err := ioutil.ReadDir(filepath.Join(port, dir))
+		if err != nil {
+			// The hardware counters are not provided by all the drivers
+			if !os.IsNotExist(err) {
+				acc.AddError(err)
+			}
+			continue
+		}
+
+		for _, file := range files {
+			if file.IsDir() || !ib.counterFilter.Match(file.Name()) {
+				continue
+			}
+			value, err := readString(filepath.Join(port, dir, file.Name()))
+			if err != nil {
+				// Some counters are not supported by the device
+				continue
+			}
+			if v, err := strconv.ParseUint(value, 10, 64); err == nil {
+				fields[file.Name()] = v
+			}
+		}
+	}
+
+	if len(fields) == 0 {
+		return
+	}
+
+	tags := map[string]string{
+		"device": device,
+		"port":   filepath.Base(port),
+	}
+	if linkLayer, err := readString(filepath.Join(port, "link_layer")); err == nil {
+		tags["link_layer"] = linkLayer
+	}
+	acc.AddFields("infiniband", fields, tags)
+}
+
+func readString(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// sys can be used to read file paths from env
+func sys(env, path string) string {
+	// try to read full file path
+	if p := os.Getenv(env); p != "" {
+		return p
+	}
+	// return default path
+	return path
+}
+
+func init() {
+	inputs.Add("infiniband", func() telegraf.Input {
+		return &Infiniband{}
+	})
+}
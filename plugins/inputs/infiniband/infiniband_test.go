@@ -0,0 +1,134 @@
+package infiniband
+
+import (
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeSysfs(t *testing.T) (string, func()) {
+	dir, cleanup := testutil.TempDir(t, "infiniband")
+
+	testutil.WriteFiles(t, filepath.Join(dir, "class", "infiniband"), map[string]string{
+		"mlx4_0/ports/1/link_layer":                   "InfiniBand\n",
+		"mlx4_0/ports/1/counters/port_rcv_data":       "7311534\n",
+		"mlx4_0/ports/1/counters/port_xmit_data":      "5842330\n",
+		"mlx4_0/ports/1/counters/port_rcv_packets":    "101593\n",
+		"mlx4_0/ports/1/counters/port_xmit_packets":   "81142\n",
+		"mlx4_0/ports/1/counters/port_rcv_errors":     "0\n",
+		"mlx4_0/ports/1/counters/symbol_error":        "3\n",
+		"mlx4_0/ports/1/counters/port_xmit_wait":      "18446744073709551615\n",
+		"mlx4_0/ports/1/counters/VL15_dropped":        "N/A (no PMA)\n",
+		"mlx4_0/ports/2/link_layer":                   "InfiniBand\n",
+		"mlx4_0/ports/2/counters/port_rcv_data":       "0\n",
+		"mlx4_0/ports/2/counters/port_rcv_packets":    "0\n",
+		"mlx5_0/ports/1/link_layer":                   "Ethernet\n",
+		"mlx5_0/ports/1/counters/port_rcv_data":       "1024\n",
+		"mlx5_0/ports/1/hw_counters/np_cnp_sent":      "12\n",
+		"mlx5_0/ports/1/hw_counters/rp_cnp_handled":   "7\n",
+		"mlx5_0/ports/1/hw_counters/out_of_sequence":  "1\n",
+		"mlx5_0/ports/1/hw_counters/lifespan":         "10\n",
+		"mlx5_0/ports/1/counters/unicast_rcv_packets": "64\n",
+	})
+	return dir, cleanup
+}
+
+func TestGather(t *testing.T) {
+	dir, cleanup := makeSysfs(t)
+	defer cleanup()
+
+	ib := &Infiniband{HostSys: dir}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(ib.Gather))
+
+	acc.AssertContainsTaggedFields(t, "infiniband",
+		map[string]interface{}{
+			"port_rcv_data":     uint64(7311534),
+			"port_xmit_data":    uint64(5842330),
+			"port_rcv_packets":  uint64(101593),
+			"port_xmit_packets": uint64(81142),
+			"port_rcv_errors":   uint64(0),
+			"symbol_error":      uint64(3),
+			"port_xmit_wait":    uint64(18446744073709551615),
+		},
+		map[string]string{
+			"device":     "mlx4_0",
+			"port":       "1",
+			"link_layer": "InfiniBand",
+		})
+
+	acc.AssertContainsTaggedFields(t, "infiniband",
+		map[string]interface{}{
+			"port_rcv_data":    uint64(0),
+			"port_rcv_packets": uint64(0),
+		},
+		map[string]string{
+			"device":     "mlx4_0",
+			"port":       "2",
+			"link_layer": "InfiniBand",
+		})
+
+	acc.AssertContainsTaggedFields(t, "infiniband",
+		map[string]interface{}{
+			"port_rcv_data":       uint64(1024),
+			"unicast_rcv_packets": uint64(64),
+			"np_cnp_sent":         uint64(12),
+			"rp_cnp_handled":      uint64(7),
+			"out_of_sequence":     uint64(1),
+			"lifespan":            uint64(10),
+		},
+		map[string]string{
+			"device":     "mlx5_0",
+			"port":       "1",
+			"link_layer": "Ethernet",
+		})
+
+	assert.Len(t, acc.Metrics, 3)
+}
+
+func TestGatherFilters(t *testing.T) {
+	dir, cleanup := makeSysfs(t)
+	defer cleanup()
+
+	ib := &Infiniband{
+		HostSys:        dir,
+		DeviceInclude:  []string{"mlx5_*", "mlx4_0"},
+		CounterInclude: []string{"port_*", "*cnp*"},
+		CounterExclude: []string{"port_xmit_*"},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(ib.Gather))
+
+	counters := []string{}
+	for _, m := range acc.Metrics {
+		for name := range m.Fields {
+			counters = append(counters, m.Tags["device"]+"/"+m.Tags["port"]+"/"+name)
+		}
+	}
+	sort.Strings(counters)
+	assert.Equal(t, []string{
+		"mlx4_0/1/port_rcv_data",
+		"mlx4_0/1/port_rcv_errors",
+		"mlx4_0/1/port_rcv_packets",
+		"mlx4_0/2/port_rcv_data",
+		"mlx4_0/2/port_rcv_packets",
+		"mlx5_0/1/np_cnp_sent",
+		"mlx5_0/1/port_rcv_data",
+		"mlx5_0/1/rp_cnp_handled",
+	}, counters)
+
+	ib = &Infiniband{
+		HostSys:       dir,
+		DeviceExclude: []string{"mlx4_*"},
+	}
+	acc = testutil.Accumulator{}
+	require.NoError(t, acc.GatherError(ib.Gather))
+	require.Len(t, acc.Metrics, 1)
+	assert.Equal(t, "mlx5_0", acc.Metrics[0].Tags["device"])
+}
@@ -0,0 +1,446 @@
+// +build linux
+
+package intel_powerstat
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+// Model specific registers
+const (
+	msrTSC               = 0x10
+	msrMPERF             = 0xE7
+	msrAPERF             = 0xE8
+	msrThermStatus       = 0x19C
+	msrTemperatureTarget = 0x1A2
+	msrCoreC3Residency   = 0x3FC
+	msrCoreC6Residency   = 0x3FD
+	msrCoreC7Residency   = 0x3FE
+)
+
+// Metric groups of the package and cpu metrics.
+const (
+	currentPowerConsumption     = "current_power_consumption"
+	currentDramPowerConsumption = "current_dram_power_consumption"
+	thermalDesignPower          = "thermal_design_power"
+
+	cpuFrequency        = "cpu_frequency"
+	cpuBusyFrequency    = "cpu_busy_frequency"
+	cpuTemperature      = "cpu_temperature"
+	cpuBusyCycles       = "cpu_busy_cycles"
+	cpuC1StateResidency = "cpu_c1_state_residency"
+	cpuC6StateResidency = "cpu_c6_state_residency"
+	cpuThrottle         = "cpu_throttle"
+)
+
+var packageMetricGroups = []string{
+	currentPowerConsumption,
+	currentDramPowerConsumption,
+	thermalDesignPower,
+}
+
+var cpuMetricGroups = []string{
+	cpuFrequency,
+	cpuBusyFrequency,
+	cpuTemperature,
+	cpuBusyCycles,
+	cpuC1StateResidency,
+	cpuC6StateResidency,
+	cpuThrottle,
+}
+
+// msrMetricGroups are the cpu metric groups reading model specific registers
+var msrMetricGroups = []string{
+	cpuBusyFrequency,
+	cpuTemperature,
+	cpuBusyCycles,
+	cpuC1StateResidency,
+	cpuC6StateResidency,
+}
+
+const sampleConfig = `
+  ## Package metrics are read from the RAPL powercap interface, available
+  ## groups are "current_power_consumption", "current_dram_power_consumption"
+  ## and "thermal_design_power".
+  # package_metrics = ["current_power_consumption", "current_dram_power_consumption", "thermal_design_power"]
+
+  ## Per CPU metrics, available groups are "cpu_frequency",
+  ## "cpu_busy_frequency", "cpu_temperature", "cpu_busy_cycles",
+  ## "cpu_c1_state_residency", "cpu_c6_state_residency" and "cpu_throttle".
+  ## All groups but "cpu_frequency" and "cpu_throttle" read model specific
+  ## registers, which requires the msr kernel module and root privileges.
+  # cpu_metrics = []
+`
+
+type IntelPowerstat struct {
+	PackageMetrics []string `toml:"package_metrics"`
+	CPUMetrics     []string `toml:"cpu_metrics"`
+
+	cpuPath  string
+	raplPath string
+	openMSR  func(cpuID string) (msrFile, error)
+	now      func() time.Time
+
+	initialized bool
+	readMSR     bool
+	energyPrev  map[string]energySample
+	msrPrev     map[string]msrSample
+}
+
+// msrFile gives access to the model specific registers of a cpu, each
+// register is read at the offset of its address.
+type msrFile interface {
+	ReadAt(b []byte, off int64) (int, error)
+	Close() error
+}
+
+func openMSRDevice(cpuID string) (msrFile, error) {
+	return os.Open(filepath.Join("/dev/cpu", cpuID, "msr"))
+}
+
+type energySample struct {
+	energy uint64
+	time   time.Time
+}
+
+type msrSample struct {
+	tsc, mperf, aperf uint64
+	c3, c6, c7        uint64
+	time              time.Time
+}
+
+func (p *IntelPowerstat) Description() string {
+	return "Read power, frequency and C-state telemetry of Intel CPUs"
+}
+
+func (p *IntelPowerstat) SampleConfig() string {
+	return sampleConfig
+}
+
+func (p *IntelPowerstat) init() error {
+	for _, m := range p.PackageMetrics {
+		if !contains(packageMetricGroups, m) {
+			return fmt.Errorf("unknown package metric group %q", m)
+		}
+	}
+	for _, m := range p.CPUMetrics {
+		if !contains(cpuMetricGroups, m) {
+			return fmt.Errorf("unknown cpu metric group %q", m)
+		}
+		if contains(msrMetricGroups, m) {
+			p.readMSR = true
+		}
+	}
+
+	p.energyPrev = make(map[string]energySample)
+	p.msrPrev = make(map[string]msrSample)
+	p.initialized = true
+	return nil
+}
+
+func (p *IntelPowerstat) Gather(acc telegraf.Accumulator) error {
+	if !p.initialized {
+		if err := p.init(); err != nil {
+			return err
+		}
+	}
+
+	if len(p.PackageMetrics) > 0 {
+		if err := p.gatherPackages(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+
+	if len(p.CPUMetrics) > 0 {
+		if err := p.gatherCPUs(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+
+	return nil
+}
+
+// gatherPackages reads the RAPL zones of each package, the power
+// consumption is computed from the energy counters between two intervals.
+func (p *IntelPowerstat) gatherPackages(acc telegraf.Accumulator) error {
+	zones, err := filepath.Glob(filepath.Join(p.raplPath, "intel-rapl:[0-9]*"))
+	if err != nil {
+		return err
+	}
+
+	now := p.now()
+	for _, zone := range zones {
+		// Subzones such as intel-rapl:0:0 are read with their package
+		if strings.Count(filepath.Base(zone), ":") != 1 {
+			continue
+		}
+
+		name, err := readString(filepath.Join(zone, "name"))
+		if err != nil || !strings.HasPrefix(name, "package-") {
+			continue
+		}
+
+		tags := map[string]string{
+			"package_id": strings.TrimPrefix(name, "package-"),
+		}
+		fields := make(map[string]interface{})
+
+		if contains(p.PackageMetrics, currentPowerConsumption) {
+			if watts, ok := p.power(zone, now); ok {
+				fields["current_power_consumption_watts"] = watts
+			}
+		}
+
+		if contains(p.PackageMetrics, currentDramPowerConsumption) {
+			subzones, _ := filepath.Glob(zone + ":[0-9]*")
+			for _, subzone := range subzones {
+				if name, err := readString(filepath.Join(subzone, "name")); err != nil || name != "dram" {
+					continue
+				}
+				if watts, ok := p.power(subzone, now); ok {
+					fields["current_dram_power_consumption_watts"] = watts
+				}
+			}
+		}
+
+		if contains(p.PackageMetrics, thermalDesignPower) {
+			if uw, err := readUint(filepath.Join(zone, "constraint_0_max_power_uw")); err == nil {
+				fields["thermal_design_power_watts"] = float64(uw) / 1e6
+			}
+		}
+
+		if len(fields) > 0 {
+			acc.AddFields("powerstat_package", fields, tags, now)
+		}
+	}
+
+	return nil
+}
+
+// power returns the average power in watts of a RAPL zone since the last
+// call, false is returned on the first call.
+func (p *IntelPowerstat) power(zone string, now time.Time) (float64, bool) {
+	energy, err := readUint(filepath.Join(zone, "energy_uj"))
+	if err != nil {
+		return 0, false
+	}
+
+	prev, ok := p.energyPrev[zone]
+	p.energyPrev[zone] = energySample{energy: energy, time: now}
+	if !ok {
+		return 0, false
+	}
+
+	elapsed := now.Sub(prev.time).Seconds()
+	if elapsed <= 0 {
+		return 0, false
+	}
+
+	// The energy counter wraps around at max_energy_range_uj
+	if energy < prev.energy {
+		max, err := readUint(filepath.Join(zone, "max_energy_range_uj"))
+		if err != nil {
+			return 0, false
+		}
+		energy += max
+	}
+
+	return float64(energy-prev.energy) / 1e6 / elapsed, true
+}
+
+func (p *IntelPowerstat) gatherCPUs(acc telegraf.Accumulator) error {
+	cpus, err := filepath.Glob(filepath.Join(p.cpuPath, "cpu[0-9]*"))
+	if err != nil {
+		return err
+	}
+
+	now := p.now()
+	for _, cpu := range cpus {
+		cpuID := strings.TrimPrefix(filepath.Base(cpu), "cpu")
+		if _, err := strconv.Atoi(cpuID); err != nil {
+			continue
+		}
+
+		tags := map[string]string{
+			"cpu_id": cpuID,
+		}
+		if id, err := readString(filepath.Join(cpu, "topology", "physical_package_id")); err == nil {
+			tags["package_id"] = id
+		}
+		if id, err := readString(filepath.Join(cpu, "topology", "core_id")); err == nil {
+			tags["core_id"] = id
+		}
+
+		fields := make(map[string]interface{})
+
+		if contains(p.CPUMetrics, cpuFrequency) {
+			if khz, err := readUint(filepath.Join(cpu, "cpufreq", "scaling_cur_freq")); err == nil {
+				fields["cpu_frequency_mhz"] = float64(khz) / 1000
+			}
+		}
+
+		if contains(p.CPUMetrics, cpuThrottle) {
+			if count, err := readUint(filepath.Join(cpu, "thermal_throttle", "core_throttle_count")); err == nil {
+				fields["cpu_throttle_count"] = count
+			}
+		}
+
+		if p.readMSR {
+			if err := p.msrFields(fields, cpuID, now); err != nil {
+				acc.AddError(fmt.Errorf("error reading msr of cpu %s: %s", cpuID, err))
+			}
+		}
+
+		if len(fields) > 0 {
+			acc.AddFields("powerstat_core", fields, tags, now)
+		}
+	}
+
+	return nil
+}
+
+// msrFields adds the fields read from the model specific registers of a cpu.
+func (p *IntelPowerstat) msrFields(fields map[string]interface{}, cpuID string, now time.Time) error {
+	f, err := p.openMSR(cpuID)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if contains(p.CPUMetrics, cpuTemperature) {
+		status, err := readRegister(f, msrThermStatus)
+		if err != nil {
+			return err
+		}
+		target, err := readRegister(f, msrTemperatureTarget)
+		if err != nil {
+			return err
+		}
+		// The digital readout is the distance to the maximum junction
+		// temperature TjMax.
+		tjMax := (target >> 16) & 0xFF
+		readout := (status >> 16) & 0x7F
+		fields["cpu_temperature_celsius"] = int64(tjMax) - int64(readout)
+	}
+
+	if !contains(p.CPUMetrics, cpuBusyFrequency) &&
+		!contains(p.CPUMetrics, cpuBusyCycles) &&
+		!contains(p.CPUMetrics, cpuC1StateResidency) &&
+		!contains(p.CPUMetrics, cpuC6StateResidency) {
+		return nil
+	}
+
+	sample := msrSample{time: now}
+	registers := []struct {
+		address uint32
+		value   *uint64
+	}{
+		{msrTSC, &sample.tsc},
+		{msrMPERF, &sample.mperf},
+		{msrAPERF, &sample.aperf},
+		{msrCoreC6Residency, &sample.c6},
+	}
+	for _, r := range registers {
+		if *r.value, err = readRegister(f, r.address); err != nil {
+			return err
+		}
+	}
+	// Not all processors have these residency counters
+	sample.c3, _ = readRegister(f, msrCoreC3Residency)
+	sample.c7, _ = readRegister(f, msrCoreC7Residency)
+
+	prev, ok := p.msrPrev[cpuID]
+	p.msrPrev[cpuID] = sample
+	if !ok {
+		return nil
+	}
+
+	tsc := float64(sample.tsc - prev.tsc)
+	mperf := float64(sample.mperf - prev.mperf)
+	elapsed := now.Sub(prev.time).Seconds()
+	if tsc == 0 || elapsed <= 0 {
+		return nil
+	}
+
+	c0 := 100 * mperf / tsc
+	c6 := 100 * float64(sample.c6-prev.c6) / tsc
+	c1 := 100 - c0 - c6 -
+		100*float64(sample.c3-prev.c3)/tsc -
+		100*float64(sample.c7-prev.c7)/tsc
+	if c1 < 0 {
+		c1 = 0
+	}
+
+	if contains(p.CPUMetrics, cpuBusyCycles) {
+		fields["cpu_busy_cycles_percent"] = c0
+	}
+	if contains(p.CPUMetrics, cpuC1StateResidency) {
+		fields["cpu_c1_state_residency_percent"] = c1
+	}
+	if contains(p.CPUMetrics, cpuC6StateResidency) {
+		fields["cpu_c6_state_residency_percent"] = c6
+	}
+	if contains(p.CPUMetrics, cpuBusyFrequency) && mperf > 0 {
+		// The TSC runs at the base frequency of the processor
+		baseMHz := tsc / elapsed / 1e6
+		fields["cpu_busy_frequency_mhz"] = baseMHz * float64(sample.aperf-prev.aperf) / mperf
+	}
+
+	return nil
+}
+
+// readRegister reads a 64 bit model specific register.
+func readRegister(f msrFile, address uint32) (uint64, error) {
+	buf := make([]byte, 8)
+	if _, err := f.ReadAt(buf, int64(address)); err != nil {
+		return 0, fmt.Errorf("could not read register 0x%X: %s", address, err)
+	}
+	return binary.LittleEndian.Uint64(buf), nil
+}
+
+func readString(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func readUint(path string) (uint64, error) {
+	value, err := readString(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(value, 10, 64)
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	inputs.Add("intel_powerstat", func() telegraf.Input {
+		return &IntelPowerstat{
+			PackageMetrics: []string{currentPowerConsumption, currentDramPowerConsumption, thermalDesignPower},
+			cpuPath:        "/sys/devices/system/cpu",
+			openMSR:        openMSRDevice,
+			raplPath:       "/sys/devices/virtual/powercap/intel-rapl",
+			now:            time.Now,
+		}
+	})
+}
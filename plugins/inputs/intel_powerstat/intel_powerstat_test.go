@@ -0,0 +1,199 @@
+// +build linux
+
+package intel_powerstat
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMSR holds the values of the registers of a cpu.
+type fakeMSR map[uint32]uint64
+
+func (m fakeMSR) ReadAt(b []byte, off int64) (int, error) {
+	value, ok := m[uint32(off)]
+	if !ok {
+		return 0, io.EOF
+	}
+	binary.LittleEndian.PutUint64(b, value)
+	return 8, nil
+}
+
+func (m fakeMSR) Close() error {
+	return nil
+}
+
+type fakeClock struct {
+	t time.Time
+}
+
+func (c *fakeClock) now() time.Time {
+	return c.t
+}
+
+func newPowerstat(t *testing.T) (*IntelPowerstat, *fakeClock, func()) {
+	dir, cleanup := testutil.TempDir(t, "intel_powerstat")
+
+	clock := &fakeClock{t: time.Unix(1531390000, 0)}
+	p := &IntelPowerstat{
+		cpuPath:  filepath.Join(dir, "cpu"),
+		raplPath: filepath.Join(dir, "rapl"),
+		openMSR: func(cpuID string) (msrFile, error) {
+			return nil, os.ErrNotExist
+		},
+		now: clock.now,
+	}
+	return p, clock, cleanup
+}
+
+func TestGatherPackage(t *testing.T) {
+	p, clock, cleanup := newPowerstat(t)
+	defer cleanup()
+	p.PackageMetrics = []string{currentPowerConsumption, currentDramPowerConsumption, thermalDesignPower}
+
+	testutil.WriteFiles(t, p.raplPath, map[string]string{
+		"intel-rapl:0/name":                      "package-0\n",
+		"intel-rapl:0/energy_uj":                 "1000000\n",
+		"intel-rapl:0/max_energy_range_uj":       "262143328850\n",
+		"intel-rapl:0/constraint_0_max_power_uw": "165000000\n",
+		"intel-rapl:0:0/name":                    "dram\n",
+		"intel-rapl:0:0/energy_uj":               "2000000\n",
+		"intel-rapl:1/name":                      "psys\n",
+	})
+
+	var acc testutil.Accumulator
+	require.NoError(t, p.Gather(&acc))
+	acc.AssertContainsTaggedFields(t, "powerstat_package",
+		map[string]interface{}{
+			"thermal_design_power_watts": 165.0,
+		},
+		map[string]string{"package_id": "0"})
+
+	testutil.WriteFiles(t, p.raplPath, map[string]string{
+		"intel-rapl:0/energy_uj":   "51000000\n",
+		"intel-rapl:0:0/energy_uj": "12000000\n",
+	})
+	clock.t = clock.t.Add(time.Second)
+
+	acc.ClearMetrics()
+	require.NoError(t, p.Gather(&acc))
+	acc.AssertContainsTaggedFields(t, "powerstat_package",
+		map[string]interface{}{
+			"current_power_consumption_watts":      50.0,
+			"current_dram_power_consumption_watts": 10.0,
+			"thermal_design_power_watts":           165.0,
+		},
+		map[string]string{"package_id": "0"})
+	assert.Len(t, acc.Metrics, 1)
+
+	// The energy counter wrapped around
+	testutil.WriteFiles(t, p.raplPath, map[string]string{
+		"intel-rapl:0/energy_uj": "12000000\n",
+	})
+	clock.t = clock.t.Add(2 * time.Second)
+
+	acc.ClearMetrics()
+	require.NoError(t, p.Gather(&acc))
+	watts, ok := acc.Metrics[0].Fields["current_power_consumption_watts"].(float64)
+	require.True(t, ok)
+	assert.InDelta(t, (262143328850-51000000+12000000)/1e6/2, watts, 1e-6)
+}
+
+func TestGatherCPU(t *testing.T) {
+	p, clock, cleanup := newPowerstat(t)
+	defer cleanup()
+	p.CPUMetrics = cpuMetricGroups
+
+	testutil.WriteFiles(t, p.cpuPath, map[string]string{
+		"cpu0/topology/physical_package_id":         "0\n",
+		"cpu0/topology/core_id":                     "3\n",
+		"cpu0/cpufreq/scaling_cur_freq":             "2899999\n",
+		"cpu0/thermal_throttle/core_throttle_count": "12\n",
+		"cpufreq/policy0/scaling_governor":          "powersave\n",
+	})
+
+	msr := fakeMSR{
+		msrTSC:               0,
+		msrMPERF:             0,
+		msrAPERF:             0,
+		msrCoreC6Residency:   0,
+		msrThermStatus:       35 << 16,
+		msrTemperatureTarget: 100 << 16,
+	}
+	p.openMSR = func(cpuID string) (msrFile, error) {
+		if cpuID != "0" {
+			return nil, os.ErrNotExist
+		}
+		return msr, nil
+	}
+
+	tags := map[string]string{
+		"cpu_id":     "0",
+		"core_id":    "3",
+		"package_id": "0",
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, p.Gather(&acc))
+	require.Len(t, acc.Errors, 0)
+	acc.AssertContainsTaggedFields(t, "powerstat_core",
+		map[string]interface{}{
+			"cpu_frequency_mhz":       2899.999,
+			"cpu_throttle_count":      uint64(12),
+			"cpu_temperature_celsius": int64(65),
+		},
+		tags)
+
+	// Busy half of the time at 1.5 times the base frequency of 2GHz
+	msr[msrTSC] = 2000000000
+	msr[msrMPERF] = 1000000000
+	msr[msrAPERF] = 1500000000
+	msr[msrCoreC6Residency] = 600000000
+	clock.t = clock.t.Add(time.Second)
+
+	acc.ClearMetrics()
+	require.NoError(t, p.Gather(&acc))
+	require.Len(t, acc.Errors, 0)
+	acc.AssertContainsTaggedFields(t, "powerstat_core",
+		map[string]interface{}{
+			"cpu_frequency_mhz":              2899.999,
+			"cpu_throttle_count":             uint64(12),
+			"cpu_temperature_celsius":        int64(65),
+			"cpu_busy_frequency_mhz":         3000.0,
+			"cpu_busy_cycles_percent":        50.0,
+			"cpu_c1_state_residency_percent": 20.0,
+			"cpu_c6_state_residency_percent": 30.0,
+		},
+		tags)
+}
+
+func TestGatherNoMSR(t *testing.T) {
+	p, _, cleanup := newPowerstat(t)
+	defer cleanup()
+	p.CPUMetrics = []string{cpuTemperature}
+
+	testutil.WriteFiles(t, p.cpuPath, map[string]string{
+		"cpu0/topology/core_id": "0\n",
+	})
+
+	var acc testutil.Accumulator
+	require.NoError(t, p.Gather(&acc))
+	assert.Len(t, acc.Errors, 1)
+}
+
+func TestInvalidMetricGroup(t *testing.T) {
+	p, _, cleanup := newPowerstat(t)
+	defer cleanup()
+	p.CPUMetrics = []string{"cpu_c7_state_residency"}
+
+	var acc testutil.Accumulator
+	require.Error(t, p.Gather(&acc))
+}
@@ -266,6 +266,67 @@ func TestJolokia2_ObjectValues(t *testing.T) {
 	})
 }
 
+func TestJolokia2_ArrayValues(t *testing.T) {
+	config := `
+	[jolokia2_agent]
+		urls = ["%s"]
+
+	[[jolokia2_agent.metric]]
+		name  = "array_of_composites"
+		mbean = "array_of_composites"
+		paths = ["LastGcInfo"]
+
+	[[jolokia2_agent.metric]]
+		name  = "array_of_scalars"
+		mbean = "array_of_scalars"`
+
+	response := `[{
+		"request": {
+			"mbean": "array_of_composites",
+			"attribute": "LastGcInfo",
+			"type": "read"
+		},
+		"value": [{
+			"used": 123,
+			"max": 456
+		}, {
+			"used": 789
+		}],
+		"status": 200
+	}, {
+		"request": {
+			"mbean": "array_of_scalars",
+			"type": "read"
+		},
+		"value": {
+			"ports": [8080, 8443]
+		},
+		"status": 200
+	}]`
+
+	server := setupServer(http.StatusOK, response)
+	defer server.Close()
+	plugin := setupPlugin(t, fmt.Sprintf(config, server.URL))
+
+	var acc testutil.Accumulator
+	assert.NoError(t, plugin.Gather(&acc))
+
+	acc.AssertContainsTaggedFields(t, "array_of_composites", map[string]interface{}{
+		"LastGcInfo.0.used": 123.0,
+		"LastGcInfo.0.max":  456.0,
+		"LastGcInfo.1.used": 789.0,
+	}, map[string]string{
+		"jolokia_agent_url": server.URL,
+	})
+
+	acc.AssertContainsTaggedFields(t, "array_of_scalars", map[string]interface{}{
+		"ports.0": 8080.0,
+		"ports.1": 8443.0,
+	}, map[string]string{
+		"jolokia_agent_url": server.URL,
+	})
+}
+
 func TestJolokia2_StatusCodes(t *testing.T) {
 	config := `
 	[jolokia2_agent]
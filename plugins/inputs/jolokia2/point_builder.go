@@ -2,6 +2,7 @@ package jolokia2
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -172,6 +173,23 @@ func (pb *pointBuilder) fillFields(name string, value interface{}, fieldMap map[
 		return
 	}
 
+	if valueList, ok := value.([]interface{}); ok {
+		// arrays of composite data are flattened by index
+		for i, innerValue := range valueList {
+			var innerName string
+
+			if name == "" {
+				innerName = pb.metric.FieldPrefix + strconv.Itoa(i)
+			} else {
+				innerName = name + pb.metric.FieldSeparator + strconv.Itoa(i)
+			}
+
+			pb.fillFields(innerName, innerValue, fieldMap)
+		}
+
+		return
+	}
+
 	if pb.metric.FieldName != "" {
 		name = pb.metric.FieldName
 		if prefix := pb.metric.FieldPrefix; prefix != "" {
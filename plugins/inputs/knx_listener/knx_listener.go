@@ -0,0 +1,210 @@
+package knx_listener
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"sync"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/vapourismo/knx-go/knx"
+	"github.com/vapourismo/knx-go/knx/dpt"
+)
+
+// KNXInterface is the group communication client of the gateway
+type KNXInterface interface {
+	Inbound() <-chan knx.GroupEvent
+	Close()
+}
+
+// Measurement maps group addresses to a measurement
+type Measurement struct {
+	Name      string   `toml:"name"`
+	Dpt       string   `toml:"dpt"`
+	Addresses []string `toml:"addresses"`
+}
+
+// KNXListener listens to the telegrams of the group addresses
+type KNXListener struct {
+	ServiceType    string        `toml:"service_type"`
+	ServiceAddress string        `toml:"service_address"`
+	Measurements   []Measurement `toml:"measurement"`
+
+	client      KNXInterface
+	gaTargetMap map[string]addressTarget
+	gaLogbook   map[string]bool
+
+	acc telegraf.Accumulator
+	wg  sync.WaitGroup
+}
+
+type addressTarget struct {
+	measurement string
+	datapoint   dpt.DatapointValue
+}
+
+const sampleConfig = `
+  ## Type of KNX-IP interface.
+  ## Can be either "tunnel" or "router".
+  # service_type = "tunnel"
+
+  ## Address of the KNX-IP interface, the gateway with "tunnel", or the
+  ## multicast group with "router".
+  service_address = "localhost:3671"
+
+  ## Measurement definition(s)
+  ## name      - name of the measurement
+  ## dpt       - datapoint type of the group addresses, ie "1.001" or "9.001"
+  ## addresses - group addresses of the measurement, ie "5/5/1"
+  # [[inputs.knx_listener.measurement]]
+  #   ## Name of the measurement
+  #   name = "temperature"
+  #   ## Datapoint-Type (DPT) of the KNX messages
+  #   dpt = "9.001"
+  #   ## List of Group-Addresses (GAs) assigned to the measurement
+  #   addresses = ["5/5/1"]
+
+  # [[inputs.knx_listener.measurement]]
+  #   name = "illumination"
+  #   dpt = "9.004"
+  #   addresses = ["5/5/3"]
+`
+
+func (kl *KNXListener) SampleConfig() string {
+	return sampleConfig
+}
+
+func (kl *KNXListener) Description() string {
+	return "Listener capable of handling KNX bus messages provided through a KNX-IP Interface."
+}
+
+func (kl *KNXListener) Gather(_ telegraf.Accumulator) error {
+	return nil
+}
+
+func (kl *KNXListener) Start(acc telegraf.Accumulator) error {
+	// Prepare the mapping of the group addresses to the measurements
+	kl.gaTargetMap = make(map[string]addressTarget)
+	for _, m := range kl.Measurements {
+		log.Printf("D! [inputs.knx_listener] group-address mapping for measurement %q:", m.Name)
+		for _, ga := range m.Addresses {
+			log.Printf("D! [inputs.knx_listener]   %s --> %s", ga, m.Dpt)
+			if _, ok := kl.gaTargetMap[ga]; ok {
+				return fmt.Errorf("duplicate specification of address %q", ga)
+			}
+			d, ok := dpt.Produce(m.Dpt)
+			if !ok {
+				return fmt.Errorf("cannot create datapoint-type %q for address %q", m.Dpt, ga)
+			}
+			kl.gaTargetMap[ga] = addressTarget{m.Name, d}
+		}
+	}
+	kl.gaLogbook = make(map[string]bool)
+
+	// Connect to the KNX-IP interface
+	if kl.client == nil {
+		switch kl.ServiceType {
+		case "", "tunnel":
+			c, err := knx.NewGroupTunnel(kl.ServiceAddress, knx.DefaultTunnelConfig)
+			if err != nil {
+				return err
+			}
+			kl.client = &c
+		case "router":
+			c, err := knx.NewGroupRouter(kl.ServiceAddress, knx.DefaultRouterConfig)
+			if err != nil {
+				return err
+			}
+			kl.client = &c
+		default:
+			return fmt.Errorf("invalid service_type %q, must be tunnel or router", kl.ServiceType)
+		}
+	}
+
+	kl.acc = acc
+
+	kl.wg.Add(1)
+	go func() {
+		defer kl.wg.Done()
+		kl.listen()
+	}()
+
+	return nil
+}
+
+func (kl *KNXListener) Stop() {
+	if kl.client != nil {
+		kl.client.Close()
+		kl.wg.Wait()
+	}
+}
+
+// listen adds the telegrams of the mapped group addresses until the client
+// is closed
+func (kl *KNXListener) listen() {
+	for msg := range kl.client.Inbound() {
+		// Match GA to DataPointType and measurement name
+		ga := msg.Destination.String()
+		target, ok := kl.gaTargetMap[ga]
+		if !ok {
+			if !kl.gaLogbook[ga] {
+				log.Printf("I! [inputs.knx_listener] ignoring message %+v for unknown GA %q", msg, ga)
+				kl.gaLogbook[ga] = true
+			}
+			continue
+		}
+
+		// Extract the value from the data-frame
+		if err := target.datapoint.Unpack(msg.Data); err != nil {
+			kl.acc.AddError(fmt.Errorf("unpacking data of GA %q failed: %v", ga, err))
+			continue
+		}
+
+		value := convert(target.datapoint)
+		if value == nil {
+			kl.acc.AddError(fmt.Errorf("unhandled datapoint type of GA %q", ga))
+			continue
+		}
+
+		// Compose the actual data to be sent
+		fields := map[string]interface{}{"value": value}
+		tags := map[string]string{
+			"groupaddress": ga,
+			"source":       msg.Source.String(),
+		}
+		if meta, ok := target.datapoint.(dpt.DatapointMeta); ok && meta.Unit() != "" {
+			tags["unit"] = meta.Unit()
+		}
+		kl.acc.AddFields(target.measurement, fields, tags)
+	}
+}
+
+// convert returns the value of the datapoint as a field value, the
+// datapoint types being defined over basic types
+func convert(d dpt.DatapointValue) interface{} {
+	v := reflect.Indirect(reflect.ValueOf(d))
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return v.Uint()
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	case reflect.String:
+		return v.String()
+	}
+
+	if s, ok := d.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return nil
+}
+
+func init() {
+	inputs.Add("knx_listener", func() telegraf.Input {
+		return &KNXListener{ServiceType: "tunnel"}
+	})
+}
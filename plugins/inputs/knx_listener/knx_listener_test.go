@@ -0,0 +1,118 @@
+package knx_listener
+
+import (
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vapourismo/knx-go/knx"
+	"github.com/vapourismo/knx-go/knx/cemi"
+	"github.com/vapourismo/knx-go/knx/dpt"
+)
+
+type fakeClient struct {
+	inbound chan knx.GroupEvent
+}
+
+func (c *fakeClient) Inbound() <-chan knx.GroupEvent {
+	return c.inbound
+}
+
+func (c *fakeClient) Close() {
+	close(c.inbound)
+}
+
+func event(t *testing.T, address string, value dpt.DatapointValue) knx.GroupEvent {
+	ga, err := cemi.NewGroupAddrString(address)
+	require.NoError(t, err)
+	return knx.GroupEvent{
+		Command:     knx.GroupWrite,
+		Source:      cemi.NewIndividualAddr3(1, 1, 10),
+		Destination: ga,
+		Data:        value.Pack(),
+	}
+}
+
+func TestListen(t *testing.T) {
+	client := &fakeClient{inbound: make(chan knx.GroupEvent)}
+	kl := &KNXListener{
+		Measurements: []Measurement{
+			{Name: "temperature", Dpt: "9.001", Addresses: []string{"5/5/1", "5/5/2"}},
+			{Name: "switch", Dpt: "1.001", Addresses: []string{"1/0/1"}},
+			{Name: "counter", Dpt: "13.001", Addresses: []string{"2/0/1"}},
+		},
+		client: client,
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, kl.Start(&acc))
+
+	tempValue := dpt.DPT_9001(21.5)
+	switchValue := dpt.DPT_1001(true)
+	counterValue := dpt.DPT_13001(-42)
+	client.inbound <- event(t, "5/5/1", &tempValue)
+	client.inbound <- event(t, "1/0/1", &switchValue)
+	client.inbound <- event(t, "2/0/1", &counterValue)
+	// unknown group address
+	client.inbound <- event(t, "7/7/7", &switchValue)
+	kl.Stop()
+
+	require.Len(t, acc.Metrics, 3)
+	acc.AssertContainsTaggedFields(t, "temperature",
+		map[string]interface{}{"value": float64(21.5)},
+		map[string]string{"groupaddress": "5/5/1", "source": "1.1.10", "unit": "°C"})
+	acc.AssertContainsTaggedFields(t, "switch",
+		map[string]interface{}{"value": true},
+		map[string]string{"groupaddress": "1/0/1", "source": "1.1.10"})
+	acc.AssertContainsTaggedFields(t, "counter",
+		map[string]interface{}{"value": int64(-42)},
+		map[string]string{"groupaddress": "2/0/1", "source": "1.1.10", "unit": "pulses"})
+}
+
+func TestInvalidData(t *testing.T) {
+	client := &fakeClient{inbound: make(chan knx.GroupEvent)}
+	kl := &KNXListener{
+		Measurements: []Measurement{
+			{Name: "temperature", Dpt: "9.001", Addresses: []string{"5/5/1"}},
+		},
+		client: client,
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, kl.Start(&acc))
+
+	ga, err := cemi.NewGroupAddrString("5/5/1")
+	require.NoError(t, err)
+	client.inbound <- knx.GroupEvent{Destination: ga, Data: []byte{0}}
+	kl.Stop()
+
+	assert.Len(t, acc.Metrics, 0)
+	assert.Len(t, acc.Errors, 1)
+}
+
+func TestInvalidConfig(t *testing.T) {
+	tests := []struct {
+		name         string
+		measurements []Measurement
+	}{
+		{"unknown dpt", []Measurement{{Name: "temperature", Dpt: "99.999", Addresses: []string{"5/5/1"}}}},
+		{"duplicate address", []Measurement{
+			{Name: "temperature", Dpt: "9.001", Addresses: []string{"5/5/1"}},
+			{Name: "humidity", Dpt: "9.007", Addresses: []string{"5/5/1"}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kl := &KNXListener{
+				Measurements: tt.measurements,
+				client:       &fakeClient{inbound: make(chan knx.GroupEvent)},
+			}
+			require.Error(t, kl.Start(&testutil.Accumulator{}))
+		})
+	}
+
+	kl := &KNXListener{ServiceType: "usb"}
+	require.Error(t, kl.Start(&testutil.Accumulator{}))
+}
@@ -24,8 +24,15 @@ type Lustre2 struct {
 	Ost_procfiles []string
 	Mds_procfiles []string
 
-	// allFields maps and OST name to the metric fields associated with that OST
-	allFields map[string]map[string]interface{}
+	// allFields maps an OST name, job and client to the metric fields
+	// associated with them
+	allFields map[tags]map[string]interface{}
+}
+
+// tags identifies the target, and the job or the client of the per job and
+// per export statistics, the fields are reported for.
+type tags struct {
+	name, job, client string
 }
 
 var sampleConfig = `
@@ -41,6 +48,16 @@ var sampleConfig = `
   #   "/proc/fs/lustre/mdt/*/md_stats",
   #   "/proc/fs/lustre/mdt/*/job_stats",
   # ]
+  ##
+  ## The statistics of each client are in the stats files of the exports,
+  ## they are tagged with the NID of the client:
+  ##   "/proc/fs/lustre/obdfilter/*/exports/*/stats"
+  ##   "/proc/fs/lustre/mdt/*/exports/*/stats"
+  ##
+  ## Lustre 2.12 and later may keep the statistics of ZFS targets and the
+  ## job statistics elsewhere, such as:
+  ##   "/proc/fs/lustre/osd-zfs/*/stats"
+  ##   "/sys/kernel/debug/lustre/obdfilter/*/job_stats"
 `
 
 /* The wanted fields would be a []string if not for the
@@ -364,14 +381,28 @@ func (l *Lustre2) GetLustreProcStats(fileglob string, wanted_fields []*mapping,
 		 * into just the object store target name
 		 * Assumpion: the target name is always second to last,
 		 * which is true in Lustre 2.1->2.8
+		 * The stats of the exports are in
+		 * /proc/fs/lustre/obdfilter/<ost_name>/exports/<client_nid>/stats
 		 */
 		path := strings.Split(file, "/")
-		name := path[len(path)-2]
+		target := tags{name: path[len(path)-2]}
+		if len(path) > 3 && path[len(path)-3] == "exports" {
+			target = tags{name: path[len(path)-4], client: path[len(path)-2]}
+		}
+
+		// The fields are created on the first wanted field, as the job
+		// statistics only have fields in the sections of each job
+		key := target
 		var fields map[string]interface{}
-		fields, ok := l.allFields[name]
-		if !ok {
-			fields = make(map[string]interface{})
-			l.allFields[name] = fields
+		getFields := func() map[string]interface{} {
+			if fields == nil {
+				fields = l.allFields[key]
+				if fields == nil {
+					fields = make(map[string]interface{})
+					l.allFields[key] = fields
+				}
+			}
+			return fields
 		}
 
 		lines, err := internal.ReadLines(file)
@@ -381,9 +412,15 @@ func (l *Lustre2) GetLustreProcStats(fileglob string, wanted_fields []*mapping,
 
 		for _, line := range lines {
 			parts := strings.Fields(line)
+			if len(parts) < 2 {
+				continue
+			}
 			if strings.HasPrefix(line, "- job_id:") {
-				// Set the job_id explicitly if present
-				fields["jobid"] = parts[2]
+				// The following fields are the statistics of this job
+				key = target
+				key.job = parts[2]
+				fields = nil
+				continue
 			}
 
 			for _, wanted := range wanted_fields {
@@ -395,6 +432,10 @@ func (l *Lustre2) GetLustreProcStats(fileglob string, wanted_fields []*mapping,
 					if wanted_field == 0 {
 						wanted_field = 1
 					}
+					// Operations without samples have no min, max and sum
+					if int(wanted_field) >= len(parts) {
+						continue
+					}
 					data, err = strconv.ParseUint(strings.TrimSuffix((parts[wanted_field]), ","), 10, 64)
 					if err != nil {
 						return err
@@ -403,7 +444,7 @@ func (l *Lustre2) GetLustreProcStats(fileglob string, wanted_fields []*mapping,
 					if wanted.reportAs != "" {
 						report_name = wanted.reportAs
 					}
-					fields[report_name] = data
+					getFields()[report_name] = data
 				}
 			}
 		}
@@ -423,7 +464,7 @@ func (l *Lustre2) Description() string {
 
 // Gather reads stats from all lustre targets
 func (l *Lustre2) Gather(acc telegraf.Accumulator) error {
-	l.allFields = make(map[string]map[string]interface{})
+	l.allFields = make(map[tags]map[string]interface{})
 
 	if len(l.Ost_procfiles) == 0 {
 		// read/write bytes are in obdfilter/<ost_name>/stats
@@ -483,15 +524,15 @@ func (l *Lustre2) Gather(acc telegraf.Accumulator) error {
 		}
 	}
 
-	for name, fields := range l.allFields {
+	for key, fields := range l.allFields {
 		tags := map[string]string{
-			"name": name,
+			"name": key.name,
 		}
-		if _, ok := fields["jobid"]; ok {
-			if jobid, ok := fields["jobid"].(string); ok {
-				tags["jobid"] = jobid
-			}
-			delete(fields, "jobid")
+		if key.job != "" {
+			tags["jobid"] = key.job
+		}
+		if key.client != "" {
+			tags["client"] = key.client
 		}
 		acc.AddFields("lustre2", fields, tags)
 	}
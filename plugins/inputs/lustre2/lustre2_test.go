@@ -246,3 +246,85 @@ func TestLustre2GeneratesJobstatsMetrics(t *testing.T) {
 	err = os.RemoveAll(os.TempDir() + "/telegraf")
 	require.NoError(t, err)
 }
+
+const obdfilterMultipleJobStatsContents = `job_stats:
+- job_id:          cluster-testjob1
+  snapshot_time:   1461772761
+  read_bytes:      { samples:           1, unit: bytes, min:    4096, max:    4096, sum:            4096 }
+  write_bytes:     { samples:          25, unit: bytes, min: 1048576, max: 1048576, sum:        26214400 }
+- job_id:          cluster-testjob2
+  snapshot_time:   1461772769
+  read_bytes:      { samples:           2, unit: bytes, min:    1024, max:    2048, sum:            3072 }
+  write_bytes:     { samples:           0, unit: bytes, min:       0, max:       0, sum:               0 }
+`
+
+const obdfilterExportContents = `snapshot_time             1438693064.430544 secs.usecs
+read_bytes                12 samples [bytes] 4096 1048576 2097152
+write_bytes               3 samples [bytes] 1 1048576 1048577
+statfs                    5 samples [reqs]
+`
+
+func TestLustre2GeneratesMultipleJobsAndExportsMetrics(t *testing.T) {
+	tempdir, err := ioutil.TempDir("", "lustre2")
+	require.NoError(t, err)
+	defer os.RemoveAll(tempdir)
+
+	ost_name := "OST0001"
+	client := "192.168.0.10@tcp"
+
+	obddir := tempdir + "/obdfilter/"
+	err = os.MkdirAll(obddir+"/"+ost_name+"/exports/"+client, 0755)
+	require.NoError(t, err)
+
+	err = ioutil.WriteFile(obddir+"/"+ost_name+"/job_stats", []byte(obdfilterMultipleJobStatsContents), 0644)
+	require.NoError(t, err)
+
+	err = ioutil.WriteFile(obddir+"/"+ost_name+"/exports/"+client+"/stats", []byte(obdfilterExportContents), 0644)
+	require.NoError(t, err)
+
+	m := &Lustre2{
+		Ost_procfiles: []string{obddir + "/*/job_stats", obddir + "/*/exports/*/stats"},
+		Mds_procfiles: []string{tempdir + "/mdt/*/md_stats"},
+	}
+
+	var acc testutil.Accumulator
+
+	err = m.Gather(&acc)
+	require.NoError(t, err)
+	require.Len(t, acc.Metrics, 3)
+
+	acc.AssertContainsTaggedFields(t, "lustre2",
+		map[string]interface{}{
+			"jobstats_read_calls":     uint64(1),
+			"jobstats_read_min_size":  uint64(4096),
+			"jobstats_read_max_size":  uint64(4096),
+			"jobstats_read_bytes":     uint64(4096),
+			"jobstats_write_calls":    uint64(25),
+			"jobstats_write_min_size": uint64(1048576),
+			"jobstats_write_max_size": uint64(1048576),
+			"jobstats_write_bytes":    uint64(26214400),
+		},
+		map[string]string{"name": ost_name, "jobid": "cluster-testjob1"})
+
+	acc.AssertContainsTaggedFields(t, "lustre2",
+		map[string]interface{}{
+			"jobstats_read_calls":     uint64(2),
+			"jobstats_read_min_size":  uint64(1024),
+			"jobstats_read_max_size":  uint64(2048),
+			"jobstats_read_bytes":     uint64(3072),
+			"jobstats_write_calls":    uint64(0),
+			"jobstats_write_min_size": uint64(0),
+			"jobstats_write_max_size": uint64(0),
+			"jobstats_write_bytes":    uint64(0),
+		},
+		map[string]string{"name": ost_name, "jobid": "cluster-testjob2"})
+
+	acc.AssertContainsTaggedFields(t, "lustre2",
+		map[string]interface{}{
+			"read_bytes":  uint64(2097152),
+			"read_calls":  uint64(12),
+			"write_bytes": uint64(1048577),
+			"write_calls": uint64(3),
+		},
+		map[string]string{"name": ost_name, "client": client})
+}
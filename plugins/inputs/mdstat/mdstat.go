@@ -0,0 +1,269 @@
+package mdstat
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+// default host proc and sys paths
+const (
+	defaultHostProc = "/proc"
+	defaultHostSys  = "/sys"
+)
+
+// env host proc and sys variable names
+const (
+	envProc = "HOST_PROC"
+	envSys  = "HOST_SYS"
+)
+
+type MdStat struct {
+	HostProc string   `toml:"host_proc"`
+	HostSys  string   `toml:"host_sys"`
+	Devices  []string `toml:"devices"`
+}
+
+var sampleConfig = `
+  ## Sets 'proc' and 'sys' directory paths
+  ## If not specified, then default is /proc and /sys
+  # host_proc = "/proc"
+  # host_sys = "/sys"
+
+  ## By default, telegraf gathers stats for all md arrays. Setting devices
+  ## will restrict the stats to the specified arrays.
+  # devices = ["md0"]
+`
+
+func (m *MdStat) Description() string {
+	return "Read metrics about md software RAID arrays from /proc/mdstat"
+}
+
+func (m *MdStat) SampleConfig() string {
+	return sampleConfig
+}
+
+// array is the status of an array in /proc/mdstat
+type array struct {
+	device      string
+	active      bool
+	readOnly    bool
+	personality string
+	blocks      int64
+	disksTotal  int64
+	disksActive int64
+	disksFailed int64
+	disksSpare  int64
+	hasStatus   bool
+
+	// resync, recovery, check, reshape or repair in progress
+	syncAction  string
+	syncPercent float64
+	syncFinish  float64
+	syncSpeed   int64
+	syncPending bool
+}
+
+var (
+	headerRe   = regexp.MustCompile(`^(md\S+) : (\S+)(.*)$`)
+	statusRe   = regexp.MustCompile(`(\d+) blocks.*\[(\d+)/(\d+)\] \[[U_]+\]`)
+	blocksRe   = regexp.MustCompile(`^(\d+) blocks`)
+	progressRe = regexp.MustCompile(`(resync|recovery|check|reshape|repair)\s*=\s*([\d.]+)%.*finish=([\d.]+)min.*speed=(\d+)K/sec`)
+	pendingRe  = regexp.MustCompile(`(resync|recovery|check|reshape|repair)\s*=\s*(DELAYED|PENDING)`)
+	diskRe     = regexp.MustCompile(`^\S+\[\d+\](\([A-Z]\))*$`)
+)
+
+func (m *MdStat) Gather(acc telegraf.Accumulator) error {
+	if m.HostProc == "" {
+		m.HostProc = path(envProc, defaultHostProc)
+	}
+	if m.HostSys == "" {
+		m.HostSys = path(envSys, defaultHostSys)
+	}
+
+	f, err := os.Open(filepath.Join(m.HostProc, "mdstat"))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	arrays, err := parseMdstat(f)
+	if err != nil {
+		return err
+	}
+
+	for _, a := range arrays {
+		if len(m.Devices) > 0 && !contains(m.Devices, a.device) {
+			continue
+		}
+		m.gatherArray(acc, a)
+	}
+	return nil
+}
+
+func (m *MdStat) gatherArray(acc telegraf.Accumulator, a *array) {
+	tags := map[string]string{
+		"device": a.device,
+	}
+	if a.personality != "" {
+		tags["personality"] = a.personality
+	}
+
+	state := "inactive"
+	if a.active {
+		state = "active"
+		if a.readOnly {
+			state = "readonly"
+		}
+	}
+
+	fields := map[string]interface{}{
+		"array_state":  state,
+		"blocks_total": a.blocks,
+		"disks_failed": a.disksFailed,
+		"disks_spare":  a.disksSpare,
+	}
+	if a.hasStatus {
+		fields["disks_total"] = a.disksTotal
+		fields["disks_active"] = a.disksActive
+		fields["degraded"] = a.disksTotal - a.disksActive
+	}
+
+	syncAction := "idle"
+	if a.syncAction != "" {
+		syncAction = a.syncAction
+		if !a.syncPending {
+			fields["sync_completed_percent"] = a.syncPercent
+			fields["sync_finish_minutes"] = a.syncFinish
+			fields["sync_speed_kbps"] = a.syncSpeed
+		}
+	}
+	fields["sync_action"] = syncAction
+
+	// the sysfs attributes are more detailed, when available
+	dir := filepath.Join(m.HostSys, "block", a.device, "md")
+	if v, err := readString(filepath.Join(dir, "array_state")); err == nil {
+		fields["array_state"] = v
+	}
+	if v, err := readString(filepath.Join(dir, "sync_action")); err == nil && a.active {
+		fields["sync_action"] = v
+	}
+	if v, err := readInt(filepath.Join(dir, "degraded")); err == nil {
+		fields["degraded"] = v
+	}
+	if v, err := readInt(filepath.Join(dir, "mismatch_cnt")); err == nil {
+		fields["mismatch_cnt"] = v
+	}
+
+	acc.AddFields("mdstat", fields, tags)
+}
+
+// parseMdstat parses the arrays of /proc/mdstat
+func parseMdstat(r io.Reader) ([]*array, error) {
+	var arrays []*array
+	var current *array
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+
+		if match := headerRe.FindStringSubmatch(line); match != nil {
+			current = &array{
+				device:   match[1],
+				active:   match[2] == "active",
+				readOnly: strings.Contains(match[3], "read-only)"),
+			}
+			for _, word := range strings.Fields(match[3]) {
+				if diskRe.MatchString(word) {
+					switch {
+					case strings.Contains(word, "(F)"):
+						current.disksFailed++
+					case strings.Contains(word, "(S)"):
+						current.disksSpare++
+					}
+				} else if current.personality == "" && current.active && !strings.HasPrefix(word, "(") {
+					current.personality = word
+				}
+			}
+			arrays = append(arrays, current)
+			continue
+		}
+
+		if current == nil || trimmed == "" {
+			current = nil
+			continue
+		}
+
+		if match := statusRe.FindStringSubmatch(trimmed); match != nil {
+			current.blocks, _ = strconv.ParseInt(match[1], 10, 64)
+			current.disksTotal, _ = strconv.ParseInt(match[2], 10, 64)
+			current.disksActive, _ = strconv.ParseInt(match[3], 10, 64)
+			current.hasStatus = true
+		} else if match := blocksRe.FindStringSubmatch(trimmed); match != nil {
+			current.blocks, _ = strconv.ParseInt(match[1], 10, 64)
+		} else if match := progressRe.FindStringSubmatch(trimmed); match != nil {
+			current.syncAction = match[1]
+			current.syncPercent, _ = strconv.ParseFloat(match[2], 64)
+			current.syncFinish, _ = strconv.ParseFloat(match[3], 64)
+			current.syncSpeed, _ = strconv.ParseInt(match[4], 10, 64)
+		} else if match := pendingRe.FindStringSubmatch(trimmed); match != nil {
+			current.syncAction = match[1]
+			current.syncPending = true
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading mdstat: %s", err)
+	}
+
+	return arrays, nil
+}
+
+func readString(path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+func readInt(path string) (int64, error) {
+	s, err := readString(path)
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func path(env, path string) string {
+	// try to read full file path
+	if p := os.Getenv(env); p != "" {
+		return p
+	}
+	// return default path
+	return path
+}
+
+func init() {
+	inputs.Add("mdstat", func() telegraf.Input {
+		return &MdStat{}
+	})
+}
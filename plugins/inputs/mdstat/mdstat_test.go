@@ -0,0 +1,140 @@
+package mdstat
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const mdstat = `Personalities : [raid1] [raid6] [raid5] [raid4] [raid0]
+md1 : active raid1 sdb1[1] sda1[0]
+      1048512 blocks super 1.2 [2/2] [UU]
+      bitmap: 0/1 pages [0KB], 65536KB chunk
+
+md2 : active raid5 sdd1[3](F) sdc1[2] sdb2[1] sda2[0] sde1[4](S)
+      3144192 blocks super 1.2 level 5, 512k chunk, algorithm 2 [4/3] [UUU_]
+      [=>...................]  recovery =  8.5% (89344/1048064) finish=0.5min speed=29781K/sec
+
+md3 : inactive sdf1[0](S)
+      1048576 blocks super 1.2
+
+md4 : active (auto-read-only) raid0 sdg1[1] sdh1[0]
+      2096128 blocks super 1.2 512k chunks
+
+md5 : active raid1 sdi1[1] sdj1[0]
+      1048512 blocks super 1.2 [2/2] [UU]
+        resync=DELAYED
+
+unused devices: <none>
+`
+
+func TestGather(t *testing.T) {
+	dir, cleanup := testutil.TempDir(t, "mdstat")
+	defer cleanup()
+
+	testutil.WriteFiles(t, dir, map[string]string{
+		"proc/mdstat":                   mdstat,
+		"sys/block/md1/md/array_state":  "clean\n",
+		"sys/block/md1/md/sync_action":  "check\n",
+		"sys/block/md1/md/degraded":     "0\n",
+		"sys/block/md1/md/mismatch_cnt": "128\n",
+		"sys/block/md3/md/array_state":  "inactive\n",
+		"sys/block/md3/md/sync_action":  "idle\n",
+	})
+
+	m := &MdStat{
+		HostProc: filepath.Join(dir, "proc"),
+		HostSys:  filepath.Join(dir, "sys"),
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(m.Gather))
+	require.Len(t, acc.Metrics, 5)
+
+	acc.AssertContainsTaggedFields(t, "mdstat",
+		map[string]interface{}{
+			"array_state":  "clean",
+			"blocks_total": int64(1048512),
+			"disks_total":  int64(2),
+			"disks_active": int64(2),
+			"disks_failed": int64(0),
+			"disks_spare":  int64(0),
+			"degraded":     int64(0),
+			"sync_action":  "check",
+			"mismatch_cnt": int64(128),
+		},
+		map[string]string{"device": "md1", "personality": "raid1"})
+
+	acc.AssertContainsTaggedFields(t, "mdstat",
+		map[string]interface{}{
+			"array_state":            "active",
+			"blocks_total":           int64(3144192),
+			"disks_total":            int64(4),
+			"disks_active":           int64(3),
+			"disks_failed":           int64(1),
+			"disks_spare":            int64(1),
+			"degraded":               int64(1),
+			"sync_action":            "recovery",
+			"sync_completed_percent": float64(8.5),
+			"sync_finish_minutes":    float64(0.5),
+			"sync_speed_kbps":        int64(29781),
+		},
+		map[string]string{"device": "md2", "personality": "raid5"})
+
+	acc.AssertContainsTaggedFields(t, "mdstat",
+		map[string]interface{}{
+			"array_state":  "inactive",
+			"blocks_total": int64(1048576),
+			"disks_failed": int64(0),
+			"disks_spare":  int64(1),
+			"sync_action":  "idle",
+		},
+		map[string]string{"device": "md3"})
+
+	acc.AssertContainsTaggedFields(t, "mdstat",
+		map[string]interface{}{
+			"array_state":  "readonly",
+			"blocks_total": int64(2096128),
+			"disks_failed": int64(0),
+			"disks_spare":  int64(0),
+			"sync_action":  "idle",
+		},
+		map[string]string{"device": "md4", "personality": "raid0"})
+
+	acc.AssertContainsTaggedFields(t, "mdstat",
+		map[string]interface{}{
+			"array_state":  "active",
+			"blocks_total": int64(1048512),
+			"disks_total":  int64(2),
+			"disks_active": int64(2),
+			"disks_failed": int64(0),
+			"disks_spare":  int64(0),
+			"degraded":     int64(0),
+			"sync_action":  "resync",
+		},
+		map[string]string{"device": "md5", "personality": "raid1"})
+}
+
+func TestGatherDevices(t *testing.T) {
+	dir, cleanup := testutil.TempDir(t, "mdstat")
+	defer cleanup()
+
+	testutil.WriteFiles(t, dir, map[string]string{"mdstat": mdstat})
+
+	m := &MdStat{HostProc: dir, HostSys: dir, Devices: []string{"md2"}}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(m.Gather))
+	require.Len(t, acc.Metrics, 1)
+	assert.Equal(t, "md2", acc.Metrics[0].Tags["device"])
+}
+
+func TestGatherMissing(t *testing.T) {
+	m := &MdStat{HostProc: "/nonexistent", HostSys: "/nonexistent"}
+
+	var acc testutil.Accumulator
+	require.Error(t, acc.GatherError(m.Gather))
+}
@@ -3,19 +3,29 @@ package memcached
 import (
 	"bufio"
 	"bytes"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/influxdata/telegraf"
+	tlsint "github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
 // Memcached is a memcached plugin
 type Memcached struct {
-	Servers     []string
-	UnixSockets []string
+	Servers        []string
+	UnixSockets    []string
+	GatherSlabs    bool `toml:"gather_slabs"`
+	GatherSettings bool `toml:"gather_settings"`
+
+	EnableTLS bool `toml:"enable_tls"`
+	tlsint.ClientConfig
+
+	tlsConfig *tls.Config
 }
 
 var sampleConfig = `
@@ -23,6 +33,22 @@ var sampleConfig = `
   ## with optional port. ie localhost, 10.0.0.1:11211, etc.
   servers = ["localhost:11211"]
   # unix_sockets = ["/var/run/memcached.sock"]
+
+  ## Gather the statistics of the slab classes, from the 'stats slabs' and
+  ## 'stats items' commands, in the memcached_slab measurement.
+  # gather_slabs = false
+
+  ## Gather the settings of the servers, from the 'stats settings' command,
+  ## in the memcached_settings measurement.
+  # gather_settings = false
+
+  ## Optional TLS Config, only used by the servers
+  # enable_tls = true
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
 `
 
 var defaultTimeout = 5 * time.Second
@@ -56,6 +82,42 @@ var sendMetrics = []string{
 	"conn_yields",
 }
 
+// The list of slab statistics that should be sent, from 'stats slabs'
+var sendSlabMetrics = []string{
+	"chunk_size",
+	"chunks_per_page",
+	"total_pages",
+	"total_chunks",
+	"used_chunks",
+	"free_chunks",
+	"free_chunks_end",
+	"mem_requested",
+	"get_hits",
+	"cmd_set",
+	"delete_hits",
+	"incr_hits",
+	"decr_hits",
+	"cas_hits",
+	"cas_badval",
+	"touch_hits",
+}
+
+// The list of item statistics that should be sent, from 'stats items'
+var sendItemMetrics = []string{
+	"number",
+	"age",
+	"evicted",
+	"evicted_nonzero",
+	"evicted_time",
+	"outofmemory",
+	"tailrepairs",
+	"reclaimed",
+	"expired_unfetched",
+	"evicted_unfetched",
+	"crawler_reclaimed",
+	"lrutail_reflocked",
+}
+
 // SampleConfig returns sample configuration message
 func (m *Memcached) SampleConfig() string {
 	return sampleConfig
@@ -102,7 +164,7 @@ func (m *Memcached) gatherServer(
 			address = address + ":11211"
 		}
 
-		conn, err = net.DialTimeout("tcp", address, defaultTimeout)
+		conn, err = m.dial(address)
 		if err != nil {
 			return err
 		}
@@ -119,15 +181,7 @@ func (m *Memcached) gatherServer(
 	// Read and write buffer
 	rw := bufio.NewReadWriter(bufio.NewReader(conn), bufio.NewWriter(conn))
 
-	// Send command
-	if _, err := fmt.Fprint(rw, "stats\r\n"); err != nil {
-		return err
-	}
-	if err := rw.Flush(); err != nil {
-		return err
-	}
-
-	values, err := parseResponse(rw.Reader)
+	values, err := command(rw, "stats")
 	if err != nil {
 		return err
 	}
@@ -139,18 +193,143 @@ func (m *Memcached) gatherServer(
 	fields := make(map[string]interface{})
 	for _, key := range sendMetrics {
 		if value, ok := values[key]; ok {
-			// Mostly it is the number
-			if iValue, errParse := strconv.ParseInt(value, 10, 64); errParse == nil {
-				fields[key] = iValue
-			} else {
-				fields[key] = value
-			}
+			fields[key] = parseValue(value)
+		}
+	}
+
+	if m.GatherSlabs {
+		if err := gatherSlabs(rw, address, fields, acc); err != nil {
+			return err
 		}
 	}
 	acc.AddFields("memcached", fields, tags)
+
+	if m.GatherSettings {
+		settings, err := command(rw, "stats settings")
+		if err != nil {
+			return err
+		}
+		fields := make(map[string]interface{})
+		for key, value := range settings {
+			fields[key] = parseValue(value)
+		}
+		acc.AddFields("memcached_settings", fields, tags)
+	}
 	return nil
 }
 
+func (m *Memcached) dial(address string) (net.Conn, error) {
+	if !m.EnableTLS {
+		return net.DialTimeout("tcp", address, defaultTimeout)
+	}
+
+	if m.tlsConfig == nil {
+		tlsConfig, err := m.ClientConfig.TLSConfig()
+		if err != nil {
+			return nil, err
+		}
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{}
+		}
+		m.tlsConfig = tlsConfig
+	}
+
+	dialer := net.Dialer{Timeout: defaultTimeout}
+	return tls.DialWithDialer(&dialer, "tcp", address, m.tlsConfig)
+}
+
+// gatherSlabs adds the statistics of each slab class, from the 'stats slabs'
+// and 'stats items' commands, in the memcached_slab measurement.  The totals
+// of 'stats slabs' are added to the fields of the server.
+func gatherSlabs(
+	rw *bufio.ReadWriter,
+	address string,
+	serverFields map[string]interface{},
+	acc telegraf.Accumulator,
+) error {
+	slabs, err := command(rw, "stats slabs")
+	if err != nil {
+		return err
+	}
+	items, err := command(rw, "stats items")
+	if err != nil {
+		return err
+	}
+
+	classes := make(map[string]map[string]interface{})
+	addField := func(class, key string, value string) {
+		if _, ok := classes[class]; !ok {
+			classes[class] = make(map[string]interface{})
+		}
+		classes[class][key] = parseValue(value)
+	}
+
+	// The stats of the classes are keyed '<class>:<stat>', followed by the
+	// totals of the server
+	for key, value := range slabs {
+		parts := strings.SplitN(key, ":", 2)
+		if len(parts) != 2 {
+			if key == "active_slabs" || key == "total_malloced" {
+				serverFields[key] = parseValue(value)
+			}
+			continue
+		}
+		if contains(sendSlabMetrics, parts[1]) {
+			addField(parts[0], parts[1], value)
+		}
+	}
+
+	// The stats of the items are keyed 'items:<class>:<stat>'
+	for key, value := range items {
+		parts := strings.SplitN(key, ":", 3)
+		if len(parts) != 3 || parts[0] != "items" {
+			continue
+		}
+		if contains(sendItemMetrics, parts[2]) {
+			addField(parts[1], "items_"+parts[2], value)
+		}
+	}
+
+	for class, fields := range classes {
+		tags := map[string]string{
+			"server": address,
+			"slab":   class,
+		}
+		acc.AddFields("memcached_slab", fields, tags)
+	}
+	return nil
+}
+
+// command sends a stats command and returns the values of the response
+func command(rw *bufio.ReadWriter, cmd string) (map[string]string, error) {
+	if _, err := fmt.Fprint(rw, cmd+"\r\n"); err != nil {
+		return nil, err
+	}
+	if err := rw.Flush(); err != nil {
+		return nil, err
+	}
+
+	return parseResponse(rw.Reader)
+}
+
+// parseValue returns an integer value when possible, as mostly it is the
+// number.
+func parseValue(value string) interface{} {
+	if iValue, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return iValue
+	}
+	return value
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
 func parseResponse(r *bufio.Reader) (map[string]string, error) {
 	values := make(map[string]string)
 
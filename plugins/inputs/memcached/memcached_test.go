@@ -2,6 +2,7 @@ package memcached
 
 import (
 	"bufio"
+	"net"
 	"strings"
 	"testing"
 
@@ -36,6 +37,109 @@ func TestMemcachedGeneratesMetrics(t *testing.T) {
 	}
 }
 
+// serve answers the stats commands of the connections with the responses
+func serve(l net.Listener, responses map[string]string) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go func(conn net.Conn) {
+			defer conn.Close()
+			r := bufio.NewReader(conn)
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				response, ok := responses[strings.TrimSpace(line)]
+				if !ok {
+					response = "ERROR\r\n"
+				}
+				conn.Write([]byte(response))
+			}
+		}(conn)
+	}
+}
+
+func TestMemcachedGatherSlabsAndSettings(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	go serve(l, map[string]string{
+		"stats":          memcachedStats,
+		"stats slabs":    memcachedSlabs,
+		"stats items":    memcachedItems,
+		"stats settings": memcachedSettings,
+	})
+
+	m := &Memcached{
+		Servers:        []string{l.Addr().String()},
+		GatherSlabs:    true,
+		GatherSettings: true,
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(m.Gather))
+
+	server := l.Addr().String()
+	assert.True(t, acc.HasInt64Field("memcached", "get_hits"))
+	assert.True(t, acc.HasInt64Field("memcached", "active_slabs"))
+	assert.True(t, acc.HasInt64Field("memcached", "total_malloced"))
+
+	acc.AssertContainsTaggedFields(t, "memcached_slab",
+		map[string]interface{}{
+			"chunk_size":              int64(96),
+			"chunks_per_page":         int64(10922),
+			"total_pages":             int64(1),
+			"total_chunks":            int64(10922),
+			"used_chunks":             int64(3),
+			"free_chunks":             int64(10919),
+			"free_chunks_end":         int64(0),
+			"mem_requested":           int64(214),
+			"get_hits":                int64(12),
+			"cmd_set":                 int64(5),
+			"delete_hits":             int64(0),
+			"incr_hits":               int64(0),
+			"decr_hits":               int64(0),
+			"cas_hits":                int64(0),
+			"cas_badval":              int64(0),
+			"touch_hits":              int64(0),
+			"items_number":            int64(3),
+			"items_age":               int64(1851),
+			"items_evicted":           int64(2),
+			"items_evicted_nonzero":   int64(1),
+			"items_evicted_time":      int64(604),
+			"items_outofmemory":       int64(0),
+			"items_tailrepairs":       int64(0),
+			"items_reclaimed":         int64(1),
+			"items_expired_unfetched": int64(0),
+			"items_evicted_unfetched": int64(1),
+		},
+		map[string]string{"server": server, "slab": "1"})
+
+	acc.AssertContainsTaggedFields(t, "memcached_slab",
+		map[string]interface{}{
+			"chunk_size":   int64(120),
+			"used_chunks":  int64(1),
+			"items_number": int64(1),
+			"items_age":    int64(60),
+		},
+		map[string]string{"server": server, "slab": "2"})
+
+	acc.AssertContainsTaggedFields(t, "memcached_settings",
+		map[string]interface{}{
+			"maxbytes":      int64(67108864),
+			"maxconns":      int64(1024),
+			"tcpport":       int64(11211),
+			"evictions":     "on",
+			"growth_factor": "1.25",
+			"cas_enabled":   "yes",
+		},
+		map[string]string{"server": server})
+}
+
 func TestMemcachedParseMetrics(t *testing.T) {
 	r := bufio.NewReader(strings.NewReader(memcachedStats))
 	values, err := parseResponse(r)
@@ -158,3 +262,50 @@ STAT evictions 0
 STAT reclaimed 0
 END
 `
+
+var memcachedSlabs = `STAT 1:chunk_size 96
+STAT 1:chunks_per_page 10922
+STAT 1:total_pages 1
+STAT 1:total_chunks 10922
+STAT 1:used_chunks 3
+STAT 1:free_chunks 10919
+STAT 1:free_chunks_end 0
+STAT 1:mem_requested 214
+STAT 1:get_hits 12
+STAT 1:cmd_set 5
+STAT 1:delete_hits 0
+STAT 1:incr_hits 0
+STAT 1:decr_hits 0
+STAT 1:cas_hits 0
+STAT 1:cas_badval 0
+STAT 1:touch_hits 0
+STAT 2:chunk_size 120
+STAT 2:used_chunks 1
+STAT active_slabs 2
+STAT total_malloced 2097152
+END
+`
+
+var memcachedItems = `STAT items:1:number 3
+STAT items:1:age 1851
+STAT items:1:evicted 2
+STAT items:1:evicted_nonzero 1
+STAT items:1:evicted_time 604
+STAT items:1:outofmemory 0
+STAT items:1:tailrepairs 0
+STAT items:1:reclaimed 1
+STAT items:1:expired_unfetched 0
+STAT items:1:evicted_unfetched 1
+STAT items:2:number 1
+STAT items:2:age 60
+END
+`
+
+var memcachedSettings = `STAT maxbytes 67108864
+STAT maxconns 1024
+STAT tcpport 11211
+STAT evictions on
+STAT growth_factor 1.25
+STAT cas_enabled yes
+END
+`
@@ -0,0 +1,298 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// field is a value decoded from the data of a request
+type field struct {
+	name    string
+	address uint16
+	// number of registers or bits of the value
+	length uint16
+	// offset of the value in the registers or bits of the request
+	offset uint16
+	decode func(data []byte) interface{}
+}
+
+// request reads a range of registers or bits holding one or more fields
+type request struct {
+	address uint16
+	length  uint16
+	fields  []field
+}
+
+type requestSet struct {
+	registerType string
+	requests     []request
+}
+
+type slaveRequests struct {
+	id       byte
+	requests []requestSet
+}
+
+// setup checks the configuration, groups the fields of each slave into
+// requests and creates the client
+func (m *Modbus) setup() error {
+	if m.Name == "" {
+		return fmt.Errorf("device name is empty")
+	}
+	if m.MaxGap < 0 {
+		return fmt.Errorf("max_gap must not be negative")
+	}
+
+	slaves := m.Slaves
+	if m.hasFields() {
+		slaves = append([]Slave{m.Slave}, slaves...)
+	}
+	if len(slaves) == 0 {
+		return fmt.Errorf("no registers or bits configured")
+	}
+
+	ids := make(map[byte]bool)
+	m.slaves = make([]*slaveRequests, 0, len(slaves))
+	for _, slave := range slaves {
+		if ids[slave.SlaveID] {
+			return fmt.Errorf("slave %d is configured more than once", slave.SlaveID)
+		}
+		ids[slave.SlaveID] = true
+
+		requests, err := slave.requests(uint16(m.MaxGap))
+		if err != nil {
+			return fmt.Errorf("slave %d: %s", slave.SlaveID, err)
+		}
+		m.slaves = append(m.slaves, &slaveRequests{id: slave.SlaveID, requests: requests})
+	}
+
+	return m.initHandler()
+}
+
+func (s *Slave) hasFields() bool {
+	return len(s.Coils) > 0 || len(s.DiscreteInputs) > 0 ||
+		len(s.HoldingRegisters) > 0 || len(s.InputRegisters) > 0
+}
+
+func (s *Slave) requests(maxGap uint16) ([]requestSet, error) {
+	var sets []requestSet
+
+	for _, bits := range []struct {
+		registerType string
+		definitions  []fieldBit
+	}{
+		{cCoils, s.Coils},
+		{cDiscreteInputs, s.DiscreteInputs},
+	} {
+		if len(bits.definitions) == 0 {
+			continue
+		}
+
+		fields := make([]field, 0, len(bits.definitions))
+		for _, def := range bits.definitions {
+			fields = append(fields, field{
+				name:    def.Name,
+				address: def.Address,
+				length:  1,
+				decode:  decodeBit,
+			})
+		}
+		requests, err := groupFields(fields, maxGap, maxBits)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", bits.registerType, err)
+		}
+		sets = append(sets, requestSet{registerType: bits.registerType, requests: requests})
+	}
+
+	for _, registers := range []struct {
+		registerType string
+		definitions  []fieldWords
+	}{
+		{cHoldingRegisters, s.HoldingRegisters},
+		{cInputRegisters, s.InputRegisters},
+	} {
+		if len(registers.definitions) == 0 {
+			continue
+		}
+
+		fields := make([]field, 0, len(registers.definitions))
+		for _, def := range registers.definitions {
+			f, err := def.field()
+			if err != nil {
+				return nil, fmt.Errorf("%s %q: %s", registers.registerType, def.Name, err)
+			}
+			fields = append(fields, f)
+		}
+		requests, err := groupFields(fields, maxGap, maxRegisters)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", registers.registerType, err)
+		}
+		sets = append(sets, requestSet{registerType: registers.registerType, requests: requests})
+	}
+
+	return sets, nil
+}
+
+// groupFields groups the fields in the fewest requests, a request covering
+// the fields separated by at most maxGap registers or bits, up to the max
+// quantity of a request.
+func groupFields(fields []field, maxGap uint16, max uint16) ([]request, error) {
+	names := make(map[string]bool)
+	for _, f := range fields {
+		if f.name == "" {
+			return nil, fmt.Errorf("empty name at address %d", f.address)
+		}
+		if names[f.name] {
+			return nil, fmt.Errorf("name %q is duplicated", f.name)
+		}
+		names[f.name] = true
+		if int(f.address)+int(f.length) > math.MaxUint16+1 {
+			return nil, fmt.Errorf("address %d of %q is out of range", f.address, f.name)
+		}
+	}
+
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].address < fields[j].address
+	})
+
+	var requests []request
+	for _, f := range fields {
+		if len(requests) > 0 {
+			r := &requests[len(requests)-1]
+			end := int(r.address) + int(r.length)
+			fieldEnd := int(f.address) + int(f.length)
+			if int(f.address) <= end+int(maxGap) && fieldEnd-int(r.address) <= int(max) {
+				if fieldEnd > end {
+					r.length = uint16(fieldEnd - int(r.address))
+				}
+				f.offset = f.address - r.address
+				r.fields = append(r.fields, f)
+				continue
+			}
+		}
+		requests = append(requests, request{
+			address: f.address,
+			length:  f.length,
+			fields:  []field{f},
+		})
+	}
+
+	return requests, nil
+}
+
+// value extracts the value of the field from the data of its request
+func (f *field) value(registerType string, data []byte) interface{} {
+	switch registerType {
+	case cCoils, cDiscreteInputs:
+		// bits are packed in bytes, least significant bit first
+		return f.decode([]byte{data[f.offset/8] >> (f.offset % 8)})
+	default:
+		return f.decode(data[2*f.offset : 2*(f.offset+f.length)])
+	}
+}
+
+func decodeBit(data []byte) interface{} {
+	return int64(data[0] & 1)
+}
+
+func (def *fieldWords) field() (field, error) {
+	var length uint16
+	switch def.DataType {
+	case "INT16", "UINT16":
+		length = 1
+	case "INT32", "UINT32", "FLOAT32":
+		length = 2
+	case "INT64", "UINT64", "FLOAT64":
+		length = 4
+	case "":
+		return field{}, fmt.Errorf("data_type is required")
+	default:
+		return field{}, fmt.Errorf("invalid data_type %q", def.DataType)
+	}
+
+	order := def.ByteOrder
+	if order == "" {
+		order = "ABCDEFGH"[:2*length]
+	}
+	if err := checkByteOrder(order, int(2*length)); err != nil {
+		return field{}, err
+	}
+
+	dataType := def.DataType
+	scale := def.Scale
+	return field{
+		name:    def.Name,
+		address: def.Address,
+		length:  length,
+		decode: func(data []byte) interface{} {
+			return scaleValue(convert(dataType, reorder(order, data)), scale)
+		},
+	}, nil
+}
+
+// checkByteOrder checks that the byte order is a permutation of the n first
+// letters of the alphabet
+func checkByteOrder(order string, n int) error {
+	if len(order) != n {
+		return fmt.Errorf("byte_order %q must have %d letters", order, n)
+	}
+	seen := make(map[byte]bool)
+	for i := 0; i < n; i++ {
+		c := order[i]
+		if c < 'A' || int(c-'A') >= n || seen[c] {
+			return fmt.Errorf("invalid byte_order %q", order)
+		}
+		seen[c] = true
+	}
+	return nil
+}
+
+// reorder returns the bytes in big endian order, the byte order giving the
+// position of each byte of the data, A being the most significant byte
+func reorder(order string, data []byte) []byte {
+	be := make([]byte, len(data))
+	for i := range data {
+		be[order[i]-'A'] = data[i]
+	}
+	return be
+}
+
+func convert(dataType string, be []byte) interface{} {
+	switch dataType {
+	case "INT16":
+		return int64(int16(binary.BigEndian.Uint16(be)))
+	case "UINT16":
+		return int64(binary.BigEndian.Uint16(be))
+	case "INT32":
+		return int64(int32(binary.BigEndian.Uint32(be)))
+	case "UINT32":
+		return int64(binary.BigEndian.Uint32(be))
+	case "INT64":
+		return int64(binary.BigEndian.Uint64(be))
+	case "UINT64":
+		return binary.BigEndian.Uint64(be)
+	case "FLOAT32":
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(be)))
+	case "FLOAT64":
+		return math.Float64frombits(binary.BigEndian.Uint64(be))
+	}
+	return nil
+}
+
+func scaleValue(value interface{}, scale float64) interface{} {
+	if scale == 0 || scale == 1 {
+		return value
+	}
+
+	switch v := value.(type) {
+	case int64:
+		return float64(v) * scale
+	case uint64:
+		return float64(v) * scale
+	case float64:
+		return v * scale
+	}
+	return value
+}
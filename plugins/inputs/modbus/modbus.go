@@ -0,0 +1,312 @@
+package modbus
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	mb "github.com/goburrow/modbus"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+// Modbus holds all data relevant to the plugin
+type Modbus struct {
+	Name             string            `toml:"name"`
+	Controller       string            `toml:"controller"`
+	TransmissionMode string            `toml:"transmission_mode"`
+	BaudRate         int               `toml:"baud_rate"`
+	DataBits         int               `toml:"data_bits"`
+	Parity           string            `toml:"parity"`
+	StopBits         int               `toml:"stop_bits"`
+	Timeout          internal.Duration `toml:"timeout"`
+	BusyRetries      int               `toml:"busy_retries"`
+	BusyRetriesWait  internal.Duration `toml:"busy_retries_wait"`
+	MaxGap           int               `toml:"max_gap"`
+
+	// Registers of the slave configured at the top level
+	Slave
+	Slaves []Slave `toml:"slave"`
+
+	slaves     []*slaveRequests
+	client     mb.Client
+	handler    modbusHandler
+	setSlaveID func(id byte)
+}
+
+// Slave holds the registers to read from a slave device
+type Slave struct {
+	SlaveID          byte         `toml:"slave_id"`
+	Coils            []fieldBit   `toml:"coils"`
+	DiscreteInputs   []fieldBit   `toml:"discrete_inputs"`
+	HoldingRegisters []fieldWords `toml:"holding_registers"`
+	InputRegisters   []fieldWords `toml:"input_registers"`
+}
+
+type fieldBit struct {
+	Name    string `toml:"name"`
+	Address uint16 `toml:"address"`
+}
+
+type fieldWords struct {
+	Name      string  `toml:"name"`
+	Address   uint16  `toml:"address"`
+	DataType  string  `toml:"data_type"`
+	ByteOrder string  `toml:"byte_order"`
+	Scale     float64 `toml:"scale"`
+}
+
+// modbusHandler is the part of the client handlers used to select the slave
+// and close the connection
+type modbusHandler interface {
+	mb.ClientHandler
+	Connect() error
+	Close() error
+}
+
+const (
+	cCoils            = "coil"
+	cDiscreteInputs   = "discrete_input"
+	cHoldingRegisters = "holding_register"
+	cInputRegisters   = "input_register"
+)
+
+// maximum quantities of a single read request
+const (
+	maxBits      = 2000
+	maxRegisters = 125
+)
+
+const description = `Retrieve data from MODBUS slave devices`
+const sampleConfig = `
+  ## Connection Configuration
+  ##
+  ## The plugin supports connections to PLCs via MODBUS/TCP or
+  ## via serial line communication in binary (RTU) or readable (ASCII) encoding
+  ##
+  ## Device name, added as the name tag
+  name = "Device"
+
+  ## Timeout for each request
+  timeout = "1s"
+
+  ## Maximum number of retries and the time to wait between retries
+  ## when a slave-device is busy.
+  # busy_retries = 0
+  # busy_retries_wait = "100ms"
+
+  # TCP - connect via Modbus/TCP
+  controller = "tcp://localhost:502"
+
+  ## Serial (RS485; RS232)
+  # controller = "file:///dev/ttyUSB0"
+  # baud_rate = 9600
+  # data_bits = 8
+  # parity = "N"
+  # stop_bits = 1
+  # transmission_mode = "RTU"
+
+  ## Registers read in the same request when the gap between them is at most
+  ## max_gap registers (or bits), to reduce the number of requests.
+  # max_gap = 10
+
+  ## Measurements
+  ##
+
+  ## Digital Variables, Discrete Inputs and Coils
+  ## name    - the variable name
+  ## address - address of the bit
+  slave_id = 1
+  discrete_inputs = [
+    { name = "start",          address = 0},
+    { name = "stop",           address = 1},
+    { name = "reset",          address = 2},
+    { name = "emergency_stop", address = 3},
+  ]
+  coils = [
+    { name = "motor1_run",     address = 0},
+    { name = "motor1_jog",     address = 1},
+    { name = "motor1_stop",    address = 2},
+  ]
+
+  ## Analog Variables, Input Registers and Holding Registers
+  ## name       - the variable name
+  ## address    - address of the first register of the value
+  ## data_type  - INT16, UINT16, INT32, UINT32, INT64, UINT64, FLOAT32 or
+  ##              FLOAT64 (IEEE 754), reading 1, 2 or 4 registers
+  ## byte_order - the order of the bytes of the value in the registers, A
+  ##              being the most significant byte:
+  ##              16 bits: AB, BA
+  ##              32 bits: ABCD (big endian), DCBA (little endian),
+  ##                       BADC (byte swap), CDAB (word swap)
+  ##              64 bits: ABCDEFGH, HGFEDCBA, BADCFEHG, GHEFCDAB
+  ## scale      - factor applied to the value, the value is a float when set
+  holding_registers = [
+    { name = "power_factor", address = 8, data_type = "INT16", scale = 0.01},
+    { name = "voltage",      address = 0, data_type = "INT16", scale = 0.1},
+    { name = "energy",       address = 5, data_type = "FLOAT32", byte_order = "CDAB"},
+    { name = "current",      address = 1, data_type = "INT32", byte_order = "ABCD", scale = 0.001},
+  ]
+  input_registers = [
+    { name = "tank_level",   address = 0, data_type = "INT16"},
+    { name = "tank_ph",      address = 1, data_type = "INT16", scale = 0.1},
+    { name = "pump1_speed",  address = 2, data_type = "INT32", byte_order = "ABCD"},
+  ]
+
+  ## Other slaves sharing the same controller, such as the devices of a
+  ## RS485 bus or behind a gateway, with the same register definitions.
+  # [[inputs.modbus.slave]]
+  #   slave_id = 2
+  #   holding_registers = [
+  #     { name = "temperature", address = 0, data_type = "INT16", scale = 0.1},
+  #   ]
+`
+
+// SampleConfig returns a basic configuration for the plugin
+func (m *Modbus) SampleConfig() string {
+	return sampleConfig
+}
+
+// Description returns a short description of what the plugin does
+func (m *Modbus) Description() string {
+	return description
+}
+
+// Gather implements the telegraf plugin interface method for data accumulation
+func (m *Modbus) Gather(acc telegraf.Accumulator) error {
+	if m.client == nil {
+		if err := m.setup(); err != nil {
+			return err
+		}
+	}
+
+	// the connection is kept between intervals, and closed by the handler
+	// when idle
+	if err := m.handler.Connect(); err != nil {
+		return fmt.Errorf("error connecting to %s: %s", m.Controller, err)
+	}
+
+	now := time.Now()
+	for _, slave := range m.slaves {
+		if err := m.gatherSlave(acc, slave, now); err != nil {
+			// the connection is established again at the next interval
+			m.handler.Close()
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (m *Modbus) gatherSlave(acc telegraf.Accumulator, slave *slaveRequests, now time.Time) error {
+	m.setSlaveID(slave.id)
+
+	for _, reqs := range slave.requests {
+		fields := make(map[string]interface{})
+		for _, req := range reqs.requests {
+			data, err := m.read(reqs.registerType, req.address, req.length)
+			if err != nil {
+				return fmt.Errorf("error reading %s %d-%d of slave %d: %s",
+					reqs.registerType, req.address, req.address+req.length-1, slave.id, err)
+			}
+			for _, f := range req.fields {
+				fields[f.name] = f.value(reqs.registerType, data)
+			}
+		}
+
+		tags := map[string]string{
+			"name":     m.Name,
+			"type":     reqs.registerType,
+			"slave_id": fmt.Sprint(slave.id),
+		}
+		acc.AddFields("modbus", fields, tags, now)
+	}
+
+	return nil
+}
+
+// read reads the registers or bits, retrying while the slave is busy
+func (m *Modbus) read(registerType string, address, length uint16) ([]byte, error) {
+	for retry := 0; ; retry++ {
+		var data []byte
+		var err error
+		switch registerType {
+		case cCoils:
+			data, err = m.client.ReadCoils(address, length)
+		case cDiscreteInputs:
+			data, err = m.client.ReadDiscreteInputs(address, length)
+		case cHoldingRegisters:
+			data, err = m.client.ReadHoldingRegisters(address, length)
+		case cInputRegisters:
+			data, err = m.client.ReadInputRegisters(address, length)
+		}
+
+		mbErr, ok := err.(*mb.ModbusError)
+		if !ok || mbErr.ExceptionCode != mb.ExceptionCodeServerDeviceBusy || retry >= m.BusyRetries {
+			return data, err
+		}
+
+		log.Printf("D! [inputs.modbus] device busy, retrying in %s", m.BusyRetriesWait.Duration)
+		time.Sleep(m.BusyRetriesWait.Duration)
+	}
+}
+
+// initHandler creates the client handler of the controller
+func (m *Modbus) initHandler() error {
+	u, err := url.Parse(m.Controller)
+	if err != nil {
+		return err
+	}
+
+	switch u.Scheme {
+	case "tcp":
+		handler := mb.NewTCPClientHandler(u.Host)
+		handler.Timeout = m.Timeout.Duration
+		m.handler = handler
+		m.setSlaveID = func(id byte) { handler.SlaveId = id }
+	case "file":
+		switch m.TransmissionMode {
+		case "", "RTU":
+			handler := mb.NewRTUClientHandler(u.Path)
+			handler.Timeout = m.Timeout.Duration
+			handler.BaudRate = m.BaudRate
+			handler.DataBits = m.DataBits
+			handler.Parity = m.Parity
+			handler.StopBits = m.StopBits
+			m.handler = handler
+			m.setSlaveID = func(id byte) { handler.SlaveId = id }
+		case "ASCII":
+			handler := mb.NewASCIIClientHandler(u.Path)
+			handler.Timeout = m.Timeout.Duration
+			handler.BaudRate = m.BaudRate
+			handler.DataBits = m.DataBits
+			handler.Parity = m.Parity
+			handler.StopBits = m.StopBits
+			m.handler = handler
+			m.setSlaveID = func(id byte) { handler.SlaveId = id }
+		default:
+			return fmt.Errorf("invalid transmission_mode %q, must be RTU or ASCII", m.TransmissionMode)
+		}
+	default:
+		return fmt.Errorf("invalid controller %q, must be tcp://host:port or file:///dev/device", m.Controller)
+	}
+
+	m.client = mb.NewClient(m.handler)
+	return nil
+}
+
+func init() {
+	inputs.Add("modbus", func() telegraf.Input {
+		return &Modbus{
+			BaudRate:        9600,
+			DataBits:        8,
+			Parity:          "N",
+			StopBits:        1,
+			Timeout:         internal.Duration{Duration: time.Second},
+			BusyRetriesWait: internal.Duration{Duration: 100 * time.Millisecond},
+			MaxGap:          10,
+		}
+	})
+}
@@ -0,0 +1,365 @@
+package modbus
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeSlave struct {
+	coils            map[uint16]bool
+	discreteInputs   map[uint16]bool
+	holdingRegisters map[uint16]uint16
+	inputRegisters   map[uint16]uint16
+	// number of requests answered with a busy exception
+	busy int
+}
+
+type fakeServer struct {
+	sync.Mutex
+	slaves   map[byte]*fakeSlave
+	requests int
+}
+
+// serve answers the read requests of Modbus/TCP clients
+func (s *fakeServer) serve(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		header := make([]byte, 7)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			return
+		}
+		pdu := make([]byte, binary.BigEndian.Uint16(header[4:])-1)
+		if _, err := io.ReadFull(conn, pdu); err != nil {
+			return
+		}
+
+		response := s.respond(header[6], pdu)
+		binary.BigEndian.PutUint16(header[4:], uint16(len(response)+1))
+		if _, err := conn.Write(append(header, response...)); err != nil {
+			return
+		}
+	}
+}
+
+func (s *fakeServer) respond(id byte, pdu []byte) []byte {
+	s.Lock()
+	defer s.Unlock()
+	s.requests++
+
+	function := pdu[0]
+	address := binary.BigEndian.Uint16(pdu[1:])
+	quantity := binary.BigEndian.Uint16(pdu[3:])
+
+	slave, ok := s.slaves[id]
+	if !ok {
+		// gateway target device failed to respond
+		return []byte{function | 0x80, 11}
+	}
+	if slave.busy > 0 {
+		slave.busy--
+		return []byte{function | 0x80, 6}
+	}
+
+	switch function {
+	case 1, 2:
+		bits := slave.coils
+		if function == 2 {
+			bits = slave.discreteInputs
+		}
+		data := make([]byte, (quantity+7)/8)
+		for i := uint16(0); i < quantity; i++ {
+			if bits[address+i] {
+				data[i/8] |= 1 << (i % 8)
+			}
+		}
+		return append([]byte{function, byte(len(data))}, data...)
+	case 3, 4:
+		registers := slave.holdingRegisters
+		if function == 4 {
+			registers = slave.inputRegisters
+		}
+		data := make([]byte, 2*quantity)
+		for i := uint16(0); i < quantity; i++ {
+			binary.BigEndian.PutUint16(data[2*i:], registers[address+i])
+		}
+		return append([]byte{function, byte(len(data))}, data...)
+	}
+	return []byte{function | 0x80, 1}
+}
+
+func startServer(t *testing.T, slaves map[byte]*fakeSlave) (*fakeServer, string) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	s := &fakeServer{slaves: slaves}
+	go s.serve(l)
+	return s, l.Addr().String()
+}
+
+func TestGather(t *testing.T) {
+	server, addr := startServer(t, map[byte]*fakeSlave{
+		1: {
+			coils:          map[uint16]bool{0: true, 2: true, 9: true},
+			discreteInputs: map[uint16]bool{1: true},
+			holdingRegisters: map[uint16]uint16{
+				0: 2300,
+				1: 0x0001, 2: 0xE240,
+				5: 0x0000, 6: 0x4148,
+				8:   0xFF9C,
+				100: 7,
+			},
+			inputRegisters: map[uint16]uint16{
+				0: 0x4009, 1: 0x21FB, 2: 0x5444, 3: 0x2D18,
+				4: 0x3412,
+			},
+		},
+		2: {
+			holdingRegisters: map[uint16]uint16{0: 215},
+		},
+	})
+
+	m := &Modbus{
+		Name:       "Device",
+		Controller: "tcp://" + addr,
+		Timeout:    internal.Duration{Duration: time.Second},
+		MaxGap:     10,
+		Slave: Slave{
+			SlaveID: 1,
+			Coils: []fieldBit{
+				{Name: "motor1_run", Address: 0},
+				{Name: "motor1_jog", Address: 1},
+				{Name: "motor1_stop", Address: 2},
+				{Name: "motor2_run", Address: 9},
+			},
+			DiscreteInputs: []fieldBit{
+				{Name: "start", Address: 0},
+				{Name: "stop", Address: 1},
+			},
+			HoldingRegisters: []fieldWords{
+				{Name: "voltage", Address: 0, DataType: "UINT16", Scale: 0.5},
+				{Name: "current", Address: 1, DataType: "INT32"},
+				{Name: "energy", Address: 5, DataType: "FLOAT32", ByteOrder: "CDAB"},
+				{Name: "power_factor", Address: 8, DataType: "INT16"},
+				{Name: "mode", Address: 100, DataType: "UINT16"},
+			},
+			InputRegisters: []fieldWords{
+				{Name: "pi", Address: 0, DataType: "FLOAT64"},
+				{Name: "level", Address: 4, DataType: "UINT16", ByteOrder: "BA"},
+			},
+		},
+		Slaves: []Slave{
+			{
+				SlaveID: 2,
+				HoldingRegisters: []fieldWords{
+					{Name: "temperature", Address: 0, DataType: "INT16", Scale: 0.2},
+				},
+			},
+		},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(m.Gather))
+
+	acc.AssertContainsTaggedFields(t, "modbus",
+		map[string]interface{}{
+			"motor1_run":  int64(1),
+			"motor1_jog":  int64(0),
+			"motor1_stop": int64(1),
+			"motor2_run":  int64(1),
+		},
+		map[string]string{"name": "Device", "type": "coil", "slave_id": "1"})
+
+	acc.AssertContainsTaggedFields(t, "modbus",
+		map[string]interface{}{
+			"start": int64(0),
+			"stop":  int64(1),
+		},
+		map[string]string{"name": "Device", "type": "discrete_input", "slave_id": "1"})
+
+	acc.AssertContainsTaggedFields(t, "modbus",
+		map[string]interface{}{
+			"voltage":      float64(1150),
+			"current":      int64(123456),
+			"energy":       float64(12.5),
+			"power_factor": int64(-100),
+			"mode":         int64(7),
+		},
+		map[string]string{"name": "Device", "type": "holding_register", "slave_id": "1"})
+
+	acc.AssertContainsTaggedFields(t, "modbus",
+		map[string]interface{}{
+			"pi":    float64(3.141592653589793),
+			"level": int64(0x1234),
+		},
+		map[string]string{"name": "Device", "type": "input_register", "slave_id": "1"})
+
+	acc.AssertContainsTaggedFields(t, "modbus",
+		map[string]interface{}{
+			"temperature": float64(43),
+		},
+		map[string]string{"name": "Device", "type": "holding_register", "slave_id": "2"})
+
+	// coils, discrete inputs, holding registers 0-8 and 100, input
+	// registers, and the holding registers of slave 2
+	server.Lock()
+	assert.Equal(t, 6, server.requests)
+	server.Unlock()
+}
+
+func TestGatherBusyRetries(t *testing.T) {
+	slave := &fakeSlave{holdingRegisters: map[uint16]uint16{0: 42}, busy: 2}
+	_, addr := startServer(t, map[byte]*fakeSlave{1: slave})
+
+	m := &Modbus{
+		Name:            "Device",
+		Controller:      "tcp://" + addr,
+		Timeout:         internal.Duration{Duration: time.Second},
+		BusyRetries:     1,
+		BusyRetriesWait: internal.Duration{Duration: time.Millisecond},
+		Slave: Slave{
+			SlaveID: 1,
+			HoldingRegisters: []fieldWords{
+				{Name: "value", Address: 0, DataType: "UINT16"},
+			},
+		},
+	}
+
+	// busy twice, only retried once
+	var acc testutil.Accumulator
+	require.Error(t, acc.GatherError(m.Gather))
+	assert.Equal(t, 0, len(acc.Metrics))
+
+	require.NoError(t, acc.GatherError(m.Gather))
+	acc.AssertContainsFields(t, "modbus", map[string]interface{}{"value": int64(42)})
+}
+
+func TestGatherUnknownSlave(t *testing.T) {
+	_, addr := startServer(t, map[byte]*fakeSlave{})
+
+	m := &Modbus{
+		Name:       "Device",
+		Controller: "tcp://" + addr,
+		Timeout:    internal.Duration{Duration: time.Second},
+		Slave: Slave{
+			SlaveID: 3,
+			Coils:   []fieldBit{{Name: "run", Address: 0}},
+		},
+	}
+
+	var acc testutil.Accumulator
+	require.Error(t, acc.GatherError(m.Gather))
+}
+
+func TestGroupFields(t *testing.T) {
+	fields := []field{
+		{name: "e", address: 40, length: 2},
+		{name: "a", address: 0, length: 1},
+		{name: "b", address: 1, length: 2},
+		{name: "c", address: 10, length: 1},
+		{name: "d", address: 21, length: 4},
+	}
+
+	requests, err := groupFields(fields, 10, 125)
+	require.NoError(t, err)
+	require.Len(t, requests, 2)
+	assert.Equal(t, uint16(0), requests[0].address)
+	assert.Equal(t, uint16(25), requests[0].length)
+	assert.Len(t, requests[0].fields, 4)
+	assert.Equal(t, uint16(21), requests[0].fields[3].offset)
+	assert.Equal(t, uint16(40), requests[1].address)
+	assert.Equal(t, uint16(2), requests[1].length)
+
+	// without gap
+	requests, err = groupFields(fields, 0, 125)
+	require.NoError(t, err)
+	assert.Len(t, requests, 4)
+
+	// limited by the quantity of a request
+	requests, err = groupFields(fields, 10, 20)
+	require.NoError(t, err)
+	assert.Len(t, requests, 3)
+
+	_, err = groupFields([]field{{name: "a"}, {name: "a", address: 1}}, 10, 125)
+	assert.Error(t, err)
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		def      fieldWords
+		data     []byte
+		expected interface{}
+	}{
+		{fieldWords{DataType: "INT16"}, []byte{0xFF, 0xFE}, int64(-2)},
+		{fieldWords{DataType: "UINT16", ByteOrder: "BA"}, []byte{0x01, 0x02}, int64(0x0201)},
+		{fieldWords{DataType: "INT32", ByteOrder: "DCBA"}, []byte{0x04, 0x03, 0x02, 0x01}, int64(0x01020304)},
+		{fieldWords{DataType: "UINT32", ByteOrder: "BADC"}, []byte{0x02, 0x01, 0x04, 0x03}, int64(0x01020304)},
+		{fieldWords{DataType: "UINT32", ByteOrder: "CDAB"}, []byte{0x03, 0x04, 0x01, 0x02}, int64(0x01020304)},
+		{fieldWords{DataType: "FLOAT32"}, []byte{0x41, 0x48, 0x00, 0x00}, float64(12.5)},
+		{fieldWords{DataType: "INT64"}, []byte{0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF}, int64(-1)},
+		{fieldWords{DataType: "UINT64", ByteOrder: "GHEFCDAB"},
+			[]byte{0x07, 0x08, 0x05, 0x06, 0x03, 0x04, 0x01, 0x02}, uint64(0x0102030405060708)},
+		{fieldWords{DataType: "FLOAT64", ByteOrder: "HGFEDCBA"},
+			[]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x04, 0x40}, float64(2.5)},
+		{fieldWords{DataType: "INT16", Scale: 0.5}, []byte{0x00, 0x05}, float64(2.5)},
+	}
+
+	for _, tt := range tests {
+		f, err := tt.def.field()
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, f.decode(tt.data), tt.def)
+	}
+}
+
+func TestInvalidConfig(t *testing.T) {
+	valid := []fieldWords{{Name: "a", Address: 0, DataType: "INT16"}}
+
+	tests := []struct {
+		name   string
+		plugin *Modbus
+	}{
+		{"no registers", &Modbus{Name: "Device", Controller: "tcp://localhost:502"}},
+		{"empty name", &Modbus{Controller: "tcp://localhost:502",
+			Slave: Slave{HoldingRegisters: valid}}},
+		{"invalid controller", &Modbus{Name: "Device", Controller: "udp://localhost:502",
+			Slave: Slave{HoldingRegisters: valid}}},
+		{"invalid transmission mode", &Modbus{Name: "Device", Controller: "file:///dev/ttyUSB0",
+			TransmissionMode: "RTUoverTCP", Slave: Slave{HoldingRegisters: valid}}},
+		{"missing data type", &Modbus{Name: "Device", Controller: "tcp://localhost:502",
+			Slave: Slave{HoldingRegisters: []fieldWords{{Name: "a"}}}}},
+		{"invalid data type", &Modbus{Name: "Device", Controller: "tcp://localhost:502",
+			Slave: Slave{HoldingRegisters: []fieldWords{{Name: "a", DataType: "FLOAT16"}}}}},
+		{"invalid byte order", &Modbus{Name: "Device", Controller: "tcp://localhost:502",
+			Slave: Slave{HoldingRegisters: []fieldWords{{Name: "a", DataType: "INT32", ByteOrder: "AB"}}}}},
+		{"duplicate byte in order", &Modbus{Name: "Device", Controller: "tcp://localhost:502",
+			Slave: Slave{HoldingRegisters: []fieldWords{{Name: "a", DataType: "INT32", ByteOrder: "AABC"}}}}},
+		{"duplicate slave", &Modbus{Name: "Device", Controller: "tcp://localhost:502",
+			Slave:  Slave{HoldingRegisters: valid},
+			Slaves: []Slave{{HoldingRegisters: valid}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Error(t, tt.plugin.setup())
+		})
+	}
+}
@@ -12,17 +12,27 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
 	tlsint "github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"gopkg.in/mgo.v2"
 )
 
 type MongoDB struct {
-	Servers          []string
-	Ssl              Ssl
-	mongos           map[string]*Server
-	GatherPerdbStats bool
+	Servers               []string
+	Ssl                   Ssl
+	mongos                map[string]*Server
+	GatherPerdbStats      bool
+	GatherColStats        bool     `toml:"gather_col_stats"`
+	GatherReplMemberStats bool     `toml:"gather_repl_member_stats"`
+	DbInclude             []string `toml:"db_include"`
+	DbExclude             []string `toml:"db_exclude"`
+	ColInclude            []string `toml:"col_include"`
+	ColExclude            []string `toml:"col_exclude"`
 	tlsint.ClientConfig
+
+	dbFilter  filter.Filter
+	colFilter filter.Filter
 }
 
 type Ssl struct {
@@ -41,6 +51,21 @@ var sampleConfig = `
   ## When true, collect per database stats
   # gather_perdb_stats = false
 
+  ## When true, collect per collection stats, including the operation
+  ## latencies on MongoDB 3.4 and later
+  # gather_col_stats = false
+
+  ## When true, collect the state and replication lag of every member of the
+  ## replica set
+  # gather_repl_member_stats = false
+
+  ## Databases and collections the per database and per collection stats are
+  ## collected for, globs are supported.  All are collected if empty.
+  # db_include = []
+  # db_exclude = ["admin", "config", "local"]
+  # col_include = []
+  # col_exclude = ["system.*"]
+
   ## Optional TLS Config
   # tls_ca = "/etc/telegraf/ca.pem"
   # tls_cert = "/etc/telegraf/cert.pem"
@@ -62,6 +87,18 @@ var localhost = &url.URL{Host: "mongodb://127.0.0.1:27017"}
 // Reads stats from all configured servers accumulates stats.
 // Returns one of the errors encountered while gather stats (if any).
 func (m *MongoDB) Gather(acc telegraf.Accumulator) error {
+	if m.dbFilter == nil {
+		var err error
+		m.dbFilter, err = filter.NewIncludeExcludeFilter(m.DbInclude, m.DbExclude)
+		if err != nil {
+			return err
+		}
+		m.colFilter, err = filter.NewIncludeExcludeFilter(m.ColInclude, m.ColExclude)
+		if err != nil {
+			return err
+		}
+	}
+
 	if len(m.Servers) == 0 {
 		m.gatherServer(m.getMongoServer(localhost), acc)
 		return nil
@@ -101,7 +138,9 @@ func (m *MongoDB) Gather(acc telegraf.Accumulator) error {
 func (m *MongoDB) getMongoServer(url *url.URL) *Server {
 	if _, ok := m.mongos[url.Host]; !ok {
 		m.mongos[url.Host] = &Server{
-			Url: url,
+			Url:       url,
+			dbFilter:  m.dbFilter,
+			colFilter: m.colFilter,
 		}
 	}
 	return m.mongos[url.Host]
@@ -164,7 +203,7 @@ func (m *MongoDB) gatherServer(server *Server, acc telegraf.Accumulator) error {
 		}
 		server.Session = sess
 	}
-	return server.gatherData(acc, m.GatherPerdbStats)
+	return server.gatherData(acc, m.GatherPerdbStats, m.GatherColStats, m.GatherReplMemberStats)
 }
 
 func init() {
@@ -9,11 +9,13 @@ import (
 )
 
 type MongodbData struct {
-	StatLine      *StatLine
-	Fields        map[string]interface{}
-	Tags          map[string]string
-	DbData        []DbData
-	ShardHostData []DbData
+	StatLine       *StatLine
+	Fields         map[string]interface{}
+	Tags           map[string]string
+	DbData         []DbData
+	ColData        []ColData
+	ReplMemberData []ReplMemberData
+	ShardHostData  []DbData
 }
 
 type DbData struct {
@@ -21,6 +23,18 @@ type DbData struct {
 	Fields map[string]interface{}
 }
 
+type ColData struct {
+	Name   string
+	DbName string
+	Fields map[string]interface{}
+}
+
+type ReplMemberData struct {
+	Name   string
+	State  string
+	Fields map[string]interface{}
+}
+
 func NewMongodbData(statLine *StatLine, tags map[string]string) *MongodbData {
 	return &MongodbData{
 		StatLine: statLine,
@@ -140,6 +154,64 @@ func (d *MongodbData) AddDbStats() {
 	}
 }
 
+var ColDataStats = map[string]string{
+	"count":                   "Count",
+	"size":                    "Size",
+	"avg_obj_size":            "AvgObjSize",
+	"storage_size":            "StorageSize",
+	"total_index_size":        "TotalIndexSize",
+	"indexes":                 "Indexes",
+	"ok":                      "Ok",
+	"reads_latency_micros":    "ReadsLatency",
+	"reads_ops":               "ReadsOps",
+	"writes_latency_micros":   "WritesLatency",
+	"writes_ops":              "WritesOps",
+	"commands_latency_micros": "CommandsLatency",
+	"commands_ops":            "CommandsOps",
+}
+
+func (d *MongodbData) AddColStats() {
+	for _, colstat := range d.StatLine.ColStatsLines {
+		colStatLine := reflect.ValueOf(&colstat).Elem()
+		newColData := &ColData{
+			Name:   colstat.Name,
+			DbName: colstat.DbName,
+			Fields: make(map[string]interface{}),
+		}
+		newColData.Fields["type"] = "col_stat"
+		for key, value := range ColDataStats {
+			val := colStatLine.FieldByName(value).Interface()
+			newColData.Fields[key] = val
+		}
+		d.ColData = append(d.ColData, *newColData)
+	}
+}
+
+var ReplMemberStats = map[string]string{
+	"self":    "Self",
+	"health":  "Health",
+	"state":   "State",
+	"uptime":  "Uptime",
+	"ping_ms": "PingMs",
+	"lag":     "Lag",
+}
+
+func (d *MongodbData) AddReplMemberStats() {
+	for _, memberstat := range d.StatLine.ReplMemberStatsLines {
+		memberStatLine := reflect.ValueOf(&memberstat).Elem()
+		newMemberData := &ReplMemberData{
+			Name:   memberstat.Name,
+			State:  memberstat.StateStr,
+			Fields: make(map[string]interface{}),
+		}
+		for key, value := range ReplMemberStats {
+			val := memberStatLine.FieldByName(value).Interface()
+			newMemberData.Fields[key] = val
+		}
+		d.ReplMemberData = append(d.ReplMemberData, *newMemberData)
+	}
+}
+
 func (d *MongodbData) AddShardHostStats() {
 	for host, hostStat := range d.StatLine.ShardHostStatsLines {
 		hostStatLine := reflect.ValueOf(&hostStat).Elem()
@@ -207,6 +279,35 @@ func (d *MongodbData) flush(acc telegraf.Accumulator) {
 		)
 		db.Fields = make(map[string]interface{})
 	}
+	for _, col := range d.ColData {
+		tags := make(map[string]string)
+		for k, v := range d.Tags {
+			tags[k] = v
+		}
+		tags["db_name"] = col.DbName
+		tags["collection"] = col.Name
+		acc.AddFields(
+			"mongodb_col_stats",
+			col.Fields,
+			tags,
+			d.StatLine.Time,
+		)
+	}
+	for _, member := range d.ReplMemberData {
+		tags := make(map[string]string)
+		for k, v := range d.Tags {
+			tags[k] = v
+		}
+		delete(tags, "db_name")
+		tags["member_name"] = member.Name
+		tags["member_state"] = member.State
+		acc.AddFields(
+			"mongodb_repl_member_stats",
+			member.Fields,
+			tags,
+			d.StatLine.Time,
+		)
+	}
 	for _, host := range d.ShardHostData {
 		d.Tags["hostname"] = host.Name
 		acc.AddFields(
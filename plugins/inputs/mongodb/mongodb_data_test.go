@@ -222,3 +222,92 @@ func TestStateTag(t *testing.T) {
 	}
 	acc.AssertContainsTaggedFields(t, "mongodb", fields, stateTags)
 }
+
+func TestAddColStats(t *testing.T) {
+	d := NewMongodbData(
+		&StatLine{
+			ColStatsLines: []ColStatLine{
+				{
+					Name:           "orders",
+					DbName:         "shop",
+					Count:          1200,
+					Size:           245760,
+					AvgObjSize:     204.8,
+					StorageSize:    102400,
+					TotalIndexSize: 40960,
+					Indexes:        2,
+					Ok:             1,
+					ReadsLatency:   12000,
+					ReadsOps:       300,
+				},
+			},
+		},
+		map[string]string{"hostname": "localhost:27017"},
+	)
+
+	var acc testutil.Accumulator
+
+	d.AddColStats()
+	d.flush(&acc)
+
+	acc.AssertContainsTaggedFields(t, "mongodb_col_stats",
+		map[string]interface{}{
+			"type":                    "col_stat",
+			"count":                   int64(1200),
+			"size":                    int64(245760),
+			"avg_obj_size":            204.8,
+			"storage_size":            int64(102400),
+			"total_index_size":        int64(40960),
+			"indexes":                 int64(2),
+			"ok":                      int64(1),
+			"reads_latency_micros":    int64(12000),
+			"reads_ops":               int64(300),
+			"writes_latency_micros":   int64(0),
+			"writes_ops":              int64(0),
+			"commands_latency_micros": int64(0),
+			"commands_ops":            int64(0),
+		},
+		map[string]string{
+			"hostname":   "localhost:27017",
+			"db_name":    "shop",
+			"collection": "orders",
+		})
+}
+
+func TestAddReplMemberStats(t *testing.T) {
+	d := NewMongodbData(
+		&StatLine{
+			ReplMemberStatsLines: []ReplMemberStatLine{
+				{Name: "mongo1:27017", StateStr: "PRIMARY", Self: true, Health: 1, State: 1, Uptime: 3600},
+				{Name: "mongo2:27017", StateStr: "SECONDARY", Health: 1, State: 2, Uptime: 3500, PingMs: 2, Lag: 5},
+			},
+		},
+		map[string]string{"hostname": "mongo1:27017"},
+	)
+
+	var acc testutil.Accumulator
+
+	d.AddReplMemberStats()
+	d.flush(&acc)
+
+	acc.AssertContainsTaggedFields(t, "mongodb_repl_member_stats",
+		map[string]interface{}{
+			"self":    false,
+			"health":  int64(1),
+			"state":   int64(2),
+			"uptime":  int64(3500),
+			"ping_ms": int64(2),
+			"lag":     int64(5),
+		},
+		map[string]string{
+			"hostname":     "mongo1:27017",
+			"member_name":  "mongo2:27017",
+			"member_state": "SECONDARY",
+		})
+	assert.True(t, acc.HasPoint("mongodb_repl_member_stats",
+		map[string]string{
+			"hostname":     "mongo1:27017",
+			"member_name":  "mongo1:27017",
+			"member_state": "PRIMARY",
+		}, "self", true))
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
 	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
@@ -14,6 +15,9 @@ type Server struct {
 	Url        *url.URL
 	Session    *mgo.Session
 	lastResult *MongoStatus
+
+	dbFilter  filter.Filter
+	colFilter filter.Filter
 }
 
 func (s *Server) getDefaultTags() map[string]string {
@@ -57,7 +61,60 @@ func (s *Server) gatherOplogStats() *OplogStats {
 	return stats
 }
 
-func (s *Server) gatherData(acc telegraf.Accumulator, gatherDbStats bool) error {
+// matchDb returns true if the stats of the database are gathered.
+func (s *Server) matchDb(name string) bool {
+	return s.dbFilter == nil || s.dbFilter.Match(name)
+}
+
+// matchCol returns true if the stats of the collection are gathered.
+func (s *Server) matchCol(name string) bool {
+	return s.colFilter == nil || s.colFilter.Match(name)
+}
+
+func (s *Server) gatherColStats(names []string) *ColStats {
+	stats := &ColStats{}
+	for _, db_name := range names {
+		col_names, err := s.Session.DB(db_name).CollectionNames()
+		if err != nil {
+			log.Println("E! Error getting collection names from " + db_name + " (" + err.Error() + ")")
+			continue
+		}
+		for _, col_name := range col_names {
+			if !s.matchCol(col_name) {
+				continue
+			}
+
+			col_stat_line := &ColStatsData{}
+			err = s.Session.DB(db_name).Run(bson.D{
+				{
+					Name:  "collStats",
+					Value: col_name,
+				},
+			}, col_stat_line)
+			if err != nil {
+				log.Println("E! Error getting col stats from " + db_name + "." + col_name + " (" + err.Error() + ")")
+				continue
+			}
+
+			// The operation latencies are only available since 3.4, the
+			// error of older versions is ignored.
+			latency := &ColLatencyStats{}
+			_ = s.Session.DB(db_name).C(col_name).Pipe([]bson.M{
+				{"$collStats": bson.M{"latencyStats": bson.M{}}},
+			}).One(latency)
+
+			stats.Collections = append(stats.Collections, Collection{
+				Name:         col_name,
+				DbName:       db_name,
+				ColStatsData: col_stat_line,
+				LatencyStats: &latency.LatencyStats,
+			})
+		}
+	}
+	return stats
+}
+
+func (s *Server) gatherData(acc telegraf.Accumulator, gatherDbStats bool, gatherColStats bool, gatherReplMemberStats bool) error {
 	s.Session.SetMode(mgo.Eventual, true)
 	s.Session.SetSocketTimeout(0)
 	result_server := &ServerStatus{}
@@ -103,13 +160,21 @@ func (s *Server) gatherData(acc telegraf.Accumulator, gatherDbStats bool) error
 
 	oplogStats := s.gatherOplogStats()
 
-	result_db_stats := &DbStats{}
-	if gatherDbStats == true {
-		names := []string{}
-		names, err = s.Session.DatabaseNames()
+	names := []string{}
+	if gatherDbStats || gatherColStats {
+		all_names, err := s.Session.DatabaseNames()
 		if err != nil {
 			log.Println("E! Error getting database names (" + err.Error() + ")")
 		}
+		for _, db_name := range all_names {
+			if s.matchDb(db_name) {
+				names = append(names, db_name)
+			}
+		}
+	}
+
+	result_db_stats := &DbStats{}
+	if gatherDbStats == true {
 		for _, db_name := range names {
 			db_stat_line := &DbStatsData{}
 			err = s.Session.DB(db_name).Run(bson.D{
@@ -130,11 +195,17 @@ func (s *Server) gatherData(acc telegraf.Accumulator, gatherDbStats bool) error
 		}
 	}
 
+	result_col_stats := &ColStats{}
+	if gatherColStats {
+		result_col_stats = s.gatherColStats(names)
+	}
+
 	result := &MongoStatus{
 		ServerStatus:  result_server,
 		ReplSetStatus: result_repl,
 		ClusterStatus: result_cluster,
 		DbStats:       result_db_stats,
+		ColStats:      result_col_stats,
 		ShardStats:    resultShards,
 		OplogStats:    oplogStats,
 	}
@@ -156,6 +227,10 @@ func (s *Server) gatherData(acc telegraf.Accumulator, gatherDbStats bool) error
 		)
 		data.AddDefaultStats()
 		data.AddDbStats()
+		data.AddColStats()
+		if gatherReplMemberStats {
+			data.AddReplMemberStats()
+		}
 		data.AddShardHostStats()
 		data.flush(acc)
 	}
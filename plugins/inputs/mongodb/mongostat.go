@@ -34,6 +34,7 @@ type MongoStatus struct {
 	ReplSetStatus *ReplSetStatus
 	ClusterStatus *ClusterStatus
 	DbStats       *DbStats
+	ColStats      *ColStats
 	ShardStats    *ShardStats
 	OplogStats    *OplogStats
 }
@@ -92,6 +93,50 @@ type DbStatsData struct {
 	GleStats    interface{} `bson:"gleStats"`
 }
 
+// ColStats stores stats from all collections
+type ColStats struct {
+	Collections []Collection
+}
+
+// Collection represent a single collection
+type Collection struct {
+	Name         string
+	DbName       string
+	ColStatsData *ColStatsData
+	LatencyStats *LatencyStats
+}
+
+// ColStatsData stores stats from a collection
+type ColStatsData struct {
+	Collection     string  `bson:"ns"`
+	Count          int64   `bson:"count"`
+	Size           int64   `bson:"size"`
+	AvgObjSize     float64 `bson:"avgObjSize"`
+	StorageSize    int64   `bson:"storageSize"`
+	TotalIndexSize int64   `bson:"totalIndexSize"`
+	Indexes        int64   `bson:"nindexes"`
+	Ok             int64   `bson:"ok"`
+}
+
+// ColLatencyStats stores the result of the $collStats aggregation stage
+type ColLatencyStats struct {
+	LatencyStats LatencyStats `bson:"latencyStats"`
+}
+
+// LatencyStats stores the cumulative latencies of the operations on a
+// collection
+type LatencyStats struct {
+	Reads    LatencyStatsData `bson:"reads"`
+	Writes   LatencyStatsData `bson:"writes"`
+	Commands LatencyStatsData `bson:"commands"`
+}
+
+// LatencyStatsData stores the latency and count of operations of a type
+type LatencyStatsData struct {
+	Latency int64 `bson:"latency"`
+	Ops     int64 `bson:"ops"`
+}
+
 // ClusterStatus stores information related to the whole cluster
 type ClusterStatus struct {
 	JumboChunksCount int64
@@ -111,8 +156,11 @@ type OplogStats struct {
 // ReplSetMember stores information related to a replica set member
 type ReplSetMember struct {
 	Name       string    `bson:"name"`
+	Health     int64     `bson:"health"`
 	State      int64     `bson:"state"`
 	StateStr   string    `bson:"stateStr"`
+	Uptime     int64     `bson:"uptime"`
+	PingMs     int64     `bson:"pingMs"`
 	OptimeDate time.Time `bson:"optimeDate"`
 }
 
@@ -500,6 +548,12 @@ type StatLine struct {
 	// DB stats field
 	DbStatsLines []DbStatLine
 
+	// Col stats field
+	ColStatsLines []ColStatLine
+
+	// Replica set members field
+	ReplMemberStatsLines []ReplMemberStatLine
+
 	// Shard stats
 	TotalInUse, TotalAvailable, TotalCreated, TotalRefreshing int64
 
@@ -520,6 +574,35 @@ type DbStatLine struct {
 	Ok          int64
 }
 
+type ColStatLine struct {
+	Name            string
+	DbName          string
+	Count           int64
+	Size            int64
+	AvgObjSize      float64
+	StorageSize     int64
+	TotalIndexSize  int64
+	Indexes         int64
+	Ok              int64
+	ReadsLatency    int64
+	ReadsOps        int64
+	WritesLatency   int64
+	WritesOps       int64
+	CommandsLatency int64
+	CommandsOps     int64
+}
+
+type ReplMemberStatLine struct {
+	Name     string
+	StateStr string
+	Self     bool
+	Health   int64
+	State    int64
+	Uptime   int64
+	PingMs   int64
+	Lag      int64
+}
+
 type ShardHostStatLine struct {
 	InUse      int64
 	Available  int64
@@ -828,6 +911,34 @@ func NewStatLine(oldMongo, newMongo MongoStatus, key string, all bool, sampleSec
 				returnVal.ReplLag = lag
 			}
 		}
+
+		// The lag of the members is relative to the primary, as seen by
+		// this node
+		var primary *ReplSetMember
+		for i, member := range newReplStat.Members {
+			if member.State == 1 {
+				primary = &newReplStat.Members[i]
+				break
+			}
+		}
+		for _, member := range newReplStat.Members {
+			memberStatLine := ReplMemberStatLine{
+				Name:     member.Name,
+				StateStr: member.StateStr,
+				Self:     member.Name == myName,
+				Health:   member.Health,
+				State:    member.State,
+				Uptime:   member.Uptime,
+				PingMs:   member.PingMs,
+			}
+			if primary != nil && member.State == 2 {
+				lag := primary.OptimeDate.Unix() - member.OptimeDate.Unix()
+				if lag > 0 {
+					memberStatLine.Lag = lag
+				}
+			}
+			returnVal.ReplMemberStatsLines = append(returnVal.ReplMemberStatsLines, memberStatLine)
+		}
 	}
 
 	newClusterStat := *newMongo.ClusterStatus
@@ -856,6 +967,32 @@ func NewStatLine(oldMongo, newMongo MongoStatus, key string, all bool, sampleSec
 		returnVal.DbStatsLines = append(returnVal.DbStatsLines, *dbStatLine)
 	}
 
+	if newMongo.ColStats != nil {
+		for _, col := range newMongo.ColStats.Collections {
+			colStatsData := col.ColStatsData
+			colStatLine := &ColStatLine{
+				Name:           col.Name,
+				DbName:         col.DbName,
+				Count:          colStatsData.Count,
+				Size:           colStatsData.Size,
+				AvgObjSize:     colStatsData.AvgObjSize,
+				StorageSize:    colStatsData.StorageSize,
+				TotalIndexSize: colStatsData.TotalIndexSize,
+				Indexes:        colStatsData.Indexes,
+				Ok:             colStatsData.Ok,
+			}
+			if latency := col.LatencyStats; latency != nil {
+				colStatLine.ReadsLatency = latency.Reads.Latency
+				colStatLine.ReadsOps = latency.Reads.Ops
+				colStatLine.WritesLatency = latency.Writes.Latency
+				colStatLine.WritesOps = latency.Writes.Ops
+				colStatLine.CommandsLatency = latency.Commands.Latency
+				colStatLine.CommandsOps = latency.Commands.Ops
+			}
+			returnVal.ColStatsLines = append(returnVal.ColStatsLines, *colStatLine)
+		}
+	}
+
 	// Set shard stats
 	newShardStats := *newMongo.ShardStats
 	returnVal.TotalInUse = newShardStats.TotalInUse
@@ -0,0 +1,57 @@
+package mongodb
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewStatLineReplMembers(t *testing.T) {
+	optime := time.Unix(1531390000, 0)
+	status := MongoStatus{
+		ServerStatus: &ServerStatus{
+			Repl: &ReplStatus{Me: "mongo2:27017", IsMaster: false, Secondary: true},
+			Mem:  &MemStats{Supported: false},
+		},
+		ReplSetStatus: &ReplSetStatus{
+			Members: []ReplSetMember{
+				{Name: "mongo1:27017", Health: 1, State: 1, StateStr: "PRIMARY", OptimeDate: optime},
+				{Name: "mongo2:27017", Health: 1, State: 2, StateStr: "SECONDARY", OptimeDate: optime.Add(-3 * time.Second)},
+				{Name: "mongo3:27017", Health: 0, State: 8, StateStr: "(not reachable/healthy)"},
+			},
+		},
+		ClusterStatus: &ClusterStatus{},
+		DbStats:       &DbStats{},
+		ColStats: &ColStats{
+			Collections: []Collection{
+				{
+					Name:         "orders",
+					DbName:       "shop",
+					ColStatsData: &ColStatsData{Count: 10},
+					LatencyStats: &LatencyStats{Writes: LatencyStatsData{Latency: 500, Ops: 4}},
+				},
+			},
+		},
+		ShardStats: &ShardStats{},
+		OplogStats: &OplogStats{},
+	}
+
+	sl := NewStatLine(status, status, "mongo2:27017", true, 1)
+
+	assert.Equal(t, int64(3), sl.ReplLag)
+	require.Len(t, sl.ReplMemberStatsLines, 3)
+	assert.Equal(t, ReplMemberStatLine{
+		Name: "mongo1:27017", StateStr: "PRIMARY", Health: 1, State: 1,
+	}, sl.ReplMemberStatsLines[0])
+	assert.Equal(t, ReplMemberStatLine{
+		Name: "mongo2:27017", StateStr: "SECONDARY", Self: true, Health: 1, State: 2, Lag: 3,
+	}, sl.ReplMemberStatsLines[1])
+	assert.Equal(t, int64(0), sl.ReplMemberStatsLines[2].Lag)
+
+	require.Len(t, sl.ColStatsLines, 1)
+	assert.Equal(t, ColStatLine{
+		Name: "orders", DbName: "shop", Count: 10, WritesLatency: 500, WritesOps: 4,
+	}, sl.ColStatsLines[0])
+}
@@ -228,3 +228,6 @@ func (m *message) MessageID() uint16 {
 func (m *message) Payload() []byte {
 	return m.payload
 }
+
+func (m *message) Ack() {
+}
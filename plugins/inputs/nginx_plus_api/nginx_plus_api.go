@@ -0,0 +1,217 @@
+package nginx_plus_api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+type NginxPlusApi struct {
+	Urls            []string          `toml:"urls"`
+	ApiVersion      int64             `toml:"api_version"`
+	Username        string            `toml:"username"`
+	Password        string            `toml:"password"`
+	ResponseTimeout internal.Duration `toml:"response_timeout"`
+	tls.ClientConfig
+
+	client *http.Client
+}
+
+const (
+	// Paths of the endpoints, relative to the versioned API root
+	processesPath         = "processes"
+	connectionsPath       = "connections"
+	sslPath               = "ssl"
+	httpRequestsPath      = "http/requests"
+	httpServerZonesPath   = "http/server_zones"
+	httpUpstreamsPath     = "http/upstreams"
+	httpCachesPath        = "http/caches"
+	streamServerZonesPath = "stream/server_zones"
+	streamUpstreamsPath   = "stream/upstreams"
+)
+
+// errNotFound is returned for the endpoints of modules which are not
+// configured, such as the stream endpoints without a stream block.
+var errNotFound = errors.New("endpoint not found")
+
+var sampleConfig = `
+  ## An array of API URI to gather stats.
+  urls = ["http://localhost/api"]
+
+  ## Version of the API, the most recent version supported by the server is
+  ## used when 0.
+  # api_version = 0
+
+  ## HTTP basic authentication
+  # username = ""
+  # password = ""
+
+  ## HTTP response timeout (default: 5s)
+  response_timeout = "5s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+func (n *NginxPlusApi) SampleConfig() string {
+	return sampleConfig
+}
+
+func (n *NginxPlusApi) Description() string {
+	return "Read Nginx Plus API advanced status information"
+}
+
+func (n *NginxPlusApi) Gather(acc telegraf.Accumulator) error {
+	var wg sync.WaitGroup
+
+	// Create an HTTP client that is re-used for each
+	// collection interval
+
+	if n.client == nil {
+		client, err := n.createHttpClient()
+		if err != nil {
+			return err
+		}
+		n.client = client
+	}
+
+	for _, u := range n.Urls {
+		addr, err := url.Parse(u)
+		if err != nil {
+			acc.AddError(fmt.Errorf("Unable to parse address '%s': %s", u, err))
+			continue
+		}
+
+		wg.Add(1)
+		go func(addr *url.URL) {
+			defer wg.Done()
+			n.gatherMetrics(addr, acc)
+		}(addr)
+	}
+
+	wg.Wait()
+	return nil
+}
+
+func (n *NginxPlusApi) createHttpClient() (*http.Client, error) {
+	if n.ResponseTimeout.Duration < time.Second {
+		n.ResponseTimeout.Duration = time.Second * 5
+	}
+
+	tlsConfig, err := n.ClientConfig.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsConfig,
+		},
+		Timeout: n.ResponseTimeout.Duration,
+	}
+
+	return client, nil
+}
+
+// apiVersion returns the configured version of the API, or the most recent
+// version listed by the API root.
+func (n *NginxPlusApi) apiVersion(addr *url.URL) (int64, error) {
+	if n.ApiVersion > 0 {
+		return n.ApiVersion, nil
+	}
+
+	body, err := n.get(strings.TrimRight(addr.String(), "/") + "/")
+	if err != nil {
+		return 0, err
+	}
+
+	var versions []int64
+	if err := json.Unmarshal(body, &versions); err != nil {
+		return 0, fmt.Errorf("Error while decoding API versions from %s: %s", addr, err)
+	}
+
+	var version int64
+	for _, v := range versions {
+		if v > version {
+			version = v
+		}
+	}
+	if version == 0 {
+		return 0, fmt.Errorf("No API version listed by %s", addr)
+	}
+	return version, nil
+}
+
+// gatherUrl returns the body of an endpoint of the API.
+func (n *NginxPlusApi) gatherUrl(addr *url.URL, version int64, path string) ([]byte, error) {
+	return n.get(fmt.Sprintf("%s/%d/%s", strings.TrimRight(addr.String(), "/"), version, path))
+}
+
+func (n *NginxPlusApi) get(u string) ([]byte, error) {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if n.Username != "" || n.Password != "" {
+		req.SetBasicAuth(n.Username, n.Password)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error making HTTP request to %s: %s", u, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, errNotFound
+	default:
+		return nil, fmt.Errorf("%s returned HTTP status %s", u, resp.Status)
+	}
+
+	contentType := strings.Split(resp.Header.Get("Content-Type"), ";")[0]
+	if contentType != "application/json" {
+		return nil, fmt.Errorf("%s returned unexpected content type %s", u, contentType)
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
+
+func getTags(addr *url.URL) map[string]string {
+	h := addr.Host
+	host, port, err := net.SplitHostPort(h)
+	if err != nil {
+		host = addr.Host
+		if addr.Scheme == "http" {
+			port = "80"
+		} else if addr.Scheme == "https" {
+			port = "443"
+		} else {
+			port = ""
+		}
+	}
+	return map[string]string{"source": host, "port": port}
+}
+
+func init() {
+	inputs.Add("nginx_plus_api", func() telegraf.Input {
+		return &NginxPlusApi{}
+	})
+}
@@ -0,0 +1,380 @@
+package nginx_plus_api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	"github.com/influxdata/telegraf"
+)
+
+func (n *NginxPlusApi) gatherMetrics(addr *url.URL, acc telegraf.Accumulator) {
+	version, err := n.apiVersion(addr)
+	if err != nil {
+		if err == errNotFound {
+			err = fmt.Errorf("%s does not list any API version", addr)
+		}
+		acc.AddError(err)
+		return
+	}
+
+	addError(acc, n.gatherProcessesMetrics(addr, version, acc))
+	addError(acc, n.gatherConnectionsMetrics(addr, version, acc))
+	addError(acc, n.gatherSslMetrics(addr, version, acc))
+	addError(acc, n.gatherHttpRequestsMetrics(addr, version, acc))
+	addError(acc, n.gatherHttpServerZonesMetrics(addr, version, acc))
+	addError(acc, n.gatherHttpUpstreamsMetrics(addr, version, acc))
+	addError(acc, n.gatherHttpCachesMetrics(addr, version, acc))
+	addError(acc, n.gatherStreamServerZonesMetrics(addr, version, acc))
+	addError(acc, n.gatherStreamUpstreamsMetrics(addr, version, acc))
+}
+
+// addError adds the errors of the endpoints, except for the endpoints of the
+// modules which are not configured.
+func addError(acc telegraf.Accumulator, err error) {
+	if err != nil && err != errNotFound {
+		acc.AddError(err)
+	}
+}
+
+// decode reads an endpoint into v.
+func (n *NginxPlusApi) decode(addr *url.URL, version int64, path string, v interface{}) error {
+	body, err := n.gatherUrl(addr, version, path)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("Error while decoding JSON response from %s/%d/%s: %s", addr, version, path, err)
+	}
+	return nil
+}
+
+func (n *NginxPlusApi) gatherProcessesMetrics(addr *url.URL, version int64, acc telegraf.Accumulator) error {
+	var processes = &Processes{}
+	if err := n.decode(addr, version, processesPath, processes); err != nil {
+		return err
+	}
+
+	acc.AddFields(
+		"nginx_plus_api_processes",
+		map[string]interface{}{
+			"respawned": processes.Respawned,
+		},
+		getTags(addr),
+	)
+
+	return nil
+}
+
+func (n *NginxPlusApi) gatherConnectionsMetrics(addr *url.URL, version int64, acc telegraf.Accumulator) error {
+	var connections = &Connections{}
+	if err := n.decode(addr, version, connectionsPath, connections); err != nil {
+		return err
+	}
+
+	acc.AddFields(
+		"nginx_plus_api_connections",
+		map[string]interface{}{
+			"accepted": connections.Accepted,
+			"dropped":  connections.Dropped,
+			"active":   connections.Active,
+			"idle":     connections.Idle,
+		},
+		getTags(addr),
+	)
+
+	return nil
+}
+
+func (n *NginxPlusApi) gatherSslMetrics(addr *url.URL, version int64, acc telegraf.Accumulator) error {
+	var ssl = &Ssl{}
+	if err := n.decode(addr, version, sslPath, ssl); err != nil {
+		return err
+	}
+
+	acc.AddFields(
+		"nginx_plus_api_ssl",
+		map[string]interface{}{
+			"handshakes":        ssl.Handshakes,
+			"handshakes_failed": ssl.HandshakesFailed,
+			"session_reuses":    ssl.SessionReuses,
+		},
+		getTags(addr),
+	)
+
+	return nil
+}
+
+func (n *NginxPlusApi) gatherHttpRequestsMetrics(addr *url.URL, version int64, acc telegraf.Accumulator) error {
+	var httpRequests = &HttpRequests{}
+	if err := n.decode(addr, version, httpRequestsPath, httpRequests); err != nil {
+		return err
+	}
+
+	acc.AddFields(
+		"nginx_plus_api_http_requests",
+		map[string]interface{}{
+			"total":   httpRequests.Total,
+			"current": httpRequests.Current,
+		},
+		getTags(addr),
+	)
+
+	return nil
+}
+
+func (n *NginxPlusApi) gatherHttpServerZonesMetrics(addr *url.URL, version int64, acc telegraf.Accumulator) error {
+	var httpServerZones HttpServerZones
+	if err := n.decode(addr, version, httpServerZonesPath, &httpServerZones); err != nil {
+		return err
+	}
+
+	tags := getTags(addr)
+
+	for zoneName, zone := range httpServerZones {
+		zoneTags := map[string]string{}
+		for k, v := range tags {
+			zoneTags[k] = v
+		}
+		zoneTags["zone"] = zoneName
+		fields := map[string]interface{}{
+			"processing":      zone.Processing,
+			"requests":        zone.Requests,
+			"responses_1xx":   zone.Responses.Responses1xx,
+			"responses_2xx":   zone.Responses.Responses2xx,
+			"responses_3xx":   zone.Responses.Responses3xx,
+			"responses_4xx":   zone.Responses.Responses4xx,
+			"responses_5xx":   zone.Responses.Responses5xx,
+			"responses_total": zone.Responses.Total,
+			"received":        zone.Received,
+			"sent":            zone.Sent,
+		}
+		if zone.Discarded != nil {
+			fields["discarded"] = *zone.Discarded
+		}
+		acc.AddFields("nginx_plus_api_http_server_zones", fields, zoneTags)
+	}
+
+	return nil
+}
+
+func (n *NginxPlusApi) gatherHttpUpstreamsMetrics(addr *url.URL, version int64, acc telegraf.Accumulator) error {
+	var httpUpstreams HttpUpstreams
+	if err := n.decode(addr, version, httpUpstreamsPath, &httpUpstreams); err != nil {
+		return err
+	}
+
+	tags := getTags(addr)
+
+	for upstreamName, upstream := range httpUpstreams {
+		upstreamTags := map[string]string{}
+		for k, v := range tags {
+			upstreamTags[k] = v
+		}
+		upstreamTags["upstream"] = upstreamName
+		upstreamFields := map[string]interface{}{
+			"keepalive": upstream.Keepalive,
+			"zombies":   upstream.Zombies,
+		}
+		if upstream.Queue != nil {
+			upstreamFields["queue_size"] = upstream.Queue.Size
+			upstreamFields["queue_max_size"] = upstream.Queue.MaxSize
+			upstreamFields["queue_overflows"] = upstream.Queue.Overflows
+		}
+		acc.AddFields("nginx_plus_api_http_upstreams", upstreamFields, upstreamTags)
+
+		for _, peer := range upstream.Peers {
+			peerFields := map[string]interface{}{
+				"backup":                 peer.Backup,
+				"weight":                 peer.Weight,
+				"state":                  peer.State,
+				"active":                 peer.Active,
+				"requests":               peer.Requests,
+				"responses_1xx":          peer.Responses.Responses1xx,
+				"responses_2xx":          peer.Responses.Responses2xx,
+				"responses_3xx":          peer.Responses.Responses3xx,
+				"responses_4xx":          peer.Responses.Responses4xx,
+				"responses_5xx":          peer.Responses.Responses5xx,
+				"responses_total":        peer.Responses.Total,
+				"sent":                   peer.Sent,
+				"received":               peer.Received,
+				"fails":                  peer.Fails,
+				"unavail":                peer.Unavail,
+				"healthchecks_checks":    peer.HealthChecks.Checks,
+				"healthchecks_fails":     peer.HealthChecks.Fails,
+				"healthchecks_unhealthy": peer.HealthChecks.Unhealthy,
+				"downtime":               peer.Downtime,
+			}
+			if peer.HealthChecks.LastPassed != nil {
+				peerFields["healthchecks_last_passed"] = *peer.HealthChecks.LastPassed
+			}
+			if peer.HeaderTime != nil {
+				peerFields["header_time"] = *peer.HeaderTime
+			}
+			if peer.ResponseTime != nil {
+				peerFields["response_time"] = *peer.ResponseTime
+			}
+			if peer.MaxConns != nil {
+				peerFields["max_conns"] = *peer.MaxConns
+			}
+			peerTags := map[string]string{}
+			for k, v := range upstreamTags {
+				peerTags[k] = v
+			}
+			peerTags["upstream_address"] = peer.Server
+			if peer.ID != nil {
+				peerTags["id"] = strconv.Itoa(*peer.ID)
+			}
+			acc.AddFields("nginx_plus_api_http_upstream_peers", peerFields, peerTags)
+		}
+	}
+
+	return nil
+}
+
+func (n *NginxPlusApi) gatherHttpCachesMetrics(addr *url.URL, version int64, acc telegraf.Accumulator) error {
+	var httpCaches HttpCaches
+	if err := n.decode(addr, version, httpCachesPath, &httpCaches); err != nil {
+		return err
+	}
+
+	tags := getTags(addr)
+
+	for cacheName, cache := range httpCaches {
+		cacheTags := map[string]string{}
+		for k, v := range tags {
+			cacheTags[k] = v
+		}
+		cacheTags["cache"] = cacheName
+		fields := map[string]interface{}{
+			"size":                      cache.Size,
+			"max_size":                  cache.MaxSize,
+			"cold":                      cache.Cold,
+			"hit_responses":             cache.Hit.Responses,
+			"hit_bytes":                 cache.Hit.Bytes,
+			"stale_responses":           cache.Stale.Responses,
+			"stale_bytes":               cache.Stale.Bytes,
+			"updating_responses":        cache.Updating.Responses,
+			"updating_bytes":            cache.Updating.Bytes,
+			"miss_responses":            cache.Miss.Responses,
+			"miss_bytes":                cache.Miss.Bytes,
+			"miss_responses_written":    cache.Miss.ResponsesWritten,
+			"miss_bytes_written":        cache.Miss.BytesWritten,
+			"expired_responses":         cache.Expired.Responses,
+			"expired_bytes":             cache.Expired.Bytes,
+			"expired_responses_written": cache.Expired.ResponsesWritten,
+			"expired_bytes_written":     cache.Expired.BytesWritten,
+			"bypass_responses":          cache.Bypass.Responses,
+			"bypass_bytes":              cache.Bypass.Bytes,
+			"bypass_responses_written":  cache.Bypass.ResponsesWritten,
+			"bypass_bytes_written":      cache.Bypass.BytesWritten,
+		}
+		if cache.Revalidated != nil {
+			fields["revalidated_responses"] = cache.Revalidated.Responses
+			fields["revalidated_bytes"] = cache.Revalidated.Bytes
+		}
+		acc.AddFields("nginx_plus_api_http_caches", fields, cacheTags)
+	}
+
+	return nil
+}
+
+func (n *NginxPlusApi) gatherStreamServerZonesMetrics(addr *url.URL, version int64, acc telegraf.Accumulator) error {
+	var streamServerZones StreamServerZones
+	if err := n.decode(addr, version, streamServerZonesPath, &streamServerZones); err != nil {
+		return err
+	}
+
+	tags := getTags(addr)
+
+	for zoneName, zone := range streamServerZones {
+		zoneTags := map[string]string{}
+		for k, v := range tags {
+			zoneTags[k] = v
+		}
+		zoneTags["zone"] = zoneName
+		fields := map[string]interface{}{
+			"processing":  zone.Processing,
+			"connections": zone.Connections,
+			"received":    zone.Received,
+			"sent":        zone.Sent,
+		}
+		if zone.Sessions != nil {
+			fields["sessions_2xx"] = zone.Sessions.Responses2xx
+			fields["sessions_4xx"] = zone.Sessions.Responses4xx
+			fields["sessions_5xx"] = zone.Sessions.Responses5xx
+			fields["sessions_total"] = zone.Sessions.Total
+		}
+		if zone.Discarded != nil {
+			fields["discarded"] = *zone.Discarded
+		}
+		acc.AddFields("nginx_plus_api_stream_server_zones", fields, zoneTags)
+	}
+
+	return nil
+}
+
+func (n *NginxPlusApi) gatherStreamUpstreamsMetrics(addr *url.URL, version int64, acc telegraf.Accumulator) error {
+	var streamUpstreams StreamUpstreams
+	if err := n.decode(addr, version, streamUpstreamsPath, &streamUpstreams); err != nil {
+		return err
+	}
+
+	tags := getTags(addr)
+
+	for upstreamName, upstream := range streamUpstreams {
+		upstreamTags := map[string]string{}
+		for k, v := range tags {
+			upstreamTags[k] = v
+		}
+		upstreamTags["upstream"] = upstreamName
+		acc.AddFields(
+			"nginx_plus_api_stream_upstreams",
+			map[string]interface{}{
+				"zombies": upstream.Zombies,
+			},
+			upstreamTags,
+		)
+
+		for _, peer := range upstream.Peers {
+			peerFields := map[string]interface{}{
+				"backup":                 peer.Backup,
+				"weight":                 peer.Weight,
+				"state":                  peer.State,
+				"active":                 peer.Active,
+				"connections":            peer.Connections,
+				"sent":                   peer.Sent,
+				"received":               peer.Received,
+				"fails":                  peer.Fails,
+				"unavail":                peer.Unavail,
+				"healthchecks_checks":    peer.HealthChecks.Checks,
+				"healthchecks_fails":     peer.HealthChecks.Fails,
+				"healthchecks_unhealthy": peer.HealthChecks.Unhealthy,
+				"downtime":               peer.Downtime,
+			}
+			if peer.HealthChecks.LastPassed != nil {
+				peerFields["healthchecks_last_passed"] = *peer.HealthChecks.LastPassed
+			}
+			if peer.ConnectTime != nil {
+				peerFields["connect_time"] = *peer.ConnectTime
+			}
+			if peer.FirstByteTime != nil {
+				peerFields["first_byte_time"] = *peer.FirstByteTime
+			}
+			if peer.ResponseTime != nil {
+				peerFields["response_time"] = *peer.ResponseTime
+			}
+			peerTags := map[string]string{}
+			for k, v := range upstreamTags {
+				peerTags[k] = v
+			}
+			peerTags["upstream_address"] = peer.Server
+			peerTags["id"] = strconv.Itoa(peer.ID)
+			acc.AddFields("nginx_plus_api_stream_upstream_peers", peerFields, peerTags)
+		}
+	}
+
+	return nil
+}
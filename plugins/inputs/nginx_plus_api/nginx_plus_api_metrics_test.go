@@ -0,0 +1,318 @@
+package nginx_plus_api
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const processesPayload = `
+{
+	"respawned": 0
+}
+`
+
+const connectionsPayload = `
+{
+	"accepted": 1234567890000,
+	"dropped": 2345678900000,
+	"active": 345,
+	"idle": 567
+}
+`
+
+const sslPayload = `
+{
+	"handshakes": 79572,
+	"handshakes_failed": 21025,
+	"session_reuses": 15762
+}
+`
+
+const httpRequestsPayload = `
+{
+	"total": 10624511,
+	"current": 4
+}
+`
+
+const httpServerZonesPayload = `
+{
+	"site1": {
+		"processing": 2,
+		"requests": 736395,
+		"responses": {
+			"1xx": 0,
+			"2xx": 727290,
+			"3xx": 4614,
+			"4xx": 934,
+			"5xx": 1535,
+			"total": 734373
+		},
+		"discarded": 2020,
+		"received": 180157219,
+		"sent": 20183175459
+	}
+}
+`
+
+const httpUpstreamsPayload = `
+{
+	"trac-backend": {
+		"peers": [
+			{
+				"id": 0,
+				"server": "10.0.0.1:8088",
+				"name": "10.0.0.1:8088",
+				"backup": false,
+				"weight": 5,
+				"state": "up",
+				"active": 0,
+				"requests": 67843,
+				"header_time": 15,
+				"response_time": 21,
+				"responses": {
+					"1xx": 0,
+					"2xx": 52951,
+					"3xx": 14880,
+					"4xx": 11,
+					"5xx": 0,
+					"total": 67842
+				},
+				"sent": 30565599,
+				"received": 2046924316,
+				"fails": 0,
+				"unavail": 0,
+				"health_checks": {
+					"checks": 26214,
+					"fails": 0,
+					"unhealthy": 0,
+					"last_passed": true
+				},
+				"downtime": 0,
+				"selected": "2018-07-12T10:13:05Z"
+			}
+		],
+		"keepalive": 0,
+		"zombies": 0,
+		"zone": "trac-backend"
+	}
+}
+`
+
+const httpCachesPayload = `
+{
+	"http-cache": {
+		"size": 530915328,
+		"max_size": 536870912,
+		"cold": false,
+		"hit": {"responses": 254032, "bytes": 6685627875},
+		"stale": {"responses": 0, "bytes": 0},
+		"updating": {"responses": 0, "bytes": 0},
+		"revalidated": {"responses": 0, "bytes": 0},
+		"miss": {"responses": 1619201, "bytes": 53841943822, "responses_written": 44992, "bytes_written": 1753535264},
+		"expired": {"responses": 7545, "bytes": 87852454, "responses_written": 5762, "bytes_written": 75327316},
+		"bypass": {"responses": 330, "bytes": 14765, "responses_written": 330, "bytes_written": 14765}
+	}
+}
+`
+
+func newServer(t *testing.T) *httptest.Server {
+	payloads := map[string]string{
+		"/api/3/processes":         processesPayload,
+		"/api/3/connections":       connectionsPayload,
+		"/api/3/ssl":               sslPayload,
+		"/api/3/http/requests":     httpRequestsPayload,
+		"/api/3/http/server_zones": httpServerZonesPayload,
+		"/api/3/http/upstreams":    httpUpstreamsPayload,
+		"/api/3/http/caches":       httpCachesPayload,
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, _ := r.BasicAuth()
+		assert.Equal(t, "telegraf", username)
+		assert.Equal(t, "secret", password)
+
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/" {
+			fmt.Fprint(w, `[1,2,3]`)
+			return
+		}
+
+		payload, ok := payloads[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, `{"error":{"status":404,"text":"path not found","code":"PathNotFound"}}`)
+			return
+		}
+		fmt.Fprint(w, payload)
+	}))
+}
+
+func TestGatherMetrics(t *testing.T) {
+	ts := newServer(t)
+	defer ts.Close()
+
+	n := &NginxPlusApi{
+		Urls:     []string{ts.URL + "/api"},
+		Username: "telegraf",
+		Password: "secret",
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(n.Gather))
+
+	host, port, err := net.SplitHostPort(ts.Listener.Addr().String())
+	require.NoError(t, err)
+	tags := map[string]string{"source": host, "port": port}
+
+	acc.AssertContainsTaggedFields(t, "nginx_plus_api_processes",
+		map[string]interface{}{"respawned": 0}, tags)
+
+	acc.AssertContainsTaggedFields(t, "nginx_plus_api_connections",
+		map[string]interface{}{
+			"accepted": int64(1234567890000),
+			"dropped":  int64(2345678900000),
+			"active":   int64(345),
+			"idle":     int64(567),
+		}, tags)
+
+	acc.AssertContainsTaggedFields(t, "nginx_plus_api_ssl",
+		map[string]interface{}{
+			"handshakes":        int64(79572),
+			"handshakes_failed": int64(21025),
+			"session_reuses":    int64(15762),
+		}, tags)
+
+	acc.AssertContainsTaggedFields(t, "nginx_plus_api_http_requests",
+		map[string]interface{}{
+			"total":   int64(10624511),
+			"current": int64(4),
+		}, tags)
+
+	acc.AssertContainsTaggedFields(t, "nginx_plus_api_http_server_zones",
+		map[string]interface{}{
+			"processing":      2,
+			"requests":        int64(736395),
+			"responses_1xx":   int64(0),
+			"responses_2xx":   int64(727290),
+			"responses_3xx":   int64(4614),
+			"responses_4xx":   int64(934),
+			"responses_5xx":   int64(1535),
+			"responses_total": int64(734373),
+			"discarded":       int64(2020),
+			"received":        int64(180157219),
+			"sent":            int64(20183175459),
+		},
+		map[string]string{"source": host, "port": port, "zone": "site1"})
+
+	acc.AssertContainsTaggedFields(t, "nginx_plus_api_http_upstreams",
+		map[string]interface{}{
+			"keepalive": 0,
+			"zombies":   0,
+		},
+		map[string]string{"source": host, "port": port, "upstream": "trac-backend"})
+
+	acc.AssertContainsTaggedFields(t, "nginx_plus_api_http_upstream_peers",
+		map[string]interface{}{
+			"backup":                   false,
+			"weight":                   5,
+			"state":                    "up",
+			"active":                   0,
+			"requests":                 int64(67843),
+			"header_time":              int64(15),
+			"response_time":            int64(21),
+			"responses_1xx":            int64(0),
+			"responses_2xx":            int64(52951),
+			"responses_3xx":            int64(14880),
+			"responses_4xx":            int64(11),
+			"responses_5xx":            int64(0),
+			"responses_total":          int64(67842),
+			"sent":                     int64(30565599),
+			"received":                 int64(2046924316),
+			"fails":                    int64(0),
+			"unavail":                  int64(0),
+			"healthchecks_checks":      int64(26214),
+			"healthchecks_fails":       int64(0),
+			"healthchecks_unhealthy":   int64(0),
+			"healthchecks_last_passed": true,
+			"downtime":                 int64(0),
+		},
+		map[string]string{
+			"source":           host,
+			"port":             port,
+			"upstream":         "trac-backend",
+			"upstream_address": "10.0.0.1:8088",
+			"id":               "0",
+		})
+
+	acc.AssertContainsTaggedFields(t, "nginx_plus_api_http_caches",
+		map[string]interface{}{
+			"size":                      int64(530915328),
+			"max_size":                  int64(536870912),
+			"cold":                      false,
+			"hit_responses":             int64(254032),
+			"hit_bytes":                 int64(6685627875),
+			"stale_responses":           int64(0),
+			"stale_bytes":               int64(0),
+			"updating_responses":        int64(0),
+			"updating_bytes":            int64(0),
+			"revalidated_responses":     int64(0),
+			"revalidated_bytes":         int64(0),
+			"miss_responses":            int64(1619201),
+			"miss_bytes":                int64(53841943822),
+			"miss_responses_written":    int64(44992),
+			"miss_bytes_written":        int64(1753535264),
+			"expired_responses":         int64(7545),
+			"expired_bytes":             int64(87852454),
+			"expired_responses_written": int64(5762),
+			"expired_bytes_written":     int64(75327316),
+			"bypass_responses":          int64(330),
+			"bypass_bytes":              int64(14765),
+			"bypass_responses_written":  int64(330),
+			"bypass_bytes_written":      int64(14765),
+		},
+		map[string]string{"source": host, "port": port, "cache": "http-cache"})
+
+	// The stream endpoints are not found without a stream block
+	assert.False(t, acc.HasMeasurement("nginx_plus_api_stream_server_zones"))
+	assert.False(t, acc.HasMeasurement("nginx_plus_api_stream_upstreams"))
+}
+
+func TestGatherApiVersion(t *testing.T) {
+	ts := newServer(t)
+	defer ts.Close()
+
+	n := &NginxPlusApi{
+		Urls:       []string{ts.URL + "/api"},
+		ApiVersion: 2,
+		Username:   "telegraf",
+		Password:   "secret",
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(n.Gather))
+
+	// None of the endpoints of version 2 are served
+	assert.Equal(t, 0, len(acc.Metrics))
+}
+
+func TestGatherNotAnApi(t *testing.T) {
+	ts := newServer(t)
+	defer ts.Close()
+
+	n := &NginxPlusApi{
+		Urls:     []string{ts.URL + "/status"},
+		Username: "telegraf",
+		Password: "secret",
+	}
+
+	var acc testutil.Accumulator
+	require.Error(t, acc.GatherError(n.Gather))
+}
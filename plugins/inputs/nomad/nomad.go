@@ -0,0 +1,311 @@
+package nomad
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const sampleConfig = `
+  ## URL of the HTTP API of the Nomad agent
+  url = "http://127.0.0.1:4646"
+
+  ## ACL token used in every request
+  # token = ""
+
+  ## Gather the summaries of the allocations of each job, per task group
+  # job_summaries = true
+
+  ## Namespaces to gather the jobs of, the names can contain wildcards.  The
+  ## jobs of the default namespace are gathered if empty.
+  # namespaces = []
+
+  ## Amount of time allowed to complete the HTTP requests
+  # timeout = "5s"
+
+  ## Optional TLS Config, the client certificate is required when the agent
+  ## verifies the HTTPS clients
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+type Nomad struct {
+	URL          string            `toml:"url"`
+	Token        string            `toml:"token"`
+	JobSummaries bool              `toml:"job_summaries"`
+	Namespaces   []string          `toml:"namespaces"`
+	Timeout      internal.Duration `toml:"timeout"`
+	tls.ClientConfig
+
+	client          *http.Client
+	namespaceFilter filter.Filter
+}
+
+// metricsSummary is the response of the metrics endpoint.
+type metricsSummary struct {
+	Gauges   []gaugeValue   `json:"Gauges"`
+	Counters []sampledValue `json:"Counters"`
+	Samples  []sampledValue `json:"Samples"`
+}
+
+type gaugeValue struct {
+	Name   string            `json:"Name"`
+	Value  float64           `json:"Value"`
+	Labels map[string]string `json:"Labels"`
+}
+
+type sampledValue struct {
+	Name   string            `json:"Name"`
+	Count  int64             `json:"Count"`
+	Rate   float64           `json:"Rate"`
+	Sum    float64           `json:"Sum"`
+	Min    float64           `json:"Min"`
+	Max    float64           `json:"Max"`
+	Mean   float64           `json:"Mean"`
+	Stddev float64           `json:"Stddev"`
+	Labels map[string]string `json:"Labels"`
+}
+
+type jobListStub struct {
+	ID         string `json:"ID"`
+	Name       string `json:"Name"`
+	Namespace  string `json:"Namespace"`
+	Type       string `json:"Type"`
+	Status     string `json:"Status"`
+	JobSummary *struct {
+		Summary map[string]taskGroupSummary `json:"Summary"`
+	} `json:"JobSummary"`
+}
+
+type taskGroupSummary struct {
+	Queued   int64 `json:"Queued"`
+	Complete int64 `json:"Complete"`
+	Failed   int64 `json:"Failed"`
+	Running  int64 `json:"Running"`
+	Starting int64 `json:"Starting"`
+	Lost     int64 `json:"Lost"`
+}
+
+func (n *Nomad) SampleConfig() string {
+	return sampleConfig
+}
+
+func (n *Nomad) Description() string {
+	return "Read metrics and job summaries from a Nomad agent"
+}
+
+func (n *Nomad) init() error {
+	tlsCfg, err := n.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	n.namespaceFilter, err = filter.Compile(n.Namespaces)
+	if err != nil {
+		return err
+	}
+
+	n.client = &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsCfg,
+		},
+		Timeout: n.Timeout.Duration,
+	}
+	return nil
+}
+
+func (n *Nomad) Gather(acc telegraf.Accumulator) error {
+	if n.client == nil {
+		if err := n.init(); err != nil {
+			return err
+		}
+	}
+
+	if err := n.gatherMetrics(acc); err != nil {
+		acc.AddError(err)
+	}
+
+	if n.JobSummaries {
+		if err := n.gatherJobSummaries(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+	return nil
+}
+
+func (n *Nomad) gatherMetrics(acc telegraf.Accumulator) error {
+	var summary metricsSummary
+	if err := n.get("/v1/metrics", nil, &summary); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, g := range summary.Gauges {
+		acc.AddGauge(measurementName(g.Name),
+			map[string]interface{}{"value": g.Value},
+			copyTags(g.Labels), now)
+	}
+	for _, c := range summary.Counters {
+		acc.AddCounter(measurementName(c.Name), sampledFields(c), copyTags(c.Labels), now)
+	}
+	for _, s := range summary.Samples {
+		acc.AddFields(measurementName(s.Name), sampledFields(s), copyTags(s.Labels), now)
+	}
+	return nil
+}
+
+func (n *Nomad) gatherJobSummaries(acc telegraf.Accumulator) error {
+	namespaces, err := n.namespaces()
+	if err != nil {
+		return err
+	}
+
+	for _, namespace := range namespaces {
+		params := url.Values{}
+		if namespace != "" {
+			params.Set("namespace", namespace)
+		}
+
+		var jobs []jobListStub
+		if err := n.get("/v1/jobs", params, &jobs); err != nil {
+			acc.AddError(err)
+			continue
+		}
+
+		for _, job := range jobs {
+			if job.JobSummary == nil {
+				continue
+			}
+
+			for taskGroup, s := range job.JobSummary.Summary {
+				tags := map[string]string{
+					"job":        job.ID,
+					"task_group": taskGroup,
+					"type":       job.Type,
+				}
+				if job.Namespace != "" {
+					tags["namespace"] = job.Namespace
+				}
+
+				acc.AddFields("nomad_job_summary", map[string]interface{}{
+					"queued":   s.Queued,
+					"starting": s.Starting,
+					"running":  s.Running,
+					"complete": s.Complete,
+					"failed":   s.Failed,
+					"lost":     s.Lost,
+					"status":   job.Status,
+				}, tags)
+			}
+		}
+	}
+	return nil
+}
+
+// namespaces returns the namespaces to gather the jobs of, an empty name
+// standing for the default namespace.
+func (n *Nomad) namespaces() ([]string, error) {
+	if len(n.Namespaces) == 0 {
+		return []string{""}, nil
+	}
+
+	hasPattern := false
+	for _, namespace := range n.Namespaces {
+		if strings.ContainsAny(namespace, "*?[") {
+			hasPattern = true
+		}
+	}
+	if !hasPattern {
+		return n.Namespaces, nil
+	}
+
+	var available []struct {
+		Name string `json:"Name"`
+	}
+	if err := n.get("/v1/namespaces", nil, &available); err != nil {
+		return nil, err
+	}
+
+	var namespaces []string
+	for _, namespace := range available {
+		if n.namespaceFilter.Match(namespace.Name) {
+			namespaces = append(namespaces, namespace.Name)
+		}
+	}
+	return namespaces, nil
+}
+
+func (n *Nomad) get(path string, params url.Values, v interface{}) error {
+	u := strings.TrimRight(n.URL, "/") + path
+	if len(params) > 0 {
+		u += "?" + params.Encode()
+	}
+
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return err
+	}
+	if n.Token != "" {
+		req.Header.Set("X-Nomad-Token", n.Token)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("nomad: %s responded with status-code %d, expected %d",
+			path, resp.StatusCode, http.StatusOK)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// measurementName converts a metric name such as nomad.client.allocs.cpu.user
+// to nomad_client_allocs_cpu_user.
+func measurementName(name string) string {
+	return strings.Replace(strings.Replace(name, ".", "_", -1), "-", "_", -1)
+}
+
+func sampledFields(s sampledValue) map[string]interface{} {
+	return map[string]interface{}{
+		"count":  s.Count,
+		"rate":   s.Rate,
+		"sum":    s.Sum,
+		"min":    s.Min,
+		"max":    s.Max,
+		"mean":   s.Mean,
+		"stddev": s.Stddev,
+	}
+}
+
+func copyTags(labels map[string]string) map[string]string {
+	tags := make(map[string]string, len(labels))
+	for k, v := range labels {
+		tags[k] = v
+	}
+	return tags
+}
+
+func init() {
+	inputs.Add("nomad", func() telegraf.Input {
+		return &Nomad{
+			JobSummaries: true,
+			Timeout:      internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
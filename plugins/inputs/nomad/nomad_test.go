@@ -0,0 +1,156 @@
+package nomad
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const metricsResponse = `{
+	"Timestamp": "2018-07-12 10:06:40 +0000 UTC",
+	"Gauges": [
+		{"Name": "nomad.runtime.num_goroutines", "Value": 56, "Labels": {}},
+		{"Name": "nomad.client.allocs.memory.usage", "Value": 1024, "Labels": {"job": "web", "task_group": "frontend", "task": "nginx"}}
+	],
+	"Counters": [
+		{"Name": "nomad.nomad.rpc.query", "Count": 2, "Rate": 0.2, "Sum": 2, "Min": 1, "Max": 1, "Mean": 1, "Stddev": 0, "Labels": {}}
+	],
+	"Samples": [
+		{"Name": "nomad.memberlist.gossip", "Count": 20, "Rate": 0.0021, "Sum": 0.021, "Min": 0.0008, "Max": 0.0015, "Mean": 0.00105, "Stddev": 0.0002, "Labels": {}}
+	]
+}`
+
+const jobsResponse = `[
+	{
+		"ID": "web",
+		"Name": "web",
+		"Namespace": "%s",
+		"Type": "service",
+		"Status": "running",
+		"JobSummary": {
+			"JobID": "web",
+			"Summary": {
+				"frontend": {"Queued": 1, "Complete": 0, "Failed": 2, "Running": 3, "Starting": 1, "Lost": 0}
+			}
+		}
+	}
+]`
+
+func newServer(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "secret", r.Header.Get("X-Nomad-Token"))
+
+		switch r.URL.Path {
+		case "/v1/metrics":
+			fmt.Fprint(w, metricsResponse)
+		case "/v1/namespaces":
+			fmt.Fprint(w, `[{"Name": "default"}, {"Name": "prod-eu"}, {"Name": "prod-us"}, {"Name": "staging"}]`)
+		case "/v1/jobs":
+			namespace := r.URL.Query().Get("namespace")
+			if namespace == "" {
+				namespace = "default"
+			}
+			fmt.Fprintf(w, jobsResponse, namespace)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestGather(t *testing.T) {
+	ts := newServer(t)
+	defer ts.Close()
+
+	n := &Nomad{
+		URL:          ts.URL,
+		Token:        "secret",
+		JobSummaries: true,
+		Timeout:      internal.Duration{Duration: time.Second},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(n.Gather))
+
+	acc.AssertContainsTaggedFields(t, "nomad_runtime_num_goroutines",
+		map[string]interface{}{"value": 56.0},
+		map[string]string{})
+	acc.AssertContainsTaggedFields(t, "nomad_client_allocs_memory_usage",
+		map[string]interface{}{"value": 1024.0},
+		map[string]string{"job": "web", "task_group": "frontend", "task": "nginx"})
+	acc.AssertContainsTaggedFields(t, "nomad_nomad_rpc_query",
+		map[string]interface{}{
+			"count":  int64(2),
+			"rate":   0.2,
+			"sum":    2.0,
+			"min":    1.0,
+			"max":    1.0,
+			"mean":   1.0,
+			"stddev": 0.0,
+		},
+		map[string]string{})
+	assert.True(t, acc.HasMeasurement("nomad_memberlist_gossip"))
+
+	acc.AssertContainsTaggedFields(t, "nomad_job_summary",
+		map[string]interface{}{
+			"queued":   int64(1),
+			"starting": int64(1),
+			"running":  int64(3),
+			"complete": int64(0),
+			"failed":   int64(2),
+			"lost":     int64(0),
+			"status":   "running",
+		},
+		map[string]string{
+			"job":        "web",
+			"task_group": "frontend",
+			"type":       "service",
+			"namespace":  "default",
+		})
+}
+
+func TestGatherNamespaces(t *testing.T) {
+	ts := newServer(t)
+	defer ts.Close()
+
+	n := &Nomad{
+		URL:          ts.URL,
+		Token:        "secret",
+		JobSummaries: true,
+		Namespaces:   []string{"prod-*"},
+		Timeout:      internal.Duration{Duration: time.Second},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(n.Gather))
+
+	var namespaces []string
+	for _, m := range acc.Metrics {
+		if m.Measurement == "nomad_job_summary" {
+			namespaces = append(namespaces, m.Tags["namespace"])
+		}
+	}
+	assert.Equal(t, []string{"prod-eu", "prod-us"}, namespaces)
+}
+
+func TestGatherError(t *testing.T) {
+	ts := newServer(t)
+	defer ts.Close()
+
+	n := &Nomad{
+		URL:     ts.URL + "/unknown",
+		Token:   "secret",
+		Timeout: internal.Duration{Duration: time.Second},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, n.Gather(&acc))
+	require.Len(t, acc.Errors, 1)
+	assert.Contains(t, acc.Errors[0].Error(), "status-code 404")
+}
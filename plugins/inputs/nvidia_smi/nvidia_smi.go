@@ -1,7 +1,7 @@
 package nvidia_smi
 
 import (
-	"bufio"
+	"encoding/xml"
 	"fmt"
 	"os"
 	"os/exec"
@@ -14,31 +14,12 @@ import (
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
-var (
-	measurement = "nvidia_smi"
-	metrics     = "fan.speed,memory.total,memory.used,memory.free,pstate,temperature.gpu,name,uuid,compute_mode,utilization.gpu,utilization.memory,index"
-	metricNames = [][]string{
-		[]string{"fan_speed", "field"},
-		[]string{"memory_total", "field"},
-		[]string{"memory_used", "field"},
-		[]string{"memory_free", "field"},
-		[]string{"pstate", "tag"},
-		[]string{"temperature_gpu", "field"},
-		[]string{"name", "tag"},
-		[]string{"uuid", "tag"},
-		[]string{"compute_mode", "tag"},
-		[]string{"utilization_gpu", "field"},
-		[]string{"utilization_memory", "field"},
-		[]string{"index", "tag"},
-	}
-)
+const measurement = "nvidia_smi"
 
 // NvidiaSMI holds the methods for this plugin
 type NvidiaSMI struct {
 	BinPath string
 	Timeout internal.Duration
-
-	metrics string
 }
 
 // Description returns the description of the NvidiaSMI plugin
@@ -82,73 +63,187 @@ func init() {
 		return &NvidiaSMI{
 			BinPath: "/usr/bin/nvidia-smi",
 			Timeout: internal.Duration{Duration: 5 * time.Second},
-			metrics: metrics,
 		}
 	})
 }
 
-func (smi *NvidiaSMI) pollSMI() (string, error) {
+func (smi *NvidiaSMI) pollSMI() ([]byte, error) {
 	// Construct and execute metrics query
-	opts := []string{"--format=noheader,nounits,csv", fmt.Sprintf("--query-gpu=%s", smi.metrics)}
-	ret, err := internal.CombinedOutputTimeout(exec.Command(smi.BinPath, opts...), smi.Timeout.Duration)
+	ret, err := internal.CombinedOutputTimeout(exec.Command(smi.BinPath, "-q", "-x"), smi.Timeout.Duration)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	return string(ret), nil
+	return ret, nil
 }
 
-func gatherNvidiaSMI(ret string, acc telegraf.Accumulator) error {
-	// First split the lines up and handle each one
-	scanner := bufio.NewScanner(strings.NewReader(ret))
-	for scanner.Scan() {
-		tags, fields, err := parseLine(scanner.Text())
-		if err != nil {
-			return err
+func gatherNvidiaSMI(ret []byte, acc telegraf.Accumulator) error {
+	smi := &SMI{}
+	err := xml.Unmarshal(ret, smi)
+	if err != nil {
+		return fmt.Errorf("Error parsing nvidia-smi output: %s", err)
+	}
+
+	for i, gpu := range smi.GPU {
+		tags := map[string]string{
+			"index": strconv.Itoa(i),
 		}
+		setTagIfUsed(tags, "pstate", gpu.PState)
+		setTagIfUsed(tags, "name", gpu.ProdName)
+		setTagIfUsed(tags, "uuid", gpu.UUID)
+		setTagIfUsed(tags, "compute_mode", gpu.ComputeMode)
+
+		fields := make(map[string]interface{})
+		setIfUsed("int", fields, "fan_speed", gpu.FanSpeed)
+		setIfUsed("int", fields, "memory_total", gpu.Memory.Total)
+		setIfUsed("int", fields, "memory_used", gpu.Memory.Used)
+		setIfUsed("int", fields, "memory_free", gpu.Memory.Free)
+		setIfUsed("int", fields, "temperature_gpu", gpu.Temp.GPUTemp)
+		setIfUsed("int", fields, "utilization_gpu", gpu.Utilization.GPU)
+		setIfUsed("int", fields, "utilization_memory", gpu.Utilization.Memory)
+		setIfUsed("int", fields, "utilization_encoder", gpu.Utilization.Encoder)
+		setIfUsed("int", fields, "utilization_decoder", gpu.Utilization.Decoder)
+		setIfUsed("float", fields, "power_draw", gpu.Power.PowerDraw)
+		setIfUsed("float", fields, "power_limit", gpu.Power.PowerLimit)
+		setIfUsed("int", fields, "clocks_current_graphics", gpu.Clocks.Graphics)
+		setIfUsed("int", fields, "clocks_current_sm", gpu.Clocks.SM)
+		setIfUsed("int", fields, "clocks_current_memory", gpu.Clocks.Memory)
+		setIfUsed("int", fields, "clocks_current_video", gpu.Clocks.Video)
+		setIfUsed("int", fields, "clocks_max_graphics", gpu.MaxClocks.Graphics)
+		setIfUsed("int", fields, "clocks_max_sm", gpu.MaxClocks.SM)
+		setIfUsed("int", fields, "clocks_max_memory", gpu.MaxClocks.Memory)
+		setIfUsed("int", fields, "clocks_max_video", gpu.MaxClocks.Video)
+		setIfUsed("int", fields, "ecc_volatile_single_bit", gpu.ECC.Volatile.SingleBit.Total)
+		setIfUsed("int", fields, "ecc_volatile_double_bit", gpu.ECC.Volatile.DoubleBit.Total)
+		setIfUsed("int", fields, "ecc_aggregate_single_bit", gpu.ECC.Aggregate.SingleBit.Total)
+		setIfUsed("int", fields, "ecc_aggregate_double_bit", gpu.ECC.Aggregate.DoubleBit.Total)
+
 		acc.AddFields(measurement, fields, tags)
-	}
 
-	if err := scanner.Err(); err != nil {
-		return fmt.Errorf("Error scanning text %s", ret)
+		for _, proc := range gpu.Processes {
+			procTags := map[string]string{
+				"index": tags["index"],
+				"pid":   proc.PID,
+			}
+			setTagIfUsed(procTags, "uuid", gpu.UUID)
+			setTagIfUsed(procTags, "name", gpu.ProdName)
+			setTagIfUsed(procTags, "process_name", proc.Name)
+			setTagIfUsed(procTags, "type", proc.Type)
+
+			procFields := make(map[string]interface{})
+			setIfUsed("int", procFields, "used_memory", proc.UsedMemory)
+			if len(procFields) > 0 {
+				acc.AddFields(measurement+"_process", procFields, procTags)
+			}
+		}
 	}
 
 	return nil
 }
 
-func parseLine(line string) (map[string]string, map[string]interface{}, error) {
-	tags := make(map[string]string, 0)
-	fields := make(map[string]interface{}, 0)
+func setTagIfUsed(m map[string]string, k, v string) {
+	if v != "" {
+		m[k] = v
+	}
+}
 
-	// Next split up the comma delimited metrics
-	met := strings.Split(line, ",")
+// setIfUsed parses the value of a field, such as "8114 MiB" or "133.22 W",
+// values that are not available or not supported are skipped.
+func setIfUsed(t string, m map[string]interface{}, k, v string) {
+	vals := strings.Fields(v)
+	if len(vals) < 1 {
+		return
+	}
 
-	// Make sure there are as many metrics in the line as there were queried.
-	if len(met) == len(metricNames) {
-		for i, m := range metricNames {
-			col := strings.TrimSpace(met[i])
+	val := vals[0]
 
-			// First handle the tags
-			if m[1] == "tag" {
-				tags[m[0]] = col
-				continue
-			}
+	switch t {
+	case "float":
+		if f, err := strconv.ParseFloat(val, 64); err == nil {
+			m[k] = f
+		}
+	case "int":
+		if i, err := strconv.ParseInt(val, 10, 64); err == nil {
+			m[k] = i
+		}
+	}
+}
 
-			if strings.Contains(col, "[Not Supported]") {
-				continue
-			}
+// SMI defines the structure for the output of _nvidia-smi -q -x_.
+type SMI struct {
+	GPU []GPU `xml:"gpu"`
+}
 
-			// Then parse the integers out of the fields
-			out, err := strconv.ParseInt(col, 10, 64)
-			if err != nil {
-				return tags, fields, err
-			}
-			fields[m[0]] = out
-		}
+// GPU defines the structure of the GPU portion of the smi output.
+type GPU struct {
+	FanSpeed    string           `xml:"fan_speed"`
+	Memory      MemoryStats      `xml:"fb_memory_usage"`
+	PState      string           `xml:"performance_state"`
+	Temp        TempStats        `xml:"temperature"`
+	ProdName    string           `xml:"product_name"`
+	UUID        string           `xml:"uuid"`
+	ComputeMode string           `xml:"compute_mode"`
+	Utilization UtilizationStats `xml:"utilization"`
+	Power       PowerReadings    `xml:"power_readings"`
+	Clocks      ClockStats       `xml:"clocks"`
+	MaxClocks   ClockStats       `xml:"max_clocks"`
+	ECC         ECCErrors        `xml:"ecc_errors"`
+	Processes   []ProcessInfo    `xml:"processes>process_info"`
+}
 
-		// Return the tags and fields
-		return tags, fields, nil
-	}
+// MemoryStats defines the structure of the memory portions in the smi output.
+type MemoryStats struct {
+	Total string `xml:"total"`
+	Used  string `xml:"used"`
+	Free  string `xml:"free"`
+}
+
+// TempStats defines the structure of the temperature portion of the smi output.
+type TempStats struct {
+	GPUTemp string `xml:"gpu_temp"`
+}
+
+// UtilizationStats defines the structure of the utilization portion of the smi output.
+type UtilizationStats struct {
+	GPU     string `xml:"gpu_util"`
+	Memory  string `xml:"memory_util"`
+	Encoder string `xml:"encoder_util"`
+	Decoder string `xml:"decoder_util"`
+}
+
+// PowerReadings defines the structure of the power_readings portion of the smi output.
+type PowerReadings struct {
+	PowerDraw  string `xml:"power_draw"`
+	PowerLimit string `xml:"power_limit"`
+}
+
+// ClockStats defines the structure of the clocks portions of the smi output.
+type ClockStats struct {
+	Graphics string `xml:"graphics_clock"`
+	SM       string `xml:"sm_clock"`
+	Memory   string `xml:"mem_clock"`
+	Video    string `xml:"video_clock"`
+}
+
+// ECCErrors defines the structure of the ecc_errors portion of the smi output.
+type ECCErrors struct {
+	Volatile  ECCErrorCounts `xml:"volatile"`
+	Aggregate ECCErrorCounts `xml:"aggregate"`
+}
+
+// ECCErrorCounts defines the structure of the ECC error counters.
+type ECCErrorCounts struct {
+	SingleBit struct {
+		Total string `xml:"total"`
+	} `xml:"single_bit"`
+	DoubleBit struct {
+		Total string `xml:"total"`
+	} `xml:"double_bit"`
+}
 
-	// If the line is empty return an emptyline error
-	return tags, fields, fmt.Errorf("Different number of metrics returned (%d) than expeced (%d)", len(met), len(metricNames))
+// ProcessInfo defines the structure of the process_info portion of the smi output.
+type ProcessInfo struct {
+	PID        string `xml:"pid"`
+	Type       string `xml:"type"`
+	Name       string `xml:"process_name"`
+	UsedMemory string `xml:"used_memory"`
 }
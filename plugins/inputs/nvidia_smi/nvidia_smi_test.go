@@ -1,44 +1,98 @@
 package nvidia_smi
 
 import (
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-func TestParseLineStandard(t *testing.T) {
-	line := "85, 8114, 553, 7561, P2, 61, GeForce GTX 1070 Ti, GPU-d1911b8a-f5c8-5e66-057c-486561269de8, Default, 100, 93, 1\n"
-	tags, fields, err := parseLine(line)
-	if err != nil {
-		t.Fail()
-	}
-	if tags["name"] != "GeForce GTX 1070 Ti" {
-		t.Fail()
-	}
-	if temp, ok := fields["temperature_gpu"].(int); ok && temp == 61 {
-		t.Fail()
-	}
+func TestGatherValidXML(t *testing.T) {
+	octets, err := ioutil.ReadFile(filepath.Join("testdata", "gtx-1070-ti.xml"))
+	require.NoError(t, err)
+
+	var acc testutil.Accumulator
+	require.NoError(t, gatherNvidiaSMI(octets, &acc))
+
+	acc.AssertContainsTaggedFields(t, "nvidia_smi",
+		map[string]interface{}{
+			"fan_speed":               int64(100),
+			"memory_total":            int64(8114),
+			"memory_used":             int64(553),
+			"memory_free":             int64(7561),
+			"temperature_gpu":         int64(61),
+			"utilization_gpu":         int64(100),
+			"utilization_memory":      int64(93),
+			"utilization_encoder":     int64(0),
+			"utilization_decoder":     int64(0),
+			"power_draw":              133.22,
+			"power_limit":             180.0,
+			"clocks_current_graphics": int64(1911),
+			"clocks_current_sm":       int64(1911),
+			"clocks_current_memory":   int64(3802),
+			"clocks_current_video":    int64(1708),
+			"clocks_max_graphics":     int64(1974),
+			"clocks_max_sm":           int64(1974),
+			"clocks_max_memory":       int64(4004),
+			"clocks_max_video":        int64(1708),
+		},
+		map[string]string{
+			"index":        "0",
+			"name":         "GeForce GTX 1070 Ti",
+			"uuid":         "GPU-f9ba66fc-a7f5-94c5-da19-019ef2f9c665",
+			"pstate":       "P2",
+			"compute_mode": "Default",
+		})
+
+	acc.AssertContainsTaggedFields(t, "nvidia_smi_process",
+		map[string]interface{}{
+			"used_memory": int64(541),
+		},
+		map[string]string{
+			"index":        "0",
+			"name":         "GeForce GTX 1070 Ti",
+			"uuid":         "GPU-f9ba66fc-a7f5-94c5-da19-019ef2f9c665",
+			"pid":          "2104",
+			"process_name": "python3",
+			"type":         "C",
+		})
 }
 
-func TestParseLineEmptyLine(t *testing.T) {
-	line := "\n"
-	_, _, err := parseLine(line)
-	if err == nil {
-		t.Fail()
-	}
+func TestGatherECC(t *testing.T) {
+	octets, err := ioutil.ReadFile(filepath.Join("testdata", "tesla-v100.xml"))
+	require.NoError(t, err)
+
+	var acc testutil.Accumulator
+	require.NoError(t, gatherNvidiaSMI(octets, &acc))
+
+	require.Len(t, acc.Metrics, 1)
+	m := acc.Metrics[0]
+	assert.Equal(t, int64(0), m.Fields["ecc_volatile_single_bit"])
+	assert.Equal(t, int64(0), m.Fields["ecc_volatile_double_bit"])
+	assert.Equal(t, int64(2), m.Fields["ecc_aggregate_single_bit"])
+	assert.Equal(t, int64(0), m.Fields["ecc_aggregate_double_bit"])
+
+	// Not available on passively cooled GPUs
+	assert.NotContains(t, m.Fields, "fan_speed")
 }
 
-func TestParseLineBad(t *testing.T) {
-	line := "the quick brown fox jumped over the lazy dog"
-	_, _, err := parseLine(line)
-	if err == nil {
-		t.Fail()
-	}
+func TestGatherInvalidXML(t *testing.T) {
+	var acc testutil.Accumulator
+	require.Error(t, gatherNvidiaSMI([]byte("the quick brown fox jumped over the lazy dog"), &acc))
 }
 
-func TestParseLineNotSupported(t *testing.T) {
-	line := "[Not Supported], 7606, 0, 7606, P0, 38, Tesla P4, GPU-xxx, Default, 0, 0, 0\n"
-	_, fields, err := parseLine(line)
-	require.NoError(t, err)
-	require.Equal(t, nil, fields["fan_speed"])
+func TestSetIfUsed(t *testing.T) {
+	fields := make(map[string]interface{})
+	setIfUsed("int", fields, "fan_speed", "[Not Supported]")
+	setIfUsed("int", fields, "memory_used", "N/A")
+	setIfUsed("int", fields, "memory_free", "")
+	require.Empty(t, fields)
+
+	setIfUsed("int", fields, "memory_used", "553 MiB")
+	setIfUsed("float", fields, "power_draw", "133.22 W")
+	require.Equal(t, int64(553), fields["memory_used"])
+	require.Equal(t, 133.22, fields["power_draw"])
 }
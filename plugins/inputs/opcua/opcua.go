@@ -0,0 +1,519 @@
+package opcua
+
+import (
+	"context"
+	"crypto/rsa"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gopcua/opcua"
+	"github.com/gopcua/opcua/ua"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+// OpcUA type
+type OpcUA struct {
+	Name           string            `toml:"name"`
+	Endpoint       string            `toml:"endpoint"`
+	SecurityPolicy string            `toml:"security_policy"`
+	SecurityMode   string            `toml:"security_mode"`
+	Certificate    string            `toml:"certificate"`
+	PrivateKey     string            `toml:"private_key"`
+	Username       string            `toml:"username"`
+	Password       string            `toml:"password"`
+	AuthMethod     string            `toml:"auth_method"`
+	ConnectTimeout internal.Duration `toml:"connect_timeout"`
+	RequestTimeout internal.Duration `toml:"request_timeout"`
+	SessionTimeout internal.Duration `toml:"session_timeout"`
+	Mode           string            `toml:"mode"`
+	Interval       internal.Duration `toml:"subscription_interval"`
+	Nodes          []OPCTag          `toml:"nodes"`
+
+	nodeIDs []*ua.NodeID
+	client  *opcua.Client
+	acc     telegraf.Accumulator
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// OPCTag type
+type OPCTag struct {
+	Name           string     `toml:"name"`
+	Namespace      string     `toml:"namespace"`
+	IdentifierType string     `toml:"identifier_type"`
+	Identifier     string     `toml:"identifier"`
+	Tags           [][]string `toml:"tags"`
+}
+
+const (
+	modeRead      = "read"
+	modeSubscribe = "subscribe"
+)
+
+const sampleConfig = `
+  ## Device name, added as the device tag
+  # name = "localhost"
+
+  ## OPC UA Endpoint URL
+  # endpoint = "opc.tcp://localhost:4840"
+
+  ## Maximum time allowed to establish a connection to the endpoint.
+  # connect_timeout = "10s"
+
+  ## Maximum time allowed for a request over the established connection.
+  # request_timeout = "5s"
+
+  ## Maximum time the session is kept by the server without activity, the
+  ## session is recreated when it expired.
+  # session_timeout = "20m"
+
+  ## Security policy, one of "None", "Basic128Rsa15", "Basic256",
+  ## "Basic256Sha256", or "auto"
+  # security_policy = "auto"
+
+  ## Security mode, one of "None", "Sign", "SignAndEncrypt", or "auto"
+  # security_mode = "auto"
+
+  ## Path to cert.pem. Required when security mode or policy isn't "None".
+  # certificate = "/etc/telegraf/cert.pem"
+
+  ## Path to private key.pem. Required when security mode or policy isn't "None".
+  # private_key = "/etc/telegraf/key.pem"
+
+  ## Authentication Method, one of "Certificate", "UserName", or "Anonymous".
+  ## The certificate and private key above are used with "Certificate".
+  # auth_method = "Anonymous"
+
+  ## Username and password, required for auth_method = "UserName"
+  # username = ""
+  # password = ""
+
+  ## Read the nodes at each interval with "read", or subscribe to the changes
+  ## of their values with "subscribe".
+  # mode = "read"
+
+  ## Publishing interval of the subscription, with mode = "subscribe"
+  # subscription_interval = "1s"
+
+  ## Node ID configuration
+  ## name            - field name to use in the output
+  ## namespace       - OPC UA namespace of the node (integer value 0 thru 3)
+  ## identifier_type - OPC UA ID type (s=string, i=numeric, g=guid, b=opaque)
+  ## identifier      - OPC UA ID (tag as shown in opcua browser)
+  ## tags            - extra tags to be added to the output metric (optional)
+  ## Example:
+  ## {name="ProductUri", namespace="0", identifier_type="i", identifier="2262", tags=[["tag1","value1"],["tag2","value2"]]}
+  nodes = [
+    {name="", namespace="", identifier_type="", identifier=""},
+  ]
+`
+
+// Description will appear directly above the plugin definition in the config file
+func (o *OpcUA) Description() string {
+	return "Retrieve data from OPCUA devices"
+}
+
+// SampleConfig will populate the sample configuration portion of the plugin's configuration
+func (o *OpcUA) SampleConfig() string {
+	return sampleConfig
+}
+
+// validate checks the configuration and parses the node IDs
+func (o *OpcUA) validate() error {
+	switch o.Mode {
+	case "":
+		o.Mode = modeRead
+	case modeRead, modeSubscribe:
+	default:
+		return fmt.Errorf("invalid mode %q, must be %q or %q", o.Mode, modeRead, modeSubscribe)
+	}
+
+	switch o.AuthMethod {
+	case "", "Anonymous":
+	case "UserName":
+		if o.Username == "" {
+			return fmt.Errorf("username is required with auth_method %q", o.AuthMethod)
+		}
+	case "Certificate":
+		if o.Certificate == "" || o.PrivateKey == "" {
+			return fmt.Errorf("certificate and private_key are required with auth_method %q", o.AuthMethod)
+		}
+	default:
+		return fmt.Errorf("invalid auth_method %q", o.AuthMethod)
+	}
+
+	switch o.SecurityPolicy {
+	case "", "auto", "None", "Basic128Rsa15", "Basic256", "Basic256Sha256":
+	default:
+		return fmt.Errorf("invalid security_policy %q", o.SecurityPolicy)
+	}
+
+	switch o.SecurityMode {
+	case "", "auto", "None", "Sign", "SignAndEncrypt":
+	default:
+		return fmt.Errorf("invalid security_mode %q", o.SecurityMode)
+	}
+
+	if len(o.Nodes) == 0 {
+		return fmt.Errorf("no nodes configured")
+	}
+
+	names := make(map[string]bool)
+	o.nodeIDs = make([]*ua.NodeID, 0, len(o.Nodes))
+	for _, node := range o.Nodes {
+		if node.Name == "" {
+			return fmt.Errorf("empty name in node %s", node.nodeID())
+		}
+		if names[node.Name] {
+			return fmt.Errorf("name %q is duplicated", node.Name)
+		}
+		names[node.Name] = true
+
+		for _, tag := range node.Tags {
+			if len(tag) != 2 {
+				return fmt.Errorf("tag %v of node %q must be a key and a value", tag, node.Name)
+			}
+		}
+
+		switch node.IdentifierType {
+		case "s", "i", "g", "b":
+		default:
+			return fmt.Errorf("invalid identifier_type %q of node %q, must be s, i, g or b", node.IdentifierType, node.Name)
+		}
+
+		id, err := ua.ParseNodeID(node.nodeID())
+		if err != nil {
+			return fmt.Errorf("invalid node id of node %q: %s", node.Name, err)
+		}
+		o.nodeIDs = append(o.nodeIDs, id)
+	}
+
+	return nil
+}
+
+// nodeID returns the string form of the node ID, ie ns=3;s=Temperature
+func (t *OPCTag) nodeID() string {
+	return fmt.Sprintf("ns=%s;%s=%s", t.Namespace, t.IdentifierType, t.Identifier)
+}
+
+// Start connects to the server and subscribes to the nodes in subscribe mode.
+// In read mode, the connection is established at the first interval.
+func (o *OpcUA) Start(acc telegraf.Accumulator) error {
+	o.acc = acc
+	if err := o.validate(); err != nil {
+		return err
+	}
+
+	if o.Mode != modeSubscribe {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	o.cancel = cancel
+
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		o.subscribe(ctx)
+	}()
+	return nil
+}
+
+// Stop closes the connection to the server
+func (o *OpcUA) Stop() {
+	if o.cancel != nil {
+		o.cancel()
+	}
+	o.wg.Wait()
+	o.disconnect()
+}
+
+// Gather reads the nodes in read mode, reconnecting when the previous read
+// failed.
+func (o *OpcUA) Gather(acc telegraf.Accumulator) error {
+	if o.Mode == modeSubscribe {
+		return nil
+	}
+
+	if o.client == nil {
+		if err := o.connect(); err != nil {
+			return err
+		}
+	}
+
+	req := &ua.ReadRequest{
+		MaxAge:             2000,
+		NodesToRead:        make([]*ua.ReadValueID, 0, len(o.nodeIDs)),
+		TimestampsToReturn: ua.TimestampsToReturnBoth,
+	}
+	for _, id := range o.nodeIDs {
+		req.NodesToRead = append(req.NodesToRead, &ua.ReadValueID{NodeID: id})
+	}
+
+	resp, err := o.client.Read(req)
+	if err != nil {
+		// reconnect at the next interval
+		o.disconnect()
+		return fmt.Errorf("read of %s failed: %s", o.Endpoint, err)
+	}
+	if len(resp.Results) != len(o.Nodes) {
+		return fmt.Errorf("read of %s returned %d values for %d nodes", o.Endpoint, len(resp.Results), len(o.Nodes))
+	}
+
+	for i, value := range resp.Results {
+		o.addValue(acc, i, value)
+	}
+	return nil
+}
+
+// subscribe creates a subscription to the nodes and adds their values as
+// they change.  The connection and the subscription are recreated when the
+// session or the secure channel fail.
+func (o *OpcUA) subscribe(ctx context.Context) {
+	for {
+		if err := o.runSubscription(ctx); err != nil {
+			o.acc.AddError(err)
+		}
+		o.disconnect()
+
+		// wait before reconnecting
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(o.ConnectTimeout.Duration):
+		}
+	}
+}
+
+func (o *OpcUA) runSubscription(ctx context.Context) error {
+	if err := o.connect(); err != nil {
+		return err
+	}
+
+	notifs := make(chan *opcua.PublishNotificationData)
+	sub, err := o.client.Subscribe(&opcua.SubscriptionParameters{
+		Interval: o.Interval.Duration,
+		Notifs:   notifs,
+	})
+	if err != nil {
+		return fmt.Errorf("subscription to %s failed: %s", o.Endpoint, err)
+	}
+	defer sub.Cancel()
+
+	items := make([]*ua.MonitoredItemCreateRequest, 0, len(o.nodeIDs))
+	for i, id := range o.nodeIDs {
+		items = append(items, opcua.NewMonitoredItemCreateRequestWithDefaults(id, ua.AttributeIDValue, uint32(i)))
+	}
+	res, err := sub.Monitor(ua.TimestampsToReturnBoth, items...)
+	if err != nil {
+		return fmt.Errorf("monitoring of the nodes of %s failed: %s", o.Endpoint, err)
+	}
+	for i, result := range res.Results {
+		if result.StatusCode != ua.StatusOK {
+			o.acc.AddError(fmt.Errorf("monitoring of node %q failed: %s", o.Nodes[i].Name, result.StatusCode))
+		}
+	}
+
+	subCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go sub.Run(subCtx)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case n := <-notifs:
+			if n.Error != nil {
+				return fmt.Errorf("subscription to %s failed: %s", o.Endpoint, n.Error)
+			}
+			notification, ok := n.Value.(*ua.DataChangeNotification)
+			if !ok {
+				continue
+			}
+			for _, item := range notification.MonitoredItems {
+				i := int(item.ClientHandle)
+				if i < len(o.Nodes) {
+					o.addValue(o.acc, i, item.Value)
+				}
+			}
+		}
+	}
+}
+
+// connect selects the endpoint matching the security policy and mode, then
+// establishes the secure channel and the session.
+func (o *OpcUA) connect() error {
+	opts, err := o.clientOptions()
+	if err != nil {
+		return err
+	}
+
+	client := opcua.NewClient(o.Endpoint, opts...)
+	ctx, cancel := context.WithTimeout(context.Background(), o.ConnectTimeout.Duration)
+	defer cancel()
+	if err := client.Connect(ctx); err != nil {
+		return fmt.Errorf("connection to %s failed: %s", o.Endpoint, err)
+	}
+
+	o.client = client
+	return nil
+}
+
+func (o *OpcUA) disconnect() {
+	if o.client == nil {
+		return
+	}
+	if err := o.client.Close(); err != nil {
+		log.Printf("D! [inputs.opcua] error closing connection to %s: %s", o.Endpoint, err)
+	}
+	o.client = nil
+}
+
+func (o *OpcUA) clientOptions() ([]opcua.Option, error) {
+	opts := []opcua.Option{
+		opcua.RequestTimeout(o.RequestTimeout.Duration),
+		opcua.SessionTimeout(o.SessionTimeout.Duration),
+	}
+
+	var cert []byte
+	if o.Certificate != "" && o.PrivateKey != "" {
+		keyPair, err := tls.LoadX509KeyPair(o.Certificate, o.PrivateKey)
+		if err != nil {
+			return nil, fmt.Errorf("error loading certificate: %s", err)
+		}
+		key, ok := keyPair.PrivateKey.(*rsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("the private key of %s is not an RSA key", o.PrivateKey)
+		}
+		cert = keyPair.Certificate[0]
+		opts = append(opts, opcua.Certificate(cert), opcua.PrivateKey(key))
+	}
+
+	endpoints, err := opcua.GetEndpoints(o.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("error getting the endpoints of %s: %s", o.Endpoint, err)
+	}
+
+	policy := o.SecurityPolicy
+	if policy == "auto" {
+		policy = ""
+	}
+	mode := ua.MessageSecurityModeInvalid
+	if o.SecurityMode != "" && o.SecurityMode != "auto" {
+		mode = ua.MessageSecurityModeFromString(o.SecurityMode)
+	} else if cert == nil && policy == "" {
+		// without certificate, only the endpoints without security are usable
+		mode = ua.MessageSecurityModeNone
+	}
+	endpoint := opcua.SelectEndpoint(endpoints, policy, mode)
+	if endpoint == nil {
+		return nil, fmt.Errorf("no endpoint of %s matches the security policy %q and mode %q",
+			o.Endpoint, o.SecurityPolicy, o.SecurityMode)
+	}
+
+	if endpoint.SecurityMode != ua.MessageSecurityModeNone && cert == nil {
+		return nil, fmt.Errorf("certificate and private_key are required with security mode %s", endpoint.SecurityMode)
+	}
+
+	var authType ua.UserTokenType
+	switch o.AuthMethod {
+	case "UserName":
+		authType = ua.UserTokenTypeUserName
+		opts = append(opts, opcua.AuthUsername(o.Username, o.Password))
+	case "Certificate":
+		authType = ua.UserTokenTypeCertificate
+		opts = append(opts, opcua.AuthCertificate(cert))
+	default:
+		authType = ua.UserTokenTypeAnonymous
+		opts = append(opts, opcua.AuthAnonymous())
+	}
+	opts = append(opts, opcua.SecurityFromEndpoint(endpoint, authType))
+
+	return opts, nil
+}
+
+// addValue adds the value of the node with index i.  The status of the value
+// is added in the quality field.
+func (o *OpcUA) addValue(acc telegraf.Accumulator, i int, value *ua.DataValue) {
+	node := o.Nodes[i]
+
+	tags := map[string]string{
+		"id": node.nodeID(),
+	}
+	if o.Name != "" {
+		tags["device"] = o.Name
+	}
+	for _, tag := range node.Tags {
+		tags[tag[0]] = tag[1]
+	}
+
+	fields := map[string]interface{}{
+		"quality": strings.TrimSpace(value.Status.Error()),
+	}
+	if value.Status == ua.StatusOK && value.Value != nil {
+		if v := convertValue(value.Value.Value()); v != nil {
+			fields[node.Name] = v
+		}
+	}
+
+	t := value.SourceTimestamp
+	if t.IsZero() {
+		t = value.ServerTimestamp
+	}
+	if t.IsZero() {
+		t = time.Now()
+	}
+
+	acc.AddFields("opcua", fields, tags, t)
+}
+
+// convertValue converts the value of a variant to a field value
+func convertValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case bool, string, int64, uint64, float64:
+		return value
+	case int8:
+		return int64(value)
+	case int16:
+		return int64(value)
+	case int32:
+		return int64(value)
+	case uint8:
+		return uint64(value)
+	case uint16:
+		return uint64(value)
+	case uint32:
+		return uint64(value)
+	case float32:
+		return float64(value)
+	case time.Time:
+		return value.UnixNano()
+	case nil:
+		return nil
+	default:
+		return fmt.Sprint(value)
+	}
+}
+
+func init() {
+	inputs.Add("opcua", func() telegraf.Input {
+		return &OpcUA{
+			Name:           "localhost",
+			Endpoint:       "opc.tcp://localhost:4840",
+			SecurityPolicy: "auto",
+			SecurityMode:   "auto",
+			AuthMethod:     "Anonymous",
+			Mode:           modeRead,
+			ConnectTimeout: internal.Duration{Duration: 10 * time.Second},
+			RequestTimeout: internal.Duration{Duration: 5 * time.Second},
+			SessionTimeout: internal.Duration{Duration: 20 * time.Minute},
+			Interval:       internal.Duration{Duration: time.Second},
+		}
+	})
+}
@@ -0,0 +1,161 @@
+package opcua
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gopcua/opcua/ua"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	o := &OpcUA{
+		Nodes: []OPCTag{
+			{Name: "ProductName", Namespace: "0", IdentifierType: "i", Identifier: "2261"},
+			{Name: "Temperature", Namespace: "3", IdentifierType: "s", Identifier: "Temp.Sensor1",
+				Tags: [][]string{{"line", "2"}}},
+		},
+	}
+	require.NoError(t, o.validate())
+
+	assert.Equal(t, modeRead, o.Mode)
+	require.Len(t, o.nodeIDs, 2)
+	assert.Equal(t, "i=2261", o.nodeIDs[0].String())
+	assert.Equal(t, "ns=3;s=Temp.Sensor1", o.nodeIDs[1].String())
+}
+
+func TestValidateErrors(t *testing.T) {
+	valid := OPCTag{Name: "ProductName", Namespace: "0", IdentifierType: "i", Identifier: "2261"}
+
+	tests := []struct {
+		name   string
+		plugin *OpcUA
+	}{
+		{"no nodes", &OpcUA{}},
+		{"invalid mode", &OpcUA{Mode: "poll", Nodes: []OPCTag{valid}}},
+		{"invalid auth method", &OpcUA{AuthMethod: "Token", Nodes: []OPCTag{valid}}},
+		{"username missing", &OpcUA{AuthMethod: "UserName", Nodes: []OPCTag{valid}}},
+		{"certificate missing", &OpcUA{AuthMethod: "Certificate", Nodes: []OPCTag{valid}}},
+		{"invalid security policy", &OpcUA{SecurityPolicy: "Basic512", Nodes: []OPCTag{valid}}},
+		{"invalid security mode", &OpcUA{SecurityMode: "Encrypt", Nodes: []OPCTag{valid}}},
+		{"empty name", &OpcUA{Nodes: []OPCTag{
+			{Namespace: "0", IdentifierType: "i", Identifier: "2261"}}}},
+		{"duplicate name", &OpcUA{Nodes: []OPCTag{valid, valid}}},
+		{"invalid identifier type", &OpcUA{Nodes: []OPCTag{
+			{Name: "a", Namespace: "0", IdentifierType: "x", Identifier: "2261"}}}},
+		{"invalid identifier", &OpcUA{Nodes: []OPCTag{
+			{Name: "a", Namespace: "0", IdentifierType: "i", Identifier: "abc"}}}},
+		{"invalid tag", &OpcUA{Nodes: []OPCTag{
+			{Name: "a", Namespace: "0", IdentifierType: "i", Identifier: "2261",
+				Tags: [][]string{{"line"}}}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Error(t, tt.plugin.validate())
+		})
+	}
+}
+
+func TestConvertValue(t *testing.T) {
+	ts := time.Unix(1562000000, 0)
+
+	tests := []struct {
+		value    interface{}
+		expected interface{}
+	}{
+		{true, true},
+		{"running", "running"},
+		{int8(-8), int64(-8)},
+		{int16(-16), int64(-16)},
+		{int32(-32), int64(-32)},
+		{int64(-64), int64(-64)},
+		{uint8(8), uint64(8)},
+		{uint16(16), uint64(16)},
+		{uint32(32), uint64(32)},
+		{uint64(64), uint64(64)},
+		{float32(1.5), float64(1.5)},
+		{float64(2.5), float64(2.5)},
+		{ts, ts.UnixNano()},
+		{[]byte{1, 2}, "[1 2]"},
+		{nil, nil},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, convertValue(tt.value))
+	}
+}
+
+func TestAddValue(t *testing.T) {
+	o := &OpcUA{
+		Name: "plc1",
+		Nodes: []OPCTag{
+			{Name: "Temperature", Namespace: "3", IdentifierType: "s", Identifier: "Temp.Sensor1",
+				Tags: [][]string{{"line", "2"}}},
+			{Name: "Pressure", Namespace: "3", IdentifierType: "i", Identifier: "1002"},
+		},
+	}
+	require.NoError(t, o.validate())
+
+	ts := time.Unix(1562000000, 0)
+	var acc testutil.Accumulator
+	o.addValue(&acc, 0, &ua.DataValue{
+		Value:           ua.MustVariant(float32(21.5)),
+		Status:          ua.StatusOK,
+		SourceTimestamp: ts,
+	})
+	o.addValue(&acc, 1, &ua.DataValue{
+		Status:          ua.StatusBadNodeIDUnknown,
+		ServerTimestamp: ts,
+	})
+
+	acc.AssertContainsTaggedFields(t, "opcua",
+		map[string]interface{}{
+			"Temperature": float64(21.5),
+			"quality":     "OK (0x0)",
+		},
+		map[string]string{
+			"id":     "ns=3;s=Temp.Sensor1",
+			"device": "plc1",
+			"line":   "2",
+		})
+
+	acc.AssertContainsTaggedFields(t, "opcua",
+		map[string]interface{}{
+			"quality": ua.StatusBadNodeIDUnknown.Error(),
+		},
+		map[string]string{
+			"id":     "ns=3;i=1002",
+			"device": "plc1",
+		})
+
+	for _, m := range acc.Metrics {
+		assert.Equal(t, ts, m.Time)
+	}
+}
+
+func TestGatherConnectionError(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	o := &OpcUA{
+		Endpoint:       "opc.tcp://127.0.0.1:1",
+		Mode:           modeRead,
+		ConnectTimeout: internal.Duration{Duration: time.Second},
+		RequestTimeout: internal.Duration{Duration: time.Second},
+		Nodes: []OPCTag{
+			{Name: "ProductName", Namespace: "0", IdentifierType: "i", Identifier: "2261"},
+		},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, o.Start(&acc))
+	defer o.Stop()
+
+	require.Error(t, acc.GatherError(o.Gather))
+	assert.Equal(t, 0, len(acc.Metrics))
+}
@@ -5,7 +5,7 @@ import (
 	"strconv"
 	"strings"
 
-	"gopkg.in/ldap.v2"
+	"gopkg.in/ldap.v3"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal/tls"
@@ -15,11 +15,16 @@ import (
 type Openldap struct {
 	Host               string
 	Port               int
-	Ssl                string
+	SSL                string `toml:"ssl"` // Deprecated in 1.7; use TLS
+	TLS                string `toml:"tls"`
 	InsecureSkipVerify bool
-	SslCa              string
+	SSLCA              string `toml:"ssl_ca"` // Deprecated in 1.7; use TLSCA
+	TLSCA              string `toml:"tls_ca"`
+	TLSCert            string `toml:"tls_cert"`
+	TLSKey             string `toml:"tls_key"`
 	BindDn             string
 	BindPassword       string
+	SASLMechanism      string `toml:"sasl_mechanism"`
 	ReverseMetricNames bool
 }
 
@@ -30,7 +35,7 @@ const sampleConfig string = `
   # ldaps, starttls, or no encryption. default is an empty string, disabling all encryption.
   # note that port will likely need to be changed to 636 for ldaps
   # valid options: "" | "starttls" | "ldaps"
-  ssl = ""
+  tls = ""
 
   # skip peer certificate verification. Default is false.
   insecure_skip_verify = false
@@ -38,10 +43,19 @@ const sampleConfig string = `
   # Path to PEM-encoded Root certificate to use to verify server certificate
   tls_ca = "/etc/ssl/certs.pem"
 
+  # Client certificate and key, used by the EXTERNAL SASL mechanism
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+
   # dn/password to bind with. If bind_dn is empty, an anonymous bind is performed.
   bind_dn = ""
   bind_password = ""
 
+  # SASL mechanism to bind with instead of bind_dn/bind_password.  The only
+  # supported mechanism is "EXTERNAL", authenticating with the client
+  # certificate over ldaps or starttls.
+  # sasl_mechanism = ""
+
   # Reverse metric names so they sort more naturally. Recommended.
   # This defaults to false if unset, but is set to true when generating a new config
   reverse_metric_names = true
@@ -70,11 +84,14 @@ func NewOpenldap() *Openldap {
 	return &Openldap{
 		Host:               "localhost",
 		Port:               389,
-		Ssl:                "",
+		SSL:                "",
+		TLS:                "",
 		InsecureSkipVerify: false,
-		SslCa:              "",
+		SSLCA:              "",
+		TLSCA:              "",
 		BindDn:             "",
 		BindPassword:       "",
+		SASLMechanism:      "",
 		ReverseMetricNames: false,
 	}
 }
@@ -83,10 +100,26 @@ func NewOpenldap() *Openldap {
 func (o *Openldap) Gather(acc telegraf.Accumulator) error {
 	var err error
 	var l *ldap.Conn
-	if o.Ssl != "" {
+	if o.TLS == "" {
+		o.TLS = o.SSL
+	}
+	if o.TLSCA == "" {
+		o.TLSCA = o.SSLCA
+	}
+
+	switch o.SASLMechanism {
+	case "", "EXTERNAL":
+	default:
+		acc.AddError(fmt.Errorf("Unsupported SASL mechanism: %s", o.SASLMechanism))
+		return nil
+	}
+
+	if o.TLS != "" {
 		// build tls config
 		clientTLSConfig := tls.ClientConfig{
-			SSLCA:              o.SslCa,
+			TLSCA:              o.TLSCA,
+			TLSCert:            o.TLSCert,
+			TLSKey:             o.TLSKey,
 			InsecureSkipVerify: o.InsecureSkipVerify,
 		}
 		tlsConfig, err := clientTLSConfig.TLSConfig()
@@ -94,13 +127,13 @@ func (o *Openldap) Gather(acc telegraf.Accumulator) error {
 			acc.AddError(err)
 			return nil
 		}
-		if o.Ssl == "ldaps" {
+		if o.TLS == "ldaps" {
 			l, err = ldap.DialTLS("tcp", fmt.Sprintf("%s:%d", o.Host, o.Port), tlsConfig)
 			if err != nil {
 				acc.AddError(err)
 				return nil
 			}
-		} else if o.Ssl == "starttls" {
+		} else if o.TLS == "starttls" {
 			l, err = ldap.Dial("tcp", fmt.Sprintf("%s:%d", o.Host, o.Port))
 			if err != nil {
 				acc.AddError(err)
@@ -108,7 +141,7 @@ func (o *Openldap) Gather(acc telegraf.Accumulator) error {
 			}
 			err = l.StartTLS(tlsConfig)
 		} else {
-			acc.AddError(fmt.Errorf("Invalid setting for ssl: %s", o.Ssl))
+			acc.AddError(fmt.Errorf("Invalid setting for tls: %s", o.TLS))
 			return nil
 		}
 	} else {
@@ -121,8 +154,15 @@ func (o *Openldap) Gather(acc telegraf.Accumulator) error {
 	}
 	defer l.Close()
 
-	// username/password bind
-	if o.BindDn != "" && o.BindPassword != "" {
+	if o.SASLMechanism == "EXTERNAL" {
+		// SASL bind with the identity established by the client certificate
+		err = l.ExternalBind()
+		if err != nil {
+			acc.AddError(err)
+			return nil
+		}
+	} else if o.BindDn != "" && o.BindPassword != "" {
+		// username/password bind
 		err = l.Bind(o.BindDn, o.BindPassword)
 		if err != nil {
 			acc.AddError(err)
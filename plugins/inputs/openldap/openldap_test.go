@@ -1,7 +1,7 @@
 package openldap
 
 import (
-	"gopkg.in/ldap.v2"
+	"gopkg.in/ldap.v3"
 	"strconv"
 	"testing"
 
@@ -74,7 +74,7 @@ func TestOpenldapStartTLS(t *testing.T) {
 	o := &Openldap{
 		Host:               testutil.GetLocalHost(),
 		Port:               389,
-		Ssl:                "starttls",
+		TLS:                "starttls",
 		InsecureSkipVerify: true,
 	}
 
@@ -92,7 +92,7 @@ func TestOpenldapLDAPS(t *testing.T) {
 	o := &Openldap{
 		Host:               testutil.GetLocalHost(),
 		Port:               636,
-		Ssl:                "ldaps",
+		TLS:                "ldaps",
 		InsecureSkipVerify: true,
 	}
 
@@ -110,7 +110,7 @@ func TestOpenldapInvalidSSL(t *testing.T) {
 	o := &Openldap{
 		Host:               testutil.GetLocalHost(),
 		Port:               636,
-		Ssl:                "invalid",
+		TLS:                "invalid",
 		InsecureSkipVerify: true,
 	}
 
@@ -121,6 +121,38 @@ func TestOpenldapInvalidSSL(t *testing.T) {
 	assert.NotEmpty(t, acc.Errors) // test that we set an error
 }
 
+func TestOpenldapInvalidSASLMechanism(t *testing.T) {
+	o := &Openldap{
+		Host:          "localhost",
+		Port:          389,
+		SASLMechanism: "GSSAPI",
+	}
+
+	var acc testutil.Accumulator
+	err := o.Gather(&acc)
+	require.NoError(t, err)        // test that we didn't return an error
+	assert.Zero(t, acc.NFields())  // test that we didn't return any fields
+	assert.NotEmpty(t, acc.Errors) // test that we set an error
+}
+
+func TestOpenldapDeprecatedSSL(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration test in short mode")
+	}
+
+	o := &Openldap{
+		Host:               testutil.GetLocalHost(),
+		Port:               389,
+		SSL:                "starttls",
+		InsecureSkipVerify: true,
+	}
+
+	var acc testutil.Accumulator
+	err := o.Gather(&acc)
+	require.NoError(t, err)
+	commonTests(t, o, &acc)
+}
+
 func TestOpenldapBind(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration test in short mode")
@@ -129,7 +161,7 @@ func TestOpenldapBind(t *testing.T) {
 	o := &Openldap{
 		Host:               testutil.GetLocalHost(),
 		Port:               389,
-		Ssl:                "",
+		TLS:                "",
 		InsecureSkipVerify: true,
 		BindDn:             "cn=manager,cn=config",
 		BindPassword:       "secret",
@@ -157,7 +189,7 @@ func TestOpenldapReverseMetrics(t *testing.T) {
 	o := &Openldap{
 		Host:               testutil.GetLocalHost(),
 		Port:               389,
-		Ssl:                "",
+		TLS:                "",
 		InsecureSkipVerify: true,
 		BindDn:             "cn=manager,cn=config",
 		BindPassword:       "secret",
@@ -0,0 +1,273 @@
+package opentelemetry
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+// addMetrics adds the data points of the metrics, named after the metric.
+// The fields follow the ones of the prometheus input: gauge or counter for
+// the sums and the gauges, and the buckets or the quantiles with the count
+// and the sum for the histograms and the summaries.
+func (o *OpenTelemetry) addMetrics(resourceMetrics []*metricspb.ResourceMetrics) {
+	for _, rm := range resourceMetrics {
+		resourceTags := resourceTags(rm.Resource)
+		for _, sm := range rm.ScopeMetrics {
+			scopeTags := scopeTags(resourceTags, sm.Scope)
+			for _, m := range sm.Metrics {
+				o.addMetric(m, scopeTags)
+			}
+		}
+	}
+}
+
+func (o *OpenTelemetry) addMetric(m *metricspb.Metric, scopeTags map[string]string) {
+	switch data := m.Data.(type) {
+	case *metricspb.Metric_Gauge:
+		for _, dp := range data.Gauge.DataPoints {
+			if value, ok := numberValue(dp); ok {
+				fields := map[string]interface{}{"gauge": value}
+				o.acc.AddGauge(m.Name, fields, tags(scopeTags, dp.Attributes), timestamp(dp.TimeUnixNano))
+			}
+		}
+	case *metricspb.Metric_Sum:
+		for _, dp := range data.Sum.DataPoints {
+			value, ok := numberValue(dp)
+			if !ok {
+				continue
+			}
+			if data.Sum.IsMonotonic {
+				fields := map[string]interface{}{"counter": value}
+				o.acc.AddCounter(m.Name, fields, tags(scopeTags, dp.Attributes), timestamp(dp.TimeUnixNano))
+			} else {
+				fields := map[string]interface{}{"gauge": value}
+				o.acc.AddGauge(m.Name, fields, tags(scopeTags, dp.Attributes), timestamp(dp.TimeUnixNano))
+			}
+		}
+	case *metricspb.Metric_Histogram:
+		for _, dp := range data.Histogram.DataPoints {
+			// The buckets are cumulative, as for prometheus.
+			fields := make(map[string]interface{})
+			var count uint64
+			for i, bound := range dp.ExplicitBounds {
+				if i < len(dp.BucketCounts) {
+					count += dp.BucketCounts[i]
+				}
+				fields[fmt.Sprint(bound)] = float64(count)
+			}
+			fields[fmt.Sprint(math.Inf(1))] = float64(dp.Count)
+			addHistogramStats(fields, dp.Count, dp.Sum, dp.Min, dp.Max)
+			o.acc.AddHistogram(m.Name, fields, tags(scopeTags, dp.Attributes), timestamp(dp.TimeUnixNano))
+		}
+	case *metricspb.Metric_ExponentialHistogram:
+		// The exponential buckets are not converted, only their statistics.
+		for _, dp := range data.ExponentialHistogram.DataPoints {
+			fields := make(map[string]interface{})
+			addHistogramStats(fields, dp.Count, dp.Sum, dp.Min, dp.Max)
+			o.acc.AddHistogram(m.Name, fields, tags(scopeTags, dp.Attributes), timestamp(dp.TimeUnixNano))
+		}
+	case *metricspb.Metric_Summary:
+		for _, dp := range data.Summary.DataPoints {
+			fields := make(map[string]interface{})
+			for _, q := range dp.QuantileValues {
+				if !math.IsNaN(q.Value) {
+					fields[fmt.Sprint(q.Quantile)] = q.Value
+				}
+			}
+			fields["count"] = float64(dp.Count)
+			fields["sum"] = dp.Sum
+			o.acc.AddSummary(m.Name, fields, tags(scopeTags, dp.Attributes), timestamp(dp.TimeUnixNano))
+		}
+	}
+}
+
+func addHistogramStats(fields map[string]interface{}, count uint64, sum, min, max *float64) {
+	fields["count"] = float64(count)
+	if sum != nil {
+		fields["sum"] = *sum
+	}
+	if min != nil {
+		fields["min"] = *min
+	}
+	if max != nil {
+		fields["max"] = *max
+	}
+}
+
+func numberValue(dp *metricspb.NumberDataPoint) (interface{}, bool) {
+	switch v := dp.Value.(type) {
+	case *metricspb.NumberDataPoint_AsDouble:
+		if math.IsNaN(v.AsDouble) {
+			return nil, false
+		}
+		return v.AsDouble, true
+	case *metricspb.NumberDataPoint_AsInt:
+		return v.AsInt, true
+	}
+	return nil, false
+}
+
+// addSpans adds a "spans" metric for each span, with its duration.
+func (o *OpenTelemetry) addSpans(resourceSpans []*tracepb.ResourceSpans) {
+	for _, rs := range resourceSpans {
+		resourceTags := resourceTags(rs.Resource)
+		for _, ss := range rs.ScopeSpans {
+			scopeTags := scopeTags(resourceTags, ss.Scope)
+			for _, span := range ss.Spans {
+				tags := tags(scopeTags, span.Attributes)
+				tags["span_name"] = span.Name
+				tags["span_kind"] = span.Kind.String()
+				fields := map[string]interface{}{
+					"trace_id":    hex.EncodeToString(span.TraceId),
+					"span_id":     hex.EncodeToString(span.SpanId),
+					"duration_ns": int64(span.EndTimeUnixNano - span.StartTimeUnixNano),
+				}
+				if len(span.ParentSpanId) > 0 {
+					fields["parent_span_id"] = hex.EncodeToString(span.ParentSpanId)
+				}
+				if span.Status != nil {
+					tags["status_code"] = span.Status.Code.String()
+					if span.Status.Message != "" {
+						fields["status_message"] = span.Status.Message
+					}
+				}
+				o.acc.AddFields("spans", fields, tags, timestamp(span.StartTimeUnixNano))
+			}
+		}
+	}
+}
+
+// addLogs adds a "logs" metric for each log record, with its body as the
+// message field.
+func (o *OpenTelemetry) addLogs(resourceLogs []*logspb.ResourceLogs) {
+	for _, rl := range resourceLogs {
+		resourceTags := resourceTags(rl.Resource)
+		for _, sl := range rl.ScopeLogs {
+			scopeTags := scopeTags(resourceTags, sl.Scope)
+			for _, record := range sl.LogRecords {
+				tags := tags(scopeTags, record.Attributes)
+				if record.SeverityText != "" {
+					tags["severity_text"] = record.SeverityText
+				}
+				fields := map[string]interface{}{
+					"message":         attributeString(record.Body),
+					"severity_number": int64(record.SeverityNumber),
+				}
+				if len(record.TraceId) > 0 {
+					fields["trace_id"] = hex.EncodeToString(record.TraceId)
+				}
+				if len(record.SpanId) > 0 {
+					fields["span_id"] = hex.EncodeToString(record.SpanId)
+				}
+				t := record.TimeUnixNano
+				if t == 0 {
+					t = record.ObservedTimeUnixNano
+				}
+				o.acc.AddFields("logs", fields, tags, timestamp(t))
+			}
+		}
+	}
+}
+
+func resourceTags(resource *resourcepb.Resource) map[string]string {
+	if resource == nil {
+		return map[string]string{}
+	}
+	return tags(nil, resource.Attributes)
+}
+
+// scopeTags adds the name and the version of the instrumentation scope to the
+// tags of the resource.
+func scopeTags(resourceTags map[string]string, scope *commonpb.InstrumentationScope) map[string]string {
+	if scope == nil {
+		return resourceTags
+	}
+	tags := tags(resourceTags, scope.Attributes)
+	if scope.Name != "" {
+		tags["otel.scope.name"] = scope.Name
+	}
+	if scope.Version != "" {
+		tags["otel.scope.version"] = scope.Version
+	}
+	return tags
+}
+
+// tags returns a copy of the parent tags with the attributes.
+func tags(parent map[string]string, attributes []*commonpb.KeyValue) map[string]string {
+	tags := make(map[string]string, len(parent)+len(attributes))
+	for k, v := range parent {
+		tags[k] = v
+	}
+	for _, attribute := range attributes {
+		tags[attribute.Key] = attributeString(attribute.Value)
+	}
+	return tags
+}
+
+// attributeString returns the value of an attribute as a string, the arrays
+// and the maps being encoded as JSON.
+func attributeString(value *commonpb.AnyValue) string {
+	switch v := attributeValue(value).(type) {
+	case string:
+		return v
+	case bool:
+		return strconv.FormatBool(v)
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case nil:
+		return ""
+	default:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+}
+
+func attributeValue(value *commonpb.AnyValue) interface{} {
+	if value == nil {
+		return nil
+	}
+	switch v := value.Value.(type) {
+	case *commonpb.AnyValue_StringValue:
+		return v.StringValue
+	case *commonpb.AnyValue_BoolValue:
+		return v.BoolValue
+	case *commonpb.AnyValue_IntValue:
+		return v.IntValue
+	case *commonpb.AnyValue_DoubleValue:
+		return v.DoubleValue
+	case *commonpb.AnyValue_BytesValue:
+		return hex.EncodeToString(v.BytesValue)
+	case *commonpb.AnyValue_ArrayValue:
+		values := make([]interface{}, 0, len(v.ArrayValue.Values))
+		for _, value := range v.ArrayValue.Values {
+			values = append(values, attributeValue(value))
+		}
+		return values
+	case *commonpb.AnyValue_KvlistValue:
+		values := make(map[string]interface{}, len(v.KvlistValue.Values))
+		for _, kv := range v.KvlistValue.Values {
+			values[kv.Key] = attributeValue(kv.Value)
+		}
+		return values
+	}
+	return nil
+}
+
+func timestamp(unixNano uint64) time.Time {
+	if unixNano == 0 {
+		return time.Now()
+	}
+	return time.Unix(0, int64(unixNano))
+}
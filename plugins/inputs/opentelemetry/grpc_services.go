@@ -0,0 +1,39 @@
+package opentelemetry
+
+import (
+	"context"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+)
+
+type metricsService struct {
+	colmetricspb.UnimplementedMetricsServiceServer
+	o *OpenTelemetry
+}
+
+func (s *metricsService) Export(ctx context.Context, req *colmetricspb.ExportMetricsServiceRequest) (*colmetricspb.ExportMetricsServiceResponse, error) {
+	s.o.addMetrics(req.ResourceMetrics)
+	return &colmetricspb.ExportMetricsServiceResponse{}, nil
+}
+
+type traceService struct {
+	coltracepb.UnimplementedTraceServiceServer
+	o *OpenTelemetry
+}
+
+func (s *traceService) Export(ctx context.Context, req *coltracepb.ExportTraceServiceRequest) (*coltracepb.ExportTraceServiceResponse, error) {
+	s.o.addSpans(req.ResourceSpans)
+	return &coltracepb.ExportTraceServiceResponse{}, nil
+}
+
+type logsService struct {
+	collogspb.UnimplementedLogsServiceServer
+	o *OpenTelemetry
+}
+
+func (s *logsService) Export(ctx context.Context, req *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	s.o.addLogs(req.ResourceLogs)
+	return &collogspb.ExportLogsServiceResponse{}, nil
+}
@@ -0,0 +1,283 @@
+package opentelemetry
+
+import (
+	"compress/gzip"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/protobuf/proto"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	tlsint "github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const (
+	defaultServiceAddress = "0.0.0.0:4317"
+	defaultMaxMessageSize = 4 * 1024 * 1024
+
+	protobufContentType = "application/x-protobuf"
+)
+
+type OpenTelemetry struct {
+	ServiceAddress     string            `toml:"service_address"`
+	HTTPServiceAddress string            `toml:"http_service_address"`
+	MaxMessageSize     int               `toml:"max_message_size"`
+	ReadTimeout        internal.Duration `toml:"read_timeout"`
+	Traces             bool              `toml:"traces"`
+	Logs               bool              `toml:"logs"`
+	tlsint.ServerConfig
+
+	acc          telegraf.Accumulator
+	grpcServer   *grpc.Server
+	grpcListener net.Listener
+	httpServer   *http.Server
+	httpListener net.Listener
+	wg           sync.WaitGroup
+}
+
+var sampleConfig = `
+  ## Address and port of the OTLP gRPC server.
+  # service_address = "0.0.0.0:4317"
+
+  ## Address and port of the OTLP HTTP server, accepting protobuf messages on
+  ## the /v1/metrics, /v1/traces and /v1/logs paths.  Disabled by default.
+  # http_service_address = "0.0.0.0:4318"
+
+  ## Maximum size in bytes of a received message.
+  # max_message_size = 4194304
+
+  ## Maximum duration before timing out the read of a HTTP request.
+  # read_timeout = "10s"
+
+  ## Accept the traces and the logs, and convert their spans and records to
+  ## metrics.  Only the metrics are accepted by default.
+  # traces = false
+  # logs = false
+
+  ## Optional TLS Config for both servers
+  # tls_allowed_cacerts = ["/etc/telegraf/clientca.pem"]
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+`
+
+func (o *OpenTelemetry) SampleConfig() string {
+	return sampleConfig
+}
+
+func (o *OpenTelemetry) Description() string {
+	return "Receive metrics, traces and logs from OpenTelemetry SDKs and collectors using OTLP"
+}
+
+func (o *OpenTelemetry) Gather(_ telegraf.Accumulator) error {
+	return nil
+}
+
+func (o *OpenTelemetry) Start(acc telegraf.Accumulator) error {
+	o.acc = acc
+
+	if o.ServiceAddress == "" {
+		o.ServiceAddress = defaultServiceAddress
+	}
+	if o.MaxMessageSize == 0 {
+		o.MaxMessageSize = defaultMaxMessageSize
+	}
+	if o.ReadTimeout.Duration < time.Second {
+		o.ReadTimeout.Duration = 10 * time.Second
+	}
+
+	tlsConf, err := o.ServerConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	if err := o.startGRPC(tlsConf); err != nil {
+		return err
+	}
+	if o.HTTPServiceAddress != "" {
+		if err := o.startHTTP(tlsConf); err != nil {
+			o.Stop()
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *OpenTelemetry) startGRPC(tlsConf *tls.Config) error {
+	options := []grpc.ServerOption{grpc.MaxRecvMsgSize(o.MaxMessageSize)}
+	if tlsConf != nil {
+		options = append(options, grpc.Creds(credentials.NewTLS(tlsConf)))
+	}
+
+	o.grpcServer = grpc.NewServer(options...)
+	colmetricspb.RegisterMetricsServiceServer(o.grpcServer, &metricsService{o: o})
+	if o.Traces {
+		coltracepb.RegisterTraceServiceServer(o.grpcServer, &traceService{o: o})
+	}
+	if o.Logs {
+		collogspb.RegisterLogsServiceServer(o.grpcServer, &logsService{o: o})
+	}
+
+	listener, err := net.Listen("tcp", o.ServiceAddress)
+	if err != nil {
+		return err
+	}
+	o.grpcListener = listener
+
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		if err := o.grpcServer.Serve(listener); err != nil {
+			o.acc.AddError(fmt.Errorf("E! Error serving OTLP gRPC: %s", err))
+		}
+	}()
+
+	log.Printf("I! Started OpenTelemetry gRPC service on %s\n", listener.Addr())
+	return nil
+}
+
+func (o *OpenTelemetry) startHTTP(tlsConf *tls.Config) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/metrics", o.serveMetrics)
+	if o.Traces {
+		mux.HandleFunc("/v1/traces", o.serveTraces)
+	}
+	if o.Logs {
+		mux.HandleFunc("/v1/logs", o.serveLogs)
+	}
+
+	o.httpServer = &http.Server{
+		Handler:     mux,
+		ReadTimeout: o.ReadTimeout.Duration,
+		TLSConfig:   tlsConf,
+	}
+
+	var listener net.Listener
+	var err error
+	if tlsConf != nil {
+		listener, err = tls.Listen("tcp", o.HTTPServiceAddress, tlsConf)
+	} else {
+		listener, err = net.Listen("tcp", o.HTTPServiceAddress)
+	}
+	if err != nil {
+		return err
+	}
+	o.httpListener = listener
+
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		if err := o.httpServer.Serve(listener); err != nil && err != http.ErrServerClosed {
+			o.acc.AddError(fmt.Errorf("E! Error serving OTLP HTTP: %s", err))
+		}
+	}()
+
+	log.Printf("I! Started OpenTelemetry HTTP service on %s\n", listener.Addr())
+	return nil
+}
+
+func (o *OpenTelemetry) Stop() {
+	if o.httpServer != nil {
+		o.httpServer.Close()
+	}
+	if o.grpcServer != nil {
+		o.grpcServer.Stop()
+	}
+	o.wg.Wait()
+	log.Println("I! Stopped OpenTelemetry service")
+}
+
+func (o *OpenTelemetry) serveMetrics(res http.ResponseWriter, req *http.Request) {
+	request := &colmetricspb.ExportMetricsServiceRequest{}
+	if !o.readRequest(res, req, request) {
+		return
+	}
+	o.addMetrics(request.ResourceMetrics)
+	writeResponse(res, &colmetricspb.ExportMetricsServiceResponse{})
+}
+
+func (o *OpenTelemetry) serveTraces(res http.ResponseWriter, req *http.Request) {
+	request := &coltracepb.ExportTraceServiceRequest{}
+	if !o.readRequest(res, req, request) {
+		return
+	}
+	o.addSpans(request.ResourceSpans)
+	writeResponse(res, &coltracepb.ExportTraceServiceResponse{})
+}
+
+func (o *OpenTelemetry) serveLogs(res http.ResponseWriter, req *http.Request) {
+	request := &collogspb.ExportLogsServiceRequest{}
+	if !o.readRequest(res, req, request) {
+		return
+	}
+	o.addLogs(request.ResourceLogs)
+	writeResponse(res, &collogspb.ExportLogsServiceResponse{})
+}
+
+// readRequest reads the protobuf message of a HTTP request, writing the
+// error response if it is invalid.
+func (o *OpenTelemetry) readRequest(res http.ResponseWriter, req *http.Request, message proto.Message) bool {
+	if req.Method != http.MethodPost {
+		http.Error(res, "method not allowed", http.StatusMethodNotAllowed)
+		return false
+	}
+	if req.Header.Get("Content-Type") != protobufContentType {
+		http.Error(res, "unsupported content type", http.StatusUnsupportedMediaType)
+		return false
+	}
+
+	var body io.Reader = http.MaxBytesReader(res, req.Body, int64(o.MaxMessageSize))
+	if req.Header.Get("Content-Encoding") == "gzip" {
+		r, err := gzip.NewReader(body)
+		if err != nil {
+			http.Error(res, err.Error(), http.StatusBadRequest)
+			return false
+		}
+		defer r.Close()
+		body = io.LimitReader(r, int64(o.MaxMessageSize))
+	}
+
+	data, err := ioutil.ReadAll(body)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	if err := proto.Unmarshal(data, message); err != nil {
+		http.Error(res, err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+func writeResponse(res http.ResponseWriter, message proto.Message) {
+	data, err := proto.Marshal(message)
+	if err != nil {
+		http.Error(res, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	res.Header().Set("Content-Type", protobufContentType)
+	res.Write(data)
+}
+
+func init() {
+	inputs.Add("opentelemetry", func() telegraf.Input {
+		return &OpenTelemetry{
+			ServiceAddress: defaultServiceAddress,
+			MaxMessageSize: defaultMaxMessageSize,
+		}
+	})
+}
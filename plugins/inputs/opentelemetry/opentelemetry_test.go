@@ -0,0 +1,299 @@
+package opentelemetry
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+)
+
+var (
+	ts     = time.Unix(1531390000, 0)
+	tsNano = uint64(ts.UnixNano())
+
+	resource = &resourcepb.Resource{
+		Attributes: []*commonpb.KeyValue{stringAttribute("service.name", "checkout")},
+	}
+	scope = &commonpb.InstrumentationScope{Name: "io.opentelemetry.http", Version: "1.2.0"}
+
+	expectedTags = map[string]string{
+		"service.name":       "checkout",
+		"otel.scope.name":    "io.opentelemetry.http",
+		"otel.scope.version": "1.2.0",
+	}
+)
+
+func stringAttribute(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{
+		Key:   key,
+		Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}},
+	}
+}
+
+func withTags(extra map[string]string) map[string]string {
+	tags := make(map[string]string)
+	for k, v := range expectedTags {
+		tags[k] = v
+	}
+	for k, v := range extra {
+		tags[k] = v
+	}
+	return tags
+}
+
+func newTestOpenTelemetry(acc telegraf.Accumulator, t *testing.T) *OpenTelemetry {
+	o := &OpenTelemetry{
+		ServiceAddress:     "localhost:0",
+		HTTPServiceAddress: "localhost:0",
+		Traces:             true,
+		Logs:               true,
+	}
+	require.NoError(t, o.Start(acc))
+	return o
+}
+
+func postProtobuf(t *testing.T, url string, message proto.Message) *http.Response {
+	data, err := proto.Marshal(message)
+	require.NoError(t, err)
+	resp, err := http.Post(url, protobufContentType, bytes.NewReader(data))
+	require.NoError(t, err)
+	resp.Body.Close()
+	return resp
+}
+
+func TestMetrics_grpc(t *testing.T) {
+	acc := &testutil.Accumulator{}
+	o := newTestOpenTelemetry(acc, t)
+	defer o.Stop()
+
+	conn, err := grpc.Dial(o.grpcListener.Addr().String(), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	sum := 12.5
+	request := &colmetricspb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricspb.ResourceMetrics{{
+			Resource: resource,
+			ScopeMetrics: []*metricspb.ScopeMetrics{{
+				Scope: scope,
+				Metrics: []*metricspb.Metric{
+					{
+						Name: "memory_usage",
+						Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{
+							DataPoints: []*metricspb.NumberDataPoint{{
+								TimeUnixNano: tsNano,
+								Value:        &metricspb.NumberDataPoint_AsInt{AsInt: 1024},
+							}},
+						}},
+					},
+					{
+						Name: "http_requests",
+						Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+							IsMonotonic: true,
+							DataPoints: []*metricspb.NumberDataPoint{{
+								Attributes:   []*commonpb.KeyValue{stringAttribute("method", "GET")},
+								TimeUnixNano: tsNano,
+								Value:        &metricspb.NumberDataPoint_AsDouble{AsDouble: 42},
+							}},
+						}},
+					},
+					{
+						Name: "http_duration",
+						Data: &metricspb.Metric_Histogram{Histogram: &metricspb.Histogram{
+							DataPoints: []*metricspb.HistogramDataPoint{{
+								TimeUnixNano:   tsNano,
+								Count:          6,
+								Sum:            &sum,
+								ExplicitBounds: []float64{0.5, 1},
+								BucketCounts:   []uint64{3, 2, 1},
+							}},
+						}},
+					},
+					{
+						Name: "rpc_duration",
+						Data: &metricspb.Metric_Summary{Summary: &metricspb.Summary{
+							DataPoints: []*metricspb.SummaryDataPoint{{
+								TimeUnixNano: tsNano,
+								Count:        4,
+								Sum:          2,
+								QuantileValues: []*metricspb.SummaryDataPoint_ValueAtQuantile{
+									{Quantile: 0.5, Value: 0.25},
+								},
+							}},
+						}},
+					},
+				},
+			}},
+		}},
+	}
+
+	client := colmetricspb.NewMetricsServiceClient(conn)
+	_, err = client.Export(context.Background(), request)
+	require.NoError(t, err)
+
+	acc.Wait(4)
+	acc.AssertContainsTaggedFields(t, "memory_usage",
+		map[string]interface{}{"gauge": int64(1024)}, expectedTags)
+	acc.AssertContainsTaggedFields(t, "http_requests",
+		map[string]interface{}{"counter": 42.0}, withTags(map[string]string{"method": "GET"}))
+	acc.AssertContainsTaggedFields(t, "http_duration",
+		map[string]interface{}{"0.5": 3.0, "1": 5.0, "+Inf": 6.0, "count": 6.0, "sum": 12.5}, expectedTags)
+	acc.AssertContainsTaggedFields(t, "rpc_duration",
+		map[string]interface{}{"0.5": 0.25, "count": 4.0, "sum": 2.0}, expectedTags)
+	require.True(t, acc.HasTimestamp("memory_usage", ts))
+}
+
+func TestMetrics_http(t *testing.T) {
+	acc := &testutil.Accumulator{}
+	o := newTestOpenTelemetry(acc, t)
+	defer o.Stop()
+
+	request := &colmetricspb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricspb.ResourceMetrics{{
+			ScopeMetrics: []*metricspb.ScopeMetrics{{
+				Metrics: []*metricspb.Metric{{
+					Name: "queue_size",
+					Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+						DataPoints: []*metricspb.NumberDataPoint{{
+							TimeUnixNano: tsNano,
+							Value:        &metricspb.NumberDataPoint_AsInt{AsInt: -3},
+						}},
+					}},
+				}},
+			}},
+		}},
+	}
+
+	url := "http://" + o.httpListener.Addr().String() + "/v1/metrics"
+	resp := postProtobuf(t, url, request)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, protobufContentType, resp.Header.Get("Content-Type"))
+
+	acc.Wait(1)
+	acc.AssertContainsTaggedFields(t, "queue_size",
+		map[string]interface{}{"gauge": int64(-3)}, map[string]string{})
+
+	resp, err := http.Post(url, "application/json", bytes.NewBufferString("{}"))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnsupportedMediaType, resp.StatusCode)
+}
+
+func TestTraces(t *testing.T) {
+	acc := &testutil.Accumulator{}
+	o := newTestOpenTelemetry(acc, t)
+	defer o.Stop()
+
+	request := &coltracepb.ExportTraceServiceRequest{
+		ResourceSpans: []*tracepb.ResourceSpans{{
+			Resource: resource,
+			ScopeSpans: []*tracepb.ScopeSpans{{
+				Scope: scope,
+				Spans: []*tracepb.Span{{
+					TraceId:           []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16},
+					SpanId:            []byte{1, 2, 3, 4, 5, 6, 7, 8},
+					Name:              "GET /cart",
+					Kind:              tracepb.Span_SPAN_KIND_SERVER,
+					StartTimeUnixNano: tsNano,
+					EndTimeUnixNano:   tsNano + 1500000,
+					Status:            &tracepb.Status{Code: tracepb.Status_STATUS_CODE_ERROR, Message: "timeout"},
+				}},
+			}},
+		}},
+	}
+
+	resp := postProtobuf(t, "http://"+o.httpListener.Addr().String()+"/v1/traces", request)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	acc.Wait(1)
+	acc.AssertContainsTaggedFields(t, "spans",
+		map[string]interface{}{
+			"trace_id":       "0102030405060708090a0b0c0d0e0f10",
+			"span_id":        "0102030405060708",
+			"duration_ns":    int64(1500000),
+			"status_message": "timeout",
+		},
+		withTags(map[string]string{
+			"span_name":   "GET /cart",
+			"span_kind":   "SPAN_KIND_SERVER",
+			"status_code": "STATUS_CODE_ERROR",
+		}))
+}
+
+func TestLogs(t *testing.T) {
+	acc := &testutil.Accumulator{}
+	o := newTestOpenTelemetry(acc, t)
+	defer o.Stop()
+
+	request := &collogspb.ExportLogsServiceRequest{
+		ResourceLogs: []*logspb.ResourceLogs{{
+			Resource: resource,
+			ScopeLogs: []*logspb.ScopeLogs{{
+				Scope: scope,
+				LogRecords: []*logspb.LogRecord{{
+					ObservedTimeUnixNano: tsNano,
+					SeverityNumber:       logspb.SeverityNumber_SEVERITY_NUMBER_WARN,
+					SeverityText:         "WARN",
+					Body:                 &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: "cart is empty"}},
+					Attributes: []*commonpb.KeyValue{{
+						Key:   "retries",
+						Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: 3}},
+					}},
+				}},
+			}},
+		}},
+	}
+
+	resp := postProtobuf(t, "http://"+o.httpListener.Addr().String()+"/v1/logs", request)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	acc.Wait(1)
+	acc.AssertContainsTaggedFields(t, "logs",
+		map[string]interface{}{
+			"message":         "cart is empty",
+			"severity_number": int64(13),
+		},
+		withTags(map[string]string{"severity_text": "WARN", "retries": "3"}))
+	require.True(t, acc.HasTimestamp("logs", ts))
+}
+
+func TestTracesDisabled(t *testing.T) {
+	acc := &testutil.Accumulator{}
+	o := &OpenTelemetry{
+		ServiceAddress:     "localhost:0",
+		HTTPServiceAddress: "localhost:0",
+	}
+	require.NoError(t, o.Start(acc))
+	defer o.Stop()
+
+	resp := postProtobuf(t, "http://"+o.httpListener.Addr().String()+"/v1/traces", &coltracepb.ExportTraceServiceRequest{})
+	require.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestAttributeString(t *testing.T) {
+	value := &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: &commonpb.ArrayValue{
+		Values: []*commonpb.AnyValue{
+			{Value: &commonpb.AnyValue_StringValue{StringValue: "a"}},
+			{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: 1.5}},
+			{Value: &commonpb.AnyValue_BoolValue{BoolValue: true}},
+		},
+	}}}
+	require.Equal(t, `["a",1.5,true]`, attributeString(value))
+	require.Equal(t, "", attributeString(nil))
+}
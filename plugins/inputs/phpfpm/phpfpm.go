@@ -8,6 +8,7 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
@@ -29,11 +30,31 @@ const (
 	PF_MAX_ACTIVE_PROCESSES = "max active processes"
 	PF_MAX_CHILDREN_REACHED = "max children reached"
 	PF_SLOW_REQUESTS        = "slow requests"
+
+	// Fields of the processes, listed by the full status output
+	PF_PID                 = "pid"
+	PF_STATE               = "state"
+	PF_START_SINCE         = "start since"
+	PF_REQUESTS            = "requests"
+	PF_REQUEST_DURATION    = "request duration"
+	PF_REQUEST_METHOD      = "request method"
+	PF_REQUEST_URI         = "request URI"
+	PF_CONTENT_LENGTH      = "content length"
+	PF_SCRIPT              = "script"
+	PF_LAST_REQUEST_CPU    = "last request cpu"
+	PF_LAST_REQUEST_MEMORY = "last request memory"
 )
 
 type metric map[string]int64
 type poolStat map[string]metric
 
+// process holds the fields of a process of the full status output
+type process struct {
+	pool   string
+	pid    string
+	fields map[string]interface{}
+}
+
 type phpfpm struct {
 	Urls []string
 
@@ -53,12 +74,20 @@ var sampleConfig = `
   ##       "/var/run/php5-fpm.sock"
   ##      or using a custom fpm status path:
   ##       "/var/run/php5-fpm.sock:fpm-custom-status-path"
+  ##      glob patterns are also supported to gather all the pools of a host:
+  ##       "/var/run/php/*.sock"
   ##
   ##   - fcgi: the URL must start with fcgi:// or cgi://, and port must be present, ie:
   ##       "fcgi://10.0.0.12:9000/status"
   ##       "cgi://10.0.10.12:9001/status"
   ##
-  ## Example of multiple gathering from local socket and remove host
+  ## Append the full query to the status path to also gather the metrics of
+  ## each process of the pools, ie:
+  ##       "http://localhost/status?full"
+  ##       "fcgi://10.0.0.12:9000/status?full"
+  ##       "/var/run/php/*.sock:status?full"
+  ##
+  ## Example of multiple gathering from local socket and remote host
   ## urls = ["http://192.168.1.20/status", "/tmp/fpm.sock"]
   urls = ["http://localhost/status"]
 `
@@ -78,9 +107,19 @@ func (g *phpfpm) Gather(acc telegraf.Accumulator) error {
 		return g.gatherServer("http://127.0.0.1/status", acc)
 	}
 
+	var urls []string
+	for _, u := range g.Urls {
+		addrs, err := expandUrl(u)
+		if err != nil {
+			acc.AddError(err)
+			continue
+		}
+		urls = append(urls, addrs...)
+	}
+
 	var wg sync.WaitGroup
 
-	for _, serv := range g.Urls {
+	for _, serv := range urls {
 		wg.Add(1)
 		go func(serv string) {
 			defer wg.Done()
@@ -128,15 +167,11 @@ func (g *phpfpm) gatherServer(addr string, acc telegraf.Accumulator) error {
 		} else {
 			statusPath = "status"
 		}
-	} else {
-		socketAddr := strings.Split(addr, ":")
-		if len(socketAddr) >= 2 {
-			socketPath = socketAddr[0]
-			statusPath = socketAddr[1]
-		} else {
-			socketPath = socketAddr[0]
-			statusPath = "status"
+		if u.RawQuery != "" {
+			statusPath += "?" + u.RawQuery
 		}
+	} else {
+		socketPath, statusPath = splitSocketAddr(addr)
 
 		if _, err := os.Stat(socketPath); os.IsNotExist(err) {
 			return fmt.Errorf("Socket doesn't exist  '%s': %s", socketPath, err)
@@ -153,9 +188,15 @@ func (g *phpfpm) gatherServer(addr string, acc telegraf.Accumulator) error {
 
 // Gather stat using fcgi protocol
 func (g *phpfpm) gatherFcgi(fcgi *conn, statusPath string, acc telegraf.Accumulator, addr string) error {
+	var query string
+	if i := strings.Index(statusPath, "?"); i >= 0 {
+		statusPath, query = statusPath[:i], statusPath[i+1:]
+	}
+
 	fpmOutput, fpmErr, err := fcgi.Request(map[string]string{
 		"SCRIPT_NAME":     "/" + statusPath,
 		"SCRIPT_FILENAME": statusPath,
+		"QUERY_STRING":    query,
 		"REQUEST_METHOD":  "GET",
 		"CONTENT_LENGTH":  "0",
 		"SERVER_PROTOCOL": "HTTP/1.0",
@@ -178,8 +219,9 @@ func (g *phpfpm) gatherHttp(addr string, acc telegraf.Accumulator) error {
 		return fmt.Errorf("Unable parse server address '%s': %s", addr, err)
 	}
 
-	req, err := http.NewRequest("GET", fmt.Sprintf("%s://%s%s", u.Scheme,
-		u.Host, u.Path), nil)
+	u.User = nil
+	u.Fragment = ""
+	req, err := http.NewRequest("GET", u.String(), nil)
 	res, err := g.client.Do(req)
 	if err != nil {
 		return fmt.Errorf("Unable to connect to phpfpm status page '%s': %v",
@@ -200,23 +242,43 @@ func (g *phpfpm) gatherHttp(addr string, acc telegraf.Accumulator) error {
 func importMetric(r io.Reader, acc telegraf.Accumulator, addr string) (poolStat, error) {
 	stats := make(poolStat)
 	var currentPool string
+	var processes []*process
+	var currentProcess *process
 
 	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
 		statLine := scanner.Text()
-		keyvalue := strings.Split(statLine, ":")
+		keyvalue := strings.SplitN(statLine, ":", 2)
 
 		if len(keyvalue) < 2 {
 			continue
 		}
 		fieldName := strings.Trim(keyvalue[0], " ")
+		value := strings.Trim(keyvalue[1], " ")
 		// We start to gather data for a new pool here
 		if fieldName == PF_POOL {
-			currentPool = strings.Trim(keyvalue[1], " ")
+			currentPool = value
+			currentProcess = nil
 			stats[currentPool] = make(metric)
 			continue
 		}
 
+		// The processes of the full status output follow the pool stats,
+		// each one starting with its pid
+		if fieldName == PF_PID {
+			currentProcess = &process{
+				pool:   currentPool,
+				pid:    value,
+				fields: make(map[string]interface{}),
+			}
+			processes = append(processes, currentProcess)
+			continue
+		}
+		if currentProcess != nil {
+			importProcessField(currentProcess, fieldName, value)
+			continue
+		}
+
 		// Start to parse metric for current pool
 		switch fieldName {
 		case PF_ACCEPTED_CONN,
@@ -229,7 +291,7 @@ func importMetric(r io.Reader, acc telegraf.Accumulator, addr string) (poolStat,
 			PF_MAX_ACTIVE_PROCESSES,
 			PF_MAX_CHILDREN_REACHED,
 			PF_SLOW_REQUESTS:
-			fieldValue, err := strconv.ParseInt(strings.Trim(keyvalue[1], " "), 10, 64)
+			fieldValue, err := strconv.ParseInt(value, 10, 64)
 			if err == nil {
 				stats[currentPool][fieldName] = fieldValue
 			}
@@ -249,9 +311,91 @@ func importMetric(r io.Reader, acc telegraf.Accumulator, addr string) (poolStat,
 		acc.AddFields("phpfpm", fields, tags)
 	}
 
+	for _, p := range processes {
+		tags := map[string]string{
+			"pool": p.pool,
+			"url":  addr,
+			"pid":  p.pid,
+		}
+		acc.AddFields("phpfpm_process", p.fields, tags)
+	}
+
 	return stats, nil
 }
 
+// importProcessField adds a field of the full status output to a process
+func importProcessField(p *process, fieldName string, value string) {
+	key := strings.ToLower(strings.Replace(fieldName, " ", "_", -1))
+	switch fieldName {
+	case PF_START_SINCE,
+		PF_REQUESTS,
+		PF_REQUEST_DURATION,
+		PF_CONTENT_LENGTH,
+		PF_LAST_REQUEST_MEMORY:
+		fieldValue, err := strconv.ParseInt(value, 10, 64)
+		if err == nil {
+			p.fields[key] = fieldValue
+		}
+	case PF_LAST_REQUEST_CPU:
+		fieldValue, err := strconv.ParseFloat(value, 64)
+		if err == nil {
+			p.fields[key] = fieldValue
+		}
+	case PF_STATE,
+		PF_REQUEST_METHOD,
+		PF_REQUEST_URI,
+		PF_SCRIPT:
+		p.fields[key] = value
+	}
+}
+
+// splitSocketAddr splits a socket address into the path of the socket and
+// the status path, "status" by default.
+func splitSocketAddr(addr string) (string, string) {
+	socketAddr := strings.SplitN(addr, ":", 2)
+	if len(socketAddr) == 2 {
+		return socketAddr[0], socketAddr[1]
+	}
+	return socketAddr[0], "status"
+}
+
+// expandUrl expands the glob pattern of a socket path, keeping its custom
+// status path.  Network addresses are returned unchanged.
+func expandUrl(addr string) ([]string, error) {
+	if isNetworkUrl(addr) {
+		return []string{addr}, nil
+	}
+
+	socketPath, statusPath := splitSocketAddr(addr)
+	if !strings.ContainsAny(socketPath, "*?[") {
+		return []string{addr}, nil
+	}
+
+	paths, err := filepath.Glob(socketPath)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid socket pattern '%s': %s", socketPath, err)
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("No socket matches the pattern '%s'", socketPath)
+	}
+
+	addrs := make([]string, 0, len(paths))
+	for _, path := range paths {
+		if strings.Contains(addr, ":") {
+			path += ":" + statusPath
+		}
+		addrs = append(addrs, path)
+	}
+	return addrs, nil
+}
+
+func isNetworkUrl(addr string) bool {
+	return strings.HasPrefix(addr, "http://") ||
+		strings.HasPrefix(addr, "https://") ||
+		strings.HasPrefix(addr, "fcgi://") ||
+		strings.HasPrefix(addr, "cgi://")
+}
+
 func init() {
 	inputs.Add("phpfpm", func() telegraf.Input {
 		return &phpfpm{}
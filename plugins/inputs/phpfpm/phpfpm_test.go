@@ -4,10 +4,13 @@ import (
 	"crypto/rand"
 	"encoding/binary"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/fcgi"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/influxdata/telegraf/testutil"
@@ -19,9 +22,13 @@ type statServer struct{}
 
 // We create a fake server to return test data
 func (s statServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	output := outputSample
+	if _, ok := r.URL.Query()["full"]; ok {
+		output += outputSampleProcesses
+	}
 	w.Header().Set("Content-Type", "text/plain")
-	w.Header().Set("Content-Length", fmt.Sprint(len(outputSample)))
-	fmt.Fprint(w, outputSample)
+	w.Header().Set("Content-Length", fmt.Sprint(len(output)))
+	fmt.Fprint(w, output)
 }
 
 func TestPhpFpmGeneratesMetrics_From_Http(t *testing.T) {
@@ -190,6 +197,114 @@ func TestPhpFpmGeneratesMetrics_From_Socket_Custom_Status_Path(t *testing.T) {
 	acc.AssertContainsTaggedFields(t, "phpfpm", fields, tags)
 }
 
+func TestPhpFpmGeneratesMetrics_From_Http_Full_Status(t *testing.T) {
+	sv := statServer{}
+	ts := httptest.NewServer(sv)
+	defer ts.Close()
+
+	r := &phpfpm{
+		Urls: []string{ts.URL + "/status?full"},
+	}
+
+	var acc testutil.Accumulator
+
+	err := acc.GatherError(r.Gather)
+	require.NoError(t, err)
+
+	assert.True(t, acc.HasMeasurement("phpfpm"))
+
+	acc.AssertContainsTaggedFields(t, "phpfpm_process",
+		map[string]interface{}{
+			"state":               "Idle",
+			"start_since":         int64(1991),
+			"requests":            int64(2),
+			"request_duration":    int64(1295),
+			"request_method":      "GET",
+			"request_uri":         "/index.php?id=12:34",
+			"content_length":      int64(0),
+			"script":              "/var/www/index.php",
+			"last_request_cpu":    float64(0.77),
+			"last_request_memory": int64(2097152),
+		},
+		map[string]string{"pool": "www", "url": r.Urls[0], "pid": "25"})
+
+	acc.AssertContainsTaggedFields(t, "phpfpm_process",
+		map[string]interface{}{
+			"state":               "Running",
+			"start_since":         int64(1991),
+			"requests":            int64(1),
+			"request_duration":    int64(185),
+			"request_method":      "GET",
+			"request_uri":         "/status?full",
+			"content_length":      int64(0),
+			"script":              "-",
+			"last_request_cpu":    float64(0),
+			"last_request_memory": int64(0),
+		},
+		map[string]string{"pool": "www", "url": r.Urls[0], "pid": "26"})
+}
+
+func TestPhpFpmGeneratesMetrics_From_Socket_Glob(t *testing.T) {
+	dir, err := ioutil.TempDir("", "test-fpm")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s := statServer{}
+	for _, name := range []string{"www.sock", "api.sock"} {
+		sock, err := net.Listen("unix", filepath.Join(dir, name))
+		require.NoError(t, err)
+		defer sock.Close()
+		go fcgi.Serve(sock, s)
+	}
+
+	r := &phpfpm{
+		Urls: []string{filepath.Join(dir, "*.sock") + ":status?full"},
+	}
+
+	var acc testutil.Accumulator
+
+	err = acc.GatherError(r.Gather)
+	require.NoError(t, err)
+
+	for _, name := range []string{"www.sock", "api.sock"} {
+		addr := filepath.Join(dir, name) + ":status?full"
+		acc.AssertContainsTaggedFields(t, "phpfpm",
+			map[string]interface{}{
+				"accepted_conn":        int64(3),
+				"listen_queue":         int64(1),
+				"max_listen_queue":     int64(0),
+				"listen_queue_len":     int64(0),
+				"idle_processes":       int64(1),
+				"active_processes":     int64(1),
+				"total_processes":      int64(2),
+				"max_active_processes": int64(1),
+				"max_children_reached": int64(2),
+				"slow_requests":        int64(1),
+			},
+			map[string]string{"pool": "www", "url": addr})
+
+		var pids []string
+		for _, m := range acc.Metrics {
+			if m.Measurement == "phpfpm_process" && m.Tags["url"] == addr {
+				pids = append(pids, m.Tags["pid"])
+			}
+		}
+		assert.Len(t, pids, 2)
+	}
+}
+
+func TestPhpFpmGeneratesMetrics_Throw_Error_When_Socket_Pattern_Does_Not_Match(t *testing.T) {
+	r := &phpfpm{
+		Urls: []string{"/tmp/invalid-fpm-*.sock"},
+	}
+
+	var acc testutil.Accumulator
+
+	err := acc.GatherError(r.Gather)
+	require.Error(t, err)
+	assert.Equal(t, `No socket matches the pattern '/tmp/invalid-fpm-*.sock'`, err.Error())
+}
+
 //When not passing server config, we default to localhost
 //We just want to make sure we did request stat from localhost
 func TestPhpFpmDefaultGetFromLocalhost(t *testing.T) {
@@ -243,3 +358,35 @@ max active processes: 1
 max children reached: 2
 slow requests:        1
 `
+
+const outputSampleProcesses = `
+************************
+pid:                  25
+state:                Idle
+start time:           11/Oct/2015:23:38:51 +0000
+start since:          1991
+requests:             2
+request duration:     1295
+request method:       GET
+request URI:          /index.php?id=12:34
+content length:       0
+user:                 -
+script:               /var/www/index.php
+last request cpu:     0.77
+last request memory:  2097152
+
+************************
+pid:                  26
+state:                Running
+start time:           11/Oct/2015:23:38:51 +0000
+start since:          1991
+requests:             1
+request duration:     185
+request method:       GET
+request URI:          /status?full
+content length:       0
+user:                 -
+script:               -
+last request cpu:     0.00
+last request memory:  0
+`
@@ -2,9 +2,12 @@ package postgresql_extensible
 
 import (
 	"bytes"
+	"database/sql"
 	"fmt"
 	"log"
+	"net/url"
 	"strings"
+	"time"
 
 	// register in driver.
 	_ "github.com/jackc/pgx/stdlib"
@@ -19,21 +22,21 @@ type Postgresql struct {
 	postgresql.Service
 	Databases      []string
 	AdditionalTags []string
-	Query          []struct {
-		Sqlquery    string
-		Version     int
-		Withdbname  bool
-		Tagvalue    string
-		Measurement string
-	}
-	Debug bool
+	Query          query
+	Debug          bool
+
+	// connections to the databases of the per_database queries
+	databaseDBs map[string]*sql.DB
 }
 
 type query []struct {
 	Sqlquery    string
 	Version     int
+	MinVersion  int `toml:"min_version"`
 	Withdbname  bool
+	PerDatabase bool `toml:"per_database"`
 	Tagvalue    string
+	Timestamp   string
 	Measurement string
 }
 
@@ -81,25 +84,45 @@ var sampleConfig = `
   ## field is used to define custom tags (separated by commas)
   ## The optional "measurement" value can be used to override the default
   ## output measurement name ("postgresql").
+  ##
+  ## The min_version field is the minimum server_version_num the query is run
+  ## against, ie: 90600 for 9.6 or 100000 for 10.  The version field is the
+  ## deprecated form using the first 3 digits of server_version_num.
+  ##
+  ## When per_database is true the query is run in each database of the
+  ## 'databases' field, or in every database accepting connections if it is
+  ## empty, and the metrics are tagged with the database.  Use it with
+  ## queries on per database catalogs such as pg_stat_user_tables.
+  ##
+  ## The optional timestamp field is the name of a column of type timestamp
+  ## used as the time of the metrics, instead of the time of the query.
   #
   ## Structure :
   ## [[inputs.postgresql_extensible.query]]
   ##   sqlquery string
-  ##   version string
+  ##   min_version int
   ##   withdbname boolean
+  ##   per_database boolean
   ##   tagvalue string (comma separated)
+  ##   timestamp string
   ##   measurement string
   [[inputs.postgresql_extensible.query]]
     sqlquery="SELECT * FROM pg_stat_database"
-    version=901
+    min_version=90100
     withdbname=false
     tagvalue=""
     measurement=""
   [[inputs.postgresql_extensible.query]]
     sqlquery="SELECT * FROM pg_stat_bgwriter"
-    version=901
+    min_version=90100
     withdbname=false
     tagvalue="postgresql.stats"
+  [[inputs.postgresql_extensible.query]]
+    sqlquery="SELECT relname, seq_scan, idx_scan, n_live_tup, n_dead_tup FROM pg_stat_user_tables"
+    min_version=90100
+    per_database=true
+    tagvalue="relname"
+    measurement="postgresql_tables"
 `
 
 func (p *Postgresql) SampleConfig() string {
@@ -121,14 +144,12 @@ func (p *Postgresql) Gather(acc telegraf.Accumulator) error {
 		query_addon string
 		db_version  int
 		query       string
-		tag_value   string
 		meas_name   string
-		columns     []string
 	)
 
 	// Retreiving the database version
 
-	query = `select substring(setting from 1 for 3) as version from pg_settings where name='server_version_num'`
+	query = `select setting::integer as version from pg_settings where name='server_version_num'`
 	if err = p.DB.QueryRow(query).Scan(&db_version); err != nil {
 		db_version = 0
 	}
@@ -138,7 +159,6 @@ func (p *Postgresql) Gather(acc telegraf.Accumulator) error {
 
 	for i := range p.Query {
 		sql_query = p.Query[i].Sqlquery
-		tag_value = p.Query[i].Tagvalue
 		if p.Query[i].Measurement != "" {
 			meas_name = p.Query[i].Measurement
 		} else {
@@ -157,46 +177,162 @@ func (p *Postgresql) Gather(acc telegraf.Accumulator) error {
 		}
 		sql_query += query_addon
 
-		if p.Query[i].Version <= db_version {
-			rows, err := p.DB.Query(sql_query)
-			if err != nil {
-				acc.AddError(err)
-				continue
+		if !versionMatches(p.Query[i].Version, p.Query[i].MinVersion, db_version) {
+			continue
+		}
+
+		p.AdditionalTags = nil
+		if p.Query[i].Tagvalue != "" {
+			tag_list := strings.Split(p.Query[i].Tagvalue, ",")
+			for t := range tag_list {
+				p.AdditionalTags = append(p.AdditionalTags, tag_list[t])
 			}
+		}
 
-			defer rows.Close()
+		if !p.Query[i].PerDatabase {
+			p.gatherQuery(acc, p.DB, sql_query, meas_name, p.Query[i].Timestamp, "")
+			continue
+		}
 
-			// grab the column information from the result
-			if columns, err = rows.Columns(); err != nil {
+		databases, err := p.perDatabaseNames()
+		if err != nil {
+			acc.AddError(err)
+			continue
+		}
+		for _, dbname := range databases {
+			db, err := p.databaseDB(dbname)
+			if err != nil {
 				acc.AddError(err)
 				continue
 			}
+			p.gatherQuery(acc, db, sql_query, meas_name, p.Query[i].Timestamp, dbname)
+		}
+	}
+	return nil
+}
 
-			p.AdditionalTags = nil
-			if tag_value != "" {
-				tag_list := strings.Split(tag_value, ",")
-				for t := range tag_list {
-					p.AdditionalTags = append(p.AdditionalTags, tag_list[t])
-				}
-			}
+// versionMatches returns true if a query can run on a server, the min_version
+// is compared to the server_version_num while the deprecated version is
+// compared to its first three digits.
+func versionMatches(version, minVersion, serverVersion int) bool {
+	if minVersion > 0 {
+		return minVersion <= serverVersion
+	}
+	return version <= serverVersion/100
+}
 
-			for rows.Next() {
-				err = p.accRow(meas_name, rows, acc, columns)
-				if err != nil {
-					acc.AddError(err)
-					break
-				}
-			}
+func (p *Postgresql) gatherQuery(acc telegraf.Accumulator, db *sql.DB, sql_query, meas_name, timestamp, dbname string) {
+	rows, err := db.Query(sql_query)
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+	defer rows.Close()
+
+	// grab the column information from the result
+	columns, err := rows.Columns()
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+
+	for rows.Next() {
+		err = p.accRow(meas_name, rows, acc, columns, timestamp, dbname)
+		if err != nil {
+			acc.AddError(err)
+			break
 		}
 	}
-	return nil
+}
+
+// perDatabaseNames returns the databases the per_database queries are run
+// against.
+func (p *Postgresql) perDatabaseNames() ([]string, error) {
+	if len(p.Databases) != 0 {
+		return p.Databases, nil
+	}
+
+	rows, err := p.DB.Query(`SELECT datname FROM pg_database WHERE datallowconn AND NOT datistemplate`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var databases []string
+	for rows.Next() {
+		var dbname string
+		if err := rows.Scan(&dbname); err != nil {
+			return nil, err
+		}
+		databases = append(databases, dbname)
+	}
+	return databases, rows.Err()
+}
+
+// databaseDB returns the connection pool to a database, it is opened on
+// first use with the address of the plugin.
+func (p *Postgresql) databaseDB(dbname string) (*sql.DB, error) {
+	if db, ok := p.databaseDBs[dbname]; ok {
+		return db, nil
+	}
+
+	address, err := addressWithDatabase(p.Address, dbname)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("pgx", address)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(p.MaxOpen)
+	db.SetMaxIdleConns(p.MaxIdle)
+	db.SetConnMaxLifetime(p.MaxLifetime.Duration)
+
+	if p.databaseDBs == nil {
+		p.databaseDBs = make(map[string]*sql.DB)
+	}
+	p.databaseDBs[dbname] = db
+	return db, nil
+}
+
+// addressWithDatabase replaces the database of a connection string, given
+// either as an url or as key/value pairs.
+func addressWithDatabase(address, dbname string) (string, error) {
+	if strings.HasPrefix(address, "postgres://") || strings.HasPrefix(address, "postgresql://") {
+		u, err := url.Parse(address)
+		if err != nil {
+			return "", err
+		}
+		u.Path = "/" + dbname
+		return u.String(), nil
+	}
+
+	escaper := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	var kvs []string
+	for _, kv := range strings.Fields(address) {
+		if !strings.HasPrefix(kv, "dbname=") {
+			kvs = append(kvs, kv)
+		}
+	}
+	kvs = append(kvs, "dbname='"+escaper.Replace(dbname)+"'")
+	return strings.Join(kvs, " "), nil
+}
+
+// Stop closes the connections to the server and to the databases.
+func (p *Postgresql) Stop() {
+	for _, db := range p.databaseDBs {
+		db.Close()
+	}
+	p.databaseDBs = nil
+	p.Service.Stop()
 }
 
 type scanner interface {
 	Scan(dest ...interface{}) error
 }
 
-func (p *Postgresql) accRow(meas_name string, row scanner, acc telegraf.Accumulator, columns []string) error {
+func (p *Postgresql) accRow(meas_name string, row scanner, acc telegraf.Accumulator, columns []string, timestamp, database string) error {
 	var (
 		err        error
 		columnVars []interface{}
@@ -224,6 +360,8 @@ func (p *Postgresql) accRow(meas_name string, row scanner, acc telegraf.Accumula
 	if columnMap["datname"] != nil {
 		// extract the database name from the column map
 		dbname.WriteString((*columnMap["datname"]).(string))
+	} else if database != "" {
+		dbname.WriteString(database)
 	} else {
 		dbname.WriteString("postgres")
 	}
@@ -238,6 +376,7 @@ func (p *Postgresql) accRow(meas_name string, row scanner, acc telegraf.Accumula
 		"db":     dbname.String(),
 	}
 
+	var metricTime []time.Time
 	fields := make(map[string]interface{})
 COLUMN:
 	for col, val := range columnMap {
@@ -247,6 +386,15 @@ COLUMN:
 			continue
 		}
 
+		if col == timestamp {
+			if t, ok := (*val).(time.Time); ok {
+				metricTime = append(metricTime, t)
+			} else {
+				log.Printf("W! postgresql_extensible: column %s is not a timestamp", col)
+			}
+			continue
+		}
+
 		for _, tag := range p.AdditionalTags {
 			if col != tag {
 				continue
@@ -270,7 +418,7 @@ COLUMN:
 			fields[col] = *val
 		}
 	}
-	acc.AddFields(meas_name, fields, tags)
+	acc.AddFields(meas_name, fields, tags, metricTime...)
 	return nil
 }
 
@@ -0,0 +1,245 @@
+package powerdns_recursor
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+type PowerdnsRecursor struct {
+	UnixSockets            []string `toml:"unix_sockets"`
+	SocketDir              string   `toml:"socket_dir"`
+	SocketMode             string   `toml:"socket_mode"`
+	ControlProtocolVersion int      `toml:"control_protocol_version"`
+
+	mode        uint32
+	initialized bool
+}
+
+var sampleConfig = `
+  ## Path to the Recursor control socket.
+  unix_sockets = ["/var/run/pdns_recursor.controlsocket"]
+
+  ## Version of the control protocol: 1 for the datagram protocol of the
+  ## Recursor before 4.5.0, 2 for the framed stream protocol of 4.5.0 and
+  ## later.
+  # control_protocol_version = 1
+
+  ## Directory to create the receive socket in, and its mode, with the
+  ## datagram protocol.  The Recursor sends its answers to this socket, so it
+  ## must be able to write to it.
+  # socket_dir = "/var/run/"
+  # socket_mode = "0666"
+`
+
+var defaultTimeout = 5 * time.Second
+
+func (p *PowerdnsRecursor) SampleConfig() string {
+	return sampleConfig
+}
+
+func (p *PowerdnsRecursor) Description() string {
+	return "Read metrics from one or many PowerDNS Recursor servers"
+}
+
+func (p *PowerdnsRecursor) init() error {
+	if p.SocketMode != "" {
+		mode, err := strconv.ParseUint(p.SocketMode, 8, 32)
+		if err != nil {
+			return fmt.Errorf("could not parse socket_mode: %v", err)
+		}
+		p.mode = uint32(mode)
+	}
+
+	switch p.ControlProtocolVersion {
+	case 0:
+		p.ControlProtocolVersion = 1
+	case 1, 2:
+	default:
+		return fmt.Errorf("unsupported control_protocol_version %d", p.ControlProtocolVersion)
+	}
+
+	p.initialized = true
+	return nil
+}
+
+func (p *PowerdnsRecursor) Gather(acc telegraf.Accumulator) error {
+	if !p.initialized {
+		if err := p.init(); err != nil {
+			return err
+		}
+	}
+
+	if len(p.UnixSockets) == 0 {
+		return p.gatherServer("/var/run/pdns_recursor.controlsocket", acc)
+	}
+
+	for _, serverSocket := range p.UnixSockets {
+		if err := p.gatherServer(serverSocket, acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+
+	return nil
+}
+
+func (p *PowerdnsRecursor) gatherServer(address string, acc telegraf.Accumulator) error {
+	var metrics string
+	var err error
+	if p.ControlProtocolVersion == 2 {
+		metrics, err = p.queryStream(address)
+	} else {
+		metrics, err = p.queryDatagram(address)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Process data
+	fields := parseResponse(metrics)
+
+	// Add server socket as a tag
+	tags := map[string]string{"server": address}
+
+	acc.AddFields("powerdns_recursor", fields, tags)
+
+	return nil
+}
+
+// queryDatagram sends the get-all command with the datagram protocol, where
+// the answer is sent back to a socket bound by the client.
+func (p *PowerdnsRecursor) queryDatagram(address string) (string, error) {
+	recvSocket := filepath.Join(p.SocketDir, fmt.Sprintf("pdns_recursor_telegraf%d", rand.Int63()))
+
+	laddr, err := net.ResolveUnixAddr("unixgram", recvSocket)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(recvSocket)
+
+	raddr, err := net.ResolveUnixAddr("unixgram", address)
+	if err != nil {
+		return "", err
+	}
+
+	conn, err := net.DialUnix("unixgram", laddr, raddr)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	if p.SocketMode != "" {
+		if err := os.Chmod(recvSocket, os.FileMode(p.mode)); err != nil {
+			return "", err
+		}
+	}
+
+	conn.SetDeadline(time.Now().Add(defaultTimeout))
+
+	// Send command
+	if _, err := fmt.Fprint(conn, "get-all\n"); err != nil {
+		return "", err
+	}
+
+	// Read data, the answer is a single datagram
+	buf := make([]byte, 65536)
+	n, err := conn.Read(buf)
+	if err != nil {
+		return "", err
+	}
+
+	return string(buf[:n]), nil
+}
+
+// queryStream sends the get-all command with the framed stream protocol, in
+// which each message is preceded by a 4 byte status code and its length as
+// an 8 byte integer, both little endian as on the hosts the Recursor runs on.
+func (p *PowerdnsRecursor) queryStream(address string) (string, error) {
+	conn, err := net.DialTimeout("unix", address, defaultTimeout)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(defaultTimeout))
+
+	command := []byte("get-all")
+
+	var request bytes.Buffer
+	binary.Write(&request, binary.LittleEndian, int32(0))
+	binary.Write(&request, binary.LittleEndian, uint64(len(command)))
+	request.Write(command)
+
+	if _, err := conn.Write(request.Bytes()); err != nil {
+		return "", err
+	}
+
+	r := bufio.NewReader(conn)
+
+	var status int32
+	if err := binary.Read(r, binary.LittleEndian, &status); err != nil {
+		return "", err
+	}
+
+	var length uint64
+	if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+		return "", err
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return "", err
+	}
+
+	if status != 0 {
+		return "", fmt.Errorf("%s: command failed with status %d: %s",
+			address, status, strings.TrimSpace(string(data)))
+	}
+
+	return string(data), nil
+}
+
+// parseResponse parses the get-all answer, one metric per line with the name
+// and the value separated by a tab.
+func parseResponse(metrics string) map[string]interface{} {
+	values := make(map[string]interface{})
+
+	for _, metric := range strings.Split(metrics, "\n") {
+		m := strings.Split(metric, "\t")
+		if len(m) < 2 {
+			continue
+		}
+
+		i, err := strconv.ParseInt(m[1], 10, 64)
+		if err != nil {
+			log.Printf("E! powerdns_recursor: Error parsing integer for metric [%s]: %s",
+				metric, err)
+			continue
+		}
+		values[m[0]] = i
+	}
+
+	return values
+}
+
+func init() {
+	inputs.Add("powerdns_recursor", func() telegraf.Input {
+		return &PowerdnsRecursor{
+			SocketDir:  "/var/run/",
+			SocketMode: "0666",
+		}
+	})
+}
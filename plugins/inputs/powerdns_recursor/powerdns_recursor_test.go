@@ -0,0 +1,157 @@
+package powerdns_recursor
+
+import (
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var metrics = "all-outqueries\t3591637\nanswers-slow\t36451\nanswers0-1\t177297\n" +
+	"answers1-10\t1209328\nanswers10-100\t1238786\nanswers100-1000\t402917\n" +
+	"auth-zone-queries\t4\nauth4-answers-slow\t44248\nauth4-answers0-1\t59169\n" +
+	"cache-entries\t295917\ncache-hits\t148630\ncache-misses\t2916149\n" +
+	"case-mismatches\t0\nconcurrent-queries\t0\nquestions\t3064779\n" +
+	"servfail-answers\t300249\nthrottle-entries\t5\nthrottled-out\t14\n" +
+	"uptime\t1069479\nsecurity-status\t1\n"
+
+var intMetrics = []string{"all-outqueries", "answers-slow", "answers0-1",
+	"answers1-10", "answers10-100", "answers100-1000", "auth-zone-queries",
+	"auth4-answers-slow", "auth4-answers0-1", "cache-entries", "cache-hits",
+	"cache-misses", "case-mismatches", "concurrent-queries", "questions",
+	"servfail-answers", "throttle-entries", "throttled-out", "uptime",
+	"security-status"}
+
+func TestPowerdnsRecursorGeneratesMetricsDatagram(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pdns")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	controlSocket := filepath.Join(dir, "pdns_recursor.controlsocket")
+	addr, err := net.ResolveUnixAddr("unixgram", controlSocket)
+	require.NoError(t, err)
+
+	socket, err := net.ListenUnixgram("unixgram", addr)
+	require.NoError(t, err)
+	defer socket.Close()
+
+	go func() {
+		buf := make([]byte, 1024)
+		n, remote, err := socket.ReadFromUnix(buf)
+		if err != nil {
+			return
+		}
+
+		if string(buf[:n]) == "get-all\n" {
+			socket.WriteToUnix([]byte(metrics), remote)
+		}
+	}()
+
+	p := &PowerdnsRecursor{
+		UnixSockets:            []string{controlSocket},
+		SocketDir:              dir,
+		SocketMode:             "0666",
+		ControlProtocolVersion: 1,
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(p.Gather))
+
+	for _, metric := range intMetrics {
+		assert.True(t, acc.HasInt64Field("powerdns_recursor", metric), metric)
+	}
+	assert.Equal(t, controlSocket, acc.TagValue("powerdns_recursor", "server"))
+}
+
+func TestPowerdnsRecursorGeneratesMetricsStream(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pdns")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	controlSocket := filepath.Join(dir, "pdns_recursor.controlsocket")
+	socket, err := net.Listen("unix", controlSocket)
+	require.NoError(t, err)
+	defer socket.Close()
+
+	go func() {
+		conn, err := socket.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		var status int32
+		var length uint64
+		binary.Read(conn, binary.LittleEndian, &status)
+		binary.Read(conn, binary.LittleEndian, &length)
+		command := make([]byte, length)
+		io.ReadFull(conn, command)
+
+		if string(command) == "get-all" {
+			binary.Write(conn, binary.LittleEndian, int32(0))
+			binary.Write(conn, binary.LittleEndian, uint64(len(metrics)))
+			conn.Write([]byte(metrics))
+		}
+	}()
+
+	p := &PowerdnsRecursor{
+		UnixSockets:            []string{controlSocket},
+		ControlProtocolVersion: 2,
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(p.Gather))
+
+	for _, metric := range intMetrics {
+		assert.True(t, acc.HasInt64Field("powerdns_recursor", metric), metric)
+	}
+}
+
+func TestPowerdnsRecursorInvalidProtocolVersion(t *testing.T) {
+	p := &PowerdnsRecursor{
+		ControlProtocolVersion: 3,
+	}
+
+	var acc testutil.Accumulator
+	require.Error(t, acc.GatherError(p.Gather))
+}
+
+func TestPowerdnsRecursorParseMetrics(t *testing.T) {
+	values := parseResponse(metrics)
+
+	tests := []struct {
+		key   string
+		value int64
+	}{
+		{"all-outqueries", 3591637},
+		{"answers0-1", 177297},
+		{"answers1-10", 1209328},
+		{"answers10-100", 1238786},
+		{"answers100-1000", 402917},
+		{"answers-slow", 36451},
+		{"cache-hits", 148630},
+		{"cache-misses", 2916149},
+		{"throttle-entries", 5},
+	}
+
+	for _, test := range tests {
+		value, ok := values[test.key]
+		if !assert.True(t, ok, test.key) {
+			continue
+		}
+		assert.Equal(t, test.value, value, test.key)
+	}
+}
+
+func TestPowerdnsRecursorParseCorruptMetrics(t *testing.T) {
+	values := parseResponse("cache-hits\t12a\ncache-misses\t3\nno-value\n")
+
+	assert.Equal(t, map[string]interface{}{"cache-misses": int64(3)}, values)
+}
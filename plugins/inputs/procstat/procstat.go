@@ -4,9 +4,11 @@ import (
 	"bytes"
 	"fmt"
 	"io/ioutil"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/influxdata/telegraf"
@@ -15,12 +17,20 @@ import (
 )
 
 var (
-	defaultPIDFinder = NewPgrep
-	defaultProcess   = NewProc
+	defaultPIDFinder   = NewPgrep
+	defaultProcess     = NewProc
+	defaultProcessTree = processTree
 )
 
 type PID int32
 
+// PidsTags is the set of PIDs found by a single selector, along with the tags
+// identifying the selector.
+type PidsTags struct {
+	PIDs []PID
+	Tags map[string]string
+}
+
 type Procstat struct {
 	PidFinder   string `toml:"pid_finder"`
 	PidFile     string `toml:"pid_file"`
@@ -33,11 +43,16 @@ type Procstat struct {
 	CGroup      string `toml:"cgroup"`
 	PidTag      bool
 
+	IncludeSystemdChildren bool `toml:"include_systemd_children"`
+	AggregateChildren      bool `toml:"aggregate_children"`
+
 	finder PIDFinder
 
-	createPIDFinder func() (PIDFinder, error)
-	procs           map[PID]Process
-	createProcess   func(PID) (Process, error)
+	createPIDFinder   func() (PIDFinder, error)
+	procs             map[PID]Process
+	children          map[PID]Process
+	createProcess     func(PID) (Process, error)
+	createProcessTree func() (map[PID][]PID, error)
 }
 
 var sampleConfig = `
@@ -51,9 +66,19 @@ var sampleConfig = `
   # user = "nginx"
   ## Systemd unit name
   # systemd_unit = "nginx.service"
-  ## CGroup name or path
+  ## CGroup name or path, glob patterns are supported and each matching
+  ## cgroup is reported with its own cgroup tag.
   # cgroup = "systemd/system.slice/nginx.service"
 
+  ## Select all processes in the control group of the systemd unit instead
+  ## of only its main process.
+  # include_systemd_children = false
+
+  ## Add the metrics of all descendants of each matched process to the
+  ## metrics of the process, the number of descendants is reported in the
+  ## num_children field.
+  # aggregate_children = false
+
   ## override for process_name
   ## This is optional; default is sourced from /proc/<pid>/status
   # process_name = "bar"
@@ -96,6 +121,9 @@ func (p *Procstat) Gather(acc telegraf.Accumulator) error {
 	if p.createProcess == nil {
 		p.createProcess = defaultProcess
 	}
+	if p.createProcessTree == nil {
+		p.createProcessTree = defaultProcessTree
+	}
 
 	procs, err := p.updateProcesses(p.procs)
 	if err != nil {
@@ -104,22 +132,35 @@ func (p *Procstat) Gather(acc telegraf.Accumulator) error {
 	}
 	p.procs = procs
 
+	var tree map[PID][]PID
+	if p.AggregateChildren {
+		tree, err = p.createProcessTree()
+		if err != nil {
+			acc.AddError(fmt.Errorf("E! Error: procstat getting process tree: %s", err))
+		}
+	}
+
+	children := make(map[PID]Process)
 	for _, proc := range p.procs {
-		p.addMetrics(proc, acc)
+		p.addMetrics(proc, tree, children, acc)
 	}
+	p.children = children
 
 	return nil
 }
 
 // Add metrics a single Process
-func (p *Procstat) addMetrics(proc Process, acc telegraf.Accumulator) {
+func (p *Procstat) addMetrics(
+	proc Process,
+	tree map[PID][]PID,
+	children map[PID]Process,
+	acc telegraf.Accumulator,
+) {
 	var prefix string
 	if p.Prefix != "" {
 		prefix = p.Prefix + "_"
 	}
 
-	fields := map[string]interface{}{}
-
 	//If process_name tag is not already set, set to actual name
 	if _, nameInTags := proc.Tags()["process_name"]; !nameInTags {
 		name, err := proc.Name()
@@ -128,11 +169,38 @@ func (p *Procstat) addMetrics(proc Process, acc telegraf.Accumulator) {
 		}
 	}
 
+	fields := processFields(proc, prefix)
+
+	if p.AggregateChildren {
+		descendants := descendants(tree, proc.PID())
+		for _, pid := range descendants {
+			child, ok := p.children[pid]
+			if !ok {
+				var err error
+				child, err = p.createProcess(pid)
+				if err != nil {
+					// Process may have ended after we found it
+					continue
+				}
+			}
+			children[pid] = child
+			aggregateFields(fields, processFields(child, prefix))
+		}
+		fields[prefix+"num_children"] = len(descendants)
+	}
+
 	//If pid is not present as a tag, include it as a field.
 	if _, pidInTags := proc.Tags()["pid"]; !pidInTags {
 		fields["pid"] = int32(proc.PID())
 	}
 
+	acc.AddFields("procstat", fields, proc.Tags())
+}
+
+// processFields returns the fields of a single Process.
+func processFields(proc Process, prefix string) map[string]interface{} {
+	fields := map[string]interface{}{}
+
 	numThreads, err := proc.NumThreads()
 	if err == nil {
 		fields[prefix+"num_threads"] = numThreads
@@ -223,26 +291,108 @@ func (p *Procstat) addMetrics(proc Process, acc telegraf.Accumulator) {
 			if name != "file_locks" { // gopsutil doesn't currently track the used file locks count
 				fields[prefix+name] = rlim.Used
 			}
+
+			if name == "num_fds" && rlim.Soft > 0 {
+				fields[prefix+"num_fds_usage"] = float64(rlim.Used) / float64(rlim.Soft) * 100
+			}
 		}
 	}
 
-	acc.AddFields("procstat", fields, proc.Tags())
+	return fields
+}
+
+// aggregateFields adds the fields of a descendant process to the fields of
+// the matched process.  The pid, the priorities and the resource limits are
+// kept from the matched process while the fd usage is the highest usage of
+// all processes, as each process has its own limit.
+func aggregateFields(dst, src map[string]interface{}) {
+	for k, v := range src {
+		if k == "pid" || strings.HasSuffix(k, "nice_priority") ||
+			strings.HasSuffix(k, "realtime_priority") || strings.Contains(k, "rlimit_") {
+			continue
+		}
+
+		cur, ok := dst[k]
+		if !ok {
+			dst[k] = v
+			continue
+		}
+
+		if strings.HasSuffix(k, "num_fds_usage") {
+			if v.(float64) > cur.(float64) {
+				dst[k] = v
+			}
+			continue
+		}
+
+		switch cur := cur.(type) {
+		case int32:
+			dst[k] = cur + v.(int32)
+		case int64:
+			dst[k] = cur + v.(int64)
+		case uint64:
+			dst[k] = cur + v.(uint64)
+		case float64:
+			dst[k] = cur + v.(float64)
+		}
+	}
+}
+
+// descendants returns the PIDs of all descendants of a process.
+func descendants(tree map[PID][]PID, pid PID) []PID {
+	var pids []PID
+	queue := tree[pid]
+	seen := map[PID]bool{pid: true}
+	for len(queue) > 0 {
+		child := queue[0]
+		queue = queue[1:]
+		if seen[child] {
+			continue
+		}
+		seen[child] = true
+		pids = append(pids, child)
+		queue = append(queue, tree[child]...)
+	}
+	return pids
+}
+
+// processTree returns the children of every process on the system, keyed by
+// the parent PID.
+func processTree() (map[PID][]PID, error) {
+	procs, err := process.Processes()
+	if err != nil {
+		return nil, err
+	}
+
+	tree := make(map[PID][]PID)
+	for _, proc := range procs {
+		ppid, err := proc.Ppid()
+		if err != nil {
+			// Process may have ended after we listed it
+			continue
+		}
+		tree[PID(ppid)] = append(tree[PID(ppid)], PID(proc.Pid))
+	}
+	return tree, nil
 }
 
 // Update monitored Processes
 func (p *Procstat) updateProcesses(prevInfo map[PID]Process) (map[PID]Process, error) {
-	pids, tags, err := p.findPids()
+	pidsTags, err := p.findPids()
 	if err != nil {
 		return nil, err
 	}
 
 	procs := make(map[PID]Process, len(prevInfo))
 
-	for _, pid := range pids {
-		info, ok := prevInfo[pid]
-		if ok {
-			procs[pid] = info
-		} else {
+	for _, pt := range pidsTags {
+		for _, pid := range pt.PIDs {
+			info, ok := prevInfo[pid]
+			if ok {
+				procs[pid] = info
+				continue
+			}
+
 			proc, err := p.createProcess(pid)
 			if err != nil {
 				// No problem; process may have ended after we found it
@@ -251,7 +401,7 @@ func (p *Procstat) updateProcesses(prevInfo map[PID]Process) (map[PID]Process, e
 			procs[pid] = proc
 
 			// Add initial tags
-			for k, v := range tags {
+			for k, v := range pt.Tags {
 				proc.Tags()[k] = v
 			}
 
@@ -281,14 +431,14 @@ func (p *Procstat) getPIDFinder() (PIDFinder, error) {
 }
 
 // Get matching PIDs and their initial tags
-func (p *Procstat) findPids() ([]PID, map[string]string, error) {
+func (p *Procstat) findPids() ([]PidsTags, error) {
 	var pids []PID
 	var tags map[string]string
 	var err error
 
 	f, err := p.getPIDFinder()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	if p.PidFile != "" {
@@ -307,13 +457,15 @@ func (p *Procstat) findPids() ([]PID, map[string]string, error) {
 		pids, err = p.systemdUnitPIDs()
 		tags = map[string]string{"systemd_unit": p.SystemdUnit}
 	} else if p.CGroup != "" {
-		pids, err = p.cgroupPIDs()
-		tags = map[string]string{"cgroup": p.CGroup}
+		return p.cgroupPIDs()
 	} else {
 		err = fmt.Errorf("Either exe, pid_file, user, pattern, systemd_unit, or cgroup must be specified")
 	}
 
-	return pids, tags, err
+	if err != nil {
+		return nil, err
+	}
+	return []PidsTags{{PIDs: pids, Tags: tags}}, nil
 }
 
 // execCommand is so tests can mock out exec.Command usage.
@@ -331,6 +483,17 @@ func (p *Procstat) systemdUnitPIDs() ([]PID, error) {
 		if len(kv) != 2 {
 			continue
 		}
+
+		if p.IncludeSystemdChildren {
+			if !bytes.Equal(kv[0], []byte("ControlGroup")) {
+				continue
+			}
+			if len(kv[1]) == 0 {
+				return nil, nil
+			}
+			return readCgroupPIDs(systemdCgroupPath(string(kv[1])))
+		}
+
 		if !bytes.Equal(kv[0], []byte("MainPID")) {
 			continue
 		}
@@ -346,15 +509,65 @@ func (p *Procstat) systemdUnitPIDs() ([]PID, error) {
 	return pids, nil
 }
 
-func (p *Procstat) cgroupPIDs() ([]PID, error) {
-	var pids []PID
+// cgroupRoot is where the cgroup filesystem is mounted.
+var cgroupRoot = "/sys/fs/cgroup"
+
+// systemdCgroupPath returns the directory of a systemd control group, on
+// hosts with the unified hierarchy this is directly below the cgroup root,
+// otherwise it is found in the named systemd hierarchy.
+func systemdCgroupPath(controlGroup string) string {
+	path := filepath.Join(cgroupRoot, controlGroup)
+	if _, err := os.Stat(filepath.Join(path, "cgroup.procs")); err == nil {
+		return path
+	}
+	return filepath.Join(cgroupRoot, "systemd", controlGroup)
+}
 
+func (p *Procstat) cgroupPIDs() ([]PidsTags, error) {
 	procsPath := p.CGroup
 	if procsPath[0] != '/' {
-		procsPath = "/sys/fs/cgroup/" + procsPath
+		procsPath = cgroupRoot + "/" + procsPath
 	}
-	procsPath = filepath.Join(procsPath, "cgroup.procs")
-	out, err := ioutil.ReadFile(procsPath)
+
+	if !strings.ContainsAny(procsPath, "*?[") {
+		pids, err := readCgroupPIDs(procsPath)
+		if err != nil {
+			return nil, err
+		}
+		return []PidsTags{{PIDs: pids, Tags: map[string]string{"cgroup": p.CGroup}}}, nil
+	}
+
+	items, err := filepath.Glob(procsPath)
+	if err != nil {
+		return nil, fmt.Errorf("glob failed '%s'", err)
+	}
+
+	pidsTags := make([]PidsTags, 0, len(items))
+	for _, item := range items {
+		if info, err := os.Stat(item); err != nil || !info.IsDir() {
+			continue
+		}
+
+		pids, err := readCgroupPIDs(item)
+		if err != nil {
+			return nil, err
+		}
+
+		// Report the cgroup in the same form as it was configured
+		cgroup := item
+		if p.CGroup[0] != '/' {
+			cgroup = strings.TrimPrefix(item, cgroupRoot+"/")
+		}
+		pidsTags = append(pidsTags, PidsTags{PIDs: pids, Tags: map[string]string{"cgroup": cgroup}})
+	}
+
+	return pidsTags, nil
+}
+
+func readCgroupPIDs(path string) ([]PID, error) {
+	var pids []PID
+
+	out, err := ioutil.ReadFile(filepath.Join(path, "cgroup.procs"))
 	if err != nil {
 		return nil, err
 	}
@@ -50,6 +50,7 @@ GuessMainPID=yes
 MainPID=11408
 ControlPID=0
 ExecMainPID=11408
+ControlGroup=/system.slice/TestGather_systemdUnitPIDs.service
 `)
 		os.Exit(0)
 	}
@@ -95,6 +96,7 @@ type testProc struct {
 
 func newTestProc(pid PID) (Process, error) {
 	proc := &testProc{
+		pid:  pid,
 		tags: make(map[string]string),
 	}
 	return proc, nil
@@ -129,7 +131,7 @@ func (p *testProc) NumFDs() (int32, error) {
 }
 
 func (p *testProc) NumThreads() (int32, error) {
-	return 0, nil
+	return 2, nil
 }
 
 func (p *testProc) Percent(interval time.Duration) (float64, error) {
@@ -144,6 +146,38 @@ func (p *testProc) RlimitUsage(gatherUsage bool) ([]process.RlimitStat, error) {
 	return []process.RlimitStat{}, nil
 }
 
+// testFdProc is a testProc which uses as many file descriptors as its PID
+type testFdProc struct {
+	testProc
+}
+
+func newTestFdProc(pid PID) (Process, error) {
+	proc := &testFdProc{
+		testProc{
+			pid:  pid,
+			tags: make(map[string]string),
+		},
+	}
+	return proc, nil
+}
+
+func (p *testFdProc) RlimitUsage(gatherUsage bool) ([]process.RlimitStat, error) {
+	return []process.RlimitStat{
+		{
+			Resource: process.RLIMIT_NOFILE,
+			Soft:     1024,
+			Hard:     4096,
+			Used:     uint64(p.pid),
+		},
+		{
+			Resource: process.RLIMIT_NICE,
+			Soft:     20,
+			Hard:     20,
+			Used:     5,
+		},
+	}, nil
+}
+
 var pid PID = PID(42)
 var exe string = "foo"
 
@@ -343,10 +377,40 @@ func TestGather_systemdUnitPIDs(t *testing.T) {
 		createPIDFinder: pidFinder([]PID{}, nil),
 		SystemdUnit:     "TestGather_systemdUnitPIDs",
 	}
-	pids, tags, err := p.findPids()
+	pidsTags, err := p.findPids()
+	require.NoError(t, err)
+	require.Len(t, pidsTags, 1)
+	assert.Equal(t, []PID{11408}, pidsTags[0].PIDs)
+	assert.Equal(t, "TestGather_systemdUnitPIDs", pidsTags[0].Tags["systemd_unit"])
+}
+
+func TestGather_systemdUnitChildrenPIDs(t *testing.T) {
+	//no cgroups in windows
+	if runtime.GOOS == "windows" {
+		t.Skip("no cgroups in windows")
+	}
+	td, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(td)
+
+	defer func(root string) { cgroupRoot = root }(cgroupRoot)
+	cgroupRoot = td
+
+	dir := filepath.Join(td, "systemd", "system.slice", "TestGather_systemdUnitPIDs.service")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	err = ioutil.WriteFile(filepath.Join(dir, "cgroup.procs"), []byte("11408\n11409\n"), 0644)
 	require.NoError(t, err)
-	assert.Equal(t, []PID{11408}, pids)
-	assert.Equal(t, "TestGather_systemdUnitPIDs", tags["systemd_unit"])
+
+	p := Procstat{
+		createPIDFinder:        pidFinder([]PID{}, nil),
+		SystemdUnit:            "TestGather_systemdUnitPIDs",
+		IncludeSystemdChildren: true,
+	}
+	pidsTags, err := p.findPids()
+	require.NoError(t, err)
+	require.Len(t, pidsTags, 1)
+	assert.Equal(t, []PID{11408, 11409}, pidsTags[0].PIDs)
+	assert.Equal(t, "TestGather_systemdUnitPIDs", pidsTags[0].Tags["systemd_unit"])
 }
 
 func TestGather_cgroupPIDs(t *testing.T) {
@@ -364,8 +428,121 @@ func TestGather_cgroupPIDs(t *testing.T) {
 		createPIDFinder: pidFinder([]PID{}, nil),
 		CGroup:          td,
 	}
-	pids, tags, err := p.findPids()
+	pidsTags, err := p.findPids()
 	require.NoError(t, err)
-	assert.Equal(t, []PID{1234, 5678}, pids)
-	assert.Equal(t, td, tags["cgroup"])
+	require.Len(t, pidsTags, 1)
+	assert.Equal(t, []PID{1234, 5678}, pidsTags[0].PIDs)
+	assert.Equal(t, td, pidsTags[0].Tags["cgroup"])
+}
+
+func TestGather_cgroupGlobPIDs(t *testing.T) {
+	//no cgroups in windows
+	if runtime.GOOS == "windows" {
+		t.Skip("no cgroups in windows")
+	}
+	td, err := ioutil.TempDir("", "")
+	require.NoError(t, err)
+	defer os.RemoveAll(td)
+
+	defer func(root string) { cgroupRoot = root }(cgroupRoot)
+	cgroupRoot = td
+
+	for name, procs := range map[string]string{
+		"nginx.service": "1234\n",
+		"redis.service": "5678\n",
+	} {
+		dir := filepath.Join(td, "system.slice", name)
+		require.NoError(t, os.MkdirAll(dir, 0755))
+		err = ioutil.WriteFile(filepath.Join(dir, "cgroup.procs"), []byte(procs), 0644)
+		require.NoError(t, err)
+	}
+
+	var acc testutil.Accumulator
+	p := Procstat{
+		CGroup:          "system.slice/*.service",
+		createPIDFinder: pidFinder([]PID{}, nil),
+		createProcess:   newTestProc,
+	}
+	require.NoError(t, acc.GatherError(p.Gather))
+	require.Len(t, acc.Metrics, 2)
+
+	for _, m := range acc.Metrics {
+		switch m.Fields["pid"] {
+		case int32(1234):
+			assert.Equal(t, "system.slice/nginx.service", m.Tags["cgroup"])
+		case int32(5678):
+			assert.Equal(t, "system.slice/redis.service", m.Tags["cgroup"])
+		default:
+			t.Errorf("unexpected pid %v", m.Fields["pid"])
+		}
+	}
+}
+
+func TestGather_FdUsage(t *testing.T) {
+	var acc testutil.Accumulator
+
+	p := Procstat{
+		Exe:             exe,
+		createPIDFinder: pidFinder([]PID{512}, nil),
+		createProcess:   newTestFdProc,
+	}
+	require.NoError(t, acc.GatherError(p.Gather))
+
+	usage, ok := acc.FloatField("procstat", "num_fds_usage")
+	require.True(t, ok)
+	assert.Equal(t, 50.0, usage)
+}
+
+func TestGather_AggregateChildren(t *testing.T) {
+	var acc testutil.Accumulator
+
+	p := Procstat{
+		Exe:               exe,
+		AggregateChildren: true,
+		createPIDFinder:   pidFinder([]PID{100}, nil),
+		createProcess:     newTestFdProc,
+		createProcessTree: func() (map[PID][]PID, error) {
+			return map[PID][]PID{
+				1:   {100, 200},
+				100: {101, 102},
+				102: {103},
+				200: {201},
+			}, nil
+		},
+	}
+	require.NoError(t, acc.GatherError(p.Gather))
+	require.Len(t, acc.Metrics, 1)
+
+	fields := acc.Metrics[0].Fields
+	assert.Equal(t, int32(100), fields["pid"])
+	assert.Equal(t, 3, fields["num_children"])
+	assert.Equal(t, int32(8), fields["num_threads"])
+	// used file descriptors are summed while the limits are kept from the
+	// matched process
+	assert.Equal(t, uint64(100+101+102+103), fields["num_fds"])
+	assert.Equal(t, int32(1024), fields["rlimit_num_fds_soft"])
+	assert.Equal(t, float64(103)/1024*100, fields["num_fds_usage"])
+}
+
+func TestGather_AggregateChildrenNoPidTag(t *testing.T) {
+	var acc testutil.Accumulator
+
+	p := Procstat{
+		Exe:               exe,
+		PidTag:            false,
+		AggregateChildren: true,
+		createPIDFinder:   pidFinder([]PID{100}, nil),
+		createProcess:     newTestFdProc,
+		createProcessTree: func() (map[PID][]PID, error) {
+			return map[PID][]PID{100: {101, 102}}, nil
+		},
+	}
+	require.NoError(t, acc.GatherError(p.Gather))
+	require.Len(t, acc.Metrics, 1)
+
+	// The pid and the priority are the ones of the matched process
+	fields := acc.Metrics[0].Fields
+	assert.Equal(t, int32(100), fields["pid"])
+	assert.Equal(t, uint64(5), fields["nice_priority"])
+	assert.Equal(t, uint64(100+101+102), fields["num_fds"])
 }
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 	"sync"
 	"time"
@@ -49,6 +50,11 @@ type RabbitMQ struct {
 
 	QueueInclude []string `toml:"queue_name_include"`
 	QueueExclude []string `toml:"queue_name_exclude"`
+	MaxQueues    int      `toml:"max_queues"`
+
+	GatherFederation bool `toml:"gather_federation"`
+	GatherShovels    bool `toml:"gather_shovels"`
+	NodeHealthCheck  bool `toml:"node_health_check"`
 
 	Client *http.Client
 
@@ -125,6 +131,16 @@ type Queue struct {
 	Durable             bool
 	AutoDelete          bool   `json:"auto_delete"`
 	IdleSince           string `json:"idle_since"`
+
+	MessagesDetails               Details `json:"messages_details"`
+	MessagesReadyDetails          Details `json:"messages_ready_details"`
+	MessagesUnacknowledgedDetails Details `json:"messages_unacknowledged_details"`
+}
+
+// QueuesPage is a page of the queues, listed when max_queues is set
+type QueuesPage struct {
+	Items     []Queue `json:"items"`
+	PageCount int     `json:"page_count"`
 }
 
 // Node ...
@@ -155,10 +171,51 @@ type Exchange struct {
 	AutoDelete   bool `json:"auto_delete"`
 }
 
+// FederationLink ...
+type FederationLink struct {
+	Type             string `json:"type"`
+	Queue            string `json:"queue"`
+	UpstreamQueue    string `json:"upstream_queue"`
+	Exchange         string `json:"exchange"`
+	UpstreamExchange string `json:"upstream_exchange"`
+	Vhost            string `json:"vhost"`
+	Upstream         string `json:"upstream"`
+	Node             string `json:"node"`
+	Status           string `json:"status"`
+	LocalChannel     struct {
+		ConsumerCount          int64 `json:"consumer_count"`
+		MessagesUnacknowledged int64 `json:"messages_unacknowledged"`
+		MessagesUnconfirmed    int64 `json:"messages_unconfirmed"`
+		MessageStats           struct {
+			Confirm          int64 `json:"confirm"`
+			Publish          int64 `json:"publish"`
+			ReturnUnroutable int64 `json:"return_unroutable"`
+		} `json:"message_stats"`
+	} `json:"local_channel"`
+}
+
+// Shovel ...
+type Shovel struct {
+	Name  string `json:"name"`
+	Vhost string `json:"vhost"`
+	Type  string `json:"type"`
+	Node  string `json:"node"`
+	State string `json:"state"`
+}
+
+// HealthCheck ...
+type HealthCheck struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
 // gatherFunc ...
 type gatherFunc func(r *RabbitMQ, acc telegraf.Accumulator)
 
-var gatherFunctions = []gatherFunc{gatherOverview, gatherNodes, gatherQueues, gatherExchanges}
+var gatherFunctions = []gatherFunc{gatherOverview, gatherNodes, gatherQueues, gatherExchanges, gatherFederationLinks, gatherShovels}
+
+// The largest page size accepted by the Management Plugin
+const maxPageSize = 500
 
 var sampleConfig = `
   ## Management Plugin url. (default: http://localhost:15672)
@@ -202,6 +259,20 @@ var sampleConfig = `
   ## Note that an empty array for both will include all queues
   queue_name_include = []
   queue_name_exclude = []
+
+  ## Upper bound of the number of queues gathered, 0 for no limit. When set,
+  ## the queues are requested by pages to limit the load of large brokers.
+  # max_queues = 0
+
+  ## Gather the federation links as the rabbitmq_federation measurement and
+  ## the shovels as the rabbitmq_shovel measurement.  These require the
+  ## rabbitmq_federation_management and rabbitmq_shovel_management plugins.
+  # gather_federation = false
+  # gather_shovels = false
+
+  ## Run the health check of each gathered node, in the health_check_status
+  ## field of the rabbitmq_node measurement.
+  # node_health_check = false
 `
 
 // SampleConfig ...
@@ -253,7 +324,7 @@ func (r *RabbitMQ) Gather(acc telegraf.Accumulator) error {
 	return nil
 }
 
-func (r *RabbitMQ) requestJSON(u string, target interface{}) error {
+func (r *RabbitMQ) request(u string) (*http.Response, error) {
 	if r.URL == "" {
 		r.URL = DefaultURL
 	}
@@ -261,7 +332,7 @@ func (r *RabbitMQ) requestJSON(u string, target interface{}) error {
 
 	req, err := http.NewRequest("GET", u, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	username := r.Username
@@ -276,13 +347,21 @@ func (r *RabbitMQ) requestJSON(u string, target interface{}) error {
 
 	req.SetBasicAuth(username, password)
 
-	resp, err := r.Client.Do(req)
+	return r.Client.Do(req)
+}
+
+func (r *RabbitMQ) requestJSON(u string, target interface{}) error {
+	resp, err := r.request(u)
 	if err != nil {
 		return err
 	}
 
 	defer resp.Body.Close()
 
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s%s returned HTTP status %s", r.URL, u, resp.Status)
+	}
+
 	json.NewDecoder(resp.Body).Decode(target)
 
 	return nil
@@ -371,6 +450,14 @@ func gatherNodes(r *RabbitMQ, acc telegraf.Accumulator) {
 			"sockets_used":    node.SocketsUsed,
 			"running":         running,
 		}
+		if r.NodeHealthCheck {
+			status, err := r.nodeHealthCheck(node.Name)
+			if err != nil {
+				acc.AddError(err)
+			} else {
+				fields["health_check_status"] = status
+			}
+		}
 		acc.AddFields("rabbitmq_node", fields, tags, now)
 	}
 }
@@ -380,17 +467,22 @@ func gatherQueues(r *RabbitMQ, acc telegraf.Accumulator) {
 		return
 	}
 	// Gather information about queues
-	queues := make([]Queue, 0)
-	err := r.requestJSON("/api/queues", &queues)
+	queues, err := r.requestQueues()
 	if err != nil {
 		acc.AddError(err)
 		return
 	}
 
+	gathered := 0
 	for _, queue := range queues {
 		if !r.queueFilter.Match(queue.Name) {
 			continue
 		}
+		if r.MaxQueues > 0 && gathered >= r.MaxQueues {
+			break
+		}
+		gathered++
+
 		tags := map[string]string{
 			"url":         r.URL,
 			"queue":       queue.Name,
@@ -415,8 +507,11 @@ func gatherQueues(r *RabbitMQ, acc telegraf.Accumulator) {
 				"message_bytes_ram":         queue.MessageRAM,
 				"message_bytes_persist":     queue.MessagePersistent,
 				"messages":                  queue.Messages,
+				"messages_rate":             queue.MessagesDetails.Rate,
 				"messages_ready":            queue.MessagesReady,
+				"messages_ready_rate":       queue.MessagesReadyDetails.Rate,
 				"messages_unack":            queue.MessagesUnacknowledged,
+				"messages_unack_rate":       queue.MessagesUnacknowledgedDetails.Rate,
 				"messages_ack":              queue.MessageStats.Ack,
 				"messages_ack_rate":         queue.MessageStats.AckDetails.Rate,
 				"messages_deliver":          queue.MessageStats.Deliver,
@@ -433,6 +528,42 @@ func gatherQueues(r *RabbitMQ, acc telegraf.Accumulator) {
 	}
 }
 
+// requestQueues returns the queues of the broker.  When max_queues is set,
+// the queues are requested by pages, until enough queues match the filter.
+func (r *RabbitMQ) requestQueues() ([]Queue, error) {
+	if r.MaxQueues <= 0 {
+		queues := make([]Queue, 0)
+		err := r.requestJSON("/api/queues", &queues)
+		return queues, err
+	}
+
+	pageSize := r.MaxQueues
+	if pageSize > maxPageSize {
+		pageSize = maxPageSize
+	}
+
+	var queues []Queue
+	matched := 0
+	for page := 1; ; page++ {
+		var qp QueuesPage
+		u := fmt.Sprintf("/api/queues?page=%d&page_size=%d", page, pageSize)
+		if err := r.requestJSON(u, &qp); err != nil {
+			return nil, err
+		}
+
+		for _, queue := range qp.Items {
+			if r.queueFilter.Match(queue.Name) {
+				matched++
+			}
+			queues = append(queues, queue)
+		}
+
+		if matched >= r.MaxQueues || page >= qp.PageCount {
+			return queues, nil
+		}
+	}
+}
+
 func gatherExchanges(r *RabbitMQ, acc telegraf.Accumulator) {
 	// Gather information about exchanges
 	exchanges := make([]Exchange, 0)
@@ -467,6 +598,120 @@ func gatherExchanges(r *RabbitMQ, acc telegraf.Accumulator) {
 	}
 }
 
+func gatherFederationLinks(r *RabbitMQ, acc telegraf.Accumulator) {
+	if !r.GatherFederation {
+		return
+	}
+
+	// Gather information about the federation links
+	links := make([]FederationLink, 0)
+	err := r.requestJSON("/api/federation-links", &links)
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+
+	for _, link := range links {
+		tags := map[string]string{
+			"url":      r.URL,
+			"type":     link.Type,
+			"vhost":    link.Vhost,
+			"upstream": link.Upstream,
+			"node":     link.Node,
+		}
+		if link.Type == "exchange" {
+			tags["exchange"] = link.Exchange
+			tags["upstream_exchange"] = link.UpstreamExchange
+		} else {
+			tags["queue"] = link.Queue
+			tags["upstream_queue"] = link.UpstreamQueue
+		}
+
+		var running int64 = 0
+		if link.Status == "running" {
+			running = 1
+		}
+
+		acc.AddFields(
+			"rabbitmq_federation",
+			map[string]interface{}{
+				"status":                     link.Status,
+				"running":                    running,
+				"consumers":                  link.LocalChannel.ConsumerCount,
+				"messages_unack":             link.LocalChannel.MessagesUnacknowledged,
+				"messages_unconfirmed":       link.LocalChannel.MessagesUnconfirmed,
+				"messages_confirm":           link.LocalChannel.MessageStats.Confirm,
+				"messages_publish":           link.LocalChannel.MessageStats.Publish,
+				"messages_return_unroutable": link.LocalChannel.MessageStats.ReturnUnroutable,
+			},
+			tags,
+		)
+	}
+}
+
+func gatherShovels(r *RabbitMQ, acc telegraf.Accumulator) {
+	if !r.GatherShovels {
+		return
+	}
+
+	// Gather information about the shovels
+	shovels := make([]Shovel, 0)
+	err := r.requestJSON("/api/shovels", &shovels)
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+
+	for _, shovel := range shovels {
+		tags := map[string]string{
+			"url":    r.URL,
+			"shovel": shovel.Name,
+			"vhost":  shovel.Vhost,
+			"type":   shovel.Type,
+			"node":   shovel.Node,
+		}
+
+		var running int64 = 0
+		if shovel.State == "running" {
+			running = 1
+		}
+
+		acc.AddFields(
+			"rabbitmq_shovel",
+			map[string]interface{}{
+				"state":   shovel.State,
+				"running": running,
+			},
+			tags,
+		)
+	}
+}
+
+// nodeHealthCheck returns 1 when the health check of the node passes, and 0
+// when it fails.  Failed checks are answered with a 503 status by the recent
+// versions of the Management Plugin, and with a 200 status by the older ones.
+func (r *RabbitMQ) nodeHealthCheck(name string) (int64, error) {
+	resp, err := r.request("/api/healthchecks/node/" + url.PathEscape(name))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusServiceUnavailable {
+		return 0, fmt.Errorf("health check of node %s returned HTTP status %s", name, resp.Status)
+	}
+
+	var check HealthCheck
+	if err := json.NewDecoder(resp.Body).Decode(&check); err != nil {
+		return 0, fmt.Errorf("error decoding health check of node %s: %s", name, err)
+	}
+
+	if check.Status == "ok" {
+		return 1, nil
+	}
+	return 0, nil
+}
+
 func (r *RabbitMQ) shouldGatherNode(node Node) bool {
 	if len(r.Nodes) == 0 {
 		return true
@@ -570,3 +570,253 @@ func TestRabbitMQGeneratesMetrics(t *testing.T) {
 		assert.True(t, acc.HasInt64Field("rabbitmq_exchange", metric))
 	}
 }
+
+const sampleFederationLinksResponse = `
+[
+  {
+    "node": "rabbit@node-a",
+    "exchange": "telegraf",
+    "upstream_exchange": "telegraf",
+    "type": "exchange",
+    "vhost": "/",
+    "upstream": "upstream-1",
+    "id": "9f1b9bb0",
+    "status": "running",
+    "local_connection": "<rabbit@node-a.1.2.3>",
+    "uri": "amqp://upstream-1",
+    "timestamp": "2018-07-12 10:13:05",
+    "local_channel": {
+      "acks_uncommitted": 0,
+      "consumer_count": 1,
+      "messages_unacknowledged": 2,
+      "messages_unconfirmed": 3,
+      "messages_uncommitted": 0,
+      "number": 1,
+      "prefetch_count": 1000,
+      "state": "running",
+      "transactional": false,
+      "message_stats": {
+        "confirm": 42,
+        "publish": 43,
+        "return_unroutable": 1
+      }
+    }
+  },
+  {
+    "node": "rabbit@node-a",
+    "queue": "collectd-queue",
+    "upstream_queue": "collectd-queue",
+    "type": "queue",
+    "vhost": "/",
+    "upstream": "upstream-2",
+    "id": "6a4e5bf1",
+    "status": "starting"
+  }
+]
+`
+
+const sampleShovelsResponse = `
+[
+  {
+    "node": "rabbit@node-a",
+    "timestamp": "2018-07-12 10:13:05",
+    "name": "shovel-1",
+    "vhost": "/",
+    "type": "dynamic",
+    "state": "running",
+    "src_uri": "amqp://",
+    "dest_uri": "amqp://remote"
+  },
+  {
+    "node": "rabbit@node-b",
+    "timestamp": "2018-07-12 10:13:05",
+    "name": "shovel-2",
+    "vhost": "/",
+    "type": "static",
+    "state": "terminated",
+    "reason": "connection refused"
+  }
+]
+`
+
+func TestRabbitMQGatherFederationAndShovels(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var rsp string
+
+		switch r.URL.Path {
+		case "/api/overview":
+			rsp = sampleOverviewResponse
+		case "/api/nodes":
+			rsp = sampleNodesResponse
+		case "/api/queues":
+			rsp = sampleQueuesResponse
+		case "/api/exchanges":
+			rsp = sampleExchangesResponse
+		case "/api/federation-links":
+			rsp = sampleFederationLinksResponse
+		case "/api/shovels":
+			rsp = sampleShovelsResponse
+		default:
+			panic("Cannot handle request")
+		}
+
+		fmt.Fprintln(w, rsp)
+	}))
+	defer ts.Close()
+
+	r := &RabbitMQ{
+		URL:              ts.URL,
+		GatherFederation: true,
+		GatherShovels:    true,
+	}
+
+	var acc testutil.Accumulator
+
+	err := acc.GatherError(r.Gather)
+	require.NoError(t, err)
+
+	acc.AssertContainsTaggedFields(t, "rabbitmq_federation",
+		map[string]interface{}{
+			"status":                     "running",
+			"running":                    int64(1),
+			"consumers":                  int64(1),
+			"messages_unack":             int64(2),
+			"messages_unconfirmed":       int64(3),
+			"messages_confirm":           int64(42),
+			"messages_publish":           int64(43),
+			"messages_return_unroutable": int64(1),
+		},
+		map[string]string{
+			"url":               ts.URL,
+			"type":              "exchange",
+			"vhost":             "/",
+			"upstream":          "upstream-1",
+			"node":              "rabbit@node-a",
+			"exchange":          "telegraf",
+			"upstream_exchange": "telegraf",
+		})
+
+	acc.AssertContainsTaggedFields(t, "rabbitmq_federation",
+		map[string]interface{}{
+			"status":                     "starting",
+			"running":                    int64(0),
+			"consumers":                  int64(0),
+			"messages_unack":             int64(0),
+			"messages_unconfirmed":       int64(0),
+			"messages_confirm":           int64(0),
+			"messages_publish":           int64(0),
+			"messages_return_unroutable": int64(0),
+		},
+		map[string]string{
+			"url":            ts.URL,
+			"type":           "queue",
+			"vhost":          "/",
+			"upstream":       "upstream-2",
+			"node":           "rabbit@node-a",
+			"queue":          "collectd-queue",
+			"upstream_queue": "collectd-queue",
+		})
+
+	acc.AssertContainsTaggedFields(t, "rabbitmq_shovel",
+		map[string]interface{}{
+			"state":   "running",
+			"running": int64(1),
+		},
+		map[string]string{
+			"url":    ts.URL,
+			"shovel": "shovel-1",
+			"vhost":  "/",
+			"type":   "dynamic",
+			"node":   "rabbit@node-a",
+		})
+
+	acc.AssertContainsTaggedFields(t, "rabbitmq_shovel",
+		map[string]interface{}{
+			"state":   "terminated",
+			"running": int64(0),
+		},
+		map[string]string{
+			"url":    ts.URL,
+			"shovel": "shovel-2",
+			"vhost":  "/",
+			"type":   "static",
+			"node":   "rabbit@node-b",
+		})
+}
+
+func TestRabbitMQNodeHealthCheck(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/nodes":
+			fmt.Fprintln(w, `[{"name": "rabbit@node-a"}, {"name": "rabbit@node-b"}]`)
+		case "/api/healthchecks/node/rabbit@node-a":
+			fmt.Fprintln(w, `{"status": "ok"}`)
+		case "/api/healthchecks/node/rabbit@node-b":
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintln(w, `{"status": "failed", "reason": "timeout"}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer ts.Close()
+
+	r := &RabbitMQ{
+		URL:             ts.URL,
+		NodeHealthCheck: true,
+		Client:          ts.Client(),
+	}
+
+	var acc testutil.Accumulator
+	gatherNodes(r, &acc)
+	require.Empty(t, acc.Errors)
+
+	for _, m := range acc.Metrics {
+		switch m.Tags["node"] {
+		case "rabbit@node-a":
+			assert.Equal(t, int64(1), m.Fields["health_check_status"])
+		case "rabbit@node-b":
+			assert.Equal(t, int64(0), m.Fields["health_check_status"])
+		}
+	}
+	assert.Len(t, acc.Metrics, 2)
+}
+
+func TestRabbitMQMaxQueues(t *testing.T) {
+	pages := map[string]string{
+		"1": `{"items": [{"name": "q1"}, {"name": "excluded"}], "page": 1, "page_count": 3}`,
+		"2": `{"items": [{"name": "q2"}, {"name": "q3"}], "page": 2, "page_count": 3}`,
+		"3": `{"items": [{"name": "q4"}, {"name": "q5"}], "page": 3, "page_count": 3}`,
+	}
+
+	var requested []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/queues" {
+			http.NotFound(w, r)
+			return
+		}
+		assert.Equal(t, "2", r.URL.Query().Get("page_size"))
+		page := r.URL.Query().Get("page")
+		requested = append(requested, page)
+		fmt.Fprintln(w, pages[page])
+	}))
+	defer ts.Close()
+
+	r := &RabbitMQ{
+		URL:          ts.URL,
+		MaxQueues:    2,
+		QueueExclude: []string{"excluded"},
+		Client:       ts.Client(),
+	}
+	require.NoError(t, r.createQueueFilter())
+
+	var acc testutil.Accumulator
+	gatherQueues(r, &acc)
+	require.Empty(t, acc.Errors)
+
+	var queues []string
+	for _, m := range acc.Metrics {
+		queues = append(queues, m.Tags["queue"])
+	}
+	assert.Equal(t, []string{"q1", "q2"}, queues)
+	assert.Equal(t, []string{"1", "2"}, requested)
+}
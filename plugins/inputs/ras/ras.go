@@ -0,0 +1,288 @@
+package ras
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+// default host sys path
+const defaultHostSys = "/sys"
+
+// env host sys variable name
+const envSys = "HOST_SYS"
+
+// ch0_ce_count, ch1_dimm_label
+var channelRe = regexp.MustCompile(`^ch(\d+)_(ce_count|dimm_label)$`)
+
+// mciStatusUC is the bit of the MCi_STATUS register set for the uncorrected
+// errors.
+const mciStatusUC = 61
+
+type Ras struct {
+	HostSys  string `toml:"host_sys"`
+	Database string `toml:"database"`
+}
+
+var sampleConfig = `
+  ## Sets 'sys' directory path
+  ## If not specified, then default is /sys
+  # host_sys = "/sys"
+
+  ## Read the machine-check exceptions and the memory errors recorded by
+  ## rasdaemon from its sqlite database.
+  # database = "/var/lib/rasdaemon/ras-mc_event.db"
+`
+
+func (r *Ras) Description() string {
+	return "Gather the memory and machine-check errors from EDAC and rasdaemon"
+}
+
+func (r *Ras) SampleConfig() string {
+	return sampleConfig
+}
+
+func (r *Ras) Gather(acc telegraf.Accumulator) error {
+	if r.HostSys == "" {
+		r.HostSys = sys(envSys, defaultHostSys)
+	}
+
+	controllers, err := filepath.Glob(filepath.Join(r.HostSys, "devices", "system", "edac", "mc", "mc*"))
+	if err != nil {
+		return err
+	}
+	for _, controller := range controllers {
+		gatherController(acc, controller)
+	}
+
+	if r.Database != "" {
+		return r.gatherDatabase(acc)
+	}
+	return nil
+}
+
+// gatherController gathers the error counts of a memory controller, and of
+// its DIMMs.
+func gatherController(acc telegraf.Accumulator, dir string) {
+	controller := filepath.Base(dir)
+	fields := readCounts(dir, map[string]string{
+		"ce_count":        "ce_count",
+		"ue_count":        "ue_count",
+		"ce_noinfo_count": "ce_noinfo_count",
+		"ue_noinfo_count": "ue_noinfo_count",
+		"size_mb":         "size_mb",
+	})
+	if len(fields) == 0 {
+		return
+	}
+
+	tags := map[string]string{"controller": controller}
+	if name, err := readString(filepath.Join(dir, "mc_name")); err == nil {
+		tags["mc_name"] = name
+	}
+	acc.AddFields("ras_edac_mc", fields, tags)
+
+	// Kernels since 3.6 report the DIMMs, or the ranks, in their own
+	// directory, older ones only per channel of the chip-select rows.
+	dimms, _ := filepath.Glob(filepath.Join(dir, "dimm*"))
+	ranks, _ := filepath.Glob(filepath.Join(dir, "rank*"))
+	dimms = append(dimms, ranks...)
+	for _, dimm := range dimms {
+		gatherDimm(acc, controller, dimm)
+	}
+	if len(dimms) == 0 {
+		csrows, _ := filepath.Glob(filepath.Join(dir, "csrow*"))
+		for _, csrow := range csrows {
+			gatherCsrow(acc, controller, csrow)
+		}
+	}
+}
+
+func gatherDimm(acc telegraf.Accumulator, controller, dir string) {
+	fields := readCounts(dir, map[string]string{
+		"dimm_ce_count": "ce_count",
+		"dimm_ue_count": "ue_count",
+		"size":          "size_mb",
+	})
+	if len(fields) == 0 {
+		return
+	}
+
+	tags := map[string]string{
+		"controller": controller,
+		"dimm":       filepath.Base(dir),
+	}
+	if label, err := readString(filepath.Join(dir, "dimm_label")); err == nil && label != "" {
+		tags["label"] = label
+	}
+	if location, err := readString(filepath.Join(dir, "dimm_location")); err == nil && location != "" {
+		tags["location"] = location
+	}
+	acc.AddFields("ras_edac_dimm", fields, tags)
+}
+
+// gatherCsrow gathers the corrected error counts of each channel of a
+// chip-select row, uncorrected errors being only counted per row.
+func gatherCsrow(acc telegraf.Accumulator, controller, dir string) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		acc.AddError(err)
+		return
+	}
+
+	labels := make(map[string]string)
+	counts := make(map[string]int64)
+	for _, file := range files {
+		m := channelRe.FindStringSubmatch(file.Name())
+		if m == nil {
+			continue
+		}
+		value, err := readString(filepath.Join(dir, file.Name()))
+		if err != nil {
+			continue
+		}
+		if m[2] == "dimm_label" {
+			labels[m[1]] = value
+		} else if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+			counts[m[1]] = v
+		}
+	}
+
+	for channel, count := range counts {
+		tags := map[string]string{
+			"controller": controller,
+			"dimm":       filepath.Base(dir) + "_ch" + channel,
+			"location":   "csrow " + strings.TrimPrefix(filepath.Base(dir), "csrow") + " channel " + channel,
+		}
+		if labels[channel] != "" {
+			tags["label"] = labels[channel]
+		}
+		acc.AddFields("ras_edac_dimm", map[string]interface{}{"ce_count": count}, tags)
+	}
+}
+
+// readCounts reads the integer attributes of a directory, returning them as
+// fields named after the mapping.
+func readCounts(dir string, names map[string]string) map[string]interface{} {
+	fields := make(map[string]interface{})
+	for file, field := range names {
+		value, err := readString(filepath.Join(dir, file))
+		if err != nil {
+			continue
+		}
+		if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+			fields[field] = v
+		}
+	}
+	return fields
+}
+
+// gatherDatabase counts the errors recorded in the sqlite database of
+// rasdaemon: the machine-check exceptions per socket and bank, and the
+// memory errors per memory controller and DIMM label.
+func (r *Ras) gatherDatabase(acc telegraf.Accumulator) error {
+	db, err := sql.Open("sqlite3", "file:"+r.Database+"?mode=ro")
+	if err != nil {
+		return fmt.Errorf("failed to open database %s: %s", r.Database, err)
+	}
+	defer db.Close()
+
+	mces, err := db.Query(fmt.Sprintf(`SELECT socketid, bank_name, COUNT(*),
+		SUM((status >> %d) & 1)
+		FROM mce_record GROUP BY socketid, bank_name`, mciStatusUC))
+	if err != nil {
+		return fmt.Errorf("failed to query machine-check exceptions of database %s: %s", r.Database, err)
+	}
+	defer mces.Close()
+	for mces.Next() {
+		var socket int64
+		var bank sql.NullString
+		var total, uncorrected int64
+		if err := mces.Scan(&socket, &bank, &total, &uncorrected); err != nil {
+			return err
+		}
+		tags := map[string]string{"socket": strconv.FormatInt(socket, 10)}
+		if bank.String != "" {
+			tags["bank"] = bank.String
+		}
+		acc.AddFields("ras_mce", map[string]interface{}{
+			"errors":             total,
+			"corrected_errors":   total - uncorrected,
+			"uncorrected_errors": uncorrected,
+		}, tags)
+	}
+	if err := mces.Err(); err != nil {
+		return err
+	}
+
+	events, err := db.Query(`SELECT mc, label, err_type, SUM(err_count)
+		FROM mc_event GROUP BY mc, label, err_type`)
+	if err != nil {
+		return fmt.Errorf("failed to query memory errors of database %s: %s", r.Database, err)
+	}
+	defer events.Close()
+
+	type key struct {
+		mc    int64
+		label string
+	}
+	fields := make(map[key]map[string]interface{})
+	for events.Next() {
+		var k key
+		var label, errType sql.NullString
+		var count int64
+		if err := events.Scan(&k.mc, &label, &errType, &count); err != nil {
+			return err
+		}
+		k.label = label.String
+		if fields[k] == nil {
+			fields[k] = make(map[string]interface{})
+		}
+		// Corrected, Uncorrected, Deferred, Fatal or Info
+		fields[k][strings.ToLower(errType.String)+"_errors"] = count
+	}
+	if err := events.Err(); err != nil {
+		return err
+	}
+
+	for k, f := range fields {
+		tags := map[string]string{"controller": "mc" + strconv.FormatInt(k.mc, 10)}
+		if k.label != "" {
+			tags["label"] = k.label
+		}
+		acc.AddFields("ras_mc_event", f, tags)
+	}
+	return nil
+}
+
+func readString(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// sys can be used to read file paths from env
+func sys(env, path string) string {
+	// try to read full file path
+	if p := os.Getenv(env); p != "" {
+		return p
+	}
+	// return default path
+	return path
+}
+
+func init() {
+	inputs.Add("ras", func() telegraf.Input {
+		return &Ras{}
+	})
+}
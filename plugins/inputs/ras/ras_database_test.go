@@ -0,0 +1,108 @@
+// +build cgo
+
+package ras
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createDatabase(t *testing.T) (string, func()) {
+	dir, cleanup := testutil.TempDir(t, "ras")
+	path := filepath.Join(dir, "ras-mc_event.db")
+
+	db, err := sql.Open("sqlite3", path)
+	require.NoError(t, err)
+	defer db.Close()
+
+	statements := []string{
+		`CREATE TABLE mce_record (id INTEGER PRIMARY KEY, timestamp TEXT, mcgcap INTEGER,
+			mcgstatus INTEGER, status INTEGER, addr INTEGER, misc INTEGER, ip INTEGER,
+			tsc INTEGER, walltime INTEGER, cpu INTEGER, cpuid INTEGER, apicid INTEGER,
+			socketid INTEGER, cs INTEGER, bank INTEGER, cpuvendor INTEGER, bank_name TEXT,
+			error_msg TEXT, mcgstatus_msg TEXT, mcistatus_msg TEXT, mcastatus_msg TEXT,
+			user_action TEXT, mc_location TEXT)`,
+		`CREATE TABLE mc_event (id INTEGER PRIMARY KEY, timestamp TEXT, err_count INTEGER,
+			err_type TEXT, err_msg TEXT, label TEXT, mc INTEGER, top_layer INTEGER,
+			middle_layer INTEGER, lower_layer INTEGER, address INTEGER, grain INTEGER,
+			syndrome INTEGER, driver_detail TEXT)`,
+		// Corrected errors
+		`INSERT INTO mce_record (status, socketid, bank_name) VALUES
+			(-7205759403792793600, 0, 'Intel IMC'),
+			(-7205759403792793600, 0, 'Intel IMC'),
+			(-7205759403792793600, 1, 'Intel IMC')`,
+		// An uncorrected error
+		`INSERT INTO mce_record (status, socketid, bank_name) VALUES
+			(-4899916394579099648, 0, 'Intel IMC')`,
+		`INSERT INTO mc_event (err_count, err_type, label, mc) VALUES
+			(1, 'Corrected', 'CPU_SrcID#0_MC#0_Chan#0_DIMM#0', 0),
+			(2, 'Corrected', 'CPU_SrcID#0_MC#0_Chan#0_DIMM#0', 0),
+			(1, 'Uncorrected', 'CPU_SrcID#0_MC#0_Chan#0_DIMM#0', 0),
+			(5, 'Corrected', 'CPU_SrcID#1_MC#0_Chan#1_DIMM#0', 1)`,
+	}
+	for _, statement := range statements {
+		_, err := db.Exec(statement)
+		require.NoError(t, err)
+	}
+	return path, cleanup
+}
+
+func TestGatherDatabase(t *testing.T) {
+	path, cleanup := createDatabase(t)
+	defer cleanup()
+
+	r := &Ras{
+		HostSys:  filepath.Dir(path),
+		Database: path,
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(r.Gather))
+
+	acc.AssertContainsTaggedFields(t, "ras_mce",
+		map[string]interface{}{
+			"errors":             int64(3),
+			"corrected_errors":   int64(2),
+			"uncorrected_errors": int64(1),
+		},
+		map[string]string{"socket": "0", "bank": "Intel IMC"})
+	acc.AssertContainsTaggedFields(t, "ras_mce",
+		map[string]interface{}{
+			"errors":             int64(1),
+			"corrected_errors":   int64(1),
+			"uncorrected_errors": int64(0),
+		},
+		map[string]string{"socket": "1", "bank": "Intel IMC"})
+
+	acc.AssertContainsTaggedFields(t, "ras_mc_event",
+		map[string]interface{}{
+			"corrected_errors":   int64(3),
+			"uncorrected_errors": int64(1),
+		},
+		map[string]string{"controller": "mc0", "label": "CPU_SrcID#0_MC#0_Chan#0_DIMM#0"})
+	acc.AssertContainsTaggedFields(t, "ras_mc_event",
+		map[string]interface{}{
+			"corrected_errors": int64(5),
+		},
+		map[string]string{"controller": "mc1", "label": "CPU_SrcID#1_MC#0_Chan#1_DIMM#0"})
+
+	assert.Len(t, acc.Metrics, 4)
+}
+
+func TestGatherDatabase_missing(t *testing.T) {
+	dir, cleanup := testutil.TempDir(t, "ras")
+	defer cleanup()
+
+	r := &Ras{
+		HostSys:  dir,
+		Database: filepath.Join(dir, "missing.db"),
+	}
+
+	var acc testutil.Accumulator
+	assert.Error(t, acc.GatherError(r.Gather))
+}
@@ -0,0 +1,138 @@
+package ras
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func makeSysfs(t *testing.T) (string, func()) {
+	dir, cleanup := testutil.TempDir(t, "ras")
+
+	testutil.WriteFiles(t, filepath.Join(dir, "devices", "system", "edac", "mc"), map[string]string{
+		"mc0/mc_name":               "Skylake Socket#0 IMC#0\n",
+		"mc0/size_mb":               "65536\n",
+		"mc0/ce_count":              "3\n",
+		"mc0/ue_count":              "1\n",
+		"mc0/ce_noinfo_count":       "0\n",
+		"mc0/ue_noinfo_count":       "0\n",
+		"mc0/dimm0/dimm_label":      "CPU_SrcID#0_MC#0_Chan#0_DIMM#0\n",
+		"mc0/dimm0/dimm_location":   "channel 0 slot 0 \n",
+		"mc0/dimm0/size":            "32768\n",
+		"mc0/dimm0/dimm_ce_count":   "3\n",
+		"mc0/dimm0/dimm_ue_count":   "1\n",
+		"mc0/dimm1/dimm_label":      "\n",
+		"mc0/dimm1/dimm_location":   "channel 1 slot 0 \n",
+		"mc0/dimm1/size":            "32768\n",
+		"mc0/dimm1/dimm_ce_count":   "0\n",
+		"mc0/dimm1/dimm_ue_count":   "0\n",
+		"mc1/mc_name":               "i3200\n",
+		"mc1/size_mb":               "4096\n",
+		"mc1/ce_count":              "2\n",
+		"mc1/ue_count":              "0\n",
+		"mc1/csrow0/ce_count":       "2\n",
+		"mc1/csrow0/ue_count":       "0\n",
+		"mc1/csrow0/ch0_ce_count":   "2\n",
+		"mc1/csrow0/ch0_dimm_label": "DIMM_A1\n",
+		"mc1/csrow0/ch1_ce_count":   "0\n",
+		"mc1/csrow0/ch1_dimm_label": "\n",
+		"power/control":             "auto\n",
+	})
+	return dir, cleanup
+}
+
+func TestGather(t *testing.T) {
+	dir, cleanup := makeSysfs(t)
+	defer cleanup()
+
+	r := &Ras{HostSys: dir}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(r.Gather))
+
+	acc.AssertContainsTaggedFields(t, "ras_edac_mc",
+		map[string]interface{}{
+			"ce_count":        int64(3),
+			"ue_count":        int64(1),
+			"ce_noinfo_count": int64(0),
+			"ue_noinfo_count": int64(0),
+			"size_mb":         int64(65536),
+		},
+		map[string]string{
+			"controller": "mc0",
+			"mc_name":    "Skylake Socket#0 IMC#0",
+		})
+
+	acc.AssertContainsTaggedFields(t, "ras_edac_dimm",
+		map[string]interface{}{
+			"ce_count": int64(3),
+			"ue_count": int64(1),
+			"size_mb":  int64(32768),
+		},
+		map[string]string{
+			"controller": "mc0",
+			"dimm":       "dimm0",
+			"label":      "CPU_SrcID#0_MC#0_Chan#0_DIMM#0",
+			"location":   "channel 0 slot 0",
+		})
+
+	acc.AssertContainsTaggedFields(t, "ras_edac_dimm",
+		map[string]interface{}{
+			"ce_count": int64(0),
+			"ue_count": int64(0),
+			"size_mb":  int64(32768),
+		},
+		map[string]string{
+			"controller": "mc0",
+			"dimm":       "dimm1",
+			"location":   "channel 1 slot 0",
+		})
+
+	acc.AssertContainsTaggedFields(t, "ras_edac_mc",
+		map[string]interface{}{
+			"ce_count": int64(2),
+			"ue_count": int64(0),
+			"size_mb":  int64(4096),
+		},
+		map[string]string{
+			"controller": "mc1",
+			"mc_name":    "i3200",
+		})
+
+	acc.AssertContainsTaggedFields(t, "ras_edac_dimm",
+		map[string]interface{}{
+			"ce_count": int64(2),
+		},
+		map[string]string{
+			"controller": "mc1",
+			"dimm":       "csrow0_ch0",
+			"label":      "DIMM_A1",
+			"location":   "csrow 0 channel 0",
+		})
+
+	acc.AssertContainsTaggedFields(t, "ras_edac_dimm",
+		map[string]interface{}{
+			"ce_count": int64(0),
+		},
+		map[string]string{
+			"controller": "mc1",
+			"dimm":       "csrow0_ch1",
+			"location":   "csrow 0 channel 1",
+		})
+
+	assert.Len(t, acc.Metrics, 6)
+}
+
+func TestGather_noEdac(t *testing.T) {
+	dir, cleanup := testutil.TempDir(t, "ras")
+	defer cleanup()
+
+	r := &Ras{HostSys: dir}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(r.Gather))
+	assert.Empty(t, acc.Metrics)
+}
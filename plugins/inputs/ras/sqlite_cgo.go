@@ -0,0 +1,8 @@
+// +build cgo
+
+package ras
+
+import (
+	// Register the sqlite3 driver used to read the database of rasdaemon
+	_ "github.com/mattn/go-sqlite3"
+)
@@ -0,0 +1,579 @@
+package redfish
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const sampleConfig = `
+  ## Redfish API base URL of the BMC, use one plugin instance per BMC.
+  address = "https://127.0.0.1:5000"
+
+  ## Credentials for the Redfish API.
+  username = "root"
+  password = "password123456"
+
+  ## Authentication method, "basic" sends the credentials with every request
+  ## while "session" logs into the Redfish session service once and uses the
+  ## returned token.
+  # auth = "basic"
+
+  ## Resource Id of the computer system, ie: "System.Embedded.1" for iDRAC,
+  ## "1" for iLO or "system" for OpenBMC.
+  computer_system_id = "System.Embedded.1"
+
+  ## Amount of time allowed to complete the HTTP requests
+  # timeout = "5s"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+type Redfish struct {
+	Address          string            `toml:"address"`
+	Username         string            `toml:"username"`
+	Password         string            `toml:"password"`
+	Auth             string            `toml:"auth"`
+	ComputerSystemId string            `toml:"computer_system_id"`
+	Timeout          internal.Duration `toml:"timeout"`
+	tls.ClientConfig
+
+	client  *http.Client
+	baseURL *url.URL
+
+	mu      sync.Mutex
+	token   string
+	session string
+}
+
+// Link is a reference to another resource.
+type Link struct {
+	ODataID string `json:"@odata.id"`
+}
+
+// Status is the common status object of Redfish resources.
+type Status struct {
+	State  string
+	Health string
+}
+
+type System struct {
+	Id         string
+	Name       string
+	Hostname   string `json:"HostName"`
+	PowerState string
+	Status     Status
+	Links      struct {
+		Chassis []Link
+	}
+}
+
+type Chassis struct {
+	Id          string
+	Name        string
+	ChassisType string
+	PowerState  string
+	Status      Status
+	Location    *struct {
+		PostalAddress struct {
+			DataCenter string
+			Room       string
+		}
+		Placement struct {
+			Rack string
+			Row  string
+		}
+	}
+	Thermal Link
+	Power   Link
+}
+
+type Thresholds struct {
+	UpperThresholdCritical *float64
+	UpperThresholdFatal    *float64
+	LowerThresholdCritical *float64
+	LowerThresholdFatal    *float64
+}
+
+type Thermal struct {
+	Temperatures []struct {
+		Name            string
+		MemberId        string
+		PhysicalContext string
+		ReadingCelsius  *float64
+		Status          Status
+		Thresholds
+	}
+	Fans []struct {
+		Name         string
+		FanName      string
+		MemberId     string
+		Reading      *float64
+		ReadingUnits string
+		Status       Status
+		Thresholds
+	}
+}
+
+type Power struct {
+	PowerControl []struct {
+		Name                string
+		MemberId            string
+		PowerConsumedWatts  *float64
+		PowerRequestedWatts *float64
+		PowerAvailableWatts *float64
+		PowerCapacityWatts  *float64
+		PowerMetrics        *struct {
+			AverageConsumedWatts *float64
+			MinConsumedWatts     *float64
+			MaxConsumedWatts     *float64
+		}
+		Status Status
+	}
+	PowerSupplies []struct {
+		Name                 string
+		MemberId             string
+		PowerInputWatts      *float64
+		PowerOutputWatts     *float64
+		PowerCapacityWatts   *float64
+		LineInputVoltage     *float64
+		LastPowerOutputWatts *float64
+		Status               Status
+	}
+	Voltages []struct {
+		Name         string
+		MemberId     string
+		ReadingVolts *float64
+		Status       Status
+		Thresholds
+	}
+}
+
+func (r *Redfish) Description() string {
+	return "Read hardware telemetry of servers from their BMC using the Redfish API"
+}
+
+func (r *Redfish) SampleConfig() string {
+	return sampleConfig
+}
+
+func (r *Redfish) Start(acc telegraf.Accumulator) error {
+	return nil
+}
+
+// Stop logs out of the session, as the BMCs only have a few session slots.
+func (r *Redfish) Stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.deleteSession(); err != nil {
+		log.Printf("E! [inputs.redfish] could not delete session: %s", err)
+	}
+}
+
+func (r *Redfish) init() error {
+	if r.Address == "" {
+		return fmt.Errorf("did not provide the Redfish address")
+	}
+	if r.ComputerSystemId == "" {
+		return fmt.Errorf("did not provide the computer system ID of the resource")
+	}
+	if r.Auth != "" && r.Auth != "basic" && r.Auth != "session" {
+		return fmt.Errorf("unknown auth method %q", r.Auth)
+	}
+
+	var err error
+	r.baseURL, err = url.Parse(r.Address)
+	if err != nil {
+		return err
+	}
+
+	tlsCfg, err := r.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+
+	r.client = &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig: tlsCfg,
+			Proxy:           http.ProxyFromEnvironment,
+		},
+		Timeout: r.Timeout.Duration,
+	}
+	return nil
+}
+
+func (r *Redfish) Gather(acc telegraf.Accumulator) error {
+	if r.client == nil {
+		if err := r.init(); err != nil {
+			return err
+		}
+	}
+
+	system := &System{}
+	err := r.getData("/redfish/v1/Systems/"+r.ComputerSystemId, system)
+	if err != nil {
+		return err
+	}
+
+	for _, link := range system.Links.Chassis {
+		chassis := &Chassis{}
+		if err := r.getData(link.ODataID, chassis); err != nil {
+			acc.AddError(err)
+			continue
+		}
+
+		tags := r.tags(system, chassis)
+		acc.AddFields("redfish_chassis", map[string]interface{}{
+			"state":       chassis.Status.State,
+			"health":      chassis.Status.Health,
+			"power_state": chassis.PowerState,
+		}, tags)
+
+		if chassis.Thermal.ODataID != "" {
+			if err := r.gatherThermal(acc, chassis.Thermal.ODataID, tags); err != nil {
+				acc.AddError(err)
+			}
+		}
+
+		if chassis.Power.ODataID != "" {
+			if err := r.gatherPower(acc, chassis.Power.ODataID, tags); err != nil {
+				acc.AddError(err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// tags returns the tags shared by all metrics of a chassis.
+func (r *Redfish) tags(system *System, chassis *Chassis) map[string]string {
+	tags := map[string]string{
+		"address": r.baseURL.Hostname(),
+		"chassis": chassis.Id,
+	}
+	if system.Hostname != "" {
+		tags["source"] = system.Hostname
+	}
+	if chassis.ChassisType != "" {
+		tags["chassis_type"] = chassis.ChassisType
+	}
+	if loc := chassis.Location; loc != nil {
+		setTag(tags, "datacenter", loc.PostalAddress.DataCenter)
+		setTag(tags, "room", loc.PostalAddress.Room)
+		setTag(tags, "rack", loc.Placement.Rack)
+		setTag(tags, "row", loc.Placement.Row)
+	}
+	return tags
+}
+
+func (r *Redfish) gatherThermal(acc telegraf.Accumulator, path string, chassisTags map[string]string) error {
+	thermal := &Thermal{}
+	if err := r.getData(path, thermal); err != nil {
+		return err
+	}
+
+	for _, t := range thermal.Temperatures {
+		tags := sensorTags(chassisTags, t.Name, t.MemberId, t.Status)
+		setTag(tags, "physical_context", t.PhysicalContext)
+
+		fields := make(map[string]interface{})
+		setField(fields, "reading_celsius", t.ReadingCelsius)
+		t.Thresholds.addFields(fields)
+		if len(fields) > 0 {
+			acc.AddFields("redfish_thermal_temperatures", fields, tags)
+		}
+	}
+
+	for _, f := range thermal.Fans {
+		name := f.Name
+		if name == "" {
+			name = f.FanName
+		}
+		tags := sensorTags(chassisTags, name, f.MemberId, f.Status)
+
+		fields := make(map[string]interface{})
+		if strings.EqualFold(f.ReadingUnits, "Percent") {
+			setField(fields, "reading_percent", f.Reading)
+		} else {
+			setField(fields, "reading_rpm", f.Reading)
+		}
+		f.Thresholds.addFields(fields)
+		if len(fields) > 0 {
+			acc.AddFields("redfish_thermal_fans", fields, tags)
+		}
+	}
+
+	return nil
+}
+
+func (r *Redfish) gatherPower(acc telegraf.Accumulator, path string, chassisTags map[string]string) error {
+	power := &Power{}
+	if err := r.getData(path, power); err != nil {
+		return err
+	}
+
+	for _, p := range power.PowerControl {
+		tags := sensorTags(chassisTags, p.Name, p.MemberId, p.Status)
+
+		fields := make(map[string]interface{})
+		setField(fields, "power_consumed_watts", p.PowerConsumedWatts)
+		setField(fields, "power_requested_watts", p.PowerRequestedWatts)
+		setField(fields, "power_available_watts", p.PowerAvailableWatts)
+		setField(fields, "power_capacity_watts", p.PowerCapacityWatts)
+		if m := p.PowerMetrics; m != nil {
+			setField(fields, "average_consumed_watts", m.AverageConsumedWatts)
+			setField(fields, "min_consumed_watts", m.MinConsumedWatts)
+			setField(fields, "max_consumed_watts", m.MaxConsumedWatts)
+		}
+		if len(fields) > 0 {
+			acc.AddFields("redfish_power_powercontrol", fields, tags)
+		}
+	}
+
+	for _, p := range power.PowerSupplies {
+		tags := sensorTags(chassisTags, p.Name, p.MemberId, p.Status)
+
+		fields := make(map[string]interface{})
+		setField(fields, "power_input_watts", p.PowerInputWatts)
+		setField(fields, "power_output_watts", p.PowerOutputWatts)
+		setField(fields, "power_capacity_watts", p.PowerCapacityWatts)
+		setField(fields, "line_input_voltage", p.LineInputVoltage)
+		setField(fields, "last_power_output_watts", p.LastPowerOutputWatts)
+		if len(fields) > 0 {
+			acc.AddFields("redfish_power_powersupplies", fields, tags)
+		}
+	}
+
+	for _, v := range power.Voltages {
+		tags := sensorTags(chassisTags, v.Name, v.MemberId, v.Status)
+
+		fields := make(map[string]interface{})
+		setField(fields, "reading_volts", v.ReadingVolts)
+		v.Thresholds.addFields(fields)
+		if len(fields) > 0 {
+			acc.AddFields("redfish_power_voltages", fields, tags)
+		}
+	}
+
+	return nil
+}
+
+func (t *Thresholds) addFields(fields map[string]interface{}) {
+	setField(fields, "upper_threshold_critical", t.UpperThresholdCritical)
+	setField(fields, "upper_threshold_fatal", t.UpperThresholdFatal)
+	setField(fields, "lower_threshold_critical", t.LowerThresholdCritical)
+	setField(fields, "lower_threshold_fatal", t.LowerThresholdFatal)
+}
+
+func sensorTags(chassisTags map[string]string, name, memberId string, status Status) map[string]string {
+	tags := make(map[string]string, len(chassisTags)+4)
+	for k, v := range chassisTags {
+		tags[k] = v
+	}
+	setTag(tags, "name", name)
+	setTag(tags, "member_id", memberId)
+	setTag(tags, "state", status.State)
+	setTag(tags, "health", status.Health)
+	return tags
+}
+
+func setTag(tags map[string]string, key, value string) {
+	if value != "" {
+		tags[key] = value
+	}
+}
+
+func setField(fields map[string]interface{}, key string, value *float64) {
+	if value != nil {
+		fields[key] = *value
+	}
+}
+
+// getData reads the resource at path into v.
+func (r *Redfish) getData(path string, v interface{}) error {
+	resp, err := r.get(path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("received status code %d (%s) for %s, expected 200 (OK)",
+			resp.StatusCode, http.StatusText(resp.StatusCode), path)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("error parsing %s: %s", path, err)
+	}
+	return nil
+}
+
+func (r *Redfish) get(path string) (*http.Response, error) {
+	u, err := r.baseURL.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.do(u.String(), false)
+	if err != nil {
+		return nil, err
+	}
+
+	// The session has expired, log in again
+	if r.Auth == "session" && resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+		return r.do(u.String(), true)
+	}
+	return resp, nil
+}
+
+func (r *Redfish) do(u string, renewSession bool) (*http.Response, error) {
+	req, err := r.newRequest("GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Auth == "session" {
+		token, err := r.sessionToken(renewSession)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("X-Auth-Token", token)
+	} else {
+		req.SetBasicAuth(r.Username, r.Password)
+	}
+
+	return r.client.Do(req)
+}
+
+// sessionToken returns the token of the current session, a new session is
+// created if there is none or renew is set.
+func (r *Redfish) sessionToken(renew bool) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.token != "" && !renew {
+		return r.token, nil
+	}
+
+	// The session is most likely expired already when renewed, in which case
+	// the BMC refuses to delete it.
+	r.deleteSession()
+
+	u, err := r.baseURL.Parse("/redfish/v1/SessionService/Sessions")
+	if err != nil {
+		return "", err
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"UserName": r.Username,
+		"Password": r.Password,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := r.newRequest("POST", u.String(), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("could not create session: received status code %d (%s)",
+			resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	token := resp.Header.Get("X-Auth-Token")
+	if token == "" {
+		return "", fmt.Errorf("could not create session: no X-Auth-Token returned")
+	}
+	r.token = token
+
+	// The session is deleted with its URL, returned in the Location header.
+	if location := resp.Header.Get("Location"); location != "" {
+		session, err := u.Parse(location)
+		if err != nil {
+			return "", fmt.Errorf("could not create session: invalid Location %q: %s", location, err)
+		}
+		r.session = session.String()
+	}
+	return r.token, nil
+}
+
+// deleteSession deletes the current session, if any, the lock must be held.
+func (r *Redfish) deleteSession() error {
+	if r.session == "" {
+		r.token = ""
+		return nil
+	}
+	session, token := r.session, r.token
+	r.session, r.token = "", ""
+
+	req, err := r.newRequest("DELETE", session, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Auth-Token", token)
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return fmt.Errorf("received status code %d (%s) for %s",
+			resp.StatusCode, http.StatusText(resp.StatusCode), session)
+	}
+	return nil
+}
+
+func (r *Redfish) newRequest(method, u string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("OData-Version", "4.0")
+	return req, nil
+}
+
+func init() {
+	inputs.Add("redfish", func() telegraf.Input {
+		return &Redfish{
+			Auth:    "basic",
+			Timeout: internal.Duration{Duration: time.Second * 5},
+		}
+	})
+}
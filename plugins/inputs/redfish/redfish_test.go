@@ -0,0 +1,357 @@
+package redfish
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const systemJSON = `
+{
+  "@odata.id": "/redfish/v1/Systems/System.Embedded.1",
+  "Id": "System.Embedded.1",
+  "Name": "System",
+  "HostName": "tpa-hostname",
+  "PowerState": "On",
+  "Status": {"State": "Enabled", "Health": "OK"},
+  "Links": {
+    "Chassis": [{"@odata.id": "/redfish/v1/Chassis/System.Embedded.1"}]
+  }
+}
+`
+
+const chassisJSON = `
+{
+  "@odata.id": "/redfish/v1/Chassis/System.Embedded.1",
+  "Id": "System.Embedded.1",
+  "Name": "Computer System Chassis",
+  "ChassisType": "RackMount",
+  "PowerState": "On",
+  "Status": {"State": "Enabled", "Health": "Warning"},
+  "Location": {
+    "PostalAddress": {"DataCenter": "Tampa", "Room": "tbc"},
+    "Placement": {"Rack": "WEB43", "Row": "North"}
+  },
+  "Thermal": {"@odata.id": "/redfish/v1/Chassis/System.Embedded.1/Thermal"},
+  "Power": {"@odata.id": "/redfish/v1/Chassis/System.Embedded.1/Power"}
+}
+`
+
+const thermalJSON = `
+{
+  "Temperatures": [
+    {
+      "MemberId": "iDRAC.Embedded.1#CPU1Temp",
+      "Name": "CPU1 Temp",
+      "PhysicalContext": "CPU",
+      "ReadingCelsius": 40,
+      "UpperThresholdCritical": 93,
+      "UpperThresholdFatal": 98,
+      "LowerThresholdCritical": null,
+      "Status": {"State": "Enabled", "Health": "OK"}
+    },
+    {
+      "MemberId": "iDRAC.Embedded.1#MissingTemp",
+      "Name": "Missing Temp",
+      "ReadingCelsius": null,
+      "Status": {"State": "Absent"}
+    }
+  ],
+  "Fans": [
+    {
+      "MemberId": "0x17||Fan.Embedded.1A",
+      "FanName": "System Board Fan1A",
+      "Reading": 17760,
+      "ReadingUnits": "RPM",
+      "LowerThresholdFatal": 600,
+      "Status": {"State": "Enabled", "Health": "OK"}
+    },
+    {
+      "MemberId": "1",
+      "Name": "Fan 2",
+      "Reading": 23,
+      "ReadingUnits": "Percent",
+      "Status": {"State": "Enabled", "Health": "OK"}
+    }
+  ]
+}
+`
+
+const powerJSON = `
+{
+  "PowerControl": [
+    {
+      "MemberId": "PowerControl",
+      "Name": "System Power Control",
+      "PowerConsumedWatts": 186,
+      "PowerRequestedWatts": 504,
+      "PowerAvailableWatts": 0,
+      "PowerCapacityWatts": 750,
+      "PowerMetrics": {
+        "AverageConsumedWatts": 185,
+        "MinConsumedWatts": 182,
+        "MaxConsumedWatts": 193
+      },
+      "Status": {"State": "Enabled", "Health": "OK"}
+    }
+  ],
+  "PowerSupplies": [
+    {
+      "MemberId": "PSU.Slot.1",
+      "Name": "PS1 Status",
+      "PowerInputWatts": 210,
+      "PowerOutputWatts": 186,
+      "PowerCapacityWatts": 750,
+      "LineInputVoltage": 230,
+      "LastPowerOutputWatts": 186,
+      "Status": {"State": "Enabled", "Health": "OK"}
+    }
+  ],
+  "Voltages": [
+    {
+      "MemberId": "iDRAC.Embedded.1#SystemBoardDIMMPG",
+      "Name": "System Board DIMM PG",
+      "ReadingVolts": 1,
+      "Status": {"State": "Enabled", "Health": "OK"}
+    }
+  ]
+}
+`
+
+// sessions are the sessions created on the test server and not deleted.
+type sessions struct {
+	sync.Mutex
+	live map[string]bool
+	next int
+}
+
+func (s *sessions) count() int {
+	s.Lock()
+	defer s.Unlock()
+	return len(s.live)
+}
+
+func newServer(auth func(r *http.Request) bool, s *sessions) *httptest.Server {
+	pages := map[string]string{
+		"/redfish/v1/Systems/System.Embedded.1":         systemJSON,
+		"/redfish/v1/Chassis/System.Embedded.1":         chassisJSON,
+		"/redfish/v1/Chassis/System.Embedded.1/Thermal": thermalJSON,
+		"/redfish/v1/Chassis/System.Embedded.1/Power":   powerJSON,
+	}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/redfish/v1/SessionService/Sessions" && r.Method == "POST" {
+			s.Lock()
+			s.next++
+			location := fmt.Sprintf("/redfish/v1/SessionService/Sessions/%d", s.next)
+			s.live[location] = true
+			s.Unlock()
+			w.Header().Set("X-Auth-Token", "token")
+			w.Header().Set("Location", location)
+			w.WriteHeader(http.StatusCreated)
+			return
+		}
+
+		if strings.HasPrefix(r.URL.Path, "/redfish/v1/SessionService/Sessions/") && r.Method == "DELETE" {
+			s.Lock()
+			defer s.Unlock()
+			if !s.live[r.URL.Path] {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			delete(s.live, r.URL.Path)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		if !auth(r) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintln(w, page)
+	}))
+}
+
+func TestGather(t *testing.T) {
+	ts := newServer(func(r *http.Request) bool {
+		username, password, ok := r.BasicAuth()
+		return ok && username == "test" && password == "test"
+	}, &sessions{live: map[string]bool{}})
+	defer ts.Close()
+
+	r := &Redfish{
+		Address:          ts.URL,
+		Username:         "test",
+		Password:         "test",
+		ComputerSystemId: "System.Embedded.1",
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(r.Gather))
+
+	chassisTags := func() map[string]string {
+		return map[string]string{
+			"address":      "127.0.0.1",
+			"source":       "tpa-hostname",
+			"chassis":      "System.Embedded.1",
+			"chassis_type": "RackMount",
+			"datacenter":   "Tampa",
+			"room":         "tbc",
+			"rack":         "WEB43",
+			"row":          "North",
+		}
+	}
+	sensorTags := func(name, memberId string) map[string]string {
+		tags := chassisTags()
+		tags["name"] = name
+		tags["member_id"] = memberId
+		tags["state"] = "Enabled"
+		tags["health"] = "OK"
+		return tags
+	}
+
+	acc.AssertContainsTaggedFields(t, "redfish_chassis",
+		map[string]interface{}{
+			"state":       "Enabled",
+			"health":      "Warning",
+			"power_state": "On",
+		},
+		chassisTags())
+
+	tags := sensorTags("CPU1 Temp", "iDRAC.Embedded.1#CPU1Temp")
+	tags["physical_context"] = "CPU"
+	acc.AssertContainsTaggedFields(t, "redfish_thermal_temperatures",
+		map[string]interface{}{
+			"reading_celsius":          40.0,
+			"upper_threshold_critical": 93.0,
+			"upper_threshold_fatal":    98.0,
+		},
+		tags)
+
+	acc.AssertContainsTaggedFields(t, "redfish_thermal_fans",
+		map[string]interface{}{
+			"reading_rpm":           17760.0,
+			"lower_threshold_fatal": 600.0,
+		},
+		sensorTags("System Board Fan1A", "0x17||Fan.Embedded.1A"))
+
+	acc.AssertContainsTaggedFields(t, "redfish_thermal_fans",
+		map[string]interface{}{
+			"reading_percent": 23.0,
+		},
+		sensorTags("Fan 2", "1"))
+
+	acc.AssertContainsTaggedFields(t, "redfish_power_powercontrol",
+		map[string]interface{}{
+			"power_consumed_watts":   186.0,
+			"power_requested_watts":  504.0,
+			"power_available_watts":  0.0,
+			"power_capacity_watts":   750.0,
+			"average_consumed_watts": 185.0,
+			"min_consumed_watts":     182.0,
+			"max_consumed_watts":     193.0,
+		},
+		sensorTags("System Power Control", "PowerControl"))
+
+	acc.AssertContainsTaggedFields(t, "redfish_power_powersupplies",
+		map[string]interface{}{
+			"power_input_watts":       210.0,
+			"power_output_watts":      186.0,
+			"power_capacity_watts":    750.0,
+			"line_input_voltage":      230.0,
+			"last_power_output_watts": 186.0,
+		},
+		sensorTags("PS1 Status", "PSU.Slot.1"))
+
+	acc.AssertContainsTaggedFields(t, "redfish_power_voltages",
+		map[string]interface{}{
+			"reading_volts": 1.0,
+		},
+		sensorTags("System Board DIMM PG", "iDRAC.Embedded.1#SystemBoardDIMMPG"))
+
+	// Sensors without readings are skipped
+	assert.Equal(t, 1, countMetrics(&acc, "redfish_thermal_temperatures"))
+}
+
+func TestGatherSession(t *testing.T) {
+	s := &sessions{live: map[string]bool{}}
+	ts := newServer(func(r *http.Request) bool {
+		return r.Header.Get("X-Auth-Token") == "token"
+	}, s)
+	defer ts.Close()
+
+	r := &Redfish{
+		Address:          ts.URL,
+		Username:         "test",
+		Password:         "test",
+		Auth:             "session",
+		ComputerSystemId: "System.Embedded.1",
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(r.Gather))
+	assert.True(t, acc.HasMeasurement("redfish_power_powersupplies"))
+
+	assert.Equal(t, 1, s.count())
+
+	// An expired token is renewed, deleting the previous session
+	r.token = "expired"
+	acc.ClearMetrics()
+	require.NoError(t, acc.GatherError(r.Gather))
+	assert.True(t, acc.HasMeasurement("redfish_chassis"))
+	assert.Equal(t, "token", r.token)
+	assert.Equal(t, 1, s.count())
+
+	// The session is deleted when stopped
+	r.Stop()
+	assert.Equal(t, 0, s.count())
+}
+
+func TestGatherUnauthorized(t *testing.T) {
+	ts := newServer(func(r *http.Request) bool {
+		return false
+	}, &sessions{live: map[string]bool{}})
+	defer ts.Close()
+
+	r := &Redfish{
+		Address:          ts.URL,
+		ComputerSystemId: "System.Embedded.1",
+	}
+
+	var acc testutil.Accumulator
+	err := r.Gather(&acc)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+}
+
+func TestInvalidConfig(t *testing.T) {
+	r := &Redfish{Address: "http://127.0.0.1"}
+	require.Error(t, r.Gather(&testutil.Accumulator{}))
+
+	r = &Redfish{Address: "http://127.0.0.1", ComputerSystemId: "1", Auth: "digest"}
+	require.Error(t, r.Gather(&testutil.Accumulator{}))
+}
+
+func countMetrics(acc *testutil.Accumulator, measurement string) int {
+	n := 0
+	for _, m := range acc.Metrics {
+		if m.Measurement == measurement {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,138 @@
+package redis
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+// gatherCluster gathers the state of the cluster and of its nodes, and
+// returns the addresses of the nodes.
+func (r *Redis) gatherCluster(client Client, acc telegraf.Accumulator) ([]string, error) {
+	info, err := client.Do("cluster", "info")
+	if err != nil {
+		return nil, err
+	}
+	infoStr, ok := info.(string)
+	if !ok {
+		return nil, fmt.Errorf("unexpected CLUSTER INFO reply %T", info)
+	}
+	acc.AddFields("redis_cluster", parseClusterInfo(infoStr), client.BaseTags())
+
+	nodes, err := client.Do("cluster", "nodes")
+	if err != nil {
+		return nil, err
+	}
+	nodesStr, ok := nodes.(string)
+	if !ok {
+		return nil, fmt.Errorf("unexpected CLUSTER NODES reply %T", nodes)
+	}
+	return gatherClusterNodes(nodesStr, acc, client.BaseTags()), nil
+}
+
+// parseClusterInfo parses the output of CLUSTER INFO, made of lines like
+// "cluster_state:ok".
+func parseClusterInfo(info string) map[string]interface{} {
+	fields := make(map[string]interface{})
+	scanner := bufio.NewScanner(strings.NewReader(info))
+	for scanner.Scan() {
+		parts := strings.SplitN(strings.TrimSpace(scanner.Text()), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		if ival, err := strconv.ParseInt(parts[1], 10, 64); err == nil {
+			fields[parts[0]] = ival
+		} else {
+			fields[parts[0]] = parts[1]
+		}
+	}
+	return fields
+}
+
+// gatherClusterNodes parses the output of CLUSTER NODES, each line being the
+// id, address, flags, master, ping-sent, pong-recv, config-epoch and
+// link-state of a node followed by its slots.
+func gatherClusterNodes(nodes string, acc telegraf.Accumulator, globalTags map[string]string) []string {
+	var addresses []string
+
+	scanner := bufio.NewScanner(strings.NewReader(nodes))
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) < 8 {
+			continue
+		}
+
+		address := strings.SplitN(parts[1], "@", 2)[0]
+		flags := strings.Split(parts[2], ",")
+
+		tags := make(map[string]string)
+		for k, v := range globalTags {
+			tags[k] = v
+		}
+		tags["node_id"] = parts[0]
+		tags["node"] = address
+		if parts[3] != "-" {
+			tags["master_id"] = parts[3]
+		}
+
+		fields := map[string]interface{}{
+			"connected": parts[7] == "connected",
+			"slots":     countSlots(parts[8:]),
+			"myself":    false,
+			"fail":      false,
+			"pfail":     false,
+		}
+		for _, flag := range flags {
+			switch flag {
+			case "master", "slave":
+				tags["role"] = flag
+			case "myself":
+				fields["myself"] = true
+			case "fail":
+				fields["fail"] = true
+			case "fail?":
+				fields["pfail"] = true
+			}
+		}
+		if epoch, err := strconv.ParseInt(parts[6], 10, 64); err == nil {
+			fields["config_epoch"] = epoch
+		}
+
+		acc.AddFields("redis_cluster_node", fields, tags)
+
+		// Nodes in handshake or without address can't be gathered
+		if !strings.HasPrefix(address, ":") && fields["fail"] == false {
+			addresses = append(addresses, address)
+		}
+	}
+	return addresses
+}
+
+// countSlots returns the number of slots served, given as single slots or
+// ranges, the slots being migrated are in brackets and not counted.
+func countSlots(slots []string) int64 {
+	var count int64
+	for _, slot := range slots {
+		if strings.HasPrefix(slot, "[") {
+			continue
+		}
+
+		bounds := strings.SplitN(slot, "-", 2)
+		start, err := strconv.ParseInt(bounds[0], 10, 64)
+		if err != nil {
+			continue
+		}
+		end := start
+		if len(bounds) == 2 {
+			if end, err = strconv.ParseInt(bounds[1], 10, 64); err != nil {
+				continue
+			}
+		}
+		count += end - start + 1
+	}
+	return count
+}
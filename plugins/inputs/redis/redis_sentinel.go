@@ -0,0 +1,181 @@
+package redis
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+)
+
+// gatherSentinel gathers the state of a sentinel and of the masters and
+// replicas it monitors, and returns the addresses of the masters and
+// replicas which are up.
+func (r *Redis) gatherSentinel(client Client, acc telegraf.Accumulator) ([]string, error) {
+	info, err := client.Info().Result()
+	if err != nil {
+		return nil, err
+	}
+	acc.AddFields("redis_sentinel", parseSentinelInfo(info), client.BaseTags())
+
+	reply, err := client.Do("sentinel", "masters")
+	if err != nil {
+		return nil, err
+	}
+	masters, err := parseSentinelReply(reply)
+	if err != nil {
+		return nil, err
+	}
+
+	var addresses []string
+	for _, master := range masters {
+		name := master["name"]
+		if len(r.SentinelMasters) > 0 && !contains(r.SentinelMasters, name) {
+			continue
+		}
+
+		tags := client.BaseTags()
+		tags["master"] = name
+		fields := sentinelFields(master, []string{
+			"num-slaves",
+			"num-other-sentinels",
+			"quorum",
+			"config-epoch",
+			"failover-timeout",
+			"parallel-syncs",
+			"down-after-milliseconds",
+			"last-ok-ping-reply",
+			"pending-commands",
+		})
+		address := net.JoinHostPort(master["ip"], master["port"])
+		fields["address"] = address
+		acc.AddFields("redis_sentinel_masters", fields, tags)
+		if fields["down"] == false {
+			addresses = append(addresses, address)
+		}
+
+		// SENTINEL SLAVES is still supported by the versions having the
+		// REPLICAS alias
+		reply, err := client.Do("sentinel", "slaves", name)
+		if err != nil {
+			return addresses, err
+		}
+		replicas, err := parseSentinelReply(reply)
+		if err != nil {
+			return addresses, err
+		}
+
+		for _, replica := range replicas {
+			address := net.JoinHostPort(replica["ip"], replica["port"])
+
+			replicaTags := client.BaseTags()
+			replicaTags["master"] = name
+			replicaTags["replica"] = address
+			fields := sentinelFields(replica, []string{
+				"master-link-down-time",
+				"slave-priority",
+				"slave-repl-offset",
+				"last-ok-ping-reply",
+			})
+			fields["master_link_status"] = replica["master-link-status"]
+			acc.AddFields("redis_sentinel_replicas", fields, replicaTags)
+			if fields["down"] == false {
+				addresses = append(addresses, address)
+			}
+		}
+	}
+	return addresses, nil
+}
+
+// sentinelFields returns the integer fields of a master or replica, along with
+// its flags and if it is down.
+func sentinelFields(values map[string]string, keys []string) map[string]interface{} {
+	fields := make(map[string]interface{})
+	for _, key := range keys {
+		if ival, err := strconv.ParseInt(values[key], 10, 64); err == nil {
+			fields[strings.Replace(key, "-", "_", -1)] = ival
+		}
+	}
+
+	flags := values["flags"]
+	fields["flags"] = flags
+	fields["down"] = false
+	for _, flag := range strings.Split(flags, ",") {
+		if flag == "s_down" || flag == "o_down" || flag == "disconnected" {
+			fields["down"] = true
+		}
+	}
+	return fields
+}
+
+// parseSentinelReply converts the reply of SENTINEL MASTERS and SLAVES, a
+// list of flat lists of keys and values, to maps.
+func parseSentinelReply(reply interface{}) ([]map[string]string, error) {
+	list, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("unexpected sentinel reply %T", reply)
+	}
+
+	var result []map[string]string
+	for _, item := range list {
+		kvs, ok := item.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("unexpected sentinel reply item %T", item)
+		}
+
+		m := make(map[string]string)
+		for i := 0; i+1 < len(kvs); i += 2 {
+			k, _ := kvs[i].(string)
+			v, _ := kvs[i+1].(string)
+			m[k] = v
+		}
+		result = append(result, m)
+	}
+	return result, nil
+}
+
+// parseSentinelInfo returns the integer fields of the Sentinel section of
+// INFO, along with the uptime and the number of clients.
+func parseSentinelInfo(info string) map[string]interface{} {
+	fields := make(map[string]interface{})
+
+	var section string
+	scanner := bufio.NewScanner(strings.NewReader(info))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "# ") {
+			section = line[2:]
+			continue
+		}
+
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		ival, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		switch {
+		case section == "Sentinel":
+			fields[parts[0]] = ival
+		case parts[0] == "uptime_in_seconds":
+			fields["uptime"] = ival
+		case parts[0] == "connected_clients":
+			fields["clients"] = ival
+		}
+	}
+	return fields
+}
+
+func contains(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
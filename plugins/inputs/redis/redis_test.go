@@ -7,6 +7,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-redis/redis"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -210,3 +211,270 @@ db0:keys=2,expires=0,avg_ttl=0
 
 (error) ERR unknown command 'eof'
 `
+
+type testClient struct {
+	address string
+	tags    map[string]string
+	info    string
+	replies map[string]interface{}
+}
+
+func (c *testClient) Info() *redis.StringCmd {
+	return redis.NewStringResult(c.info, nil)
+}
+
+func (c *testClient) Do(args ...interface{}) (interface{}, error) {
+	cmd := strings.TrimSpace(fmt.Sprintln(args...))
+	if reply, ok := c.replies[cmd]; ok {
+		return reply, nil
+	}
+	return nil, redis.Nil
+}
+
+func (c *testClient) BaseTags() map[string]string {
+	tags := make(map[string]string)
+	for k, v := range c.tags {
+		tags[k] = v
+	}
+	return tags
+}
+
+func (c *testClient) Address() string {
+	return c.address
+}
+
+func (c *testClient) Password() string {
+	return ""
+}
+
+// newTestRedis returns a plugin whose clients are looked up in the given
+// map, by address.
+func newTestRedis(r *Redis, clients map[string]*testClient) *Redis {
+	r.newClient = func(network, address, password string, tags map[string]string) Client {
+		c, ok := clients[address]
+		if !ok {
+			c = &testClient{}
+		}
+		c.address = address
+		c.tags = tags
+		return c
+	}
+	return r
+}
+
+const clusterNodesOutput = `07c37dfeb235213a872192d90877d0cd55635b91 127.0.0.1:30004@31004 slave e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 0 1426238317239 4 connected
+67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1 127.0.0.1:30002@31002 master - 0 1426238316232 2 connected 5461-10922
+292f8b365bb7edb5e285caf0b7e6ddc7265d2f4f 127.0.0.1:30003@31003 master,fail - 0 1426238318243 3 disconnected 10923-16383
+e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca 127.0.0.1:30001@31001 myself,master - 0 0 1 connected 0-5460 [5461->-67ed2db8d677e59ec4a4cefb06858cf2a1a89fa1]
+`
+
+func TestRedis_Cluster(t *testing.T) {
+	seed := &testClient{
+		info: "# Server\r\nuptime_in_seconds:238\r\n",
+		replies: map[string]interface{}{
+			"cluster info":  "cluster_state:ok\r\ncluster_slots_assigned:16384\r\ncluster_known_nodes:4\r\n",
+			"cluster nodes": clusterNodesOutput,
+		},
+	}
+	replica := &testClient{info: "# Server\r\nuptime_in_seconds:120\r\n"}
+
+	r := newTestRedis(&Redis{
+		Servers:          []string{"tcp://127.0.0.1:30001"},
+		ClusterDiscovery: true,
+	}, map[string]*testClient{
+		"127.0.0.1:30001": seed,
+		"127.0.0.1:30004": replica,
+	})
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(r.Gather))
+
+	seedTags := map[string]string{"server": "127.0.0.1", "port": "30001"}
+	acc.AssertContainsTaggedFields(t, "redis_cluster",
+		map[string]interface{}{
+			"cluster_state":          "ok",
+			"cluster_slots_assigned": int64(16384),
+			"cluster_known_nodes":    int64(4),
+		}, seedTags)
+
+	acc.AssertContainsTaggedFields(t, "redis_cluster_node",
+		map[string]interface{}{
+			"connected":    true,
+			"slots":        int64(5461),
+			"myself":       true,
+			"fail":         false,
+			"pfail":        false,
+			"config_epoch": int64(1),
+		},
+		map[string]string{
+			"server":  "127.0.0.1",
+			"port":    "30001",
+			"node_id": "e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca",
+			"node":    "127.0.0.1:30001",
+			"role":    "master",
+		})
+	acc.AssertContainsTaggedFields(t, "redis_cluster_node",
+		map[string]interface{}{
+			"connected":    true,
+			"slots":        int64(0),
+			"myself":       false,
+			"fail":         false,
+			"pfail":        false,
+			"config_epoch": int64(4),
+		},
+		map[string]string{
+			"server":    "127.0.0.1",
+			"port":      "30001",
+			"node_id":   "07c37dfeb235213a872192d90877d0cd55635b91",
+			"node":      "127.0.0.1:30004",
+			"role":      "slave",
+			"master_id": "e7d1eecce10fd6bb5eb35b9f99a514335d9ba9ca",
+		})
+
+	// The discovered nodes are gathered, except the failed one
+	assert.True(t, acc.HasPoint("redis", seedTags, "uptime", int64(238)))
+	assert.True(t, acc.HasPoint("redis",
+		map[string]string{"server": "127.0.0.1", "port": "30004"}, "uptime", int64(120)))
+	assert.True(t, acc.HasTag("redis", "server"))
+	for _, m := range acc.Metrics {
+		if m.Measurement == "redis" {
+			assert.NotEqual(t, "30003", m.Tags["port"])
+		}
+	}
+}
+
+func TestRedis_Sentinel(t *testing.T) {
+	sentinel := &testClient{
+		info: "# Server\r\nuptime_in_seconds:3600\r\n# Clients\r\nconnected_clients:3\r\n" +
+			"# Sentinel\r\nsentinel_masters:1\r\nsentinel_tilt:0\r\n" +
+			"master0:name=mymaster,status=ok,address=10.0.0.1:6379,slaves=1,sentinels=3\r\n",
+		replies: map[string]interface{}{
+			"sentinel masters": []interface{}{
+				[]interface{}{"name", "mymaster", "ip", "10.0.0.1", "port", "6379", "flags", "master",
+					"num-slaves", "1", "num-other-sentinels", "2", "quorum", "2", "config-epoch", "3"},
+				[]interface{}{"name", "other", "ip", "10.0.1.1", "port", "6379", "flags", "master"},
+			},
+			"sentinel slaves mymaster": []interface{}{
+				[]interface{}{"name", "10.0.0.2:6379", "ip", "10.0.0.2", "port", "6379",
+					"flags", "slave,s_down", "master-link-status", "err", "slave-repl-offset", "1024"},
+			},
+		},
+	}
+
+	r := newTestRedis(&Redis{
+		Sentinels:         []string{"tcp://10.0.0.10:26379"},
+		SentinelMasters:   []string{"mymaster"},
+		SentinelDiscovery: true,
+	}, map[string]*testClient{
+		"10.0.0.10:26379": sentinel,
+		"10.0.0.1:6379":   {info: "# Replication\r\nrole:master\r\n"},
+	})
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(r.Gather))
+
+	sentinelTags := map[string]string{"server": "10.0.0.10", "port": "26379"}
+	acc.AssertContainsTaggedFields(t, "redis_sentinel",
+		map[string]interface{}{
+			"uptime":           int64(3600),
+			"clients":          int64(3),
+			"sentinel_masters": int64(1),
+			"sentinel_tilt":    int64(0),
+		}, sentinelTags)
+
+	acc.AssertContainsTaggedFields(t, "redis_sentinel_masters",
+		map[string]interface{}{
+			"address":             "10.0.0.1:6379",
+			"flags":               "master",
+			"down":                false,
+			"num_slaves":          int64(1),
+			"num_other_sentinels": int64(2),
+			"quorum":              int64(2),
+			"config_epoch":        int64(3),
+		},
+		map[string]string{"server": "10.0.0.10", "port": "26379", "master": "mymaster"})
+
+	acc.AssertContainsTaggedFields(t, "redis_sentinel_replicas",
+		map[string]interface{}{
+			"flags":              "slave,s_down",
+			"down":               true,
+			"master_link_status": "err",
+			"slave_repl_offset":  int64(1024),
+		},
+		map[string]string{"server": "10.0.0.10", "port": "26379", "master": "mymaster", "replica": "10.0.0.2:6379"})
+
+	// Only the master which is up is gathered
+	assert.True(t, acc.HasPoint("redis",
+		map[string]string{"server": "10.0.0.1", "port": "6379", "replication_role": "master"},
+		"keyspace_hitrate", 0.0))
+	assert.Equal(t, 4, len(acc.Metrics))
+}
+
+func TestRedis_Commands(t *testing.T) {
+	server := &testClient{
+		replies: map[string]interface{}{
+			"get sample-key": "42.5",
+			"llen queue":     int64(12),
+			"get counter":    "7",
+		},
+	}
+
+	r := newTestRedis(&Redis{
+		Servers: []string{"tcp://localhost:6379"},
+		Commands: []*RedisCommand{
+			{Command: []interface{}{"get", "sample-key"}, Field: "sample_key", Type: "string"},
+			{Command: []interface{}{"llen", "queue"}, Field: "queue_length"},
+			{Command: []interface{}{"get", "counter"}, Field: "counter", Type: "float"},
+			{Command: []interface{}{"get", "missing"}, Field: "missing"},
+		},
+	}, map[string]*testClient{"localhost:6379": server})
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(r.Gather))
+
+	acc.AssertContainsTaggedFields(t, "redis_commands",
+		map[string]interface{}{
+			"sample_key":   "42.5",
+			"queue_length": int64(12),
+			"counter":      7.0,
+		},
+		map[string]string{"server": "localhost", "port": "6379"})
+}
+
+func TestRedis_CommandsInvalidType(t *testing.T) {
+	r := newTestRedis(&Redis{
+		Commands: []*RedisCommand{
+			{Command: []interface{}{"get", "a"}, Field: "a", Type: "bool"},
+		},
+	}, nil)
+
+	var acc testutil.Accumulator
+	require.Error(t, r.Gather(&acc))
+}
+
+func TestCommandField(t *testing.T) {
+	tests := []struct {
+		val      interface{}
+		typ      string
+		expected interface{}
+	}{
+		{"12", "", int64(12)},
+		{"1.5", "", 1.5},
+		{"abc", "", "abc"},
+		{int64(3), "", int64(3)},
+		{int64(3), "string", "3"},
+		{int64(3), "float", 3.0},
+		{"12", "integer", int64(12)},
+	}
+
+	for _, tt := range tests {
+		v, err := commandField(tt.val, tt.typ)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, v)
+	}
+
+	_, err := commandField("abc", "integer")
+	assert.Error(t, err)
+	_, err = commandField([]interface{}{"a"}, "")
+	assert.Error(t, err)
+}
@@ -0,0 +1,451 @@
+package s3_consumer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/sqs"
+
+	"github.com/influxdata/telegraf"
+	internalaws "github.com/influxdata/telegraf/internal/config/aws"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"github.com/influxdata/telegraf/plugins/parsers"
+)
+
+const defaultMaxMessages = 100
+
+type s3Client interface {
+	ListObjectsV2Pages(*s3.ListObjectsV2Input, func(*s3.ListObjectsV2Output, bool) bool) error
+	GetObject(*s3.GetObjectInput) (*s3.GetObjectOutput, error)
+}
+
+type sqsClient interface {
+	ReceiveMessage(*sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error)
+	DeleteMessage(*sqs.DeleteMessageInput) (*sqs.DeleteMessageOutput, error)
+}
+
+type S3Consumer struct {
+	Region      string `toml:"region"`
+	AccessKey   string `toml:"access_key"`
+	SecretKey   string `toml:"secret_key"`
+	RoleARN     string `toml:"role_arn"`
+	Profile     string `toml:"profile"`
+	Filename    string `toml:"shared_credential_file"`
+	Token       string `toml:"token"`
+	EndpointURL string `toml:"endpoint_url"`
+
+	Bucket      string `toml:"bucket"`
+	Prefix      string `toml:"prefix"`
+	SQSQueueURL string `toml:"sqs_queue_url"`
+	MaxMessages int    `toml:"max_messages"`
+	StateFile   string `toml:"state_file"`
+	KeyTag      string `toml:"key_tag"`
+
+	parser parsers.Parser
+	s3     s3Client
+	sqs    sqsClient
+	state  *listState
+	listed bool
+}
+
+// listState tracks the objects read when listing the prefix: the objects
+// modified before LastModified are read, as well as the objects of Keys
+// modified at LastModified.
+type listState struct {
+	LastModified time.Time `json:"last_modified"`
+	Keys         []string  `json:"keys"`
+}
+
+// isNew returns whether the object has not been read yet.
+func (s *listState) isNew(key string, modified time.Time) bool {
+	if modified.After(s.LastModified) {
+		return true
+	}
+	if modified.Before(s.LastModified) {
+		return false
+	}
+	for _, k := range s.Keys {
+		if k == key {
+			return false
+		}
+	}
+	return true
+}
+
+// add records the object as read, the objects must be added by increasing
+// modification time.
+func (s *listState) add(key string, modified time.Time) {
+	if modified.After(s.LastModified) {
+		s.LastModified = modified
+		s.Keys = s.Keys[:0]
+	}
+	s.Keys = append(s.Keys, key)
+}
+
+// s3Event is an S3 event notification, delivered to SQS directly or through
+// an SNS topic.
+type s3Event struct {
+	Records []struct {
+		EventName string `json:"eventName"`
+		S3        struct {
+			Bucket struct {
+				Name string `json:"name"`
+			} `json:"bucket"`
+			Object struct {
+				Key string `json:"key"`
+			} `json:"object"`
+		} `json:"s3"`
+	} `json:"Records"`
+
+	// Set when the notification is wrapped in an SNS message
+	Type    string `json:"Type"`
+	Message string `json:"Message"`
+}
+
+var sampleConfig = `
+  ## Amazon Region
+  region = "us-east-1"
+
+  ## Amazon Credentials
+  ## Credentials are loaded in the following order
+  ## 1) Assumed credentials via STS if role_arn is specified
+  ## 2) explicit credentials from 'access_key' and 'secret_key'
+  ## 3) shared profile from 'profile'
+  ## 4) environment variables
+  ## 5) shared credentials file
+  ## 6) EC2 Instance Profile
+  #access_key = ""
+  #secret_key = ""
+  #token = ""
+  #role_arn = ""
+  #profile = ""
+  #shared_credential_file = ""
+
+  ## Endpoint of an S3 compatible object storage, instead of AWS.
+  # endpoint_url = ""
+
+  ## Bucket and prefix of the objects to read.
+  bucket = "my-bucket"
+  # prefix = "logs/"
+
+  ## URL of an SQS queue receiving the event notifications of the bucket.  The
+  ## objects created are then read on the notifications, instead of listing
+  ## the prefix every interval.
+  # sqs_queue_url = ""
+
+  ## Maximum number of notifications handled every interval.
+  # max_messages = 100
+
+  ## File to store the last object read when listing the prefix, to not read
+  ## the objects again when telegraf restarts.  Without a state file, only
+  ## the objects created after telegraf starts are read.
+  # state_file = "/var/lib/telegraf/s3_consumer.state"
+
+  ## The name of a tag holding the key of the object the metrics are read
+  ## from.
+  # key_tag = ""
+
+  ## Data format to consume, the objects compressed with gzip are
+  ## decompressed first.
+  ## Each data format has its own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_INPUT.md
+  data_format = "influx"
+`
+
+func (c *S3Consumer) SampleConfig() string {
+	return sampleConfig
+}
+
+func (c *S3Consumer) Description() string {
+	return "Read metrics from the objects of an S3 bucket"
+}
+
+func (c *S3Consumer) SetParser(parser parsers.Parser) {
+	c.parser = parser
+}
+
+func (c *S3Consumer) connect() {
+	credentialConfig := &internalaws.CredentialConfig{
+		Region:    c.Region,
+		AccessKey: c.AccessKey,
+		SecretKey: c.SecretKey,
+		RoleARN:   c.RoleARN,
+		Profile:   c.Profile,
+		Filename:  c.Filename,
+		Token:     c.Token,
+	}
+	configProvider := credentialConfig.Credentials()
+
+	config := aws.NewConfig()
+	if c.EndpointURL != "" {
+		config = config.WithEndpoint(c.EndpointURL).WithS3ForcePathStyle(true)
+	}
+	c.s3 = s3.New(configProvider, config)
+
+	if c.SQSQueueURL != "" {
+		c.sqs = sqs.New(configProvider)
+	}
+}
+
+func (c *S3Consumer) Gather(acc telegraf.Accumulator) error {
+	if c.s3 == nil {
+		c.connect()
+	}
+
+	if c.SQSQueueURL != "" {
+		return c.gatherNotifications(acc)
+	}
+	return c.gatherList(acc)
+}
+
+// gatherList reads the objects of the prefix created or modified since the
+// last interval.
+func (c *S3Consumer) gatherList(acc telegraf.Accumulator) error {
+	if c.state == nil {
+		state, err := c.loadState()
+		if err != nil {
+			return err
+		}
+		c.state = state
+	}
+
+	var objects []*s3.Object
+	input := &s3.ListObjectsV2Input{
+		Bucket: aws.String(c.Bucket),
+		Prefix: aws.String(c.Prefix),
+	}
+	err := c.s3.ListObjectsV2Pages(input, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		for _, object := range page.Contents {
+			key := aws.StringValue(object.Key)
+			if c.state.isNew(key, aws.TimeValue(object.LastModified)) {
+				objects = append(objects, object)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return fmt.Errorf("listing s3://%s/%s failed: %s", c.Bucket, c.Prefix, err)
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		ti, tj := aws.TimeValue(objects[i].LastModified), aws.TimeValue(objects[j].LastModified)
+		if ti.Equal(tj) {
+			return aws.StringValue(objects[i].Key) < aws.StringValue(objects[j].Key)
+		}
+		return ti.Before(tj)
+	})
+
+	// Without a state file, the objects already in the bucket are skipped
+	if !c.listed && c.StateFile == "" {
+		c.listed = true
+		for _, object := range objects {
+			c.state.add(aws.StringValue(object.Key), aws.TimeValue(object.LastModified))
+		}
+		return nil
+	}
+	c.listed = true
+
+	for _, object := range objects {
+		key := aws.StringValue(object.Key)
+		if err := c.readObject(acc, c.Bucket, key); err != nil {
+			acc.AddError(err)
+		}
+		// The objects which can't be read or parsed are not retried
+		c.state.add(key, aws.TimeValue(object.LastModified))
+	}
+
+	if len(objects) > 0 {
+		return c.saveState()
+	}
+	return nil
+}
+
+// gatherNotifications reads the objects created from the notifications
+// received on the queue.
+func (c *S3Consumer) gatherNotifications(acc telegraf.Accumulator) error {
+	maxMessages := c.MaxMessages
+	if maxMessages <= 0 {
+		maxMessages = defaultMaxMessages
+	}
+
+	for received := 0; received < maxMessages; {
+		batch := maxMessages - received
+		if batch > 10 {
+			batch = 10
+		}
+		output, err := c.sqs.ReceiveMessage(&sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(c.SQSQueueURL),
+			MaxNumberOfMessages: aws.Int64(int64(batch)),
+		})
+		if err != nil {
+			return fmt.Errorf("receiving from %s failed: %s", c.SQSQueueURL, err)
+		}
+		if len(output.Messages) == 0 {
+			return nil
+		}
+		received += len(output.Messages)
+
+		for _, message := range output.Messages {
+			if err := c.handleNotification(acc, aws.StringValue(message.Body)); err != nil {
+				// The message is received again after its visibility timeout
+				acc.AddError(err)
+				continue
+			}
+			_, err := c.sqs.DeleteMessage(&sqs.DeleteMessageInput{
+				QueueUrl:      aws.String(c.SQSQueueURL),
+				ReceiptHandle: message.ReceiptHandle,
+			})
+			if err != nil {
+				acc.AddError(fmt.Errorf("deleting message from %s failed: %s", c.SQSQueueURL, err))
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *S3Consumer) handleNotification(acc telegraf.Accumulator, body string) error {
+	var event s3Event
+	if err := json.Unmarshal([]byte(body), &event); err != nil {
+		return fmt.Errorf("invalid s3 notification: %s", err)
+	}
+	if event.Type == "Notification" {
+		if err := json.Unmarshal([]byte(event.Message), &event); err != nil {
+			return fmt.Errorf("invalid s3 notification: %s", err)
+		}
+	}
+
+	for _, record := range event.Records {
+		if !strings.HasPrefix(record.EventName, "ObjectCreated:") {
+			continue
+		}
+		bucket := record.S3.Bucket.Name
+		// The keys of the notifications are URL encoded
+		key, err := url.QueryUnescape(record.S3.Object.Key)
+		if err != nil {
+			return fmt.Errorf("invalid key in s3 notification %q: %s", record.S3.Object.Key, err)
+		}
+		if bucket != c.Bucket || !strings.HasPrefix(key, c.Prefix) {
+			continue
+		}
+		if err := c.readObject(acc, bucket, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readObject downloads, decompresses and parses an object.
+func (c *S3Consumer) readObject(acc telegraf.Accumulator, bucket, key string) error {
+	output, err := c.s3.GetObject(&s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	if err != nil {
+		return fmt.Errorf("getting s3://%s/%s failed: %s", bucket, key, err)
+	}
+	defer output.Body.Close()
+
+	// Detect gzip from the content, as the encoding and suffix of the object
+	// are not reliable
+	var reader io.Reader = bufio.NewReader(output.Body)
+	if magic, err := reader.(*bufio.Reader).Peek(2); err == nil && bytes.Equal(magic, []byte{0x1f, 0x8b}) {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return fmt.Errorf("decompressing s3://%s/%s failed: %s", bucket, key, err)
+		}
+		defer gz.Close()
+		reader = gz
+	}
+
+	buf, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("reading s3://%s/%s failed: %s", bucket, key, err)
+	}
+	metrics, err := c.parser.Parse(buf)
+	if err != nil {
+		return fmt.Errorf("parsing s3://%s/%s failed: %s", bucket, key, err)
+	}
+
+	for _, m := range metrics {
+		if c.KeyTag != "" {
+			m.AddTag(c.KeyTag, key)
+		}
+		acc.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
+	}
+	return nil
+}
+
+func (c *S3Consumer) loadState() (*listState, error) {
+	state := &listState{}
+	if c.StateFile == "" {
+		return state, nil
+	}
+
+	data, err := ioutil.ReadFile(c.StateFile)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("invalid state file %s: %s", c.StateFile, err)
+	}
+	return state, nil
+}
+
+// saveState writes the state file, through a temporary file to not leave a
+// partial state.
+func (c *S3Consumer) saveState() error {
+	if c.StateFile == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(c.state)
+	if err != nil {
+		return err
+	}
+	tmp, err := ioutil.TempFile(filepath.Dir(c.StateFile), filepath.Base(c.StateFile))
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), c.StateFile); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	log.Printf("D! [inputs.s3_consumer] saved state to %s", c.StateFile)
+	return nil
+}
+
+func init() {
+	inputs.Add("s3_consumer", func() telegraf.Input {
+		return &S3Consumer{
+			MaxMessages: defaultMaxMessages,
+		}
+	})
+}
@@ -0,0 +1,198 @@
+package s3_consumer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/influxdata/telegraf/plugins/parsers"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeObject struct {
+	data     []byte
+	modified time.Time
+}
+
+type fakeS3 struct {
+	objects map[string]fakeObject
+	gets    []string
+}
+
+func (f *fakeS3) ListObjectsV2Pages(input *s3.ListObjectsV2Input, fn func(*s3.ListObjectsV2Output, bool) bool) error {
+	// One page per object, to check all the pages are read
+	for key, object := range f.objects {
+		page := &s3.ListObjectsV2Output{
+			Contents: []*s3.Object{{
+				Key:          aws.String(key),
+				LastModified: aws.Time(object.modified),
+			}},
+		}
+		if !fn(page, false) {
+			break
+		}
+	}
+	return nil
+}
+
+func (f *fakeS3) GetObject(input *s3.GetObjectInput) (*s3.GetObjectOutput, error) {
+	key := aws.StringValue(input.Key)
+	f.gets = append(f.gets, key)
+	object, ok := f.objects[key]
+	if !ok {
+		return nil, errors.New("NoSuchKey")
+	}
+	return &s3.GetObjectOutput{Body: ioutil.NopCloser(bytes.NewReader(object.data))}, nil
+}
+
+type fakeSQS struct {
+	messages []*sqs.Message
+	deleted  []string
+}
+
+func (f *fakeSQS) ReceiveMessage(input *sqs.ReceiveMessageInput) (*sqs.ReceiveMessageOutput, error) {
+	n := int(aws.Int64Value(input.MaxNumberOfMessages))
+	if n > len(f.messages) {
+		n = len(f.messages)
+	}
+	output := &sqs.ReceiveMessageOutput{Messages: f.messages[:n]}
+	f.messages = f.messages[n:]
+	return output, nil
+}
+
+func (f *fakeSQS) DeleteMessage(input *sqs.DeleteMessageInput) (*sqs.DeleteMessageOutput, error) {
+	f.deleted = append(f.deleted, aws.StringValue(input.ReceiptHandle))
+	return &sqs.DeleteMessageOutput{}, nil
+}
+
+func gzipData(t *testing.T, data string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write([]byte(data))
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func newConsumer(t *testing.T) *S3Consumer {
+	parser, err := parsers.NewInfluxParser()
+	require.NoError(t, err)
+
+	c := &S3Consumer{
+		Bucket: "bucket",
+		Prefix: "logs/",
+		KeyTag: "key",
+	}
+	c.SetParser(parser)
+	return c
+}
+
+func TestGatherList(t *testing.T) {
+	dir, err := ioutil.TempDir("", "s3_consumer")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	t0 := time.Date(2018, 7, 12, 10, 0, 0, 0, time.UTC)
+	client := &fakeS3{objects: map[string]fakeObject{
+		"logs/a": {[]byte("cpu value=1 1531389600000000000\n"), t0},
+		"logs/b": {gzipData(t, "cpu value=2 1531389600000000000\n"), t0},
+	}}
+
+	c := newConsumer(t)
+	c.StateFile = filepath.Join(dir, "state")
+	c.s3 = client
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(c.Gather))
+	acc.AssertContainsTaggedFields(t, "cpu", map[string]interface{}{"value": 1.0}, map[string]string{"key": "logs/a"})
+	acc.AssertContainsTaggedFields(t, "cpu", map[string]interface{}{"value": 2.0}, map[string]string{"key": "logs/b"})
+
+	// Only the new objects are read
+	client.objects["logs/c"] = fakeObject{[]byte("cpu value=3 1531389600000000000\n"), t0}
+	client.objects["logs/d"] = fakeObject{[]byte("cpu value=4 1531389600000000000\n"), t0.Add(time.Minute)}
+	acc.ClearMetrics()
+	require.NoError(t, acc.GatherError(c.Gather))
+	assert.Len(t, acc.Metrics, 2)
+	acc.AssertContainsTaggedFields(t, "cpu", map[string]interface{}{"value": 3.0}, map[string]string{"key": "logs/c"})
+	acc.AssertContainsTaggedFields(t, "cpu", map[string]interface{}{"value": 4.0}, map[string]string{"key": "logs/d"})
+
+	// The state is kept across restarts
+	c = newConsumer(t)
+	c.StateFile = filepath.Join(dir, "state")
+	c.s3 = client
+	acc.ClearMetrics()
+	require.NoError(t, acc.GatherError(c.Gather))
+	assert.Len(t, acc.Metrics, 0)
+	assert.Equal(t, []string{"logs/a", "logs/b", "logs/c", "logs/d"}, client.gets)
+}
+
+func TestGatherListWithoutState(t *testing.T) {
+	t0 := time.Date(2018, 7, 12, 10, 0, 0, 0, time.UTC)
+	client := &fakeS3{objects: map[string]fakeObject{
+		"logs/a": {[]byte("cpu value=1 1531389600000000000\n"), t0},
+	}}
+
+	c := newConsumer(t)
+	c.s3 = client
+
+	// The objects in the bucket on the first interval are skipped
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(c.Gather))
+	assert.Len(t, acc.Metrics, 0)
+
+	client.objects["logs/b"] = fakeObject{[]byte("cpu value=2 1531389600000000000\n"), t0.Add(time.Second)}
+	require.NoError(t, acc.GatherError(c.Gather))
+	assert.Len(t, acc.Metrics, 1)
+	acc.AssertContainsTaggedFields(t, "cpu", map[string]interface{}{"value": 2.0}, map[string]string{"key": "logs/b"})
+}
+
+func TestGatherNotifications(t *testing.T) {
+	client := &fakeS3{objects: map[string]fakeObject{
+		"logs/a b.gz": {gzipData(t, "cpu value=1 1531389600000000000\n"), time.Now()},
+		"logs/bad":    {[]byte("not line protocol\n"), time.Now()},
+	}}
+	queue := &fakeSQS{messages: []*sqs.Message{
+		{
+			ReceiptHandle: aws.String("1"),
+			Body:          aws.String(`{"Records":[{"eventName":"ObjectCreated:Put","s3":{"bucket":{"name":"bucket"},"object":{"key":"logs/a+b.gz"}}}]}`),
+		},
+		{
+			ReceiptHandle: aws.String("2"),
+			Body:          aws.String(`{"Records":[{"eventName":"ObjectRemoved:Delete","s3":{"bucket":{"name":"bucket"},"object":{"key":"logs/gone"}}}]}`),
+		},
+		{
+			ReceiptHandle: aws.String("3"),
+			Body:          aws.String(`{"Records":[{"eventName":"ObjectCreated:Put","s3":{"bucket":{"name":"bucket"},"object":{"key":"logs/bad"}}}]}`),
+		},
+		{
+			ReceiptHandle: aws.String("4"),
+			Body:          aws.String(`{"Type":"Notification","Message":"{\"Records\":[{\"eventName\":\"ObjectCreated:Put\",\"s3\":{\"bucket\":{\"name\":\"bucket\"},\"object\":{\"key\":\"other/c\"}}}]}"}`),
+		},
+	}}
+
+	c := newConsumer(t)
+	c.SQSQueueURL = "https://sqs.us-east-1.amazonaws.com/123456789012/queue"
+	c.s3 = client
+	c.sqs = queue
+
+	var acc testutil.Accumulator
+	require.Error(t, acc.GatherError(c.Gather))
+	assert.Len(t, acc.Errors, 1)
+
+	acc.AssertContainsTaggedFields(t, "cpu", map[string]interface{}{"value": 1.0}, map[string]string{"key": "logs/a b.gz"})
+	assert.Len(t, acc.Metrics, 1)
+
+	// The message of the object which failed to parse is kept in the queue
+	assert.Equal(t, []string{"1", "2", "4"}, queue.deleted)
+	assert.Equal(t, []string{"logs/a b.gz", "logs/bad"}, client.gets)
+}
@@ -21,18 +21,31 @@ var (
 	execCommand = exec.Command // execCommand is used to mock commands in tests.
 
 	// Device Model:     APPLE SSD SM256E
-	modelInInfo = regexp.MustCompile("^Device Model:\\s+(.*)$")
+	// Model Number:     Samsung SSD 970 EVO 500GB
+	modelInInfo = regexp.MustCompile("^(?:Device Model|Model Number|Product):\\s+(.*)$")
 	// Serial Number:    S0X5NZBC422720
 	serialInInfo = regexp.MustCompile("^Serial Number:\\s+(.*)$")
 	// LU WWN Device Id: 5 002538 655584d30
 	wwnInInfo = regexp.MustCompile("^LU WWN Device Id:\\s+(.*)$")
 	// User Capacity:    251,000,193,024 bytes [251 GB]
-	usercapacityInInfo = regexp.MustCompile("^User Capacity:\\s+([0-9,]+)\\s+bytes.*$")
+	// Total NVM Capacity:                 500,107,862,016 [500 GB]
+	usercapacityInInfo = regexp.MustCompile("^(?:User Capacity:\\s+([0-9,]+)\\s+bytes|Total NVM Capacity:\\s+([0-9,]+)\\s+\\[).*$")
 	// SMART support is: Enabled
 	smartEnabledInInfo = regexp.MustCompile("^SMART support is:\\s+(\\w+)$")
 	// SMART overall-health self-assessment test result: PASSED
 	// PASSED, FAILED, UNKNOWN
 	smartOverallHealth = regexp.MustCompile("^SMART overall-health self-assessment test result:\\s+(\\w+).*$")
+	// SMART Health Status: OK
+	smartHealthStatus = regexp.MustCompile("^SMART Health Status:\\s+(\\w+).*$")
+
+	// === START OF SMART DATA SECTION ===
+	// SMART/Health Information (NVMe Log 0x02, NSID 0xffffffff)
+	nvmeSection = regexp.MustCompile("^SMART/Health Information \\(NVMe Log")
+	// Available Spare:                    100%
+	// Data Units Read:                    2,150,339 [1.10 TB]
+	nvmeAttribute = regexp.MustCompile("^([A-Za-z][\\w ./-]*?)\\s*:\\s+(.*)$")
+	// 0x00, 38 Celsius, 100%, 1,232
+	nvmeValue = regexp.MustCompile("^(0x[0-9a-fA-F]+|[0-9,]+)")
 
 	// ID# ATTRIBUTE_NAME          FLAGS    VALUE WORST THRESH FAIL RAW_VALUE
 	//   1 Raw_Read_Error_Rate     -O-RC-   200   200   000    -    0
@@ -50,17 +63,24 @@ var (
 
 type Smart struct {
 	Path       string
+	PathNVMe   string `toml:"path_nvme"`
 	Nocheck    string
 	Attributes bool
 	Excludes   []string
 	Devices    []string
 	UseSudo    bool
+	Timeout    internal.Duration
 }
 
 var sampleConfig = `
   ## Optionally specify the path to the smartctl executable
   # path = "/usr/bin/smartctl"
   #
+  ## Optionally specify the path to the nvme-cli executable, when found NVMe
+  ## attributes are read using "nvme smart-log" which includes vendor
+  ## specific log entries not reported by smartctl.
+  # path_nvme = "/usr/sbin/nvme"
+  #
   ## On most platforms smartctl requires root access.
   ## Setting 'use_sudo' to true will make use of sudo to run smartctl.
   ## Sudo must be configured to to allow the telegraf user to run smartctl
@@ -88,6 +108,9 @@ var sampleConfig = `
   ## done and all found will be included except for the
   ## excluded in excludes.
   # devices = [ "/dev/ada0 -d atacam" ]
+  #
+  ## Timeout for the smartctl and nvme commands to complete.
+  # timeout = "5s"
 `
 
 func (m *Smart) SampleConfig() string {
@@ -129,7 +152,7 @@ func sudo(sudo bool, command string, args ...string) *exec.Cmd {
 func (m *Smart) scan() ([]string, error) {
 
 	cmd := sudo(m.UseSudo, m.Path, "--scan")
-	out, err := internal.CombinedOutputTimeout(cmd, time.Second*5)
+	out, err := internal.CombinedOutputTimeout(cmd, m.Timeout.Duration)
 	if err != nil {
 		return []string{}, fmt.Errorf("failed to run command %s: %s - %s", strings.Join(cmd.Args, " "), err, string(out))
 	}
@@ -163,7 +186,7 @@ func (m *Smart) getAttributes(acc telegraf.Accumulator, devices []string) {
 	wg.Add(len(devices))
 
 	for _, device := range devices {
-		go gatherDisk(acc, m.UseSudo, m.Attributes, m.Path, m.Nocheck, device, &wg)
+		go m.gatherDisk(acc, device, &wg)
 	}
 
 	wg.Wait()
@@ -180,14 +203,14 @@ func exitStatus(err error) (int, error) {
 	return 0, err
 }
 
-func gatherDisk(acc telegraf.Accumulator, usesudo, attributes bool, smartctl, nockeck, device string, wg *sync.WaitGroup) {
+func (m *Smart) gatherDisk(acc telegraf.Accumulator, device string, wg *sync.WaitGroup) {
 
 	defer wg.Done()
 	// smartctl 5.41 & 5.42 have are broken regarding handling of --nocheck/-n
-	args := []string{"--info", "--health", "--attributes", "--tolerance=verypermissive", "-n", nockeck, "--format=brief"}
+	args := []string{"--info", "--health", "--attributes", "--tolerance=verypermissive", "-n", m.Nocheck, "--format=brief"}
 	args = append(args, strings.Split(device, " ")...)
-	cmd := sudo(usesudo, smartctl, args...)
-	out, e := internal.CombinedOutputTimeout(cmd, time.Second*5)
+	cmd := sudo(m.UseSudo, m.Path, args...)
+	out, e := internal.CombinedOutputTimeout(cmd, m.Timeout.Duration)
 	outStr := string(out)
 
 	// Ignore all exit statuses except if it is a command line parse error
@@ -203,6 +226,11 @@ func gatherDisk(acc telegraf.Accumulator, usesudo, attributes bool, smartctl, no
 	device_fields := make(map[string]interface{})
 	device_fields["exit_status"] = exitStatus
 
+	// NVMe attributes are read with nvme-cli when available
+	isNVMe := strings.Contains(device, "nvme")
+	useNVMeCli := isNVMe && m.Attributes && len(m.PathNVMe) > 0
+	inNVMeSection := false
+
 	scanner := bufio.NewScanner(strings.NewReader(outStr))
 
 	for scanner.Scan() {
@@ -210,7 +238,7 @@ func gatherDisk(acc telegraf.Accumulator, usesudo, attributes bool, smartctl, no
 
 		model := modelInInfo.FindStringSubmatch(line)
 		if len(model) > 1 {
-			device_tags["model"] = model[1]
+			device_tags["model"] = strings.TrimSpace(model[1])
 		}
 
 		serial := serialInInfo.FindStringSubmatch(line)
@@ -224,8 +252,8 @@ func gatherDisk(acc telegraf.Accumulator, usesudo, attributes bool, smartctl, no
 		}
 
 		capacity := usercapacityInInfo.FindStringSubmatch(line)
-		if len(capacity) > 1 {
-			device_tags["capacity"] = strings.Replace(capacity[1], ",", "", -1)
+		if len(capacity) > 2 {
+			device_tags["capacity"] = strings.Replace(capacity[1]+capacity[2], ",", "", -1)
 		}
 
 		enabled := smartEnabledInInfo.FindStringSubmatch(line)
@@ -238,23 +266,53 @@ func gatherDisk(acc telegraf.Accumulator, usesudo, attributes bool, smartctl, no
 			device_fields["health_ok"] = (health[1] == "PASSED")
 		}
 
+		health = smartHealthStatus.FindStringSubmatch(line)
+		if len(health) > 1 {
+			device_fields["health_ok"] = (health[1] == "OK")
+		}
+
+		if nvmeSection.MatchString(line) {
+			inNVMeSection = true
+			continue
+		}
+
+		if inNVMeSection {
+			attr := nvmeAttribute.FindStringSubmatch(line)
+			if len(attr) < 3 {
+				inNVMeSection = false
+				continue
+			}
+
+			val, err := parseNVMeValue(attr[2])
+			if err != nil {
+				continue
+			}
+
+			if attr[1] == "Temperature" {
+				device_fields["temp_c"] = val
+			}
+
+			if m.Attributes && !useNVMeCli {
+				tags := attributeTags(device_tags)
+				tags["name"] = strings.Join(strings.Fields(attr[1]), "_")
+
+				fields := map[string]interface{}{
+					"exit_status": exitStatus,
+					"raw_value":   val,
+				}
+				acc.AddFields("smart_attribute", fields, tags)
+			}
+			continue
+		}
+
 		attr := attribute.FindStringSubmatch(line)
 
 		if len(attr) > 1 {
 
-			if attributes {
-				tags := map[string]string{}
+			if m.Attributes {
+				tags := attributeTags(device_tags)
 				fields := make(map[string]interface{})
 
-				device_node := strings.Split(device, " ")[0]
-				tags["device"] = path.Base(device_node)
-
-				if serial, ok := device_tags["serial_no"]; ok {
-					tags["serial_no"] = serial
-				}
-				if wwn, ok := device_tags["wwn"]; ok {
-					tags["wwn"] = wwn
-				}
 				tags["id"] = attr[1]
 				tags["name"] = attr[2]
 				tags["flags"] = attr[3]
@@ -287,9 +345,68 @@ func gatherDisk(acc telegraf.Accumulator, usesudo, attributes bool, smartctl, no
 			}
 		}
 	}
+
+	if useNVMeCli {
+		m.gatherNVMeLog(acc, device_node, device_tags)
+	}
+
 	acc.AddFields("smart_device", device_fields, device_tags)
 }
 
+// attributeTags returns the tags of the device identifying an attribute.
+func attributeTags(device_tags map[string]string) map[string]string {
+	tags := map[string]string{
+		"device": device_tags["device"],
+	}
+	for _, key := range []string{"model", "serial_no", "wwn"} {
+		if value, ok := device_tags[key]; ok {
+			tags[key] = value
+		}
+	}
+	return tags
+}
+
+// Get the attributes of a NVMe device using nvme-cli.
+func (m *Smart) gatherNVMeLog(acc telegraf.Accumulator, device string, device_tags map[string]string) {
+	cmd := sudo(m.UseSudo, m.PathNVMe, "smart-log", device)
+	out, err := internal.CombinedOutputTimeout(cmd, m.Timeout.Duration)
+	if err != nil {
+		acc.AddError(fmt.Errorf("failed to run command %s: %s - %s", strings.Join(cmd.Args, " "), err, string(out)))
+		return
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(string(out)))
+	for scanner.Scan() {
+		attr := nvmeAttribute.FindStringSubmatch(scanner.Text())
+		if len(attr) < 3 {
+			continue
+		}
+
+		val, err := parseNVMeValue(attr[2])
+		if err != nil {
+			continue
+		}
+
+		tags := attributeTags(device_tags)
+		tags["name"] = strings.Join(strings.Fields(attr[1]), "_")
+		acc.AddFields("smart_attribute", map[string]interface{}{"raw_value": val}, tags)
+	}
+}
+
+// parseNVMeValue parses the leading number of a NVMe attribute, such as
+// "38 Celsius", "100%", "0x00" or "2,150,339 [1.10 TB]".
+func parseNVMeValue(rawVal string) (int64, error) {
+	number := nvmeValue.FindString(strings.TrimSpace(rawVal))
+	if number == "" {
+		return 0, fmt.Errorf("Couldn't parse value '%s'", rawVal)
+	}
+
+	if strings.HasPrefix(number, "0x") {
+		return strconv.ParseInt(number[2:], 16, 64)
+	}
+	return strconv.ParseInt(strings.Replace(number, ",", "", -1), 10, 64)
+}
+
 func parseRawValue(rawVal string) (int64, error) {
 
 	// Integer
@@ -338,7 +455,12 @@ func init() {
 	if len(path) > 0 {
 		m.Path = path
 	}
+	path, _ = exec.LookPath("nvme")
+	if len(path) > 0 {
+		m.PathNVMe = path
+	}
 	m.Nocheck = "standby"
+	m.Timeout = internal.Duration{Duration: time.Second * 5}
 
 	inputs.Add("smart", func() telegraf.Input {
 		return &m
@@ -5,7 +5,9 @@ import (
 	"os"
 	"os/exec"
 	"testing"
+	"time"
 
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -59,6 +61,76 @@ ID# ATTRIBUTE_NAME          FLAGS    VALUE WORST THRESH FAIL RAW_VALUE
                             |||____ S speed/performance
                             ||_____ O updated online
                             |______ P prefailure warning
+`
+
+	mockInfoNVMeData = `smartctl 6.6 2016-05-31 r4324 [x86_64-linux-4.15.0-24-generic] (local build)
+Copyright (C) 2002-16, Bruce Allen, Christian Franke, www.smartmontools.org
+
+=== START OF INFORMATION SECTION ===
+Model Number:                       Samsung SSD 970 EVO 500GB
+Serial Number:                      S466NX0K701203
+Firmware Version:                   1B2QEXE7
+PCI Vendor/Subsystem ID:            0x144d
+IEEE OUI Identifier:                0x002538
+Total NVM Capacity:                 500,107,862,016 [500 GB]
+Unallocated NVM Capacity:           0
+Controller ID:                      4
+Number of Namespaces:               1
+Namespace 1 Size/Capacity:          500,107,862,016 [500 GB]
+Namespace 1 Utilization:            93,306,036,224 [93.3 GB]
+Namespace 1 Formatted LBA Size:     512
+Local Time is:                      Thu Jul 12 10:23:45 2018 CEST
+
+=== START OF SMART DATA SECTION ===
+SMART overall-health self-assessment test result: PASSED
+
+SMART/Health Information (NVMe Log 0x02, NSID 0xffffffff)
+Critical Warning:                   0x00
+Temperature:                        38 Celsius
+Available Spare:                    100%
+Available Spare Threshold:          10%
+Percentage Used:                    0%
+Data Units Read:                    2,150,339 [1.10 TB]
+Data Units Written:                 3,486,175 [1.78 TB]
+Host Read Commands:                 27,348,937
+Host Write Commands:                56,306,491
+Controller Busy Time:               212
+Power Cycles:                       1,232
+Power On Hours:                     1,408
+Unsafe Shutdowns:                   81
+Media and Data Integrity Errors:    0
+Error Information Log Entries:      1,473
+Warning  Comp. Temperature Time:    0
+Critical Comp. Temperature Time:    0
+Temperature Sensor 1:               38 Celsius
+Temperature Sensor 2:               44 Celsius
+
+`
+
+	mockNVMeSmartLogData = `Smart Log for NVME device:nvme0 namespace-id:ffffffff
+critical_warning                    : 0
+temperature                         : 38 C
+available_spare                     : 100%
+available_spare_threshold           : 10%
+percentage_used                     : 0%
+data_units_read                     : 2,150,339
+data_units_written                  : 3,486,175
+host_read_commands                  : 27,348,937
+host_write_commands                 : 56,306,491
+controller_busy_time                : 212
+power_cycles                        : 1,232
+power_on_hours                      : 1,408
+unsafe_shutdowns                    : 81
+media_errors                        : 0
+num_err_log_entries                 : 1,473
+Warning Temperature Time            : 0
+Critical Composite Temperature Time : 0
+Temperature Sensor 1                : 38 C
+Temperature Sensor 2                : 44 C
+Thermal Management T1 Trans Count   : 3
+Thermal Management T2 Trans Count   : 0
+Thermal Management T1 Total Time    : 1,032
+Thermal Management T2 Total Time    : 0
 `
 )
 
@@ -66,6 +138,7 @@ func TestGatherAttributes(t *testing.T) {
 	s := &Smart{
 		Path:       "smartctl",
 		Attributes: true,
+		Timeout:    internal.Duration{Duration: time.Second * 5},
 	}
 	// overwriting exec commands with mock commands
 	execCommand = fakeExecCommand
@@ -90,6 +163,7 @@ func TestGatherAttributes(t *testing.T) {
 			},
 			map[string]string{
 				"device":    "ada0",
+				"model":     "APPLE SSD SM256E",
 				"serial_no": "S0X5NZBC422720",
 				"wwn":       "5002538043584d30",
 				"id":        "1",
@@ -108,6 +182,7 @@ func TestGatherAttributes(t *testing.T) {
 			},
 			map[string]string{
 				"device":    "ada0",
+				"model":     "APPLE SSD SM256E",
 				"serial_no": "S0X5NZBC422720",
 				"wwn":       "5002538043584d30",
 				"id":        "5",
@@ -126,6 +201,7 @@ func TestGatherAttributes(t *testing.T) {
 			},
 			map[string]string{
 				"device":    "ada0",
+				"model":     "APPLE SSD SM256E",
 				"serial_no": "S0X5NZBC422720",
 				"wwn":       "5002538043584d30",
 				"id":        "9",
@@ -144,6 +220,7 @@ func TestGatherAttributes(t *testing.T) {
 			},
 			map[string]string{
 				"device":    "ada0",
+				"model":     "APPLE SSD SM256E",
 				"serial_no": "S0X5NZBC422720",
 				"wwn":       "5002538043584d30",
 				"id":        "12",
@@ -162,6 +239,7 @@ func TestGatherAttributes(t *testing.T) {
 			},
 			map[string]string{
 				"device":    "ada0",
+				"model":     "APPLE SSD SM256E",
 				"serial_no": "S0X5NZBC422720",
 				"wwn":       "5002538043584d30",
 				"id":        "169",
@@ -180,6 +258,7 @@ func TestGatherAttributes(t *testing.T) {
 			},
 			map[string]string{
 				"device":    "ada0",
+				"model":     "APPLE SSD SM256E",
 				"serial_no": "S0X5NZBC422720",
 				"wwn":       "5002538043584d30",
 				"id":        "173",
@@ -198,6 +277,7 @@ func TestGatherAttributes(t *testing.T) {
 			},
 			map[string]string{
 				"device":    "ada0",
+				"model":     "APPLE SSD SM256E",
 				"serial_no": "S0X5NZBC422720",
 				"wwn":       "5002538043584d30",
 				"id":        "190",
@@ -216,6 +296,7 @@ func TestGatherAttributes(t *testing.T) {
 			},
 			map[string]string{
 				"device":    "ada0",
+				"model":     "APPLE SSD SM256E",
 				"serial_no": "S0X5NZBC422720",
 				"wwn":       "5002538043584d30",
 				"id":        "192",
@@ -234,6 +315,7 @@ func TestGatherAttributes(t *testing.T) {
 			},
 			map[string]string{
 				"device":    "ada0",
+				"model":     "APPLE SSD SM256E",
 				"serial_no": "S0X5NZBC422720",
 				"wwn":       "5002538043584d30",
 				"id":        "194",
@@ -252,6 +334,7 @@ func TestGatherAttributes(t *testing.T) {
 			},
 			map[string]string{
 				"device":    "ada0",
+				"model":     "APPLE SSD SM256E",
 				"serial_no": "S0X5NZBC422720",
 				"wwn":       "5002538043584d30",
 				"id":        "197",
@@ -270,6 +353,7 @@ func TestGatherAttributes(t *testing.T) {
 			},
 			map[string]string{
 				"device":    "ada0",
+				"model":     "APPLE SSD SM256E",
 				"serial_no": "S0X5NZBC422720",
 				"wwn":       "5002538043584d30",
 				"id":        "199",
@@ -288,6 +372,7 @@ func TestGatherAttributes(t *testing.T) {
 			},
 			map[string]string{
 				"device":    "ada0",
+				"model":     "APPLE SSD SM256E",
 				"serial_no": "S0X5NZBC422720",
 				"wwn":       "5002538043584d30",
 				"id":        "240",
@@ -337,6 +422,7 @@ func TestGatherNoAttributes(t *testing.T) {
 	s := &Smart{
 		Path:       "smartctl",
 		Attributes: false,
+		Timeout:    internal.Duration{Duration: time.Second * 5},
 	}
 	// overwriting exec commands with mock commands
 	execCommand = fakeExecCommand
@@ -379,6 +465,123 @@ func TestGatherNoAttributes(t *testing.T) {
 
 }
 
+func TestGatherNVMe(t *testing.T) {
+	s := &Smart{
+		Path:       "smartctl",
+		Attributes: true,
+		Devices:    []string{"/dev/nvme0"},
+		Timeout:    internal.Duration{Duration: time.Second * 5},
+	}
+	// overwriting exec commands with mock commands
+	execCommand = fakeExecCommand
+	var acc testutil.Accumulator
+
+	err := s.Gather(&acc)
+
+	require.NoError(t, err)
+	assert.Len(t, acc.Errors, 0)
+
+	tags := map[string]string{
+		"device":    "nvme0",
+		"model":     "Samsung SSD 970 EVO 500GB",
+		"serial_no": "S466NX0K701203",
+		"name":      "Available_Spare",
+	}
+	fields := map[string]interface{}{
+		"raw_value":   int64(100),
+		"exit_status": int(0),
+	}
+	acc.AssertContainsTaggedFields(t, "smart_attribute", fields, tags)
+
+	tags["name"] = "Data_Units_Read"
+	fields["raw_value"] = int64(2150339)
+	acc.AssertContainsTaggedFields(t, "smart_attribute", fields, tags)
+
+	acc.AssertContainsTaggedFields(t, "smart_device",
+		map[string]interface{}{
+			"exit_status": int(0),
+			"health_ok":   true,
+			"temp_c":      int64(38),
+		},
+		map[string]string{
+			"device":    "nvme0",
+			"model":     "Samsung SSD 970 EVO 500GB",
+			"serial_no": "S466NX0K701203",
+			"capacity":  "500107862016",
+		})
+}
+
+func TestGatherNVMeCli(t *testing.T) {
+	s := &Smart{
+		Path:       "smartctl",
+		PathNVMe:   "nvme",
+		Attributes: true,
+		Devices:    []string{"/dev/nvme0"},
+		Timeout:    internal.Duration{Duration: time.Second * 5},
+	}
+	// overwriting exec commands with mock commands
+	execCommand = fakeExecCommand
+	var acc testutil.Accumulator
+
+	err := s.Gather(&acc)
+
+	require.NoError(t, err)
+	assert.Len(t, acc.Errors, 0)
+
+	tags := map[string]string{
+		"device":    "nvme0",
+		"model":     "Samsung SSD 970 EVO 500GB",
+		"serial_no": "S466NX0K701203",
+	}
+
+	var tests = []struct {
+		name  string
+		value int64
+	}{
+		{"critical_warning", 0},
+		{"temperature", 38},
+		{"available_spare", 100},
+		{"data_units_read", 2150339},
+		{"Thermal_Management_T1_Trans_Count", 3},
+		{"Temperature_Sensor_2", 44},
+	}
+
+	for _, test := range tests {
+		tags["name"] = test.name
+		acc.AssertContainsTaggedFields(t, "smart_attribute",
+			map[string]interface{}{"raw_value": test.value}, tags)
+	}
+
+	// smartctl attributes are not reported when nvme-cli is used
+	for _, m := range acc.Metrics {
+		if m.Measurement == "smart_attribute" {
+			assert.NotEqual(t, "Available_Spare", m.Tags["name"])
+		}
+	}
+}
+
+func TestParseNVMeValue(t *testing.T) {
+	var tests = []struct {
+		input string
+		value int64
+	}{
+		{"0x00", 0},
+		{"0x1f", 31},
+		{"38 Celsius", 38},
+		{"311 C", 311},
+		{"100%", 100},
+		{"2,150,339 [1.10 TB]", 2150339},
+	}
+	for _, test := range tests {
+		value, err := parseNVMeValue(test.input)
+		require.NoError(t, err)
+		assert.Equal(t, test.value, value)
+	}
+
+	_, err := parseNVMeValue("-")
+	assert.Error(t, err)
+}
+
 func TestExcludedDev(t *testing.T) {
 	assert.Equal(t, true, excludedDev([]string{"/dev/pass6"}, "/dev/pass6 -d atacam"), "Should be excluded.")
 	assert.Equal(t, false, excludedDev([]string{}, "/dev/pass6 -d atacam"), "Shouldn't be excluded.")
@@ -416,8 +619,14 @@ func TestHelperProcess(t *testing.T) {
 			fmt.Fprint(os.Stdout, mockScanData)
 		}
 		if arg1 == "--info" {
-			fmt.Fprint(os.Stdout, mockInfoAttributeData)
+			if args[len(args)-1] == "/dev/nvme0" {
+				fmt.Fprint(os.Stdout, mockInfoNVMeData)
+			} else {
+				fmt.Fprint(os.Stdout, mockInfoAttributeData)
+			}
 		}
+	} else if cmd == "nvme" && arg1 == "smart-log" {
+		fmt.Fprint(os.Stdout, mockNVMeSmartLogData)
 	} else {
 		fmt.Fprint(os.Stdout, "command not found")
 		os.Exit(1)
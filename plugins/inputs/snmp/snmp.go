@@ -35,6 +35,13 @@ const sampleConfig = `
   ## The GETBULK max-repetitions parameter
   max_repetitions = 10
 
+  ## Translator used to look up the OIDs and the tables in the MIBs, values
+  ## can be "native" to parse the MIB files in-process, or "netsnmp" to use
+  ## the net-snmp utilities snmptranslate and snmptable.
+  # translator = "native"
+  ## Directories of the MIB files, used by the native translator.
+  # mib_paths = ["/usr/share/snmp/mibs"]
+
   ## SNMPv3 auth parameters
   #sec_name = "myuser"
   #auth_protocol = "md5"      # Values: "MD5", "SHA", ""
@@ -78,6 +85,9 @@ const sampleConfig = `
     oid = "HOST-RESOURCES-MIB::hrNetworkTable"
 `
 
+// defaultMibPaths are the directories of the MIB files installed by net-snmp.
+var defaultMibPaths = []string{"/usr/share/snmp/mibs"}
+
 // execCommand is so tests can mock out exec.Command usage.
 var execCommand = exec.Command
 
@@ -110,6 +120,11 @@ type Snmp struct {
 	// Parameters for Version 1 & 2
 	Community string
 
+	// Values: "native", "netsnmp". Default: "native"
+	Translator string
+	// Directories of the MIB files for the native translator.
+	MibPaths []string
+
 	// Parameters for Version 2 & 3
 	MaxRepetitions uint8
 
@@ -137,6 +152,7 @@ type Snmp struct {
 	Fields []Field `toml:"field"`
 
 	connectionCache []snmpConnection
+	translator      translator
 	initialized     bool
 }
 
@@ -147,14 +163,31 @@ func (s *Snmp) init() error {
 
 	s.connectionCache = make([]snmpConnection, len(s.Agents))
 
+	switch s.Translator {
+	case "", "native":
+		paths := s.MibPaths
+		if len(paths) == 0 {
+			paths = defaultMibPaths
+		}
+		tr, err := newMibTranslator(paths)
+		if err != nil {
+			return err
+		}
+		s.translator = tr
+	case "netsnmp":
+		s.translator = netsnmpTranslator{}
+	default:
+		return fmt.Errorf("invalid translator %q", s.Translator)
+	}
+
 	for i := range s.Tables {
-		if err := s.Tables[i].init(); err != nil {
+		if err := s.Tables[i].init(s.translator); err != nil {
 			return Errorf(err, "initializing table %s", s.Tables[i].Name)
 		}
 	}
 
 	for i := range s.Fields {
-		if err := s.Fields[i].init(); err != nil {
+		if err := s.Fields[i].init(s.translator); err != nil {
 			return Errorf(err, "initializing field %s", s.Fields[i].Name)
 		}
 	}
@@ -186,18 +219,18 @@ type Table struct {
 }
 
 // init() builds & initializes the nested fields.
-func (t *Table) init() error {
+func (t *Table) init(tr translator) error {
 	if t.initialized {
 		return nil
 	}
 
-	if err := t.initBuild(); err != nil {
+	if err := t.initBuild(tr); err != nil {
 		return err
 	}
 
 	// initialize all the nested fields
 	for i := range t.Fields {
-		if err := t.Fields[i].init(); err != nil {
+		if err := t.Fields[i].init(tr); err != nil {
 			return Errorf(err, "initializing field %s", t.Fields[i].Name)
 		}
 	}
@@ -207,14 +240,14 @@ func (t *Table) init() error {
 }
 
 // initBuild initializes the table if it has an OID configured. If so, the
-// net-snmp tools will be used to look up the OID and auto-populate the table's
+// translator will be used to look up the OID and auto-populate the table's
 // fields.
-func (t *Table) initBuild() error {
+func (t *Table) initBuild(tr translator) error {
 	if t.Oid == "" {
 		return nil
 	}
 
-	_, _, oidText, fields, err := snmpTable(t.Oid)
+	_, _, oidText, fields, err := tr.snmpTable(t.Oid)
 	if err != nil {
 		return err
 	}
@@ -263,12 +296,12 @@ type Field struct {
 }
 
 // init() converts OID names to numbers, and sets the .Name attribute if unset.
-func (f *Field) init() error {
+func (f *Field) init(tr translator) error {
 	if f.initialized {
 		return nil
 	}
 
-	_, oidNum, oidText, conversion, err := snmpTranslate(f.Oid)
+	_, oidNum, oidText, conversion, err := tr.snmpTranslate(f.Oid)
 	if err != nil {
 		return Errorf(err, "translating")
 	}
@@ -1007,12 +1040,7 @@ func snmpTranslateCall(oid string) (mibName string, oidNum string, oidText strin
 
 		if strings.HasPrefix(line, "  -- TEXTUAL CONVENTION ") {
 			tc := strings.TrimPrefix(line, "  -- TEXTUAL CONVENTION ")
-			switch tc {
-			case "MacAddress", "PhysAddress":
-				conversion = "hwaddr"
-			case "InetAddressIPv4", "InetAddressIPv6", "InetAddress":
-				conversion = "ipaddr"
-			}
+			conversion = textualConventionConversion(tc)
 		} else if strings.HasPrefix(line, "::= { ") {
 			objs := strings.TrimPrefix(line, "::= { ")
 			objs = strings.TrimSuffix(objs, " }")
@@ -148,7 +148,7 @@ func TestFieldInit(t *testing.T) {
 
 	for _, txl := range translations {
 		f := Field{Oid: txl.inputOid, Name: txl.inputName, Conversion: txl.inputConversion}
-		err := f.init()
+		err := f.init(netsnmpTranslator{})
 		if !assert.NoError(t, err, "inputOid='%s' inputName='%s'", txl.inputOid, txl.inputName) {
 			continue
 		}
@@ -165,7 +165,7 @@ func TestTableInit(t *testing.T) {
 			{Oid: "TEST::description", Name: "description", IsTag: true},
 		},
 	}
-	err := tbl.init()
+	err := tbl.init(netsnmpTranslator{})
 	require.NoError(t, err)
 
 	assert.Equal(t, "testTable", tbl.Name)
@@ -180,6 +180,7 @@ func TestTableInit(t *testing.T) {
 
 func TestSnmpInit(t *testing.T) {
 	s := &Snmp{
+		Translator: "netsnmp",
 		Tables: []Table{
 			{Oid: "TEST::testTable"},
 		},
@@ -212,6 +213,7 @@ func TestSnmpInit_noTranslate(t *testing.T) {
 	}
 
 	s := &Snmp{
+		Translator: "netsnmp",
 		Fields: []Field{
 			{Oid: ".1.1.1.1", Name: "one", IsTag: true},
 			{Oid: ".1.1.1.2", Name: "two"},
@@ -254,6 +256,90 @@ func TestSnmpInit_noTranslate(t *testing.T) {
 	assert.Equal(t, false, s.Tables[0].Fields[2].IsTag)
 }
 
+func TestFieldInit_native(t *testing.T) {
+	tr, err := newMibTranslator([]string{"testdata"})
+	require.NoError(t, err)
+
+	translations := []struct {
+		inputOid           string
+		inputName          string
+		expectedOid        string
+		expectedName       string
+		expectedConversion string
+	}{
+		{".1.2.3", "foo", ".1.2.3", "foo", ""},
+		{".iso.2.3", "foo", ".1.2.3", "foo", ""},
+		{".1.0.0.0.1.1", "", ".1.0.0.0.1.1", "server", ""},
+		{".1.0.0.0.1.1.0", "", ".1.0.0.0.1.1.0", "server.0", ""},
+		{".999", "", ".999", ".999", ""},
+		{"TEST::server", "", ".1.0.0.0.1.1", "server", ""},
+		{"TEST::server.0", "", ".1.0.0.0.1.1.0", "server.0", ""},
+		{"TEST::server", "foo", ".1.0.0.0.1.1", "foo", ""},
+		{"hostname", "", ".1.0.0.1.1", "hostname", ""},
+		{"TEST-TC::testMacAddress.0", "", ".1.0.0.3.1.0", "testMacAddress.0", "hwaddr"},
+		{".1.0.0.3.2", "", ".1.0.0.3.2", "testInetAddress", "ipaddr"},
+	}
+
+	for _, txl := range translations {
+		f := Field{Oid: txl.inputOid, Name: txl.inputName}
+		err := f.init(tr)
+		if !assert.NoError(t, err, "inputOid='%s' inputName='%s'", txl.inputOid, txl.inputName) {
+			continue
+		}
+		assert.Equal(t, txl.expectedOid, f.Oid, "inputOid='%s' inputName='%s'", txl.inputOid, txl.inputName)
+		assert.Equal(t, txl.expectedName, f.Name, "inputOid='%s' inputName='%s'", txl.inputOid, txl.inputName)
+		assert.Equal(t, txl.expectedConversion, f.Conversion, "inputOid='%s' inputName='%s'", txl.inputOid, txl.inputName)
+	}
+
+	f := Field{Oid: "TEST::unknown"}
+	assert.Error(t, f.init(tr))
+}
+
+func TestSnmpInit_native(t *testing.T) {
+	// override execCommand so the net-snmp utilities cannot be used
+	defer func(ec func(string, ...string) *exec.Cmd) { execCommand = ec }(execCommand)
+	execCommand = func(_ string, _ ...string) *exec.Cmd {
+		return exec.Command("snmptranslateExecErrNotFound")
+	}
+
+	s := &Snmp{
+		MibPaths: []string{"testdata"},
+		Tables: []Table{
+			{Oid: "TEST::testTable"},
+		},
+		Fields: []Field{
+			{Oid: "TEST::hostname"},
+		},
+	}
+
+	err := s.init()
+	require.NoError(t, err)
+
+	assert.Equal(t, "testTable", s.Tables[0].Name)
+	assert.Len(t, s.Tables[0].Fields, 4)
+	assert.Contains(t, s.Tables[0].Fields, Field{Oid: ".1.0.0.0.1.1", Name: "server", IsTag: true, initialized: true})
+	assert.Contains(t, s.Tables[0].Fields, Field{Oid: ".1.0.0.0.1.2", Name: "connections", initialized: true})
+	assert.Contains(t, s.Tables[0].Fields, Field{Oid: ".1.0.0.0.1.3", Name: "latency", initialized: true})
+	assert.Contains(t, s.Tables[0].Fields, Field{Oid: ".1.0.0.0.1.4", Name: "description", initialized: true})
+
+	assert.Equal(t, Field{
+		Oid:         ".1.0.0.1.1",
+		Name:        "hostname",
+		initialized: true,
+	}, s.Fields[0])
+
+	s = &Snmp{
+		MibPaths: []string{"testdata"},
+		Tables: []Table{
+			{Oid: "TEST::server"},
+		},
+	}
+	assert.Error(t, s.init())
+
+	s = &Snmp{Translator: "foo"}
+	assert.Error(t, s.init())
+}
+
 func TestGetSNMPConnection_v2(t *testing.T) {
 	s := &Snmp{
 		Agents:    []string{"1.2.3.4:567", "1.2.3.4"},
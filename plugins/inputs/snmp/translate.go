@@ -0,0 +1,118 @@
+package snmp
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/influxdata/telegraf/internal/mib"
+)
+
+// translator resolves OIDs, and the fields of tables, using the MIBs.
+type translator interface {
+	snmpTranslate(oid string) (mibName string, oidNum string, oidText string, conversion string, err error)
+	snmpTable(oid string) (mibName string, oidNum string, oidText string, fields []Field, err error)
+}
+
+// netsnmpTranslator performs the lookups with the net-snmp utilities
+// `snmptranslate` and `snmptable`.
+type netsnmpTranslator struct{}
+
+func (netsnmpTranslator) snmpTranslate(oid string) (string, string, string, string, error) {
+	return snmpTranslate(oid)
+}
+
+func (netsnmpTranslator) snmpTable(oid string) (string, string, string, []Field, error) {
+	return snmpTable(oid)
+}
+
+// mibTranslator performs the lookups in-process, parsing the MIB files.
+type mibTranslator struct {
+	mib *mib.MIB
+}
+
+var mibCaches map[string]*mib.MIB
+var mibCachesLock sync.Mutex
+
+// newMibTranslator returns a translator using the MIB files of the
+// directories.  The MIB files are only loaded once for all the plugins using
+// the same directories.
+func newMibTranslator(paths []string) (*mibTranslator, error) {
+	mibCachesLock.Lock()
+	defer mibCachesLock.Unlock()
+	if mibCaches == nil {
+		mibCaches = map[string]*mib.MIB{}
+	}
+
+	key := strings.Join(paths, "\000")
+	m, ok := mibCaches[key]
+	if !ok {
+		var err error
+		if m, err = mib.Load(paths); err != nil {
+			return nil, Errorf(err, "loading MIBs")
+		}
+		mibCaches[key] = m
+	}
+	return &mibTranslator{mib: m}, nil
+}
+
+func (t *mibTranslator) snmpTranslate(oid string) (mibName string, oidNum string, oidText string, conversion string, err error) {
+	node, suffix, err := t.mib.Lookup(oid)
+	if err != nil {
+		return "", "", "", "", err
+	}
+	if node == nil {
+		// Not found in the MIBs. We can get by without the lookup.
+		return "", suffix, suffix, "", nil
+	}
+	return node.Module, node.OID + suffix, node.Name + suffix, textualConventionConversion(node.Syntax), nil
+}
+
+func (t *mibTranslator) snmpTable(oid string) (mibName string, oidNum string, oidText string, fields []Field, err error) {
+	mibName, oidNum, oidText, _, err = t.snmpTranslate(oid)
+	if err != nil {
+		return "", "", "", nil, Errorf(err, "translating")
+	}
+
+	node, _, _ := t.mib.Lookup(oidNum)
+	if node == nil || node.OID != oidNum || len(node.Children()) == 0 {
+		return "", "", "", nil, fmt.Errorf("could not find any columns in table")
+	}
+	entry := node.Children()[0]
+
+	index := entry.Index
+	if entry.Augments != "" {
+		if augmented, _, err := t.mib.Lookup(entry.Module + "::" + entry.Augments); err == nil && augmented != nil {
+			index = augmented.Index
+		}
+	}
+	tagOids := map[string]struct{}{}
+	for _, col := range index {
+		tagOids[col] = struct{}{}
+	}
+
+	for _, col := range entry.Children() {
+		if col.Access == "not-accessible" {
+			continue
+		}
+		_, isTag := tagOids[col.Name]
+		fields = append(fields, Field{Name: col.Name, Oid: col.Module + "::" + col.Name, IsTag: isTag})
+	}
+	if len(fields) == 0 {
+		return "", "", "", nil, fmt.Errorf("could not find any columns in table")
+	}
+
+	return mibName, oidNum, oidText, fields, nil
+}
+
+// textualConventionConversion returns the conversion of the values of a
+// textual convention.
+func textualConventionConversion(tc string) string {
+	switch tc {
+	case "MacAddress", "PhysAddress":
+		return "hwaddr"
+	case "InetAddressIPv4", "InetAddressIPv6", "InetAddress":
+		return "ipaddr"
+	}
+	return ""
+}
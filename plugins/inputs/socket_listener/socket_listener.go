@@ -16,6 +16,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/proxyproto"
 	tlsint "github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/plugins/parsers"
@@ -29,14 +30,20 @@ type streamSocketListener struct {
 	net.Listener
 	*SocketListener
 
-	sockType string
+	sockType  string
+	tlsConfig *tls.Config
 
+	// connections are keyed by the address of the peer, which is the one of
+	// the proxy with the PROXY protocol, and sources count the connections
+	// of each source address
 	connections    map[string]net.Conn
+	sources        *proxyproto.SourceCounter
 	connectionsMtx sync.Mutex
 }
 
 func (ssl *streamSocketListener) listen() {
 	ssl.connections = map[string]net.Conn{}
+	ssl.sources = proxyproto.NewSourceCounter(ssl.MaxConnectionsPerSource)
 
 	for {
 		c, err := ssl.Accept()
@@ -55,20 +62,30 @@ func (ssl *streamSocketListener) listen() {
 			}
 		}
 
+		id := c.RemoteAddr().String()
 		ssl.connectionsMtx.Lock()
 		if ssl.MaxConnections > 0 && len(ssl.connections) >= ssl.MaxConnections {
 			ssl.connectionsMtx.Unlock()
 			c.Close()
 			continue
 		}
-		ssl.connections[c.RemoteAddr().String()] = c
+		ssl.connections[id] = c
 		ssl.connectionsMtx.Unlock()
 
 		if err := ssl.setKeepAlive(c); err != nil {
 			ssl.AddError(fmt.Errorf("unable to configure keep alive (%s): %s", ssl.ServiceAddress, err))
 		}
 
-		go ssl.read(c)
+		var pc *proxyproto.Conn
+		if ssl.ProxyProtocol {
+			pc = proxyproto.NewConn(c, 0)
+			c = pc
+		}
+		if ssl.tlsConfig != nil {
+			c = tls.Server(c, ssl.tlsConfig)
+		}
+
+		go ssl.read(c, pc, id)
 	}
 
 	ssl.connectionsMtx.Lock()
@@ -95,16 +112,32 @@ func (ssl *streamSocketListener) setKeepAlive(c net.Conn) error {
 	return tcpc.SetKeepAlivePeriod(ssl.KeepAlivePeriod.Duration)
 }
 
-func (ssl *streamSocketListener) removeConnection(c net.Conn) {
+func (ssl *streamSocketListener) removeConnection(id string) {
 	ssl.connectionsMtx.Lock()
-	delete(ssl.connections, c.RemoteAddr().String())
+	delete(ssl.connections, id)
 	ssl.connectionsMtx.Unlock()
 }
 
-func (ssl *streamSocketListener) read(c net.Conn) {
-	defer ssl.removeConnection(c)
+// read parses the metrics of the connection, pc is the PROXY protocol
+// connection wrapped by the connection when enabled.
+func (ssl *streamSocketListener) read(c net.Conn, pc *proxyproto.Conn, id string) {
+	defer ssl.removeConnection(id)
 	defer c.Close()
 
+	if pc != nil {
+		if err := pc.ReadHeader(); err != nil {
+			ssl.AddError(fmt.Errorf("unable to read PROXY protocol header from %s: %s", id, err))
+			return
+		}
+	}
+
+	source := proxyproto.SourceAddress(c.RemoteAddr())
+	if !ssl.sources.Add(source) {
+		log.Printf("W! [inputs.socket_listener] Maximum connections reached for source %s", source)
+		return
+	}
+	defer ssl.sources.Remove(source)
+
 	scnr := bufio.NewScanner(c)
 	for {
 		if ssl.ReadTimeout != nil && ssl.ReadTimeout.Duration > 0 {
@@ -119,9 +152,7 @@ func (ssl *streamSocketListener) read(c net.Conn) {
 			//TODO rate limit
 			continue
 		}
-		for _, m := range metrics {
-			ssl.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
-		}
+		ssl.addMetrics(metrics, source)
 	}
 
 	if err := scnr.Err(); err != nil {
@@ -141,7 +172,7 @@ type packetSocketListener struct {
 func (psl *packetSocketListener) listen() {
 	buf := make([]byte, 64*1024) // 64kb - maximum size of IP packet
 	for {
-		n, _, err := psl.ReadFrom(buf)
+		n, addr, err := psl.ReadFrom(buf)
 		if err != nil {
 			if !strings.HasSuffix(err.Error(), ": use of closed network connection") {
 				psl.AddError(err)
@@ -155,9 +186,7 @@ func (psl *packetSocketListener) listen() {
 			//TODO rate limit
 			continue
 		}
-		for _, m := range metrics {
-			psl.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
-		}
+		psl.addMetrics(metrics, proxyproto.SourceAddress(addr))
 	}
 }
 
@@ -167,6 +196,10 @@ type SocketListener struct {
 	ReadBufferSize  int                `toml:"read_buffer_size"`
 	ReadTimeout     *internal.Duration `toml:"read_timeout"`
 	KeepAlivePeriod *internal.Duration `toml:"keep_alive_period"`
+	SourceTag       string             `toml:"source_tag"`
+
+	ProxyProtocol           bool `toml:"proxy_protocol"`
+	MaxConnectionsPerSource int  `toml:"max_connections_per_source"`
 	tlsint.ServerConfig
 
 	parsers.Parser
@@ -197,6 +230,20 @@ func (sl *SocketListener) SampleConfig() string {
   ## 0 (default) is unlimited.
   # max_connections = 1024
 
+  ## Maximum number of concurrent connections from a single source address.
+  ## Only applies to stream sockets (e.g. TCP).
+  ## 0 (default) is unlimited.
+  # max_connections_per_source = 0
+
+  ## Read the PROXY protocol v1 or v2 header sent by the load balancer at the
+  ## start of the connections, so that the source address is the one of the
+  ## original client.  The connections without the header are closed.
+  ## Only applies to stream sockets (e.g. TCP).
+  # proxy_protocol = false
+
+  ## Name of a tag holding the source address of the metrics.
+  # source_tag = ""
+
   ## Read timeout.
   ## Only applies to stream sockets (e.g. TCP).
   ## 0 (default) is unlimited.
@@ -263,11 +310,9 @@ func (sl *SocketListener) Start(acc telegraf.Accumulator) error {
 			return nil
 		}
 
-		if tlsCfg == nil {
-			l, err = net.Listen(spl[0], spl[1])
-		} else {
-			l, err = tls.Listen(spl[0], spl[1], tlsCfg)
-		}
+		// TLS is set up on the accepted connections, after the PROXY
+		// protocol header
+		l, err = net.Listen(spl[0], spl[1])
 		if err != nil {
 			return err
 		}
@@ -276,6 +321,7 @@ func (sl *SocketListener) Start(acc telegraf.Accumulator) error {
 			Listener:       l,
 			SocketListener: sl,
 			sockType:       spl[0],
+			tlsConfig:      tlsCfg,
 		}
 
 		sl.Closer = ssl
@@ -312,6 +358,16 @@ func (sl *SocketListener) Start(acc telegraf.Accumulator) error {
 	return nil
 }
 
+// addMetrics adds the metrics, with the source address tag if configured.
+func (sl *SocketListener) addMetrics(metrics []telegraf.Metric, source string) {
+	for _, m := range metrics {
+		if sl.SourceTag != "" && source != "" {
+			m.AddTag(sl.SourceTag, source)
+		}
+		sl.AddMetric(m)
+	}
+}
+
 func (sl *SocketListener) Stop() {
 	if sl.Closer != nil {
 		sl.Close()
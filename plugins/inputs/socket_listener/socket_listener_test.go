@@ -145,6 +145,136 @@ func TestSocketListener_unixgram(t *testing.T) {
 	testSocketListener(t, sl, client)
 }
 
+func TestSocketListener_proxy_protocol(t *testing.T) {
+	defer testEmptyLog(t)()
+
+	sl := newSocketListener()
+	sl.ServiceAddress = "tcp://127.0.0.1:0"
+	sl.ProxyProtocol = true
+	sl.SourceTag = "source"
+
+	acc := &testutil.Accumulator{}
+	err := sl.Start(acc)
+	require.NoError(t, err)
+	defer sl.Stop()
+
+	client, err := net.Dial("tcp", sl.Closer.(net.Listener).Addr().String())
+	require.NoError(t, err)
+	defer client.Close()
+
+	_, err = client.Write([]byte("PROXY TCP4 192.0.2.1 192.0.2.2 56324 8094\r\ntest,foo=bar v=1i 123456789\n"))
+	require.NoError(t, err)
+
+	acc.Wait(1)
+	acc.AssertContainsTaggedFields(t, "test",
+		map[string]interface{}{"v": int64(1)},
+		map[string]string{"foo": "bar", "source": "192.0.2.1"})
+}
+
+func TestSocketListener_proxy_protocol_tls(t *testing.T) {
+	defer testEmptyLog(t)()
+
+	sl := newSocketListener()
+	sl.ServiceAddress = "tcp://127.0.0.1:0"
+	sl.ServerConfig = *pki.TLSServerConfig()
+	sl.ProxyProtocol = true
+	sl.SourceTag = "source"
+
+	acc := &testutil.Accumulator{}
+	err := sl.Start(acc)
+	require.NoError(t, err)
+	defer sl.Stop()
+
+	tlsCfg, err := pki.TLSClientConfig().TLSConfig()
+	require.NoError(t, err)
+
+	// The header is sent in clear, before the TLS handshake
+	conn, err := net.Dial("tcp", sl.Closer.(net.Listener).Addr().String())
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("PROXY TCP6 2001:db8::1 2001:db8::2 56324 8094\r\n"))
+	require.NoError(t, err)
+	tlsCfg.ServerName = "127.0.0.1"
+	client := tls.Client(conn, tlsCfg)
+	defer client.Close()
+
+	_, err = client.Write([]byte("test,foo=bar v=1i 123456789\n"))
+	require.NoError(t, err)
+
+	acc.Wait(1)
+	acc.AssertContainsTaggedFields(t, "test",
+		map[string]interface{}{"v": int64(1)},
+		map[string]string{"foo": "bar", "source": "2001:db8::1"})
+}
+
+func TestSocketListener_max_connections_per_source(t *testing.T) {
+	sl := newSocketListener()
+	sl.ServiceAddress = "tcp://127.0.0.1:0"
+	sl.ProxyProtocol = true
+	sl.MaxConnectionsPerSource = 1
+
+	acc := &testutil.Accumulator{}
+	err := sl.Start(acc)
+	require.NoError(t, err)
+	defer sl.Stop()
+
+	addr := sl.Closer.(net.Listener).Addr().String()
+	dial := func(source string) net.Conn {
+		client, err := net.Dial("tcp", addr)
+		require.NoError(t, err)
+		_, err = client.Write([]byte("PROXY TCP4 " + source + " 192.0.2.2 56324 8094\r\n"))
+		require.NoError(t, err)
+		return client
+	}
+
+	first := dial("192.0.2.1")
+	defer first.Close()
+	_, err = first.Write([]byte("test v=1i 1\n"))
+	require.NoError(t, err)
+	acc.Wait(1)
+
+	// The second connection from the same client is closed
+	second := dial("192.0.2.1")
+	defer second.Close()
+	second.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err = second.Read(make([]byte, 1))
+	assert.Error(t, err)
+	if netErr, ok := err.(net.Error); ok {
+		assert.False(t, netErr.Timeout())
+	}
+
+	// A connection from another client is accepted
+	other := dial("192.0.2.3")
+	defer other.Close()
+	_, err = other.Write([]byte("test v=2i 2\n"))
+	require.NoError(t, err)
+	acc.Wait(2)
+}
+
+func TestSocketListener_missing_proxy_protocol_header(t *testing.T) {
+	sl := newSocketListener()
+	sl.ServiceAddress = "tcp://127.0.0.1:0"
+	sl.ProxyProtocol = true
+
+	acc := &testutil.Accumulator{}
+	err := sl.Start(acc)
+	require.NoError(t, err)
+	defer sl.Stop()
+
+	client, err := net.Dial("tcp", sl.Closer.(net.Listener).Addr().String())
+	require.NoError(t, err)
+	defer client.Close()
+	_, err = client.Write([]byte("test v=1i 1\n"))
+	require.NoError(t, err)
+
+	client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err = client.Read(make([]byte, 1))
+	assert.Error(t, err)
+	acc.Lock()
+	assert.Len(t, acc.Errors, 1)
+	assert.Len(t, acc.Metrics, 0)
+	acc.Unlock()
+}
+
 func testSocketListener(t *testing.T, sl *SocketListener, client net.Conn) {
 	mstr12 := "test,foo=bar v=1i 123456789\ntest,foo=baz v=2i 123456790\n"
 	mstr3 := "test,foo=zab v=3i 123456791"
@@ -0,0 +1,220 @@
+package socketcan
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// message is a CAN message of a DBC file
+type message struct {
+	id      uint32
+	name    string
+	length  int
+	signals []*signal
+	// multiplexer switch of the multiplexed signals
+	multiplexer *signal
+}
+
+// signal is a signal of a DBC message
+type signal struct {
+	name      string
+	start     uint
+	length    uint
+	bigEndian bool
+	signed    bool
+	factor    float64
+	offset    float64
+	unit      string
+	// multiplexed signals are only present when the multiplexer has the
+	// value mux
+	multiplexed bool
+	mux         uint64
+}
+
+// DBC message IDs have the bit 31 set for extended frames
+const dbcExtendedFlag = 0x80000000
+
+var (
+	messageRe = regexp.MustCompile(`^BO_\s+(\d+)\s+(\w+)\s*:\s*(\d+)`)
+	signalRe  = regexp.MustCompile(`^SG_\s+(\w+)\s*(M|m\d+)?\s*:\s*(\d+)\|(\d+)@([01])([+-])\s*\(([^,]+),([^)]+)\)\s*\[[^]]*\]\s*"([^"]*)"`)
+)
+
+// loadDBC reads the messages and signals of a DBC file
+func loadDBC(path string) (map[uint32]*message, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return parseDBC(f)
+}
+
+// parseDBC parses the message (BO_) and signal (SG_) definitions, the other
+// definitions are ignored.
+func parseDBC(r io.Reader) (map[uint32]*message, error) {
+	messages := make(map[uint32]*message)
+
+	var current *message
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+
+		switch {
+		case strings.HasPrefix(line, "BO_ "):
+			m := messageRe.FindStringSubmatch(line)
+			if m == nil {
+				return nil, fmt.Errorf("line %d: invalid message %q", lineNumber, line)
+			}
+			id, err := strconv.ParseUint(m[1], 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid message id: %s", lineNumber, err)
+			}
+			length, _ := strconv.Atoi(m[3])
+			current = &message{
+				id:     uint32(id) &^ dbcExtendedFlag,
+				name:   m[2],
+				length: length,
+			}
+			messages[current.id] = current
+		case strings.HasPrefix(line, "SG_ "):
+			if current == nil {
+				return nil, fmt.Errorf("line %d: signal outside of a message", lineNumber)
+			}
+			s, mux, err := parseSignal(line)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %s", lineNumber, err)
+			}
+			if mux == "M" {
+				current.multiplexer = s
+			}
+			current.signals = append(current.signals, s)
+		case line != "":
+			current = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return messages, nil
+}
+
+func parseSignal(line string) (*signal, string, error) {
+	m := signalRe.FindStringSubmatch(line)
+	if m == nil {
+		return nil, "", fmt.Errorf("invalid signal %q", line)
+	}
+
+	start, _ := strconv.ParseUint(m[3], 10, 32)
+	length, _ := strconv.ParseUint(m[4], 10, 32)
+	if length == 0 || length > 64 {
+		return nil, "", fmt.Errorf("invalid length of signal %s", m[1])
+	}
+	factor, err := strconv.ParseFloat(strings.TrimSpace(m[7]), 64)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid factor of signal %s: %s", m[1], err)
+	}
+	offset, err := strconv.ParseFloat(strings.TrimSpace(m[8]), 64)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid offset of signal %s: %s", m[1], err)
+	}
+
+	s := &signal{
+		name:      m[1],
+		start:     uint(start),
+		length:    uint(length),
+		bigEndian: m[5] == "0",
+		signed:    m[6] == "-",
+		factor:    factor,
+		offset:    offset,
+		unit:      m[9],
+	}
+	if strings.HasPrefix(m[2], "m") {
+		s.multiplexed = true
+		s.mux, _ = strconv.ParseUint(m[2][1:], 10, 64)
+	}
+	return s, m[2], nil
+}
+
+// decode returns the values of the signals present in the data of a frame
+func (m *message) decode(data []byte) map[*signal]interface{} {
+	var payload [8]byte
+	copy(payload[:], data)
+
+	values := make(map[*signal]interface{}, len(m.signals))
+	var mux uint64
+	if m.multiplexer != nil {
+		mux = m.multiplexer.raw(payload[:])
+	}
+	for _, s := range m.signals {
+		if s.multiplexed && (m.multiplexer == nil || s.mux != mux) {
+			continue
+		}
+		if !s.fits(len(data)) {
+			continue
+		}
+		values[s] = s.value(payload[:])
+	}
+	return values
+}
+
+// fits tells whether the signal is within the length of the frame
+func (s *signal) fits(length int) bool {
+	if s.bigEndian {
+		msb := 8*(s.start/8) + (7 - s.start%8)
+		return int(msb+s.length) <= 8*length
+	}
+	return int(s.start+s.length) <= 8*length
+}
+
+// raw extracts the unsigned raw value of the signal.  The start bit is the
+// least significant bit of little endian (Intel) signals, and the most
+// significant bit of big endian (Motorola) signals.
+func (s *signal) raw(data []byte) uint64 {
+	mask := uint64(1)<<s.length - 1
+	if s.length == 64 {
+		mask = ^uint64(0)
+	}
+
+	if s.bigEndian {
+		// position of the most significant bit counted from the most
+		// significant bit of the first byte
+		msb := 8*(s.start/8) + (7 - s.start%8)
+		return (binary.BigEndian.Uint64(data) >> (64 - msb - s.length)) & mask
+	}
+	return (binary.LittleEndian.Uint64(data) >> s.start) & mask
+}
+
+// value returns the physical value of the signal, an integer when the
+// signal is not scaled
+func (s *signal) value(data []byte) interface{} {
+	raw := s.raw(data)
+
+	var v int64
+	if s.signed && s.length < 64 && raw&(1<<(s.length-1)) != 0 {
+		// sign extension
+		v = int64(raw | ^(uint64(1)<<s.length - 1))
+	} else {
+		v = int64(raw)
+	}
+
+	if s.factor == 1 && s.offset == 0 {
+		if !s.signed && s.length == 64 {
+			return raw
+		}
+		return v
+	}
+	if s.signed {
+		return float64(v)*s.factor + s.offset
+	}
+	return float64(raw)*s.factor + s.offset
+}
@@ -0,0 +1,121 @@
+package socketcan
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testDBC = `VERSION ""
+
+NS_ :
+	NS_DESC_
+	CM_
+
+BU_: ECU Dashboard
+
+BO_ 2364540158 EEC1: 8 ECU
+ SG_ EngineSpeed : 24|16@1+ (0.125,0) [0|8031.875] "rpm" Dashboard
+ SG_ EngineTorqueMode : 0|4@1+ (1,0) [0|15] "" Dashboard
+
+BO_ 256 Body: 3 ECU
+ SG_ Temperature : 7|16@0- (0.1,-40) [-40|100] "degC" Dashboard
+ SG_ DoorOpen : 16|1@1+ (1,0) [0|1] "" Dashboard
+
+BO_ 512 Battery: 8 ECU
+ SG_ Page M : 0|8@1+ (1,0) [0|255] "" Dashboard
+ SG_ Voltage m1 : 8|16@1+ (0.5,0) [0|32767] "V" Dashboard
+ SG_ Current m2 : 8|16@1- (0.25,0) [-8192|8191] "A" Dashboard
+
+CM_ SG_ 256 Temperature "Cabin temperature";
+BA_DEF_ BO_ "GenMsgCycleTime" INT 0 65535;
+`
+
+func values(m *message, data []byte) map[string]interface{} {
+	result := make(map[string]interface{})
+	for s, v := range m.decode(data) {
+		result[s.name] = v
+	}
+	return result
+}
+
+func TestParseDBC(t *testing.T) {
+	messages, err := parseDBC(strings.NewReader(testDBC))
+	require.NoError(t, err)
+	require.Len(t, messages, 3)
+
+	eec1, ok := messages[0x0CF004FE]
+	require.True(t, ok)
+	assert.Equal(t, "EEC1", eec1.name)
+	assert.Equal(t, 8, eec1.length)
+	require.Len(t, eec1.signals, 2)
+	assert.Equal(t, &signal{
+		name:   "EngineSpeed",
+		start:  24,
+		length: 16,
+		factor: 0.125,
+		unit:   "rpm",
+	}, eec1.signals[0])
+
+	body := messages[256]
+	require.NotNil(t, body)
+	assert.True(t, body.signals[0].bigEndian)
+	assert.True(t, body.signals[0].signed)
+	assert.Equal(t, float64(-40), body.signals[0].offset)
+
+	battery := messages[512]
+	require.NotNil(t, battery)
+	assert.Equal(t, "Page", battery.multiplexer.name)
+	assert.True(t, battery.signals[2].multiplexed)
+	assert.Equal(t, uint64(2), battery.signals[2].mux)
+}
+
+func TestParseDBCInvalid(t *testing.T) {
+	_, err := parseDBC(strings.NewReader("BO_ 256 Body: 8 ECU\n SG_ Temperature : 7|16@0- (a,b) [0|0] \"\" ECU\n"))
+	assert.Error(t, err)
+
+	_, err = parseDBC(strings.NewReader("BO_ x Body: 8 ECU\n"))
+	assert.Error(t, err)
+}
+
+func TestDecode(t *testing.T) {
+	messages, err := parseDBC(strings.NewReader(testDBC))
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		map[string]interface{}{
+			"EngineSpeed":      float64(900),
+			"EngineTorqueMode": int64(3),
+		},
+		values(messages[0x0CF004FE], []byte{0xF3, 0xFF, 0xFF, 0x20, 0x1C, 0xFF, 0xFF, 0xFF}))
+
+	assert.Equal(t,
+		map[string]interface{}{
+			"Temperature": float64(-65),
+			"DoorOpen":    int64(1),
+		},
+		values(messages[256], []byte{0xFF, 0x06, 0x01}))
+
+	assert.Equal(t,
+		map[string]interface{}{
+			"Page":    int64(1),
+			"Voltage": float64(13),
+		},
+		values(messages[512], []byte{0x01, 0x1A, 0x00}))
+
+	assert.Equal(t,
+		map[string]interface{}{
+			"Page":    int64(2),
+			"Current": float64(-2.5),
+		},
+		values(messages[512], []byte{0x02, 0xF6, 0xFF}))
+
+	// the signals beyond the length of the frame are missing
+	assert.Equal(t,
+		map[string]interface{}{
+			"Temperature": float64(-40),
+		},
+		values(messages[256], []byte{0x00, 0x00}))
+}
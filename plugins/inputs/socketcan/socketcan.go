@@ -0,0 +1,253 @@
+// +build linux
+
+package socketcan
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"golang.org/x/sys/unix"
+)
+
+const sampleConfig = `
+  ## SocketCAN interface to read the frames from
+  interface = "can0"
+
+  ## DBC file describing the messages and signals, the frames are added
+  ## raw without it.
+  # dbc_file = "/etc/telegraf/vehicle.dbc"
+
+  ## Add a metric per message, with a field per signal ("message"), or a
+  ## metric per signal, with the unit of the signal as a tag ("signal").
+  # metric_format = "message"
+
+  ## Add the frames of the messages not described in the DBC file as raw
+  ## frames.
+  # raw_fallback = false
+
+  ## IDs of the messages to keep, all the messages are kept if empty.
+  # message_ids = [256, 1024]
+`
+
+// SocketCAN reads the frames of a SocketCAN interface
+type SocketCAN struct {
+	Interface    string   `toml:"interface"`
+	DBCFile      string   `toml:"dbc_file"`
+	MetricFormat string   `toml:"metric_format"`
+	RawFallback  bool     `toml:"raw_fallback"`
+	MessageIDs   []uint32 `toml:"message_ids"`
+
+	messages map[uint32]*message
+	filter   map[uint32]bool
+	conn     io.ReadCloser
+	wg       sync.WaitGroup
+
+	// open opens the interface, replaced in tests
+	open func(iface string) (io.ReadCloser, error)
+}
+
+// can_frame flags of the ID
+const (
+	canEFFFlag = 0x80000000
+	canRTRFlag = 0x40000000
+	canERRFlag = 0x20000000
+	canEFFMask = 0x1FFFFFFF
+	canSFFMask = 0x000007FF
+)
+
+// size of struct can_frame
+const frameSize = 16
+
+func (s *SocketCAN) Description() string {
+	return "Read the frames of a SocketCAN interface and decode them with a DBC file"
+}
+
+func (s *SocketCAN) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *SocketCAN) Gather(acc telegraf.Accumulator) error {
+	return nil
+}
+
+func (s *SocketCAN) Start(acc telegraf.Accumulator) error {
+	switch s.MetricFormat {
+	case "", "message", "signal":
+	default:
+		return fmt.Errorf("invalid metric_format %q", s.MetricFormat)
+	}
+
+	if s.DBCFile != "" {
+		messages, err := loadDBC(s.DBCFile)
+		if err != nil {
+			return fmt.Errorf("error loading %s: %s", s.DBCFile, err)
+		}
+		s.messages = messages
+	}
+
+	s.filter = make(map[uint32]bool)
+	for _, id := range s.MessageIDs {
+		s.filter[id] = true
+	}
+
+	conn, err := s.open(s.Interface)
+	if err != nil {
+		return fmt.Errorf("error opening %s: %s", s.Interface, err)
+	}
+	s.conn = conn
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.read(acc)
+	}()
+
+	return nil
+}
+
+func (s *SocketCAN) Stop() {
+	s.conn.Close()
+	s.wg.Wait()
+}
+
+// read reads the frames until the connection is closed
+func (s *SocketCAN) read(acc telegraf.Accumulator) {
+	frame := make([]byte, frameSize)
+	for {
+		if _, err := io.ReadFull(s.conn, frame); err != nil {
+			if err != io.EOF && err != io.ErrUnexpectedEOF && !isClosed(err) {
+				acc.AddError(fmt.Errorf("error reading %s: %s", s.Interface, err))
+			}
+			return
+		}
+		s.handleFrame(acc, frame, time.Now())
+	}
+}
+
+func (s *SocketCAN) handleFrame(acc telegraf.Accumulator, frame []byte, t time.Time) {
+	canID := binary.LittleEndian.Uint32(frame)
+	if canID&(canRTRFlag|canERRFlag) != 0 {
+		// remote requests and error frames have no data
+		return
+	}
+
+	id := canID & canSFFMask
+	if canID&canEFFFlag != 0 {
+		id = canID & canEFFMask
+	}
+	if len(s.filter) > 0 && !s.filter[id] {
+		return
+	}
+
+	length := int(frame[4])
+	if length > 8 {
+		length = 8
+	}
+	data := frame[8 : 8+length]
+
+	msg, ok := s.messages[id]
+	switch {
+	case ok:
+		s.addMessage(acc, msg, data, t)
+	case s.messages == nil || s.RawFallback:
+		acc.AddFields("socketcan_raw",
+			map[string]interface{}{
+				"data":   hex.EncodeToString(data),
+				"length": length,
+			},
+			map[string]string{
+				"interface": s.Interface,
+				"id":        formatID(id),
+			},
+			t)
+	}
+}
+
+func (s *SocketCAN) addMessage(acc telegraf.Accumulator, msg *message, data []byte, t time.Time) {
+	values := msg.decode(data)
+	if len(values) == 0 {
+		return
+	}
+
+	tags := map[string]string{
+		"interface": s.Interface,
+		"id":        formatID(msg.id),
+		"message":   msg.name,
+	}
+
+	if s.MetricFormat == "signal" {
+		for sig, value := range values {
+			signalTags := map[string]string{"signal": sig.name}
+			for k, v := range tags {
+				signalTags[k] = v
+			}
+			if sig.unit != "" {
+				signalTags["unit"] = sig.unit
+			}
+			acc.AddFields("socketcan", map[string]interface{}{"value": value}, signalTags, t)
+		}
+		return
+	}
+
+	fields := make(map[string]interface{}, len(values))
+	for sig, value := range values {
+		fields[sig.name] = value
+	}
+	acc.AddFields("socketcan", fields, tags, t)
+}
+
+func formatID(id uint32) string {
+	return "0x" + strconv.FormatUint(uint64(id), 16)
+}
+
+func isClosed(err error) bool {
+	if pe, ok := err.(*os.PathError); ok {
+		err = pe.Err
+	}
+	return err == os.ErrClosed
+}
+
+// openSocket binds a raw CAN socket to the interface
+func openSocket(iface string) (io.ReadCloser, error) {
+	ifi, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, err
+	}
+
+	fd, err := unix.Socket(unix.AF_CAN, unix.SOCK_RAW, unix.CAN_RAW)
+	if err != nil {
+		return nil, err
+	}
+	if err := unix.Bind(fd, &unix.SockaddrCAN{Ifindex: ifi.Index}); err != nil {
+		unix.Close(fd)
+		return nil, err
+	}
+	// non-blocking so that closing the file interrupts a pending read
+	if err := unix.SetNonblock(fd, true); err != nil {
+		unix.Close(fd)
+		return nil, err
+	}
+
+	log.Printf("D! [inputs.socketcan] reading frames of %s", iface)
+	return os.NewFile(uintptr(fd), iface), nil
+}
+
+func init() {
+	inputs.Add("socketcan", func() telegraf.Input {
+		return &SocketCAN{
+			Interface:    "can0",
+			MetricFormat: "message",
+			open:         openSocket,
+		}
+	})
+}
@@ -0,0 +1,140 @@
+// +build linux
+
+package socketcan
+
+import (
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func frame(id uint32, data ...byte) []byte {
+	f := make([]byte, frameSize)
+	binary.LittleEndian.PutUint32(f, id)
+	f[4] = byte(len(data))
+	copy(f[8:], data)
+	return f
+}
+
+// run starts the plugin reading the frames, and waits until all of them
+// are read
+func run(t *testing.T, s *SocketCAN, frames ...[]byte) *testutil.Accumulator {
+	r, w := io.Pipe()
+	s.open = func(iface string) (io.ReadCloser, error) {
+		assert.Equal(t, "can0", iface)
+		return r, nil
+	}
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, s.Start(acc))
+	for _, f := range frames {
+		_, err := w.Write(f)
+		require.NoError(t, err)
+	}
+	w.Close()
+	s.wg.Wait()
+	s.Stop()
+
+	return acc
+}
+
+func writeDBC(t *testing.T) string {
+	f, err := ioutil.TempFile("", "socketcan")
+	require.NoError(t, err)
+	_, err = f.WriteString(testDBC)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	return f.Name()
+}
+
+func TestRaw(t *testing.T) {
+	s := &SocketCAN{Interface: "can0"}
+	acc := run(t, s,
+		frame(0x123, 0xDE, 0xAD, 0xBE, 0xEF),
+		frame(0x8CF004FE, 0x01),
+		// remote request
+		frame(0x40000123),
+	)
+
+	require.Len(t, acc.Metrics, 2)
+	acc.AssertContainsTaggedFields(t, "socketcan_raw",
+		map[string]interface{}{"data": "deadbeef", "length": 4},
+		map[string]string{"interface": "can0", "id": "0x123"})
+	acc.AssertContainsTaggedFields(t, "socketcan_raw",
+		map[string]interface{}{"data": "01", "length": 1},
+		map[string]string{"interface": "can0", "id": "0xcf004fe"})
+}
+
+func TestDBC(t *testing.T) {
+	dbc := writeDBC(t)
+	defer os.Remove(dbc)
+
+	s := &SocketCAN{Interface: "can0", DBCFile: dbc}
+	acc := run(t, s,
+		frame(0x8CF004FE, 0xF3, 0xFF, 0xFF, 0x20, 0x1C, 0xFF, 0xFF, 0xFF),
+		frame(0x100, 0xFF, 0x06, 0x01),
+		// unknown message
+		frame(0x300, 0x01),
+	)
+
+	require.Len(t, acc.Metrics, 2)
+	acc.AssertContainsTaggedFields(t, "socketcan",
+		map[string]interface{}{"EngineSpeed": float64(900), "EngineTorqueMode": int64(3)},
+		map[string]string{"interface": "can0", "id": "0xcf004fe", "message": "EEC1"})
+	acc.AssertContainsTaggedFields(t, "socketcan",
+		map[string]interface{}{"Temperature": float64(-65), "DoorOpen": int64(1)},
+		map[string]string{"interface": "can0", "id": "0x100", "message": "Body"})
+}
+
+func TestDBCSignalFormat(t *testing.T) {
+	dbc := writeDBC(t)
+	defer os.Remove(dbc)
+
+	s := &SocketCAN{Interface: "can0", DBCFile: dbc, MetricFormat: "signal"}
+	acc := run(t, s, frame(0x8CF004FE, 0xF3, 0xFF, 0xFF, 0x20, 0x1C, 0xFF, 0xFF, 0xFF))
+
+	require.Len(t, acc.Metrics, 2)
+	acc.AssertContainsTaggedFields(t, "socketcan",
+		map[string]interface{}{"value": float64(900)},
+		map[string]string{"interface": "can0", "id": "0xcf004fe", "message": "EEC1",
+			"signal": "EngineSpeed", "unit": "rpm"})
+	acc.AssertContainsTaggedFields(t, "socketcan",
+		map[string]interface{}{"value": int64(3)},
+		map[string]string{"interface": "can0", "id": "0xcf004fe", "message": "EEC1",
+			"signal": "EngineTorqueMode"})
+}
+
+func TestRawFallbackAndFilter(t *testing.T) {
+	dbc := writeDBC(t)
+	defer os.Remove(dbc)
+
+	s := &SocketCAN{
+		Interface:   "can0",
+		DBCFile:     dbc,
+		RawFallback: true,
+		MessageIDs:  []uint32{0x100, 0x300},
+	}
+	acc := run(t, s,
+		frame(0x8CF004FE, 0xF3, 0xFF, 0xFF, 0x20, 0x1C, 0xFF, 0xFF, 0xFF),
+		frame(0x100, 0xFF, 0x06, 0x01),
+		frame(0x300, 0x01),
+	)
+
+	require.Len(t, acc.Metrics, 2)
+	assert.Equal(t, "socketcan", acc.Metrics[0].Measurement)
+	assert.Equal(t, "0x100", acc.Metrics[0].Tags["id"])
+	acc.AssertContainsTaggedFields(t, "socketcan_raw",
+		map[string]interface{}{"data": "01", "length": 1},
+		map[string]string{"interface": "can0", "id": "0x300"})
+}
+
+func TestInvalidDBC(t *testing.T) {
+	s := &SocketCAN{Interface: "can0", DBCFile: "/nonexistent.dbc"}
+	assert.Error(t, s.Start(&testutil.Accumulator{}))
+}
@@ -11,12 +11,14 @@ import (
 	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
 const mbeansPath = "/admin/mbeans?stats=true&wt=json&cat=CORE&cat=QUERYHANDLER&cat=UPDATEHANDLER&cat=CACHE"
 const adminCoresPath = "/solr/admin/cores?action=STATUS&wt=json"
+const jvmMetricsPath = "/solr/admin/metrics?group=jvm&wt=json"
 
 type node struct {
 	Host string `json:"host"`
@@ -26,8 +28,17 @@ const sampleConfig = `
   ## specify a list of one or more Solr servers
   servers = ["http://localhost:8983"]
 
-  ## specify a list of one or more Solr cores (default - all)
+  ## specify a list of one or more Solr cores, the names can contain
+  ## wildcards (default - all)
   # cores = ["main"]
+
+  ## Set jvm_metrics to true to also obtain the JVM metrics of the servers
+  ## from the metrics API, available since Solr 6.4.
+  # jvm_metrics = false
+
+  ## Optional HTTP Basic Auth Credentials
+  # username = "username"
+  # password = "pa$$word"
 `
 
 // Solr is a plugin to read stats from one or many Solr servers
@@ -36,7 +47,11 @@ type Solr struct {
 	Servers     []string
 	HTTPTimeout internal.Duration
 	Cores       []string
+	JVMMetrics  bool `toml:"jvm_metrics"`
+	Username    string
+	Password    string
 	client      *http.Client
+	coreFilter  filter.Filter
 }
 
 // AdminCoresStatus is an exported type that
@@ -49,9 +64,20 @@ type AdminCoresStatus struct {
 			MaxDoc      int64 `json:"maxDoc"`
 			DeletedDocs int64 `json:"deletedDocs"`
 		} `json:"index"`
+		Cloud *struct {
+			Collection string `json:"collection"`
+			Shard      string `json:"shard"`
+			Replica    string `json:"replica"`
+		} `json:"cloud"`
 	} `json:"status"`
 }
 
+// JVMMetrics is an exported type that
+// contains a response from the Solr metrics API
+type JVMMetrics struct {
+	Metrics map[string]map[string]interface{} `json:"metrics"`
+}
+
 // MBeansData is an exported type that
 // contains a response from Solr with metrics
 type MBeansData struct {
@@ -70,9 +96,11 @@ type ResponseHeader struct {
 // contains Core metrics
 type Core struct {
 	Stats struct {
-		DeletedDocs int64 `json:"deletedDocs"`
-		MaxDoc      int64 `json:"maxDoc"`
-		NumDocs     int64 `json:"numDocs"`
+		DeletedDocs  int64 `json:"deletedDocs"`
+		MaxDoc       int64 `json:"maxDoc"`
+		NumDocs      int64 `json:"numDocs"`
+		WarmupTime   int64 `json:"warmupTime"`
+		IndexVersion int64 `json:"indexVersion"`
 	} `json:"stats"`
 }
 
@@ -154,6 +182,14 @@ func (s *Solr) Gather(acc telegraf.Accumulator) error {
 		s.client = client
 	}
 
+	if s.coreFilter == nil {
+		coreFilter, err := filter.Compile(s.Cores)
+		if err != nil {
+			return err
+		}
+		s.coreFilter = coreFilter
+	}
+
 	var wg sync.WaitGroup
 	wg.Add(len(s.Servers))
 
@@ -174,31 +210,68 @@ func (s *Solr) gatherServerMetrics(server string, acc telegraf.Accumulator) erro
 	if err := s.gatherData(s.adminURL(server), adminCoresStatus); err != nil {
 		return err
 	}
-	addAdminCoresStatusToAcc(acc, adminCoresStatus, measurementTime)
 	cores := s.filterCores(getCoresFromStatus(adminCoresStatus))
+	addAdminCoresStatusToAcc(acc, adminCoresStatus, cores, measurementTime)
 	var wg sync.WaitGroup
 	wg.Add(len(cores))
 	for _, core := range cores {
 		go func(server string, core string, acc telegraf.Accumulator) {
 			defer wg.Done()
+			tags := coreTags(adminCoresStatus, core)
 			mBeansData := &MBeansData{}
 			acc.AddError(s.gatherData(s.mbeansURL(server, core), mBeansData))
-			acc.AddError(addCoreMetricsToAcc(acc, core, mBeansData, measurementTime))
-			acc.AddError(addQueryHandlerMetricsToAcc(acc, core, mBeansData, measurementTime))
-			acc.AddError(addUpdateHandlerMetricsToAcc(acc, core, mBeansData, measurementTime))
-			acc.AddError(addCacheMetricsToAcc(acc, core, mBeansData, measurementTime))
+			acc.AddError(addCoreMetricsToAcc(acc, tags, mBeansData, measurementTime))
+			acc.AddError(addQueryHandlerMetricsToAcc(acc, tags, mBeansData, measurementTime))
+			acc.AddError(addUpdateHandlerMetricsToAcc(acc, tags, mBeansData, measurementTime))
+			acc.AddError(addCacheMetricsToAcc(acc, tags, mBeansData, measurementTime))
 		}(server, core, acc)
 	}
+
+	if s.JVMMetrics {
+		jvmMetrics := &JVMMetrics{}
+		if err := s.gatherData(server+jvmMetricsPath, jvmMetrics); err != nil {
+			acc.AddError(err)
+		} else {
+			addJVMMetricsToAcc(acc, jvmMetrics, measurementTime)
+		}
+	}
 	wg.Wait()
 	return nil
 }
 
-// Use cores from configuration if exists, else use cores from server
+// Use cores from server matching the configuration if exists
 func (s *Solr) filterCores(serverCores []string) []string {
-	if len(s.Cores) == 0 {
+	if s.coreFilter == nil {
 		return serverCores
 	}
-	return s.Cores
+	cores := []string{}
+	for _, core := range serverCores {
+		if s.coreFilter.Match(core) {
+			cores = append(cores, core)
+		}
+	}
+	return cores
+}
+
+// Return the tags of a core, with its collection, shard and replica when
+// running in SolrCloud mode
+func coreTags(adminCoresStatus *AdminCoresStatus, core string) map[string]string {
+	tags := map[string]string{"core": core}
+	if cloud := adminCoresStatus.Status[core].Cloud; cloud != nil {
+		tags["collection"] = cloud.Collection
+		tags["shard"] = cloud.Shard
+		tags["replica"] = cloud.Replica
+	}
+	return tags
+}
+
+// Return the tags of a handler of a core
+func handlerTags(coreTags map[string]string, handler string) map[string]string {
+	tags := map[string]string{"handler": handler}
+	for k, v := range coreTags {
+		tags[k] = v
+	}
+	return tags
 }
 
 // Return list of cores from solr server
@@ -212,8 +285,9 @@ func getCoresFromStatus(adminCoresStatus *AdminCoresStatus) []string {
 
 // Add core metrics from admin to accumulator
 // This is the only point where size_in_bytes is available (as far as I checked)
-func addAdminCoresStatusToAcc(acc telegraf.Accumulator, adminCoreStatus *AdminCoresStatus, time time.Time) {
-	for core, metrics := range adminCoreStatus.Status {
+func addAdminCoresStatusToAcc(acc telegraf.Accumulator, adminCoreStatus *AdminCoresStatus, cores []string, time time.Time) {
+	for _, core := range cores {
+		metrics := adminCoreStatus.Status[core]
 		coreFields := map[string]interface{}{
 			"deleted_docs":  metrics.Index.DeletedDocs,
 			"max_docs":      metrics.Index.MaxDoc,
@@ -223,14 +297,32 @@ func addAdminCoresStatusToAcc(acc telegraf.Accumulator, adminCoreStatus *AdminCo
 		acc.AddFields(
 			"solr_admin",
 			coreFields,
-			map[string]string{"core": core},
+			coreTags(adminCoreStatus, core),
 			time,
 		)
 	}
 }
 
+// Add JVM metrics from the metrics API to accumulator
+func addJVMMetricsToAcc(acc telegraf.Accumulator, jvmMetrics *JVMMetrics, time time.Time) {
+	fields := make(map[string]interface{})
+	for name, value := range jvmMetrics.Metrics["solr.jvm"] {
+		key := strings.Replace(strings.Replace(name, ".", "_", -1), "-", "_", -1)
+		switch v := value.(type) {
+		case float64:
+			fields[key] = v
+		case bool:
+			fields[key] = v
+		}
+	}
+	if len(fields) == 0 {
+		return
+	}
+	acc.AddFields("solr_jvm", fields, map[string]string{}, time)
+}
+
 // Add core metrics section to accumulator
-func addCoreMetricsToAcc(acc telegraf.Accumulator, core string, mBeansData *MBeansData, time time.Time) error {
+func addCoreMetricsToAcc(acc telegraf.Accumulator, coreTags map[string]string, mBeansData *MBeansData, time time.Time) error {
 	var coreMetrics map[string]Core
 	if len(mBeansData.SolrMbeans) < 2 {
 		return fmt.Errorf("no core metric data to unmarshall")
@@ -247,12 +339,14 @@ func addCoreMetricsToAcc(acc telegraf.Accumulator, core string, mBeansData *MBea
 			"max_docs":     metrics.Stats.MaxDoc,
 			"num_docs":     metrics.Stats.NumDocs,
 		}
+		if name == "searcher" {
+			coreFields["warmup_time"] = metrics.Stats.WarmupTime
+			coreFields["index_version"] = metrics.Stats.IndexVersion
+		}
 		acc.AddFields(
 			"solr_core",
 			coreFields,
-			map[string]string{
-				"core":    core,
-				"handler": name},
+			handlerTags(coreTags, name),
 			time,
 		)
 	}
@@ -260,7 +354,7 @@ func addCoreMetricsToAcc(acc telegraf.Accumulator, core string, mBeansData *MBea
 }
 
 // Add query metrics section to accumulator
-func addQueryHandlerMetricsToAcc(acc telegraf.Accumulator, core string, mBeansData *MBeansData, time time.Time) error {
+func addQueryHandlerMetricsToAcc(acc telegraf.Accumulator, coreTags map[string]string, mBeansData *MBeansData, time time.Time) error {
 	var queryMetrics map[string]QueryHandler
 
 	if len(mBeansData.SolrMbeans) < 4 {
@@ -291,9 +385,7 @@ func addQueryHandlerMetricsToAcc(acc telegraf.Accumulator, core string, mBeansDa
 		acc.AddFields(
 			"solr_queryhandler",
 			coreFields,
-			map[string]string{
-				"core":    core,
-				"handler": name},
+			handlerTags(coreTags, name),
 			time,
 		)
 
@@ -335,7 +427,7 @@ func convertQueryHandlerMap(value map[string]interface{}) map[string]interface{}
 }
 
 // Add update metrics section to accumulator
-func addUpdateHandlerMetricsToAcc(acc telegraf.Accumulator, core string, mBeansData *MBeansData, time time.Time) error {
+func addUpdateHandlerMetricsToAcc(acc telegraf.Accumulator, coreTags map[string]string, mBeansData *MBeansData, time time.Time) error {
 	var updateMetrics map[string]UpdateHandler
 
 	if len(mBeansData.SolrMbeans) < 6 {
@@ -371,9 +463,7 @@ func addUpdateHandlerMetricsToAcc(acc telegraf.Accumulator, core string, mBeansD
 		acc.AddFields(
 			"solr_updatehandler",
 			coreFields,
-			map[string]string{
-				"core":    core,
-				"handler": name},
+			handlerTags(coreTags, name),
 			time,
 		)
 	}
@@ -415,7 +505,7 @@ func getInt(unk interface{}) int64 {
 }
 
 // Add cache metrics section to accumulator
-func addCacheMetricsToAcc(acc telegraf.Accumulator, core string, mBeansData *MBeansData, time time.Time) error {
+func addCacheMetricsToAcc(acc telegraf.Accumulator, coreTags map[string]string, mBeansData *MBeansData, time time.Time) error {
 	if len(mBeansData.SolrMbeans) < 8 {
 		return fmt.Errorf("no cache metric data to unmarshall")
 	}
@@ -443,9 +533,7 @@ func addCacheMetricsToAcc(acc telegraf.Accumulator, core string, mBeansData *MBe
 		acc.AddFields(
 			"solr_cache",
 			coreFields,
-			map[string]string{
-				"core":    core,
-				"handler": name},
+			handlerTags(coreTags, name),
 			time,
 		)
 	}
@@ -475,7 +563,14 @@ func (s *Solr) createHTTPClient() *http.Client {
 }
 
 func (s *Solr) gatherData(url string, v interface{}) error {
-	r, err := s.client.Get(url)
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	if s.Username != "" || s.Password != "" {
+		req.SetBasicAuth(s.Username, s.Password)
+	}
+	r, err := s.client.Do(req)
 	if err != nil {
 		return err
 	}
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -96,6 +97,78 @@ func TestNoCoreDataHandling(t *testing.T) {
 
 }
 
+func TestGatherCoresFilter(t *testing.T) {
+	ts := createMockServer()
+	solr := NewSolr()
+	solr.Servers = []string{ts.URL}
+	solr.Cores = []string{"ma*"}
+	var acc testutil.Accumulator
+	require.NoError(t, solr.Gather(&acc))
+
+	acc.AssertContainsTaggedFields(t, "solr_admin",
+		solrAdminMainCoreStatusExpected,
+		map[string]string{"core": "main"})
+
+	for _, m := range acc.Metrics {
+		assert.Equal(t, "main", m.Tags["core"])
+	}
+}
+
+func TestGatherCloudAndJVM(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		username, password, ok := r.BasicAuth()
+		if !ok || username != "solr" || password != "SolrRocks" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		if strings.Contains(r.URL.Path, "/solr/admin/cores") {
+			fmt.Fprint(w, statusCloudResponse)
+		} else if strings.Contains(r.URL.Path, "/solr/admin/metrics") {
+			assert.Equal(t, "jvm", r.URL.Query().Get("group"))
+			fmt.Fprint(w, jvmMetricsResponse)
+		} else if strings.Contains(r.URL.Path, "/admin/mbeans") {
+			fmt.Fprint(w, mBeansMainResponse)
+		} else {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintln(w, "nope")
+		}
+	}))
+	defer ts.Close()
+
+	solr := NewSolr()
+	solr.Servers = []string{ts.URL}
+	solr.JVMMetrics = true
+	solr.Username = "solr"
+	solr.Password = "SolrRocks"
+	var acc testutil.Accumulator
+	require.NoError(t, solr.Gather(&acc))
+	require.Empty(t, acc.Errors)
+
+	cloudTags := map[string]string{
+		"core":       "films_shard1_replica_n1",
+		"collection": "films",
+		"shard":      "shard1",
+		"replica":    "core_node2",
+	}
+	acc.AssertContainsTaggedFields(t, "solr_admin",
+		solrAdminCore1StatusExpected,
+		cloudTags)
+
+	cloudTags["handler"] = "searcher"
+	acc.AssertContainsTaggedFields(t, "solr_core",
+		solrCoreExpected,
+		cloudTags)
+
+	acc.AssertContainsTaggedFields(t, "solr_core",
+		solrCoreExpected,
+		map[string]string{"core": "main", "handler": "searcher"})
+
+	acc.AssertContainsTaggedFields(t, "solr_jvm",
+		solrJVMExpected,
+		map[string]string{})
+}
+
 func createMockServer() *httptest.Server {
 	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if strings.Contains(r.URL.Path, "/solr/admin/cores") {
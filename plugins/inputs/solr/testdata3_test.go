@@ -731,9 +731,11 @@ const mBeansSolr3MainResponse = `{
 `
 
 var solr3CoreExpected = map[string]interface{}{
-	"num_docs":     int64(117166),
-	"max_docs":     int64(117305),
-	"deleted_docs": int64(0),
+	"num_docs":      int64(117166),
+	"max_docs":      int64(117305),
+	"deleted_docs":  int64(0),
+	"warmup_time":   int64(1),
+	"index_version": int64(1491861981523),
 }
 
 var solr3QueryHandlerExpected = map[string]interface{}{
@@ -1766,9 +1766,11 @@ var solrAdminCore1StatusExpected = map[string]interface{}{
 }
 
 var solrCoreExpected = map[string]interface{}{
-	"num_docs":     int64(168962621),
-	"max_docs":     int64(169647870),
-	"deleted_docs": int64(685249),
+	"num_docs":      int64(168962621),
+	"max_docs":      int64(169647870),
+	"deleted_docs":  int64(685249),
+	"warmup_time":   int64(0),
+	"index_version": int64(70709031),
 }
 
 var solrQueryHandlerExpected = map[string]interface{}{
@@ -1822,3 +1824,66 @@ var solrCacheExpected = map[string]interface{}{
 	"size":                 int64(0),
 	"warmup_time":          int64(0),
 }
+
+const statusCloudResponse = `
+{
+  "status": {
+    "films_shard1_replica_n1": {
+      "index": {
+        "sizeInBytes": 1784635686,
+        "numDocs": 7517488,
+        "maxDoc": 7620303,
+        "deletedDocs": 102815
+      },
+      "name": "films_shard1_replica_n1",
+      "cloud": {
+        "collection": "films",
+        "shard": "shard1",
+        "replica": "core_node2"
+      }
+    },
+    "main": {
+      "index": {
+        "sizeInBytes": 247497521642,
+        "numDocs": 168943425,
+        "maxDoc": 169562700,
+        "deletedDocs": 619275
+      },
+      "name": "main"
+    }
+  }
+}
+`
+
+const jvmMetricsResponse = `
+{
+  "responseHeader": {
+    "status": 0,
+    "QTime": 3
+  },
+  "metrics": {
+    "solr.jvm": {
+      "buffers.direct.Count": 21,
+      "buffers.direct.MemoryUsed": 1226752,
+      "gc.G1-Young-Generation.count": 73,
+      "gc.G1-Young-Generation.time": 1084,
+      "memory.heap.used": 175012688,
+      "memory.heap.max": 536870912,
+      "memory.heap.usage": 0.32598,
+      "os.name": "Linux",
+      "threads.count": 47
+    }
+  }
+}
+`
+
+var solrJVMExpected = map[string]interface{}{
+	"buffers_direct_Count":         float64(21),
+	"buffers_direct_MemoryUsed":    float64(1226752),
+	"gc_G1_Young_Generation_count": float64(73),
+	"gc_G1_Young_Generation_time":  float64(1084),
+	"memory_heap_used":             float64(175012688),
+	"memory_heap_max":              float64(536870912),
+	"memory_heap_usage":            0.32598,
+	"threads_count":                float64(47),
+}
@@ -0,0 +1,8 @@
+package sql
+
+import (
+	// Register the drivers
+	_ "github.com/ClickHouse/clickhouse-go"
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/stdlib"
+)
@@ -0,0 +1,8 @@
+// +build cgo,odbc
+
+package sql
+
+import (
+	// Register the ODBC driver, requiring the unixODBC headers to build
+	_ "github.com/alexbrainman/odbc"
+)
@@ -0,0 +1,120 @@
+package sql
+
+import (
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccRow(t *testing.T) {
+	q := &Query{
+		Measurement: "orders",
+		TagColumns:  []string{"country"},
+		TimeColumn:  "hour",
+		FieldTypes:  map[string]string{"paid": "bool"},
+	}
+
+	var acc testutil.Accumulator
+	columns := []string{"country", "orders", "revenue", "paid", "note", "hour"}
+	values := []interface{}{[]byte("fr"), []byte("42"), []byte("1234.5"), int64(1), nil, time.Unix(1531390000, 0)}
+	require.NoError(t, q.accRow(&acc, columns, values, time.Now()))
+
+	acc.AssertContainsTaggedFields(t, "orders",
+		map[string]interface{}{
+			"orders":  int64(42),
+			"revenue": 1234.5,
+			"paid":    true,
+		},
+		map[string]string{"country": "fr"})
+	assert.Equal(t, time.Unix(1531390000, 0), acc.Metrics[0].Time)
+}
+
+func TestAccRowFieldColumns(t *testing.T) {
+	q := &Query{
+		FieldColumns: []string{"revenue"},
+		FieldTypes:   map[string]string{"revenue": "int"},
+	}
+
+	now := time.Now()
+	var acc testutil.Accumulator
+	require.NoError(t, q.accRow(&acc, []string{"orders", "revenue"}, []interface{}{int64(42), 1234.5}, now))
+
+	acc.AssertContainsTaggedFields(t, "sql",
+		map[string]interface{}{"revenue": int64(1234)},
+		map[string]string{})
+	assert.Equal(t, now, acc.Metrics[0].Time)
+}
+
+func TestAccRowInvalid(t *testing.T) {
+	q := &Query{FieldTypes: map[string]string{"orders": "int"}}
+
+	var acc testutil.Accumulator
+	assert.Error(t, q.accRow(&acc, []string{"orders"}, []interface{}{"many"}, time.Now()))
+	assert.Len(t, acc.Metrics, 0)
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		typ      string
+		expected interface{}
+	}{
+		{[]byte("abc"), "", "abc"},
+		{[]byte("-3"), "", int64(-3)},
+		{[]byte("0.25"), "", 0.25},
+		{int64(3), "", int64(3)},
+		{[]byte("3"), "string", "3"},
+		{int64(3), "float", 3.0},
+		{"3", "uint", uint64(3)},
+		{2.9, "int", int64(2)},
+		{true, "int", int64(1)},
+		{[]byte("1"), "bool", true},
+		{int64(0), "bool", false},
+	}
+
+	for _, tt := range tests {
+		v, err := convert(tt.value, tt.typ)
+		require.NoError(t, err)
+		assert.Equal(t, tt.expected, v)
+	}
+
+	_, err := convert(int64(1), "decimal")
+	assert.Error(t, err)
+}
+
+func TestParseTime(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		format   string
+		expected time.Time
+	}{
+		{int64(1531390000), "", time.Unix(1531390000, 0)},
+		{int64(1531390000123), "unix_ms", time.Unix(1531390000, 123000000)},
+		{[]byte("1531390000123456"), "unix_us", time.Unix(1531390000, 123456000)},
+		{"2018-07-12T10:06:40Z", "", time.Unix(1531390000, 0)},
+		{[]byte("2018-07-12 10:06:40"), "2006-01-02 15:04:05", time.Unix(1531390000, 0)},
+	}
+
+	for _, tt := range tests {
+		v, err := parseTime(tt.value, tt.format)
+		require.NoError(t, err)
+		assert.True(t, tt.expected.Equal(v), "%v != %v", tt.expected, v)
+	}
+
+	_, err := parseTime(int64(1531390000), "2006-01-02")
+	assert.Error(t, err)
+}
+
+func TestStartInvalid(t *testing.T) {
+	s := &SQL{Driver: "oracle"}
+	assert.Error(t, s.Start(nil))
+
+	s = &SQL{
+		Driver:  "mysql",
+		Queries: []*Query{{FieldTypes: map[string]string{"a": "decimal"}}},
+	}
+	assert.Error(t, s.Start(nil))
+}
@@ -2,6 +2,8 @@ package sqlserver
 
 import (
 	"database/sql"
+	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -17,6 +19,8 @@ type SQLServer struct {
 	Servers      []string `toml:"servers"`
 	QueryVersion int      `toml:"query_version"`
 	AzureDB      bool     `toml:"azuredb"`
+	DatabaseType string   `toml:"database_type"`
+	IncludeQuery []string `toml:"include_query"`
 	ExcludeQuery []string `toml:"exclude_query"`
 }
 
@@ -37,6 +41,13 @@ var isInitialized = false
 
 var defaultServer = "Server=.;app name=telegraf;log=1;"
 
+// Database types
+const (
+	typeSQLServer               = "SQLServer"
+	typeAzureSQLDB              = "AzureSQLDB"
+	typeAzureSQLManagedInstance = "AzureSQLManagedInstance"
+)
+
 var sampleConfig = `
   ## Specify instances to monitor with a list of connection strings.
   ## All connection parameters are optional.
@@ -53,11 +64,17 @@ var sampleConfig = `
   ## dashboards.
   query_version = 2
 
-  ## If you are using AzureDB, setting this to true will gather resource utilization metrics
+  ## Type of the monitored instances, one of "SQLServer", "AzureSQLDB" or
+  ## "AzureSQLManagedInstance".  The Azure types always use the version 2
+  ## queries, adapted to the views available in Azure.
+  # database_type = "SQLServer"
+
+  ## If you are using AzureDB, setting this to true will gather resource
+  ## utilization metrics.  Deprecated in favor of database_type.
   # azuredb = false
 
-  ## If you would like to exclude some of the metrics queries, list them here
-  ## Possible choices:
+  ## Queries to gather, all the queries of the database type and query
+  ## version are gathered if empty.  Possible choices:
   ## - PerformanceCounters
   ## - WaitStatsCategorized
   ## - DatabaseIO
@@ -68,6 +85,13 @@ var sampleConfig = `
   ## - MemoryClerk
   ## - VolumeSpace
   ## - PerformanceMetrics
+  ## - ServerProperties
+  ## - AvailabilityReplicaStates
+  ## - AvailabilityDatabaseReplicaStates
+  ## - ResourceStats
+  # include_query = []
+
+  ## If you would like to exclude some of the metrics queries, list them here
   # exclude_query = [ 'DatabaseIO' ]
 `
 
@@ -85,32 +109,65 @@ type scanner interface {
 	Scan(dest ...interface{}) error
 }
 
-func initQueries(s *SQLServer) {
+func initQueries(s *SQLServer) error {
 	queries = make(MapQuery)
 
-	// If this is an AzureDB instance, grab some extra metrics
-	if s.AzureDB {
-		queries["AzureDB"] = Query{Script: sqlAzureDB, ResultByRow: true}
-	}
-
-	// Decide if we want to run version 1 or version 2 queries
-	if s.QueryVersion == 2 {
+	switch s.DatabaseType {
+	case typeAzureSQLDB:
+		queries["ResourceStats"] = Query{Script: sqlAzureDB, ResultByRow: false}
+		queries["PerformanceCounters"] = Query{Script: sqlPerformanceCountersV2, ResultByRow: true}
+		queries["WaitStatsCategorized"] = Query{Script: sqlAzureDBWaitStatsCategorized, ResultByRow: false}
+		queries["DatabaseIO"] = Query{Script: sqlAzureDBDatabaseIO, ResultByRow: false}
+		queries["DatabaseSize"] = Query{Script: sqlAzureDBDatabaseSize, ResultByRow: false}
+		queries["MemoryClerk"] = Query{Script: sqlMemoryClerkV2, ResultByRow: false}
+	case typeAzureSQLManagedInstance:
+		queries["ResourceStats"] = Query{Script: sqlAzureMIResourceStats, ResultByRow: false}
 		queries["PerformanceCounters"] = Query{Script: sqlPerformanceCountersV2, ResultByRow: true}
 		queries["WaitStatsCategorized"] = Query{Script: sqlWaitStatsCategorizedV2, ResultByRow: false}
 		queries["DatabaseIO"] = Query{Script: sqlDatabaseIOV2, ResultByRow: false}
+		queries["DatabaseSize"] = Query{Script: sqlDatabaseSizeV2, ResultByRow: false}
 		queries["ServerProperties"] = Query{Script: sqlServerPropertiesV2, ResultByRow: false}
 		queries["MemoryClerk"] = Query{Script: sqlMemoryClerkV2, ResultByRow: false}
-	} else {
-		queries["PerformanceCounters"] = Query{Script: sqlPerformanceCounters, ResultByRow: true}
-		queries["WaitStatsCategorized"] = Query{Script: sqlWaitStatsCategorized, ResultByRow: false}
-		queries["CPUHistory"] = Query{Script: sqlCPUHistory, ResultByRow: false}
-		queries["DatabaseIO"] = Query{Script: sqlDatabaseIO, ResultByRow: false}
-		queries["DatabaseSize"] = Query{Script: sqlDatabaseSize, ResultByRow: false}
-		queries["DatabaseStats"] = Query{Script: sqlDatabaseStats, ResultByRow: false}
-		queries["DatabaseProperties"] = Query{Script: sqlDatabaseProperties, ResultByRow: false}
-		queries["MemoryClerk"] = Query{Script: sqlMemoryClerk, ResultByRow: false}
-		queries["VolumeSpace"] = Query{Script: sqlVolumeSpace, ResultByRow: false}
-		queries["PerformanceMetrics"] = Query{Script: sqlPerformanceMetrics, ResultByRow: false}
+	case "", typeSQLServer:
+		// If this is an AzureDB instance, grab some extra metrics
+		if s.AzureDB {
+			queries["AzureDB"] = Query{Script: sqlAzureDB, ResultByRow: false}
+		}
+
+		// Decide if we want to run version 1 or version 2 queries
+		if s.QueryVersion == 2 {
+			queries["PerformanceCounters"] = Query{Script: sqlPerformanceCountersV2, ResultByRow: true}
+			queries["WaitStatsCategorized"] = Query{Script: sqlWaitStatsCategorizedV2, ResultByRow: false}
+			queries["DatabaseIO"] = Query{Script: sqlDatabaseIOV2, ResultByRow: false}
+			queries["DatabaseSize"] = Query{Script: sqlDatabaseSizeV2, ResultByRow: false}
+			queries["ServerProperties"] = Query{Script: sqlServerPropertiesV2, ResultByRow: false}
+			queries["MemoryClerk"] = Query{Script: sqlMemoryClerkV2, ResultByRow: false}
+			queries["AvailabilityReplicaStates"] = Query{Script: sqlAvailabilityReplicaStatesV2, ResultByRow: false}
+			queries["AvailabilityDatabaseReplicaStates"] = Query{Script: sqlAvailabilityDatabaseReplicaStatesV2, ResultByRow: false}
+		} else {
+			queries["PerformanceCounters"] = Query{Script: sqlPerformanceCounters, ResultByRow: true}
+			queries["WaitStatsCategorized"] = Query{Script: sqlWaitStatsCategorized, ResultByRow: false}
+			queries["CPUHistory"] = Query{Script: sqlCPUHistory, ResultByRow: false}
+			queries["DatabaseIO"] = Query{Script: sqlDatabaseIO, ResultByRow: false}
+			queries["DatabaseSize"] = Query{Script: sqlDatabaseSize, ResultByRow: false}
+			queries["DatabaseStats"] = Query{Script: sqlDatabaseStats, ResultByRow: false}
+			queries["DatabaseProperties"] = Query{Script: sqlDatabaseProperties, ResultByRow: false}
+			queries["MemoryClerk"] = Query{Script: sqlMemoryClerk, ResultByRow: false}
+			queries["VolumeSpace"] = Query{Script: sqlVolumeSpace, ResultByRow: false}
+			queries["PerformanceMetrics"] = Query{Script: sqlPerformanceMetrics, ResultByRow: false}
+		}
+	default:
+		return fmt.Errorf("unknown database_type %q", s.DatabaseType)
+	}
+
+	if len(s.IncludeQuery) != 0 {
+		included := make(MapQuery)
+		for _, query := range s.IncludeQuery {
+			if q, ok := queries[query]; ok {
+				included[query] = q
+			}
+		}
+		queries = included
 	}
 
 	for _, query := range s.ExcludeQuery {
@@ -119,12 +176,15 @@ func initQueries(s *SQLServer) {
 
 	// Set a flag so we know that queries have already been initialized
 	isInitialized = true
+	return nil
 }
 
 // Gather collect data from SQL Server
 func (s *SQLServer) Gather(acc telegraf.Accumulator) error {
 	if !isInitialized {
-		initQueries(s)
+		if err := initQueries(s); err != nil {
+			return err
+		}
 	}
 
 	if len(s.Servers) == 0 {
@@ -1146,9 +1206,132 @@ BEGIN
 END
 ELSE
 BEGIN
-	RAISERROR('This does not seem to be an AzureDB instance. Set "azureDB = false" in your telegraf configuration.',16,1)
+	RAISERROR('This does not seem to be an AzureDB instance. Set "azureDB = false" or "database_type" in your telegraf configuration.',16,1)
 END`
 
+const sqlDatabaseSizeV2 = `SELECT
+'sqlserver_database_size' AS [measurement],
+REPLACE(@@SERVERNAME,'\',':') AS [sql_instance],
+DB_NAME(mf.database_id) AS [database_name],
+mf.name AS [logical_filename],
+CASE WHEN mf.type = 1 THEN 'LOG' ELSE 'ROWS' END AS file_type,
+CAST(mf.size AS BIGINT) * 8 AS size_kb,
+CASE WHEN mf.max_size = -1 THEN CAST(-1 AS BIGINT) ELSE CAST(mf.max_size AS BIGINT) * 8 END AS max_size_kb
+FROM
+sys.master_files AS mf
+OPTION( RECOMPILE );
+`
+
+const sqlAvailabilityReplicaStatesV2 = `IF SERVERPROPERTY('IsHadrEnabled') = 1
+BEGIN
+	SELECT
+	'sqlserver_hadr_replica_states' AS [measurement],
+	REPLACE(@@SERVERNAME,'\',':') AS [sql_instance],
+	ag.name AS [availability_group_name],
+	ar.replica_server_name,
+	ISNULL(ars.role_desc,'RESOLVING') AS [role_desc],
+	ars.connected_state_desc,
+	ars.synchronization_health_desc,
+	CAST(ISNULL(ars.role,0) AS INT) AS [role],
+	CAST(ars.connected_state AS INT) AS [connected_state],
+	CAST(ars.synchronization_health AS INT) AS [synchronization_health]
+	FROM
+	sys.dm_hadr_availability_replica_states AS ars
+	INNER JOIN sys.availability_replicas AS ar ON ars.replica_id = ar.replica_id
+	INNER JOIN sys.availability_groups AS ag ON ars.group_id = ag.group_id
+	OPTION( RECOMPILE );
+END
+`
+
+const sqlAvailabilityDatabaseReplicaStatesV2 = `IF SERVERPROPERTY('IsHadrEnabled') = 1
+BEGIN
+	SELECT
+	'sqlserver_hadr_dbreplica_states' AS [measurement],
+	REPLACE(@@SERVERNAME,'\',':') AS [sql_instance],
+	ag.name AS [availability_group_name],
+	ar.replica_server_name,
+	DB_NAME(drs.database_id) AS [database_name],
+	drs.synchronization_state_desc,
+	drs.synchronization_health_desc,
+	CAST(drs.synchronization_state AS INT) AS [synchronization_state],
+	CAST(drs.synchronization_health AS INT) AS [synchronization_health],
+	CAST(drs.is_suspended AS INT) AS [is_suspended],
+	ISNULL(drs.log_send_queue_size,0) AS [log_send_queue_size_kb],
+	ISNULL(drs.log_send_rate,0) AS [log_send_rate_kb],
+	ISNULL(drs.redo_queue_size,0) AS [redo_queue_size_kb],
+	ISNULL(drs.redo_rate,0) AS [redo_rate_kb]
+	FROM
+	sys.dm_hadr_database_replica_states AS drs
+	INNER JOIN sys.availability_replicas AS ar ON drs.replica_id = ar.replica_id
+	INNER JOIN sys.availability_groups AS ag ON drs.group_id = ag.group_id
+	OPTION( RECOMPILE );
+END
+`
+
+// Queries - Azure SQL Database and Managed Instance
+// Azure SQL Database only exposes the database scoped views
+
+const sqlAzureDBDatabaseIO = `SELECT
+'sqlserver_database_io' As [measurement],
+REPLACE(@@SERVERNAME,'\',':') AS [sql_instance],
+DB_NAME() AS [database_name],
+df.name AS [logical_filename],
+vfs.io_stall_read_ms AS read_latency_ms,
+vfs.num_of_reads AS reads,
+vfs.num_of_bytes_read AS read_bytes,
+vfs.io_stall_write_ms AS write_latency_ms,
+vfs.num_of_writes AS writes,
+vfs.num_of_bytes_written AS write_bytes,
+CASE WHEN vfs.file_id = 2 THEN 'LOG' ELSE 'ROWS' END AS file_type
+FROM
+[sys].[dm_io_virtual_file_stats](NULL,NULL) AS vfs
+INNER JOIN sys.database_files AS df ON vfs.file_id = df.file_id
+WHERE vfs.database_id = DB_ID()
+OPTION( RECOMPILE );
+`
+
+const sqlAzureDBDatabaseSize = `SELECT
+'sqlserver_database_size' AS [measurement],
+REPLACE(@@SERVERNAME,'\',':') AS [sql_instance],
+DB_NAME() AS [database_name],
+df.name AS [logical_filename],
+CASE WHEN df.type = 1 THEN 'LOG' ELSE 'ROWS' END AS file_type,
+CAST(df.size AS BIGINT) * 8 AS size_kb,
+CASE WHEN df.max_size = -1 THEN CAST(-1 AS BIGINT) ELSE CAST(df.max_size AS BIGINT) * 8 END AS max_size_kb
+FROM
+sys.database_files AS df
+OPTION( RECOMPILE );
+`
+
+// The wait stats of Azure SQL Database are scoped to the database
+var sqlAzureDBWaitStatsCategorized = strings.Replace(sqlWaitStatsCategorizedV2,
+	"sys.dm_os_wait_stats", "sys.dm_db_wait_stats", 1)
+
+const sqlAzureMIResourceStats = `IF SERVERPROPERTY('EngineEdition') = 8
+BEGIN
+	SELECT TOP(1)
+		'sqlserver_azurestats' AS [measurement],
+		REPLACE(@@SERVERNAME,'\',':') AS [sql_instance],
+		sku,
+		virtual_core_count,
+		avg_cpu_percent,
+		reserved_storage_mb,
+		storage_space_used_mb,
+		io_requests,
+		io_bytes_read,
+		io_bytes_written
+	FROM
+		sys.server_resource_stats
+	ORDER BY
+		end_time DESC
+	OPTION (RECOMPILE)
+END
+ELSE
+BEGIN
+	RAISERROR('This does not seem to be an Azure SQL Managed Instance. Set "database_type" in your telegraf configuration.',16,1)
+END
+`
+
 // Queries V1
 const sqlPerformanceMetrics string = `SET NOCOUNT ON;
 SET ARITHABORT ON;
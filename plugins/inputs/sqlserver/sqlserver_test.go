@@ -1,12 +1,14 @@
 package sqlserver
 
 import (
+	"sort"
 	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -81,6 +83,52 @@ func TestSqlServer_ParseMetrics(t *testing.T) {
 	}
 }
 
+func queryNames() []string {
+	names := make([]string, 0, len(queries))
+	for name := range queries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestSqlServer_InitQueries(t *testing.T) {
+	require.NoError(t, initQueries(&SQLServer{QueryVersion: 2}))
+	assert.Equal(t, []string{
+		"AvailabilityDatabaseReplicaStates",
+		"AvailabilityReplicaStates",
+		"DatabaseIO",
+		"DatabaseSize",
+		"MemoryClerk",
+		"PerformanceCounters",
+		"ServerProperties",
+		"WaitStatsCategorized",
+	}, queryNames())
+
+	require.NoError(t, initQueries(&SQLServer{
+		DatabaseType: "AzureSQLDB",
+		ExcludeQuery: []string{"MemoryClerk"},
+	}))
+	assert.Equal(t, []string{
+		"DatabaseIO",
+		"DatabaseSize",
+		"PerformanceCounters",
+		"ResourceStats",
+		"WaitStatsCategorized",
+	}, queryNames())
+	assert.Contains(t, queries["WaitStatsCategorized"].Script, "sys.dm_db_wait_stats")
+
+	require.NoError(t, initQueries(&SQLServer{
+		DatabaseType: "AzureSQLManagedInstance",
+		IncludeQuery: []string{"ResourceStats", "DatabaseIO", "CPUHistory"},
+		ExcludeQuery: []string{"DatabaseIO"},
+	}))
+	assert.Equal(t, []string{"ResourceStats"}, queryNames())
+
+	assert.Error(t, initQueries(&SQLServer{DatabaseType: "Oracle"}))
+	isInitialized = false
+}
+
 const mockPerformanceMetrics = `measurement;servername;type;Point In Time Recovery;Available physical memory (bytes);Average pending disk IO;Average runnable tasks;Average tasks;Buffer pool rate (bytes/sec);Connection memory per connection (bytes);Memory grant pending;Page File Usage (%);Page lookup per batch request;Page split per batch request;Readahead per page read;Signal wait (%);Sql compilation per batch request;Sql recompilation per batch request;Total target memory ratio
 Performance metrics;WIN8-DEV;Performance metrics;0;6353158144;0;0;7;2773;415061;0;25;229371;130;10;18;188;52;14`
 
@@ -0,0 +1,169 @@
+package statsd
+
+// datadog events and service checks, see
+// https://docs.datadoghq.com/developers/dogstatsd/datagram_shell/
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	eventsMeasurement        = "statsd_events"
+	serviceChecksMeasurement = "statsd_service_checks"
+
+	// dataDogEntityIDTag is the tag the datadog clients use to send the id
+	// of the entity, e.g. the uid of a kubernetes pod
+	dataDogEntityIDTag = "dd.internal.entity_id"
+)
+
+// parseDataDogTags parses the comma separated datadog tags into tags.
+func (s *Statsd) parseDataDogTags(tags map[string]string, tagstr string) {
+	for _, tag := range strings.Split(tagstr, ",") {
+		ts := strings.SplitN(tag, ":", 2)
+		var k, v string
+		switch len(ts) {
+		case 1:
+			// just a tag
+			k = ts[0]
+			v = ""
+		case 2:
+			k = ts[0]
+			v = ts[1]
+		}
+		if s.DataDogExtensions && k == dataDogEntityIDTag {
+			k = "entity_id"
+		}
+		if k != "" {
+			tags[k] = v
+		}
+	}
+}
+
+// parseEventMessage parses a datadog event, which looks like:
+// _e{title.length,text.length}:title|text|d:timestamp|h:hostname|p:priority|t:alert_type|#tag1,tag2
+func (s *Statsd) parseEventMessage(line string) error {
+	header := strings.SplitN(line[len("_e{"):], "}:", 2)
+	if len(header) != 2 {
+		return fmt.Errorf("invalid event header: %s", line)
+	}
+	lengths := strings.Split(header[0], ",")
+	if len(lengths) != 2 {
+		return fmt.Errorf("invalid event lengths: %s", line)
+	}
+	titleLen, err := strconv.Atoi(lengths[0])
+	if err != nil {
+		return fmt.Errorf("invalid event title length: %s", line)
+	}
+	textLen, err := strconv.Atoi(lengths[1])
+	if err != nil {
+		return fmt.Errorf("invalid event text length: %s", line)
+	}
+
+	// The lengths are the number of bytes of the title and text, separated
+	// by a pipe
+	message := header[1]
+	if titleLen < 0 || textLen < 0 || len(message) < titleLen+1+textLen ||
+		message[titleLen] != '|' {
+		return fmt.Errorf("event title and text do not match their lengths: %s", line)
+	}
+	title := message[:titleLen]
+	text := message[titleLen+1 : titleLen+1+textLen]
+
+	fields := map[string]interface{}{
+		"title":      title,
+		"text":       strings.Replace(text, `\n`, "\n", -1),
+		"priority":   "normal",
+		"alert_type": "info",
+	}
+	tags := make(map[string]string)
+	ts := time.Now()
+
+	rest := message[titleLen+1+textLen:]
+	if rest != "" && rest[0] != '|' {
+		return fmt.Errorf("event title and text do not match their lengths: %s", line)
+	}
+	for _, segment := range strings.Split(rest, "|") {
+		switch {
+		case segment == "":
+		case segment[0] == '#':
+			s.parseDataDogTags(tags, segment[1:])
+		case strings.HasPrefix(segment, "d:"):
+			sec, err := strconv.ParseInt(segment[2:], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid event timestamp: %s", line)
+			}
+			ts = time.Unix(sec, 0)
+		case strings.HasPrefix(segment, "h:"):
+			tags["source"] = segment[2:]
+		case strings.HasPrefix(segment, "k:"):
+			fields["aggregation_key"] = segment[2:]
+		case strings.HasPrefix(segment, "p:"):
+			fields["priority"] = segment[2:]
+		case strings.HasPrefix(segment, "s:"):
+			fields["source_type_name"] = segment[2:]
+		case strings.HasPrefix(segment, "t:"):
+			fields["alert_type"] = segment[2:]
+		case strings.HasPrefix(segment, "c:"):
+			tags["container_id"] = segment[2:]
+		}
+	}
+
+	s.acc.AddFields(eventsMeasurement, fields, tags, ts)
+	return nil
+}
+
+// parseServiceCheckMessage parses a datadog service check, which looks like:
+// _sc|name|status|d:timestamp|h:hostname|#tag1,tag2|m:message
+func (s *Statsd) parseServiceCheckMessage(line string) error {
+	// The message is the last segment and can hold pipes
+	var message string
+	if i := strings.Index(line, "|m:"); i >= 0 {
+		message = line[i+len("|m:"):]
+		line = line[:i]
+	}
+
+	segments := strings.Split(line, "|")
+	if len(segments) < 3 || segments[1] == "" {
+		return errors.New("invalid service check: " + line)
+	}
+	status, err := strconv.ParseInt(segments[2], 10, 64)
+	if err != nil || status < 0 || status > 3 {
+		return fmt.Errorf("invalid service check status: %s", line)
+	}
+
+	fields := map[string]interface{}{
+		"status": status,
+	}
+	if message != "" {
+		fields["message"] = strings.Replace(message, `\n`, "\n", -1)
+	}
+	tags := map[string]string{
+		"check": segments[1],
+	}
+	ts := time.Now()
+
+	for _, segment := range segments[3:] {
+		switch {
+		case segment == "":
+		case segment[0] == '#':
+			s.parseDataDogTags(tags, segment[1:])
+		case strings.HasPrefix(segment, "d:"):
+			sec, err := strconv.ParseInt(segment[2:], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid service check timestamp: %s", line)
+			}
+			ts = time.Unix(sec, 0)
+		case strings.HasPrefix(segment, "h:"):
+			tags["source"] = segment[2:]
+		case strings.HasPrefix(segment, "c:"):
+			tags["container_id"] = segment[2:]
+		}
+	}
+
+	s.acc.AddFields(serviceChecksMeasurement, fields, tags, ts)
+	return nil
+}
@@ -0,0 +1,200 @@
+package statsd
+
+import (
+	"math"
+)
+
+const (
+	// defaultSketchAccuracy is the relative accuracy of the percentiles
+	// estimated by the sketch.
+	defaultSketchAccuracy = 0.01
+	// defaultSketchMaxBins is the maximum number of bins per sign, which
+	// bounds the memory of a sketch whatever the number of values.
+	defaultSketchMaxBins = 2048
+)
+
+// Sketch estimates the percentiles of a distribution in bounded memory.
+// Values are counted in logarithmic bins, so that the estimated percentiles
+// are within a relative accuracy of the actual ones.  Once the maximum number
+// of bins is reached the lowest bins are collapsed, only losing the accuracy
+// of the lowest percentiles.
+type Sketch struct {
+	gamma    float64
+	logGamma float64
+	maxBins  int
+
+	positive sketchStore
+	negative sketchStore
+	zeros    int64
+
+	n     int64
+	sum   float64
+	lower float64
+	upper float64
+}
+
+// sketchStore holds the counts of contiguous bins, bins[0] being the count of
+// the bin of index offset.
+type sketchStore struct {
+	bins   []int64
+	offset int
+	count  int64
+}
+
+func NewSketch(accuracy float64, maxBins int) *Sketch {
+	if accuracy <= 0 || accuracy >= 1 {
+		accuracy = defaultSketchAccuracy
+	}
+	if maxBins <= 0 {
+		maxBins = defaultSketchMaxBins
+	}
+	gamma := (1 + accuracy) / (1 - accuracy)
+	return &Sketch{
+		gamma:    gamma,
+		logGamma: math.Log(gamma),
+		maxBins:  maxBins,
+	}
+}
+
+func (s *Sketch) AddValue(v float64) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return
+	}
+
+	if s.n == 0 {
+		s.lower = v
+		s.upper = v
+	} else if v < s.lower {
+		s.lower = v
+	} else if v > s.upper {
+		s.upper = v
+	}
+	s.n++
+	s.sum += v
+
+	switch {
+	case v > 0:
+		s.positive.add(s.index(v), s.maxBins)
+	case v < 0:
+		s.negative.add(s.index(-v), s.maxBins)
+	default:
+		s.zeros++
+	}
+}
+
+// index returns the index of the bin of a positive value.
+func (s *Sketch) index(v float64) int {
+	return int(math.Ceil(math.Log(v) / s.logGamma))
+}
+
+// value returns the value estimated for the bin of the index, which is
+// within the relative accuracy of all the values of the bin.
+func (s *Sketch) value(index int) float64 {
+	return 2 * math.Pow(s.gamma, float64(index)) / (1 + s.gamma)
+}
+
+func (s *Sketch) Count() int64 {
+	return s.n
+}
+
+func (s *Sketch) Sum() float64 {
+	return s.sum
+}
+
+func (s *Sketch) Mean() float64 {
+	if s.n == 0 {
+		return 0
+	}
+	return s.sum / float64(s.n)
+}
+
+func (s *Sketch) Lower() float64 {
+	return s.lower
+}
+
+func (s *Sketch) Upper() float64 {
+	return s.upper
+}
+
+// Percentile returns the estimated value of the percentile p, between 0 and
+// 100.
+func (s *Sketch) Percentile(p float64) float64 {
+	if s.n == 0 {
+		return 0
+	}
+	if p <= 0 {
+		return s.lower
+	}
+	if p >= 100 {
+		return s.upper
+	}
+
+	rank := int64(p / 100 * float64(s.n-1))
+	var v float64
+	switch {
+	case rank < s.negative.count:
+		// The negative values are ordered by decreasing absolute value
+		v = -s.value(s.negative.key(s.negative.count - 1 - rank))
+	case rank < s.negative.count+s.zeros:
+		v = 0
+	default:
+		v = s.value(s.positive.key(rank - s.negative.count - s.zeros))
+	}
+
+	// The estimate of the lowest and highest bins can be out of the range of
+	// the values
+	return math.Max(s.lower, math.Min(s.upper, v))
+}
+
+func (st *sketchStore) add(index int, maxBins int) {
+	if len(st.bins) == 0 {
+		st.bins = make([]int64, 1, 64)
+		st.offset = index
+	}
+
+	if index < st.offset {
+		// Extend the bins below, unless the lowest bins are collapsed
+		if st.offset-index+len(st.bins) > maxBins {
+			index = st.offset
+		} else {
+			bins := make([]int64, st.offset-index+len(st.bins), cap(st.bins)+st.offset-index)
+			copy(bins[st.offset-index:], st.bins)
+			st.bins = bins
+			st.offset = index
+		}
+	} else if index >= st.offset+len(st.bins) {
+		n := index - st.offset + 1
+		for len(st.bins) < n {
+			st.bins = append(st.bins, 0)
+		}
+		if len(st.bins) > maxBins {
+			st.collapse(len(st.bins) - maxBins)
+		}
+	}
+
+	st.bins[index-st.offset]++
+	st.count++
+}
+
+// collapse merges the n lowest bins into the following one.
+func (st *sketchStore) collapse(n int) {
+	var count int64
+	for _, c := range st.bins[:n] {
+		count += c
+	}
+	st.bins = st.bins[n:]
+	st.bins[0] += count
+	st.offset += n
+}
+
+// key returns the index of the bin holding the value of the rank.
+func (st *sketchStore) key(rank int64) int {
+	var n int64
+	for i, c := range st.bins {
+		n += c
+		if n > rank {
+			return st.offset + i
+		}
+	}
+	return st.offset + len(st.bins) - 1
+}
@@ -0,0 +1,82 @@
+package statsd
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// Test that the percentiles are within the relative accuracy of the sketch
+func TestSketch_Accuracy(t *testing.T) {
+	sketch := NewSketch(0.01, 2048)
+	values := make([]float64, 0, 10000)
+	for i := 0; i < 10000; i++ {
+		v := rand.ExpFloat64() * 100
+		if i%10 == 0 {
+			v = -v
+		}
+		values = append(values, v)
+		sketch.AddValue(v)
+	}
+	sort.Float64s(values)
+
+	for _, p := range []float64{1, 10, 25, 50, 75, 90, 99, 99.9} {
+		expected := values[int(p/100*float64(len(values)-1))]
+		actual := sketch.Percentile(p)
+		if math.Abs(actual-expected) > 0.01*math.Abs(expected)+1e-9 {
+			t.Errorf("Percentile %v: expected %v, got %v", p, expected, actual)
+		}
+	}
+	if sketch.Count() != 10000 {
+		t.Errorf("Expected %v, got %v", 10000, sketch.Count())
+	}
+	if sketch.Percentile(0) != values[0] {
+		t.Errorf("Expected %v, got %v", values[0], sketch.Percentile(0))
+	}
+	if sketch.Percentile(100) != values[len(values)-1] {
+		t.Errorf("Expected %v, got %v", values[len(values)-1], sketch.Percentile(100))
+	}
+}
+
+// Test that a single value and zeros are handled correctly
+func TestSketch_Single(t *testing.T) {
+	sketch := NewSketch(0.01, 2048)
+	sketch.AddValue(10.1)
+
+	for _, p := range []float64{0, 50, 90, 100} {
+		if sketch.Percentile(p) != 10.1 {
+			t.Errorf("Percentile %v: expected %v, got %v", p, 10.1, sketch.Percentile(p))
+		}
+	}
+
+	sketch = NewSketch(0.01, 2048)
+	sketch.AddValue(0)
+	sketch.AddValue(0)
+	sketch.AddValue(5)
+	if sketch.Percentile(50) != 0 {
+		t.Errorf("Expected %v, got %v", 0, sketch.Percentile(50))
+	}
+	if sketch.Mean() != 5.0/3 {
+		t.Errorf("Expected %v, got %v", 5.0/3, sketch.Mean())
+	}
+	if sketch.Sum() != 5 {
+		t.Errorf("Expected %v, got %v", 5, sketch.Sum())
+	}
+}
+
+// Test that the number of bins is bounded, and that only the lowest
+// percentiles lose their accuracy
+func TestSketch_MaxBins(t *testing.T) {
+	sketch := NewSketch(0.01, 100)
+	for v := 1e-6; v < 1e6; v *= 1.001 {
+		sketch.AddValue(v)
+	}
+
+	if len(sketch.positive.bins) > 100 {
+		t.Errorf("Expected at most %v bins, got %v", 100, len(sketch.positive.bins))
+	}
+	if p := sketch.Percentile(99); math.Abs(p-1e6*math.Pow(10, -0.12))/p > 0.02 {
+		t.Errorf("Expected the 99th percentile to be accurate, got %v", p)
+	}
+}
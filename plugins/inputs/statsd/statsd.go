@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"os"
 	"sort"
 	"strconv"
 	"strings"
@@ -17,6 +18,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	tmetric "github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/inputs"
 	"github.com/influxdata/telegraf/selfstat"
 )
@@ -70,6 +72,15 @@ type Statsd struct {
 	// statsd protocol (http://docs.datadoghq.com/guides/dogstatsd/)
 	ParseDataDogTags bool
 
+	// DataDogExtensions enables the extensions of the dogstatsd datagrams:
+	// the tags, the distribution type, the events, the service checks and the
+	// entity and container ids.
+	DataDogExtensions bool `toml:"datadog_extensions"`
+
+	// DistributionPercentiles specifies the percentiles that will be
+	// calculated for distribution stats, estimated with a sketch.
+	DistributionPercentiles []float64
+
 	// UDPPacketSize is deprecated, it's only here for legacy support
 	// we now always create 1 max size buffer and then copy only what we need
 	// into the in channel
@@ -96,17 +107,19 @@ type Statsd struct {
 	// Cache gauges, counters & sets so they can be aggregated as they arrive
 	// gauges and counters map measurement/tags hash -> field name -> metrics
 	// sets and timings map measurement/tags hash -> metrics
-	gauges   map[string]cachedgauge
-	counters map[string]cachedcounter
-	sets     map[string]cachedset
-	timings  map[string]cachedtimings
+	gauges        map[string]cachedgauge
+	counters      map[string]cachedcounter
+	sets          map[string]cachedset
+	timings       map[string]cachedtimings
+	distributions map[string]cacheddistributions
 
 	// bucket -> influx templates
 	Templates []string
 
 	// Protocol listeners
-	UDPlistener *net.UDPConn
-	TCPlistener *net.TCPListener
+	UDPlistener      *net.UDPConn
+	TCPlistener      *net.TCPListener
+	UnixgramListener *net.UnixConn
 
 	// track current connections so we can close them in Stop()
 	conns map[string]*net.TCPConn
@@ -169,12 +182,18 @@ type cachedtimings struct {
 	tags   map[string]string
 }
 
+type cacheddistributions struct {
+	name   string
+	fields map[string]*Sketch
+	tags   map[string]string
+}
+
 func (_ *Statsd) Description() string {
-	return "Statsd UDP/TCP Server"
+	return "Statsd UDP/TCP/Unix Server"
 }
 
 const sampleConfig = `
-  ## Protocol, must be "tcp", "udp", "udp4" or "udp6" (default=udp)
+  ## Protocol, must be "tcp", "udp", "udp4", "udp6" or "unixgram" (default=udp)
   protocol = "udp"
 
   ## MaxTCPConnection - applicable when protocol is set to tcp (default=250)
@@ -188,7 +207,8 @@ const sampleConfig = `
   ## Defaults to the OS configuration.
   # tcp_keep_alive_period = "2h"
 
-  ## Address and port to host UDP listener on
+  ## Address and port to host UDP listener on, or path of the socket with
+  ## the unixgram protocol
   service_address = ":8125"
 
   ## The following configuration options control when telegraf clears it's cache
@@ -200,7 +220,7 @@ const sampleConfig = `
   delete_counters = true
   ## Reset sets every interval (default=true)
   delete_sets = true
-  ## Reset timings & histograms every interval (default=true)
+  ## Reset timings, histograms & distributions every interval (default=true)
   delete_timings = true
 
   ## Percentiles to calculate for timing & histogram stats
@@ -213,6 +233,13 @@ const sampleConfig = `
   ## http://docs.datadoghq.com/guides/dogstatsd/
   parse_data_dog_tags = false
 
+  ## Parses the extensions of the datadog statsd format: tags, distributions,
+  ## events, service checks, entity and container ids
+  datadog_extensions = false
+
+  ## Percentiles to estimate for distribution stats, in bounded memory
+  distribution_percentiles = [50.0, 90.0, 95.0, 99.0]
+
   ## Statsd data translation templates, more info can be read here:
   ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_INPUT.md#graphite
   # templates = [
@@ -238,54 +265,87 @@ func (s *Statsd) Gather(acc telegraf.Accumulator) error {
 	defer s.Unlock()
 	now := time.Now()
 
+	// The metrics of all the cached series are made with the same builder,
+	// instead of maps of fields made for each series.
+	b := tmetric.GetBuilder()
+	defer tmetric.PutBuilder(b)
+
 	for _, metric := range s.timings {
 		// Defining a template to parse field names for timers allows us to split
 		// out multiple fields per timer. In this case we prefix each stat with the
 		// field name and store these all in a single measurement.
-		fields := make(map[string]interface{})
 		for fieldName, stats := range metric.fields {
 			var prefix string
 			if fieldName != defaultFieldName {
 				prefix = fieldName + "_"
 			}
-			fields[prefix+"mean"] = stats.Mean()
-			fields[prefix+"stddev"] = stats.Stddev()
-			fields[prefix+"sum"] = stats.Sum()
-			fields[prefix+"upper"] = stats.Upper()
-			fields[prefix+"lower"] = stats.Lower()
-			fields[prefix+"count"] = stats.Count()
+			b.AddField(prefix+"mean", stats.Mean())
+			b.AddField(prefix+"stddev", stats.Stddev())
+			b.AddField(prefix+"sum", stats.Sum())
+			b.AddField(prefix+"upper", stats.Upper())
+			b.AddField(prefix+"lower", stats.Lower())
+			b.AddField(prefix+"count", stats.Count())
 			for _, percentile := range s.Percentiles {
 				name := fmt.Sprintf("%s%v_percentile", prefix, percentile)
-				fields[name] = stats.Percentile(percentile)
+				b.AddField(name, stats.Percentile(percentile))
 			}
 		}
 
-		acc.AddFields(metric.name, fields, metric.tags, now)
+		addMetric(acc, b, metric.name, metric.tags, telegraf.Untyped, now)
 	}
 	if s.DeleteTimings {
 		s.timings = make(map[string]cachedtimings)
 	}
 
+	for _, metric := range s.distributions {
+		for fieldName, sketch := range metric.fields {
+			var prefix string
+			if fieldName != defaultFieldName {
+				prefix = fieldName + "_"
+			}
+			b.AddField(prefix+"mean", sketch.Mean())
+			b.AddField(prefix+"sum", sketch.Sum())
+			b.AddField(prefix+"upper", sketch.Upper())
+			b.AddField(prefix+"lower", sketch.Lower())
+			b.AddField(prefix+"count", sketch.Count())
+			for _, percentile := range s.DistributionPercentiles {
+				name := fmt.Sprintf("%s%s_percentile", prefix,
+					strconv.FormatFloat(percentile, 'f', -1, 64))
+				b.AddField(name, sketch.Percentile(percentile))
+			}
+		}
+
+		addMetric(acc, b, metric.name, metric.tags, telegraf.Untyped, now)
+	}
+	if s.DeleteTimings {
+		s.distributions = make(map[string]cacheddistributions)
+	}
+
 	for _, metric := range s.gauges {
-		acc.AddGauge(metric.name, metric.fields, metric.tags, now)
+		for field, value := range metric.fields {
+			b.AddField(field, value)
+		}
+		addMetric(acc, b, metric.name, metric.tags, telegraf.Gauge, now)
 	}
 	if s.DeleteGauges {
 		s.gauges = make(map[string]cachedgauge)
 	}
 
 	for _, metric := range s.counters {
-		acc.AddCounter(metric.name, metric.fields, metric.tags, now)
+		for field, value := range metric.fields {
+			b.AddField(field, value)
+		}
+		addMetric(acc, b, metric.name, metric.tags, telegraf.Counter, now)
 	}
 	if s.DeleteCounters {
 		s.counters = make(map[string]cachedcounter)
 	}
 
 	for _, metric := range s.sets {
-		fields := make(map[string]interface{})
 		for field, set := range metric.fields {
-			fields[field] = int64(len(set))
+			b.AddField(field, int64(len(set)))
 		}
-		acc.AddFields(metric.name, fields, metric.tags, now)
+		addMetric(acc, b, metric.name, metric.tags, telegraf.Untyped, now)
 	}
 	if s.DeleteSets {
 		s.sets = make(map[string]cachedset)
@@ -294,12 +354,36 @@ func (s *Statsd) Gather(acc telegraf.Accumulator) error {
 	return nil
 }
 
-func (s *Statsd) Start(_ telegraf.Accumulator) error {
+// addMetric adds the metric of the fields added to the builder, resetting the
+// builder for the next metric.
+func addMetric(
+	acc telegraf.Accumulator,
+	b *tmetric.Builder,
+	name string,
+	tags map[string]string,
+	tp telegraf.ValueType,
+	now time.Time,
+) {
+	b.SetName(name)
+	for k, v := range tags {
+		b.AddTag(k, v)
+	}
+	b.SetType(tp)
+	b.SetTime(now)
+	if m, err := b.Metric(); err == nil {
+		acc.AddMetric(m)
+	}
+	b.Reset()
+}
+
+func (s *Statsd) Start(acc telegraf.Accumulator) error {
 	// Make data structures
 	s.gauges = make(map[string]cachedgauge)
 	s.counters = make(map[string]cachedcounter)
 	s.sets = make(map[string]cachedset)
 	s.timings = make(map[string]cachedtimings)
+	s.distributions = make(map[string]cacheddistributions)
+	s.acc = acc
 
 	s.Lock()
 	defer s.Unlock()
@@ -340,6 +424,8 @@ func (s *Statsd) Start(_ telegraf.Accumulator) error {
 	// Start the UDP listener
 	if s.isUDP() {
 		go s.udpListen()
+	} else if s.isUnixgram() {
+		go s.unixgramListen()
 	} else {
 		go s.tcpListen()
 	}
@@ -411,13 +497,37 @@ func (s *Statsd) udpListen() error {
 	}
 	log.Println("I! Statsd UDP listener listening on: ", s.UDPlistener.LocalAddr().String())
 
+	return s.readPackets(s.UDPlistener)
+}
+
+// unixgramListen starts listening for datagrams on the configured unix socket.
+func (s *Statsd) unixgramListen() error {
+	defer s.wg.Done()
+	// Remove the socket left over by a previous run
+	if info, err := os.Stat(s.ServiceAddress); err == nil && info.Mode()&os.ModeSocket != 0 {
+		os.Remove(s.ServiceAddress)
+	}
+	var err error
+	address := &net.UnixAddr{Name: s.ServiceAddress, Net: "unixgram"}
+	s.UnixgramListener, err = net.ListenUnixgram("unixgram", address)
+	if err != nil {
+		log.Fatalf("ERROR: ListenUnixgram - %s", err)
+	}
+	log.Println("I! Statsd unixgram listener listening on: ", s.ServiceAddress)
+
+	return s.readPackets(s.UnixgramListener)
+}
+
+// readPackets queues the packets read from the connection until the service
+// is stopped.
+func (s *Statsd) readPackets(conn net.PacketConn) error {
 	buf := make([]byte, UDP_MAX_PACKET_SIZE)
 	for {
 		select {
 		case <-s.done:
 			return nil
 		default:
-			n, _, err := s.UDPlistener.ReadFromUDP(buf)
+			n, _, err := conn.ReadFrom(buf)
 			if err != nil && !strings.Contains(err.Error(), "closed network") {
 				log.Printf("E! Error READ: %s\n", err.Error())
 				continue
@@ -466,8 +576,23 @@ func (s *Statsd) parseStatsdLine(line string) error {
 	s.Lock()
 	defer s.Unlock()
 
+	if s.DataDogExtensions && (strings.HasPrefix(line, "_e{") || strings.HasPrefix(line, "_sc|")) {
+		// events and service checks are not aggregated, they are added as
+		// they arrive
+		var err error
+		if strings.HasPrefix(line, "_e{") {
+			err = s.parseEventMessage(line)
+		} else {
+			err = s.parseServiceCheckMessage(line)
+		}
+		if err != nil {
+			log.Printf("E! Error: %s\n", err)
+		}
+		return err
+	}
+
 	lineTags := make(map[string]string)
-	if s.ParseDataDogTags {
+	if s.ParseDataDogTags || s.DataDogExtensions {
 		recombinedSegments := make([]string, 0)
 		// datadog tags look like this:
 		// users.online:1|c|@0.5|#country:china,environment:production
@@ -478,23 +603,11 @@ func (s *Statsd) parseStatsdLine(line string) error {
 		for _, segment := range pipesplit {
 			if len(segment) > 0 && segment[0] == '#' {
 				// we have ourselves a tag; they are comma separated
-				tagstr := segment[1:]
-				tags := strings.Split(tagstr, ",")
-				for _, tag := range tags {
-					ts := strings.SplitN(tag, ":", 2)
-					var k, v string
-					switch len(ts) {
-					case 1:
-						// just a tag
-						k = ts[0]
-						v = ""
-					case 2:
-						k = ts[0]
-						v = ts[1]
-					}
-					if k != "" {
-						lineTags[k] = v
-					}
+				s.parseDataDogTags(lineTags, segment[1:])
+			} else if s.DataDogExtensions && strings.HasPrefix(segment, "c:") {
+				// the id of the container the metric is sent from
+				if segment[2:] != "" {
+					lineTags["container_id"] = segment[2:]
 				}
 			} else {
 				recombinedSegments = append(recombinedSegments, segment)
@@ -545,6 +658,12 @@ func (s *Statsd) parseStatsdLine(line string) error {
 		switch pipesplit[1] {
 		case "g", "c", "s", "ms", "h":
 			m.mtype = pipesplit[1]
+		case "d":
+			if !s.DataDogExtensions {
+				log.Printf("E! Error: Statsd Metric type d requires datadog_extensions")
+				return errors.New("Error Parsing statsd line")
+			}
+			m.mtype = pipesplit[1]
 		default:
 			log.Printf("E! Error: Statsd Metric type %s unsupported", pipesplit[1])
 			return errors.New("Error Parsing statsd line")
@@ -560,7 +679,7 @@ func (s *Statsd) parseStatsdLine(line string) error {
 		}
 
 		switch m.mtype {
-		case "g", "ms", "h":
+		case "g", "ms", "h", "d":
 			v, err := strconv.ParseFloat(pipesplit[0], 64)
 			if err != nil {
 				log.Printf("E! Error: parsing value to float64: %s\n", line)
@@ -600,6 +719,8 @@ func (s *Statsd) parseStatsdLine(line string) error {
 			m.tags["metric_type"] = "timing"
 		case "h":
 			m.tags["metric_type"] = "histogram"
+		case "d":
+			m.tags["metric_type"] = "distribution"
 		}
 
 		if len(lineTags) > 0 {
@@ -716,6 +837,28 @@ func (s *Statsd) aggregate(m metric) {
 		}
 		cached.fields[m.field] = field
 		s.timings[m.hash] = cached
+	case "d":
+		cached, ok := s.distributions[m.hash]
+		if !ok {
+			cached = cacheddistributions{
+				name:   m.name,
+				fields: make(map[string]*Sketch),
+				tags:   m.tags,
+			}
+		}
+		field, ok := cached.fields[m.field]
+		if !ok {
+			field = NewSketch(defaultSketchAccuracy, defaultSketchMaxBins)
+		}
+		if m.samplerate > 0 {
+			for i := 0; i < int(1.0/m.samplerate); i++ {
+				field.AddValue(m.floatvalue)
+			}
+		} else {
+			field.AddValue(m.floatvalue)
+		}
+		cached.fields[m.field] = field
+		s.distributions[m.hash] = cached
 	case "c":
 		// check if the measurement exists
 		_, ok := s.counters[m.hash]
@@ -849,6 +992,9 @@ func (s *Statsd) Stop() {
 	close(s.done)
 	if s.isUDP() {
 		s.UDPlistener.Close()
+	} else if s.isUnixgram() {
+		s.UnixgramListener.Close()
+		os.Remove(s.ServiceAddress)
 	} else {
 		s.TCPlistener.Close()
 		// Close all open TCP connections
@@ -880,19 +1026,25 @@ func (s *Statsd) isUDP() bool {
 	return strings.HasPrefix(s.Protocol, "udp")
 }
 
+// isUnixgram returns true if the protocol is datagrams over a unix socket.
+func (s *Statsd) isUnixgram() bool {
+	return s.Protocol == "unixgram"
+}
+
 func init() {
 	inputs.Add("statsd", func() telegraf.Input {
 		return &Statsd{
-			Protocol:               defaultProtocol,
-			ServiceAddress:         ":8125",
-			MaxTCPConnections:      250,
-			TCPKeepAlive:           false,
-			MetricSeparator:        "_",
-			AllowedPendingMessages: defaultAllowPendingMessage,
-			DeleteCounters:         true,
-			DeleteGauges:           true,
-			DeleteSets:             true,
-			DeleteTimings:          true,
+			Protocol:                defaultProtocol,
+			ServiceAddress:          ":8125",
+			MaxTCPConnections:       250,
+			TCPKeepAlive:            false,
+			MetricSeparator:         "_",
+			AllowedPendingMessages:  defaultAllowPendingMessage,
+			DeleteCounters:          true,
+			DeleteGauges:            true,
+			DeleteSets:              true,
+			DeleteTimings:           true,
+			DistributionPercentiles: []float64{50, 90, 95, 99},
 		}
 	})
 }
@@ -4,7 +4,10 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -40,6 +43,7 @@ func NewTestStatsd() *Statsd {
 	s.counters = make(map[string]cachedcounter)
 	s.sets = make(map[string]cachedset)
 	s.timings = make(map[string]cachedtimings)
+	s.distributions = make(map[string]cacheddistributions)
 
 	s.MetricSeparator = "_"
 
@@ -1600,3 +1604,191 @@ func test_validate_gauge(
 	}
 	return nil
 }
+
+func TestParse_DataDogDistributions(t *testing.T) {
+	s := NewTestStatsd()
+	s.DataDogExtensions = true
+	s.DistributionPercentiles = []float64{50, 99.9}
+	acc := &testutil.Accumulator{}
+
+	lines := []string{
+		"latency:1|d|#endpoint:/",
+		"latency:2|d|#endpoint:/",
+		"latency:3|d|@0.5|#endpoint:/",
+	}
+	for _, line := range lines {
+		require.NoError(t, s.parseStatsdLine(line))
+	}
+	require.NoError(t, s.Gather(acc))
+
+	m, ok := acc.Get("latency")
+	require.True(t, ok)
+	assert.Equal(t, map[string]string{
+		"endpoint":    "/",
+		"metric_type": "distribution",
+	}, m.Tags)
+	assert.Equal(t, int64(4), m.Fields["count"])
+	assert.Equal(t, 9.0, m.Fields["sum"])
+	assert.Equal(t, 1.0, m.Fields["lower"])
+	assert.Equal(t, 3.0, m.Fields["upper"])
+	assert.InEpsilon(t, 2.0, m.Fields["50_percentile"], 0.01)
+	assert.InEpsilon(t, 3.0, m.Fields["99.9_percentile"], 0.01)
+
+	// Distributions are only parsed with the datadog extensions
+	s = NewTestStatsd()
+	s.ParseDataDogTags = true
+	assert.Error(t, s.parseStatsdLine("latency:1|d"))
+}
+
+func TestParse_DataDogEntityTags(t *testing.T) {
+	s := NewTestStatsd()
+	s.DataDogExtensions = true
+
+	require.NoError(t, s.parseStatsdLine("requests:1|c|#dd.internal.entity_id:pod-uid,env:prod|c:abc123"))
+
+	assert.Equal(t, map[string]string{
+		"entity_id":    "pod-uid",
+		"env":          "prod",
+		"container_id": "abc123",
+		"metric_type":  "counter",
+	}, tagsForItem(s.counters))
+	require.NoError(t, test_validate_counter("requests", 1, s.counters))
+}
+
+func TestParse_DataDogEvents(t *testing.T) {
+	s := NewTestStatsd()
+	s.DataDogExtensions = true
+	acc := &testutil.Accumulator{}
+	s.acc = acc
+
+	lines := []string{
+		`_e{12,17}:Deploy|ment!|Version 2\nis out|d:1531389600|h:web01|k:deploys|p:low|s:ci|t:success|#env:prod,service:api`,
+		"_e{5,4}:Title|Text",
+	}
+	for _, line := range lines {
+		require.NoError(t, s.parseStatsdLine(line))
+	}
+
+	acc.AssertContainsTaggedFields(t, "statsd_events",
+		map[string]interface{}{
+			"title":            "Deploy|ment!",
+			"text":             "Version 2\nis out",
+			"priority":         "low",
+			"alert_type":       "success",
+			"aggregation_key":  "deploys",
+			"source_type_name": "ci",
+		},
+		map[string]string{
+			"source":  "web01",
+			"env":     "prod",
+			"service": "api",
+		})
+	m, ok := acc.Get("statsd_events")
+	require.True(t, ok)
+	assert.Equal(t, time.Unix(1531389600, 0), m.Time)
+
+	acc.AssertContainsTaggedFields(t, "statsd_events",
+		map[string]interface{}{
+			"title":      "Title",
+			"text":       "Text",
+			"priority":   "normal",
+			"alert_type": "info",
+		},
+		map[string]string{})
+
+	invalid := []string{
+		"_e{5,10}:Title|Text",
+		"_e{5}:Title|Text",
+		"_e{a,4}:Title|Text",
+		"_e{4,4}:Title|Text",
+	}
+	for _, line := range invalid {
+		assert.Error(t, s.parseStatsdLine(line), line)
+	}
+	assert.Len(t, acc.Metrics, 2)
+}
+
+func TestParse_DataDogServiceChecks(t *testing.T) {
+	s := NewTestStatsd()
+	s.DataDogExtensions = true
+	acc := &testutil.Accumulator{}
+	s.acc = acc
+
+	lines := []string{
+		"_sc|redis.can_connect|2|d:1531389600|h:web01|#env:prod|m:timeout | retrying",
+		"_sc|app.ok|0",
+	}
+	for _, line := range lines {
+		require.NoError(t, s.parseStatsdLine(line))
+	}
+
+	acc.AssertContainsTaggedFields(t, "statsd_service_checks",
+		map[string]interface{}{
+			"status":  int64(2),
+			"message": "timeout | retrying",
+		},
+		map[string]string{
+			"check":  "redis.can_connect",
+			"source": "web01",
+			"env":    "prod",
+		})
+	acc.AssertContainsTaggedFields(t, "statsd_service_checks",
+		map[string]interface{}{
+			"status": int64(0),
+		},
+		map[string]string{
+			"check": "app.ok",
+		})
+
+	assert.Error(t, s.parseStatsdLine("_sc|app.ok|5"))
+	assert.Error(t, s.parseStatsdLine("_sc|app.ok"))
+
+	// Without the extensions, events and service checks are invalid metrics
+	s.DataDogExtensions = false
+	assert.Error(t, s.parseStatsdLine("_sc|app.ok|0"))
+	assert.Len(t, acc.Metrics, 2)
+}
+
+func TestUnixgram(t *testing.T) {
+	dir, err := ioutil.TempDir("", "statsd")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	socket := filepath.Join(dir, "dsd.socket")
+
+	listener := Statsd{
+		Protocol:               "unixgram",
+		ServiceAddress:         socket,
+		AllowedPendingMessages: 10000,
+		DataDogExtensions:      true,
+	}
+
+	acc := &testutil.Accumulator{}
+	require.NoError(t, listener.Start(acc))
+	defer listener.Stop()
+
+	for i := 0; i < 50; i++ {
+		if _, err = os.Stat(socket); err == nil {
+			break
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	conn, err := net.Dial("unixgram", socket)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("cpu.time_idle:42|c|#host:localhost\n"))
+	require.NoError(t, err)
+
+	for i := 0; i < 50; i++ {
+		listener.Lock()
+		n := len(listener.counters)
+		listener.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	require.NoError(t, listener.Gather(acc))
+	acc.AssertContainsTaggedFields(t, "cpu_time_idle",
+		map[string]interface{}{"value": int64(42)},
+		map[string]string{"host": "localhost", "metric_type": "counter"})
+}
@@ -0,0 +1,329 @@
+package suricata
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/influxdata/tail"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const (
+	// InBufSize is the input buffer size for JSON received via socket.
+	// Set to 10MB, as depending on the number of threads the stats records
+	// can be quite large.
+	InBufSize = 10 * 1024 * 1024
+)
+
+// Suricata is a Telegraf input plugin for the EVE JSON records of Suricata.
+type Suricata struct {
+	Source    string `toml:"source"`
+	File      string `toml:"file"`
+	Delimiter string `toml:"delimiter"`
+	Alerts    bool   `toml:"alerts"`
+
+	inputListener *net.UnixListener
+	tailer        *tail.Tail
+	acc           telegraf.Accumulator
+	cancel        chan struct{}
+	wg            sync.WaitGroup
+}
+
+// Description returns the plugin description.
+func (s *Suricata) Description() string {
+	return "Suricata stats and alerts plugin"
+}
+
+const sampleConfig = `
+  ## Data sink for Suricata EVE records, a unix socket created to listen to
+  ## the eve-log output of Suricata configured with 'filetype: unix_stream'.
+  source = "/var/run/suricata-stats.sock"
+
+  ## Tail an EVE JSON log file instead of listening on the socket.
+  # file = "/var/log/suricata/eve.json"
+
+  ## Delimiter for flattening field keys, e.g. subitem "alert" of "detect"
+  ## becomes "detect_alert" when delimiter is "_".
+  delimiter = "_"
+
+  ## Gather the alert events as the suricata_alert measurement.
+  # alerts = false
+`
+
+// SampleConfig returns a sample TOML section to illustrate configuration
+// options.
+func (s *Suricata) SampleConfig() string {
+	return sampleConfig
+}
+
+// Start initiates background collection of JSON data from the socket or the
+// file.
+func (s *Suricata) Start(acc telegraf.Accumulator) error {
+	s.acc = acc
+	s.cancel = make(chan struct{})
+
+	if s.File != "" {
+		return s.startTail()
+	}
+
+	var err error
+	s.inputListener, err = net.ListenUnix("unix", &net.UnixAddr{
+		Name: s.Source,
+		Net:  "unix",
+	})
+	if err != nil {
+		return err
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.handleServerConnection()
+	}()
+	return nil
+}
+
+func (s *Suricata) startTail() error {
+	var err error
+	s.tailer, err = tail.TailFile(s.File, tail.Config{
+		ReOpen:    true,
+		Follow:    true,
+		Location:  &tail.SeekInfo{Whence: io.SeekEnd},
+		MustExist: true,
+		Logger:    tail.DiscardingLogger,
+	})
+	if err != nil {
+		return err
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for line := range s.tailer.Lines {
+			if line.Err != nil {
+				s.acc.AddError(line.Err)
+				continue
+			}
+			s.parse([]byte(line.Text))
+		}
+	}()
+	return nil
+}
+
+// Stop causes the plugin to cease collecting JSON data from the socket or
+// the file.
+func (s *Suricata) Stop() {
+	close(s.cancel)
+	if s.inputListener != nil {
+		s.inputListener.Close()
+		// the socket file is not removed by the listener when closed
+		os.Remove(s.Source)
+	}
+	if s.tailer != nil {
+		s.tailer.Stop()
+		s.tailer.Cleanup()
+	}
+	s.wg.Wait()
+}
+
+func (s *Suricata) readInput(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReaderSize(conn, InBufSize)
+	for {
+		line, rerr := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			s.parse(line)
+		}
+		if rerr != nil {
+			if rerr != io.EOF {
+				select {
+				case <-s.cancel:
+				default:
+					s.acc.AddError(rerr)
+				}
+			}
+			return
+		}
+	}
+}
+
+func (s *Suricata) handleServerConnection() {
+	for {
+		conn, err := s.inputListener.Accept()
+		if err != nil {
+			select {
+			case <-s.cancel:
+			default:
+				s.acc.AddError(err)
+			}
+			return
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+
+			// close the connection when stopping
+			done := make(chan struct{})
+			defer close(done)
+			go func() {
+				select {
+				case <-s.cancel:
+					conn.Close()
+				case <-done:
+				}
+			}()
+
+			s.readInput(conn)
+		}()
+	}
+}
+
+// event is an EVE JSON record, only the stats and alert records are used
+type event struct {
+	Timestamp string                 `json:"timestamp"`
+	EventType string                 `json:"event_type"`
+	Stats     map[string]interface{} `json:"stats"`
+	Alert     *alert                 `json:"alert"`
+	FlowID    int64                  `json:"flow_id"`
+	SrcIP     string                 `json:"src_ip"`
+	SrcPort   int64                  `json:"src_port"`
+	DestIP    string                 `json:"dest_ip"`
+	DestPort  int64                  `json:"dest_port"`
+	Proto     string                 `json:"proto"`
+}
+
+type alert struct {
+	Action      string `json:"action"`
+	GID         int64  `json:"gid"`
+	SignatureID int64  `json:"signature_id"`
+	Rev         int64  `json:"rev"`
+	Signature   string `json:"signature"`
+	Category    string `json:"category"`
+	Severity    int64  `json:"severity"`
+}
+
+func (s *Suricata) parse(line []byte) {
+	line = bytes.TrimSpace(line)
+	if len(line) == 0 {
+		return
+	}
+
+	var e event
+	if err := json.Unmarshal(line, &e); err != nil {
+		s.acc.AddError(fmt.Errorf("failed to parse EVE record: %s", err))
+		return
+	}
+
+	t, err := time.Parse("2006-01-02T15:04:05.999999-0700", e.Timestamp)
+	if err != nil {
+		t = time.Now()
+	}
+
+	switch e.EventType {
+	case "stats":
+		s.parseStats(e.Stats, t)
+	case "alert":
+		if s.Alerts && e.Alert != nil {
+			s.parseAlert(&e, t)
+		}
+	}
+}
+
+// parseStats adds the stats of each thread, and of all the threads with the
+// thread tag "total".
+func (s *Suricata) parseStats(stats map[string]interface{}, t time.Time) {
+	if stats == nil {
+		log.Printf("D! [inputs.suricata] stats record without stats")
+		return
+	}
+
+	if threads, ok := stats["threads"].(map[string]interface{}); ok {
+		for thread, threadStats := range threads {
+			if m, ok := threadStats.(map[string]interface{}); ok {
+				s.addStats(thread, m, t)
+			}
+		}
+	}
+
+	total := make(map[string]interface{})
+	for k, v := range stats {
+		if k != "threads" {
+			total[k] = v
+		}
+	}
+	s.addStats("total", total, t)
+}
+
+func (s *Suricata) addStats(thread string, stats map[string]interface{}, t time.Time) {
+	fields := make(map[string]interface{})
+	s.flatten("", stats, fields)
+	if len(fields) == 0 {
+		return
+	}
+	s.acc.AddFields("suricata", fields, map[string]string{"thread": thread}, t)
+}
+
+// flatten adds the numeric values of the nested stats to the fields, the keys
+// of the levels being joined with the delimiter.
+func (s *Suricata) flatten(prefix string, stats map[string]interface{}, fields map[string]interface{}) {
+	for k, v := range stats {
+		key := k
+		if prefix != "" {
+			key = prefix + s.Delimiter + k
+		}
+
+		switch value := v.(type) {
+		case map[string]interface{}:
+			s.flatten(key, value, fields)
+		case float64:
+			fields[key] = value
+		}
+	}
+}
+
+func (s *Suricata) parseAlert(e *event, t time.Time) {
+	tags := map[string]string{
+		"signature": e.Alert.Signature,
+		"category":  e.Alert.Category,
+		"severity":  fmt.Sprint(e.Alert.Severity),
+	}
+	fields := map[string]interface{}{
+		"action":       e.Alert.Action,
+		"gid":          e.Alert.GID,
+		"signature_id": e.Alert.SignatureID,
+		"rev":          e.Alert.Rev,
+		"flow_id":      e.FlowID,
+		"src_ip":       e.SrcIP,
+		"src_port":     e.SrcPort,
+		"dest_ip":      e.DestIP,
+		"dest_port":    e.DestPort,
+		"proto":        e.Proto,
+	}
+	s.acc.AddFields("suricata_alert", fields, tags, t)
+}
+
+// Gather measures and submits one full set of telemetry to Telegraf.
+// Not used here, submission is completely input-driven.
+func (s *Suricata) Gather(acc telegraf.Accumulator) error {
+	return nil
+}
+
+func init() {
+	inputs.Add("suricata", func() telegraf.Input {
+		return &Suricata{
+			Source:    "/var/run/suricata-stats.sock",
+			Delimiter: "_",
+		}
+	})
+}
@@ -0,0 +1,209 @@
+package suricata
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const ex2 = `{"timestamp":"2017-03-06T07:43:39.000397+0000","event_type":"stats","stats":{"capture":{"kernel_packets":905344474,"kernel_drops":78355440,"kernel_packets_delta":2376742,"kernel_drops_delta":82049}}}`
+const ex3 = `{"timestamp":"2017-03-06T07:43:39.000397+0000","event_type":"stats","stats":{"uptime":100,"threads":{"W#05-wlp4s0":{"capture":{"kernel_packets":905344474,"kernel_drops":78355440}}}}}`
+const exAlert = `{"timestamp":"2017-03-06T07:43:40.000397+0000","flow_id":1234,"event_type":"alert","src_ip":"192.168.0.2","src_port":54321,"dest_ip":"10.0.0.1","dest_port":80,"proto":"TCP","alert":{"action":"allowed","gid":1,"signature_id":2100498,"rev":7,"signature":"GPL ATTACK_RESPONSE id check returned root","category":"Potentially Bad Traffic","severity":2}}`
+
+func tempSocket(t *testing.T) (string, func()) {
+	dir, err := ioutil.TempDir("", "test")
+	require.NoError(t, err)
+	return filepath.Join(dir, "suricata.sock"), func() { os.RemoveAll(dir) }
+}
+
+func TestSuricata(t *testing.T) {
+	sock, cleanup := tempSocket(t)
+	defer cleanup()
+
+	s := Suricata{
+		Source:    sock,
+		Delimiter: ".",
+	}
+	acc := testutil.Accumulator{}
+	require.NoError(t, s.Start(&acc))
+	defer s.Stop()
+
+	c, err := net.Dial("unix", sock)
+	require.NoError(t, err)
+	c.Write([]byte(ex2))
+	c.Write([]byte("\n"))
+	c.Close()
+
+	acc.Wait(1)
+
+	acc.AssertContainsTaggedFields(t, "suricata",
+		map[string]interface{}{
+			"capture.kernel_packets":       float64(905344474),
+			"capture.kernel_drops":         float64(78355440),
+			"capture.kernel_packets_delta": float64(2376742),
+			"capture.kernel_drops_delta":   float64(82049),
+		},
+		map[string]string{"thread": "total"})
+
+	m := acc.Metrics[0]
+	assert.Equal(t, time.Date(2017, 3, 6, 7, 43, 39, 397000, time.UTC), m.Time.UTC())
+}
+
+func TestSuricataThreads(t *testing.T) {
+	sock, cleanup := tempSocket(t)
+	defer cleanup()
+
+	s := Suricata{
+		Source:    sock,
+		Delimiter: "_",
+	}
+	acc := testutil.Accumulator{}
+	require.NoError(t, s.Start(&acc))
+	defer s.Stop()
+
+	c, err := net.Dial("unix", sock)
+	require.NoError(t, err)
+	fmt.Fprintln(c, ex3)
+	c.Close()
+
+	acc.Wait(2)
+
+	acc.AssertContainsTaggedFields(t, "suricata",
+		map[string]interface{}{
+			"capture_kernel_packets": float64(905344474),
+			"capture_kernel_drops":   float64(78355440),
+		},
+		map[string]string{"thread": "W#05-wlp4s0"})
+	acc.AssertContainsTaggedFields(t, "suricata",
+		map[string]interface{}{
+			"uptime": float64(100),
+		},
+		map[string]string{"thread": "total"})
+}
+
+func TestSuricataAlerts(t *testing.T) {
+	sock, cleanup := tempSocket(t)
+	defer cleanup()
+
+	s := Suricata{
+		Source:    sock,
+		Delimiter: "_",
+		Alerts:    true,
+	}
+	acc := testutil.Accumulator{}
+	require.NoError(t, s.Start(&acc))
+	defer s.Stop()
+
+	c, err := net.Dial("unix", sock)
+	require.NoError(t, err)
+	fmt.Fprintln(c, exAlert)
+	fmt.Fprintln(c, ex2)
+	c.Close()
+
+	acc.Wait(2)
+
+	acc.AssertContainsTaggedFields(t, "suricata_alert",
+		map[string]interface{}{
+			"action":       "allowed",
+			"gid":          int64(1),
+			"signature_id": int64(2100498),
+			"rev":          int64(7),
+			"flow_id":      int64(1234),
+			"src_ip":       "192.168.0.2",
+			"src_port":     int64(54321),
+			"dest_ip":      "10.0.0.1",
+			"dest_port":    int64(80),
+			"proto":        "TCP",
+		},
+		map[string]string{
+			"signature": "GPL ATTACK_RESPONSE id check returned root",
+			"category":  "Potentially Bad Traffic",
+			"severity":  "2",
+		})
+}
+
+func TestSuricataAlertsDisabled(t *testing.T) {
+	sock, cleanup := tempSocket(t)
+	defer cleanup()
+
+	s := Suricata{
+		Source:    sock,
+		Delimiter: "_",
+	}
+	acc := testutil.Accumulator{}
+	require.NoError(t, s.Start(&acc))
+	defer s.Stop()
+
+	c, err := net.Dial("unix", sock)
+	require.NoError(t, err)
+	fmt.Fprintln(c, exAlert)
+	fmt.Fprintln(c, ex2)
+	c.Close()
+
+	acc.Wait(1)
+
+	assert.False(t, acc.HasMeasurement("suricata_alert"))
+}
+
+func TestSuricataInvalid(t *testing.T) {
+	sock, cleanup := tempSocket(t)
+	defer cleanup()
+
+	s := Suricata{
+		Source:    sock,
+		Delimiter: "_",
+	}
+	acc := testutil.Accumulator{}
+	require.NoError(t, s.Start(&acc))
+	defer s.Stop()
+
+	c, err := net.Dial("unix", sock)
+	require.NoError(t, err)
+	fmt.Fprintln(c, "sfjiowef")
+	c.Close()
+
+	acc.WaitError(1)
+}
+
+func TestSuricataFile(t *testing.T) {
+	if os.Getenv("CIRCLE_PROJECT_REPONAME") != "" {
+		t.Skip("Skipping CI testing due to race conditions")
+	}
+
+	tmpfile, err := ioutil.TempFile("", "eve")
+	require.NoError(t, err)
+	defer os.Remove(tmpfile.Name())
+	defer tmpfile.Close()
+
+	s := Suricata{
+		File:      tmpfile.Name(),
+		Delimiter: "_",
+	}
+	acc := testutil.Accumulator{}
+	require.NoError(t, s.Start(&acc))
+	defer s.Stop()
+
+	// give the tailer the time to seek to the end of the file
+	time.Sleep(100 * time.Millisecond)
+	_, err = fmt.Fprintln(tmpfile, ex2)
+	require.NoError(t, err)
+
+	acc.Wait(1)
+
+	acc.AssertContainsTaggedFields(t, "suricata",
+		map[string]interface{}{
+			"capture_kernel_packets":       float64(905344474),
+			"capture_kernel_drops":         float64(78355440),
+			"capture_kernel_packets_delta": float64(2376742),
+			"capture_kernel_drops_delta":   float64(82049),
+		},
+		map[string]string{"thread": "total"})
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
@@ -34,7 +35,7 @@ func getTestCasesForRFC5425() []testCase5425 {
 				testutil.Metric{
 					Measurement: "syslog",
 					Fields: map[string]interface{}{
-						"version":       uint16(1),
+						"version":       uint64(1),
 						"timestamp":     time.Unix(1456029177, 0).UnixNano(),
 						"procid":        "2341",
 						"msgid":         "2",
@@ -42,8 +43,8 @@ func getTestCasesForRFC5425() []testCase5425 {
 						"origin":        true,
 						"meta_sequence": "14125553",
 						"meta_service":  "someservice",
-						"severity_code": 5,
-						"facility_code": 3,
+						"severity_code": int64(5),
+						"facility_code": int64(3),
 					},
 					Tags: map[string]string{
 						"severity": "notice",
@@ -52,13 +53,14 @@ func getTestCasesForRFC5425() []testCase5425 {
 						"appname":  "someservice",
 					},
 					Time: defaultTime,
+					Type: telegraf.Untyped,
 				},
 			},
 			wantBestEffort: []testutil.Metric{
 				testutil.Metric{
 					Measurement: "syslog",
 					Fields: map[string]interface{}{
-						"version":       uint16(1),
+						"version":       uint64(1),
 						"timestamp":     time.Unix(1456029177, 0).UnixNano(),
 						"procid":        "2341",
 						"msgid":         "2",
@@ -66,8 +68,8 @@ func getTestCasesForRFC5425() []testCase5425 {
 						"origin":        true,
 						"meta_sequence": "14125553",
 						"meta_service":  "someservice",
-						"severity_code": 5,
-						"facility_code": 3,
+						"severity_code": int64(5),
+						"facility_code": int64(3),
 					},
 					Tags: map[string]string{
 						"severity": "notice",
@@ -76,6 +78,7 @@ func getTestCasesForRFC5425() []testCase5425 {
 						"appname":  "someservice",
 					},
 					Time: defaultTime,
+					Type: telegraf.Untyped,
 				},
 			},
 		},
@@ -86,56 +89,60 @@ func getTestCasesForRFC5425() []testCase5425 {
 				testutil.Metric{
 					Measurement: "syslog",
 					Fields: map[string]interface{}{
-						"version":       uint16(2),
-						"severity_code": 1,
-						"facility_code": 0,
+						"version":       uint64(2),
+						"severity_code": int64(1),
+						"facility_code": int64(0),
 					},
 					Tags: map[string]string{
 						"severity": "alert",
 						"facility": "kern",
 					},
 					Time: defaultTime,
+					Type: telegraf.Untyped,
 				},
 				testutil.Metric{
 					Measurement: "syslog",
 					Fields: map[string]interface{}{
-						"version":       uint16(11),
-						"severity_code": 4,
-						"facility_code": 0,
+						"version":       uint64(11),
+						"severity_code": int64(4),
+						"facility_code": int64(0),
 					},
 					Tags: map[string]string{
 						"severity": "warning",
 						"facility": "kern",
 					},
 					Time: defaultTime.Add(time.Nanosecond),
+					Type: telegraf.Untyped,
 				},
 			},
 			wantBestEffort: []testutil.Metric{
 				testutil.Metric{
 					Measurement: "syslog",
 					Fields: map[string]interface{}{
-						"version":       uint16(2),
-						"severity_code": 1,
-						"facility_code": 0,
+						"version":       uint64(2),
+						"severity_code": int64(1),
+						"facility_code": int64(0),
 					},
 					Tags: map[string]string{
 						"severity": "alert",
 						"facility": "kern",
 					},
 					Time: defaultTime,
+					Type: telegraf.Untyped,
 				},
 				testutil.Metric{
 					Measurement: "syslog",
 					Fields: map[string]interface{}{
-						"version":       uint16(11),
-						"severity_code": 4,
-						"facility_code": 0,
+						"version":       uint64(11),
+						"severity_code": int64(4),
+						"facility_code": int64(0),
 					},
 					Tags: map[string]string{
 						"severity": "warning",
 						"facility": "kern",
 					},
 					Time: defaultTime.Add(time.Nanosecond),
+					Type: telegraf.Untyped,
 				},
 			},
 		},
@@ -146,32 +153,34 @@ func getTestCasesForRFC5425() []testCase5425 {
 				testutil.Metric{
 					Measurement: "syslog",
 					Fields: map[string]interface{}{
-						"version":       uint16(1),
+						"version":       uint64(1),
 						"message":       "hellø",
-						"severity_code": 1,
-						"facility_code": 0,
+						"severity_code": int64(1),
+						"facility_code": int64(0),
 					},
 					Tags: map[string]string{
 						"severity": "alert",
 						"facility": "kern",
 					},
 					Time: defaultTime,
+					Type: telegraf.Untyped,
 				},
 			},
 			wantBestEffort: []testutil.Metric{
 				testutil.Metric{
 					Measurement: "syslog",
 					Fields: map[string]interface{}{
-						"version":       uint16(1),
+						"version":       uint64(1),
 						"message":       "hellø",
-						"severity_code": 1,
-						"facility_code": 0,
+						"severity_code": int64(1),
+						"facility_code": int64(0),
 					},
 					Tags: map[string]string{
 						"severity": "alert",
 						"facility": "kern",
 					},
 					Time: defaultTime,
+					Type: telegraf.Untyped,
 				},
 			},
 		},
@@ -182,32 +191,34 @@ func getTestCasesForRFC5425() []testCase5425 {
 				testutil.Metric{
 					Measurement: "syslog",
 					Fields: map[string]interface{}{
-						"version":       uint16(3),
+						"version":       uint64(3),
 						"message":       "hello\nworld",
-						"severity_code": 1,
-						"facility_code": 0,
+						"severity_code": int64(1),
+						"facility_code": int64(0),
 					},
 					Tags: map[string]string{
 						"severity": "alert",
 						"facility": "kern",
 					},
 					Time: defaultTime,
+					Type: telegraf.Untyped,
 				},
 			},
 			wantBestEffort: []testutil.Metric{
 				testutil.Metric{
 					Measurement: "syslog",
 					Fields: map[string]interface{}{
-						"version":       uint16(3),
+						"version":       uint64(3),
 						"message":       "hello\nworld",
-						"severity_code": 1,
-						"facility_code": 0,
+						"severity_code": int64(1),
+						"facility_code": int64(0),
 					},
 					Tags: map[string]string{
 						"severity": "alert",
 						"facility": "kern",
 					},
 					Time: defaultTime,
+					Type: telegraf.Untyped,
 				},
 			},
 		},
@@ -219,15 +230,16 @@ func getTestCasesForRFC5425() []testCase5425 {
 				testutil.Metric{
 					Measurement: "syslog",
 					Fields: map[string]interface{}{
-						"version":       uint16(2),
-						"severity_code": 1,
-						"facility_code": 0,
+						"version":       uint64(2),
+						"severity_code": int64(1),
+						"facility_code": int64(0),
 					},
 					Tags: map[string]string{
 						"severity": "alert",
 						"facility": "kern",
 					},
 					Time: defaultTime,
+					Type: telegraf.Untyped,
 				},
 			},
 			werr: 1,
@@ -239,30 +251,32 @@ func getTestCasesForRFC5425() []testCase5425 {
 				testutil.Metric{
 					Measurement: "syslog",
 					Fields: map[string]interface{}{
-						"version":       uint16(1),
-						"severity_code": 1,
-						"facility_code": 0,
+						"version":       uint64(1),
+						"severity_code": int64(1),
+						"facility_code": int64(0),
 					},
 					Tags: map[string]string{
 						"severity": "alert",
 						"facility": "kern",
 					},
 					Time: defaultTime,
+					Type: telegraf.Untyped,
 				},
 			},
 			wantBestEffort: []testutil.Metric{
 				testutil.Metric{
 					Measurement: "syslog",
 					Fields: map[string]interface{}{
-						"version":       uint16(1),
-						"severity_code": 1,
-						"facility_code": 0,
+						"version":       uint64(1),
+						"severity_code": int64(1),
+						"facility_code": int64(0),
 					},
 					Tags: map[string]string{
 						"severity": "alert",
 						"facility": "kern",
 					},
 					Time: defaultTime,
+					Type: telegraf.Untyped,
 				},
 			},
 		},
@@ -274,15 +288,16 @@ func getTestCasesForRFC5425() []testCase5425 {
 				testutil.Metric{
 					Measurement: "syslog",
 					Fields: map[string]interface{}{
-						"version":       uint16(217),
-						"severity_code": 1,
-						"facility_code": 0,
+						"version":       uint64(217),
+						"severity_code": int64(1),
+						"facility_code": int64(0),
 					},
 					Tags: map[string]string{
 						"severity": "alert",
 						"facility": "kern",
 					},
 					Time: defaultTime,
+					Type: telegraf.Untyped,
 				},
 			},
 			werr: 1,
@@ -299,13 +314,13 @@ func getTestCasesForRFC5425() []testCase5425 {
 				testutil.Metric{
 					Measurement: "syslog",
 					Fields: map[string]interface{}{
-						"version":       maxV,
+						"version":       uint64(maxV),
 						"timestamp":     time.Unix(1514764799, 999999000).UnixNano(),
 						"message":       message7681,
 						"procid":        maxPID,
 						"msgid":         maxMID,
-						"facility_code": 23,
-						"severity_code": 7,
+						"facility_code": int64(23),
+						"severity_code": int64(7),
 					},
 					Tags: map[string]string{
 						"severity": "debug",
@@ -314,19 +329,20 @@ func getTestCasesForRFC5425() []testCase5425 {
 						"appname":  maxA,
 					},
 					Time: defaultTime,
+					Type: telegraf.Untyped,
 				},
 			},
 			wantBestEffort: []testutil.Metric{
 				testutil.Metric{
 					Measurement: "syslog",
 					Fields: map[string]interface{}{
-						"version":       maxV,
+						"version":       uint64(maxV),
 						"timestamp":     time.Unix(1514764799, 999999000).UnixNano(),
 						"message":       message7681,
 						"procid":        maxPID,
 						"msgid":         maxMID,
-						"facility_code": 23,
-						"severity_code": 7,
+						"facility_code": int64(23),
+						"severity_code": int64(7),
 					},
 					Tags: map[string]string{
 						"severity": "debug",
@@ -335,6 +351,7 @@ func getTestCasesForRFC5425() []testCase5425 {
 						"appname":  maxA,
 					},
 					Time: defaultTime,
+					Type: telegraf.Untyped,
 				},
 			},
 		},
@@ -518,3 +535,49 @@ func TestStrict_unix_tls(t *testing.T) {
 func TestBestEffort_unix_tls(t *testing.T) {
 	testBestEffortRFC5425(t, "unix", "/tmp/telegraf_test.sock", true, nil)
 }
+
+func TestProxyProtocol_tcp(t *testing.T) {
+	receiver := newTCPSyslogReceiver("tcp://"+address, nil, 0, false)
+	receiver.ProxyProtocol = true
+	receiver.MaxConnectionsPerSource = 1
+	receiver.SourceTag = "source"
+	acc := &testutil.Accumulator{}
+	require.NoError(t, receiver.Start(acc))
+	defer receiver.Stop()
+
+	dial := func(source string) net.Conn {
+		conn, err := net.Dial("tcp", address)
+		require.NoError(t, err)
+		_, err = conn.Write([]byte("PROXY TCP4 " + source + " 192.0.2.2 56324 6514\r\n"))
+		require.NoError(t, err)
+		return conn
+	}
+
+	frame := func(msg string) []byte {
+		return []byte(fmt.Sprintf("%d %s", len(msg), msg))
+	}
+
+	conn := dial("192.0.2.1")
+	defer conn.Close()
+	conn.Write(frame("<1>1 - host app - - - first"))
+	acc.Wait(1)
+
+	// The second connection from the same client is closed
+	second := dial("192.0.2.1")
+	defer second.Close()
+	second.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err := second.Read(make([]byte, 1))
+	require.Error(t, err)
+
+	other := dial("192.0.2.3")
+	defer other.Close()
+	other.Write(frame("<1>1 - host app - - - second"))
+	acc.Wait(2)
+
+	acc.Lock()
+	defer acc.Unlock()
+	require.Equal(t, "192.0.2.1", acc.Metrics[0].Tags["source"])
+	require.Equal(t, "first", acc.Metrics[0].Fields["message"])
+	require.Equal(t, "192.0.2.3", acc.Metrics[1].Tags["source"])
+	require.Equal(t, "second", acc.Metrics[1].Fields["message"])
+}
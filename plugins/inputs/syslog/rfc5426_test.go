@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
 )
@@ -34,30 +35,32 @@ func getTestCasesForRFC5426() []testCase5426 {
 			wantBestEffort: &testutil.Metric{
 				Measurement: "syslog",
 				Fields: map[string]interface{}{
-					"version":       uint16(1),
+					"version":       uint64(1),
 					"message":       "A",
-					"facility_code": 0,
-					"severity_code": 1,
+					"facility_code": int64(0),
+					"severity_code": int64(1),
 				},
 				Tags: map[string]string{
 					"severity": "alert",
 					"facility": "kern",
 				},
 				Time: defaultTime,
+				Type: telegraf.Untyped,
 			},
 			wantStrict: &testutil.Metric{
 				Measurement: "syslog",
 				Fields: map[string]interface{}{
-					"version":       uint16(1),
+					"version":       uint64(1),
 					"message":       "A",
-					"facility_code": 0,
-					"severity_code": 1,
+					"facility_code": int64(0),
+					"severity_code": int64(1),
 				},
 				Tags: map[string]string{
 					"severity": "alert",
 					"facility": "kern",
 				},
 				Time: defaultTime,
+				Type: telegraf.Untyped,
 			},
 		},
 		{
@@ -66,30 +69,32 @@ func getTestCasesForRFC5426() []testCase5426 {
 			wantBestEffort: &testutil.Metric{
 				Measurement: "syslog",
 				Fields: map[string]interface{}{
-					"version":       uint16(3),
+					"version":       uint64(3),
 					"message":       "A<1>4 - - - - - - B",
-					"severity_code": 1,
-					"facility_code": 0,
+					"severity_code": int64(1),
+					"facility_code": int64(0),
 				},
 				Tags: map[string]string{
 					"severity": "alert",
 					"facility": "kern",
 				},
 				Time: defaultTime,
+				Type: telegraf.Untyped,
 			},
 			wantStrict: &testutil.Metric{
 				Measurement: "syslog",
 				Fields: map[string]interface{}{
-					"version":       uint16(3),
+					"version":       uint64(3),
 					"message":       "A<1>4 - - - - - - B",
-					"severity_code": 1,
-					"facility_code": 0,
+					"severity_code": int64(1),
+					"facility_code": int64(0),
 				},
 				Tags: map[string]string{
 					"severity": "alert",
 					"facility": "kern",
 				},
 				Time: defaultTime,
+				Type: telegraf.Untyped,
 			},
 		},
 		{
@@ -98,7 +103,7 @@ func getTestCasesForRFC5426() []testCase5426 {
 			wantBestEffort: &testutil.Metric{
 				Measurement: "syslog",
 				Fields: map[string]interface{}{
-					"version":       uint16(1),
+					"version":       uint64(1),
 					"timestamp":     time.Unix(1456029177, 0).UnixNano(),
 					"procid":        "2341",
 					"msgid":         "2",
@@ -106,8 +111,8 @@ func getTestCasesForRFC5426() []testCase5426 {
 					"origin":        true,
 					"meta_sequence": "14125553",
 					"meta_service":  "someservice",
-					"severity_code": 5,
-					"facility_code": 3,
+					"severity_code": int64(5),
+					"facility_code": int64(3),
 				},
 				Tags: map[string]string{
 					"severity": "notice",
@@ -116,11 +121,12 @@ func getTestCasesForRFC5426() []testCase5426 {
 					"appname":  "someservice",
 				},
 				Time: defaultTime,
+				Type: telegraf.Untyped,
 			},
 			wantStrict: &testutil.Metric{
 				Measurement: "syslog",
 				Fields: map[string]interface{}{
-					"version":       uint16(1),
+					"version":       uint64(1),
 					"timestamp":     time.Unix(1456029177, 0).UnixNano(),
 					"procid":        "2341",
 					"msgid":         "2",
@@ -128,8 +134,8 @@ func getTestCasesForRFC5426() []testCase5426 {
 					"origin":        true,
 					"meta_sequence": "14125553",
 					"meta_service":  "someservice",
-					"severity_code": 5,
-					"facility_code": 3,
+					"severity_code": int64(5),
+					"facility_code": int64(3),
 				},
 				Tags: map[string]string{
 					"severity": "notice",
@@ -138,6 +144,7 @@ func getTestCasesForRFC5426() []testCase5426 {
 					"appname":  "someservice",
 				},
 				Time: defaultTime,
+				Type: telegraf.Untyped,
 			},
 		},
 		{
@@ -146,13 +153,13 @@ func getTestCasesForRFC5426() []testCase5426 {
 			wantBestEffort: &testutil.Metric{
 				Measurement: "syslog",
 				Fields: map[string]interface{}{
-					"version":       maxV,
+					"version":       uint64(maxV),
 					"timestamp":     time.Unix(1514764799, 999999000).UnixNano(),
 					"message":       message7681,
 					"procid":        maxPID,
 					"msgid":         maxMID,
-					"severity_code": 7,
-					"facility_code": 23,
+					"severity_code": int64(7),
+					"facility_code": int64(23),
 				},
 				Tags: map[string]string{
 					"severity": "debug",
@@ -161,17 +168,18 @@ func getTestCasesForRFC5426() []testCase5426 {
 					"appname":  maxA,
 				},
 				Time: defaultTime,
+				Type: telegraf.Untyped,
 			},
 			wantStrict: &testutil.Metric{
 				Measurement: "syslog",
 				Fields: map[string]interface{}{
-					"version":       maxV,
+					"version":       uint64(maxV),
 					"timestamp":     time.Unix(1514764799, 999999000).UnixNano(),
 					"message":       message7681,
 					"procid":        maxPID,
 					"msgid":         maxMID,
-					"severity_code": 7,
-					"facility_code": 23,
+					"severity_code": int64(7),
+					"facility_code": int64(23),
 				},
 				Tags: map[string]string{
 					"severity": "debug",
@@ -180,6 +188,7 @@ func getTestCasesForRFC5426() []testCase5426 {
 					"appname":  maxA,
 				},
 				Time: defaultTime,
+				Type: telegraf.Untyped,
 			},
 		},
 		{
@@ -188,15 +197,16 @@ func getTestCasesForRFC5426() []testCase5426 {
 			wantBestEffort: &testutil.Metric{
 				Measurement: "syslog",
 				Fields: map[string]interface{}{
-					"version":       uint16(2),
-					"facility_code": 0,
-					"severity_code": 1,
+					"version":       uint64(2),
+					"facility_code": int64(0),
+					"severity_code": int64(1),
 				},
 				Tags: map[string]string{
 					"severity": "alert",
 					"facility": "kern",
 				},
 				Time: defaultTime,
+				Type: telegraf.Untyped,
 			},
 			werr: true,
 		},
@@ -322,15 +332,16 @@ func TestTimeIncrement_udp(t *testing.T) {
 	want := &testutil.Metric{
 		Measurement: "syslog",
 		Fields: map[string]interface{}{
-			"version":       uint16(1),
-			"facility_code": 0,
-			"severity_code": 1,
+			"version":       uint64(1),
+			"facility_code": int64(0),
+			"severity_code": int64(1),
 		},
 		Tags: map[string]string{
 			"severity": "alert",
 			"facility": "kern",
 		},
 		Time: getNow(),
+		Type: telegraf.Untyped,
 	}
 
 	if !cmp.Equal(want, acc.Metrics[0]) {
@@ -353,15 +364,16 @@ func TestTimeIncrement_udp(t *testing.T) {
 	want = &testutil.Metric{
 		Measurement: "syslog",
 		Fields: map[string]interface{}{
-			"version":       uint16(1),
-			"facility_code": 0,
-			"severity_code": 1,
+			"version":       uint64(1),
+			"facility_code": int64(0),
+			"severity_code": int64(1),
 		},
 		Tags: map[string]string{
 			"severity": "alert",
 			"facility": "kern",
 		},
 		Time: getNow(),
+		Type: telegraf.Untyped,
 	}
 
 	if !cmp.Equal(want, acc.Metrics[0]) {
@@ -383,15 +395,16 @@ func TestTimeIncrement_udp(t *testing.T) {
 	want = &testutil.Metric{
 		Measurement: "syslog",
 		Fields: map[string]interface{}{
-			"version":       uint16(1),
-			"facility_code": 0,
-			"severity_code": 1,
+			"version":       uint64(1),
+			"facility_code": int64(0),
+			"severity_code": int64(1),
 		},
 		Tags: map[string]string{
 			"severity": "alert",
 			"facility": "kern",
 		},
 		Time: getNow().Add(time.Nanosecond),
+		Type: telegraf.Untyped,
 	}
 
 	if !cmp.Equal(want, acc.Metrics[0]) {
@@ -4,6 +4,7 @@ import (
 	"crypto/tls"
 	"fmt"
 	"io"
+	"log"
 	"net"
 	"net/url"
 	"os"
@@ -15,7 +16,9 @@ import (
 	"github.com/influxdata/go-syslog/rfc5425"
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/proxyproto"
 	tlsConfig "github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/metric"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -32,6 +35,10 @@ type Syslog struct {
 	BestEffort      bool
 	Separator       string `toml:"sdparam_separator"`
 
+	ProxyProtocol           bool
+	MaxConnectionsPerSource int
+	SourceTag               string
+
 	now      func() time.Time
 	lastTime time.Time
 
@@ -43,6 +50,7 @@ type Syslog struct {
 	tcpListener   net.Listener
 	tlsConfig     *tls.Config
 	connections   map[string]net.Conn
+	sources       *proxyproto.SourceCounter
 	connectionsMu sync.Mutex
 
 	udpListener net.PacketConn
@@ -59,6 +67,15 @@ var sampleConfig = `
   # tls_allowed_cacerts = ["/etc/telegraf/ca.pem"]
   # tls_cert = "/etc/telegraf/cert.pem"
   # tls_key = "/etc/telegraf/key.pem"
+  ## Passphrase of an encrypted tls_key, or of the tls_pkcs12 bundle.
+  # tls_key_pwd = ""
+  ## PKCS#12 bundle of the certificate and key, instead of tls_cert and tls_key.
+  # tls_pkcs12 = "/etc/telegraf/cert.p12"
+  ## Minimum and maximum TLS versions accepted, one of TLS10, TLS11, TLS12
+  ## or TLS13, and the cipher suites accepted up to TLS 1.2.
+  # tls_min_version = "TLS12"
+  # tls_max_version = "TLS13"
+  # tls_cipher_suites = ["TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384", "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384"]
 
   ## Period between keep alive probes.
   ## 0 disables keep alive probes.
@@ -71,6 +88,22 @@ var sampleConfig = `
   ## Only applies to stream sockets (e.g. TCP).
   # max_connections = 1024
 
+  ## Maximum number of concurrent connections from a single source address
+  ## (default = 0).
+  ## 0 means unlimited.
+  ## Only applies to stream sockets (e.g. TCP).
+  # max_connections_per_source = 0
+
+  ## Read the PROXY protocol v1 or v2 header sent by the load balancer at the
+  ## start of the connections, so that the source address is the one of the
+  ## original client (default = false).
+  ## The connections without the header are closed.
+  ## Only applies to stream sockets (e.g. TCP).
+  # proxy_protocol = false
+
+  ## Name of a tag holding the source address of the messages.
+  # source_tag = ""
+
   ## Read timeout (default = 500ms).
   ## 0 means unlimited.
   # read_timeout = 500ms
@@ -204,7 +237,7 @@ func (s *Syslog) listenPacket(acc telegraf.Accumulator) {
 	b := make([]byte, ipMaxPacketSize)
 	p := rfc5424.NewParser()
 	for {
-		n, _, err := s.udpListener.ReadFrom(b)
+		n, addr, err := s.udpListener.ReadFrom(b)
 		if err != nil {
 			if !strings.HasSuffix(err.Error(), ": use of closed network connection") {
 				acc.AddError(err)
@@ -218,7 +251,7 @@ func (s *Syslog) listenPacket(acc telegraf.Accumulator) {
 
 		message, err := p.Parse(b[:n], &s.BestEffort)
 		if message != nil {
-			acc.AddFields("syslog", fields(*message, s), tags(*message), s.time())
+			acc.AddMetric(s.metric(*message, proxyproto.SourceAddress(addr)))
 		}
 		if err != nil {
 			acc.AddError(err)
@@ -230,6 +263,7 @@ func (s *Syslog) listenStream(acc telegraf.Accumulator) {
 	defer s.wg.Done()
 
 	s.connections = map[string]net.Conn{}
+	s.sources = proxyproto.NewSourceCounter(s.MaxConnectionsPerSource)
 
 	for {
 		conn, err := s.tcpListener.Accept()
@@ -240,6 +274,14 @@ func (s *Syslog) listenStream(acc telegraf.Accumulator) {
 			break
 		}
 		var tcpConn, _ = conn.(*net.TCPConn)
+		// The connections are keyed by the address of the peer, which is the
+		// one of the proxy with the PROXY protocol
+		id := conn.RemoteAddr().String()
+		var pc *proxyproto.Conn
+		if s.ProxyProtocol {
+			pc = proxyproto.NewConn(conn, 0)
+			conn = pc
+		}
 		if s.tlsConfig != nil {
 			conn = tls.Server(conn, s.tlsConfig)
 		}
@@ -250,14 +292,14 @@ func (s *Syslog) listenStream(acc telegraf.Accumulator) {
 			conn.Close()
 			continue
 		}
-		s.connections[conn.RemoteAddr().String()] = conn
+		s.connections[id] = conn
 		s.connectionsMu.Unlock()
 
 		if err := s.setKeepAlive(tcpConn); err != nil {
 			acc.AddError(fmt.Errorf("unable to configure keep alive (%s): %s", s.Address, err))
 		}
 
-		go s.handle(conn, acc)
+		go s.handle(conn, pc, id, acc)
 	}
 
 	s.connectionsMu.Lock()
@@ -267,18 +309,34 @@ func (s *Syslog) listenStream(acc telegraf.Accumulator) {
 	s.connectionsMu.Unlock()
 }
 
-func (s *Syslog) removeConnection(c net.Conn) {
+func (s *Syslog) removeConnection(id string) {
 	s.connectionsMu.Lock()
-	delete(s.connections, c.RemoteAddr().String())
+	delete(s.connections, id)
 	s.connectionsMu.Unlock()
 }
 
-func (s *Syslog) handle(conn net.Conn, acc telegraf.Accumulator) {
+// handle parses the messages of the connection, pc is the PROXY protocol
+// connection wrapped by the connection when enabled.
+func (s *Syslog) handle(conn net.Conn, pc *proxyproto.Conn, id string, acc telegraf.Accumulator) {
 	defer func() {
-		s.removeConnection(conn)
+		s.removeConnection(id)
 		conn.Close()
 	}()
 
+	if pc != nil {
+		if err := pc.ReadHeader(); err != nil {
+			acc.AddError(fmt.Errorf("unable to read PROXY protocol header from %s: %s", id, err))
+			return
+		}
+	}
+
+	source := proxyproto.SourceAddress(conn.RemoteAddr())
+	if !s.sources.Add(source) {
+		log.Printf("W! [inputs.syslog] Maximum connections reached for source %s", source)
+		return
+	}
+	defer s.sources.Remove(source)
+
 	if s.ReadTimeout != nil && s.ReadTimeout.Duration > 0 {
 		conn.SetReadDeadline(time.Now().Add(s.ReadTimeout.Duration))
 	}
@@ -291,7 +349,7 @@ func (s *Syslog) handle(conn net.Conn, acc telegraf.Accumulator) {
 	}
 
 	p.ParseExecuting(func(r *rfc5425.Result) {
-		s.store(*r, acc)
+		s.store(*r, source, acc)
 	})
 }
 
@@ -309,7 +367,7 @@ func (s *Syslog) setKeepAlive(c *net.TCPConn) error {
 	return c.SetKeepAlivePeriod(s.KeepAlivePeriod.Duration)
 }
 
-func (s *Syslog) store(res rfc5425.Result, acc telegraf.Accumulator) {
+func (s *Syslog) store(res rfc5425.Result, source string, acc telegraf.Accumulator) {
 	if res.Error != nil {
 		acc.AddError(res.Error)
 	}
@@ -318,67 +376,70 @@ func (s *Syslog) store(res rfc5425.Result, acc telegraf.Accumulator) {
 	}
 	if res.Message != nil {
 		msg := *res.Message
-		acc.AddFields("syslog", fields(msg, s), tags(msg), s.time())
+		acc.AddMetric(s.metric(msg, source))
 	}
 }
 
-func tags(msg rfc5424.SyslogMessage) map[string]string {
-	ts := map[string]string{}
+// metric returns the metric of the message, made with a pooled builder as
+// the metrics of the busy servers are made at a high rate.
+func (s *Syslog) metric(msg rfc5424.SyslogMessage, source string) telegraf.Metric {
+	b := metric.GetBuilder()
+	defer metric.PutBuilder(b)
+	b.SetName("syslog")
+	b.SetTime(s.time())
 
 	// Not checking assuming a minimally valid message
-	ts["severity"] = *msg.SeverityShortLevel()
-	ts["facility"] = *msg.FacilityLevel()
+	b.AddTag("severity", *msg.SeverityShortLevel())
+	b.AddTag("facility", *msg.FacilityLevel())
 
 	if msg.Hostname() != nil {
-		ts["hostname"] = *msg.Hostname()
+		b.AddTag("hostname", *msg.Hostname())
 	}
 
 	if msg.Appname() != nil {
-		ts["appname"] = *msg.Appname()
+		b.AddTag("appname", *msg.Appname())
 	}
 
-	return ts
-}
-
-func fields(msg rfc5424.SyslogMessage, s *Syslog) map[string]interface{} {
-	// Not checking assuming a minimally valid message
-	flds := map[string]interface{}{
-		"version": msg.Version(),
+	if s.SourceTag != "" && source != "" {
+		b.AddTag(s.SourceTag, source)
 	}
-	flds["severity_code"] = int(*msg.Severity())
-	flds["facility_code"] = int(*msg.Facility())
+
+	b.AddField("version", msg.Version())
+	b.AddField("severity_code", int(*msg.Severity()))
+	b.AddField("facility_code", int(*msg.Facility()))
 
 	if msg.Timestamp() != nil {
-		flds["timestamp"] = (*msg.Timestamp()).UnixNano()
+		b.AddField("timestamp", (*msg.Timestamp()).UnixNano())
 	}
 
 	if msg.ProcID() != nil {
-		flds["procid"] = *msg.ProcID()
+		b.AddField("procid", *msg.ProcID())
 	}
 
 	if msg.MsgID() != nil {
-		flds["msgid"] = *msg.MsgID()
+		b.AddField("msgid", *msg.MsgID())
 	}
 
 	if msg.Message() != nil {
-		flds["message"] = *msg.Message()
+		b.AddField("message", *msg.Message())
 	}
 
 	if msg.StructuredData() != nil {
 		for sdid, sdparams := range *msg.StructuredData() {
 			if len(sdparams) == 0 {
 				// When SD-ID does not have params we indicate its presence with a bool
-				flds[sdid] = true
+				b.AddField(sdid, true)
 				continue
 			}
 			for name, value := range sdparams {
 				// Using whitespace as separator since it is not allowed by the grammar within SDID
-				flds[sdid+s.Separator+name] = value
+				b.AddField(sdid+s.Separator+name, value)
 			}
 		}
 	}
 
-	return flds
+	m, _ := b.Metric()
+	return m
 }
 
 type unixCloser struct {
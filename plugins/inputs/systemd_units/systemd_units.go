@@ -0,0 +1,414 @@
+// +build linux
+
+package systemd_units
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/godbus/dbus"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const (
+	systemdDest       = "org.freedesktop.systemd1"
+	systemdPath       = dbus.ObjectPath("/org/freedesktop/systemd1")
+	managerInterface  = "org.freedesktop.systemd1.Manager"
+	serviceInterface  = "org.freedesktop.systemd1.Service"
+	unitTypeInterface = "org.freedesktop.systemd1."
+)
+
+// UnitStatus is a single entry as returned by the ListUnits D-Bus method.
+type UnitStatus struct {
+	Name        string
+	Description string
+	LoadState   string
+	ActiveState string
+	SubState    string
+	Followed    string
+	Path        dbus.ObjectPath
+	JobID       uint32
+	JobType     string
+	JobPath     dbus.ObjectPath
+}
+
+// UnitLister is the subset of the systemd D-Bus API used by the plugin, it
+// exists so that it can be replaced during tests.
+type UnitLister interface {
+	ListUnits() ([]UnitStatus, error)
+	GetProperty(path dbus.ObjectPath, iface, property string) (interface{}, error)
+	Close() error
+}
+
+// Connector opens a new UnitLister.
+type Connector func(timeout time.Duration) (UnitLister, error)
+
+type SystemdUnits struct {
+	Units              []string
+	ResourceAccounting bool `toml:"resource_accounting"`
+	Timeout            internal.Duration
+
+	connect Connector
+	filter  filter.Filter
+}
+
+var sampleConfig = `
+  ## Units to monitor, glob patterns are supported.  When empty all units
+  ## loaded by systemd are reported.
+  # units = ["*.service", "*.socket"]
+
+  ## Report CPU and memory usage for each unit.  This requires that
+  ## CPUAccounting and MemoryAccounting are enabled for the units.
+  # resource_accounting = false
+
+  ## Timeout for D-Bus calls
+  # timeout = "5s"
+`
+
+// Numeric codes for the load and active states, these are ordered as they
+// are declared by systemd in src/basic/unit-def.h.
+var loadMap = map[string]int{
+	"loaded":      0,
+	"stub":        1,
+	"not-found":   2,
+	"bad-setting": 3,
+	"error":       4,
+	"merged":      5,
+	"masked":      6,
+}
+
+var activeMap = map[string]int{
+	"active":       0,
+	"reloading":    1,
+	"inactive":     2,
+	"failed":       3,
+	"activating":   4,
+	"deactivating": 5,
+}
+
+// Sub states are specific to the unit type, each type is given its own range
+// of codes, including for the sub states shared between the types.  The codes
+// of the service sub states are ordered as declared by systemd in
+// service_state_table of src/basic/unit-def.c.
+var subMap = map[string]map[string]int{
+	"service": {
+		"dead":                       0x0000,
+		"condition":                  0x0001,
+		"start-pre":                  0x0002,
+		"start":                      0x0003,
+		"start-post":                 0x0004,
+		"running":                    0x0005,
+		"exited":                     0x0006,
+		"reload":                     0x0007,
+		"reload-signal":              0x0008,
+		"reload-notify":              0x0009,
+		"stop":                       0x000a,
+		"stop-watchdog":              0x000b,
+		"stop-sigterm":               0x000c,
+		"stop-sigkill":               0x000d,
+		"stop-post":                  0x000e,
+		"final-watchdog":             0x000f,
+		"final-sigterm":              0x0010,
+		"final-sigkill":              0x0011,
+		"failed":                     0x0012,
+		"dead-before-auto-restart":   0x0013,
+		"failed-before-auto-restart": 0x0014,
+		"dead-resources-pinned":      0x0015,
+		"auto-restart":               0x0016,
+		"auto-restart-queued":        0x0017,
+		"cleaning":                   0x0018,
+	},
+	"automount": {
+		"waiting": 0x0100,
+		"dead":    0x0101,
+		"running": 0x0102,
+		"failed":  0x0103,
+	},
+	"device": {
+		"tentative": 0x0200,
+		"plugged":   0x0201,
+		"dead":      0x0202,
+	},
+	"mount": {
+		"mounting":           0x0300,
+		"mounting-done":      0x0301,
+		"mounted":            0x0302,
+		"remounting":         0x0303,
+		"unmounting":         0x0304,
+		"remounting-sigterm": 0x0305,
+		"remounting-sigkill": 0x0306,
+		"unmounting-sigterm": 0x0307,
+		"unmounting-sigkill": 0x0308,
+		"dead":               0x0309,
+		"failed":             0x030a,
+		"cleaning":           0x030b,
+	},
+	"path": {
+		"waiting": 0x0400,
+		"dead":    0x0401,
+		"running": 0x0402,
+		"failed":  0x0403,
+	},
+	"scope": {
+		"abandoned":    0x0500,
+		"dead":         0x0501,
+		"running":      0x0502,
+		"stop-sigterm": 0x0503,
+		"stop-sigkill": 0x0504,
+		"failed":       0x0505,
+	},
+	"socket": {
+		"start-chown":      0x0600,
+		"start-post":       0x0601,
+		"listening":        0x0602,
+		"stop-pre":         0x0603,
+		"stop-pre-sigterm": 0x0604,
+		"stop-pre-sigkill": 0x0605,
+		"final-sigkill":    0x0606,
+		"dead":             0x0607,
+		"start-pre":        0x0608,
+		"running":          0x0609,
+		"stop-post":        0x060a,
+		"final-sigterm":    0x060b,
+		"failed":           0x060c,
+		"cleaning":         0x060d,
+	},
+	"swap": {
+		"activating":           0x0700,
+		"activating-done":      0x0701,
+		"deactivating":         0x0702,
+		"dead":                 0x0703,
+		"active":               0x0704,
+		"deactivating-sigterm": 0x0705,
+		"deactivating-sigkill": 0x0706,
+		"failed":               0x0707,
+		"cleaning":             0x0708,
+	},
+	"target": {
+		"active": 0x0800,
+		"dead":   0x0801,
+	},
+	"timer": {
+		"elapsed": 0x0900,
+		"dead":    0x0901,
+		"waiting": 0x0902,
+		"running": 0x0903,
+		"failed":  0x0904,
+	},
+	"slice": {
+		"active": 0x0a00,
+		"dead":   0x0a01,
+	},
+}
+
+func (s *SystemdUnits) Description() string {
+	return "Gather systemd units state, restart count and resource usage over D-Bus"
+}
+
+func (s *SystemdUnits) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *SystemdUnits) Gather(acc telegraf.Accumulator) error {
+	if s.filter == nil && len(s.Units) > 0 {
+		f, err := filter.Compile(s.Units)
+		if err != nil {
+			return fmt.Errorf("could not compile units filter: %s", err)
+		}
+		s.filter = f
+	}
+
+	lister, err := s.connect(s.Timeout.Duration)
+	if err != nil {
+		return fmt.Errorf("could not connect to systemd: %s", err)
+	}
+	defer lister.Close()
+
+	units, err := lister.ListUnits()
+	if err != nil {
+		return fmt.Errorf("could not list units: %s", err)
+	}
+
+	for _, unit := range units {
+		if s.filter != nil && !s.filter.Match(unit.Name) {
+			continue
+		}
+
+		tags := map[string]string{
+			"name":   unit.Name,
+			"load":   unit.LoadState,
+			"active": unit.ActiveState,
+			"sub":    unit.SubState,
+		}
+
+		fields := make(map[string]interface{})
+		if code, ok := loadMap[unit.LoadState]; ok {
+			fields["load_code"] = code
+		}
+		if code, ok := activeMap[unit.ActiveState]; ok {
+			fields["active_code"] = code
+		}
+		if code, ok := subMap[unitType(unit.Name)][unit.SubState]; ok {
+			fields["sub_code"] = code
+		}
+
+		// Units which are not loaded have no meaningful properties.
+		if unit.LoadState == "loaded" {
+			s.gatherProperties(acc, lister, unit, fields)
+		}
+
+		acc.AddFields("systemd_units", fields, tags)
+	}
+
+	return nil
+}
+
+func (s *SystemdUnits) gatherProperties(
+	acc telegraf.Accumulator,
+	lister UnitLister,
+	unit UnitStatus,
+	fields map[string]interface{},
+) {
+	unitType := unitType(unit.Name)
+
+	// NRestarts is only available since systemd v235, missing properties
+	// are silently ignored.
+	if unitType == "service" {
+		v, err := lister.GetProperty(unit.Path, serviceInterface, "NRestarts")
+		if err == nil {
+			if restarts, ok := v.(uint32); ok {
+				fields["restarts"] = int64(restarts)
+			}
+		}
+	}
+
+	if !s.ResourceAccounting {
+		return
+	}
+
+	switch unitType {
+	case "service", "slice", "scope", "socket", "mount", "swap":
+	default:
+		return
+	}
+
+	iface := unitTypeInterface + capitalize(unitType)
+	for property, field := range map[string]string{
+		"CPUUsageNSec":  "cpu_usage_nsec",
+		"MemoryCurrent": "memory_current",
+		"TasksCurrent":  "tasks_current",
+	} {
+		v, err := lister.GetProperty(unit.Path, iface, property)
+		if err != nil {
+			acc.AddError(fmt.Errorf("could not get %s of %s: %s", property, unit.Name, err))
+			continue
+		}
+		value, ok := v.(uint64)
+		// systemd reports (uint64)-1 when accounting is disabled.
+		if !ok || value == ^uint64(0) {
+			continue
+		}
+		fields[field] = value
+	}
+}
+
+// unitType returns the suffix of the unit name, ie: "service" for
+// "sshd.service".
+func unitType(name string) string {
+	for i := len(name) - 1; i >= 0; i-- {
+		if name[i] == '.' {
+			return name[i+1:]
+		}
+	}
+	return ""
+}
+
+func capitalize(s string) string {
+	if len(s) == 0 {
+		return s
+	}
+	b := []byte(s)
+	if b[0] >= 'a' && b[0] <= 'z' {
+		b[0] -= 'a' - 'A'
+	}
+	return string(b)
+}
+
+// dbusLister implements UnitLister using a private system bus connection.
+type dbusLister struct {
+	conn    *dbus.Conn
+	timeout time.Duration
+}
+
+func connectDbus(timeout time.Duration) (UnitLister, error) {
+	conn, err := dbus.SystemBusPrivate()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = conn.Auth(nil); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if err = conn.Hello(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return &dbusLister{conn: conn, timeout: timeout}, nil
+}
+
+func (d *dbusLister) call(path dbus.ObjectPath, method string, args ...interface{}) (*dbus.Call, error) {
+	ch := make(chan *dbus.Call, 1)
+	d.conn.Object(systemdDest, path).Go(method, 0, ch, args...)
+
+	select {
+	case call := <-ch:
+		return call, call.Err
+	case <-time.After(d.timeout):
+		return nil, fmt.Errorf("timeout calling %s", method)
+	}
+}
+
+func (d *dbusLister) ListUnits() ([]UnitStatus, error) {
+	call, err := d.call(systemdPath, managerInterface+".ListUnits")
+	if err != nil {
+		return nil, err
+	}
+
+	var units []UnitStatus
+	if err := call.Store(&units); err != nil {
+		return nil, err
+	}
+	return units, nil
+}
+
+func (d *dbusLister) GetProperty(path dbus.ObjectPath, iface, property string) (interface{}, error) {
+	call, err := d.call(path, "org.freedesktop.DBus.Properties.Get", iface, property)
+	if err != nil {
+		return nil, err
+	}
+
+	var v dbus.Variant
+	if err := call.Store(&v); err != nil {
+		return nil, err
+	}
+	return v.Value(), nil
+}
+
+func (d *dbusLister) Close() error {
+	return d.conn.Close()
+}
+
+func init() {
+	inputs.Add("systemd_units", func() telegraf.Input {
+		return &SystemdUnits{
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+			connect: connectDbus,
+		}
+	})
+}
@@ -0,0 +1,197 @@
+// +build linux
+
+package systemd_units
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type property struct {
+	path  dbus.ObjectPath
+	iface string
+	name  string
+}
+
+type fakeLister struct {
+	units      []UnitStatus
+	listErr    error
+	properties map[property]interface{}
+	closed     bool
+}
+
+func (f *fakeLister) ListUnits() ([]UnitStatus, error) {
+	return f.units, f.listErr
+}
+
+func (f *fakeLister) GetProperty(path dbus.ObjectPath, iface, name string) (interface{}, error) {
+	v, ok := f.properties[property{path, iface, name}]
+	if !ok {
+		return nil, errors.New("unknown property")
+	}
+	return v, nil
+}
+
+func (f *fakeLister) Close() error {
+	f.closed = true
+	return nil
+}
+
+func newSystemdUnits(lister *fakeLister) *SystemdUnits {
+	return &SystemdUnits{
+		connect: func(time.Duration) (UnitLister, error) {
+			return lister, nil
+		},
+	}
+}
+
+var testUnits = []UnitStatus{
+	{
+		Name:        "sshd.service",
+		LoadState:   "loaded",
+		ActiveState: "active",
+		SubState:    "running",
+		Path:        "/org/freedesktop/systemd1/unit/sshd_2eservice",
+	},
+	{
+		Name:        "cron.service",
+		LoadState:   "loaded",
+		ActiveState: "failed",
+		SubState:    "failed",
+		Path:        "/org/freedesktop/systemd1/unit/cron_2eservice",
+	},
+	{
+		Name:        "missing.mount",
+		LoadState:   "not-found",
+		ActiveState: "inactive",
+		SubState:    "dead",
+		Path:        "/org/freedesktop/systemd1/unit/missing_2emount",
+	},
+}
+
+func TestGather(t *testing.T) {
+	lister := &fakeLister{
+		units: testUnits,
+		properties: map[property]interface{}{
+			{"/org/freedesktop/systemd1/unit/sshd_2eservice", serviceInterface, "NRestarts"}: uint32(2),
+			{"/org/freedesktop/systemd1/unit/cron_2eservice", serviceInterface, "NRestarts"}: uint32(0),
+		},
+	}
+
+	var acc testutil.Accumulator
+	s := newSystemdUnits(lister)
+	require.NoError(t, acc.GatherError(s.Gather))
+	require.True(t, lister.closed)
+
+	acc.AssertContainsTaggedFields(t, "systemd_units",
+		map[string]interface{}{
+			"load_code":   0,
+			"active_code": 0,
+			"sub_code":    0x0005,
+			"restarts":    int64(2),
+		},
+		map[string]string{
+			"name":   "sshd.service",
+			"load":   "loaded",
+			"active": "active",
+			"sub":    "running",
+		})
+
+	acc.AssertContainsTaggedFields(t, "systemd_units",
+		map[string]interface{}{
+			"load_code":   0,
+			"active_code": 3,
+			"sub_code":    0x0012,
+			"restarts":    int64(0),
+		},
+		map[string]string{
+			"name":   "cron.service",
+			"load":   "loaded",
+			"active": "failed",
+			"sub":    "failed",
+		})
+
+	acc.AssertContainsTaggedFields(t, "systemd_units",
+		map[string]interface{}{
+			"load_code":   2,
+			"active_code": 2,
+			"sub_code":    0x0309,
+		},
+		map[string]string{
+			"name":   "missing.mount",
+			"load":   "not-found",
+			"active": "inactive",
+			"sub":    "dead",
+		})
+}
+
+func TestGatherUnitFilter(t *testing.T) {
+	lister := &fakeLister{units: testUnits}
+
+	var acc testutil.Accumulator
+	s := newSystemdUnits(lister)
+	s.Units = []string{"*.mount"}
+	require.NoError(t, acc.GatherError(s.Gather))
+
+	require.Len(t, acc.Metrics, 1)
+	require.Equal(t, "missing.mount", acc.TagValue("systemd_units", "name"))
+}
+
+func TestGatherResourceAccounting(t *testing.T) {
+	path := dbus.ObjectPath("/org/freedesktop/systemd1/unit/sshd_2eservice")
+	lister := &fakeLister{
+		units: testUnits[:1],
+		properties: map[property]interface{}{
+			{path, serviceInterface, "NRestarts"}:     uint32(0),
+			{path, serviceInterface, "CPUUsageNSec"}:  uint64(1234567),
+			{path, serviceInterface, "MemoryCurrent"}: uint64(4096),
+			// accounting disabled
+			{path, serviceInterface, "TasksCurrent"}: ^uint64(0),
+		},
+	}
+
+	var acc testutil.Accumulator
+	s := newSystemdUnits(lister)
+	s.ResourceAccounting = true
+	require.NoError(t, acc.GatherError(s.Gather))
+
+	acc.AssertContainsFields(t, "systemd_units",
+		map[string]interface{}{
+			"load_code":      0,
+			"active_code":    0,
+			"sub_code":       0x0005,
+			"restarts":       int64(0),
+			"cpu_usage_nsec": uint64(1234567),
+			"memory_current": uint64(4096),
+		})
+}
+
+func TestGatherListError(t *testing.T) {
+	lister := &fakeLister{listErr: errors.New("access denied")}
+
+	var acc testutil.Accumulator
+	s := newSystemdUnits(lister)
+	require.Error(t, acc.GatherError(s.Gather))
+	require.True(t, lister.closed)
+}
+
+func TestSubCodes(t *testing.T) {
+	require.Equal(t, 0x0004, subMap["service"]["start-post"])
+	require.Equal(t, 0x000f, subMap["service"]["final-watchdog"])
+	require.Equal(t, 0x0011, subMap["service"]["final-sigkill"])
+
+	// Every sub state of every unit type has its own code
+	seen := make(map[int]string)
+	for typ, states := range subMap {
+		for state, code := range states {
+			name := typ + "/" + state
+			require.Empty(t, seen[code], "%s has the code of %s", name, seen[code])
+			seen[code] = name
+		}
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"crypto/tls"
 	"fmt"
+	"io"
 	"net"
 	"os/exec"
 	"strconv"
@@ -14,6 +16,7 @@ import (
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/filter"
 	"github.com/influxdata/telegraf/internal"
+	tlsint "github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
@@ -21,11 +24,15 @@ type runner func(cmdName string, Timeout internal.Duration, UseSudo bool, Server
 
 // Unbound is used to store configuration values
 type Unbound struct {
-	Binary      string
-	Timeout     internal.Duration
-	UseSudo     bool
-	Server      string
-	ThreadAsTag bool
+	Binary        string
+	Timeout       internal.Duration
+	UseSudo       bool
+	Server        string
+	ThreadAsTag   bool
+	GatherThreads bool `toml:"gather_threads"`
+	Histogram     bool `toml:"histogram"`
+	RemoteControl bool `toml:"remote_control"`
+	tlsint.ClientConfig
 
 	filter filter.Filter
 	run    runner
@@ -54,6 +61,24 @@ var sampleConfig = `
   ## true in a future version.  It is recommended to set to true on new
   ## deployments.
   thread_as_tag = false
+
+  ## Gather the statistics of each thread, in addition to the totals.  Set to
+  ## false to only report the totals on servers with many threads.
+  # gather_threads = true
+
+  ## Gather the histogram of the recursion times in the unbound_histogram
+  ## measurement.
+  # histogram = false
+
+  ## Connect directly to the remote control port of the server instead of
+  ## running unbound-control; server can also be the path of the unix socket
+  ## set in control-interface.  The TLS files are the control-cert-file,
+  ## control-key-file and server-cert-file of the server; without them the
+  ## connection is not encrypted, as with control-use-cert set to no.
+  # remote_control = false
+  # tls_ca = "/etc/unbound/unbound_server.pem"
+  # tls_cert = "/etc/unbound/unbound_control.pem"
+  # tls_key = "/etc/unbound/unbound_control.key"
 `
 
 // Description displays what this plugin is about
@@ -113,9 +138,62 @@ func unboundRunner(cmdName string, Timeout internal.Duration, UseSudo bool, Serv
 	return &out, nil
 }
 
+// remoteControl sends the stats_noreset command to the remote control port
+// of the server, as unbound-control does, and returns the output.
+func (s *Unbound) remoteControl() (*bytes.Buffer, error) {
+	server := s.Server
+	if server == "" {
+		server = "127.0.0.1:8953"
+	}
+
+	network := "tcp"
+	if strings.HasPrefix(server, "/") {
+		network = "unix"
+	} else if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "8953")
+	}
+
+	tlsConfig, err := s.ClientConfig.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := net.Dialer{Timeout: s.Timeout.Duration}
+	var conn net.Conn
+	if tlsConfig != nil {
+		// The certificate generated by unbound-control-setup is for the
+		// name unbound.
+		tlsConfig.ServerName = "unbound"
+		conn, err = tls.DialWithDialer(&dialer, network, server, tlsConfig)
+	} else {
+		conn, err = dialer.Dial(network, server)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error connecting to remote control %s: %s", server, err)
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(s.Timeout.Duration))
+
+	if _, err := io.WriteString(conn, "UBCT1 stats_noreset\n"); err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if _, err := io.Copy(&out, conn); err != nil {
+		return &out, fmt.Errorf("error reading from remote control %s: %s", server, err)
+	}
+
+	if strings.HasPrefix(out.String(), "error") {
+		return &out, fmt.Errorf("remote control %s: %s", server, strings.TrimSpace(out.String()))
+	}
+	return &out, nil
+}
+
 // Gather collects stats from unbound-control and adds them to the Accumulator
 //
-// All the dots in stat name will replaced by underscores. Histogram statistics will not be collected.
+// All the dots in stat name will replaced by underscores. Histogram statistics
+// are only collected, in their own measurement, when enabled.
 func (s *Unbound) Gather(acc telegraf.Accumulator) error {
 
 	// Always exclude histrogram statistics
@@ -125,7 +203,12 @@ func (s *Unbound) Gather(acc telegraf.Accumulator) error {
 		return err
 	}
 
-	out, err := s.run(s.Binary, s.Timeout, s.UseSudo, s.Server, s.ThreadAsTag)
+	var out *bytes.Buffer
+	if s.RemoteControl {
+		out, err = s.remoteControl()
+	} else {
+		out, err = s.run(s.Binary, s.Timeout, s.UseSudo, s.Server, s.ThreadAsTag)
+	}
 	if err != nil {
 		return fmt.Errorf("error gathering metrics: %s", err)
 	}
@@ -149,6 +232,13 @@ func (s *Unbound) Gather(acc telegraf.Accumulator) error {
 
 		// Filter value
 		if filterExcluded.Match(stat) {
+			if s.Histogram {
+				addHistogramBucket(acc, stat, value)
+			}
+			continue
+		}
+
+		if !s.GatherThreads && strings.HasPrefix(stat, "thread") {
 			continue
 		}
 
@@ -198,15 +288,48 @@ func (s *Unbound) Gather(acc telegraf.Accumulator) error {
 	return nil
 }
 
+// addHistogramBucket adds a bucket of the recursion time histogram, such as
+// histogram.000000.000512.to.000000.001024=5503 for the recursions which took
+// from 0.000512s to 0.001024s.
+func addHistogramBucket(acc telegraf.Accumulator, stat string, value string) {
+	bounds := strings.Split(strings.TrimPrefix(stat, "histogram."), ".to.")
+	if len(bounds) != 2 {
+		return
+	}
+
+	lower, err := strconv.ParseFloat(bounds[0], 64)
+	if err != nil {
+		return
+	}
+	upper, err := strconv.ParseFloat(bounds[1], 64)
+	if err != nil {
+		return
+	}
+
+	count, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		acc.AddError(fmt.Errorf("Expected a numerical value for %s = %v",
+			stat, value))
+		return
+	}
+
+	tags := map[string]string{
+		"lower": strconv.FormatFloat(lower, 'f', -1, 64),
+		"upper": strconv.FormatFloat(upper, 'f', -1, 64),
+	}
+	acc.AddFields("unbound_histogram", map[string]interface{}{"count": count}, tags)
+}
+
 func init() {
 	inputs.Add("unbound", func() telegraf.Input {
 		return &Unbound{
-			run:         unboundRunner,
-			Binary:      defaultBinary,
-			Timeout:     defaultTimeout,
-			UseSudo:     false,
-			Server:      "",
-			ThreadAsTag: false,
+			run:           unboundRunner,
+			Binary:        defaultBinary,
+			Timeout:       defaultTimeout,
+			UseSudo:       false,
+			Server:        "",
+			ThreadAsTag:   false,
+			GatherThreads: true,
 		}
 	})
 }
@@ -1,13 +1,17 @@
 package unbound
 
 import (
+	"bufio"
 	"bytes"
+	"io"
+	"net"
 	"testing"
 	"time"
 
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 var TestTimeout = internal.Duration{Duration: time.Second}
@@ -21,7 +25,8 @@ func UnboundControl(output string, Timeout internal.Duration, useSudo bool, Serv
 func TestParseFullOutput(t *testing.T) {
 	acc := &testutil.Accumulator{}
 	v := &Unbound{
-		run: UnboundControl(fullOutput, TestTimeout, true, "", false),
+		run:           UnboundControl(fullOutput, TestTimeout, true, "", false),
+		GatherThreads: true,
 	}
 	err := v.Gather(acc)
 
@@ -38,8 +43,9 @@ func TestParseFullOutput(t *testing.T) {
 func TestParseFullOutputThreadAsTag(t *testing.T) {
 	acc := &testutil.Accumulator{}
 	v := &Unbound{
-		run:         UnboundControl(fullOutput, TestTimeout, true, "", true),
-		ThreadAsTag: true,
+		run:           UnboundControl(fullOutput, TestTimeout, true, "", true),
+		ThreadAsTag:   true,
+		GatherThreads: true,
 	}
 	err := v.Gather(acc)
 
@@ -55,6 +61,73 @@ func TestParseFullOutputThreadAsTag(t *testing.T) {
 	acc.AssertContainsFields(t, "unbound_threads", parsedFullOutputThreadAsTagMeasurementUnboundThreads)
 }
 
+func TestParseFullOutputWithoutThreads(t *testing.T) {
+	acc := &testutil.Accumulator{}
+	v := &Unbound{
+		run: UnboundControl(fullOutput, TestTimeout, true, "", false),
+	}
+	err := v.Gather(acc)
+
+	assert.NoError(t, err)
+
+	assert.Len(t, acc.Metrics, 1)
+	assert.Equal(t, acc.NFields(), 50)
+	assert.False(t, acc.HasField("unbound", "thread0_num_queries"))
+	assert.True(t, acc.HasField("unbound", "total_num_queries"))
+}
+
+func TestParseHistogram(t *testing.T) {
+	acc := &testutil.Accumulator{}
+	v := &Unbound{
+		run:       UnboundControl(fullOutput, TestTimeout, true, "", false),
+		Histogram: true,
+	}
+	err := v.Gather(acc)
+
+	assert.NoError(t, err)
+
+	assert.Len(t, acc.Metrics, 41)
+	acc.AssertContainsTaggedFields(t, "unbound_histogram",
+		map[string]interface{}{"count": int64(5503)},
+		map[string]string{"lower": "0.000512", "upper": "0.001024"})
+	acc.AssertContainsTaggedFields(t, "unbound_histogram",
+		map[string]interface{}{"count": int64(136)},
+		map[string]string{"lower": "1", "upper": "2"})
+}
+
+func TestRemoteControl(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		if line == "UBCT1 stats_noreset\n" {
+			io.WriteString(conn, fullOutput)
+		} else {
+			io.WriteString(conn, "error unknown command\n")
+		}
+	}()
+
+	acc := &testutil.Accumulator{}
+	v := &Unbound{
+		Server:        l.Addr().String(),
+		Timeout:       TestTimeout,
+		RemoteControl: true,
+		GatherThreads: true,
+	}
+	err = v.Gather(acc)
+
+	require.NoError(t, err)
+	acc.AssertContainsFields(t, "unbound", parsedFullOutput)
+}
+
 var parsedFullOutput = map[string]interface{}{
 	"thread0_num_queries":              float64(11907596),
 	"thread0_num_cachehits":            float64(11489288),
@@ -0,0 +1,365 @@
+package upsd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/inputs"
+)
+
+const defaultAddress = "127.0.0.1:3493"
+
+// The variables of the UPS gathered as fields by default, and the name of
+// their field.
+var defaultVariables = map[string]string{
+	"battery.charge":  "battery_charge_percent",
+	"battery.runtime": "battery_runtime",
+	"battery.voltage": "battery_voltage",
+	"input.voltage":   "input_voltage",
+	"input.frequency": "input_frequency",
+	"output.voltage":  "output_voltage",
+	"ups.load":        "load_percent",
+	"ups.realpower":   "real_power",
+	"ups.temperature": "internal_temp",
+}
+
+// statusFlags are the bits of the status_flags field for each flag of the
+// ups.status variable, which are the same as the ones of the STATFLAG of
+// apcupsd.
+var statusFlags = map[string]uint64{
+	"CAL":   1 << 0,
+	"TRIM":  1 << 1,
+	"BOOST": 1 << 2,
+	"OL":    1 << 3,
+	"OB":    1 << 4,
+	"OVER":  1 << 5,
+	"LB":    1 << 6,
+	"RB":    1 << 7,
+}
+
+type Upsd struct {
+	Servers   []string
+	Username  string
+	Password  string
+	Timeout   internal.Duration
+	Ups       []string `toml:"ups"`
+	Variables []string
+
+	upsFilter      filter.Filter
+	variableFilter filter.Filter
+	initialized    bool
+}
+
+var sampleConfig = `
+  ## NUT servers to connect to, as "host:port".
+  # servers = ["127.0.0.1:3493"]
+
+  ## Credentials of the server, if required.
+  # username = ""
+  # password = ""
+
+  ## Timeout of the queries to a server.
+  # timeout = "5s"
+
+  ## UPS to gather, matched against the name of the UPS, globs are supported.
+  ## All the UPS of the servers are gathered by default.
+  # ups = []
+
+  ## Additional variables of the UPS to gather as fields, such as
+  ## "battery.mfr.date" or "input.*", globs are supported.  The dots of the
+  ## names are replaced with underscores in the field names.
+  # variables = []
+`
+
+func (u *Upsd) Description() string {
+	return "Monitor UPS using the upsd server of Network UPS Tools"
+}
+
+func (u *Upsd) SampleConfig() string {
+	return sampleConfig
+}
+
+func (u *Upsd) init() error {
+	var err error
+	u.upsFilter, err = filter.Compile(u.Ups)
+	if err != nil {
+		return err
+	}
+	u.variableFilter, err = filter.Compile(u.Variables)
+	if err != nil {
+		return err
+	}
+	u.initialized = true
+	return nil
+}
+
+func (u *Upsd) Gather(acc telegraf.Accumulator) error {
+	if !u.initialized {
+		if err := u.init(); err != nil {
+			return err
+		}
+	}
+
+	servers := u.Servers
+	if len(servers) == 0 {
+		servers = []string{defaultAddress}
+	}
+
+	var wg sync.WaitGroup
+	for _, server := range servers {
+		wg.Add(1)
+		go func(server string) {
+			defer wg.Done()
+			if err := u.gatherServer(acc, server); err != nil {
+				acc.AddError(fmt.Errorf("upsd %s: %s", server, err))
+			}
+		}(server)
+	}
+	wg.Wait()
+	return nil
+}
+
+func (u *Upsd) gatherServer(acc telegraf.Accumulator, server string) error {
+	c, err := dial(server, u.Timeout.Duration)
+	if err != nil {
+		return err
+	}
+	defer c.close()
+
+	if u.Username != "" {
+		if err := c.command("USERNAME", u.Username); err != nil {
+			return err
+		}
+	}
+	if u.Password != "" {
+		if err := c.command("PASSWORD", u.Password); err != nil {
+			return err
+		}
+	}
+
+	upsList, err := c.list("UPS")
+	if err != nil {
+		return err
+	}
+	for _, ups := range upsList {
+		// UPS <upsname> "<description>"
+		if len(ups) < 2 {
+			continue
+		}
+		name := ups[1]
+		if u.upsFilter != nil && !u.upsFilter.Match(name) {
+			continue
+		}
+
+		vars, err := c.list("VAR", name)
+		if err != nil {
+			return err
+		}
+		variables := make(map[string]string, len(vars))
+		for _, v := range vars {
+			// VAR <upsname> <varname> "<value>"
+			if len(v) == 4 {
+				variables[v[2]] = v[3]
+			}
+		}
+		u.addUps(acc, server, name, variables)
+	}
+	return nil
+}
+
+func (u *Upsd) addUps(acc telegraf.Accumulator, server, name string, variables map[string]string) {
+	tags := map[string]string{
+		"server":   server,
+		"ups_name": name,
+	}
+	if model, ok := variables["ups.model"]; ok {
+		tags["model"] = strings.TrimSpace(model)
+	}
+	if serial, ok := variables["ups.serial"]; ok && strings.TrimSpace(serial) != "" {
+		tags["serial"] = strings.TrimSpace(serial)
+	}
+
+	fields := make(map[string]interface{})
+	for variable, value := range variables {
+		if u.variableFilter != nil && u.variableFilter.Match(variable) {
+			fields[strings.Replace(variable, ".", "_", -1)] = parseValue(value)
+		}
+	}
+	for variable, field := range defaultVariables {
+		if value, ok := variables[variable]; ok {
+			if v, err := strconv.ParseFloat(value, 64); err == nil {
+				fields[field] = v
+			}
+		}
+	}
+	if status, ok := variables["ups.status"]; ok {
+		var flags uint64
+		for _, flag := range strings.Fields(status) {
+			flags |= statusFlags[flag]
+		}
+		fields["ups_status"] = status
+		fields["status_flags"] = flags
+	}
+
+	acc.AddFields("upsd", fields, tags)
+}
+
+// parseValue returns the value of a variable as a float when numeric, or else
+// as a string.
+func parseValue(value string) interface{} {
+	if v, err := strconv.ParseFloat(value, 64); err == nil {
+		return v
+	}
+	return value
+}
+
+// conn is a connection to a NUT server, see
+// https://networkupstools.org/docs/developer-guide.chunked/ar01s09.html
+type conn struct {
+	net.Conn
+	reader  *bufio.Reader
+	timeout time.Duration
+}
+
+func dial(address string, timeout time.Duration) (*conn, error) {
+	c, err := net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return &conn{Conn: c, reader: bufio.NewReader(c), timeout: timeout}, nil
+}
+
+func (c *conn) close() {
+	c.send("LOGOUT")
+	c.Close()
+}
+
+func (c *conn) send(args ...string) error {
+	if c.timeout > 0 {
+		c.SetDeadline(time.Now().Add(c.timeout))
+	}
+	words := []string{args[0]}
+	for _, arg := range args[1:] {
+		words = append(words, quote(arg))
+	}
+	_, err := c.Write([]byte(strings.Join(words, " ") + "\n"))
+	return err
+}
+
+func (c *conn) readLine() ([]string, error) {
+	line, err := c.reader.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	words, err := split(strings.TrimRight(line, "\r\n"))
+	if err != nil {
+		return nil, err
+	}
+	if len(words) == 0 {
+		return nil, errors.New("empty response")
+	}
+	if words[0] == "ERR" {
+		return nil, fmt.Errorf("server error: %s", strings.Join(words[1:], " "))
+	}
+	return words, nil
+}
+
+// command sends a command, such as USERNAME, answered with OK.
+func (c *conn) command(args ...string) error {
+	if err := c.send(args...); err != nil {
+		return err
+	}
+	words, err := c.readLine()
+	if err != nil {
+		return err
+	}
+	if words[0] != "OK" {
+		return fmt.Errorf("unexpected response to %s: %s", args[0], strings.Join(words, " "))
+	}
+	return nil
+}
+
+// list sends a LIST command and returns the words of the lines between the
+// BEGIN and END lines of the response.
+func (c *conn) list(args ...string) ([][]string, error) {
+	query := strings.Join(args, " ")
+	if err := c.send(append([]string{"LIST"}, args...)...); err != nil {
+		return nil, err
+	}
+	words, err := c.readLine()
+	if err != nil {
+		return nil, err
+	}
+	if strings.Join(words, " ") != "BEGIN LIST "+query {
+		return nil, fmt.Errorf("unexpected response to LIST %s: %s", query, strings.Join(words, " "))
+	}
+
+	var lines [][]string
+	for {
+		words, err := c.readLine()
+		if err != nil {
+			return nil, err
+		}
+		if strings.Join(words, " ") == "END LIST "+query {
+			return lines, nil
+		}
+		lines = append(lines, words)
+	}
+}
+
+// split splits a line of the protocol into words, which may be quoted with
+// backslash escapes.
+func split(line string) ([]string, error) {
+	var words []string
+	for i := 0; i < len(line); {
+		switch line[i] {
+		case ' ', '\t':
+			i++
+		case '"':
+			var word []byte
+			for i++; i < len(line) && line[i] != '"'; i++ {
+				if line[i] == '\\' && i+1 < len(line) {
+					i++
+				}
+				word = append(word, line[i])
+			}
+			if i == len(line) {
+				return nil, fmt.Errorf("unterminated quote: %s", line)
+			}
+			i++
+			words = append(words, string(word))
+		default:
+			start := i
+			for i < len(line) && line[i] != ' ' && line[i] != '\t' {
+				i++
+			}
+			words = append(words, line[start:i])
+		}
+	}
+	return words, nil
+}
+
+func quote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\"\\") {
+		return s
+	}
+	s = strings.Replace(s, `\`, `\\`, -1)
+	return `"` + strings.Replace(s, `"`, `\"`, -1) + `"`
+}
+
+func init() {
+	inputs.Add("upsd", func() telegraf.Input {
+		return &Upsd{
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
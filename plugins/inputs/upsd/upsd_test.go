@@ -0,0 +1,170 @@
+package upsd
+
+import (
+	"bufio"
+	"net"
+	"testing"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var responses = map[string]string{
+	"USERNAME telegraf":    "OK\n",
+	`PASSWORD "p4ss word"`: "OK\n",
+	"LIST UPS": `BEGIN LIST UPS
+UPS rack "Rack UPS"
+UPS desk "Desk \"UPS\""
+END LIST UPS
+`,
+	"LIST VAR rack": `BEGIN LIST VAR rack
+VAR rack battery.charge "100"
+VAR rack battery.runtime "1930"
+VAR rack battery.voltage "27.30"
+VAR rack battery.mfr.date "2017/05/24"
+VAR rack input.voltage "231.0"
+VAR rack input.frequency "50.0"
+VAR rack input.transfer.high "264"
+VAR rack output.voltage "230.0"
+VAR rack ups.load "17"
+VAR rack ups.model "Smart-UPS 1500"
+VAR rack ups.serial "AS1234567890"
+VAR rack ups.status "OL CHRG"
+END LIST VAR rack
+`,
+	"LIST VAR desk": `BEGIN LIST VAR desk
+VAR desk battery.charge "42"
+VAR desk ups.load "5"
+VAR desk ups.model "Back-UPS ES 700"
+VAR desk ups.serial ""
+VAR desk ups.status "OB LB"
+END LIST VAR desk
+`,
+}
+
+// serve answers the commands of the connections with the responses.
+func serve(t *testing.T) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func(c net.Conn) {
+				defer c.Close()
+				scanner := bufio.NewScanner(c)
+				for scanner.Scan() {
+					command := scanner.Text()
+					if command == "LOGOUT" {
+						c.Write([]byte("OK Goodbye\n"))
+						return
+					}
+					response, ok := responses[command]
+					if !ok {
+						response = "ERR UNKNOWN-COMMAND\n"
+					}
+					c.Write([]byte(response))
+				}
+			}(c)
+		}
+	}()
+	return l
+}
+
+func TestGather(t *testing.T) {
+	l := serve(t)
+	defer l.Close()
+
+	u := &Upsd{
+		Servers:   []string{l.Addr().String()},
+		Username:  "telegraf",
+		Password:  "p4ss word",
+		Variables: []string{"battery.mfr.date", "input.transfer.*"},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(u.Gather))
+	require.Empty(t, acc.Errors)
+
+	acc.AssertContainsTaggedFields(t, "upsd",
+		map[string]interface{}{
+			"battery_charge_percent": 100.0,
+			"battery_runtime":        1930.0,
+			"battery_voltage":        27.3,
+			"input_voltage":          231.0,
+			"input_frequency":        50.0,
+			"output_voltage":         230.0,
+			"load_percent":           17.0,
+			"ups_status":             "OL CHRG",
+			"status_flags":           uint64(8),
+			"battery_mfr_date":       "2017/05/24",
+			"input_transfer_high":    264.0,
+		},
+		map[string]string{
+			"server":   l.Addr().String(),
+			"ups_name": "rack",
+			"model":    "Smart-UPS 1500",
+			"serial":   "AS1234567890",
+		})
+
+	acc.AssertContainsTaggedFields(t, "upsd",
+		map[string]interface{}{
+			"battery_charge_percent": 42.0,
+			"load_percent":           5.0,
+			"ups_status":             "OB LB",
+			"status_flags":           uint64(80),
+		},
+		map[string]string{
+			"server":   l.Addr().String(),
+			"ups_name": "desk",
+			"model":    "Back-UPS ES 700",
+		})
+}
+
+func TestGather_filter(t *testing.T) {
+	l := serve(t)
+	defer l.Close()
+
+	u := &Upsd{
+		Servers: []string{l.Addr().String()},
+		Ups:     []string{"de*"},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(u.Gather))
+	require.Empty(t, acc.Errors)
+	require.Len(t, acc.Metrics, 1)
+	assert.Equal(t, "desk", acc.Metrics[0].Tags["ups_name"])
+}
+
+func TestGather_error(t *testing.T) {
+	l := serve(t)
+	defer l.Close()
+
+	u := &Upsd{
+		Servers:  []string{l.Addr().String()},
+		Username: "unknown",
+	}
+
+	var acc testutil.Accumulator
+	err := acc.GatherError(u.Gather)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "UNKNOWN-COMMAND")
+}
+
+func TestSplit(t *testing.T) {
+	words, err := split(`VAR ups device.mfr "APC \"Inc\" \\ Co" `)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"VAR", "ups", "device.mfr", `APC "Inc" \ Co`}, words)
+
+	_, err = split(`VAR ups device.mfr "APC`)
+	assert.Error(t, err)
+
+	assert.Equal(t, `"a \"b\" \\"`, quote(`a "b" \`))
+	assert.Equal(t, `ups`, quote(`ups`))
+	assert.Equal(t, `""`, quote(``))
+}
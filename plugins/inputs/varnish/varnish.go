@@ -1,3 +1,4 @@
+//go:build !windows
 // +build !windows
 
 package varnish
@@ -5,6 +6,7 @@ package varnish
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"os/exec"
 	"strconv"
@@ -17,14 +19,16 @@ import (
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
-type runner func(cmdName string, UseSudo bool, InstanceName string) (*bytes.Buffer, error)
+type runner func(cmdName string, UseSudo bool, cmdArgs []string) (*bytes.Buffer, error)
 
 // Varnish is used to store configuration values
 type Varnish struct {
-	Stats        []string
-	Binary       string
-	UseSudo      bool
-	InstanceName string
+	Stats           []string
+	Binary          string
+	UseSudo         bool
+	InstanceName    string
+	UseJSON         bool   `toml:"use_json"`
+	DockerContainer string `toml:"docker_container"`
 
 	filter filter.Filter
 	run    runner
@@ -49,6 +53,15 @@ var sampleConfig = `
   ## Optional name for the varnish instance (or working directory) to query
   ## Usually appened after -n in varnish cli
   #name = instanceName
+
+  ## Parse the JSON output of 'varnishstat -j' instead of the text output.
+  ## The stats of the backends and storages are then tagged with their name,
+  ## in the backend and storage tags.
+  #use_json = false
+
+  ## Run varnishstat inside a container with 'docker exec', the binary is
+  ## then the path of varnishstat in the container.
+  #docker_container = ""
 `
 
 func (s *Varnish) Description() string {
@@ -61,13 +74,7 @@ func (s *Varnish) SampleConfig() string {
 }
 
 // Shell out to varnish_stat and return the output
-func varnishRunner(cmdName string, UseSudo bool, InstanceName string) (*bytes.Buffer, error) {
-	cmdArgs := []string{"-1"}
-
-	if InstanceName != "" {
-		cmdArgs = append(cmdArgs, []string{"-n", InstanceName}...)
-	}
-
+func varnishRunner(cmdName string, UseSudo bool, cmdArgs []string) (*bytes.Buffer, error) {
 	cmd := exec.Command(cmdName, cmdArgs...)
 
 	if UseSudo {
@@ -86,6 +93,25 @@ func varnishRunner(cmdName string, UseSudo bool, InstanceName string) (*bytes.Bu
 	return &out, nil
 }
 
+// command returns the command and its arguments to run varnishstat
+func (s *Varnish) command() (string, []string) {
+	cmdArgs := []string{"-1"}
+	if s.UseJSON {
+		cmdArgs = []string{"-j"}
+	}
+
+	if s.InstanceName != "" {
+		cmdArgs = append(cmdArgs, []string{"-n", s.InstanceName}...)
+	}
+
+	if s.DockerContainer != "" {
+		cmdArgs = append([]string{"exec", s.DockerContainer, s.Binary}, cmdArgs...)
+		return "docker", cmdArgs
+	}
+
+	return s.Binary, cmdArgs
+}
+
 // Gather collects the configured stats from varnish_stat and adds them to the
 // Accumulator
 //
@@ -109,11 +135,16 @@ func (s *Varnish) Gather(acc telegraf.Accumulator) error {
 		}
 	}
 
-	out, err := s.run(s.Binary, s.UseSudo, s.InstanceName)
+	cmdName, cmdArgs := s.command()
+	out, err := s.run(cmdName, s.UseSudo, cmdArgs)
 	if err != nil {
 		return fmt.Errorf("error gathering metrics: %s", err)
 	}
 
+	if s.UseJSON {
+		return s.gatherJSON(out, acc)
+	}
+
 	sectionMap := make(map[string]map[string]interface{})
 	scanner := bufio.NewScanner(out)
 	for scanner.Scan() {
@@ -162,6 +193,91 @@ func (s *Varnish) Gather(acc telegraf.Accumulator) error {
 	return nil
 }
 
+// gatherJSON parses the output of 'varnishstat -j'.  The stats of the backends
+// (VBE) and storages (SMA, SMF, SMU) are tagged with the identifier of the
+// backend or storage, the other stats are grouped by section as with the text
+// output.
+func (s *Varnish) gatherJSON(out *bytes.Buffer, acc telegraf.Accumulator) error {
+	var stats map[string]json.RawMessage
+	if err := json.Unmarshal(out.Bytes(), &stats); err != nil {
+		return fmt.Errorf("error parsing varnishstat JSON output: %s", err)
+	}
+
+	// Since varnish 6.5, the stats are listed in the counters object
+	if counters, ok := stats["counters"]; ok {
+		stats = nil
+		if err := json.Unmarshal(counters, &stats); err != nil {
+			return fmt.Errorf("error parsing varnishstat JSON counters: %s", err)
+		}
+	}
+
+	type groupKey struct {
+		section string
+		tag     string
+		ident   string
+	}
+	groups := make(map[groupKey]map[string]interface{})
+
+	for stat, raw := range stats {
+		if !strings.Contains(stat, ".") {
+			continue
+		}
+		if s.filter != nil && !s.filter.Match(stat) {
+			continue
+		}
+
+		var counter struct {
+			Value json.Number `json:"value"`
+		}
+		if err := json.Unmarshal(raw, &counter); err != nil {
+			acc.AddError(fmt.Errorf("error parsing varnishstat counter %s: %s", stat, err))
+			continue
+		}
+		value, err := strconv.ParseUint(counter.Value.String(), 10, 64)
+		if err != nil {
+			acc.AddError(fmt.Errorf("Expected a numeric value for %s = %v\n",
+				stat, counter.Value))
+			continue
+		}
+
+		parts := strings.SplitN(stat, ".", 2)
+		key := groupKey{section: parts[0]}
+		field := parts[1]
+
+		// The identifier of the backend or storage is between the section
+		// and the name of the stat, ie VBE.boot.default.req
+		var tag string
+		switch key.section {
+		case "VBE":
+			tag = "backend"
+		case "SMA", "SMF", "SMU":
+			tag = "storage"
+		}
+		if i := strings.LastIndex(field, "."); tag != "" && i > 0 {
+			key.tag = tag
+			key.ident = field[:i]
+			field = field[i+1:]
+		}
+
+		if _, ok := groups[key]; !ok {
+			groups[key] = make(map[string]interface{})
+		}
+		groups[key][field] = value
+	}
+
+	for key, fields := range groups {
+		tags := map[string]string{
+			"section": key.section,
+		}
+		if key.tag != "" {
+			tags[key.tag] = key.ident
+		}
+		acc.AddFields("varnish", fields, tags)
+	}
+
+	return nil
+}
+
 func init() {
 	inputs.Add("varnish", func() telegraf.Input {
 		return &Varnish{
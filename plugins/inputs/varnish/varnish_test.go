@@ -1,3 +1,4 @@
+//go:build !windows
 // +build !windows
 
 package varnish
@@ -12,8 +13,8 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func fakeVarnishStat(output string, useSudo bool, InstanceName string) func(string, bool, string) (*bytes.Buffer, error) {
-	return func(string, bool, string) (*bytes.Buffer, error) {
+func fakeVarnishStat(output string, useSudo bool, InstanceName string) func(string, bool, []string) (*bytes.Buffer, error) {
+	return func(string, bool, []string) (*bytes.Buffer, error) {
 		return bytes.NewBuffer([]byte(output)), nil
 	}
 }
@@ -87,6 +88,76 @@ func TestFieldConfig(t *testing.T) {
 	}
 }
 
+func TestCommand(t *testing.T) {
+	v := &Varnish{
+		Binary:       defaultBinary,
+		InstanceName: "cache1",
+	}
+	cmd, args := v.command()
+	assert.Equal(t, defaultBinary, cmd)
+	assert.Equal(t, []string{"-1", "-n", "cache1"}, args)
+
+	v.UseJSON = true
+	v.DockerContainer = "varnish"
+	cmd, args = v.command()
+	assert.Equal(t, "docker", cmd)
+	assert.Equal(t, []string{"exec", "varnish", defaultBinary, "-j", "-n", "cache1"}, args)
+}
+
+func TestGatherJSON(t *testing.T) {
+	for _, output := range []string{jsonOutput, jsonOutputV1} {
+		acc := &testutil.Accumulator{}
+		v := &Varnish{
+			run:     fakeVarnishStat(output, false, ""),
+			Stats:   []string{"*"},
+			UseJSON: true,
+		}
+		assert.NoError(t, v.Gather(acc))
+
+		acc.AssertContainsTaggedFields(t, "varnish",
+			map[string]interface{}{
+				"uptime":     uint64(895),
+				"cache_hit":  uint64(95),
+				"cache_miss": uint64(5),
+			},
+			map[string]string{"section": "MAIN"})
+		acc.AssertContainsTaggedFields(t, "varnish",
+			map[string]interface{}{
+				"vbc.live": uint64(0),
+				"vbc.pool": uint64(10),
+			},
+			map[string]string{"section": "MEMPOOL"})
+		acc.AssertContainsTaggedFields(t, "varnish",
+			map[string]interface{}{
+				"req":            uint64(42),
+				"happy":          uint64(18446744073709551615),
+				"bereq_hdrbytes": uint64(12345),
+			},
+			map[string]string{"section": "VBE", "backend": "boot.default"})
+		acc.AssertContainsTaggedFields(t, "varnish",
+			map[string]interface{}{
+				"c_req":   uint64(7),
+				"g_bytes": uint64(1024),
+			},
+			map[string]string{"section": "SMA", "storage": "s0"})
+		assert.Len(t, acc.Metrics, 4)
+	}
+}
+
+func TestGatherJSONFilter(t *testing.T) {
+	acc := &testutil.Accumulator{}
+	v := &Varnish{
+		run:     fakeVarnishStat(jsonOutput, false, ""),
+		Stats:   []string{"VBE.*.req", "SMA.*"},
+		UseJSON: true,
+	}
+	assert.NoError(t, v.Gather(acc))
+
+	flat := flatten(acc.Metrics)
+	assert.Len(t, acc.Metrics, 2)
+	assert.Equal(t, 3, len(flat))
+}
+
 func flatten(metrics []*testutil.Metric) map[string]interface{} {
 	flat := map[string]interface{}{}
 	for _, m := range metrics {
@@ -424,3 +495,34 @@ LCK.pipestat.creat                                       1         0.00 Created
 LCK.pipestat.destroy                                     0         0.00 Destroyed locks
 LCK.pipestat.locks                                       0         0.00 Lock Operations
 `
+
+var jsonOutput = `{
+  "timestamp": "2018-07-12T10:13:05",
+  "MAIN.uptime": {"description": "Child process uptime", "type": "MAIN", "flag": "c", "format": "d", "value": 895},
+  "MAIN.cache_hit": {"description": "Cache hits", "type": "MAIN", "flag": "c", "format": "i", "value": 95},
+  "MAIN.cache_miss": {"description": "Cache misses", "type": "MAIN", "flag": "c", "format": "i", "value": 5},
+  "MEMPOOL.vbc.live": {"description": "In use", "type": "MEMPOOL", "ident": "vbc", "flag": "g", "format": "i", "value": 0},
+  "MEMPOOL.vbc.pool": {"description": "In Pool", "type": "MEMPOOL", "ident": "vbc", "flag": "g", "format": "i", "value": 10},
+  "VBE.boot.default.happy": {"description": "Happy health probes", "type": "VBE", "ident": "boot.default", "flag": "b", "format": "b", "value": 18446744073709551615},
+  "VBE.boot.default.req": {"description": "Backend requests sent", "type": "VBE", "ident": "boot.default", "flag": "c", "format": "i", "value": 42},
+  "VBE.boot.default.bereq_hdrbytes": {"description": "Request header bytes", "type": "VBE", "ident": "boot.default", "flag": "c", "format": "B", "value": 12345},
+  "SMA.s0.c_req": {"description": "Allocator requests", "type": "SMA", "ident": "s0", "flag": "c", "format": "i", "value": 7},
+  "SMA.s0.g_bytes": {"description": "Bytes outstanding", "type": "SMA", "ident": "s0", "flag": "g", "format": "B", "value": 1024}
+}`
+
+var jsonOutputV1 = `{
+  "version": 1,
+  "timestamp": "2021-03-15T10:13:05",
+  "counters": {
+    "MAIN.uptime": {"description": "Child process uptime", "flag": "c", "format": "d", "value": 895},
+    "MAIN.cache_hit": {"description": "Cache hits", "flag": "c", "format": "i", "value": 95},
+    "MAIN.cache_miss": {"description": "Cache misses", "flag": "c", "format": "i", "value": 5},
+    "MEMPOOL.vbc.live": {"description": "In use", "flag": "g", "format": "i", "value": 0},
+    "MEMPOOL.vbc.pool": {"description": "In Pool", "flag": "g", "format": "i", "value": 10},
+    "VBE.boot.default.happy": {"description": "Happy health probes", "flag": "b", "format": "b", "value": 18446744073709551615},
+    "VBE.boot.default.req": {"description": "Backend requests sent", "flag": "c", "format": "i", "value": 42},
+    "VBE.boot.default.bereq_hdrbytes": {"description": "Request header bytes", "flag": "c", "format": "B", "value": 12345},
+    "SMA.s0.c_req": {"description": "Allocator requests", "flag": "c", "format": "i", "value": 7},
+    "SMA.s0.g_bytes": {"description": "Bytes outstanding", "flag": "g", "format": "B", "value": 1024}
+  }
+}`
@@ -0,0 +1,304 @@
+package generic
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/influxdata/telegraf"
+	"github.com/tidwall/gjson"
+)
+
+const defaultName = "generic_webhooks"
+
+// GenericWebhooks are the webhooks mapping the body of the requests to
+// metrics, each one on its own path.
+type GenericWebhooks []*GenericWebhook
+
+func (gw GenericWebhooks) Register(router *mux.Router, acc telegraf.Accumulator) {
+	for _, webhook := range gw {
+		webhook.Register(router, acc)
+	}
+}
+
+// GenericWebhook maps the JSON or form body of the requests to metrics, with
+// GJSON paths (https://github.com/tidwall/gjson#path-syntax).
+type GenericWebhook struct {
+	Path            string
+	DataFormat      string
+	Name            string
+	NamePath        string
+	MetricsPath     string
+	Tags            map[string]string
+	Fields          map[string]string
+	TimestampPath   string
+	TimestampFormat string
+
+	Secret             string
+	SignatureHeader    string
+	SignatureAlgorithm string
+
+	acc telegraf.Accumulator
+}
+
+func (gw *GenericWebhook) Register(router *mux.Router, acc telegraf.Accumulator) {
+	router.HandleFunc(gw.Path, gw.eventHandler).Methods("POST")
+	log.Printf("I! Started the webhooks_generic on %s\n", gw.Path)
+	gw.acc = acc
+}
+
+func (gw *GenericWebhook) eventHandler(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	if gw.Secret != "" {
+		if err := gw.checkSignature(data, r.Header); err != nil {
+			log.Printf("E! Fail to check the generic webhook signature on %s: %s\n", gw.Path, err)
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+	}
+
+	body, err := gw.decode(data)
+	if err != nil {
+		gw.acc.AddError(fmt.Errorf("E! Invalid body on the generic webhook %s: %s", gw.Path, err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	metrics, err := gw.metrics(body, time.Now())
+	if err != nil {
+		gw.acc.AddError(fmt.Errorf("E! Unable to map the body on the generic webhook %s: %s", gw.Path, err))
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	for _, m := range metrics {
+		gw.acc.AddFields(m.name, m.fields, m.tags, m.time)
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// checkSignature verifies the HMAC of the body, sent hex encoded in the
+// signature header with an optional "<algorithm>=" prefix.
+func (gw *GenericWebhook) checkSignature(data []byte, header http.Header) error {
+	headerName := gw.SignatureHeader
+	if headerName == "" {
+		headerName = "X-Hub-Signature"
+	}
+	signature := header.Get(headerName)
+	if signature == "" {
+		return fmt.Errorf("missing %s header", headerName)
+	}
+	if i := strings.Index(signature, "="); i >= 0 {
+		signature = signature[i+1:]
+	}
+	sent, err := hex.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %s", err)
+	}
+
+	var h func() hash.Hash
+	switch gw.SignatureAlgorithm {
+	case "", "sha256":
+		h = sha256.New
+	case "sha1":
+		h = sha1.New
+	case "sha512":
+		h = sha512.New
+	default:
+		return fmt.Errorf("unknown signature algorithm %s", gw.SignatureAlgorithm)
+	}
+
+	mac := hmac.New(h, []byte(gw.Secret))
+	mac.Write(data)
+	if !hmac.Equal(sent, mac.Sum(nil)) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+// decode returns the body as JSON, the values of a form body are converted to
+// a JSON object.
+func (gw *GenericWebhook) decode(data []byte) (gjson.Result, error) {
+	switch gw.DataFormat {
+	case "", "json":
+		if !json.Valid(data) {
+			return gjson.Result{}, fmt.Errorf("invalid JSON")
+		}
+		return gjson.ParseBytes(data), nil
+	case "form":
+		values, err := url.ParseQuery(string(data))
+		if err != nil {
+			return gjson.Result{}, err
+		}
+		object := make(map[string]interface{}, len(values))
+		for key, v := range values {
+			if len(v) == 1 {
+				object[key] = v[0]
+			} else {
+				object[key] = v
+			}
+		}
+		buf, err := json.Marshal(object)
+		if err != nil {
+			return gjson.Result{}, err
+		}
+		return gjson.ParseBytes(buf), nil
+	default:
+		return gjson.Result{}, fmt.Errorf("unknown format %s", gw.DataFormat)
+	}
+}
+
+type metric struct {
+	name   string
+	tags   map[string]string
+	fields map[string]interface{}
+	time   time.Time
+}
+
+// metrics maps the body to metrics, one for the body or one for each
+// element of the array at the metrics path.
+func (gw *GenericWebhook) metrics(body gjson.Result, now time.Time) ([]*metric, error) {
+	elements := []gjson.Result{body}
+	if gw.MetricsPath != "" {
+		result := body.Get(gw.MetricsPath)
+		if !result.IsArray() {
+			return nil, fmt.Errorf("%s is not an array", gw.MetricsPath)
+		}
+		elements = result.Array()
+	}
+
+	metrics := make([]*metric, 0, len(elements))
+	for _, element := range elements {
+		m, err := gw.metric(element, now)
+		if err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+func (gw *GenericWebhook) metric(element gjson.Result, now time.Time) (*metric, error) {
+	m := &metric{
+		name:   gw.Name,
+		tags:   make(map[string]string, len(gw.Tags)),
+		fields: make(map[string]interface{}, len(gw.Fields)),
+		time:   now,
+	}
+	if m.name == "" {
+		m.name = defaultName
+	}
+	if gw.NamePath != "" {
+		if name := element.Get(gw.NamePath).String(); name != "" {
+			m.name = name
+		}
+	}
+
+	for tag, path := range gw.Tags {
+		result := element.Get(path)
+		if result.Exists() && result.String() != "" {
+			m.tags[tag] = result.String()
+		}
+	}
+
+	for field, path := range gw.Fields {
+		if value := gw.fieldValue(element.Get(path)); value != nil {
+			m.fields[field] = value
+		}
+	}
+	if len(m.fields) == 0 {
+		return nil, fmt.Errorf("no field found")
+	}
+
+	if gw.TimestampPath != "" {
+		result := element.Get(gw.TimestampPath)
+		if result.Exists() {
+			t, err := parseTimestamp(result, gw.TimestampFormat)
+			if err != nil {
+				return nil, err
+			}
+			m.time = t
+		}
+	}
+
+	return m, nil
+}
+
+// fieldValue converts a value of the body to a field value, the numbers,
+// booleans and strings are kept while objects and arrays are ignored.  The
+// values of form bodies are strings, converted to numbers or booleans when
+// possible.
+func (gw *GenericWebhook) fieldValue(result gjson.Result) interface{} {
+	switch result.Type {
+	case gjson.Number:
+		return result.Float()
+	case gjson.True, gjson.False:
+		return result.Bool()
+	case gjson.String:
+		if gw.DataFormat == "form" {
+			if f, err := strconv.ParseFloat(result.Str, 64); err == nil {
+				return f
+			}
+			if b, err := strconv.ParseBool(result.Str); err == nil {
+				return b
+			}
+		}
+		return result.Str
+	}
+	return nil
+}
+
+// parseTimestamp parses a timestamp in seconds, milliseconds, microseconds or
+// nanoseconds since the epoch, or with a Go time layout, RFC3339 by default.
+func parseTimestamp(result gjson.Result, format string) (time.Time, error) {
+	switch format {
+	case "unix", "unix_ms", "unix_us", "unix_ns":
+		var unit int64
+		switch format {
+		case "unix":
+			unit = int64(time.Second)
+		case "unix_ms":
+			unit = int64(time.Millisecond)
+		case "unix_us":
+			unit = int64(time.Microsecond)
+		default:
+			unit = 1
+		}
+		// Integers are parsed as is to not lose the precision of a float
+		if value, err := strconv.ParseInt(result.String(), 10, 64); err == nil {
+			return time.Unix(0, value*unit), nil
+		}
+		value, err := strconv.ParseFloat(result.String(), 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid timestamp %q", result.String())
+		}
+		return time.Unix(0, int64(value*float64(unit))), nil
+	case "":
+		format = time.RFC3339
+	}
+
+	t, err := time.Parse(format, result.String())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q: %s", result.String(), err)
+	}
+	return t, nil
+}
@@ -0,0 +1,210 @@
+package generic
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func postWebhooks(gw *GenericWebhook, body string, header http.Header) *httptest.ResponseRecorder {
+	req, _ := http.NewRequest("POST", "/", strings.NewReader(body))
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	w.Code = 500
+
+	gw.eventHandler(w, req)
+
+	return w
+}
+
+const deployJSON = `
+{
+  "event": "deploy",
+  "service": {"name": "api", "env": "prod"},
+  "deploy": {"duration": 12.5, "success": true, "version": "1.2.3"},
+  "created_at": "2018-07-12T10:00:00Z"
+}`
+
+func TestJSON(t *testing.T) {
+	var acc testutil.Accumulator
+	gw := &GenericWebhook{
+		Path:     "/generic",
+		NamePath: "event",
+		Tags: map[string]string{
+			"service": "service.name",
+			"env":     "service.env",
+			"missing": "service.missing",
+		},
+		Fields: map[string]string{
+			"duration": "deploy.duration",
+			"success":  "deploy.success",
+			"version":  "deploy.version",
+		},
+		TimestampPath: "created_at",
+		acc:           &acc,
+	}
+
+	resp := postWebhooks(gw, deployJSON, nil)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	acc.AssertContainsTaggedFields(t, "deploy",
+		map[string]interface{}{
+			"duration": 12.5,
+			"success":  true,
+			"version":  "1.2.3",
+		},
+		map[string]string{
+			"service": "api",
+			"env":     "prod",
+		})
+	m, ok := acc.Get("deploy")
+	require.True(t, ok)
+	assert.Equal(t, time.Date(2018, 7, 12, 10, 0, 0, 0, time.UTC), m.Time.UTC())
+}
+
+func TestMetricsPath(t *testing.T) {
+	var acc testutil.Accumulator
+	gw := &GenericWebhook{
+		Path:        "/generic",
+		Name:        "sensors",
+		MetricsPath: "readings",
+		Tags: map[string]string{
+			"device": "device",
+		},
+		Fields: map[string]string{
+			"value": "value",
+		},
+		TimestampPath:   "ts",
+		TimestampFormat: "unix_ms",
+		acc:             &acc,
+	}
+
+	body := `{"readings": [
+		{"device": "a", "value": 1, "ts": 1531389600000},
+		{"device": "b", "value": 2, "ts": 1531389601000}
+	]}`
+	resp := postWebhooks(gw, body, nil)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	require.Len(t, acc.Metrics, 2)
+	acc.AssertContainsTaggedFields(t, "sensors",
+		map[string]interface{}{"value": 1.0}, map[string]string{"device": "a"})
+	acc.AssertContainsTaggedFields(t, "sensors",
+		map[string]interface{}{"value": 2.0}, map[string]string{"device": "b"})
+	assert.Equal(t, time.Unix(1531389600, 0), acc.Metrics[0].Time)
+	assert.Equal(t, time.Unix(1531389601, 0), acc.Metrics[1].Time)
+}
+
+func TestForm(t *testing.T) {
+	var acc testutil.Accumulator
+	gw := &GenericWebhook{
+		Path:       "/generic",
+		DataFormat: "form",
+		Tags: map[string]string{
+			"host": "host",
+		},
+		Fields: map[string]string{
+			"load":    "load",
+			"up":      "up",
+			"message": "message",
+		},
+		acc: &acc,
+	}
+
+	resp := postWebhooks(gw, "host=web01&load=0.75&up=true&message=all+good", nil)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	acc.AssertContainsTaggedFields(t, "generic_webhooks",
+		map[string]interface{}{
+			"load":    0.75,
+			"up":      true,
+			"message": "all good",
+		},
+		map[string]string{"host": "web01"})
+}
+
+func TestNoField(t *testing.T) {
+	var acc testutil.Accumulator
+	gw := &GenericWebhook{
+		Path:   "/generic",
+		Fields: map[string]string{"value": "missing"},
+		acc:    &acc,
+	}
+
+	resp := postWebhooks(gw, deployJSON, nil)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Len(t, acc.Metrics, 0)
+	assert.Len(t, acc.Errors, 1)
+
+	resp = postWebhooks(gw, "not json", nil)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Len(t, acc.Errors, 2)
+}
+
+func TestSignature(t *testing.T) {
+	var acc testutil.Accumulator
+	gw := &GenericWebhook{
+		Path:            "/generic",
+		Fields:          map[string]string{"duration": "deploy.duration"},
+		Secret:          "secret",
+		SignatureHeader: "X-Signature",
+		acc:             &acc,
+	}
+
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(deployJSON))
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	resp := postWebhooks(gw, deployJSON, nil)
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+
+	resp = postWebhooks(gw, deployJSON, http.Header{"X-Signature": {"sha256=" + strings.Repeat("0", len(signature))}})
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Len(t, acc.Metrics, 0)
+
+	resp = postWebhooks(gw, deployJSON, http.Header{"X-Signature": {"sha256=" + signature}})
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	resp = postWebhooks(gw, deployJSON, http.Header{"X-Signature": {signature}})
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Len(t, acc.Metrics, 2)
+}
+
+func TestParseTimestamp(t *testing.T) {
+	var acc testutil.Accumulator
+	tests := []struct {
+		format   string
+		value    string
+		expected time.Time
+	}{
+		{"unix", `1531389600`, time.Unix(1531389600, 0)},
+		{"unix", `"1531389600.5"`, time.Unix(1531389600, 5e8)},
+		{"unix_us", `1531389600000000`, time.Unix(1531389600, 0)},
+		{"unix_ns", `"1531389600000000000"`, time.Unix(1531389600, 0)},
+		{"2006-01-02 15:04:05", `"2018-07-12 10:00:00"`, time.Date(2018, 7, 12, 10, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		acc.ClearMetrics()
+		gw := &GenericWebhook{
+			Fields:          map[string]string{"value": "value"},
+			TimestampPath:   "ts",
+			TimestampFormat: tt.format,
+			acc:             &acc,
+		}
+		resp := postWebhooks(gw, `{"value": 1, "ts": `+tt.value+`}`, nil)
+		require.Equal(t, http.StatusOK, resp.Code, tt.format)
+		require.Len(t, acc.Metrics, 1)
+		assert.Equal(t, tt.expected.UnixNano(), acc.Metrics[0].Time.UnixNano(), tt.format)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/influxdata/telegraf/plugins/inputs"
 
 	"github.com/influxdata/telegraf/plugins/inputs/webhooks/filestack"
+	"github.com/influxdata/telegraf/plugins/inputs/webhooks/generic"
 	"github.com/influxdata/telegraf/plugins/inputs/webhooks/github"
 	"github.com/influxdata/telegraf/plugins/inputs/webhooks/mandrill"
 	"github.com/influxdata/telegraf/plugins/inputs/webhooks/papertrail"
@@ -36,6 +37,7 @@ type Webhooks struct {
 	Rollbar    *rollbar.RollbarWebhook
 	Papertrail *papertrail.PapertrailWebhook
 	Particle   *particle.ParticleWebhook
+	Generic    generic.GenericWebhooks
 
 	srv *http.Server
 }
@@ -67,6 +69,16 @@ func (wb *Webhooks) SampleConfig() string {
 
   [inputs.webhooks.particle]
     path = "/particle"
+
+  ## Generic webhooks, mapping the JSON or form body of the requests to
+  ## metrics.  See the generic webhooks README for the available options.
+  # [[inputs.webhooks.generic]]
+  #   path = "/generic"
+  #   name = "deploys"
+  #   [inputs.webhooks.generic.tags]
+  #     service = "service.name"
+  #   [inputs.webhooks.generic.fields]
+  #     duration = "deploy.duration"
  `
 }
 
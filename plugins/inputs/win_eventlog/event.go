@@ -0,0 +1,156 @@
+package win_eventlog
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Event is the XML representation of an event as rendered by EvtRender or
+// EvtFormatMessage.
+type Event struct {
+	Source struct {
+		Name string `xml:"Name,attr"`
+	} `xml:"System>Provider"`
+	EventID     int    `xml:"System>EventID"`
+	Version     int    `xml:"System>Version"`
+	Level       int    `xml:"System>Level"`
+	Task        int    `xml:"System>Task"`
+	Opcode      int    `xml:"System>Opcode"`
+	Keywords    string `xml:"System>Keywords"`
+	TimeCreated struct {
+		SystemTime string `xml:"SystemTime,attr"`
+	} `xml:"System>TimeCreated"`
+	EventRecordID uint64 `xml:"System>EventRecordID"`
+	Execution     struct {
+		ProcessID uint32 `xml:"ProcessID,attr"`
+		ThreadID  uint32 `xml:"ThreadID,attr"`
+	} `xml:"System>Execution"`
+	Channel  string `xml:"System>Channel"`
+	Computer string `xml:"System>Computer"`
+	Security struct {
+		UserID string `xml:"UserID,attr"`
+	} `xml:"System>Security"`
+
+	EventData []EventDataItem `xml:"EventData>Data"`
+
+	RenderingInfo *RenderingInfo `xml:"RenderingInfo"`
+}
+
+// EventDataItem is a single value of the EventData element, unnamed values
+// are found in legacy events.
+type EventDataItem struct {
+	Name  string `xml:"Name,attr"`
+	Value string `xml:",chardata"`
+}
+
+// RenderingInfo holds the localized strings of the event, it is only present
+// when the event was formatted with the metadata of its publisher.
+type RenderingInfo struct {
+	Message  string   `xml:"Message"`
+	Level    string   `xml:"Level"`
+	Task     string   `xml:"Task"`
+	Opcode   string   `xml:"Opcode"`
+	Keywords []string `xml:"Keywords>Keyword"`
+}
+
+// ParseEvent decodes the XML representation of an event.
+func ParseEvent(data string) (*Event, error) {
+	var event Event
+	if err := xml.Unmarshal([]byte(data), &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// levelNames are the standard level names, used when the event could not be
+// formatted with the metadata of its publisher.
+var levelNames = map[int]string{
+	0: "Information",
+	1: "Critical",
+	2: "Error",
+	3: "Warning",
+	4: "Information",
+	5: "Verbose",
+}
+
+// Time returns the time the event was created, or the zero time if it can
+// not be parsed.
+func (e *Event) Time() time.Time {
+	t, err := time.Parse(time.RFC3339Nano, e.TimeCreated.SystemTime)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// Tags returns the tags identifying the source of the event.
+func (e *Event) Tags() map[string]string {
+	tags := map[string]string{
+		"channel": e.Channel,
+		"source":  e.Source.Name,
+	}
+	if e.Computer != "" {
+		tags["computer"] = e.Computer
+	}
+	return tags
+}
+
+// Fields returns the fields of the event.  When onlyFirstLine is set only the
+// first line of the message is kept.
+func (e *Event) Fields(onlyFirstLine bool) map[string]interface{} {
+	fields := map[string]interface{}{
+		"event_id":        e.EventID,
+		"version":         e.Version,
+		"level":           e.Level,
+		"task":            e.Task,
+		"opcode":          e.Opcode,
+		"keywords":        e.Keywords,
+		"event_record_id": e.EventRecordID,
+		"process_id":      e.Execution.ProcessID,
+		"thread_id":       e.Execution.ThreadID,
+	}
+
+	if e.Security.UserID != "" {
+		fields["user_sid"] = e.Security.UserID
+	}
+
+	if ri := e.RenderingInfo; ri != nil {
+		message := strings.TrimSpace(ri.Message)
+		if onlyFirstLine {
+			message = strings.TrimSpace(strings.SplitN(message, "\n", 2)[0])
+		}
+		if message != "" {
+			fields["message"] = message
+		}
+		if ri.Level != "" {
+			fields["level_text"] = ri.Level
+		}
+		if ri.Task != "" {
+			fields["task_text"] = ri.Task
+		}
+		if ri.Opcode != "" {
+			fields["opcode_text"] = ri.Opcode
+		}
+		if len(ri.Keywords) > 0 {
+			fields["keywords_text"] = strings.Join(ri.Keywords, ",")
+		}
+	}
+
+	if _, ok := fields["level_text"]; !ok {
+		if name, ok := levelNames[e.Level]; ok {
+			fields["level_text"] = name
+		}
+	}
+
+	for i, data := range e.EventData {
+		name := data.Name
+		if name == "" {
+			name = strconv.Itoa(i + 1)
+		}
+		fields["data_"+name] = strings.TrimSpace(data.Value)
+	}
+
+	return fields
+}
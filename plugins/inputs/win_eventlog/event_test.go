@@ -0,0 +1,142 @@
+package win_eventlog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const renderedEvent = `<Event xmlns='http://schemas.microsoft.com/win/2004/08/events/event'>
+  <System>
+    <Provider Name='Microsoft-Windows-Security-Auditing' Guid='{54849625-5478-4994-A5BA-3E3B0328C30D}'/>
+    <EventID>4624</EventID>
+    <Version>2</Version>
+    <Level>0</Level>
+    <Task>12544</Task>
+    <Opcode>0</Opcode>
+    <Keywords>0x8020000000000000</Keywords>
+    <TimeCreated SystemTime='2018-06-12T14:33:05.918245600Z'/>
+    <EventRecordID>1215466</EventRecordID>
+    <Correlation/>
+    <Execution ProcessID='628' ThreadID='7024'/>
+    <Channel>Security</Channel>
+    <Computer>DESKTOP-1</Computer>
+    <Security UserID='S-1-5-18'/>
+  </System>
+  <EventData>
+    <Data Name='SubjectUserSid'>S-1-5-18</Data>
+    <Data Name='TargetUserName'>SYSTEM</Data>
+    <Data Name='LogonType'>5</Data>
+  </EventData>
+  <RenderingInfo Culture='en-US'>
+    <Message>An account was successfully logged on.
+
+Subject:
+	Security ID:		SYSTEM</Message>
+    <Level>Information</Level>
+    <Task>Logon</Task>
+    <Opcode>Info</Opcode>
+    <Channel>Security</Channel>
+    <Provider>Microsoft Windows security auditing.</Provider>
+    <Keywords>
+      <Keyword>Audit Success</Keyword>
+    </Keywords>
+  </RenderingInfo>
+</Event>`
+
+const legacyEvent = `<Event xmlns='http://schemas.microsoft.com/win/2004/08/events/event'>
+  <System>
+    <Provider Name='Application Error'/>
+    <EventID Qualifiers='0'>1000</EventID>
+    <Level>2</Level>
+    <Task>100</Task>
+    <Keywords>0x80000000000000</Keywords>
+    <TimeCreated SystemTime='2018-06-12T14:33:05Z'/>
+    <EventRecordID>42</EventRecordID>
+    <Channel>Application</Channel>
+    <Computer>DESKTOP-1</Computer>
+    <Security/>
+  </System>
+  <EventData>
+    <Data>app.exe</Data>
+    <Data>1.0.0.0</Data>
+  </EventData>
+</Event>`
+
+func TestParseRenderedEvent(t *testing.T) {
+	event, err := ParseEvent(renderedEvent)
+	require.NoError(t, err)
+
+	require.Equal(t,
+		time.Date(2018, 6, 12, 14, 33, 5, 918245600, time.UTC),
+		event.Time())
+
+	require.Equal(t,
+		map[string]string{
+			"channel":  "Security",
+			"source":   "Microsoft-Windows-Security-Auditing",
+			"computer": "DESKTOP-1",
+		},
+		event.Tags())
+
+	require.Equal(t,
+		map[string]interface{}{
+			"event_id":            4624,
+			"version":             2,
+			"level":               0,
+			"task":                12544,
+			"opcode":              0,
+			"keywords":            "0x8020000000000000",
+			"event_record_id":     uint64(1215466),
+			"process_id":          uint32(628),
+			"thread_id":           uint32(7024),
+			"user_sid":            "S-1-5-18",
+			"message":             "An account was successfully logged on.",
+			"level_text":          "Information",
+			"task_text":           "Logon",
+			"opcode_text":         "Info",
+			"keywords_text":       "Audit Success",
+			"data_SubjectUserSid": "S-1-5-18",
+			"data_TargetUserName": "SYSTEM",
+			"data_LogonType":      "5",
+		},
+		event.Fields(true))
+}
+
+func TestParseRenderedEventFullMessage(t *testing.T) {
+	event, err := ParseEvent(renderedEvent)
+	require.NoError(t, err)
+
+	fields := event.Fields(false)
+	require.Equal(t,
+		"An account was successfully logged on.\n\nSubject:\n\tSecurity ID:\t\tSYSTEM",
+		fields["message"])
+}
+
+func TestParseLegacyEvent(t *testing.T) {
+	event, err := ParseEvent(legacyEvent)
+	require.NoError(t, err)
+
+	require.Equal(t,
+		map[string]interface{}{
+			"event_id":        1000,
+			"version":         0,
+			"level":           2,
+			"task":            100,
+			"opcode":          0,
+			"keywords":        "0x80000000000000",
+			"event_record_id": uint64(42),
+			"process_id":      uint32(0),
+			"thread_id":       uint32(0),
+			"level_text":      "Error",
+			"data_1":          "app.exe",
+			"data_2":          "1.0.0.0",
+		},
+		event.Fields(true))
+}
+
+func TestParseInvalidEvent(t *testing.T) {
+	_, err := ParseEvent("<Event>")
+	require.Error(t, err)
+}
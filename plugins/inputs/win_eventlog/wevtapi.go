@@ -0,0 +1,211 @@
+// +build windows
+
+package win_eventlog
+
+import (
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// EvtHandle is a handle to an event log object, such as a subscription, an
+// event or a bookmark.
+type EvtHandle uintptr
+
+// EVT_SUBSCRIBE_FLAGS
+const (
+	EvtSubscribeToFutureEvents      = 1
+	EvtSubscribeStartAtOldestRecord = 2
+	EvtSubscribeStartAfterBookmark  = 3
+)
+
+// EVT_RENDER_FLAGS
+const (
+	EvtRenderEventXml = 1
+	EvtRenderBookmark = 2
+)
+
+// EVT_FORMAT_MESSAGE_FLAGS
+const (
+	EvtFormatMessageXml = 9
+)
+
+// Error codes returned by the event log functions
+const (
+	ERROR_INSUFFICIENT_BUFFER syscall.Errno = 122
+	ERROR_NO_MORE_ITEMS       syscall.Errno = 259
+	ERROR_INVALID_OPERATION   syscall.Errno = 4317
+)
+
+var (
+	modwevtapi = windows.NewLazySystemDLL("wevtapi.dll")
+
+	procEvtSubscribe             = modwevtapi.NewProc("EvtSubscribe")
+	procEvtNext                  = modwevtapi.NewProc("EvtNext")
+	procEvtRender                = modwevtapi.NewProc("EvtRender")
+	procEvtClose                 = modwevtapi.NewProc("EvtClose")
+	procEvtFormatMessage         = modwevtapi.NewProc("EvtFormatMessage")
+	procEvtOpenPublisherMetadata = modwevtapi.NewProc("EvtOpenPublisherMetadata")
+	procEvtCreateBookmark        = modwevtapi.NewProc("EvtCreateBookmark")
+	procEvtUpdateBookmark        = modwevtapi.NewProc("EvtUpdateBookmark")
+)
+
+func callErr(e syscall.Errno) error {
+	if e == 0 {
+		return syscall.EINVAL
+	}
+	return e
+}
+
+// EvtSubscribe creates a pull subscription, signalEvent is set when new
+// events are available.
+func EvtSubscribe(signalEvent windows.Handle, channelPath, query string, bookmark EvtHandle, flags uint32) (EvtHandle, error) {
+	var channelPtr, queryPtr *uint16
+	var err error
+	if channelPath != "" {
+		if channelPtr, err = syscall.UTF16PtrFromString(channelPath); err != nil {
+			return 0, err
+		}
+	}
+	if query != "" {
+		if queryPtr, err = syscall.UTF16PtrFromString(query); err != nil {
+			return 0, err
+		}
+	}
+
+	r0, _, e1 := procEvtSubscribe.Call(
+		0,
+		uintptr(signalEvent),
+		uintptr(unsafe.Pointer(channelPtr)),
+		uintptr(unsafe.Pointer(queryPtr)),
+		uintptr(bookmark),
+		0,
+		0,
+		uintptr(flags))
+	if r0 == 0 {
+		return 0, callErr(e1.(syscall.Errno))
+	}
+	return EvtHandle(r0), nil
+}
+
+// EvtNext reads up to len(events) events from the subscription.
+func EvtNext(resultSet EvtHandle, events []EvtHandle, timeout uint32) (uint32, error) {
+	var returned uint32
+	r0, _, e1 := procEvtNext.Call(
+		uintptr(resultSet),
+		uintptr(len(events)),
+		uintptr(unsafe.Pointer(&events[0])),
+		uintptr(timeout),
+		0,
+		uintptr(unsafe.Pointer(&returned)))
+	if r0 == 0 {
+		return 0, callErr(e1.(syscall.Errno))
+	}
+	return returned, nil
+}
+
+// EvtRender renders an event or a bookmark as XML.
+func EvtRender(fragment EvtHandle, flags uint32) (string, error) {
+	var used, count uint32
+	buf := make([]uint16, 4096)
+	for {
+		r0, _, e1 := procEvtRender.Call(
+			0,
+			uintptr(fragment),
+			uintptr(flags),
+			uintptr(len(buf)*2),
+			uintptr(unsafe.Pointer(&buf[0])),
+			uintptr(unsafe.Pointer(&used)),
+			uintptr(unsafe.Pointer(&count)))
+		if r0 != 0 {
+			return windows.UTF16ToString(buf), nil
+		}
+		if e1.(syscall.Errno) != ERROR_INSUFFICIENT_BUFFER {
+			return "", callErr(e1.(syscall.Errno))
+		}
+		buf = make([]uint16, used/2+1)
+	}
+}
+
+// EvtFormatMessage formats an event using the metadata of its publisher,
+// with EvtFormatMessageXml the event XML is extended with the RenderingInfo
+// element.
+func EvtFormatMessage(publisherMetadata, event EvtHandle, flags uint32) (string, error) {
+	var used uint32
+	buf := make([]uint16, 4096)
+	for {
+		r0, _, e1 := procEvtFormatMessage.Call(
+			uintptr(publisherMetadata),
+			uintptr(event),
+			0,
+			0,
+			0,
+			uintptr(flags),
+			uintptr(len(buf)),
+			uintptr(unsafe.Pointer(&buf[0])),
+			uintptr(unsafe.Pointer(&used)))
+		if r0 != 0 {
+			return windows.UTF16ToString(buf), nil
+		}
+		if e1.(syscall.Errno) != ERROR_INSUFFICIENT_BUFFER {
+			return "", callErr(e1.(syscall.Errno))
+		}
+		buf = make([]uint16, used+1)
+	}
+}
+
+// EvtOpenPublisherMetadata opens the metadata of an event provider.
+func EvtOpenPublisherMetadata(publisher string, locale uint32) (EvtHandle, error) {
+	publisherPtr, err := syscall.UTF16PtrFromString(publisher)
+	if err != nil {
+		return 0, err
+	}
+
+	r0, _, e1 := procEvtOpenPublisherMetadata.Call(
+		0,
+		uintptr(unsafe.Pointer(publisherPtr)),
+		0,
+		uintptr(locale),
+		0)
+	if r0 == 0 {
+		return 0, callErr(e1.(syscall.Errno))
+	}
+	return EvtHandle(r0), nil
+}
+
+// EvtCreateBookmark creates a bookmark, from its XML representation if
+// bookmarkXML is not empty.
+func EvtCreateBookmark(bookmarkXML string) (EvtHandle, error) {
+	var xmlPtr *uint16
+	if bookmarkXML != "" {
+		var err error
+		if xmlPtr, err = syscall.UTF16PtrFromString(bookmarkXML); err != nil {
+			return 0, err
+		}
+	}
+
+	r0, _, e1 := procEvtCreateBookmark.Call(uintptr(unsafe.Pointer(xmlPtr)))
+	if r0 == 0 {
+		return 0, callErr(e1.(syscall.Errno))
+	}
+	return EvtHandle(r0), nil
+}
+
+// EvtUpdateBookmark moves the bookmark to the event.
+func EvtUpdateBookmark(bookmark, event EvtHandle) error {
+	r0, _, e1 := procEvtUpdateBookmark.Call(uintptr(bookmark), uintptr(event))
+	if r0 == 0 {
+		return callErr(e1.(syscall.Errno))
+	}
+	return nil
+}
+
+// EvtClose closes an event log handle.
+func EvtClose(h EvtHandle) error {
+	r0, _, e1 := procEvtClose.Call(uintptr(h))
+	if r0 == 0 {
+		return callErr(e1.(syscall.Errno))
+	}
+	return nil
+}
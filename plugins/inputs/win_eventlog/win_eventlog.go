@@ -0,0 +1,287 @@
+// +build windows
+
+package win_eventlog
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/inputs"
+	"golang.org/x/sys/windows"
+)
+
+var sampleConfig = `
+  ## Name of the event log channel to subscribe to, ie: "Application",
+  ## "System", "Security" or "Microsoft-Windows-Sysmon/Operational".
+  eventlog_name = "Application"
+
+  ## XPath query selecting the events, see
+  ## https://docs.microsoft.com/en-us/windows/desktop/WES/consuming-events
+  ## To subscribe to several channels at once leave eventlog_name empty and
+  ## use a QueryList, ie:
+  ##   xpath_query = '''
+  ##   <QueryList>
+  ##     <Query Id="0">
+  ##       <Select Path="Application">*[System[(Level &lt; 4)]]</Select>
+  ##       <Select Path="System">*[System[(Level &lt; 4)]]</Select>
+  ##     </Query>
+  ##   </QueryList>
+  ##   '''
+  xpath_query = "*"
+
+  ## File where the position in the event log is stored, the subscription
+  ## resumes after the last read event when Telegraf is restarted.  When
+  ## empty events generated while Telegraf was stopped are not read.
+  # bookmark_path = 'C:\Program Files\Telegraf\eventlog_application.xml'
+
+  ## Read all events stored in the channel when there is no bookmark, instead
+  ## of only new events.
+  # from_beginning = false
+
+  ## Maximum number of events read at once.
+  # batch_size = 100
+
+  ## Only keep the first line of the rendered message.
+  # only_first_line_of_message = true
+
+  ## Locale used to render messages, as a Windows LCID.  The default of 0
+  ## uses the locale of the Telegraf process.
+  # locale = 0
+`
+
+type WinEventLog struct {
+	EventlogName           string `toml:"eventlog_name"`
+	Query                  string `toml:"xpath_query"`
+	BookmarkPath           string `toml:"bookmark_path"`
+	FromBeginning          bool   `toml:"from_beginning"`
+	BatchSize              int    `toml:"batch_size"`
+	OnlyFirstLineOfMessage bool   `toml:"only_first_line_of_message"`
+	Locale                 uint32 `toml:"locale"`
+
+	mu           sync.Mutex
+	signal       windows.Handle
+	subscription EvtHandle
+	bookmark     EvtHandle
+	publishers   map[string]EvtHandle
+}
+
+func (w *WinEventLog) Description() string {
+	return "Read events from Windows event log channels"
+}
+
+func (w *WinEventLog) SampleConfig() string {
+	return sampleConfig
+}
+
+func (w *WinEventLog) Start(acc telegraf.Accumulator) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.BatchSize <= 0 {
+		w.BatchSize = 100
+	}
+	w.publishers = make(map[string]EvtHandle)
+
+	var flags uint32 = EvtSubscribeToFutureEvents
+	if w.FromBeginning {
+		flags = EvtSubscribeStartAtOldestRecord
+	}
+
+	var bookmarkXML string
+	if w.BookmarkPath != "" {
+		b, err := ioutil.ReadFile(w.BookmarkPath)
+		if err == nil && len(b) > 0 {
+			bookmarkXML = string(b)
+			flags = EvtSubscribeStartAfterBookmark
+		} else if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("could not read bookmark: %s", err)
+		}
+	}
+
+	bookmark, err := EvtCreateBookmark(bookmarkXML)
+	if err != nil && bookmarkXML != "" {
+		// The stored bookmark is corrupted, start over
+		log.Printf("W! [inputs.win_eventlog] Ignoring invalid bookmark %s: %s", w.BookmarkPath, err)
+		flags = EvtSubscribeToFutureEvents
+		if w.FromBeginning {
+			flags = EvtSubscribeStartAtOldestRecord
+		}
+		bookmark, err = EvtCreateBookmark("")
+	}
+	if err != nil {
+		return fmt.Errorf("could not create bookmark: %s", err)
+	}
+	w.bookmark = bookmark
+
+	w.signal, err = windows.CreateEvent(nil, 1, 1, nil)
+	if err != nil {
+		EvtClose(w.bookmark)
+		return fmt.Errorf("could not create event: %s", err)
+	}
+
+	var subscribeBookmark EvtHandle
+	if flags == EvtSubscribeStartAfterBookmark {
+		subscribeBookmark = w.bookmark
+	}
+
+	w.subscription, err = EvtSubscribe(w.signal, w.EventlogName, w.Query, subscribeBookmark, flags)
+	if err != nil {
+		EvtClose(w.bookmark)
+		windows.CloseHandle(w.signal)
+		return fmt.Errorf("could not subscribe to %q: %s", w.EventlogName, err)
+	}
+
+	return nil
+}
+
+func (w *WinEventLog) Gather(acc telegraf.Accumulator) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.subscription == 0 {
+		return nil
+	}
+
+	events := make([]EvtHandle, w.BatchSize)
+	read := 0
+	for {
+		n, err := EvtNext(w.subscription, events, 0)
+		if err == ERROR_NO_MORE_ITEMS || err == ERROR_INVALID_OPERATION {
+			break
+		}
+		if err != nil {
+			acc.AddError(fmt.Errorf("could not read events: %s", err))
+			break
+		}
+
+		for _, event := range events[:n] {
+			if err := w.addEvent(acc, event); err != nil {
+				acc.AddError(err)
+			}
+			if err := EvtUpdateBookmark(w.bookmark, event); err != nil {
+				acc.AddError(fmt.Errorf("could not update bookmark: %s", err))
+			}
+			EvtClose(event)
+		}
+		read += int(n)
+
+		if int(n) < len(events) {
+			break
+		}
+	}
+
+	if read > 0 {
+		if err := w.saveBookmark(); err != nil {
+			acc.AddError(err)
+		}
+	}
+
+	return nil
+}
+
+func (w *WinEventLog) addEvent(acc telegraf.Accumulator, handle EvtHandle) error {
+	data, err := EvtRender(handle, EvtRenderEventXml)
+	if err != nil {
+		return fmt.Errorf("could not render event: %s", err)
+	}
+
+	event, err := ParseEvent(data)
+	if err != nil {
+		return fmt.Errorf("could not parse event: %s", err)
+	}
+
+	// Format the event with the metadata of its publisher, this adds the
+	// message and the localized names of the level, task and keywords.
+	if publisher := w.publisher(event.Source.Name); publisher != 0 {
+		data, err := EvtFormatMessage(publisher, handle, EvtFormatMessageXml)
+		if err == nil {
+			if formatted, err := ParseEvent(data); err == nil {
+				event = formatted
+			}
+		}
+	}
+
+	acc.AddFields("win_eventlog", event.Fields(w.OnlyFirstLineOfMessage), event.Tags(), event.Time())
+	return nil
+}
+
+// publisher returns the cached metadata handle of a publisher, or zero if the
+// metadata is not available.
+func (w *WinEventLog) publisher(name string) EvtHandle {
+	if h, ok := w.publishers[name]; ok {
+		return h
+	}
+
+	h, err := EvtOpenPublisherMetadata(name, w.Locale)
+	if err != nil {
+		h = 0
+	}
+	w.publishers[name] = h
+	return h
+}
+
+func (w *WinEventLog) saveBookmark() error {
+	if w.BookmarkPath == "" {
+		return nil
+	}
+
+	data, err := EvtRender(w.bookmark, EvtRenderBookmark)
+	if err != nil {
+		return fmt.Errorf("could not render bookmark: %s", err)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(w.BookmarkPath), "bookmark")
+	if err != nil {
+		return fmt.Errorf("could not save bookmark: %s", err)
+	}
+	_, err = tmp.WriteString(data)
+	tmp.Close()
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("could not save bookmark: %s", err)
+	}
+
+	if err := os.Rename(tmp.Name(), w.BookmarkPath); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("could not save bookmark: %s", err)
+	}
+	return nil
+}
+
+func (w *WinEventLog) Stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.subscription == 0 {
+		return
+	}
+
+	if err := w.saveBookmark(); err != nil {
+		log.Printf("E! [inputs.win_eventlog] %s", err)
+	}
+
+	EvtClose(w.subscription)
+	EvtClose(w.bookmark)
+	windows.CloseHandle(w.signal)
+	for _, h := range w.publishers {
+		if h != 0 {
+			EvtClose(h)
+		}
+	}
+	w.subscription = 0
+}
+
+func init() {
+	inputs.Add("win_eventlog", func() telegraf.Input {
+		return &WinEventLog{
+			Query:                  "*",
+			BatchSize:              100,
+			OnlyFirstLineOfMessage: true,
+		}
+	})
+}
@@ -4,8 +4,13 @@ package win_services
 
 import (
 	"fmt"
+	"log"
+	"strings"
+
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
 	"github.com/influxdata/telegraf/plugins/inputs"
+	"golang.org/x/sys/windows"
 	"golang.org/x/sys/windows/svc"
 	"golang.org/x/sys/windows/svc/mgr"
 )
@@ -59,19 +64,26 @@ func (rmr *MgProvider) Connect() (WinServiceManager, error) {
 }
 
 var sampleConfig = `
-  ## Names of the services to monitor. Leave empty to monitor all the available services on the host
+  ## Names of the services to monitor. Leave empty to monitor all the available services on the host.
+  ## Glob patterns are supported, ie: "SQL*".
   service_names = [
     "LanmanServer",
     "TermService",
   ]
+
+  ## Names of the services to ignore, glob patterns are supported.
+  # excluded_service_names = []
 `
 
 var description = "Input plugin to report Windows services info."
 
 //WinServices is an implementation if telegraf.Input interface, providing info about Windows Services
 type WinServices struct {
-	ServiceNames []string `toml:"service_names"`
-	mgrProvider  WinServiceManagerProvider
+	ServiceNames         []string `toml:"service_names"`
+	ServiceNamesExcluded []string `toml:"excluded_service_names"`
+	mgrProvider          WinServiceManagerProvider
+
+	servicesFilter filter.Filter
 }
 
 type ServiceInfo struct {
@@ -80,6 +92,8 @@ type ServiceInfo struct {
 	State       int
 	StartUpMode int
 	Error       error
+	//AccessDenied is set when the Error was caused by missing permissions
+	AccessDenied bool
 }
 
 func (m *WinServices) Description() string {
@@ -91,14 +105,32 @@ func (m *WinServices) SampleConfig() string {
 }
 
 func (m *WinServices) Gather(acc telegraf.Accumulator) error {
+	if m.servicesFilter == nil && m.hasFilter() {
+		f, err := filter.NewIncludeExcludeFilter(m.ServiceNames, m.ServiceNamesExcluded)
+		if err != nil {
+			return fmt.Errorf("Could not compile services filter: %s", err)
+		}
+		m.servicesFilter = f
+	}
 
-	serviceInfos, err := listServices(m.mgrProvider, m.ServiceNames)
+	var serviceInfos []ServiceInfo
+	var err error
+	if m.servicesFilter != nil {
+		serviceInfos, err = listFilteredServices(m.mgrProvider, m.servicesFilter)
+	} else {
+		serviceInfos, err = listServices(m.mgrProvider, m.ServiceNames)
+	}
 
 	if err != nil {
 		return err
 	}
 
 	for _, service := range serviceInfos {
+		if service.AccessDenied {
+			//services protected from the current user are skipped, as they would fail on every gather
+			log.Printf("D! [inputs.win_services] %s", service.Error)
+			continue
+		}
 		if service.Error == nil {
 			fields := make(map[string]interface{})
 			tags := make(map[string]string)
@@ -121,6 +153,43 @@ func (m *WinServices) Gather(acc telegraf.Accumulator) error {
 	return nil
 }
 
+// hasFilter returns true if the services have to be filtered, that is if an exclusion or a glob pattern is used
+func (m *WinServices) hasFilter() bool {
+	if len(m.ServiceNamesExcluded) > 0 {
+		return true
+	}
+	for _, name := range m.ServiceNames {
+		if strings.ContainsAny(name, "*?[") {
+			return true
+		}
+	}
+	return false
+}
+
+// listFilteredServices gathers info about all services on current Windows host matching the filter. Any a critical error is returned.
+func listFilteredServices(mgrProv WinServiceManagerProvider, servicesFilter filter.Filter) ([]ServiceInfo, error) {
+	scmgr, err := mgrProv.Connect()
+	if err != nil {
+		return nil, fmt.Errorf("Could not open service manager: %s", err)
+	}
+	defer scmgr.Disconnect()
+
+	serviceNames, err := scmgr.ListServices()
+	if err != nil {
+		return nil, fmt.Errorf("Could not list services: %s", err)
+	}
+
+	var serviceInfos []ServiceInfo
+	for _, srvName := range serviceNames {
+		if !servicesFilter.Match(srvName) {
+			continue
+		}
+		serviceInfos = append(serviceInfos, collectServiceInfo(scmgr, srvName))
+	}
+
+	return serviceInfos, nil
+}
+
 //listServices gathers info about given services. If userServices is empty, it return info about all services on current Windows host.  Any a critical error is returned.
 func listServices(mgrProv WinServiceManagerProvider, userServices []string) ([]ServiceInfo, error) {
 	scmgr, err := mgrProv.Connect()
@@ -155,6 +224,7 @@ func collectServiceInfo(scmgr WinServiceManager, serviceName string) (serviceInf
 	srv, err := scmgr.OpenService(serviceName)
 	if err != nil {
 		serviceInfo.Error = fmt.Errorf("Could not open service '%s': %s", serviceName, err)
+		serviceInfo.AccessDenied = err == windows.ERROR_ACCESS_DENIED
 		return
 	}
 	defer srv.Close()
@@ -164,6 +234,7 @@ func collectServiceInfo(scmgr WinServiceManager, serviceName string) (serviceInf
 		serviceInfo.State = int(srvStatus.State)
 	} else {
 		serviceInfo.Error = fmt.Errorf("Could not query service '%s': %s", serviceName, err)
+		serviceInfo.AccessDenied = err == windows.ERROR_ACCESS_DENIED
 		//finish collecting info on first found error
 		return
 	}
@@ -174,6 +245,7 @@ func collectServiceInfo(scmgr WinServiceManager, serviceName string) (serviceInf
 		serviceInfo.StartUpMode = int(srvCfg.StartType)
 	} else {
 		serviceInfo.Error = fmt.Errorf("Could not get config of service '%s': %s", serviceName, err)
+		serviceInfo.AccessDenied = err == windows.ERROR_ACCESS_DENIED
 	}
 	return
 }
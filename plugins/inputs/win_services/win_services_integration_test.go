@@ -53,7 +53,7 @@ func TestGather(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration test in short mode")
 	}
-	ws := &WinServices{KnownServices, &MgProvider{}}
+	ws := &WinServices{ServiceNames: KnownServices, mgrProvider: &MgProvider{}}
 	assert.Len(t, ws.ServiceNames, 2, "Different number of services")
 	var acc testutil.Accumulator
 	require.NoError(t, ws.Gather(&acc))
@@ -75,7 +75,7 @@ func TestGatherErrors(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping integration test in short mode")
 	}
-	ws := &WinServices{InvalidServices, &MgProvider{}}
+	ws := &WinServices{ServiceNames: InvalidServices, mgrProvider: &MgProvider{}}
 	assert.Len(t, ws.ServiceNames, 3, "Different number of services")
 	var acc testutil.Accumulator
 	require.NoError(t, ws.Gather(&acc))
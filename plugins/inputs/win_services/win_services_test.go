@@ -8,6 +8,7 @@ import (
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/windows"
 	"golang.org/x/sys/windows/svc"
 	"golang.org/x/sys/windows/svc/mgr"
 	"testing"
@@ -110,28 +111,28 @@ var testErrors = []testData{
 
 func TestBasicInfo(t *testing.T) {
 
-	winServices := &WinServices{nil, &FakeMgProvider{testErrors[0]}}
+	winServices := &WinServices{mgrProvider: &FakeMgProvider{testErrors[0]}}
 	assert.NotEmpty(t, winServices.SampleConfig())
 	assert.NotEmpty(t, winServices.Description())
 }
 
 func TestMgrErrors(t *testing.T) {
 	//mgr.connect error
-	winServices := &WinServices{nil, &FakeMgProvider{testErrors[0]}}
+	winServices := &WinServices{mgrProvider: &FakeMgProvider{testErrors[0]}}
 	var acc1 testutil.Accumulator
 	err := winServices.Gather(&acc1)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), testErrors[0].mgrConnectError.Error())
 
 	////mgr.listServices error
-	winServices = &WinServices{nil, &FakeMgProvider{testErrors[1]}}
+	winServices = &WinServices{mgrProvider: &FakeMgProvider{testErrors[1]}}
 	var acc2 testutil.Accumulator
 	err = winServices.Gather(&acc2)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), testErrors[1].mgrListServicesError.Error())
 
 	////mgr.listServices error 2
-	winServices = &WinServices{[]string{"Fake service 1"}, &FakeMgProvider{testErrors[3]}}
+	winServices = &WinServices{ServiceNames: []string{"Fake service 1"}, mgrProvider: &FakeMgProvider{testErrors[3]}}
 	var acc3 testutil.Accumulator
 	err = winServices.Gather(&acc3)
 	require.NoError(t, err)
@@ -140,7 +141,7 @@ func TestMgrErrors(t *testing.T) {
 }
 
 func TestServiceErrors(t *testing.T) {
-	winServices := &WinServices{nil, &FakeMgProvider{testErrors[2]}}
+	winServices := &WinServices{mgrProvider: &FakeMgProvider{testErrors[2]}}
 	var acc1 testutil.Accumulator
 	require.NoError(t, winServices.Gather(&acc1))
 	assert.Len(t, acc1.Errors, 3)
@@ -161,7 +162,7 @@ var testSimpleData = []testData{
 }
 
 func TestGather2(t *testing.T) {
-	winServices := &WinServices{nil, &FakeMgProvider{testSimpleData[0]}}
+	winServices := &WinServices{mgrProvider: &FakeMgProvider{testSimpleData[0]}}
 	var acc1 testutil.Accumulator
 	require.NoError(t, winServices.Gather(&acc1))
 	assert.Len(t, acc1.Errors, 0, "There should be no errors after gather")
@@ -177,3 +178,48 @@ func TestGather2(t *testing.T) {
 	}
 
 }
+
+var testFilterData = []testData{
+	{[]string{"SQLAgent", "SQLBrowser", "SQLWriter", "Spooler", "WinDefend"}, nil, nil, []serviceTestInfo{
+		{nil, nil, nil, "SQLAgent", "SQL Server Agent", 4, 2},
+		{nil, nil, nil, "SQLBrowser", "SQL Server Browser", 1, 4},
+		{nil, nil, nil, "SQLWriter", "SQL Server VSS Writer", 4, 2},
+		{nil, nil, nil, "Spooler", "Print Spooler", 4, 2},
+		{windows.ERROR_ACCESS_DENIED, nil, nil, "WinDefend", "", 0, 0},
+	}},
+}
+
+func TestGatherFilter(t *testing.T) {
+	winServices := &WinServices{
+		ServiceNames:         []string{"SQL*", "WinDefend"},
+		ServiceNamesExcluded: []string{"SQLWriter"},
+		mgrProvider:          &FakeMgProvider{testFilterData[0]},
+	}
+	var acc1 testutil.Accumulator
+	require.NoError(t, winServices.Gather(&acc1))
+	//access denied services are skipped without error
+	assert.Len(t, acc1.Errors, 0, "There should be no errors after gather")
+	assert.Len(t, acc1.Metrics, 2)
+
+	for _, s := range testFilterData[0].services[:2] {
+		fields := make(map[string]interface{})
+		tags := make(map[string]string)
+		fields["state"] = int(s.state)
+		fields["startup_mode"] = int(s.startUpMode)
+		tags["service_name"] = s.serviceName
+		tags["display_name"] = s.displayName
+		acc1.AssertContainsTaggedFields(t, "win_services", fields, tags)
+	}
+}
+
+func TestGatherExcludeAll(t *testing.T) {
+	winServices := &WinServices{
+		ServiceNamesExcluded: []string{"SQL*"},
+		mgrProvider:          &FakeMgProvider{testFilterData[0]},
+	}
+	var acc1 testutil.Accumulator
+	require.NoError(t, winServices.Gather(&acc1))
+	assert.Len(t, acc1.Errors, 0, "There should be no errors after gather")
+	assert.Len(t, acc1.Metrics, 1)
+	assert.Equal(t, "Spooler", acc1.TagValue("win_services", "service_name"))
+}
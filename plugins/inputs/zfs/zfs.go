@@ -1,14 +1,27 @@
 package zfs
 
+import (
+	"github.com/influxdata/telegraf/filter"
+)
+
 type Sysctl func(metric string) ([]string, error)
 type Zpool func() ([]string, error)
+type ZpoolIostat func(args ...string) ([]string, error)
+type ZfsList func() ([]string, error)
 
 type Zfs struct {
-	KstatPath    string
-	KstatMetrics []string
-	PoolMetrics  bool
-	sysctl       Sysctl
-	zpool        Zpool
+	KstatPath         string
+	KstatMetrics      []string
+	PoolMetrics       bool
+	LatencyMetrics    bool
+	LatencyHistograms bool
+	DatasetMetrics    bool
+	Datasets          []string
+	sysctl            Sysctl
+	zpool             Zpool
+	zpoolIostat       ZpoolIostat
+	zfsList           ZfsList
+	datasetFilter     filter.Filter
 }
 
 var sampleConfig = `
@@ -24,6 +37,19 @@ var sampleConfig = `
   #   "dmu_tx", "fm", "vdev_mirror_stats", "zfetchstats", "zil"]
   ## By default, don't gather zpool stats
   # poolMetrics = false
+
+  ## Gather the average latencies and queue depths of the pools, from
+  ## 'zpool iostat -l' and 'zpool iostat -q'
+  # latencyMetrics = false
+
+  ## Gather the latency histograms of the pools, from 'zpool iostat -w'
+  # latencyHistograms = false
+
+  ## Gather the space usage of the datasets, from 'zfs list'
+  # datasetMetrics = false
+  ## Datasets to gather, all datasets are gathered if empty. Globs are
+  ## supported, ie ["tank/home/*"]
+  # datasets = []
 `
 
 func (z *Zfs) SampleConfig() string {
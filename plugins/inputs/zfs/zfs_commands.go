@@ -0,0 +1,290 @@
+// +build linux freebsd
+
+package zfs
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+)
+
+// columns of 'zpool iostat -l' and 'zpool iostat -w' following the pool
+// name, the capacity and the operations and bandwidth columns, the last ones
+// depending on the version of ZFS
+var latencyColumns = []string{
+	"total_wait_read", "total_wait_write",
+	"disk_wait_read", "disk_wait_write",
+	"syncq_wait_read", "syncq_wait_write",
+	"asyncq_wait_read", "asyncq_wait_write",
+	"scrub_wait", "trim_wait", "rebuild_wait",
+}
+
+// columns of 'zpool iostat -q' following the operations and bandwidth
+// columns
+var queueColumns = []string{
+	"syncq_read_pend", "syncq_read_activ",
+	"syncq_write_pend", "syncq_write_activ",
+	"asyncq_read_pend", "asyncq_read_activ",
+	"asyncq_write_pend", "asyncq_write_activ",
+	"scrubq_read_pend", "scrubq_read_activ",
+	"trimq_write_pend", "trimq_write_activ",
+	"rebuildq_write_pend", "rebuildq_write_activ",
+}
+
+// columns of 'zpool iostat' common to -l and -q
+var iostatColumns = []string{
+	"pool", "alloc", "free", "read_ops", "write_ops", "read_bytes", "write_bytes",
+}
+
+// parsePoolList parses a line of 'zpool list -Hp'
+func parsePoolList(line string) (map[string]string, map[string]interface{}, error) {
+	col := strings.Split(line, "\t")
+	if len(col) < 9 {
+		return nil, nil, fmt.Errorf("Unexpected zpool list output: %q", line)
+	}
+
+	tags := map[string]string{"pool": col[0], "health": col[8]}
+	fields := map[string]interface{}{}
+
+	if tags["health"] == "UNAVAIL" {
+		fields["size"] = int64(0)
+		return tags, fields, nil
+	}
+
+	size, err := strconv.ParseInt(col[1], 10, 64)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error parsing size: %s", err)
+	}
+	fields["size"] = size
+
+	alloc, err := strconv.ParseInt(col[2], 10, 64)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error parsing allocation: %s", err)
+	}
+	fields["allocated"] = alloc
+
+	free, err := strconv.ParseInt(col[3], 10, 64)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error parsing free: %s", err)
+	}
+	fields["free"] = free
+
+	frag, err := strconv.ParseInt(strings.TrimSuffix(col[5], "%"), 10, 0)
+	if err != nil { // This might be - for RO devs
+		frag = 0
+	}
+	fields["fragmentation"] = frag
+
+	capval, err := strconv.ParseInt(col[6], 10, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error parsing capacity: %s", err)
+	}
+	fields["capacity"] = capval
+
+	dedup, err := strconv.ParseFloat(strings.TrimSuffix(col[7], "x"), 32)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error parsing dedupratio: %s", err)
+	}
+	fields["dedupratio"] = dedup
+
+	return tags, fields, nil
+}
+
+// gatherLatencyStats adds the average latencies and the queue depths of the
+// pools in the zfs_pool_latency measurement
+func (z *Zfs) gatherLatencyStats(acc telegraf.Accumulator) error {
+	latencies, err := z.zpoolIostat("-l")
+	if err != nil {
+		return err
+	}
+	queues, err := z.zpoolIostat("-q")
+	if err != nil {
+		return err
+	}
+
+	pools := make(map[string]map[string]interface{})
+	var order []string
+	for _, output := range []struct {
+		lines   []string
+		columns []string
+	}{
+		{latencies, latencyColumns},
+		{queues, queueColumns},
+	} {
+		for _, line := range output.lines {
+			col := strings.Split(line, "\t")
+			if len(col) < len(iostatColumns) {
+				continue
+			}
+
+			fields, ok := pools[col[0]]
+			if !ok {
+				fields = make(map[string]interface{})
+				pools[col[0]] = fields
+				order = append(order, col[0])
+			}
+
+			names := make([]string, 0, len(iostatColumns)+len(output.columns))
+			names = append(names, iostatColumns[3:]...)
+			names = append(names, output.columns...)
+			for i, value := range col[3:] {
+				if i >= len(names) {
+					break
+				}
+				if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+					fields[names[i]] = v
+				}
+			}
+		}
+	}
+
+	for _, pool := range order {
+		acc.AddFields("zfs_pool_latency", pools[pool], map[string]string{"pool": pool})
+	}
+	return nil
+}
+
+// gatherLatencyHistograms adds the number of I/O operations per latency
+// bucket of the pools, a metric per bucket
+func (z *Zfs) gatherLatencyHistograms(acc telegraf.Accumulator, pools []string) error {
+	for _, pool := range pools {
+		lines, err := z.zpoolIostat("-w", pool)
+		if err != nil {
+			return err
+		}
+
+		for _, line := range lines {
+			col := strings.Fields(line)
+			if len(col) < 2 {
+				continue
+			}
+			// the rows of the buckets start with the upper bound of the
+			// bucket, other rows hold the name of the pool
+			if _, err := strconv.ParseUint(col[0], 10, 64); err != nil {
+				continue
+			}
+
+			fields := make(map[string]interface{})
+			for i, value := range col[1:] {
+				if i >= len(latencyColumns) {
+					break
+				}
+				if v, err := strconv.ParseInt(value, 10, 64); err == nil {
+					fields[latencyColumns[i]] = v
+				}
+			}
+			tags := map[string]string{"pool": pool, "bucket_ns": col[0]}
+			acc.AddFields("zfs_pool_latency_histogram", fields, tags)
+		}
+	}
+	return nil
+}
+
+// gatherDatasetStats adds the space usage of the datasets matching the
+// datasets filter
+func (z *Zfs) gatherDatasetStats(acc telegraf.Accumulator) error {
+	if z.datasetFilter == nil && len(z.Datasets) > 0 {
+		f, err := filter.Compile(z.Datasets)
+		if err != nil {
+			return err
+		}
+		z.datasetFilter = f
+	}
+
+	lines, err := z.zfsList()
+	if err != nil {
+		return err
+	}
+
+	names := []string{"used", "available", "referenced", "used_by_snapshots", "used_by_dataset"}
+	for _, line := range lines {
+		col := strings.Split(line, "\t")
+		if len(col) < len(names)+1 {
+			continue
+		}
+
+		dataset := col[0]
+		if z.datasetFilter != nil && !z.datasetFilter.Match(dataset) {
+			continue
+		}
+
+		fields := make(map[string]interface{})
+		for i, name := range names {
+			if v, err := strconv.ParseInt(col[i+1], 10, 64); err == nil {
+				fields[name] = v
+			}
+		}
+		tags := map[string]string{
+			"dataset": dataset,
+			"pool":    strings.SplitN(dataset, "/", 2)[0],
+		}
+		acc.AddFields("zfs_dataset", fields, tags)
+	}
+	return nil
+}
+
+// gatherExtraStats gathers the latency and dataset metrics when enabled
+func (z *Zfs) gatherExtraStats(acc telegraf.Accumulator, pools []string) {
+	if (z.LatencyMetrics || z.LatencyHistograms) && z.zpoolIostat == nil {
+		acc.AddError(fmt.Errorf("zpool command not found"))
+	} else {
+		if z.LatencyMetrics {
+			if err := z.gatherLatencyStats(acc); err != nil {
+				acc.AddError(err)
+			}
+		}
+		if z.LatencyHistograms {
+			if err := z.gatherLatencyHistograms(acc, pools); err != nil {
+				acc.AddError(err)
+			}
+		}
+	}
+
+	if z.DatasetMetrics {
+		if z.zfsList == nil {
+			acc.AddError(fmt.Errorf("zfs command not found"))
+		} else if err := z.gatherDatasetStats(acc); err != nil {
+			acc.AddError(err)
+		}
+	}
+}
+
+func run(command string, args ...string) ([]string, error) {
+	cmd := exec.Command(command, args...)
+	var outbuf, errbuf bytes.Buffer
+	cmd.Stdout = &outbuf
+	cmd.Stderr = &errbuf
+	err := cmd.Run()
+
+	stdout := strings.TrimSpace(outbuf.String())
+	stderr := strings.TrimSpace(errbuf.String())
+
+	if _, ok := err.(*exec.ExitError); ok {
+		return nil, fmt.Errorf("%s error: %s", command, stderr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(stdout, "\n"), nil
+}
+
+// zpool lists the pools with explicit columns, recent versions of ZFS having
+// additional default columns
+func zpool() ([]string, error) {
+	return run("zpool", []string{"list", "-Hp", "-o",
+		"name,size,allocated,free,expandsize,fragmentation,capacity,dedupratio,health,altroot"}...)
+}
+
+func zpoolIostat(args ...string) ([]string, error) {
+	return run("zpool", append([]string{"iostat", "-Hp"}, args...)...)
+}
+
+func zfsList() ([]string, error) {
+	return run("zfs", []string{"list", "-Hp", "-t", "filesystem,volume",
+		"-o", "name,used,avail,refer,usedsnap,usedds"}...)
+}
@@ -161,6 +161,40 @@ func TestZfsGeneratesMetrics(t *testing.T) {
 	acc.AssertContainsTaggedFields(t, "zfs", intMetrics, tags)
 }
 
+// $ zfs list -Hp -t filesystem,volume -o name,used,avail,refer,usedsnap,usedds
+var zfs_list_output = []string{
+	"red1	1126164848640	7520545005568	98304	0	98304",
+	"red1/media	1126164750336	7520545005568	1126164750336	0	1126164750336",
+}
+
+func mock_zfs_list() ([]string, error) {
+	return zfs_list_output, nil
+}
+
+func TestZfsDatasetMetrics(t *testing.T) {
+	var acc testutil.Accumulator
+
+	z := &Zfs{
+		KstatMetrics:   []string{"vdev_cache_stats"},
+		DatasetMetrics: true,
+		sysctl:         mock_sysctl,
+		zpool:          mock_zpool,
+		zfsList:        mock_zfs_list,
+	}
+	err := z.Gather(&acc)
+	require.NoError(t, err)
+
+	acc.AssertContainsTaggedFields(t, "zfs_dataset",
+		map[string]interface{}{
+			"used":              int64(1126164750336),
+			"available":         int64(7520545005568),
+			"referenced":        int64(1126164750336),
+			"used_by_snapshots": int64(0),
+			"used_by_dataset":   int64(1126164750336),
+		},
+		map[string]string{"pool": "red1", "dataset": "red1/media"})
+}
+
 func getFreeNasBootPoolMetrics() map[string]interface{} {
 	return map[string]interface{}{
 		"allocated":     int64(2022177280),
@@ -4,6 +4,7 @@ package zfs
 
 import (
 	"fmt"
+	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -44,7 +45,33 @@ func getTags(pools []poolInfo) map[string]string {
 	return map[string]string{"pools": poolNames}
 }
 
-func gatherPoolStats(pool poolInfo, acc telegraf.Accumulator) error {
+// poolList holds the tags and fields of a pool in 'zpool list'
+type poolList struct {
+	tags   map[string]string
+	fields map[string]interface{}
+}
+
+// listPools returns the health and capacity of the pools
+func (z *Zfs) listPools() (map[string]poolList, []string, error) {
+	lines, err := z.zpool()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	list := make(map[string]poolList)
+	var names []string
+	for _, line := range lines {
+		tags, fields, err := parsePoolList(line)
+		if err != nil {
+			return nil, nil, err
+		}
+		list[tags["pool"]] = poolList{tags: tags, fields: fields}
+		names = append(names, tags["pool"])
+	}
+	return list, names, nil
+}
+
+func gatherPoolStats(pool poolInfo, list poolList, acc telegraf.Accumulator) error {
 	lines, err := internal.ReadLines(pool.ioFilename)
 	if err != nil {
 		return err
@@ -65,6 +92,12 @@ func gatherPoolStats(pool poolInfo, acc telegraf.Accumulator) error {
 
 	tag := map[string]string{"pool": pool.name}
 	fields := make(map[string]interface{})
+	for k, v := range list.tags {
+		tag[k] = v
+	}
+	for k, v := range list.fields {
+		fields[k] = v
+	}
 	for i := 0; i < keyCount; i++ {
 		value, err := strconv.ParseInt(values[i], 10, 64)
 		if err != nil {
@@ -95,15 +128,43 @@ func (z *Zfs) Gather(acc telegraf.Accumulator) error {
 	pools := getPools(kstatPath)
 	tags := getTags(pools)
 
+	// the health and capacity of the pools are only available from the
+	// zpool command
+	var list map[string]poolList
+	poolNames := make([]string, 0, len(pools))
+	for _, pool := range pools {
+		poolNames = append(poolNames, pool.name)
+	}
+	if z.zpool != nil && (z.PoolMetrics || z.LatencyHistograms) {
+		var err error
+		var names []string
+		list, names, err = z.listPools()
+		if err != nil {
+			acc.AddError(err)
+		}
+		if len(pools) == 0 {
+			// recent versions of ZFS have no io kstat
+			poolNames = names
+		}
+	}
+
 	if z.PoolMetrics {
 		for _, pool := range pools {
-			err := gatherPoolStats(pool, acc)
+			err := gatherPoolStats(pool, list[pool.name], acc)
 			if err != nil {
 				return err
 			}
+			delete(list, pool.name)
+		}
+		for _, name := range poolNames {
+			if pool, ok := list[name]; ok {
+				acc.AddFields("zfs_pool", pool.fields, pool.tags)
+			}
 		}
 	}
 
+	z.gatherExtraStats(acc, poolNames)
+
 	fields := make(map[string]interface{})
 	for _, metric := range kstatMetrics {
 		lines, err := internal.ReadLines(kstatPath + "/" + metric)
@@ -133,6 +194,16 @@ func (z *Zfs) Gather(acc telegraf.Accumulator) error {
 
 func init() {
 	inputs.Add("zfs", func() telegraf.Input {
-		return &Zfs{}
+		z := &Zfs{}
+		// the kstats are read from procfs, the zpool and zfs commands are
+		// only required by the pool health, latency and dataset metrics
+		if _, err := exec.LookPath("zpool"); err == nil {
+			z.zpool = zpool
+			z.zpoolIostat = zpoolIostat
+		}
+		if _, err := exec.LookPath("zfs"); err == nil {
+			z.zfsList = zfsList
+		}
+		return z
 	})
 }
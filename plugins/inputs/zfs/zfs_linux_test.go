@@ -3,6 +3,7 @@
 package zfs
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"testing"
@@ -538,3 +539,223 @@ func getPoolMetrics() map[string]interface{} {
 		"rcnt":     int64(0),
 	}
 }
+
+// $ zpool list -Hp -o name,size,allocated,free,expandsize,fragmentation,capacity,dedupratio,health,altroot
+var zpoolListOutput = []string{
+	"HOME	1992864825344	694958080000	1297906745344	-	12	34	1.00x	DEGRADED	-",
+}
+
+// $ zpool iostat -Hp -l
+var zpoolIostatLatencyOutput = []string{
+	"HOME	694958080000	1297906745344	12	98	405504	3108864	1425632	3524384	1012455	2233765	4012	13211	-	601236	-	-	-",
+}
+
+// $ zpool iostat -Hp -q
+var zpoolIostatQueueOutput = []string{
+	"HOME	694958080000	1297906745344	12	98	405504	3108864	0	0	0	1	0	0	3	2	0	0	0	0",
+}
+
+// $ zpool iostat -Hp -w HOME
+var zpoolIostatHistogramOutput = []string{
+	"HOME",
+	"1	0	0	0	0	0	0	0	0	0	0",
+	"1023	0	3	0	5	12	48	0	21	0	0",
+	"2047	2	17	4	22	3	10	1	94	0	0",
+}
+
+// $ zfs list -Hp -t filesystem,volume -o name,used,avail,refer,usedsnap,usedds
+var zfsListOutput = []string{
+	"HOME	694958080000	1234567	98304	0	98304",
+	"HOME/user	694900000000	1234567	594900000000	100000000000	594900000000",
+	"HOME/vm	4096	1234567	4096	-	4096",
+}
+
+func mockZpool() ([]string, error) {
+	return zpoolListOutput, nil
+}
+
+func mockZpoolIostat(args ...string) ([]string, error) {
+	switch args[0] {
+	case "-l":
+		return zpoolIostatLatencyOutput, nil
+	case "-q":
+		return zpoolIostatQueueOutput, nil
+	case "-w":
+		return zpoolIostatHistogramOutput, nil
+	}
+	return nil, fmt.Errorf("Invalid arg")
+}
+
+func mockZfsList() ([]string, error) {
+	return zfsListOutput, nil
+}
+
+func TestZfsPoolList(t *testing.T) {
+	err := os.MkdirAll(testKstatPath+"/HOME", 0755)
+	require.NoError(t, err)
+	defer os.RemoveAll(os.TempDir() + "/telegraf")
+
+	err = ioutil.WriteFile(testKstatPath+"/HOME/io", []byte(pool_ioContents), 0644)
+	require.NoError(t, err)
+
+	var acc testutil.Accumulator
+	z := &Zfs{
+		KstatPath:    testKstatPath,
+		KstatMetrics: []string{"arcstats"},
+		PoolMetrics:  true,
+		zpool:        mockZpool,
+	}
+	require.NoError(t, acc.GatherError(z.Gather))
+
+	poolMetrics := getPoolMetrics()
+	poolMetrics["size"] = int64(1992864825344)
+	poolMetrics["allocated"] = int64(694958080000)
+	poolMetrics["free"] = int64(1297906745344)
+	poolMetrics["fragmentation"] = int64(12)
+	poolMetrics["capacity"] = int64(34)
+	poolMetrics["dedupratio"] = float64(1)
+
+	acc.AssertContainsTaggedFields(t, "zfs_pool", poolMetrics,
+		map[string]string{"pool": "HOME", "health": "DEGRADED"})
+}
+
+func TestZfsPoolListWithoutKstat(t *testing.T) {
+	err := os.MkdirAll(testKstatPath, 0755)
+	require.NoError(t, err)
+	defer os.RemoveAll(os.TempDir() + "/telegraf")
+
+	var acc testutil.Accumulator
+	z := &Zfs{
+		KstatPath:    testKstatPath,
+		KstatMetrics: []string{"arcstats"},
+		PoolMetrics:  true,
+		zpool:        mockZpool,
+	}
+	require.NoError(t, acc.GatherError(z.Gather))
+
+	acc.AssertContainsTaggedFields(t, "zfs_pool",
+		map[string]interface{}{
+			"size":          int64(1992864825344),
+			"allocated":     int64(694958080000),
+			"free":          int64(1297906745344),
+			"fragmentation": int64(12),
+			"capacity":      int64(34),
+			"dedupratio":    float64(1),
+		},
+		map[string]string{"pool": "HOME", "health": "DEGRADED"})
+}
+
+func TestZfsLatencyMetrics(t *testing.T) {
+	err := os.MkdirAll(testKstatPath+"/HOME", 0755)
+	require.NoError(t, err)
+	defer os.RemoveAll(os.TempDir() + "/telegraf")
+
+	err = ioutil.WriteFile(testKstatPath+"/HOME/io", []byte(pool_ioContents), 0644)
+	require.NoError(t, err)
+
+	var acc testutil.Accumulator
+	z := &Zfs{
+		KstatPath:         testKstatPath,
+		KstatMetrics:      []string{"arcstats"},
+		LatencyMetrics:    true,
+		LatencyHistograms: true,
+		zpoolIostat:       mockZpoolIostat,
+	}
+	require.NoError(t, acc.GatherError(z.Gather))
+
+	acc.AssertContainsTaggedFields(t, "zfs_pool_latency",
+		map[string]interface{}{
+			"read_ops":           int64(12),
+			"write_ops":          int64(98),
+			"read_bytes":         int64(405504),
+			"write_bytes":        int64(3108864),
+			"total_wait_read":    int64(1425632),
+			"total_wait_write":   int64(3524384),
+			"disk_wait_read":     int64(1012455),
+			"disk_wait_write":    int64(2233765),
+			"syncq_wait_read":    int64(4012),
+			"syncq_wait_write":   int64(13211),
+			"asyncq_wait_write":  int64(601236),
+			"syncq_read_pend":    int64(0),
+			"syncq_read_activ":   int64(0),
+			"syncq_write_pend":   int64(0),
+			"syncq_write_activ":  int64(1),
+			"asyncq_read_pend":   int64(0),
+			"asyncq_read_activ":  int64(0),
+			"asyncq_write_pend":  int64(3),
+			"asyncq_write_activ": int64(2),
+			"scrubq_read_pend":   int64(0),
+			"scrubq_read_activ":  int64(0),
+			"trimq_write_pend":   int64(0),
+			"trimq_write_activ":  int64(0),
+		},
+		map[string]string{"pool": "HOME"})
+
+	acc.AssertContainsTaggedFields(t, "zfs_pool_latency_histogram",
+		map[string]interface{}{
+			"total_wait_read":   int64(0),
+			"total_wait_write":  int64(3),
+			"disk_wait_read":    int64(0),
+			"disk_wait_write":   int64(5),
+			"syncq_wait_read":   int64(12),
+			"syncq_wait_write":  int64(48),
+			"asyncq_wait_read":  int64(0),
+			"asyncq_wait_write": int64(21),
+			"scrub_wait":        int64(0),
+			"trim_wait":         int64(0),
+		},
+		map[string]string{"pool": "HOME", "bucket_ns": "1023"})
+
+	count := 0
+	for _, m := range acc.Metrics {
+		if m.Measurement == "zfs_pool_latency_histogram" {
+			count++
+		}
+	}
+	require.Equal(t, 3, count)
+}
+
+func TestZfsLatencyMetricsWithoutZpool(t *testing.T) {
+	var acc testutil.Accumulator
+	z := &Zfs{
+		KstatPath:      testKstatPath,
+		KstatMetrics:   []string{"arcstats"},
+		LatencyMetrics: true,
+	}
+	require.Error(t, acc.GatherError(z.Gather))
+}
+
+func TestZfsDatasetMetrics(t *testing.T) {
+	var acc testutil.Accumulator
+	z := &Zfs{
+		KstatPath:      testKstatPath,
+		KstatMetrics:   []string{"arcstats"},
+		DatasetMetrics: true,
+		Datasets:       []string{"HOME/*"},
+		zfsList:        mockZfsList,
+	}
+	require.NoError(t, acc.GatherError(z.Gather))
+
+	acc.AssertContainsTaggedFields(t, "zfs_dataset",
+		map[string]interface{}{
+			"used":              int64(694900000000),
+			"available":         int64(1234567),
+			"referenced":        int64(594900000000),
+			"used_by_snapshots": int64(100000000000),
+			"used_by_dataset":   int64(594900000000),
+		},
+		map[string]string{"pool": "HOME", "dataset": "HOME/user"})
+
+	acc.AssertContainsTaggedFields(t, "zfs_dataset",
+		map[string]interface{}{
+			"used":            int64(4096),
+			"available":       int64(1234567),
+			"referenced":      int64(4096),
+			"used_by_dataset": int64(4096),
+		},
+		map[string]string{"pool": "HOME", "dataset": "HOME/vm"})
+
+	for _, m := range acc.Metrics {
+		require.NotEqual(t, "HOME", m.Tags["dataset"])
+	}
+}
@@ -121,6 +121,7 @@ func TestLineProtocolConverter_Record(t *testing.T) {
 						"duration_ns": (time.Duration(53106) * time.Microsecond).Nanoseconds(),
 					},
 					Time: time.Unix(0, 1498688360851331000).UTC(),
+					Type: telegraf.Untyped,
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
@@ -138,6 +139,7 @@ func TestLineProtocolConverter_Record(t *testing.T) {
 						"duration_ns": (time.Duration(53106) * time.Microsecond).Nanoseconds(),
 					},
 					Time: time.Unix(0, 1498688360851331000).UTC(),
+					Type: telegraf.Untyped,
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
@@ -152,6 +154,7 @@ func TestLineProtocolConverter_Record(t *testing.T) {
 						"duration_ns": (time.Duration(50410) * time.Microsecond).Nanoseconds(),
 					},
 					Time: time.Unix(0, 1498688360904552000).UTC(),
+					Type: telegraf.Untyped,
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
@@ -169,6 +172,7 @@ func TestLineProtocolConverter_Record(t *testing.T) {
 						"duration_ns": (time.Duration(50410) * time.Microsecond).Nanoseconds(),
 					},
 					Time: time.Unix(0, 1498688360904552000).UTC(),
+					Type: telegraf.Untyped,
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
@@ -183,6 +187,7 @@ func TestLineProtocolConverter_Record(t *testing.T) {
 						"duration_ns": (time.Duration(103680) * time.Microsecond).Nanoseconds(),
 					},
 					Time: time.Unix(0, 1498688360851318000).UTC(),
+					Type: telegraf.Untyped,
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
@@ -199,6 +204,7 @@ func TestLineProtocolConverter_Record(t *testing.T) {
 						"duration_ns": (time.Duration(103680) * time.Microsecond).Nanoseconds(),
 					},
 					Time: time.Unix(0, 1498688360851318000).UTC(),
+					Type: telegraf.Untyped,
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
@@ -215,6 +221,7 @@ func TestLineProtocolConverter_Record(t *testing.T) {
 						"duration_ns": (time.Duration(103680) * time.Microsecond).Nanoseconds(),
 					},
 					Time: time.Unix(0, 1498688360851318000).UTC(),
+					Type: telegraf.Untyped,
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
@@ -231,6 +238,7 @@ func TestLineProtocolConverter_Record(t *testing.T) {
 						"duration_ns": (time.Duration(103680) * time.Microsecond).Nanoseconds(),
 					},
 					Time: time.Unix(0, 1498688360851318000).UTC(),
+					Type: telegraf.Untyped,
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
@@ -248,6 +256,7 @@ func TestLineProtocolConverter_Record(t *testing.T) {
 						"duration_ns": (time.Duration(103680) * time.Microsecond).Nanoseconds(),
 					},
 					Time: time.Unix(0, 1498688360851318000).UTC(),
+					Type: telegraf.Untyped,
 				},
 			},
 			wantErr: false,
@@ -296,6 +305,7 @@ func TestLineProtocolConverter_Record(t *testing.T) {
 						"duration_ns": (time.Duration(1) * time.Nanosecond).Nanoseconds(),
 					},
 					Time: time.Unix(1, 0).UTC(),
+					Type: telegraf.Untyped,
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
@@ -312,6 +322,7 @@ func TestLineProtocolConverter_Record(t *testing.T) {
 						"duration_ns": (time.Duration(1) * time.Nanosecond).Nanoseconds(),
 					},
 					Time: time.Unix(1, 0).UTC(),
+					Type: telegraf.Untyped,
 				},
 			},
 		},
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/testutil"
 )
 
@@ -40,6 +41,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": (time.Duration(53106) * time.Microsecond).Nanoseconds(),
 					},
 					Time: time.Unix(0, 1498688360851331000).UTC(),
+					Type: telegraf.Untyped,
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
@@ -57,6 +59,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": (time.Duration(53106) * time.Microsecond).Nanoseconds(),
 					},
 					Time: time.Unix(0, 1498688360851331000).UTC(),
+					Type: telegraf.Untyped,
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
@@ -71,6 +74,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": (time.Duration(50410) * time.Microsecond).Nanoseconds(),
 					},
 					Time: time.Unix(0, 1498688360904552000).UTC(),
+					Type: telegraf.Untyped,
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
@@ -88,6 +92,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": (time.Duration(50410) * time.Microsecond).Nanoseconds(),
 					},
 					Time: time.Unix(0, 1498688360904552000).UTC(),
+					Type: telegraf.Untyped,
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
@@ -102,6 +107,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": (time.Duration(103680) * time.Microsecond).Nanoseconds(),
 					},
 					Time: time.Unix(0, 1498688360851318000).UTC(),
+					Type: telegraf.Untyped,
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
@@ -118,6 +124,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": (time.Duration(103680) * time.Microsecond).Nanoseconds(),
 					},
 					Time: time.Unix(0, 1498688360851318000).UTC(),
+					Type: telegraf.Untyped,
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
@@ -134,6 +141,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": (time.Duration(103680) * time.Microsecond).Nanoseconds(),
 					},
 					Time: time.Unix(0, 1498688360851318000).UTC(),
+					Type: telegraf.Untyped,
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
@@ -150,6 +158,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": (time.Duration(103680) * time.Microsecond).Nanoseconds(),
 					},
 					Time: time.Unix(0, 1498688360851318000).UTC(),
+					Type: telegraf.Untyped,
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
@@ -167,6 +176,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": (time.Duration(103680) * time.Microsecond).Nanoseconds(),
 					},
 					Time: time.Unix(0, 1498688360851318000).UTC(),
+					Type: telegraf.Untyped,
 				},
 			},
 			wantErr: false,
@@ -189,6 +199,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": (time.Duration(1) * time.Microsecond).Nanoseconds(),
 					},
 					Time: time.Unix(0, 1433330263415871*int64(time.Microsecond)).UTC(),
+					Type: telegraf.Untyped,
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
@@ -205,6 +216,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": (time.Duration(1) * time.Microsecond).Nanoseconds(),
 					},
 					Time: time.Unix(0, 1433330263415871*int64(time.Microsecond)).UTC(),
+					Type: telegraf.Untyped,
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
@@ -221,6 +233,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": (time.Duration(1) * time.Microsecond).Nanoseconds(),
 					},
 					Time: time.Unix(0, 1433330263415871*int64(time.Microsecond)).UTC(),
+					Type: telegraf.Untyped,
 				},
 			},
 		},
@@ -241,6 +254,7 @@ func TestZipkinPlugin(t *testing.T) {
 					Fields: map[string]interface{}{
 						"duration_ns": int64(3000000),
 					}, Time: time.Unix(0, 1503031538791000*int64(time.Microsecond)).UTC(),
+					Type: telegraf.Untyped,
 				},
 				{
 					Measurement: "zipkin",
@@ -257,6 +271,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": int64(3000000),
 					},
 					Time: time.Unix(0, 1503031538791000*int64(time.Microsecond)).UTC(),
+					Type: telegraf.Untyped,
 				},
 				{
 					Measurement: "zipkin",
@@ -273,6 +288,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": int64(3000000),
 					},
 					Time: time.Unix(0, 1503031538791000*int64(time.Microsecond)).UTC(),
+					Type: telegraf.Untyped,
 				},
 				{
 					Measurement: "zipkin",
@@ -290,6 +306,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": int64(3000000),
 					},
 					Time: time.Unix(0, 1503031538791000*int64(time.Microsecond)).UTC(),
+					Type: telegraf.Untyped,
 				},
 				{
 					Measurement: "zipkin",
@@ -307,6 +324,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": int64(3000000),
 					},
 					Time: time.Unix(0, 1503031538791000*int64(time.Microsecond)).UTC(),
+					Type: telegraf.Untyped,
 				},
 				{
 					Measurement: "zipkin",
@@ -324,6 +342,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": int64(3000000),
 					},
 					Time: time.Unix(0, 1503031538791000*int64(time.Microsecond)).UTC(),
+					Type: telegraf.Untyped,
 				},
 				{
 					Measurement: "zipkin",
@@ -338,6 +357,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": int64(10000000),
 					},
 					Time: time.Unix(0, 1503031538786000*int64(time.Microsecond)).UTC(),
+					Type: telegraf.Untyped,
 				},
 				{
 					Measurement: "zipkin",
@@ -354,6 +374,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": int64(10000000),
 					},
 					Time: time.Unix(0, 1503031538786000*int64(time.Microsecond)).UTC(),
+					Type: telegraf.Untyped,
 				},
 				{
 					Measurement: "zipkin",
@@ -370,6 +391,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": int64(10000000),
 					},
 					Time: time.Unix(0, 1503031538786000*int64(time.Microsecond)).UTC(),
+					Type: telegraf.Untyped,
 				},
 				{
 					Measurement: "zipkin",
@@ -387,6 +409,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": int64(10000000),
 					},
 					Time: time.Unix(0, 1503031538786000*int64(time.Microsecond)).UTC(),
+					Type: telegraf.Untyped,
 				},
 				{
 					Measurement: "zipkin",
@@ -404,6 +427,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": int64(10000000),
 					},
 					Time: time.Unix(0, 1503031538786000*int64(time.Microsecond)).UTC(),
+					Type: telegraf.Untyped,
 				},
 				{
 					Measurement: "zipkin",
@@ -421,6 +445,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": int64(10000000),
 					},
 					Time: time.Unix(0, 1503031538786000*int64(time.Microsecond)).UTC(),
+					Type: telegraf.Untyped,
 				},
 				{
 					Measurement: "zipkin",
@@ -438,6 +463,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": int64(10000000),
 					},
 					Time: time.Unix(0, 1503031538786000*int64(time.Microsecond)).UTC(),
+					Type: telegraf.Untyped,
 				},
 				{
 					Measurement: "zipkin",
@@ -455,6 +481,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": int64(10000000),
 					},
 					Time: time.Unix(0, 1503031538786000*int64(time.Microsecond)).UTC(),
+					Type: telegraf.Untyped,
 				},
 				{
 					Measurement: "zipkin",
@@ -469,6 +496,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": int64(23393000),
 					},
 					Time: time.Unix(0, 1503031538778000*int64(time.Microsecond)).UTC(),
+					Type: telegraf.Untyped,
 				},
 				{
 					Measurement: "zipkin",
@@ -485,6 +513,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": int64(23393000),
 					},
 					Time: time.Unix(0, 1503031538778000*int64(time.Microsecond)).UTC(),
+					Type: telegraf.Untyped,
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
@@ -501,6 +530,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": int64(23393000),
 					},
 					Time: time.Unix(0, 1503031538778000*int64(time.Microsecond)).UTC(),
+					Type: telegraf.Untyped,
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
@@ -518,6 +548,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": int64(23393000),
 					},
 					Time: time.Unix(0, 1503031538778000*int64(time.Microsecond)).UTC(),
+					Type: telegraf.Untyped,
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
@@ -535,6 +566,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": int64(23393000),
 					},
 					Time: time.Unix(0, 1503031538778000*int64(time.Microsecond)).UTC(),
+					Type: telegraf.Untyped,
 				},
 				testutil.Metric{
 					Measurement: "zipkin",
@@ -552,6 +584,7 @@ func TestZipkinPlugin(t *testing.T) {
 						"duration_ns": int64(23393000),
 					},
 					Time: time.Unix(0, 1503031538778000*int64(time.Microsecond)).UTC(),
+					Type: telegraf.Untyped,
 				},
 			},
 		},
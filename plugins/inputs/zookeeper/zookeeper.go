@@ -4,8 +4,11 @@ import (
 	"bufio"
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"net"
+	"net/http"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
@@ -17,10 +20,14 @@ import (
 	"github.com/influxdata/telegraf/plugins/inputs"
 )
 
+var mntrLine = regexp.MustCompile(`^zk_(\w+)\s+([\w\.\-]+)`)
+
 // Zookeeper is a zookeeper plugin
 type Zookeeper struct {
-	Servers []string
-	Timeout internal.Duration
+	Servers           []string
+	Timeout           internal.Duration
+	Ruok              bool `toml:"ruok"`
+	EnsembleDiscovery bool `toml:"ensemble_discovery"`
 
 	EnableTLS bool `toml:"enable_tls"`
 	EnableSSL bool `toml:"enable_ssl"` // deprecated in 1.7; use enable_tls
@@ -28,11 +35,14 @@ type Zookeeper struct {
 
 	initialized bool
 	tlsConfig   *tls.Config
+	client      *http.Client
 }
 
 var sampleConfig = `
   ## An array of address to gather stats about. Specify an ip or hostname
   ## with port. ie localhost:2181, 10.0.0.1:2181, etc.
+  ## The AdminServer of ZooKeeper 3.5 can be used instead of the four letter
+  ## word commands with its URL, ie http://localhost:8080.
 
   ## If no servers are specified, then localhost is used as the host.
   ## If no port is specified, 2181 is used
@@ -41,6 +51,15 @@ var sampleConfig = `
   ## Timeout for metric collections from all servers.  Minimum timeout is "1s".
   # timeout = "5s"
 
+  ## Also send the 'ruok' command, and report if the server answered 'imok'
+  ## in the ruok field.
+  # ruok = false
+
+  ## Gather all the members of the ensembles of the servers, as listed by the
+  ## 'conf' command.  Only one member of each ensemble needs to be listed in
+  ## servers.  The 'conf' command has to be whitelisted on ZooKeeper 3.5.
+  # ensemble_discovery = false
+
   ## Optional TLS Config
   # enable_tls = true
   # tls_ca = "/etc/telegraf/ca.pem"
@@ -85,6 +104,11 @@ func (z *Zookeeper) Gather(acc telegraf.Accumulator) error {
 			return err
 		}
 		z.tlsConfig = tlsConfig
+		z.client = &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: tlsConfig,
+			},
+		}
 		z.initialized = true
 	}
 
@@ -99,22 +123,99 @@ func (z *Zookeeper) Gather(acc telegraf.Accumulator) error {
 		z.Servers = []string{":2181"}
 	}
 
+	servers := make([]string, 0, len(z.Servers))
+	gathered := make(map[string]bool)
 	for _, serverAddress := range z.Servers {
+		if !strings.HasPrefix(serverAddress, "http://") && !strings.HasPrefix(serverAddress, "https://") {
+			serverAddress = withDefaultPort(serverAddress)
+		}
+		servers = append(servers, serverAddress)
+		gathered[serverAddress] = true
+	}
+
+	for _, serverAddress := range servers {
 		acc.AddError(z.gatherServer(ctx, serverAddress, acc))
 	}
+
+	if z.EnsembleDiscovery {
+		for _, serverAddress := range servers {
+			if strings.Contains(serverAddress, "://") {
+				continue
+			}
+
+			members, err := z.ensembleMembers(ctx, serverAddress)
+			if err != nil {
+				acc.AddError(err)
+				continue
+			}
+
+			for _, member := range members {
+				if gathered[member] {
+					continue
+				}
+				gathered[member] = true
+				acc.AddError(z.gatherServer(ctx, member, acc))
+			}
+		}
+	}
 	return nil
 }
 
+func withDefaultPort(address string) string {
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		return address + ":2181"
+	}
+	return address
+}
+
 func (z *Zookeeper) gatherServer(ctx context.Context, address string, acc telegraf.Accumulator) error {
-	var zookeeper_state string
-	_, _, err := net.SplitHostPort(address)
-	if err != nil {
-		address = address + ":2181"
+	var fields map[string]interface{}
+	var state, host, port string
+	var err error
+
+	if strings.Contains(address, "://") {
+		u, err := url.Parse(address)
+		if err != nil {
+			return err
+		}
+		host, port = u.Hostname(), u.Port()
+
+		fields, state, err = z.adminCommand(ctx, u)
+		if err != nil {
+			return err
+		}
+	} else {
+		host, port, err = net.SplitHostPort(address)
+		if err != nil {
+			return fmt.Errorf("Invalid service address: %s", address)
+		}
+
+		fields, state, err = z.mntr(ctx, address)
+		if err != nil {
+			return err
+		}
+
+		if z.Ruok {
+			fields["ruok"] = z.ruok(ctx, address)
+		}
+	}
+
+	tags := map[string]string{
+		"server": host,
+		"port":   port,
+		"state":  state,
 	}
+	acc.AddFields("zookeeper", fields, tags)
 
+	return nil
+}
+
+// command sends a four letter word command and returns the lines of the
+// response.
+func (z *Zookeeper) command(ctx context.Context, address string, cmd string) ([]string, error) {
 	c, err := z.dial(ctx, address)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer c.Close()
 
@@ -124,24 +225,29 @@ func (z *Zookeeper) gatherServer(ctx context.Context, address string, acc telegr
 		c.SetDeadline(deadline)
 	}
 
-	fmt.Fprintf(c, "%s\n", "mntr")
-	rdr := bufio.NewReader(c)
-	scanner := bufio.NewScanner(rdr)
+	fmt.Fprintf(c, "%s\n", cmd)
+	scanner := bufio.NewScanner(c)
+
+	var lines []string
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	return lines, scanner.Err()
+}
 
-	service := strings.Split(address, ":")
-	if len(service) != 2 {
-		return fmt.Errorf("Invalid service address: %s", address)
+func (z *Zookeeper) mntr(ctx context.Context, address string) (map[string]interface{}, string, error) {
+	lines, err := z.command(ctx, address, "mntr")
+	if err != nil {
+		return nil, "", err
 	}
 
+	var zookeeper_state string
 	fields := make(map[string]interface{})
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		re := regexp.MustCompile(`^zk_(\w+)\s+([\w\.\-]+)`)
-		parts := re.FindStringSubmatch(string(line))
+	for _, line := range lines {
+		parts := mntrLine.FindStringSubmatch(line)
 
 		if len(parts) != 3 {
-			return fmt.Errorf("unexpected line in mntr response: %q", line)
+			return nil, "", fmt.Errorf("unexpected line in mntr response: %q", line)
 		}
 
 		measurement := strings.TrimPrefix(parts[1], "zk_")
@@ -158,14 +264,111 @@ func (z *Zookeeper) gatherServer(ctx context.Context, address string, acc telegr
 			}
 		}
 	}
-	tags := map[string]string{
-		"server": service[0],
-		"port":   service[1],
-		"state":  zookeeper_state,
+	return fields, zookeeper_state, nil
+}
+
+// ruok reports if the server answers the ruok command with imok, which it
+// does when it is running without errors.
+func (z *Zookeeper) ruok(ctx context.Context, address string) bool {
+	lines, err := z.command(ctx, address, "ruok")
+	if err != nil {
+		return false
 	}
-	acc.AddFields("zookeeper", fields, tags)
+	return len(lines) > 0 && strings.HasPrefix(lines[0], "imok")
+}
 
-	return nil
+// ensembleMembers returns the client addresses of the members of the
+// ensemble of a server, from the server.N lines of the conf command which
+// are either server.1=zk1:2888:3888:participant;0.0.0.0:2181 or, before
+// ZooKeeper 3.5, server.1=zk1:2888:3888 in which case the client port of the
+// server is used.
+func (z *Zookeeper) ensembleMembers(ctx context.Context, address string) ([]string, error) {
+	lines, err := z.command(ctx, address, "conf")
+	if err != nil {
+		return nil, err
+	}
+
+	_, clientPort, err := net.SplitHostPort(address)
+	if err != nil {
+		return nil, err
+	}
+
+	var members []string
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "server.") {
+			continue
+		}
+
+		parts := strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		server := strings.SplitN(parts[1], ";", 2)
+		host := strings.Split(server[0], ":")[0]
+		port := clientPort
+		if len(server) == 2 {
+			if _, p, err := net.SplitHostPort(server[1]); err == nil {
+				port = p
+			}
+		}
+		members = append(members, net.JoinHostPort(host, port))
+	}
+	return members, nil
+}
+
+// adminCommand runs the monitor command of the AdminServer, which returns
+// the mntr stats as JSON.
+func (z *Zookeeper) adminCommand(ctx context.Context, u *url.URL) (map[string]interface{}, string, error) {
+	req, err := http.NewRequest("GET", strings.TrimRight(u.String(), "/")+"/commands/monitor", nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	resp, err := z.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("AdminServer %s responded with status-code %d", u.Host, resp.StatusCode)
+	}
+
+	var values map[string]interface{}
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&values); err != nil {
+		return nil, "", err
+	}
+
+	if e, ok := values["error"].(string); ok && e != "" {
+		return nil, "", fmt.Errorf("AdminServer %s: %s", u.Host, e)
+	}
+
+	var state string
+	fields := make(map[string]interface{})
+	for k, v := range values {
+		switch k {
+		case "command", "error":
+			continue
+		case "server_state":
+			state, _ = v.(string)
+			continue
+		}
+
+		switch v := v.(type) {
+		case json.Number:
+			if iVal, err := v.Int64(); err == nil {
+				fields[k] = iVal
+			} else if fVal, err := v.Float64(); err == nil {
+				fields[k] = fVal
+			}
+		case string:
+			fields[k] = v
+		}
+	}
+	return fields, state, nil
 }
 
 func init() {
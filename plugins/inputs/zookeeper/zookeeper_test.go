@@ -1,6 +1,13 @@
 package zookeeper
 
 import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 
 	"github.com/influxdata/telegraf/testutil"
@@ -40,3 +47,143 @@ func TestZookeeperGeneratesMetrics(t *testing.T) {
 		assert.True(t, acc.HasInt64Field("zookeeper", metric), metric)
 	}
 }
+
+const mntrResponse = `zk_version	3.4.9-3--1, built on Thu, 01 Jun 2017 16:26:44 -0700
+zk_avg_latency	0
+zk_max_latency	3
+zk_min_latency	0
+zk_packets_received	8
+zk_packets_sent	7
+zk_num_alive_connections	1
+zk_outstanding_requests	0
+zk_server_state	%s
+zk_znode_count	129
+zk_watch_count	2
+`
+
+// fakeServer answers the four letter word commands like a ZooKeeper member
+// of an ensemble, and returns its address.
+func fakeServer(t *testing.T, state string, conf string) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			cmd, _ := bufio.NewReader(c).ReadString('\n')
+			switch strings.TrimSpace(cmd) {
+			case "mntr":
+				fmt.Fprintf(c, mntrResponse, state)
+			case "ruok":
+				fmt.Fprint(c, "imok")
+			case "conf":
+				fmt.Fprint(c, conf)
+			}
+			c.Close()
+		}
+	}()
+	return l.Addr().String()
+}
+
+func TestGatherMntr(t *testing.T) {
+	address := fakeServer(t, "leader", "")
+	host, port, _ := net.SplitHostPort(address)
+
+	z := &Zookeeper{
+		Servers: []string{address},
+		Ruok:    true,
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(z.Gather))
+
+	acc.AssertContainsTaggedFields(t, "zookeeper",
+		map[string]interface{}{
+			"version":               "3.4.9-3--1",
+			"avg_latency":           int64(0),
+			"max_latency":           int64(3),
+			"min_latency":           int64(0),
+			"packets_received":      int64(8),
+			"packets_sent":          int64(7),
+			"num_alive_connections": int64(1),
+			"outstanding_requests":  int64(0),
+			"znode_count":           int64(129),
+			"watch_count":           int64(2),
+			"ruok":                  true,
+		},
+		map[string]string{
+			"server": host,
+			"port":   port,
+			"state":  "leader",
+		})
+}
+
+func TestGatherEnsembleDiscovery(t *testing.T) {
+	follower := fakeServer(t, "follower", "")
+	_, followerPort, _ := net.SplitHostPort(follower)
+
+	conf := "clientPort=2181\n" +
+		"dataDir=/var/lib/zookeeper/version-2\n" +
+		"server.1=127.0.0.1:2888:3888:participant;0.0.0.0:" + followerPort + "\n" +
+		"server.2=127.0.0.1:2889:3889:participant;0.0.0.0:" + followerPort + "\n" +
+		"membership: \n"
+	leader := fakeServer(t, "leader", conf)
+
+	z := &Zookeeper{
+		Servers:           []string{leader},
+		EnsembleDiscovery: true,
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(z.Gather))
+
+	require.Len(t, acc.Metrics, 2)
+	assert.Equal(t, "leader", acc.Metrics[0].Tags["state"])
+	assert.Equal(t, "follower", acc.Metrics[1].Tags["state"])
+	assert.Equal(t, followerPort, acc.Metrics[1].Tags["port"])
+}
+
+func TestGatherAdminServer(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/commands/monitor", r.URL.Path)
+		fmt.Fprint(w, `{
+			"version": "3.5.4-beta-7f51e5b68cf2f80176ff944a9ebd2abbc65e7327, built on 05/11/2018 16:27 GMT",
+			"avg_latency": 0,
+			"max_latency": 12,
+			"outstanding_requests": 0,
+			"server_state": "follower",
+			"znode_count": 5,
+			"watch_count": 1,
+			"command": "monitor",
+			"error": null
+		}`)
+	}))
+	defer ts.Close()
+
+	u, _ := url.Parse(ts.URL)
+	z := &Zookeeper{
+		Servers: []string{ts.URL},
+	}
+
+	var acc testutil.Accumulator
+	require.NoError(t, acc.GatherError(z.Gather))
+
+	acc.AssertContainsTaggedFields(t, "zookeeper",
+		map[string]interface{}{
+			"version":              "3.5.4-beta-7f51e5b68cf2f80176ff944a9ebd2abbc65e7327, built on 05/11/2018 16:27 GMT",
+			"avg_latency":          int64(0),
+			"max_latency":          int64(12),
+			"outstanding_requests": int64(0),
+			"znode_count":          int64(5),
+			"watch_count":          int64(1),
+		},
+		map[string]string{
+			"server": u.Hostname(),
+			"port":   u.Port(),
+			"state":  "follower",
+		})
+}
@@ -0,0 +1,236 @@
+package azure_data_explorer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tables"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+const (
+	tablePerMetric = "TablePerMetric"
+	singleTable    = "SingleTable"
+
+	queuedIngestion    = "queued"
+	streamingIngestion = "streaming"
+)
+
+// Characters which are not allowed in the names of the tables.
+var invalidTableChars = regexp.MustCompile(`[^a-zA-Z0-9_.\- ]`)
+
+var sampleConfig = `
+  ## URL of the Azure Data Explorer cluster.
+  endpoint_url = "https://mycluster.westeurope.kusto.windows.net"
+
+  ## Database of the tables, which must exist.
+  database = "telegraf"
+
+  ## Timeout of the management commands and ingestions.
+  # timeout = "20s"
+
+  ## Tables the metrics are written in: "TablePerMetric" for a table per
+  ## measurement, named after it, or "SingleTable" for the table_name table.
+  # metrics_grouping_type = "TablePerMetric"
+  # table_name = ""
+
+  ## Create the missing tables, and their JSON ingestion mapping, named after
+  ## the table with the "_mapping" suffix.  Otherwise the tables and mappings
+  ## must exist.
+  # create_tables = true
+
+  ## Ingestion method: "queued" through the ingestion service of the cluster,
+  ## or "streaming", with a lower latency, which requires the streaming
+  ## ingestion to be enabled on the cluster and the tables.  The streaming
+  ## ingestions larger than 4MB are queued.
+  # ingestion_type = "queued"
+
+  ## Azure Active Directory application credentials.
+  # tenant_id = ""
+  # client_id = ""
+  # client_secret = ""
+
+  ## Use the managed identity of the Azure resource instead of the application
+  ## credentials, client_id selecting a user assigned identity.  When neither
+  ## are set, the credentials are read from the AZURE_TENANT_ID,
+  ## AZURE_CLIENT_ID and AZURE_CLIENT_SECRET environment variables.
+  # use_managed_identity = false
+`
+
+// client runs the commands and ingestions of a cluster.
+type client interface {
+	mgmt(ctx context.Context, database, command string) error
+	ingest(ctx context.Context, database, table, mapping string, payload []byte) error
+	close() error
+}
+
+type AzureDataExplorer struct {
+	Endpoint           string            `toml:"endpoint_url"`
+	Database           string            `toml:"database"`
+	Timeout            internal.Duration `toml:"timeout"`
+	MetricsGrouping    string            `toml:"metrics_grouping_type"`
+	TableName          string            `toml:"table_name"`
+	CreateTables       bool              `toml:"create_tables"`
+	IngestionType      string            `toml:"ingestion_type"`
+	TenantID           string            `toml:"tenant_id"`
+	ClientID           string            `toml:"client_id"`
+	ClientSecret       string            `toml:"client_secret"`
+	UseManagedIdentity bool              `toml:"use_managed_identity"`
+
+	client     client
+	serializer serializers.Serializer
+
+	// The tables created or checked.
+	tables map[string]bool
+}
+
+func (adx *AzureDataExplorer) SampleConfig() string {
+	return sampleConfig
+}
+
+func (adx *AzureDataExplorer) Description() string {
+	return "Sends metrics to Azure Data Explorer"
+}
+
+func (adx *AzureDataExplorer) Connect() error {
+	if adx.Endpoint == "" {
+		return fmt.Errorf("endpoint_url is a required field for azure_data_explorer output")
+	}
+	if adx.Database == "" {
+		return fmt.Errorf("database is a required field for azure_data_explorer output")
+	}
+	switch adx.IngestionType {
+	case queuedIngestion, streamingIngestion:
+	default:
+		return fmt.Errorf("unknown ingestion_type %q", adx.IngestionType)
+	}
+
+	authorization, err := adx.authorization()
+	if err != nil {
+		return err
+	}
+	c, err := newKustoClient(adx.Endpoint, authorization, adx.IngestionType == streamingIngestion)
+	if err != nil {
+		return err
+	}
+	return adx.connect(c)
+}
+
+// connect checks the configuration and sets the client of the cluster.
+func (adx *AzureDataExplorer) connect(c client) error {
+	switch adx.MetricsGrouping {
+	case tablePerMetric:
+	case singleTable:
+		if adx.TableName == "" {
+			return fmt.Errorf("table_name is required with the %s metrics_grouping_type", singleTable)
+		}
+	default:
+		return fmt.Errorf("unknown metrics_grouping_type %q", adx.MetricsGrouping)
+	}
+
+	serializer, err := serializers.NewJsonSerializer(time.Nanosecond)
+	if err != nil {
+		return err
+	}
+	adx.serializer = serializer
+	adx.client = c
+	adx.tables = make(map[string]bool)
+	return nil
+}
+
+func (adx *AzureDataExplorer) Close() error {
+	if adx.client != nil {
+		return adx.client.close()
+	}
+	return nil
+}
+
+func (adx *AzureDataExplorer) Write(metrics []telegraf.Metric) error {
+	batches := tables.Group(metrics, adx.table)
+	payloads := make([]bytes.Buffer, len(batches))
+	for i, batch := range batches {
+		for _, metric := range batch.Metrics {
+			line, err := adx.serializer.Serialize(metric)
+			if err != nil {
+				return err
+			}
+			payloads[i].Write(line)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), adx.Timeout.Duration)
+	defer cancel()
+	for i, batch := range batches {
+		name := batch.Table
+		if err := adx.prepareTable(ctx, name); err != nil {
+			return err
+		}
+		if err := adx.client.ingest(ctx, adx.Database, name, mappingName(name), payloads[i].Bytes()); err != nil {
+			return fmt.Errorf("ingesting into table %s failed: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// table returns the table of a metric, named after its measurement unless
+// all the metrics are written to the single table.
+func (adx *AzureDataExplorer) table(metric telegraf.Metric) string {
+	if adx.MetricsGrouping == tablePerMetric {
+		return tableName(metric.Name())
+	}
+	return adx.TableName
+}
+
+// prepareTable creates the table and its ingestion mapping, the table getting
+// the missing columns if it exists.
+func (adx *AzureDataExplorer) prepareTable(ctx context.Context, name string) error {
+	if !adx.CreateTables || adx.tables[name] {
+		return nil
+	}
+
+	create := fmt.Sprintf(".create-merge table ['%s'] "+
+		"(['fields']:dynamic, ['name']:string, ['tags']:dynamic, ['timestamp']:datetime)", name)
+	if err := adx.client.mgmt(ctx, adx.Database, create); err != nil {
+		return fmt.Errorf("creating table %s failed: %v", name, err)
+	}
+
+	mapping := fmt.Sprintf(".create-or-alter table ['%s'] ingestion json mapping '%s' "+
+		`'[{"column":"fields","Properties":{"Path":"$.fields"}},`+
+		`{"column":"name","Properties":{"Path":"$.name"}},`+
+		`{"column":"tags","Properties":{"Path":"$.tags"}},`+
+		`{"column":"timestamp","Properties":{"Path":"$.timestamp","Transform":"DateTimeFromUnixNanoseconds"}}]'`,
+		name, mappingName(name))
+	if err := adx.client.mgmt(ctx, adx.Database, mapping); err != nil {
+		return fmt.Errorf("creating the ingestion mapping of table %s failed: %v", name, err)
+	}
+
+	adx.tables[name] = true
+	return nil
+}
+
+// tableName returns the name of the table of a measurement, replacing the
+// invalid characters with underscores.
+func tableName(measurement string) string {
+	return invalidTableChars.ReplaceAllString(measurement, "_")
+}
+
+func mappingName(table string) string {
+	return table + "_mapping"
+}
+
+func init() {
+	outputs.Add("azure_data_explorer", func() telegraf.Output {
+		return &AzureDataExplorer{
+			Timeout:         internal.Duration{Duration: 20 * time.Second},
+			MetricsGrouping: tablePerMetric,
+			CreateTables:    true,
+			IngestionType:   queuedIngestion,
+		}
+	})
+}
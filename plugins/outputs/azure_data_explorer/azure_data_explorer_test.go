@@ -0,0 +1,147 @@
+package azure_data_explorer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type ingestion struct {
+	database, table, mapping, payload string
+}
+
+// fakeClient records the commands and ingestions.
+type fakeClient struct {
+	commands   []string
+	ingestions []ingestion
+	err        error
+}
+
+func (c *fakeClient) mgmt(ctx context.Context, database, command string) error {
+	c.commands = append(c.commands, command)
+	return c.err
+}
+
+func (c *fakeClient) ingest(ctx context.Context, database, table, mapping string, payload []byte) error {
+	c.ingestions = append(c.ingestions, ingestion{database, table, mapping, string(payload)})
+	return c.err
+}
+
+func (c *fakeClient) close() error {
+	return nil
+}
+
+func newAzureDataExplorer() *AzureDataExplorer {
+	return &AzureDataExplorer{
+		Endpoint:        "https://mycluster.kusto.windows.net",
+		Database:        "telegraf",
+		Timeout:         internal.Duration{Duration: 5 * time.Second},
+		MetricsGrouping: tablePerMetric,
+		CreateTables:    true,
+		IngestionType:   queuedIngestion,
+	}
+}
+
+func TestWriteTablePerMetric(t *testing.T) {
+	c := &fakeClient{}
+	adx := newAzureDataExplorer()
+	require.NoError(t, adx.connect(c))
+
+	err := adx.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 0)),
+		testutil.MustMetric("disk/io", nil, map[string]interface{}{"reads": int64(1)}, time.Unix(1500000000, 0)),
+		testutil.MustMetric("cpu", map[string]string{"host": "b"}, map[string]interface{}{"usage": 1.5}, time.Unix(1500000000, 0)),
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{
+		".create-merge table ['cpu'] (['fields']:dynamic, ['name']:string, ['tags']:dynamic, ['timestamp']:datetime)",
+		`.create-or-alter table ['cpu'] ingestion json mapping 'cpu_mapping' '[` +
+			`{"column":"fields","Properties":{"Path":"$.fields"}},` +
+			`{"column":"name","Properties":{"Path":"$.name"}},` +
+			`{"column":"tags","Properties":{"Path":"$.tags"}},` +
+			`{"column":"timestamp","Properties":{"Path":"$.timestamp","Transform":"DateTimeFromUnixNanoseconds"}}]'`,
+		".create-merge table ['disk_io'] (['fields']:dynamic, ['name']:string, ['tags']:dynamic, ['timestamp']:datetime)",
+	}, c.commands[:3])
+	require.Len(t, c.commands, 4)
+
+	require.Equal(t, []ingestion{
+		{
+			database: "telegraf",
+			table:    "cpu",
+			mapping:  "cpu_mapping",
+			payload: `{"fields":{"usage":42.5},"name":"cpu","tags":{"host":"a"},"timestamp":1500000000000000000}` + "\n" +
+				`{"fields":{"usage":1.5},"name":"cpu","tags":{"host":"b"},"timestamp":1500000000000000000}` + "\n",
+		},
+		{
+			database: "telegraf",
+			table:    "disk_io",
+			mapping:  "disk_io_mapping",
+			payload:  `{"fields":{"reads":1},"name":"disk/io","tags":{},"timestamp":1500000000000000000}` + "\n",
+		},
+	}, c.ingestions)
+
+	// The tables are only created once
+	require.NoError(t, adx.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 0)),
+	}))
+	require.Len(t, c.commands, 4)
+	require.Len(t, c.ingestions, 3)
+}
+
+func TestWriteSingleTable(t *testing.T) {
+	c := &fakeClient{}
+	adx := newAzureDataExplorer()
+	adx.MetricsGrouping = singleTable
+	adx.TableName = "metrics"
+	adx.CreateTables = false
+	require.NoError(t, adx.connect(c))
+
+	err := adx.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 0)),
+		testutil.MustMetric("mem", nil, map[string]interface{}{"used": int64(7)}, time.Unix(1500000000, 0)),
+	})
+	require.NoError(t, err)
+
+	require.Empty(t, c.commands)
+	require.Len(t, c.ingestions, 1)
+	require.Equal(t, "metrics", c.ingestions[0].table)
+	require.Equal(t, "metrics_mapping", c.ingestions[0].mapping)
+}
+
+func TestWriteFailure(t *testing.T) {
+	c := &fakeClient{err: errors.New("unauthorized")}
+	adx := newAzureDataExplorer()
+	require.NoError(t, adx.connect(c))
+
+	err := adx.Write([]telegraf.Metric{testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 0))})
+	require.Error(t, err)
+	require.Empty(t, c.ingestions)
+
+	// The table is created again with the next write
+	c.err = nil
+	err = adx.Write([]telegraf.Metric{testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 0))})
+	require.NoError(t, err)
+	require.Len(t, c.commands, 3)
+	require.Len(t, c.ingestions, 1)
+}
+
+func TestConnectInvalidConfig(t *testing.T) {
+	adx := newAzureDataExplorer()
+	adx.MetricsGrouping = singleTable
+	require.Error(t, adx.connect(&fakeClient{}))
+
+	adx = newAzureDataExplorer()
+	adx.MetricsGrouping = "TablePerTag"
+	require.Error(t, adx.connect(&fakeClient{}))
+
+	adx = newAzureDataExplorer()
+	adx.IngestionType = "direct"
+	require.Error(t, adx.Connect())
+}
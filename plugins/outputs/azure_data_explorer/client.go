@@ -0,0 +1,103 @@
+package azure_data_explorer
+
+import (
+	"bytes"
+	"context"
+	"sync"
+
+	"github.com/Azure/azure-kusto-go/kusto"
+	"github.com/Azure/azure-kusto-go/kusto/ingest"
+	"github.com/Azure/azure-kusto-go/kusto/unsafe"
+	"github.com/Azure/go-autorest/autorest/azure/auth"
+)
+
+// authorization returns the authorization of the configured credentials,
+// the credentials being read from the environment when none are set.
+func (adx *AzureDataExplorer) authorization() (kusto.Authorization, error) {
+	if adx.ClientSecret != "" {
+		return kusto.Authorization{
+			Config: auth.NewClientCredentialsConfig(adx.ClientID, adx.ClientSecret, adx.TenantID),
+		}, nil
+	}
+	if adx.UseManagedIdentity {
+		config := auth.NewMSIConfig()
+		config.ClientID = adx.ClientID
+		return kusto.Authorization{Config: config}, nil
+	}
+
+	authorizer, err := auth.NewAuthorizerFromEnvironmentWithResource(adx.Endpoint)
+	if err != nil {
+		return kusto.Authorization{}, err
+	}
+	return kusto.Authorization{Authorizer: authorizer}, nil
+}
+
+// kustoClient runs the commands and ingestions with the Kusto client.
+type kustoClient struct {
+	client    *kusto.Client
+	streaming bool
+
+	mu sync.Mutex
+	// The ingestors of the tables, by database and table.
+	ingestors map[[2]string]*ingest.Ingestion
+}
+
+func newKustoClient(endpoint string, authorization kusto.Authorization, streaming bool) (*kustoClient, error) {
+	c, err := kusto.New(endpoint, authorization)
+	if err != nil {
+		return nil, err
+	}
+	return &kustoClient{
+		client:    c,
+		streaming: streaming,
+		ingestors: make(map[[2]string]*ingest.Ingestion),
+	}, nil
+}
+
+func (c *kustoClient) mgmt(ctx context.Context, database, command string) error {
+	stmt := kusto.NewStmt("", kusto.UnsafeStmt(unsafe.Stmt{Add: true, SuppressWarning: true})).UnsafeAdd(command)
+	rows, err := c.client.Mgmt(ctx, database, stmt)
+	if err != nil {
+		return err
+	}
+	rows.Stop()
+	return nil
+}
+
+func (c *kustoClient) ingest(ctx context.Context, database, table, mapping string, payload []byte) error {
+	ingestor, err := c.ingestor(database, table)
+	if err != nil {
+		return err
+	}
+
+	if c.streaming {
+		err := ingestor.Stream(ctx, payload, ingest.JSON, mapping)
+		if err != ingest.ErrTooLarge {
+			return err
+		}
+	}
+
+	_, err = ingestor.FromReader(ctx, bytes.NewReader(payload),
+		ingest.FileFormat(ingest.JSON), ingest.IngestionMappingRef(mapping, ingest.JSON))
+	return err
+}
+
+func (c *kustoClient) ingestor(database, table string) (*ingest.Ingestion, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := [2]string{database, table}
+	if ingestor, ok := c.ingestors[key]; ok {
+		return ingestor, nil
+	}
+	ingestor, err := ingest.New(c.client, database, table)
+	if err != nil {
+		return nil, err
+	}
+	c.ingestors[key] = ingestor
+	return ingestor, nil
+}
+
+func (c *kustoClient) close() error {
+	return nil
+}
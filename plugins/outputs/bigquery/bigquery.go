@@ -0,0 +1,398 @@
+package bigquery
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"cloud.google.com/go/bigquery"
+	"google.golang.org/api/googleapi"
+	"google.golang.org/api/option"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tables"
+	"github.com/influxdata/telegraf/plugins/outputs"
+)
+
+const timestampColumn = "timestamp"
+
+// Characters which are not allowed in the names of the tables and columns.
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+var metricSchema = tables.Schema{
+	Types: map[tables.Kind]string{
+		tables.Float:  string(bigquery.FloatFieldType),
+		tables.Int:    string(bigquery.IntegerFieldType),
+		tables.Uint:   string(bigquery.IntegerFieldType),
+		tables.Bool:   string(bigquery.BooleanFieldType),
+		tables.String: string(bigquery.StringFieldType),
+	},
+	Reserved: []string{timestampColumn},
+	Name:     sanitize,
+}
+
+var sampleConfig = `
+  ## Credentials File of a service account.  When not set, the Application
+  ## Default Credentials are used.
+  # credentials_file = "/path/to/service/account/key.json"
+
+  ## Google Cloud Platform Project
+  project = "my-gcp-project"
+
+  ## Dataset of the tables
+  dataset = "telegraf"
+
+  ## Name of the table all the metrics are written in, with the "timestamp",
+  ## "name", "tags" and "fields" columns, the tags and fields being JSON
+  ## objects.  When not set, the metrics are written in a table per
+  ## measurement, with a column per tag and field.
+  # compact_table = ""
+
+  ## Create the missing tables, and add the columns of the new tags and fields
+  ## to the tables.
+  # create_tables = true
+
+  ## Timeout for BigQuery operations.
+  # timeout = "5s"
+
+  ## Number of retries of a write failing on a quota or server error, with a
+  ## delay doubling from retry_delay between the attempts.
+  # max_retries = 3
+  # retry_delay = "1s"
+`
+
+type BigQuery struct {
+	CredentialsFile string            `toml:"credentials_file"`
+	Project         string            `toml:"project"`
+	Dataset         string            `toml:"dataset"`
+	CompactTable    string            `toml:"compact_table"`
+	CreateTables    bool              `toml:"create_tables"`
+	Timeout         internal.Duration `toml:"timeout"`
+	MaxRetries      int               `toml:"max_retries"`
+	RetryDelay      internal.Duration `toml:"retry_delay"`
+
+	client *bigquery.Client
+
+	// The columns of the known tables and their types.
+	tables map[string]map[string]bigquery.FieldType
+
+	// Extra options of the client, used by the tests.
+	options []option.ClientOption
+	sleep   func(time.Duration)
+}
+
+func (b *BigQuery) SampleConfig() string {
+	return sampleConfig
+}
+
+func (b *BigQuery) Description() string {
+	return "Configuration for Google Cloud BigQuery to send entries"
+}
+
+func (b *BigQuery) Connect() error {
+	if b.Project == "" {
+		return fmt.Errorf("project is a required field for bigquery output")
+	}
+	if b.Dataset == "" {
+		return fmt.Errorf("dataset is a required field for bigquery output")
+	}
+
+	opts := b.options
+	if b.CredentialsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(b.CredentialsFile))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), b.Timeout.Duration)
+	defer cancel()
+	client, err := bigquery.NewClient(ctx, b.Project, opts...)
+	if err != nil {
+		return err
+	}
+	b.client = client
+	b.tables = make(map[string]map[string]bigquery.FieldType)
+	if b.sleep == nil {
+		b.sleep = time.Sleep
+	}
+	return nil
+}
+
+func (b *BigQuery) Close() error {
+	if b.client != nil {
+		return b.client.Close()
+	}
+	return nil
+}
+
+// row is a row of a table, its insert ID deduplicating the rows of the
+// retried writes.
+type row struct {
+	values   map[string]bigquery.Value
+	insertID string
+}
+
+func (r *row) Save() (map[string]bigquery.Value, string, error) {
+	return r.values, r.insertID, nil
+}
+
+func (b *BigQuery) Write(metrics []telegraf.Metric) error {
+	for _, batch := range tables.Group(metrics, b.tableName) {
+		if err := b.writeTable(batch.Table, batch.Metrics); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tableName returns the table of a metric, named after its measurement
+// unless the metrics are written to the compact table.
+func (b *BigQuery) tableName(metric telegraf.Metric) string {
+	if b.CompactTable != "" {
+		return b.CompactTable
+	}
+	return sanitize(metric.Name())
+}
+
+// writeTable writes the metrics of a table, retrying the quota and server
+// errors.
+func (b *BigQuery) writeTable(name string, metrics []telegraf.Metric) error {
+	delay := b.RetryDelay.Duration
+	for attempt := 0; ; attempt++ {
+		err := b.insert(name, metrics)
+		if err == nil {
+			return nil
+		}
+		if attempt >= b.MaxRetries || !retryable(err) {
+			return fmt.Errorf("writing to table %s failed: %v", name, err)
+		}
+		log.Printf("W! [outputs.bigquery] writing to table %s failed, retrying in %s: %v", name, delay, err)
+		b.sleep(delay)
+		delay *= 2
+	}
+}
+
+func (b *BigQuery) insert(name string, metrics []telegraf.Metric) error {
+	ctx, cancel := context.WithTimeout(context.Background(), b.Timeout.Duration)
+	defer cancel()
+
+	table := b.client.Dataset(b.Dataset).Table(name)
+
+	var rows []bigquery.ValueSaver
+	if b.CompactTable != "" {
+		if err := b.prepareTable(ctx, table, compactSchema()); err != nil {
+			return err
+		}
+		for _, metric := range metrics {
+			r, err := compactRow(metric)
+			if err != nil {
+				log.Printf("E! [outputs.bigquery] could not serialize metric: %v", err)
+				continue
+			}
+			rows = append(rows, r)
+		}
+	} else {
+		if err := b.prepareTable(ctx, table, metricsSchema(metrics)); err != nil {
+			return err
+		}
+		columns := b.tables[name]
+		for _, metric := range metrics {
+			rows = append(rows, metricRow(metric, columns))
+		}
+	}
+
+	err := table.Inserter().Put(ctx, rows)
+	if multiErr, ok := err.(bigquery.PutMultiError); ok {
+		// The invalid rows are dropped, as writing them again would fail.
+		for _, rowErr := range multiErr {
+			log.Printf("E! [outputs.bigquery] dropping row %d of table %s: %v", rowErr.RowIndex, name, rowErr.Errors)
+		}
+		return nil
+	}
+	if err != nil {
+		// The table could have been changed, read its schema again.
+		delete(b.tables, name)
+		return err
+	}
+	return nil
+}
+
+// prepareTable creates the table or adds the columns of the schema missing
+// in it.
+func (b *BigQuery) prepareTable(ctx context.Context, table *bigquery.Table, schema bigquery.Schema) error {
+	columns, ok := b.tables[table.TableID]
+	if !ok {
+		meta, err := table.Metadata(ctx)
+		if err != nil {
+			if !notFound(err) || !b.CreateTables {
+				return fmt.Errorf("reading the schema of table %s failed: %v", table.TableID, err)
+			}
+			meta = &bigquery.TableMetadata{Schema: schema}
+			if err := table.Create(ctx, meta); err != nil {
+				return fmt.Errorf("creating table %s failed: %v", table.TableID, err)
+			}
+		}
+		columns = make(map[string]bigquery.FieldType)
+		for _, field := range meta.Schema {
+			columns[field.Name] = field.Type
+		}
+		b.tables[table.TableID] = columns
+	}
+
+	var missing bigquery.Schema
+	for _, field := range schema {
+		if _, ok := columns[field.Name]; !ok {
+			missing = append(missing, field)
+		}
+	}
+	if len(missing) == 0 || !b.CreateTables {
+		return nil
+	}
+
+	// The new columns are appended to the current schema of the table, the
+	// update failing if it is changed in the meantime.
+	meta, err := table.Metadata(ctx)
+	if err != nil {
+		return fmt.Errorf("reading the schema of table %s failed: %v", table.TableID, err)
+	}
+	update := bigquery.TableMetadataToUpdate{Schema: append(meta.Schema, missing...)}
+	if _, err := table.Update(ctx, update, meta.ETag); err != nil {
+		return fmt.Errorf("updating the schema of table %s failed: %v", table.TableID, err)
+	}
+	for _, field := range update.Schema {
+		columns[field.Name] = field.Type
+	}
+	return nil
+}
+
+func compactSchema() bigquery.Schema {
+	return bigquery.Schema{
+		{Name: timestampColumn, Type: bigquery.TimestampFieldType, Required: true},
+		{Name: "name", Type: bigquery.StringFieldType},
+		{Name: "tags", Type: bigquery.StringFieldType},
+		{Name: "fields", Type: bigquery.StringFieldType},
+	}
+}
+
+func compactRow(metric telegraf.Metric) (*row, error) {
+	tags, err := json.Marshal(metric.Tags())
+	if err != nil {
+		return nil, err
+	}
+	fields, err := json.Marshal(metric.Fields())
+	if err != nil {
+		return nil, err
+	}
+	return &row{
+		values: map[string]bigquery.Value{
+			timestampColumn: metric.Time(),
+			"name":          metric.Name(),
+			"tags":          string(tags),
+			"fields":        string(fields),
+		},
+		insertID: insertID(metric),
+	}, nil
+}
+
+// metricsSchema returns the columns of the metrics of a table: the time, the
+// tags and the fields.
+func metricsSchema(metrics []telegraf.Metric) bigquery.Schema {
+	tags, fields := metricSchema.Columns(metrics)
+
+	schema := bigquery.Schema{{Name: timestampColumn, Type: bigquery.TimestampFieldType, Required: true}}
+	for _, name := range tags {
+		schema = append(schema, &bigquery.FieldSchema{Name: name, Type: bigquery.StringFieldType})
+	}
+	for _, field := range fields {
+		schema = append(schema, &bigquery.FieldSchema{Name: field.Name, Type: bigquery.FieldType(field.Type)})
+	}
+	return schema
+}
+
+// metricRow returns the row of a metric, with the values of the columns of
+// the table.  The values which do not match the type of their column are
+// dropped.
+func metricRow(metric telegraf.Metric, columns map[string]bigquery.FieldType) *row {
+	values := map[string]bigquery.Value{timestampColumn: metric.Time()}
+	for _, tag := range metric.TagList() {
+		name := sanitize(tag.Key)
+		if columns[name] == bigquery.StringFieldType {
+			values[name] = tag.Value
+		}
+	}
+	for _, field := range metric.FieldList() {
+		name := sanitize(field.Key)
+		if _, ok := values[name]; ok {
+			continue
+		}
+		typ, ok := columns[name]
+		if !ok {
+			continue
+		}
+		if string(typ) != metricSchema.Type(field.Value) {
+			log.Printf("D! [outputs.bigquery] dropping field %s of type %T, the column is %s", field.Key, field.Value, typ)
+			continue
+		}
+		// BigQuery integers are signed 64 bit integers.
+		if v, ok := field.Value.(uint64); ok && v > math.MaxInt64 {
+			values[name] = int64(math.MaxInt64)
+			continue
+		}
+		values[name] = field.Value
+	}
+	return &row{values: values, insertID: insertID(metric)}
+}
+
+func insertID(metric telegraf.Metric) string {
+	return strconv.FormatUint(metric.HashID(), 16) + "-" + strconv.FormatInt(metric.Time().UnixNano(), 16)
+}
+
+// sanitize returns the name of a table or column, replacing the invalid
+// characters with underscores.
+func sanitize(name string) string {
+	name = invalidNameChars.ReplaceAllString(name, "_")
+	if name != "" && name[0] >= '0' && name[0] <= '9' {
+		name = "_" + name
+	}
+	return name
+}
+
+func notFound(err error) bool {
+	e, ok := err.(*googleapi.Error)
+	return ok && e.Code == http.StatusNotFound
+}
+
+// retryable returns whether the error is caused by the quotas or the
+// availability of BigQuery.
+func retryable(err error) bool {
+	e, ok := err.(*googleapi.Error)
+	if !ok {
+		return false
+	}
+	if e.Code == http.StatusTooManyRequests || e.Code >= 500 {
+		return true
+	}
+	for _, item := range e.Errors {
+		switch item.Reason {
+		case "quotaExceeded", "rateLimitExceeded", "backendError":
+			return true
+		}
+	}
+	return false
+}
+
+func init() {
+	outputs.Add("bigquery", func() telegraf.Output {
+		return &BigQuery{
+			CreateTables: true,
+			Timeout:      internal.Duration{Duration: 5 * time.Second},
+			MaxRetries:   3,
+			RetryDelay:   internal.Duration{Duration: time.Second},
+		}
+	})
+}
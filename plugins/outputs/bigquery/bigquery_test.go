@@ -0,0 +1,256 @@
+package bigquery
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/option"
+)
+
+const tablesPath = "/projects/test-project/datasets/telegraf/tables"
+
+type tableField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Mode string `json:"mode,omitempty"`
+}
+
+type tableResource struct {
+	Schema struct {
+		Fields []tableField `json:"fields"`
+	} `json:"schema"`
+	ETag string `json:"etag"`
+}
+
+// fakeServer is a BigQuery API recording the requests, with the schemas of
+// the existing tables.
+type fakeServer struct {
+	*httptest.Server
+	tables   map[string][]tableField
+	requests []string
+	rows     map[string][]map[string]interface{}
+	// Number of inserts failing with a quota error.
+	quotaErrors int
+}
+
+func newFakeServer(t *testing.T, tables map[string][]tableField) *fakeServer {
+	s := &fakeServer{tables: tables, rows: make(map[string][]map[string]interface{})}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.requests = append(s.requests, r.Method+" "+strings.TrimPrefix(r.URL.Path, tablesPath))
+		name := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, tablesPath), "/")
+		w.Header().Set("Content-Type", "application/json")
+
+		switch {
+		case r.Method == http.MethodGet:
+			fields, ok := s.tables[name]
+			if !ok {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"error":{"code":404,"message":"Not found: Table","errors":[{"reason":"notFound"}]}}`))
+				return
+			}
+			var resource tableResource
+			resource.Schema.Fields = fields
+			resource.ETag = "etag"
+			json.NewEncoder(w).Encode(resource)
+		case r.Method == http.MethodPost && name == "":
+			var resource struct {
+				TableReference struct {
+					TableID string `json:"tableId"`
+				} `json:"tableReference"`
+				tableResource
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&resource))
+			s.tables[resource.TableReference.TableID] = resource.Schema.Fields
+			json.NewEncoder(w).Encode(resource)
+		case r.Method == http.MethodPatch:
+			require.Equal(t, "etag", r.Header.Get("If-Match"))
+			var resource tableResource
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&resource))
+			s.tables[name] = resource.Schema.Fields
+			json.NewEncoder(w).Encode(resource)
+		case r.Method == http.MethodPost && strings.HasSuffix(name, "/insertAll"):
+			if s.quotaErrors > 0 {
+				s.quotaErrors--
+				w.WriteHeader(http.StatusForbidden)
+				w.Write([]byte(`{"error":{"code":403,"message":"Quota exceeded","errors":[{"reason":"quotaExceeded"}]}}`))
+				return
+			}
+			var request struct {
+				Rows []struct {
+					InsertID string                 `json:"insertId"`
+					JSON     map[string]interface{} `json:"json"`
+				} `json:"rows"`
+			}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+			table := strings.TrimSuffix(name, "/insertAll")
+			for _, row := range request.Rows {
+				require.NotEmpty(t, row.InsertID)
+				s.rows[table] = append(s.rows[table], row.JSON)
+			}
+			w.Write([]byte(`{"kind":"bigquery#tableDataInsertAllResponse"}`))
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+	}))
+	return s
+}
+
+func newBigQuery(url string) *BigQuery {
+	return &BigQuery{
+		Project:      "test-project",
+		Dataset:      "telegraf",
+		CreateTables: true,
+		Timeout:      internal.Duration{Duration: 5 * time.Second},
+		MaxRetries:   3,
+		RetryDelay:   internal.Duration{Duration: time.Second},
+		options: []option.ClientOption{
+			option.WithEndpoint(url + "/"),
+			option.WithoutAuthentication(),
+		},
+	}
+}
+
+func TestWriteCreatesTables(t *testing.T) {
+	s := newFakeServer(t, map[string][]tableField{})
+	defer s.Close()
+
+	b := newBigQuery(s.URL)
+	require.NoError(t, b.Connect())
+	defer b.Close()
+
+	err := b.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 0)),
+		testutil.MustMetric("disk.io", map[string]string{"host": "a"}, map[string]interface{}{"reads": uint64(math.MaxUint64)}, time.Unix(1500000000, 0)),
+		testutil.MustMetric("cpu", map[string]string{"host": "b"}, map[string]interface{}{"usage": 1.0, "ok": true}, time.Unix(1500000000, 0)),
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{
+		"GET /cpu",
+		"POST ",
+		"POST /cpu/insertAll",
+		"GET /disk_io",
+		"POST ",
+		"POST /disk_io/insertAll",
+	}, s.requests)
+
+	require.Equal(t, []tableField{
+		{Name: "timestamp", Type: "TIMESTAMP", Mode: "REQUIRED"},
+		{Name: "host", Type: "STRING"},
+		{Name: "ok", Type: "BOOLEAN"},
+		{Name: "usage", Type: "FLOAT"},
+	}, s.tables["cpu"])
+	require.Equal(t, []map[string]interface{}{
+		{"timestamp": "2017-07-14T02:40:00Z", "host": "a", "usage": 42.5},
+		{"timestamp": "2017-07-14T02:40:00Z", "host": "b", "usage": 1.0, "ok": true},
+	}, s.rows["cpu"])
+	require.Equal(t, float64(math.MaxInt64), s.rows["disk_io"][0]["reads"])
+
+	// The schemas of the known tables are not read again
+	s.requests = nil
+	require.NoError(t, b.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 0)),
+	}))
+	require.Equal(t, []string{"POST /cpu/insertAll"}, s.requests)
+
+	// The columns of the new fields are added
+	s.requests = nil
+	require.NoError(t, b.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"idle": int64(3)}, time.Unix(1500000000, 0)),
+	}))
+	require.Equal(t, []string{"GET /cpu", "PATCH /cpu", "POST /cpu/insertAll"}, s.requests)
+	require.Equal(t, tableField{Name: "idle", Type: "INTEGER"}, s.tables["cpu"][4])
+	require.Equal(t, 3.0, s.rows["cpu"][3]["idle"])
+}
+
+func TestWriteExistingTable(t *testing.T) {
+	s := newFakeServer(t, map[string][]tableField{
+		"cpu": {
+			{Name: "timestamp", Type: "TIMESTAMP"},
+			{Name: "usage", Type: "INTEGER"},
+		},
+	})
+	defer s.Close()
+
+	b := newBigQuery(s.URL)
+	b.CreateTables = false
+	require.NoError(t, b.Connect())
+	defer b.Close()
+
+	err := b.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 42.5, "count": int64(1)}, time.Unix(1500000000, 0)),
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"GET /cpu", "POST /cpu/insertAll"}, s.requests)
+	// The fields of another type and the missing columns are not written
+	require.Equal(t, []map[string]interface{}{{"timestamp": "2017-07-14T02:40:00Z"}}, s.rows["cpu"])
+
+	// Writing to a missing table fails
+	err = b.Write([]telegraf.Metric{testutil.MustMetric("mem", nil, map[string]interface{}{"used": int64(1)}, time.Unix(1500000000, 0))})
+	require.Error(t, err)
+}
+
+func TestWriteCompactTable(t *testing.T) {
+	s := newFakeServer(t, map[string][]tableField{})
+	defer s.Close()
+
+	b := newBigQuery(s.URL)
+	b.CompactTable = "metrics"
+	require.NoError(t, b.Connect())
+	defer b.Close()
+
+	err := b.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 0)),
+		testutil.MustMetric("mem", map[string]string{"host": "a"}, map[string]interface{}{"used": int64(7)}, time.Unix(1500000000, 0)),
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"GET /metrics", "POST ", "POST /metrics/insertAll"}, s.requests)
+	require.Equal(t, []map[string]interface{}{
+		{"timestamp": "2017-07-14T02:40:00Z", "name": "cpu", "tags": `{"host":"a"}`, "fields": `{"usage":42.5}`},
+		{"timestamp": "2017-07-14T02:40:00Z", "name": "mem", "tags": `{"host":"a"}`, "fields": `{"used":7}`},
+	}, s.rows["metrics"])
+}
+
+func TestWriteRetriesQuotaErrors(t *testing.T) {
+	s := newFakeServer(t, map[string][]tableField{
+		"cpu": {
+			{Name: "timestamp", Type: "TIMESTAMP"},
+			{Name: "usage", Type: "FLOAT"},
+		},
+	})
+	defer s.Close()
+
+	var delays []time.Duration
+	b := newBigQuery(s.URL)
+	b.sleep = func(d time.Duration) { delays = append(delays, d) }
+	require.NoError(t, b.Connect())
+	defer b.Close()
+
+	m := testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 0))
+	s.quotaErrors = 2
+	require.NoError(t, b.Write([]telegraf.Metric{m}))
+	require.Equal(t, []time.Duration{time.Second, 2 * time.Second}, delays)
+	require.Len(t, s.rows["cpu"], 1)
+
+	// The write fails after the retries
+	delays = nil
+	s.quotaErrors = 10
+	require.Error(t, b.Write([]telegraf.Metric{m}))
+	require.Len(t, delays, 3)
+}
+
+func TestSanitize(t *testing.T) {
+	require.Equal(t, "disk_io", sanitize("disk.io"))
+	require.Equal(t, "_1m_load", sanitize("1m-load"))
+}
@@ -0,0 +1,423 @@
+package clickhouse
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tables"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/outputs"
+)
+
+const (
+	timestampColumn   = "timestamp"
+	measurementColumn = "measurement"
+
+	timestampType = "DateTime64(9, 'UTC')"
+	tagType       = "LowCardinality(String)"
+)
+
+// fieldTypes are the types of the columns of the fields, which are nullable.
+var fieldTypes = map[tables.Kind]string{
+	tables.Float:  "Float64",
+	tables.Int:    "Int64",
+	tables.Uint:   "UInt64",
+	tables.Bool:   "UInt8",
+	tables.String: "String",
+}
+
+// columnKinds are the kinds of the values of the column types.
+var columnKinds = map[string]tables.Kind{
+	"String":  tables.String,
+	"Float64": tables.Float,
+	"Int64":   tables.Int,
+	"UInt64":  tables.Uint,
+	"UInt8":   tables.Uint,
+}
+
+var sampleConfig = `
+  ## URL of the ClickHouse server: the HTTP interface with a "http" or "https"
+  ## scheme, or the native protocol with a "tcp" scheme.
+  url = "http://127.0.0.1:8123"
+  # url = "tcp://127.0.0.1:9000"
+
+  ## Credentials of the user.
+  # username = "default"
+  # password = ""
+
+  ## Database of the tables.
+  # database = "default"
+
+  ## Layout of the tables: "measurement" to write the metrics in a table per
+  ## measurement, named after it, or "single" to write all the metrics in the
+  ## wide table named by the table option, with a "measurement" column.
+  # table_mode = "measurement"
+  # table = "telegraf"
+
+  ## Create the tables if they do not exist, and add the columns of the new
+  ## tags and fields.  The tags are LowCardinality(String) columns and the
+  ## fields Nullable columns of their type, the time being the "timestamp"
+  ## column.
+  # create_tables = true
+
+  ## Table engine, partition key and sorting key of the created tables.  The
+  ## tables are not partitioned by default, a common key being
+  ## "toYYYYMM(timestamp)".  The sorting key defaults to the tags of the first
+  ## metrics written, followed by the timestamp.
+  # engine = "MergeTree()"
+  # partition_by = ""
+  # order_by = ""
+
+  ## Time to live of the rows of the created tables, 0 to keep them forever.
+  # ttl = "0s"
+
+  ## Use the asynchronous inserts of ClickHouse, buffering the inserts on the
+  ## server to insert the rows of many small writes at once.  If
+  ## wait_for_async_insert is false, the writes succeed as soon as the rows
+  ## are buffered, so the rows can be lost if the server fails.
+  # async_insert = false
+  # wait_for_async_insert = true
+
+  ## Amount of time allowed to complete the queries.
+  # timeout = "5s"
+
+  ## Optional TLS Config, for the HTTP interface
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+// client runs the queries on a ClickHouse server.
+type client interface {
+	// exec runs a statement without result.
+	exec(ctx context.Context, query string) error
+	// columns returns the types of the columns of a table by name, or an
+	// empty map if the table does not exist.
+	columns(ctx context.Context, database, table string) (map[string]string, error)
+	// insert inserts the rows with the insert query, which does not contain
+	// the values.
+	insert(ctx context.Context, query string, columns []string, rows [][]interface{}) error
+	close() error
+}
+
+type ClickHouse struct {
+	URL                string            `toml:"url"`
+	Username           string            `toml:"username"`
+	Password           string            `toml:"password"`
+	Database           string            `toml:"database"`
+	TableMode          string            `toml:"table_mode"`
+	Table              string            `toml:"table"`
+	CreateTables       bool              `toml:"create_tables"`
+	Engine             string            `toml:"engine"`
+	PartitionBy        string            `toml:"partition_by"`
+	OrderBy            string            `toml:"order_by"`
+	TTL                internal.Duration `toml:"ttl"`
+	AsyncInsert        bool              `toml:"async_insert"`
+	WaitForAsyncInsert bool              `toml:"wait_for_async_insert"`
+	Timeout            internal.Duration `toml:"timeout"`
+	tls.ClientConfig
+
+	client client
+	native bool
+
+	// The types of the columns of the known tables.
+	tables *tables.Cache
+}
+
+func (ch *ClickHouse) SampleConfig() string {
+	return sampleConfig
+}
+
+func (ch *ClickHouse) Description() string {
+	return "Write metrics to ClickHouse tables"
+}
+
+func (ch *ClickHouse) Connect() error {
+	switch ch.TableMode {
+	case "":
+		ch.TableMode = "measurement"
+	case "measurement":
+	case "single":
+		if ch.Table == "" {
+			return fmt.Errorf("table is required in the single table_mode")
+		}
+	default:
+		return fmt.Errorf("invalid table_mode %q", ch.TableMode)
+	}
+
+	u, err := url.Parse(ch.URL)
+	if err != nil {
+		return fmt.Errorf("error parsing url [%s]: %v", ch.URL, err)
+	}
+
+	switch u.Scheme {
+	case "http", "https":
+		tlsCfg, err := ch.ClientConfig.TLSConfig()
+		if err != nil {
+			return err
+		}
+		ch.client = newHTTPClient(u, ch.Username, ch.Password, ch.Timeout.Duration, tlsCfg)
+	case "tcp":
+		ch.client, err = newNativeClient(u, ch.Username, ch.Password, ch.Database)
+		if err != nil {
+			return err
+		}
+		ch.native = true
+	default:
+		return fmt.Errorf("unsupported scheme [%s]: %q", ch.URL, u.Scheme)
+	}
+
+	ch.tables = tables.NewCache(ch.CreateTables)
+	return nil
+}
+
+func (ch *ClickHouse) Close() error {
+	if ch.client == nil {
+		return nil
+	}
+	return ch.client.close()
+}
+
+func (ch *ClickHouse) Write(metrics []telegraf.Metric) error {
+	ctx, cancel := context.WithTimeout(context.Background(), ch.Timeout.Duration)
+	defer cancel()
+
+	for _, batch := range tables.Group(metrics, ch.tableName) {
+		if err := ch.writeTable(ctx, batch.Table, batch.Metrics); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tableName returns the table of a metric, named after its measurement
+// unless all the metrics are written to the single table.
+func (ch *ClickHouse) tableName(metric telegraf.Metric) string {
+	if ch.TableMode == "single" {
+		return ch.Table
+	}
+	return metric.Name()
+}
+
+// tableColumns returns the columns of the metrics, in the order of the
+// table creation: the timestamp, the measurement in the single table, the
+// tags and the fields.
+func (ch *ClickHouse) tableColumns(metrics []telegraf.Metric) []tables.Column {
+	columns := []tables.Column{{Name: timestampColumn, Type: timestampType}}
+	schema := tables.Schema{Types: fieldTypes, Reserved: []string{timestampColumn}}
+	if ch.TableMode == "single" {
+		columns = append(columns, tables.Column{Name: measurementColumn, Type: tagType})
+		schema.Reserved = append(schema.Reserved, measurementColumn)
+	}
+
+	tags, fields := schema.Columns(metrics)
+	for _, name := range tags {
+		columns = append(columns, tables.Column{Name: name, Type: tagType})
+	}
+	for _, field := range fields {
+		columns = append(columns, tables.Column{Name: field.Name, Type: "Nullable(" + field.Type + ")"})
+	}
+	return columns
+}
+
+func (ch *ClickHouse) writeTable(ctx context.Context, table string, metrics []telegraf.Metric) error {
+	columns := ch.tableColumns(metrics)
+	types, err := ch.prepareTable(ctx, table, columns)
+	if err != nil {
+		return err
+	}
+
+	// Only the columns of the table are written, when they are not managed.
+	var names []string
+	for _, c := range columns {
+		if _, ok := types[c.Name]; ok {
+			names = append(names, c.Name)
+		} else {
+			log.Printf("D! [outputs.clickhouse] table %s has no column %s", table, c.Name)
+		}
+	}
+
+	rows := make([][]interface{}, 0, len(metrics))
+	for _, metric := range metrics {
+		row := make([]interface{}, len(names))
+		for i, name := range names {
+			row[i] = ch.value(metric, name, types[name])
+		}
+		rows = append(rows, row)
+	}
+
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quoteIdentifier(name)
+	}
+	query := fmt.Sprintf("INSERT INTO %s.%s (%s)", quoteIdentifier(ch.Database), quoteIdentifier(table),
+		strings.Join(quoted, ", "))
+	if settings := ch.insertSettings(); len(settings) > 0 {
+		query += " SETTINGS " + strings.Join(settings, ", ")
+	}
+
+	if err := ch.client.insert(ctx, query, names, rows); err != nil {
+		// The table could have been changed, load its columns again.
+		ch.tables.Forget(table)
+		return fmt.Errorf("inserting into table %s failed: %v", table, err)
+	}
+	return nil
+}
+
+func (ch *ClickHouse) insertSettings() []string {
+	var settings []string
+	if ch.native {
+		// The native driver does not support the LowCardinality columns, the
+		// server converts them to the ordinary columns.
+		settings = append(settings, "low_cardinality_allow_in_native_format = 0")
+	}
+	if ch.AsyncInsert {
+		settings = append(settings, "async_insert = 1")
+		if ch.WaitForAsyncInsert {
+			settings = append(settings, "wait_for_async_insert = 1")
+		} else {
+			settings = append(settings, "wait_for_async_insert = 0")
+		}
+	}
+	return settings
+}
+
+// prepareTable returns the types of the columns of the table, creating the
+// table and adding the columns if needed.
+func (ch *ClickHouse) prepareTable(ctx context.Context, table string, columns []tables.Column) (map[string]string, error) {
+	return ch.tables.Prepare(ctx, tableDatabase{ch}, table, columns)
+}
+
+// tableDatabase creates the tables of the database and adds their columns.
+type tableDatabase struct {
+	ch *ClickHouse
+}
+
+func (d tableDatabase) CreateTable(ctx context.Context, table string, columns []tables.Column) error {
+	if err := d.ch.client.exec(ctx, d.ch.createTableQuery(table, columns)); err != nil {
+		return fmt.Errorf("creating table %s failed: %v", table, err)
+	}
+	return nil
+}
+
+func (d tableDatabase) Columns(ctx context.Context, table string) (map[string]string, error) {
+	return d.ch.client.columns(ctx, d.ch.Database, table)
+}
+
+func (d tableDatabase) AddColumn(ctx context.Context, table string, c tables.Column) error {
+	query := fmt.Sprintf("ALTER TABLE %s.%s ADD COLUMN IF NOT EXISTS %s %s",
+		quoteIdentifier(d.ch.Database), quoteIdentifier(table), quoteIdentifier(c.Name), c.Type)
+	return d.ch.client.exec(ctx, query)
+}
+
+func (ch *ClickHouse) createTableQuery(table string, columns []tables.Column) string {
+	definitions := make([]string, len(columns))
+	var orderBy []string
+	for i, c := range columns {
+		definitions[i] = quoteIdentifier(c.Name) + " " + c.Type
+		if c.Type == tagType {
+			orderBy = append(orderBy, quoteIdentifier(c.Name))
+		}
+	}
+	orderBy = append(orderBy, quoteIdentifier(timestampColumn))
+
+	query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s.%s (%s) ENGINE = %s",
+		quoteIdentifier(ch.Database), quoteIdentifier(table), strings.Join(definitions, ", "), ch.Engine)
+	if ch.PartitionBy != "" {
+		query += " PARTITION BY " + ch.PartitionBy
+	}
+	if ch.OrderBy != "" {
+		query += " ORDER BY " + ch.OrderBy
+	} else {
+		query += " ORDER BY (" + strings.Join(orderBy, ", ") + ")"
+	}
+	if ch.TTL.Duration > 0 {
+		query += fmt.Sprintf(" TTL toDateTime(%s) + INTERVAL %d SECOND",
+			quoteIdentifier(timestampColumn), int64(ch.TTL.Duration/time.Second))
+	}
+	return query
+}
+
+// value returns the value of a column for the metric, converted to the type
+// of the column.
+func (ch *ClickHouse) value(metric telegraf.Metric, name, typ string) interface{} {
+	switch {
+	case name == timestampColumn:
+		return metric.Time().UTC()
+	case name == measurementColumn && ch.TableMode == "single":
+		return metric.Name()
+	}
+
+	if tag, ok := metric.GetTag(name); ok {
+		return convertValue(tag, typ)
+	}
+	if field, ok := metric.GetField(name); ok {
+		return convertValue(field, typ)
+	}
+
+	// The missing values of the non nullable columns are their default.
+	if typ == tagType || typ == "String" {
+		return ""
+	}
+	return nil
+}
+
+// convertValue converts the value to the type of a column, returning nil if
+// it cannot be converted.
+func convertValue(value interface{}, typ string) interface{} {
+	if strings.HasPrefix(typ, "Nullable(") {
+		typ = typ[len("Nullable(") : len(typ)-1]
+	}
+	if strings.HasPrefix(typ, "LowCardinality(") {
+		typ = typ[len("LowCardinality(") : len(typ)-1]
+	}
+
+	value = tables.Convert(value, columnKinds[typ])
+	switch v := value.(type) {
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil
+		}
+	case uint64:
+		// The booleans are stored as UInt8.
+		if typ == "UInt8" {
+			if v > math.MaxUint8 {
+				return nil
+			}
+			return uint8(v)
+		}
+	}
+	return value
+}
+
+// quoteIdentifier quotes the name of a database, table or column.
+func quoteIdentifier(name string) string {
+	return "`" + strings.NewReplacer("\\", "\\\\", "`", "\\`").Replace(name) + "`"
+}
+
+// quoteString quotes a string literal.
+func quoteString(s string) string {
+	return "'" + strings.NewReplacer("\\", "\\\\", "'", "\\'").Replace(s) + "'"
+}
+
+func init() {
+	outputs.Add("clickhouse", func() telegraf.Output {
+		return &ClickHouse{
+			Database:           "default",
+			Table:              "telegraf",
+			CreateTables:       true,
+			Engine:             "MergeTree()",
+			WaitForAsyncInsert: true,
+			Timeout:            internal.Duration{Duration: 5 * time.Second},
+		}
+	})
+}
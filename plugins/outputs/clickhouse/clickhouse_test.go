@@ -0,0 +1,203 @@
+package clickhouse
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeServer is a ClickHouse HTTP interface recording the statements and
+// inserts, and returning the columns of the tables.
+type fakeServer struct {
+	*httptest.Server
+	columns    map[string]string
+	statements []string
+	inserts    []string
+	data       []string
+}
+
+func newFakeServer(t *testing.T, columns map[string]string) *fakeServer {
+	s := &fakeServer{columns: columns}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "default", r.Header.Get("X-ClickHouse-User"))
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		if query := r.URL.Query().Get("query"); query != "" {
+			s.inserts = append(s.inserts, query)
+			s.data = append(s.data, string(body))
+			return
+		}
+
+		statement := string(body)
+		if strings.HasPrefix(statement, "SELECT name, type FROM system.columns") {
+			for name, typ := range s.columns {
+				fmt.Fprintf(w, `{"name":%q,"type":%q}`+"\n", name, typ)
+			}
+			return
+		}
+		s.statements = append(s.statements, statement)
+	}))
+	return s
+}
+
+func newClickHouse(url string) *ClickHouse {
+	return &ClickHouse{
+		URL:                url,
+		Username:           "default",
+		Database:           "default",
+		Table:              "telegraf",
+		CreateTables:       true,
+		Engine:             "MergeTree()",
+		WaitForAsyncInsert: true,
+		Timeout:            internal.Duration{Duration: 5 * time.Second},
+	}
+}
+
+func TestWriteMeasurementTables(t *testing.T) {
+	s := newFakeServer(t, map[string]string{
+		"timestamp": timestampType,
+		"host":      tagType,
+		"value":     "Nullable(Float64)",
+		"ok":        "Nullable(UInt8)",
+	})
+	defer s.Close()
+
+	ch := newClickHouse(s.URL)
+	ch.PartitionBy = "toYYYYMM(timestamp)"
+	ch.TTL.Duration = 24 * time.Hour
+	require.NoError(t, ch.Connect())
+
+	err := ch.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 42.5, "ok": true}, time.Unix(1500000000, 123456789)),
+		testutil.MustMetric("cpu", map[string]string{"host": "b"}, map[string]interface{}{"value": int64(3)}, time.Unix(1500000000, 123456789)),
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{
+		"CREATE TABLE IF NOT EXISTS `default`.`cpu` (`timestamp` DateTime64(9, 'UTC'), " +
+			"`host` LowCardinality(String), `ok` Nullable(UInt8), `value` Nullable(Float64)) " +
+			"ENGINE = MergeTree() PARTITION BY toYYYYMM(timestamp) ORDER BY (`host`, `timestamp`) " +
+			"TTL toDateTime(`timestamp`) + INTERVAL 86400 SECOND",
+	}, s.statements)
+
+	require.Equal(t, []string{
+		"INSERT INTO `default`.`cpu` (`timestamp`, `host`, `ok`, `value`) FORMAT JSONEachRow",
+	}, s.inserts)
+	require.Equal(t,
+		`{"host":"a","ok":1,"timestamp":"2017-07-14 02:40:00.123456789","value":42.5}`+"\n"+
+			`{"host":"b","ok":null,"timestamp":"2017-07-14 02:40:00.123456789","value":3}`+"\n",
+		s.data[0])
+
+	// The columns of the known tables are not read again.
+	require.NoError(t, ch.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 1.0}, time.Unix(1500000000, 123456789)),
+	}))
+	require.Len(t, s.statements, 1)
+	require.Len(t, s.inserts, 2)
+}
+
+func TestWriteSingleTableAddsColumns(t *testing.T) {
+	s := newFakeServer(t, map[string]string{
+		"timestamp":   timestampType,
+		"measurement": tagType,
+		"host":        tagType,
+		"usage":       "Nullable(Float64)",
+	})
+	defer s.Close()
+
+	ch := newClickHouse(s.URL)
+	ch.TableMode = "single"
+	ch.AsyncInsert = true
+	ch.WaitForAsyncInsert = false
+	require.NoError(t, ch.Connect())
+
+	err := ch.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 123456789)),
+		testutil.MustMetric("mem", map[string]string{"host": "a"}, map[string]interface{}{"used": uint64(7)}, time.Unix(1500000000, 123456789)),
+	})
+	require.NoError(t, err)
+
+	require.Len(t, s.statements, 2)
+	require.Contains(t, s.statements[0], "CREATE TABLE IF NOT EXISTS `default`.`telegraf`")
+	require.Contains(t, s.statements[0], "ORDER BY (`measurement`, `host`, `timestamp`)")
+	require.Equal(t, "ALTER TABLE `default`.`telegraf` ADD COLUMN IF NOT EXISTS `used` Nullable(UInt64)", s.statements[1])
+
+	require.Equal(t, []string{
+		"INSERT INTO `default`.`telegraf` (`timestamp`, `measurement`, `host`, `usage`, `used`) " +
+			"SETTINGS async_insert = 1, wait_for_async_insert = 0 FORMAT JSONEachRow",
+	}, s.inserts)
+	require.Contains(t, s.data[0], `"measurement":"mem"`)
+	require.Contains(t, s.data[0], `"usage":null,"used":7`)
+}
+
+func TestWriteUnmanagedTable(t *testing.T) {
+	s := newFakeServer(t, map[string]string{
+		"timestamp": timestampType,
+		"value":     "Float64",
+	})
+	defer s.Close()
+
+	ch := newClickHouse(s.URL)
+	ch.CreateTables = false
+	require.NoError(t, ch.Connect())
+
+	err := ch.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"value": 42.5}, time.Unix(1500000000, 123456789)),
+	})
+	require.NoError(t, err)
+
+	require.Empty(t, s.statements)
+	require.Equal(t, []string{
+		"INSERT INTO `default`.`cpu` (`timestamp`, `value`) FORMAT JSONEachRow",
+	}, s.inserts)
+}
+
+func TestWriteMissingTable(t *testing.T) {
+	s := newFakeServer(t, map[string]string{})
+	defer s.Close()
+
+	ch := newClickHouse(s.URL)
+	ch.CreateTables = false
+	require.NoError(t, ch.Connect())
+
+	err := ch.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", nil, map[string]interface{}{"value": 42.5}, time.Unix(1500000000, 123456789)),
+	})
+	require.Error(t, err)
+}
+
+func TestConvertValue(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		typ      string
+		expected interface{}
+	}{
+		{42.5, "Nullable(Float64)", 42.5},
+		{int64(42), "Float64", 42.0},
+		{int64(-1), "UInt64", nil},
+		{uint64(42), "Nullable(Int64)", int64(42)},
+		{true, "Nullable(UInt8)", uint8(1)},
+		{"a", "Float64", nil},
+		{int64(42), "LowCardinality(String)", "42"},
+		{42.0, "Nullable(Date)", nil},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, convertValue(tt.value, tt.typ), "%v as %s", tt.value, tt.typ)
+	}
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	require.Equal(t, "`a\\`b`", quoteIdentifier("a`b"))
+	require.Equal(t, "'it\\'s'", quoteString("it's"))
+}
@@ -0,0 +1,130 @@
+package clickhouse
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// httpClient runs the queries with the HTTP interface.
+type httpClient struct {
+	url      *url.URL
+	username string
+	password string
+	client   *http.Client
+}
+
+func newHTTPClient(u *url.URL, username, password string, timeout time.Duration, tlsCfg *tls.Config) *httpClient {
+	return &httpClient{
+		url:      u,
+		username: username,
+		password: password,
+		client: &http.Client{
+			Transport: &http.Transport{
+				TLSClientConfig: tlsCfg,
+				Proxy:           http.ProxyFromEnvironment,
+			},
+			Timeout: timeout,
+		},
+	}
+}
+
+// do posts the body, with the query in the URL if it is not the body.
+func (c *httpClient) do(ctx context.Context, query string, body io.Reader) ([]byte, error) {
+	reqURL := *c.url
+	if body == nil {
+		body = strings.NewReader(query)
+	} else {
+		q := reqURL.Query()
+		q.Set("query", query)
+		reqURL.RawQuery = q.Encode()
+	}
+
+	req, err := http.NewRequest("POST", reqURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if c.username != "" {
+		req.Header.Set("X-ClickHouse-User", c.username)
+	}
+	if c.password != "" {
+		req.Header.Set("X-ClickHouse-Key", c.password)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	result, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s returned HTTP status %s: %s", c.url.Host, resp.Status,
+			strings.TrimSpace(string(bytes.SplitN(result, []byte("\n"), 2)[0])))
+	}
+	return result, nil
+}
+
+func (c *httpClient) exec(ctx context.Context, query string) error {
+	_, err := c.do(ctx, query, nil)
+	return err
+}
+
+func (c *httpClient) columns(ctx context.Context, database, table string) (map[string]string, error) {
+	query := fmt.Sprintf("SELECT name, type FROM system.columns WHERE database = %s AND table = %s FORMAT JSONEachRow",
+		quoteString(database), quoteString(table))
+	result, err := c.do(ctx, query, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]string)
+	dec := json.NewDecoder(bytes.NewReader(result))
+	for dec.More() {
+		var row struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+		}
+		if err := dec.Decode(&row); err != nil {
+			return nil, err
+		}
+		columns[row.Name] = row.Type
+	}
+	return columns, nil
+}
+
+func (c *httpClient) insert(ctx context.Context, query string, columns []string, rows [][]interface{}) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, row := range rows {
+		values := make(map[string]interface{}, len(columns))
+		for i, v := range row {
+			if t, ok := v.(time.Time); ok {
+				v = t.Format("2006-01-02 15:04:05.000000000")
+			}
+			values[columns[i]] = v
+		}
+		if err := enc.Encode(values); err != nil {
+			return err
+		}
+	}
+
+	_, err := c.do(ctx, query+" FORMAT JSONEachRow", &buf)
+	return err
+}
+
+func (c *httpClient) close() error {
+	return nil
+}
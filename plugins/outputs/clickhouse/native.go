@@ -0,0 +1,90 @@
+package clickhouse
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"strings"
+
+	// Register the native protocol driver
+	_ "github.com/ClickHouse/clickhouse-go"
+)
+
+// nativeClient runs the queries with the native protocol.
+type nativeClient struct {
+	db *sql.DB
+}
+
+func newNativeClient(u *url.URL, username, password, database string) (*nativeClient, error) {
+	dsn := *u
+	q := dsn.Query()
+	if username != "" {
+		q.Set("username", username)
+	}
+	if password != "" {
+		q.Set("password", password)
+	}
+	if database != "" {
+		q.Set("database", database)
+	}
+	dsn.RawQuery = q.Encode()
+
+	db, err := sql.Open("clickhouse", dsn.String())
+	if err != nil {
+		return nil, err
+	}
+	return &nativeClient{db: db}, nil
+}
+
+func (c *nativeClient) exec(ctx context.Context, query string) error {
+	_, err := c.db.ExecContext(ctx, query)
+	return err
+}
+
+func (c *nativeClient) columns(ctx context.Context, database, table string) (map[string]string, error) {
+	rows, err := c.db.QueryContext(ctx, "SELECT name, type FROM system.columns WHERE database = ? AND table = ?",
+		database, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]string)
+	for rows.Next() {
+		var name, typ string
+		if err := rows.Scan(&name, &typ); err != nil {
+			return nil, err
+		}
+		columns[name] = typ
+	}
+	return columns, rows.Err()
+}
+
+// insert sends the rows in a single block, the driver batching the rows of
+// a transaction.
+func (c *nativeClient) insert(ctx context.Context, query string, columns []string, rows [][]interface{}) error {
+	tx, err := c.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", ")
+	stmt, err := tx.PrepareContext(ctx, query+" VALUES ("+placeholders+")")
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, row := range rows {
+		if _, err := stmt.ExecContext(ctx, row...); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (c *nativeClient) close() error {
+	return c.db.Close()
+}
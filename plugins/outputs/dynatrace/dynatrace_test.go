@@ -0,0 +1,148 @@
+package dynatrace
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+type received struct {
+	authorization string
+	body          string
+}
+
+func newServer(t *testing.T, status int, response string) (*httptest.Server, chan received) {
+	requests := make(chan received, 100)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		requests <- received{r.Header.Get("Authorization"), string(body)}
+		w.WriteHeader(status)
+		fmt.Fprint(w, response)
+	}))
+	return ts, requests
+}
+
+func newDynatrace(url string) *Dynatrace {
+	return &Dynatrace{
+		URL:      url,
+		APIToken: "token",
+		Prefix:   "telegraf",
+		Timeout:  internal.Duration{Duration: 5 * time.Second},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	ts, requests := newServer(t, http.StatusAccepted, `{"linesOk":3,"linesInvalid":0,"error":null}`)
+	defer ts.Close()
+
+	d := newDynatrace(ts.URL)
+	d.DefaultDimensions = map[string]string{"env": "prod"}
+	require.NoError(t, d.Connect())
+
+	require.NoError(t, d.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "web 01", "CPU": "cpu0"}, map[string]interface{}{"usage_idle": 95.5}, time.Unix(1500000000, 0), telegraf.Untyped),
+		testutil.MustMetric("temperature", map[string]string{"sensor": "a,b=c"}, map[string]interface{}{"value": int64(21)}, time.Unix(1500000000, 0), telegraf.Gauge),
+		testutil.MustMetric("mem", nil, map[string]interface{}{"state": "ok", "online": true}, time.Unix(1500000000, 0), telegraf.Untyped),
+	}))
+
+	r := <-requests
+	require.Equal(t, "Api-Token token", r.authorization)
+	require.Equal(t, strings.Join([]string{
+		"telegraf.cpu.usage_idle,cpu=cpu0,env=prod,host=web\\ 01 gauge,95.5 1500000000000",
+		"telegraf.temperature,env=prod,sensor=a\\,b\\=c gauge,21 1500000000000",
+		"telegraf.mem.online,env=prod gauge,1 1500000000000",
+	}, "\n"), r.body)
+}
+
+func TestWriteCounters(t *testing.T) {
+	ts, requests := newServer(t, http.StatusAccepted, `{"linesOk":1,"linesInvalid":0,"error":null}`)
+	defer ts.Close()
+
+	d := newDynatrace(ts.URL)
+	d.AdditionalCounters = []string{"*_total"}
+	require.NoError(t, d.Connect())
+
+	write := func(requestsTotal, bytes int64) {
+		require.NoError(t, d.Write([]telegraf.Metric{
+			testutil.MustMetric("http", nil, map[string]interface{}{"requests_total": requestsTotal}, time.Unix(1500000000, 0), telegraf.Untyped),
+			testutil.MustMetric("net", nil, map[string]interface{}{"bytes": bytes}, time.Unix(1500000000, 0), telegraf.Counter),
+		}))
+	}
+
+	// The first values of the counters are only recorded, the deltas being
+	// sent from the second ones
+	write(10, 100)
+	require.Len(t, requests, 0)
+
+	write(15, 160)
+	require.Equal(t,
+		"telegraf.http.requests_total count,delta=5 1500000000000\ntelegraf.net.bytes count,delta=60 1500000000000",
+		(<-requests).body)
+
+	// The counters reset are sent again from the next values
+	write(2, 170)
+	require.Equal(t, "telegraf.net.bytes count,delta=10 1500000000000", (<-requests).body)
+}
+
+func TestWriteSplit(t *testing.T) {
+	ts, requests := newServer(t, http.StatusAccepted, `{"linesOk":1000,"linesInvalid":0,"error":null}`)
+	defer ts.Close()
+
+	d := newDynatrace(ts.URL)
+	require.NoError(t, d.Connect())
+
+	var metrics []telegraf.Metric
+	for i := 0; i < maxLinesPerRequest+1; i++ {
+		metrics = append(metrics, testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": float64(i)}, time.Unix(1500000000, 0), telegraf.Untyped))
+	}
+	require.NoError(t, d.Write(metrics))
+
+	require.Equal(t, maxLinesPerRequest, strings.Count((<-requests).body, "\n")+1)
+	require.Equal(t, "telegraf.cpu.usage gauge,1000 1500000000000", (<-requests).body)
+}
+
+func TestWriteStatus(t *testing.T) {
+	// The invalid lines are dropped
+	ts, _ := newServer(t, http.StatusBadRequest,
+		`{"linesOk":0,"linesInvalid":1,"error":{"code":400,"message":"invalid line"}}`)
+	defer ts.Close()
+	d := newDynatrace(ts.URL)
+	require.NoError(t, d.Connect())
+	require.NoError(t, d.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 1.0}, time.Unix(1500000000, 0), telegraf.Untyped),
+	}))
+
+	ts, _ = newServer(t, http.StatusUnauthorized, `{"error":{"code":401,"message":"invalid token"}}`)
+	defer ts.Close()
+	d = newDynatrace(ts.URL)
+	require.NoError(t, d.Connect())
+	require.Error(t, d.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 1.0}, time.Unix(1500000000, 0), telegraf.Untyped),
+	}))
+}
+
+func TestConnect(t *testing.T) {
+	d := &Dynatrace{}
+	require.NoError(t, d.Connect())
+	require.Equal(t, oneAgentURL, d.URL)
+
+	d = &Dynatrace{URL: "https://example.live.dynatrace.com/api/v2/metrics/ingest"}
+	require.Error(t, d.Connect())
+}
+
+func TestNormalize(t *testing.T) {
+	require.Equal(t, "telegraf.cpu.usage_idle", normalizeKey("telegraf.cpu.usage idle"))
+	require.Equal(t, "telegraf.a.b", normalizeKey("telegraf..1a.b"))
+	require.Equal(t, "host_name", normalizeDimensionKey("Host Name"))
+	require.Equal(t, "a", normalizeDimensionKey("1a"))
+}
@@ -2,6 +2,9 @@ package elasticsearch
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
@@ -29,11 +32,23 @@ type Elasticsearch struct {
 	ManageTemplate      bool
 	TemplateName        string
 	OverwriteTemplate   bool
+	APIKey              string `toml:"api_key"`
+	DataStream          bool   `toml:"data_stream"`
+	ILMPolicy           string `toml:"ilm_policy"`
+	ForceDocumentID     bool   `toml:"force_document_id"`
 	tls.ClientConfig
 
 	Client *elastic.Client
+
+	majorReleaseNumber int
 }
 
+// Characters not allowed in the index names, replaced in the tag values.
+var indexNameReplacer = strings.NewReplacer(
+	"\\", "_", "/", "_", "*", "_", "?", "_", "\"", "_", "<", "_", ">", "_",
+	"|", "_", " ", "_", ",", "_", "#", "_", ":", "_",
+)
+
 var sampleConfig = `
   ## The full HTTP endpoint URL for your Elasticsearch instance
   ## Multiple urls can be specified as part of the same cluster,
@@ -50,6 +65,9 @@ var sampleConfig = `
   ## HTTP basic authentication details (eg. when using Shield)
   # username = "telegraf"
   # password = "mypassword"
+  ## API key authentication, the base64 encoding of "id:api_key" as returned
+  ## in the "encoded" field by the create API key API of Elasticsearch.
+  # api_key = ""
 
   ## Index Config
   ## The target index for metrics (Elasticsearch will create if it not exists).
@@ -63,11 +81,27 @@ var sampleConfig = `
   # %V - week of the year (ISO week) (01..53)
   ## Additionally, you can specify a tag name using the notation {{tag_name}}
   ## which will be used as part of the index name. If the tag does not exist,
-  ## the default tag value will be used.
+  ## the default tag value will be used.  The index names are converted to
+  ## lowercase, and the characters not allowed in them are replaced in the tag
+  ## values.
   # index_name = "telegraf-{{host}}-%Y.%m.%d"
   # default_tag_value = "none"
   index_name = "telegraf-%Y.%m.%d" # required.
 
+  ## Set to true to write to the data streams named by index_name instead of
+  ## indexes, requires Elasticsearch 7.9 or later.  Data streams manage the
+  ## rollover of their backing indexes, with the ILM policy of their template.
+  # data_stream = false
+
+  ## Name of the ILM policy set in the managed template, managing the rollover
+  ## and retention of the indexes.
+  # ilm_policy = ""
+
+  ## Set to true to use a hash of the series and timestamp of the metrics as
+  ## the ID of the documents, so a batch sent again after a failure does not
+  ## duplicate the documents already indexed.
+  # force_document_id = false
+
   ## Optional TLS Config
   # tls_ca = "/etc/telegraf/ca.pem"
   # tls_cert = "/etc/telegraf/cert.pem"
@@ -115,6 +149,13 @@ func (a *Elasticsearch) Connect() error {
 		elastic.SetHealthcheckInterval(a.HealthCheckInterval.Duration),
 	)
 
+	if a.APIKey != "" {
+		httpclient.Transport = &apiKeyTransport{
+			apiKey:    a.APIKey,
+			transport: tr,
+		}
+	}
+
 	if a.Username != "" && a.Password != "" {
 		clientOptions = append(clientOptions,
 			elastic.SetBasicAuth(a.Username, a.Password),
@@ -142,10 +183,22 @@ func (a *Elasticsearch) Connect() error {
 	}
 
 	// quit if ES version is not supported
-	i, err := strconv.Atoi(strings.Split(esVersion, ".")[0])
+	parts := strings.Split(esVersion, ".")
+	i, err := strconv.Atoi(parts[0])
 	if err != nil || i < 5 {
 		return fmt.Errorf("Elasticsearch version not supported: %s", esVersion)
 	}
+	a.majorReleaseNumber = i
+
+	if a.DataStream {
+		var minor int
+		if len(parts) > 1 {
+			minor, _ = strconv.Atoi(parts[1])
+		}
+		if i < 7 || (i == 7 && minor < 9) {
+			return fmt.Errorf("Elasticsearch version %s does not support data streams", esVersion)
+		}
+	}
 
 	log.Println("I! Elasticsearch version: " + esVersion)
 
@@ -184,11 +237,25 @@ func (a *Elasticsearch) Write(metrics []telegraf.Metric) error {
 		m["tag"] = metric.Tags()
 		m[name] = metric.Fields()
 
-		bulkRequest.Add(elastic.NewBulkIndexRequest().
+		br := elastic.NewBulkIndexRequest().
 			Index(indexName).
-			Type("metrics").
-			Doc(m))
+			Doc(m)
+
+		// Mapping types are removed since Elasticsearch 7
+		if a.majorReleaseNumber < 7 {
+			br.Type("metrics")
+		}
+
+		// Data streams only accept the create operation
+		if a.DataStream {
+			br.OpType("create")
+		}
 
+		if a.ForceDocumentID {
+			br.Id(documentID(metric))
+		}
+
+		bulkRequest.Add(br)
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), a.Timeout.Duration)
@@ -201,22 +268,65 @@ func (a *Elasticsearch) Write(metrics []telegraf.Metric) error {
 	}
 
 	if res.Errors {
-		for id, err := range res.Failed() {
-			log.Printf("E! Elasticsearch indexing failure, id: %d, error: %s, caused by: %s, %s", id, err.Error.Reason, err.Error.CausedBy["reason"], err.Error.CausedBy["type"])
+		var retry int
+		for id, items := range res.Items {
+			for _, item := range items {
+				if item.Status >= 200 && item.Status <= 299 {
+					continue
+				}
+
+				if item.Status == http.StatusConflict && a.ForceDocumentID {
+					// The document was indexed by a previous write of the batch
+					continue
+				}
+
+				var reason, causedBy, causedByType interface{}
+				if item.Error != nil {
+					reason = item.Error.Reason
+					causedBy = item.Error.CausedBy["reason"]
+					causedByType = item.Error.CausedBy["type"]
+				}
+
+				if isRetryable(item.Status) {
+					retry++
+					log.Printf("E! Elasticsearch indexing failure, id: %d, status: %d, error: %s, caused by: %s, %s; retrying", id, item.Status, reason, causedBy, causedByType)
+					continue
+				}
+
+				// Sending the document again would fail the same way
+				log.Printf("E! Elasticsearch indexing failure, id: %d, status: %d, error: %s, caused by: %s, %s; dropping metric %s", id, item.Status, reason, causedBy, causedByType, metrics[id].Name())
+			}
+		}
+
+		if retry > 0 {
+			return fmt.Errorf("Elasticsearch failed to index %d metrics", retry)
 		}
-		return fmt.Errorf("W! Elasticsearch failed to index %d metrics", len(res.Failed()))
 	}
 
 	return nil
 
 }
 
+// isRetryable returns true if the failure of a document is temporary, such
+// as when the cluster is overloaded, and the document should be sent again.
+func isRetryable(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// documentID returns an ID identifying the metric by its series and time.
+func documentID(metric telegraf.Metric) string {
+	var buf [16]byte
+	binary.BigEndian.PutUint64(buf[:8], metric.HashID())
+	binary.BigEndian.PutUint64(buf[8:], uint64(metric.Time().UnixNano()))
+	return fmt.Sprintf("%x", sha256.Sum256(buf[:]))
+}
+
 func (a *Elasticsearch) manageTemplate(ctx context.Context) error {
 	if a.TemplateName == "" {
 		return fmt.Errorf("Elasticsearch template_name configuration not defined")
 	}
 
-	templateExists, errExists := a.Client.IndexTemplateExists(a.TemplateName).Do(ctx)
+	templateExists, errExists := a.templateExists(ctx)
 
 	if errExists != nil {
 		return fmt.Errorf("Elasticsearch template check failed, template name: %s, error: %s", a.TemplateName, errExists)
@@ -236,66 +346,19 @@ func (a *Elasticsearch) manageTemplate(ctx context.Context) error {
 		return fmt.Errorf("Template cannot be created for dynamic index names without an index prefix")
 	}
 
-	if (a.OverwriteTemplate) || (!templateExists) || (templatePattern != "") {
+	if a.OverwriteTemplate || !templateExists {
 		// Create or update the template
-		tmpl := fmt.Sprintf(`
-			{
-				"template":"%s",
-				"settings": {
-					"index": {
-						"refresh_interval": "10s",
-						"mapping.total_fields.limit": 5000
-					}
-				},
-				"mappings" : {
-					"_default_" : {
-						"_all": { "enabled": false	  },
-						"properties" : {
-							"@timestamp" : { "type" : "date" },
-							"measurement_name" : { "type" : "keyword" }
-						},
-						"dynamic_templates": [
-							{
-								"tags": {
-									"match_mapping_type": "string",
-									"path_match": "tag.*",
-									"mapping": {
-										"ignore_above": 512,
-										"type": "keyword"
-									}
-								}
-							},
-							{
-								"metrics_long": {
-									"match_mapping_type": "long",
-									"mapping": {
-										"type": "float",
-										"index": false
-									}
-								}
-							},
-							{
-								"metrics_double": {
-									"match_mapping_type": "double",
-									"mapping": {
-										"type": "float",
-										"index": false
-									}
-								}
-							},
-							{
-								"text_fields": {
-									"match": "*",
-									"mapping": {
-										"norms": false
-									}
-								}
-							}
-						]
-					}
-				}
-			}`, templatePattern+"*")
-		_, errCreateTemplate := a.Client.IndexPutTemplate(a.TemplateName).BodyString(tmpl).Do(ctx)
+		tmpl, err := json.Marshal(a.template(templatePattern + "*"))
+		if err != nil {
+			return err
+		}
+
+		var errCreateTemplate error
+		if a.DataStream {
+			_, errCreateTemplate = a.Client.PerformRequest(ctx, "PUT", "/_index_template/"+a.TemplateName, nil, string(tmpl))
+		} else {
+			_, errCreateTemplate = a.Client.IndexPutTemplate(a.TemplateName).BodyString(string(tmpl)).Do(ctx)
+		}
 
 		if errCreateTemplate != nil {
 			return fmt.Errorf("Elasticsearch failed to create index template %s : %s", a.TemplateName, errCreateTemplate)
@@ -311,6 +374,106 @@ func (a *Elasticsearch) manageTemplate(ctx context.Context) error {
 	return nil
 }
 
+func (a *Elasticsearch) templateExists(ctx context.Context) (bool, error) {
+	if !a.DataStream {
+		return a.Client.IndexTemplateExists(a.TemplateName).Do(ctx)
+	}
+
+	// Data streams use the composable index templates
+	res, err := a.Client.PerformRequest(ctx, "HEAD", "/_index_template/"+a.TemplateName, nil, nil, http.StatusNotFound)
+	if err != nil {
+		return false, err
+	}
+	return res.StatusCode == http.StatusOK, nil
+}
+
+// template returns the index template for the version of Elasticsearch.
+func (a *Elasticsearch) template(pattern string) map[string]interface{} {
+	settings := map[string]interface{}{
+		"refresh_interval":           "10s",
+		"mapping.total_fields.limit": 5000,
+	}
+	if a.ILMPolicy != "" {
+		settings["lifecycle.name"] = a.ILMPolicy
+	}
+
+	mapping := map[string]interface{}{
+		"properties": map[string]interface{}{
+			"@timestamp":       map[string]interface{}{"type": "date"},
+			"measurement_name": map[string]interface{}{"type": "keyword"},
+		},
+		"dynamic_templates": []interface{}{
+			map[string]interface{}{
+				"tags": map[string]interface{}{
+					"match_mapping_type": "string",
+					"path_match":         "tag.*",
+					"mapping": map[string]interface{}{
+						"ignore_above": 512,
+						"type":         "keyword",
+					},
+				},
+			},
+			map[string]interface{}{
+				"metrics_long": map[string]interface{}{
+					"match_mapping_type": "long",
+					"mapping": map[string]interface{}{
+						"type":  "float",
+						"index": false,
+					},
+				},
+			},
+			map[string]interface{}{
+				"metrics_double": map[string]interface{}{
+					"match_mapping_type": "double",
+					"mapping": map[string]interface{}{
+						"type":  "float",
+						"index": false,
+					},
+				},
+			},
+			map[string]interface{}{
+				"text_fields": map[string]interface{}{
+					"match": "*",
+					"mapping": map[string]interface{}{
+						"norms": false,
+					},
+				},
+			},
+		},
+	}
+
+	if a.DataStream {
+		return map[string]interface{}{
+			"index_patterns": []string{pattern},
+			"data_stream":    map[string]interface{}{},
+			// Higher than the priority of the built-in templates, such as
+			// the one of the "metrics-*-*" data streams
+			"priority": 200,
+			"template": map[string]interface{}{
+				"settings": map[string]interface{}{"index": settings},
+				"mappings": mapping,
+			},
+		}
+	}
+
+	tmpl := map[string]interface{}{
+		"settings": map[string]interface{}{"index": settings},
+	}
+	switch {
+	case a.majorReleaseNumber < 6:
+		mapping["_all"] = map[string]interface{}{"enabled": false}
+		tmpl["template"] = pattern
+		tmpl["mappings"] = map[string]interface{}{"_default_": mapping}
+	case a.majorReleaseNumber < 7:
+		tmpl["index_patterns"] = []string{pattern}
+		tmpl["mappings"] = map[string]interface{}{"metrics": mapping}
+	default:
+		tmpl["index_patterns"] = []string{pattern}
+		tmpl["mappings"] = mapping
+	}
+	return tmpl
+}
+
 func (a *Elasticsearch) GetTagKeys(indexName string) (string, []string) {
 
 	tagKeys := []string{}
@@ -357,14 +520,14 @@ func (a *Elasticsearch) GetIndexName(indexName string, eventTime time.Time, tagK
 
 	for _, key := range tagKeys {
 		if value, ok := metricTags[key]; ok {
-			tagValues = append(tagValues, value)
+			tagValues = append(tagValues, indexNameReplacer.Replace(value))
 		} else {
 			log.Printf("D! Tag '%s' not found, using '%s' on index name instead\n", key, a.DefaultTagValue)
 			tagValues = append(tagValues, a.DefaultTagValue)
 		}
 	}
 
-	return fmt.Sprintf(indexName, tagValues...)
+	return strings.ToLower(fmt.Sprintf(indexName, tagValues...))
 
 }
 
@@ -386,6 +549,24 @@ func (a *Elasticsearch) Close() error {
 	return nil
 }
 
+// apiKeyTransport authenticates the requests with an API key.
+type apiKeyTransport struct {
+	apiKey    string
+	transport http.RoundTripper
+}
+
+func (t *apiKeyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// The request must not be modified by the transport
+	r := new(http.Request)
+	*r = *req
+	r.Header = make(http.Header, len(req.Header)+1)
+	for k, v := range req.Header {
+		r.Header[k] = v
+	}
+	r.Header.Set("Authorization", "ApiKey "+t.apiKey)
+	return t.transport.RoundTrip(r)
+}
+
 func init() {
 	outputs.Add("elasticsearch", func() telegraf.Output {
 		return &Elasticsearch{
@@ -2,10 +2,17 @@ package elasticsearch
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
@@ -257,3 +264,154 @@ func TestGetIndexName(t *testing.T) {
 		}
 	}
 }
+
+// esServer is a fake Elasticsearch node, recording the requests received.
+type esServer struct {
+	*httptest.Server
+	version  string
+	requests []*http.Request
+	bodies   []string
+	bulk     func(w http.ResponseWriter, lines []string)
+}
+
+func newESServer(t *testing.T, version string) *esServer {
+	s := &esServer{version: version}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		s.requests = append(s.requests, r)
+		s.bodies = append(s.bodies, string(body))
+
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/":
+			fmt.Fprintf(w, `{"version": {"number": "%s"}}`, s.version)
+		case r.URL.Path == "/_bulk":
+			lines := strings.Split(strings.TrimSpace(string(body)), "\n")
+			if s.bulk != nil {
+				s.bulk(w, lines)
+				return
+			}
+			fmt.Fprint(w, `{"errors": false, "items": []}`)
+		case r.Method == "HEAD":
+			w.WriteHeader(http.StatusNotFound)
+		default:
+			fmt.Fprint(w, `{"acknowledged": true}`)
+		}
+	}))
+	return s
+}
+
+func newElasticsearch(url string) *Elasticsearch {
+	return &Elasticsearch{
+		URLs:         []string{url},
+		IndexName:    "metrics-%Y.%m.%d-{{host}}",
+		Timeout:      internal.Duration{Duration: time.Second * 5},
+		TemplateName: "telegraf",
+	}
+}
+
+func TestWriteDataStream(t *testing.T) {
+	s := newESServer(t, "7.10.0")
+	defer s.Close()
+
+	e := newElasticsearch(s.URL)
+	e.IndexName = "metrics-telegraf-{{tag1}}"
+	e.DataStream = true
+	e.ManageTemplate = true
+	e.ILMPolicy = "telegraf"
+	e.APIKey = "dGVzdDprZXk="
+	require.NoError(t, e.Connect())
+	require.NoError(t, e.Write(testutil.MockMetrics()))
+
+	for _, r := range s.requests {
+		require.Equal(t, "ApiKey dGVzdDprZXk=", r.Header.Get("Authorization"))
+	}
+
+	var tmpl map[string]interface{}
+	for i, r := range s.requests {
+		if r.Method == "PUT" {
+			require.Equal(t, "/_index_template/telegraf", r.URL.Path)
+			require.NoError(t, json.Unmarshal([]byte(s.bodies[i]), &tmpl))
+		}
+	}
+	require.Equal(t, []interface{}{"metrics-telegraf-*"}, tmpl["index_patterns"])
+	require.Contains(t, tmpl, "data_stream")
+	settings := tmpl["template"].(map[string]interface{})["settings"].(map[string]interface{})
+	require.Equal(t, "telegraf", settings["index"].(map[string]interface{})["lifecycle.name"])
+
+	bulk := s.bodies[len(s.bodies)-1]
+	lines := strings.Split(strings.TrimSpace(bulk), "\n")
+	require.Len(t, lines, 2)
+	require.JSONEq(t, `{"create": {"_index": "metrics-telegraf-value1"}}`, lines[0])
+}
+
+func TestWriteDataStreamUnsupported(t *testing.T) {
+	s := newESServer(t, "7.8.1")
+	defer s.Close()
+
+	e := newElasticsearch(s.URL)
+	e.DataStream = true
+	require.Error(t, e.Connect())
+}
+
+func TestTemplateVersions(t *testing.T) {
+	e := &Elasticsearch{}
+
+	e.majorReleaseNumber = 5
+	tmpl := e.template("telegraf-*")
+	require.Equal(t, "telegraf-*", tmpl["template"])
+	require.Contains(t, tmpl["mappings"], "_default_")
+
+	e.majorReleaseNumber = 6
+	tmpl = e.template("telegraf-*")
+	require.Equal(t, []string{"telegraf-*"}, tmpl["index_patterns"])
+	require.Contains(t, tmpl["mappings"], "metrics")
+
+	e.majorReleaseNumber = 7
+	tmpl = e.template("telegraf-*")
+	require.Contains(t, tmpl["mappings"], "dynamic_templates")
+	require.NotContains(t, tmpl["mappings"], "_all")
+}
+
+func TestWriteBulkErrors(t *testing.T) {
+	s := newESServer(t, "6.8.0")
+	defer s.Close()
+
+	e := newElasticsearch(s.URL)
+	e.ForceDocumentID = true
+	require.NoError(t, e.Connect())
+
+	metrics := []telegraf.Metric{testutil.TestMetric(1.0), testutil.TestMetric(2.0, "other")}
+
+	// A rejected document is dropped and an existing one is ignored
+	s.bulk = func(w http.ResponseWriter, lines []string) {
+		require.Contains(t, lines[0], `"_type":"metrics"`)
+		require.Contains(t, lines[0], `"_id":"`+documentID(metrics[0])+`"`)
+		fmt.Fprint(w, `{"errors": true, "items": [
+			{"index": {"status": 400, "error": {"type": "mapper_parsing_exception", "reason": "failed to parse"}}},
+			{"index": {"status": 409, "error": {"type": "version_conflict_engine_exception"}}}
+		]}`)
+	}
+	require.NoError(t, e.Write(metrics))
+
+	// An overloaded cluster fails the write to retry it
+	s.bulk = func(w http.ResponseWriter, lines []string) {
+		fmt.Fprint(w, `{"errors": true, "items": [
+			{"index": {"status": 201}},
+			{"index": {"status": 429, "error": {"type": "es_rejected_execution_exception"}}}
+		]}`)
+	}
+	err := e.Write(metrics)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to index 1 metrics")
+}
+
+func TestGetIndexNameSanitized(t *testing.T) {
+	e := &Elasticsearch{}
+
+	indexName, tagKeys := e.GetTagKeys("metrics-%Y.%m.%d-{{host}}")
+	name := e.GetIndexName(indexName, time.Date(2014, 12, 01, 23, 30, 00, 00, time.UTC),
+		tagKeys, map[string]string{"host": "My Host/1"})
+	require.Equal(t, "metrics-2014.12.01-my_host_1", name)
+}
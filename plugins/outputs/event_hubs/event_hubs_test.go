@@ -0,0 +1,148 @@
+package event_hubs
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+const connectionString = "Endpoint=sb://mynamespace.servicebus.windows.net/;SharedAccessKeyName=send;SharedAccessKey=c2VjcmV0;EntityPath=telegraf"
+
+type received struct {
+	authorization string
+	contentType   string
+	events        []event
+}
+
+// fakeServer is an Event Hub answering with the status codes in order, then
+// with 201.
+type fakeServer struct {
+	*httptest.Server
+	requests chan received
+	statuses []int
+}
+
+func newFakeServer(t *testing.T, statuses ...int) *fakeServer {
+	s := &fakeServer{requests: make(chan received, 100), statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/telegraf/messages", r.URL.Path)
+		body, _ := ioutil.ReadAll(r.Body)
+		var events []event
+		require.NoError(t, json.Unmarshal(body, &events))
+		s.requests <- received{r.Header.Get("Authorization"), r.Header.Get("Content-Type"), events}
+
+		status := http.StatusCreated
+		if len(s.statuses) > 0 {
+			status, s.statuses = s.statuses[0], s.statuses[1:]
+		}
+		w.WriteHeader(status)
+	}))
+	return s
+}
+
+func newEventHubs(t *testing.T, s *fakeServer) *EventHubs {
+	e := &EventHubs{
+		ConnectionString: connectionString,
+		MaxMessageSize:   internal.Size{Size: 256 * 1000},
+		Timeout:          internal.Duration{Duration: 5 * time.Second},
+		MaxRetries:       3,
+		RetryDelay:       internal.Duration{Duration: time.Second},
+		now:              func() time.Time { return time.Unix(1500000000, 0) },
+		sleep:            func(time.Duration) {},
+	}
+	e.SetSerializer(influx.NewSerializer())
+	require.NoError(t, e.Connect())
+	require.Equal(t, "https://mynamespace.servicebus.windows.net/telegraf/messages", e.url)
+	e.url = s.URL + "/telegraf/messages"
+	return e
+}
+
+func TestWrite(t *testing.T) {
+	s := newFakeServer(t)
+	defer s.Close()
+
+	e := newEventHubs(t, s)
+	e.PartitionKeyTag = "host"
+	require.NoError(t, e.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 1.0}, time.Unix(1500000000, 0)),
+		testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 2.0}, time.Unix(1500000000, 0)),
+	}))
+
+	r := <-s.requests
+	require.True(t, strings.HasPrefix(r.authorization, "SharedAccessSignature sr="))
+	require.Contains(t, r.authorization, "&se=1500003600&skn=send")
+	require.Equal(t, batchContentType, r.contentType)
+	require.Equal(t, []event{
+		{Body: "cpu,host=a usage=1 1500000000000000000\n", BrokerProperties: &brokerProperties{PartitionKey: "a"}},
+		{Body: "cpu usage=2 1500000000000000000\n"},
+	}, r.events)
+}
+
+func TestWriteMaxMessageSize(t *testing.T) {
+	s := newFakeServer(t)
+	defer s.Close()
+
+	// Room for two events per request
+	e := newEventHubs(t, s)
+	e.MaxMessageSize = internal.Size{Size: 100}
+	require.NoError(t, e.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 1.0}, time.Unix(1500000000, 0)),
+		testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 2.0}, time.Unix(1500000000, 0)),
+		testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 3.0}, time.Unix(1500000000, 0)),
+	}))
+
+	require.Len(t, (<-s.requests).events, 2)
+	require.Len(t, (<-s.requests).events, 1)
+	require.Len(t, s.requests, 0)
+}
+
+func TestWriteRetry(t *testing.T) {
+	// The throttled requests are retried
+	s := newFakeServer(t, http.StatusTooManyRequests, http.StatusServiceUnavailable)
+	defer s.Close()
+	e := newEventHubs(t, s)
+	var delays []time.Duration
+	e.sleep = func(d time.Duration) { delays = append(delays, d) }
+	require.NoError(t, e.Write([]telegraf.Metric{testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 1.0}, time.Unix(1500000000, 0))}))
+	require.Equal(t, []time.Duration{time.Second, 2 * time.Second}, delays)
+	require.Len(t, s.requests, 3)
+
+	// The invalid events are dropped
+	s = newFakeServer(t, http.StatusBadRequest)
+	defer s.Close()
+	e = newEventHubs(t, s)
+	require.NoError(t, e.Write([]telegraf.Metric{testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 1.0}, time.Unix(1500000000, 0))}))
+	require.Len(t, s.requests, 1)
+
+	// The other failures are returned, the metrics being written again
+	s = newFakeServer(t, http.StatusUnauthorized)
+	defer s.Close()
+	e = newEventHubs(t, s)
+	require.Error(t, e.Write([]telegraf.Metric{testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 1.0}, time.Unix(1500000000, 0))}))
+	require.Len(t, s.requests, 1)
+}
+
+func TestConnect(t *testing.T) {
+	e := &EventHubs{
+		ConnectionString: "Endpoint=sb://mynamespace.servicebus.windows.net/;SharedAccessKeyName=send;SharedAccessKey=c2VjcmV0",
+		MaxMessageSize:   internal.Size{Size: 256 * 1000},
+	}
+	require.Error(t, e.Connect())
+
+	e.EventHub = "telegraf"
+	require.NoError(t, e.Connect())
+
+	e.ConnectionString = "Endpoint=sb://mynamespace.servicebus.windows.net/;EntityPath=telegraf"
+	require.Error(t, e.Connect())
+}
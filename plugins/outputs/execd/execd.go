@@ -0,0 +1,262 @@
+package execd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+const (
+	// The restart delay doubles after each exit of the program, up to
+	// maxRestartDelay, and is reset once metrics are written again.
+	maxRestartDelay = 5 * time.Minute
+
+	// Time given to the program to exit once its stdin is closed, before
+	// being killed.
+	closeTimeout = 5 * time.Second
+
+	// Time given to the program to exit when writing to it fails, so that
+	// its exit status is reported.
+	exitTimeout = time.Second
+)
+
+var sampleConfig = `
+  ## Program to run, with its arguments.  The metrics are written to the
+  ## stdin of the program, which is expected to keep running.
+  command = ["/usr/bin/my-sink", "--flag"]
+
+  ## Environment variables of the program, in addition to the ones of
+  ## Telegraf.
+  # environment = ["KEY=value"]
+
+  ## Delay before restarting the program once it exited, doubling after each
+  ## exit up to 5m.
+  # restart_delay = "10s"
+
+  ## Data format to output.
+  ## Each data format has its own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_OUTPUT.md
+  data_format = "influx"
+`
+
+type Execd struct {
+	Command      []string          `toml:"command"`
+	Environment  []string          `toml:"environment"`
+	RestartDelay internal.Duration `toml:"restart_delay"`
+
+	serializer serializers.Serializer
+
+	proc *process
+
+	restartDelay time.Duration
+	nextRestart  time.Time
+	now          func() time.Time
+}
+
+// process is a running instance of the program.
+type process struct {
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+
+	// done is closed once the program exited, err being its exit error.
+	done chan struct{}
+	err  error
+}
+
+func (e *Execd) SampleConfig() string {
+	return sampleConfig
+}
+
+func (e *Execd) Description() string {
+	return "Run a program, writing the metrics to its stdin."
+}
+
+func (e *Execd) SetSerializer(serializer serializers.Serializer) {
+	e.serializer = serializer
+}
+
+func (e *Execd) Connect() error {
+	if len(e.Command) == 0 {
+		return fmt.Errorf("no command given")
+	}
+	if e.now == nil {
+		e.now = time.Now
+	}
+	return e.start()
+}
+
+func (e *Execd) start() error {
+	cmd := exec.Command(e.Command[0], e.Command[1:]...)
+	if len(e.Environment) > 0 {
+		cmd.Env = append(os.Environ(), e.Environment...)
+	}
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting %s failed: %v", e.Command[0], err)
+	}
+
+	p := &process{cmd: cmd, stdin: stdin, done: make(chan struct{})}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		logLines(stdout, "I! [outputs.execd] %s: %s", e.Command[0])
+	}()
+	go func() {
+		defer wg.Done()
+		logLines(stderr, "E! [outputs.execd] %s: %s", e.Command[0])
+	}()
+	go func() {
+		// The output has to be read before waiting for the program.
+		wg.Wait()
+		p.err = cmd.Wait()
+		close(p.done)
+	}()
+
+	e.proc = p
+	return nil
+}
+
+func logLines(r io.Reader, format string, command string) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		log.Printf(format, command, scanner.Text())
+	}
+}
+
+// running returns whether the program is running, starting it again if it
+// exited, after the restart delay.
+func (e *Execd) running() error {
+	if e.proc != nil {
+		select {
+		case <-e.proc.done:
+			e.exited()
+		default:
+			return nil
+		}
+	}
+
+	now := e.now()
+	if now.Before(e.nextRestart) {
+		return fmt.Errorf("%s not running, restarting in %s", e.Command[0], e.nextRestart.Sub(now))
+	}
+	if err := e.start(); err != nil {
+		e.backoff()
+		return err
+	}
+	return nil
+}
+
+// exited forgets the program once it exited, delaying its restart.
+func (e *Execd) exited() {
+	if e.proc.err != nil {
+		log.Printf("E! [outputs.execd] %s exited: %v", e.Command[0], e.proc.err)
+	} else {
+		log.Printf("W! [outputs.execd] %s exited", e.Command[0])
+	}
+	e.proc = nil
+	e.backoff()
+}
+
+func (e *Execd) backoff() {
+	if e.restartDelay == 0 {
+		e.restartDelay = e.RestartDelay.Duration
+	} else if e.restartDelay *= 2; e.restartDelay > maxRestartDelay {
+		e.restartDelay = maxRestartDelay
+	}
+	e.nextRestart = e.now().Add(e.restartDelay)
+}
+
+func (e *Execd) Write(metrics []telegraf.Metric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+	if err := e.running(); err != nil {
+		return err
+	}
+
+	b, err := e.serializer.SerializeBatch(metrics)
+	if err != nil {
+		return fmt.Errorf("failed to serialize message: %s", err)
+	}
+
+	p := e.proc
+	if _, err := p.stdin.Write(b); err != nil {
+		// The write most likely failed as the program exited, the program
+		// being killed otherwise as it does not read its stdin anymore.
+		select {
+		case <-p.done:
+		case <-time.After(exitTimeout):
+			p.cmd.Process.Kill()
+			<-p.done
+			e.exited()
+			return fmt.Errorf("writing to %s failed: %v", e.Command[0], err)
+		}
+	}
+
+	// The metrics are not acknowledged by the program, an exit being the only
+	// failure to notice.
+	select {
+	case <-p.done:
+		err := p.err
+		e.exited()
+		if err != nil {
+			return fmt.Errorf("%s exited during write: %v", e.Command[0], err)
+		}
+		return fmt.Errorf("%s exited during write", e.Command[0])
+	default:
+	}
+
+	e.restartDelay = 0
+	return nil
+}
+
+func (e *Execd) Close() error {
+	if e.proc == nil {
+		return nil
+	}
+	p := e.proc
+	e.proc = nil
+
+	p.stdin.Close()
+	select {
+	case <-p.done:
+		return p.err
+	case <-time.After(closeTimeout):
+		log.Printf("W! [outputs.execd] %s did not exit, killing it", e.Command[0])
+		p.cmd.Process.Kill()
+		<-p.done
+		return nil
+	}
+}
+
+func init() {
+	outputs.Add("execd", func() telegraf.Output {
+		return &Execd{
+			RestartDelay: internal.Duration{Duration: 10 * time.Second},
+		}
+	})
+}
@@ -0,0 +1,140 @@
+package execd
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+// TestHelperProcess isn't a real test, it is the program run by the plugin,
+// copying its stdin to the file of EXECD_OUTPUT and exiting with an error
+// once it read the line of EXECD_FAIL_ON.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("GO_WANT_HELPER_PROCESS") != "1" {
+		return
+	}
+
+	f, err := os.OpenFile(os.Getenv("EXECD_OUTPUT"), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		fmt.Fprintln(f, scanner.Text())
+		if failOn := os.Getenv("EXECD_FAIL_ON"); failOn != "" && scanner.Text() == failOn {
+			f.Close()
+			os.Exit(1)
+		}
+	}
+	f.Close()
+	os.Exit(0)
+}
+
+func newExecd(t *testing.T, env ...string) (*Execd, string) {
+	dir, err := ioutil.TempDir("", "execd")
+	require.NoError(t, err)
+	output := filepath.Join(dir, "output")
+
+	e := &Execd{
+		Command:      []string{os.Args[0], "-test.run=TestHelperProcess"},
+		Environment:  append([]string{"GO_WANT_HELPER_PROCESS=1", "EXECD_OUTPUT=" + output}, env...),
+		RestartDelay: internal.Duration{Duration: 10 * time.Second},
+	}
+	e.SetSerializer(influx.NewSerializer())
+	return e, output
+}
+
+func readOutput(t *testing.T, output string) string {
+	b, err := ioutil.ReadFile(output)
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestWrite(t *testing.T) {
+	e, output := newExecd(t)
+	defer os.RemoveAll(filepath.Dir(output))
+	require.NoError(t, e.Connect())
+
+	require.NoError(t, e.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 0)),
+		testutil.MustMetric("mem", nil, map[string]interface{}{"used": 1.5}, time.Unix(1500000000, 0)),
+	}))
+	require.NoError(t, e.Close())
+
+	require.Equal(t,
+		"cpu usage=42.5 1500000000000000000\nmem used=1.5 1500000000000000000\n",
+		readOutput(t, output))
+}
+
+func TestWriteExit(t *testing.T) {
+	e, output := newExecd(t, "EXECD_FAIL_ON=cpu usage=42.5 1500000000000000000")
+	defer os.RemoveAll(filepath.Dir(output))
+	now := time.Unix(1500000000, 0)
+	e.now = func() time.Time { return now }
+	require.NoError(t, e.Connect())
+	defer e.Close()
+
+	// The program exits once it read the metric, the exit being noticed by
+	// this write or the next one
+	metrics := []telegraf.Metric{testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 0))}
+	if p := e.proc; e.Write(metrics) == nil {
+		select {
+		case <-p.done:
+		case <-time.After(5 * time.Second):
+			t.Fatal("program did not exit")
+		}
+	}
+
+	err := e.Write(metrics)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "restarting in 10s")
+	require.Nil(t, e.proc)
+
+	// The metrics are written again to the restarted program
+	now = now.Add(10 * time.Second)
+	e.Write(metrics)
+	if p := e.proc; p != nil {
+		<-p.done
+	}
+	require.Equal(t,
+		"cpu usage=42.5 1500000000000000000\ncpu usage=42.5 1500000000000000000\n",
+		readOutput(t, output))
+}
+
+func TestWriteRestartBackoff(t *testing.T) {
+	e, output := newExecd(t)
+	defer os.RemoveAll(filepath.Dir(output))
+	e.Command = []string{filepath.Join(filepath.Dir(output), "missing")}
+	now := time.Unix(1500000000, 0)
+	e.now = func() time.Time { return now }
+	require.Error(t, e.Connect())
+
+	metrics := []telegraf.Metric{testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 0))}
+	require.Error(t, e.Write(metrics))
+	require.Equal(t, 10*time.Second, e.restartDelay)
+
+	err := e.Write(metrics)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "restarting in 10s")
+
+	now = now.Add(10 * time.Second)
+	require.Error(t, e.Write(metrics))
+	require.Equal(t, 20*time.Second, e.restartDelay)
+}
+
+func TestConnectNoCommand(t *testing.T) {
+	e := &Execd{}
+	require.Error(t, e.Connect())
+}
@@ -6,12 +6,18 @@ import (
 	"os"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/rotate"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers"
 )
 
 type File struct {
-	Files []string
+	Files               []string          `toml:"files"`
+	RotationInterval    internal.Duration `toml:"rotation_interval"`
+	RotationMaxSize     internal.Size     `toml:"rotation_max_size"`
+	RotationMaxArchives int               `toml:"rotation_max_archives"`
+	RotationCompress    bool              `toml:"rotation_compress"`
 
 	writers []io.Writer
 	closers []io.Closer
@@ -23,6 +29,32 @@ var sampleConfig = `
   ## Files to write to, "stdout" is a specially handled file.
   files = ["stdout", "/tmp/metrics.out"]
 
+  ## The file names can contain the date specifiers below, replaced by the
+  ## current local time, the metrics being written to a new file whenever the
+  ## name changes.  The missing directories are created.
+  # %Y - year (2016)
+  # %y - last two digits of year (00..99)
+  # %m - month (01..12)
+  # %d - day of month (e.g., 01)
+  # %H - hour (00..23)
+  # %M - minute (00..59)
+  # %S - second (00..59)
+  # files = ["/var/log/telegraf/metrics-%Y-%m-%d.out"]
+
+  ## The files are rotated once they are older than the interval, or before
+  ## exceeding the maximum size.  The rotated files are kept as archives
+  ## next to the file, named with the time of the rotation, such as
+  ## "metrics.20160102T150405.out".  Zero disables the rotation.
+  # rotation_interval = "0h"
+  # rotation_max_size = "0MB"
+
+  ## Maximum number of archives kept for each file, the oldest ones being
+  ## removed.  -1 keeps all the archives.
+  # rotation_max_archives = 5
+
+  ## Compress the archives with gzip.
+  # rotation_compress = false
+
   ## Data format to output.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
@@ -43,14 +75,8 @@ func (f *File) Connect() error {
 		if file == "stdout" {
 			f.writers = append(f.writers, os.Stdout)
 		} else {
-			var of *os.File
-			var err error
-			if _, err := os.Stat(file); os.IsNotExist(err) {
-				of, err = os.Create(file)
-			} else {
-				of, err = os.OpenFile(file, os.O_APPEND|os.O_WRONLY, os.ModeAppend)
-			}
-
+			of, err := rotate.NewFileWriter(file, f.RotationInterval.Duration,
+				f.RotationMaxSize.Size, f.RotationMaxArchives, f.RotationCompress)
 			if err != nil {
 				return err
 			}
@@ -106,6 +132,8 @@ func (f *File) Write(metrics []telegraf.Metric) error {
 
 func init() {
 	outputs.Add("file", func() telegraf.Output {
-		return &File{}
+		return &File{
+			RotationMaxArchives: 5,
+		}
 	})
 }
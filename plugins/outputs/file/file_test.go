@@ -5,6 +5,7 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -170,6 +171,38 @@ func TestFileStdout(t *testing.T) {
 	assert.Equal(t, expNewFile, out)
 }
 
+func TestFileRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s, _ := serializers.NewInfluxSerializer()
+	f := File{
+		Files:               []string{filepath.Join(dir, "metrics.out")},
+		RotationMaxSize:     internal.Size{Size: int64(len(expNewFile))},
+		RotationMaxArchives: 5,
+		serializer:          s,
+	}
+
+	err = f.Connect()
+	assert.NoError(t, err)
+
+	// The file is rotated before the second write exceeding the maximum size
+	for i := 0; i < 2; i++ {
+		err = f.Write(testutil.MockMetrics())
+		assert.NoError(t, err)
+	}
+
+	err = f.Close()
+	assert.NoError(t, err)
+
+	validateFile(filepath.Join(dir, "metrics.out"), expNewFile, t)
+	archives, err := filepath.Glob(filepath.Join(dir, "metrics.*.out"))
+	assert.NoError(t, err)
+	assert.Len(t, archives, 1)
+	validateFile(archives[0], expNewFile, t)
+}
+
 func createFile() *os.File {
 	f, err := ioutil.TempFile("", "")
 	if err != nil {
@@ -0,0 +1,193 @@
+package graylog
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/rand"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+const (
+	defaultMaxChunkSizeWan = 1420
+	defaultMaxChunkSizeLan = 8154
+
+	// The chunks start with the magic bytes, the 8 bytes of the message ID,
+	// and the sequence number and count of the chunk.
+	chunkHeaderSize = 12
+	maxChunks       = 128
+)
+
+var chunkMagic = []byte{0x1e, 0x0f}
+
+// sender sends the GELF messages to a server.
+type sender interface {
+	send(message []byte) error
+	close() error
+	String() string
+}
+
+// udpSender sends the compressed messages in datagrams, chunked when larger
+// than the chunk size.
+type udpSender struct {
+	address     string
+	compression string
+	chunkSize   int
+	timeout     time.Duration
+
+	conn net.Conn
+}
+
+func (s *udpSender) send(message []byte) error {
+	payload, err := compress(s.compression, message)
+	if err != nil {
+		return err
+	}
+
+	if s.conn == nil {
+		conn, err := net.DialTimeout("udp", s.address, s.timeout)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+
+	if len(payload) <= s.chunkSize {
+		return s.write(payload)
+	}
+
+	dataSize := s.chunkSize - chunkHeaderSize
+	count := (len(payload) + dataSize - 1) / dataSize
+	if count > maxChunks {
+		return &messageTooLargeError{size: len(payload), chunks: count}
+	}
+
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	chunk := make([]byte, 0, s.chunkSize)
+	for i := 0; i < count; i++ {
+		end := (i + 1) * dataSize
+		if end > len(payload) {
+			end = len(payload)
+		}
+		chunk = append(chunk[:0], chunkMagic...)
+		chunk = append(chunk, id...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, payload[i*dataSize:end]...)
+		if err := s.write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *udpSender) write(datagram []byte) error {
+	if s.timeout > 0 {
+		s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+	}
+	_, err := s.conn.Write(datagram)
+	return err
+}
+
+func (s *udpSender) close() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *udpSender) String() string {
+	return "udp://" + s.address
+}
+
+// tcpSender sends the uncompressed messages delimited by null bytes, over TLS
+// when configured.
+type tcpSender struct {
+	address   string
+	tlsConfig *tls.Config
+	timeout   time.Duration
+
+	conn net.Conn
+}
+
+func (s *tcpSender) send(message []byte) error {
+	if s.conn == nil {
+		dialer := &net.Dialer{Timeout: s.timeout}
+		var conn net.Conn
+		var err error
+		if s.tlsConfig != nil {
+			conn, err = tls.DialWithDialer(dialer, "tcp", s.address, s.tlsConfig)
+		} else {
+			conn, err = dialer.Dial("tcp", s.address)
+		}
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+
+	if s.timeout > 0 {
+		s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+	}
+	frame := make([]byte, 0, len(message)+1)
+	frame = append(frame, message...)
+	frame = append(frame, 0)
+	if _, err := s.conn.Write(frame); err != nil {
+		// The connection is opened again with the next message.
+		s.close()
+		return err
+	}
+	return nil
+}
+
+func (s *tcpSender) close() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *tcpSender) String() string {
+	return "tcp://" + s.address
+}
+
+// messageTooLargeError is returned for the messages needing more chunks than
+// allowed by GELF, which would be dropped by any server.
+type messageTooLargeError struct {
+	size   int
+	chunks int
+}
+
+func (e *messageTooLargeError) Error() string {
+	return fmt.Sprintf("message of %d bytes needs %d chunks, more than the %d allowed", e.size, e.chunks, maxChunks)
+}
+
+func compress(compression string, message []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch compression {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "zlib":
+		w = zlib.NewWriter(&buf)
+	default:
+		return message, nil
+	}
+	if _, err := w.Write(message); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
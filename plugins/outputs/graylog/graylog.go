@@ -1,181 +1,139 @@
 package graylog
 
 import (
-	"bytes"
-	"compress/zlib"
-	"crypto/rand"
-	"encoding/binary"
 	ejson "encoding/json"
 	"fmt"
-	"io"
-	"math"
-	"net"
+	"log"
 	"os"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	tlsint "github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
 )
 
 const (
 	defaultGraylogEndpoint = "127.0.0.1:12201"
-	defaultConnection      = "wan"
-	defaultMaxChunkSizeWan = 1420
-	defaultMaxChunkSizeLan = 8154
+	defaultShortMessage    = "telegraf"
 )
 
-type GelfConfig struct {
-	GraylogEndpoint string
-	Connection      string
-	MaxChunkSizeWan int
-	MaxChunkSizeLan int
-}
-
-type Gelf struct {
-	GelfConfig
-}
-
-func NewGelfWriter(config GelfConfig) *Gelf {
-	if config.GraylogEndpoint == "" {
-		config.GraylogEndpoint = defaultGraylogEndpoint
-	}
-
-	if config.Connection == "" {
-		config.Connection = defaultConnection
-	}
-
-	if config.MaxChunkSizeWan == 0 {
-		config.MaxChunkSizeWan = defaultMaxChunkSizeWan
-	}
-
-	if config.MaxChunkSizeLan == 0 {
-		config.MaxChunkSizeLan = defaultMaxChunkSizeLan
-	}
-
-	g := &Gelf{GelfConfig: config}
-
-	return g
-}
-
-func (g *Gelf) Write(message []byte) (n int, err error) {
-	compressed := g.compress(message)
-
-	chunksize := g.GelfConfig.MaxChunkSizeWan
-	length := compressed.Len()
-
-	if length > chunksize {
-
-		chunkCountInt := int(math.Ceil(float64(length) / float64(chunksize)))
-
-		id := make([]byte, 8)
-		rand.Read(id)
-
-		for i, index := 0, 0; i < length; i, index = i+chunksize, index+1 {
-			packet := g.createChunkedMessage(index, chunkCountInt, id, &compressed)
-			_, err = g.send(packet.Bytes())
-			if err != nil {
-				return 0, err
-			}
-		}
-	} else {
-		_, err = g.send(compressed.Bytes())
-		if err != nil {
-			return 0, err
-		}
-	}
-
-	n = len(message)
+// Characters which are not allowed in the names of the additional fields.
+var invalidFieldChars = regexp.MustCompile(`[^\w.\-]`)
 
-	return
+type Graylog struct {
+	Servers           []string          `toml:"servers"`
+	Failover          bool              `toml:"failover"`
+	Connection        string            `toml:"connection"`
+	Compression       string            `toml:"compression"`
+	Timeout           internal.Duration `toml:"timeout"`
+	ShortMessageField string            `toml:"short_message_field"`
+	tlsint.ClientConfig
+
+	hostname string
+	senders  []sender
+	// The index of the sender the messages are sent with in failover, the
+	// next senders being used when it fails.
+	current int
 }
 
-func (g *Gelf) createChunkedMessage(index int, chunkCountInt int, id []byte, compressed *bytes.Buffer) bytes.Buffer {
-	var packet bytes.Buffer
-
-	chunksize := g.getChunksize()
-
-	packet.Write(g.intToBytes(30))
-	packet.Write(g.intToBytes(15))
-	packet.Write(id)
-
-	packet.Write(g.intToBytes(index))
-	packet.Write(g.intToBytes(chunkCountInt))
-
-	packet.Write(compressed.Next(chunksize))
-
-	return packet
-}
+var sampleConfig = `
+  ## Endpoints of your graylog instances, as "udp://host:port" or
+  ## "tcp://host:port", the UDP protocol being used without scheme.
+  servers = ["udp://127.0.0.1:12201"]
+
+  ## Send the messages to the first endpoint only, failing over to the next
+  ## ones when a write fails, instead of sending them to all the endpoints.
+  ## The UDP writes practically never fail, as the delivery is not
+  ## acknowledged, so that the failover is only useful with TCP endpoints.
+  # failover = false
+
+  ## Size of the UDP chunks: "wan" for 1420 bytes, "lan" for 8154 bytes.
+  # connection = "wan"
+
+  ## Compression of the UDP messages: "zlib", "gzip" or "none".  The TCP
+  ## messages are not compressed.
+  # compression = "zlib"
+
+  ## Timeout of the connections and writes.
+  # timeout = "5s"
+
+  ## Field used as the short_message of the messages, instead of "telegraf".
+  # short_message_field = ""
+
+  ## Optional TLS Config, for the TCP endpoints
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
 
-func (g *Gelf) getChunksize() int {
-	if g.GelfConfig.Connection == "wan" {
-		return g.GelfConfig.MaxChunkSizeWan
+func (g *Graylog) Connect() error {
+	if len(g.Servers) == 0 {
+		g.Servers = []string{defaultGraylogEndpoint}
 	}
 
-	if g.GelfConfig.Connection == "lan" {
-		return g.GelfConfig.MaxChunkSizeLan
+	var chunkSize int
+	switch g.Connection {
+	case "", "wan":
+		chunkSize = defaultMaxChunkSizeWan
+	case "lan":
+		chunkSize = defaultMaxChunkSizeLan
+	default:
+		return fmt.Errorf("unknown connection %q", g.Connection)
 	}
 
-	return g.GelfConfig.MaxChunkSizeWan
-}
-
-func (g *Gelf) intToBytes(i int) []byte {
-	buf := new(bytes.Buffer)
-
-	binary.Write(buf, binary.LittleEndian, int8(i))
-	return buf.Bytes()
-}
-
-func (g *Gelf) compress(b []byte) bytes.Buffer {
-	var buf bytes.Buffer
-	comp := zlib.NewWriter(&buf)
-
-	comp.Write(b)
-	comp.Close()
-
-	return buf
-}
-
-func (g *Gelf) send(b []byte) (n int, err error) {
-	udpAddr, err := net.ResolveUDPAddr("udp", g.GelfConfig.GraylogEndpoint)
-	if err != nil {
-		return
+	switch g.Compression {
+	case "":
+		g.Compression = "zlib"
+	case "zlib", "gzip", "none":
+	default:
+		return fmt.Errorf("unknown compression %q", g.Compression)
 	}
 
-	conn, err := net.DialUDP("udp", nil, udpAddr)
+	tlsConfig, err := g.ClientConfig.TLSConfig()
 	if err != nil {
-		return
-	}
-
-	n, err = conn.Write(b)
-	return
-}
-
-type Graylog struct {
-	Servers []string
-	writer  io.Writer
-}
-
-var sampleConfig = `
-  ## UDP endpoint for your graylog instance.
-  servers = ["127.0.0.1:12201", "192.168.1.1:12201"]
-`
-
-func (g *Graylog) Connect() error {
-	writers := []io.Writer{}
-
-	if len(g.Servers) == 0 {
-		g.Servers = append(g.Servers, "localhost:12201")
+		return err
 	}
 
+	g.senders = nil
 	for _, server := range g.Servers {
-		w := NewGelfWriter(GelfConfig{GraylogEndpoint: server})
-		writers = append(writers, w)
+		scheme, address := "udp", server
+		if parts := strings.SplitN(server, "://", 2); len(parts) == 2 {
+			scheme, address = parts[0], parts[1]
+		}
+
+		switch scheme {
+		case "udp":
+			g.senders = append(g.senders, &udpSender{
+				address:     address,
+				compression: g.Compression,
+				chunkSize:   chunkSize,
+				timeout:     g.Timeout.Duration,
+			})
+		case "tcp":
+			g.senders = append(g.senders, &tcpSender{
+				address:   address,
+				tlsConfig: tlsConfig,
+				timeout:   g.Timeout.Duration,
+			})
+		default:
+			return fmt.Errorf("unknown scheme of server %q", server)
+		}
 	}
+	g.current = 0
 
-	g.writer = io.MultiWriter(writers...)
-	return nil
+	g.hostname, err = os.Hostname()
+	return err
 }
 
 func (g *Graylog) Close() error {
+	for _, s := range g.senders {
+		s.close()
+	}
 	return nil
 }
 
@@ -188,66 +146,125 @@ func (g *Graylog) Description() string {
 }
 
 func (g *Graylog) Write(metrics []telegraf.Metric) error {
-	if len(metrics) == 0 {
-		return nil
-	}
-
 	for _, metric := range metrics {
-		values, err := serialize(metric)
+		message, err := g.serialize(metric)
 		if err != nil {
 			return err
 		}
-
-		for _, value := range values {
-			_, err := g.writer.Write([]byte(value))
-			if err != nil {
-				return fmt.Errorf("FAILED to write message: %s, %s", value, err)
+		if err := g.send(message); err != nil {
+			if tooLarge, ok := err.(*messageTooLargeError); ok {
+				log.Printf("E! [outputs.graylog] dropping metric %s: %v", metric.Name(), tooLarge)
+				continue
 			}
+			return err
 		}
 	}
 	return nil
 }
 
-func serialize(metric telegraf.Metric) ([]string, error) {
-	out := []string{}
+// send sends the message with all the senders, or with the current sender
+// in failover.
+func (g *Graylog) send(message []byte) error {
+	if g.Failover {
+		return g.sendFailover(message)
+	}
+
+	// A failed sender does not prevent sending the message with the next
+	// ones, the write failing afterwards.  A message too large is dropped.
+	var failed, tooLarge error
+	for _, s := range g.senders {
+		err := s.send(message)
+		if err == nil {
+			continue
+		}
+		if _, ok := err.(*messageTooLargeError); ok {
+			tooLarge = err
+			continue
+		}
+
+		log.Printf("W! [outputs.graylog] writing to %s failed: %v", s, err)
+		s.close()
+		failed = err
+	}
+	if failed != nil {
+		return fmt.Errorf("writing to the servers failed, last error: %v", failed)
+	}
+	return tooLarge
+}
+
+// sendFailover sends the message with the current sender, failing over to
+// the next senders.
+func (g *Graylog) sendFailover(message []byte) error {
+	var err error
+	for i := 0; i < len(g.senders); i++ {
+		s := g.senders[g.current]
+		err = s.send(message)
+		if err == nil {
+			return nil
+		}
+		if _, ok := err.(*messageTooLargeError); ok {
+			return err
+		}
+
+		log.Printf("W! [outputs.graylog] writing to %s failed: %v", s, err)
+		s.close()
+		g.current = (g.current + 1) % len(g.senders)
+	}
+	return fmt.Errorf("writing to all the servers failed, last error: %v", err)
+}
 
+func (g *Graylog) serialize(metric telegraf.Metric) ([]byte, error) {
 	m := make(map[string]interface{})
 	m["version"] = "1.1"
-	m["timestamp"] = metric.Time().UnixNano() / 1000000000
-	m["short_message"] = "telegraf"
-	m["name"] = metric.Name()
+	m["timestamp"] = float64(metric.Time().UnixNano()/int64(time.Millisecond)) / 1000
+	m["short_message"] = defaultShortMessage
+	m["_name"] = metric.Name()
 
-	if host, ok := metric.Tags()["host"]; ok {
+	if host, ok := metric.GetTag("host"); ok {
 		m["host"] = host
 	} else {
-		host, err := os.Hostname()
-		if err != nil {
-			return []string{}, err
-		}
-		m["host"] = host
+		m["host"] = g.hostname
 	}
 
-	for key, value := range metric.Tags() {
-		if key != "host" {
-			m["_"+key] = value
+	for _, tag := range metric.TagList() {
+		if tag.Key != "host" {
+			m[fieldName(tag.Key)] = tag.Value
 		}
 	}
 
-	for key, value := range metric.Fields() {
-		m["_"+key] = value
+	for _, field := range metric.FieldList() {
+		if field.Key == g.ShortMessageField {
+			m["short_message"] = fmt.Sprint(field.Value)
+			continue
+		}
+		value := field.Value
+		if b, ok := value.(bool); ok {
+			value = 0
+			if b {
+				value = 1
+			}
+		}
+		m[fieldName(field.Key)] = value
 	}
 
-	serialized, err := ejson.Marshal(m)
-	if err != nil {
-		return []string{}, err
-	}
-	out = append(out, string(serialized))
+	return ejson.Marshal(m)
+}
 
-	return out, nil
+// fieldName returns the name of the additional field of a tag or field,
+// prefixed by an underscore and with the invalid characters replaced by
+// underscores.  The reserved "_id" name is suffixed by an underscore.
+func fieldName(key string) string {
+	name := "_" + invalidFieldChars.ReplaceAllString(key, "_")
+	if name == "_id" {
+		name += "_"
+	}
+	return name
 }
 
 func init() {
 	outputs.Add("graylog", func() telegraf.Output {
-		return &Graylog{}
+		return &Graylog{
+			Timeout: internal.Duration{Duration: 5 * time.Second},
+		}
 	})
 }
@@ -1,58 +1,280 @@
 package graylog
 
 import (
+	"bufio"
 	"bytes"
+	"compress/gzip"
 	"compress/zlib"
+	"crypto/tls"
 	"encoding/json"
-	"io"
+	"io/ioutil"
 	"net"
-	"sync"
 	"testing"
+	"time"
 
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/testutil"
-	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+var pki = testutil.NewPKI("../../../testutil/pki")
+
+type GelfObject map[string]interface{}
+
+func readDatagram(t *testing.T, conn net.PacketConn) []byte {
+	buf := make([]byte, 65536)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	require.NoError(t, err)
+	return buf[:n]
+}
+
+func decompress(t *testing.T, compression string, data []byte) GelfObject {
+	var b []byte
+	var err error
+	switch compression {
+	case "zlib":
+		r, err := zlib.NewReader(bytes.NewReader(data))
+		require.NoError(t, err)
+		b, err = ioutil.ReadAll(r)
+		require.NoError(t, err)
+	case "gzip":
+		r, err := gzip.NewReader(bytes.NewReader(data))
+		require.NoError(t, err)
+		b, err = ioutil.ReadAll(r)
+		require.NoError(t, err)
+	default:
+		b = data
+	}
+
+	var obj GelfObject
+	err = json.Unmarshal(b, &obj)
+	require.NoError(t, err)
+	return obj
+}
+
 func TestWrite(t *testing.T) {
-	var wg sync.WaitGroup
-	var wg2 sync.WaitGroup
-	wg.Add(1)
-	wg2.Add(1)
-	go UDPServer(t, &wg, &wg2)
-	wg2.Wait()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	i := Graylog{
+		Servers: []string{conn.LocalAddr().String()},
+	}
+	require.NoError(t, i.Connect())
+	defer i.Close()
+
+	require.NoError(t, i.Write(testutil.MockMetrics()))
+
+	obj := decompress(t, "zlib", readDatagram(t, conn))
+	require.Equal(t, float64(1), obj["_value"])
+}
+
+func TestSerialize(t *testing.T) {
+	i := Graylog{ShortMessageField: "message"}
+	require.NoError(t, i.Connect())
+
+	b, err := i.serialize(testutil.MustMetric(
+		"syslog",
+		map[string]string{"host": "a", "app name": "sshd", "id": "7"},
+		map[string]interface{}{"message": "accepted", "severity": int64(6), "ok": true},
+		time.Unix(1500000000, 123000000),
+	))
+	require.NoError(t, err)
+
+	var obj GelfObject
+	require.NoError(t, json.Unmarshal(b, &obj))
+	require.Equal(t, GelfObject{
+		"version":       "1.1",
+		"host":          "a",
+		"short_message": "accepted",
+		"timestamp":     1500000000.123,
+		"_name":         "syslog",
+		"_app_name":     "sshd",
+		"_id_":          "7",
+		"_severity":     float64(6),
+		"_ok":           float64(1),
+	}, obj)
+}
+
+func TestWriteChunked(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
+
+	i := Graylog{
+		Servers:     []string{"udp://" + conn.LocalAddr().String()},
+		Compression: "none",
+	}
+	require.NoError(t, i.Connect())
+	defer i.Close()
+
+	long := string(bytes.Repeat([]byte("x"), 3000))
+	require.NoError(t, i.Write([]telegraf.Metric{
+		testutil.MustMetric("log", nil, map[string]interface{}{"message": long}, time.Unix(1500000000, 123000000)),
+	}))
+
+	var payload []byte
+	var id []byte
+	for seq := 0; seq < 3; seq++ {
+		chunk := readDatagram(t, conn)
+		require.True(t, len(chunk) <= defaultMaxChunkSizeWan)
+		require.Equal(t, chunkMagic, chunk[:2])
+		if id == nil {
+			id = chunk[2:10]
+		}
+		require.Equal(t, id, chunk[2:10])
+		require.Equal(t, byte(seq), chunk[10])
+		require.Equal(t, byte(3), chunk[11])
+		payload = append(payload, chunk[chunkHeaderSize:]...)
+	}
+
+	obj := decompress(t, "none", payload)
+	require.Equal(t, long, obj["_message"])
+}
+
+func TestWriteGzip(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer conn.Close()
 
 	i := Graylog{
-		Servers: []string{"127.0.0.1:12201"},
+		Servers:     []string{conn.LocalAddr().String()},
+		Compression: "gzip",
+		Connection:  "lan",
 	}
-	i.Connect()
+	require.NoError(t, i.Connect())
+	defer i.Close()
+
+	require.NoError(t, i.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 123000000)),
+	}))
+	obj := decompress(t, "gzip", readDatagram(t, conn))
+	require.Equal(t, 42.5, obj["_usage"])
+}
 
-	metrics := testutil.MockMetrics()
+func readTCP(t *testing.T, listener net.Listener) GelfObject {
+	conn, err := listener.Accept()
+	require.NoError(t, err)
+	defer conn.Close()
 
-	i.Write(metrics)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	frame, err := bufio.NewReader(conn).ReadBytes(0)
+	require.NoError(t, err)
+	return decompress(t, "none", frame[:len(frame)-1])
+}
+
+func TestWriteTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	i := Graylog{
+		Servers: []string{"tcp://" + listener.Addr().String()},
+		Timeout: internal.Duration{Duration: 5 * time.Second},
+	}
+	require.NoError(t, i.Connect())
+	defer i.Close()
+
+	done := make(chan GelfObject)
+	go func() { done <- readTCP(t, listener) }()
+	require.NoError(t, i.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 123000000)),
+	}))
+	require.Equal(t, 42.5, (<-done)["_usage"])
+}
+
+func TestWriteTLS(t *testing.T) {
+	serverConfig, err := pki.TLSServerConfig().TLSConfig()
+	require.NoError(t, err)
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", serverConfig)
+	require.NoError(t, err)
+	defer listener.Close()
+
+	i := Graylog{
+		Servers:      []string{"tcp://" + listener.Addr().String()},
+		Timeout:      internal.Duration{Duration: 5 * time.Second},
+		ClientConfig: *pki.TLSClientConfig(),
+	}
+	require.NoError(t, i.Connect())
+	defer i.Close()
 
-	wg.Wait()
+	done := make(chan GelfObject)
+	go func() { done <- readTCP(t, listener) }()
+	require.NoError(t, i.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 123000000)),
+	}))
+	require.Equal(t, 42.5, (<-done)["_usage"])
+}
+
+func TestWriteFailover(t *testing.T) {
+	// A closed listener, refusing the connections
+	down, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	down.Close()
+
+	up, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer up.Close()
+
+	i := Graylog{
+		Servers:  []string{"tcp://" + down.Addr().String(), "tcp://" + up.Addr().String()},
+		Failover: true,
+		Timeout:  internal.Duration{Duration: 5 * time.Second},
+	}
+	require.NoError(t, i.Connect())
+	defer i.Close()
+
+	done := make(chan GelfObject)
+	go func() { done <- readTCP(t, up) }()
+	require.NoError(t, i.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 123000000)),
+	}))
+	require.Equal(t, 42.5, (<-done)["_usage"])
+	require.Equal(t, 1, i.current)
+
+	// The write fails when all the servers are down
+	up.Close()
 	i.Close()
+	require.Error(t, i.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 123000000)),
+	}))
 }
 
-type GelfObject map[string]interface{}
+func TestWriteAllServers(t *testing.T) {
+	first, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer first.Close()
 
-func UDPServer(t *testing.T, wg *sync.WaitGroup, wg2 *sync.WaitGroup) {
-	serverAddr, _ := net.ResolveUDPAddr("udp", "127.0.0.1:12201")
-	udpServer, _ := net.ListenUDP("udp", serverAddr)
-	defer wg.Done()
+	second, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer second.Close()
 
-	bufR := make([]byte, 1024)
-	wg2.Done()
-	n, _, _ := udpServer.ReadFromUDP(bufR)
+	i := Graylog{
+		Servers: []string{"udp://" + first.LocalAddr().String(), "tcp://" + second.Addr().String()},
+		Timeout: internal.Duration{Duration: 5 * time.Second},
+	}
+	require.NoError(t, i.Connect())
+	defer i.Close()
 
-	b := bytes.NewReader(bufR[0:n])
-	r, _ := zlib.NewReader(b)
+	done := make(chan GelfObject)
+	go func() { done <- readTCP(t, second) }()
+	require.NoError(t, i.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 123000000)),
+	}))
+	require.Equal(t, 42.5, decompress(t, "zlib", readDatagram(t, first))["_usage"])
+	require.Equal(t, 42.5, (<-done)["_usage"])
 
-	bufW := bytes.NewBuffer(nil)
-	io.Copy(bufW, r)
-	r.Close()
+	// The write fails when a server is down
+	second.Close()
+	i.Close()
+	require.Error(t, i.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 123000000)),
+	}))
+}
 
-	var obj GelfObject
-	json.Unmarshal(bufW.Bytes(), &obj)
-	assert.Equal(t, obj["_value"], float64(1))
+func TestConnectInvalidServer(t *testing.T) {
+	i := Graylog{Servers: []string{"http://127.0.0.1:12201"}}
+	require.Error(t, i.Connect())
 }
@@ -0,0 +1,341 @@
+package influxdb_v2
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+)
+
+const (
+	defaultRequestTimeout   = time.Second * 5
+	defaultUserAgent        = "telegraf"
+	defaultMaxRetryInterval = time.Minute * 5
+)
+
+// APIError is an error reported by the InfluxDB server
+type APIError struct {
+	StatusCode  int
+	Title       string
+	Description string
+}
+
+func (e APIError) Error() string {
+	if e.Description != "" {
+		return fmt.Sprintf("%s: %s", e.Title, e.Description)
+	}
+	return e.Title
+}
+
+// WriteResponse is the response body from the /api/v2/write endpoint
+type WriteResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+type HTTPConfig struct {
+	URL              *url.URL
+	Token            string
+	Organization     string
+	Bucket           string
+	BucketTag        string
+	ExcludeBucketTag bool
+	Timeout          time.Duration
+	Headers          map[string]string
+	Proxy            *url.URL
+	UserAgent        string
+	ContentEncoding  string
+	MaxRetryInterval time.Duration
+	TLSConfig        *tls.Config
+
+	Serializer *influx.Serializer
+}
+
+type httpClient struct {
+	ContentEncoding  string
+	Timeout          time.Duration
+	Headers          map[string]string
+	Organization     string
+	Bucket           string
+	BucketTag        string
+	ExcludeBucketTag bool
+	MaxRetryInterval time.Duration
+
+	client     *http.Client
+	serializer *influx.Serializer
+	url        *url.URL
+
+	// The time before which no write is attempted, after the server asked
+	// to retry later or failed, and the count of consecutive failures.
+	retryTime  time.Time
+	retryCount int
+}
+
+func NewHTTPClient(config *HTTPConfig) (*httpClient, error) {
+	if config.URL == nil {
+		return nil, ErrMissingURL
+	}
+
+	timeout := config.Timeout
+	if timeout == 0 {
+		timeout = defaultRequestTimeout
+	}
+
+	userAgent := config.UserAgent
+	if userAgent == "" {
+		userAgent = defaultUserAgent
+	}
+
+	maxRetryInterval := config.MaxRetryInterval
+	if maxRetryInterval == 0 {
+		maxRetryInterval = defaultMaxRetryInterval
+	}
+
+	var headers = make(map[string]string, len(config.Headers)+2)
+	headers["User-Agent"] = userAgent
+	headers["Authorization"] = "Token " + config.Token
+	for k, v := range config.Headers {
+		headers[k] = v
+	}
+
+	var proxy func(*http.Request) (*url.URL, error)
+	if config.Proxy != nil {
+		proxy = http.ProxyURL(config.Proxy)
+	} else {
+		proxy = http.ProxyFromEnvironment
+	}
+
+	serializer := config.Serializer
+	if serializer == nil {
+		serializer = influx.NewSerializer()
+	}
+
+	var transport *http.Transport
+	switch config.URL.Scheme {
+	case "http", "https":
+		transport = &http.Transport{
+			Proxy:           proxy,
+			TLSClientConfig: config.TLSConfig,
+		}
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q", config.URL.Scheme)
+	}
+
+	client := &httpClient{
+		serializer: serializer,
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+		},
+		url:              config.URL,
+		ContentEncoding:  config.ContentEncoding,
+		Timeout:          timeout,
+		Headers:          headers,
+		Organization:     config.Organization,
+		Bucket:           config.Bucket,
+		BucketTag:        config.BucketTag,
+		ExcludeBucketTag: config.ExcludeBucketTag,
+		MaxRetryInterval: maxRetryInterval,
+	}
+	return client, nil
+}
+
+// URL returns the origin URL that this client connects too.
+func (c *httpClient) URL() string {
+	return c.url.String()
+}
+
+// Write sends the metrics to InfluxDB, in a batch per bucket.
+func (c *httpClient) Write(ctx context.Context, metrics []telegraf.Metric) error {
+	if time.Now().Before(c.retryTime) {
+		return fmt.Errorf("retrying after %s", c.retryTime.Format(time.RFC3339))
+	}
+
+	if c.BucketTag == "" {
+		return c.writeBatch(ctx, c.Bucket, metrics)
+	}
+
+	var buckets []string
+	batches := make(map[string][]telegraf.Metric)
+	for _, metric := range metrics {
+		bucket, ok := metric.GetTag(c.BucketTag)
+		if !ok || bucket == "" {
+			bucket = c.Bucket
+		}
+		if _, ok := batches[bucket]; !ok {
+			buckets = append(buckets, bucket)
+		}
+
+		if c.ExcludeBucketTag {
+			// The metrics are shared by the outputs.
+			metric = metric.Copy()
+			metric.RemoveTag(c.BucketTag)
+		}
+		batches[bucket] = append(batches[bucket], metric)
+	}
+
+	for _, bucket := range buckets {
+		if err := c.writeBatch(ctx, bucket, batches[bucket]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *httpClient) writeBatch(ctx context.Context, bucket string, metrics []telegraf.Metric) error {
+	writeURL, err := makeWriteURL(c.url, c.Organization, bucket)
+	if err != nil {
+		return err
+	}
+
+	reader := influx.NewReader(metrics, c.serializer)
+	req, err := c.makeWriteRequest(writeURL, reader)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		c.backoff(0)
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		c.retryCount = 0
+		c.retryTime = time.Time{}
+		return nil
+	}
+
+	writeResp := &WriteResponse{}
+	dec := json.NewDecoder(resp.Body)
+
+	var desc string
+	err = dec.Decode(writeResp)
+	if err == nil {
+		desc = writeResp.Message
+	}
+
+	switch resp.StatusCode {
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		// The points are invalid, or some of them conflict with the
+		// existing data, as for a "partial write" of the v1 API: retries
+		// would not be successful and so the points are dropped.
+		log.Printf("E! [outputs.influxdb_v2] when writing to [%s]: received error %v; discarding points",
+			c.URL(), desc)
+		return nil
+	case http.StatusRequestEntityTooLarge:
+		if len(metrics) > 1 {
+			log.Printf("W! [outputs.influxdb_v2] when writing to [%s]: batch of %d metrics too large; splitting",
+				c.URL(), len(metrics))
+			half := len(metrics) / 2
+			if err := c.writeBatch(ctx, bucket, metrics[:half]); err != nil {
+				return err
+			}
+			return c.writeBatch(ctx, bucket, metrics[half:])
+		}
+		log.Printf("E! [outputs.influxdb_v2] when writing to [%s]: metric too large; discarding point",
+			c.URL())
+		return nil
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		retryAfter, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		c.backoff(time.Duration(retryAfter) * time.Second)
+	default:
+		if resp.StatusCode >= 500 {
+			c.backoff(0)
+		}
+	}
+
+	return &APIError{
+		StatusCode:  resp.StatusCode,
+		Title:       resp.Status,
+		Description: desc,
+	}
+}
+
+// backoff delays the next write by the retry interval asked by the server,
+// or else by an exponential interval.
+func (c *httpClient) backoff(retryAfter time.Duration) {
+	c.retryCount++
+	if retryAfter == 0 {
+		retryAfter = time.Duration(math.Pow(2, float64(c.retryCount-1))) * time.Second
+	}
+	if retryAfter > c.MaxRetryInterval {
+		retryAfter = c.MaxRetryInterval
+	}
+	c.retryTime = time.Now().Add(retryAfter)
+}
+
+func (c *httpClient) makeWriteRequest(url string, body io.Reader) (*http.Request, error) {
+	var err error
+	if c.ContentEncoding == "gzip" {
+		body, err = compressWithGzip(body)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequest("POST", url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	for header, value := range c.Headers {
+		req.Header.Set(header, value)
+	}
+
+	if c.ContentEncoding == "gzip" {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	return req, nil
+}
+
+func compressWithGzip(data io.Reader) (io.Reader, error) {
+	pr, pw := io.Pipe()
+	gw := gzip.NewWriter(pw)
+
+	go func() {
+		_, err := io.Copy(gw, data)
+		if err == nil {
+			err = gw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	return pr, nil
+}
+
+func makeWriteURL(loc *url.URL, org, bucket string) (string, error) {
+	if bucket == "" {
+		return "", errors.New("missing bucket")
+	}
+
+	params := url.Values{}
+	params.Set("org", org)
+	params.Set("bucket", bucket)
+
+	u := *loc
+	switch u.Scheme {
+	case "http", "https":
+		u.Path = path.Join(u.Path, "api/v2/write")
+	default:
+		return "", fmt.Errorf("unsupported scheme: %q", loc.Scheme)
+	}
+	u.RawQuery = params.Encode()
+	return u.String(), nil
+}
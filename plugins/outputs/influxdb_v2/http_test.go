@@ -0,0 +1,283 @@
+package influxdb_v2_test
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	influxdb "github.com/influxdata/telegraf/plugins/outputs/influxdb_v2"
+	"github.com/stretchr/testify/require"
+)
+
+func getHTTPURL() *url.URL {
+	u, err := url.Parse("http://localhost")
+	if err != nil {
+		panic(err)
+	}
+	return u
+}
+
+func getMetric(bucket string) telegraf.Metric {
+	tags := map[string]string{}
+	if bucket != "" {
+		tags["bucket"] = bucket
+	}
+	m, err := metric.New(
+		"cpu",
+		tags,
+		map[string]interface{}{
+			"value": 42.0,
+		},
+		time.Unix(0, 0),
+	)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func TestHTTP_EmptyConfig(t *testing.T) {
+	config := &influxdb.HTTPConfig{}
+	_, err := influxdb.NewHTTPClient(config)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), influxdb.ErrMissingURL.Error())
+}
+
+func TestHTTP_MinimalConfig(t *testing.T) {
+	config := &influxdb.HTTPConfig{
+		URL: getHTTPURL(),
+	}
+	_, err := influxdb.NewHTTPClient(config)
+	require.NoError(t, err)
+}
+
+func TestHTTP_UnsupportedScheme(t *testing.T) {
+	config := &influxdb.HTTPConfig{
+		URL: &url.URL{
+			Scheme: "unix",
+			Host:   "localhost",
+		},
+	}
+	_, err := influxdb.NewHTTPClient(config)
+	require.Error(t, err)
+}
+
+func TestHTTP_Write(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	defer ts.Close()
+
+	u, err := url.Parse(fmt.Sprintf("http://%s", ts.Listener.Addr().String()))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		config  *influxdb.HTTPConfig
+		metrics []telegraf.Metric
+		handler func(t *testing.T, w http.ResponseWriter, r *http.Request)
+		errFunc func(t *testing.T, err error)
+	}{
+		{
+			name: "success",
+			config: &influxdb.HTTPConfig{
+				URL:          u,
+				Token:        "my-token",
+				Organization: "my-org",
+				Bucket:       "my-bucket",
+			},
+			metrics: []telegraf.Metric{getMetric("")},
+			handler: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "/api/v2/write", r.URL.Path)
+				require.Equal(t, "my-org", r.FormValue("org"))
+				require.Equal(t, "my-bucket", r.FormValue("bucket"))
+				require.Equal(t, "Token my-token", r.Header.Get("Authorization"))
+				require.Equal(t, "telegraf", r.Header.Get("User-Agent"))
+				body, err := ioutil.ReadAll(r.Body)
+				require.NoError(t, err)
+				require.Equal(t, "cpu value=42 0\n", string(body))
+				w.WriteHeader(http.StatusNoContent)
+			},
+		},
+		{
+			name: "gzip",
+			config: &influxdb.HTTPConfig{
+				URL:             u,
+				Bucket:          "my-bucket",
+				ContentEncoding: "gzip",
+			},
+			metrics: []telegraf.Metric{getMetric("")},
+			handler: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				require.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+				gr, err := gzip.NewReader(r.Body)
+				require.NoError(t, err)
+				body, err := ioutil.ReadAll(gr)
+				require.NoError(t, err)
+				require.Equal(t, "cpu value=42 0\n", string(body))
+				w.WriteHeader(http.StatusNoContent)
+			},
+		},
+		{
+			name: "partial write is dropped",
+			config: &influxdb.HTTPConfig{
+				URL:    u,
+				Bucket: "my-bucket",
+			},
+			metrics: []telegraf.Metric{getMetric("")},
+			handler: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnprocessableEntity)
+				w.Write([]byte(`{"code":"unprocessable entity","message":"failure writing points to database: partial write: field type conflict"}`))
+			},
+		},
+		{
+			name: "unauthorized",
+			config: &influxdb.HTTPConfig{
+				URL:    u,
+				Bucket: "my-bucket",
+			},
+			metrics: []telegraf.Metric{getMetric("")},
+			handler: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+				w.Write([]byte(`{"code":"unauthorized","message":"unauthorized access"}`))
+			},
+			errFunc: func(t *testing.T, err error) {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), "unauthorized access")
+			},
+		},
+		{
+			name: "missing bucket",
+			config: &influxdb.HTTPConfig{
+				URL: u,
+			},
+			metrics: []telegraf.Metric{getMetric("")},
+			handler: func(t *testing.T, w http.ResponseWriter, r *http.Request) {
+				t.Fatal("unexpected request")
+			},
+			errFunc: func(t *testing.T, err error) {
+				require.Error(t, err)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tt.handler(t, w, r)
+			})
+
+			client, err := influxdb.NewHTTPClient(tt.config)
+			require.NoError(t, err)
+
+			err = client.Write(context.Background(), tt.metrics)
+			if tt.errFunc != nil {
+				tt.errFunc(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestHTTP_BucketTag(t *testing.T) {
+	requests := make(map[string]string)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		requests[r.FormValue("bucket")] += string(body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+
+	client, err := influxdb.NewHTTPClient(&influxdb.HTTPConfig{
+		URL:              u,
+		Bucket:           "telegraf",
+		BucketTag:        "bucket",
+		ExcludeBucketTag: true,
+	})
+	require.NoError(t, err)
+
+	tagged := getMetric("foo")
+	err = client.Write(context.Background(), []telegraf.Metric{
+		tagged,
+		getMetric(""),
+		getMetric("foo"),
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]string{
+		"foo":      "cpu value=42 0\ncpu value=42 0\n",
+		"telegraf": "cpu value=42 0\n",
+	}, requests)
+
+	// The tag is only removed from the written copy.
+	require.True(t, tagged.HasTag("bucket"))
+}
+
+func TestHTTP_RetryAfter(t *testing.T) {
+	var count int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		count++
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+
+	client, err := influxdb.NewHTTPClient(&influxdb.HTTPConfig{
+		URL:    u,
+		Bucket: "telegraf",
+	})
+	require.NoError(t, err)
+
+	err = client.Write(context.Background(), []telegraf.Metric{getMetric("")})
+	require.Error(t, err)
+	require.Equal(t, 1, count)
+
+	// No request is sent until the retry time is reached.
+	err = client.Write(context.Background(), []telegraf.Metric{getMetric("")})
+	require.Error(t, err)
+	require.Equal(t, 1, count)
+}
+
+func TestHTTP_TooLarge(t *testing.T) {
+	var batches []int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		lines := strings.Count(string(body), "\n")
+		batches = append(batches, lines)
+		if lines > 2 {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	u, err := url.Parse(ts.URL)
+	require.NoError(t, err)
+
+	client, err := influxdb.NewHTTPClient(&influxdb.HTTPConfig{
+		URL:    u,
+		Bucket: "telegraf",
+	})
+	require.NoError(t, err)
+
+	metrics := []telegraf.Metric{getMetric(""), getMetric(""), getMetric(""), getMetric("")}
+	err = client.Write(context.Background(), metrics)
+	require.NoError(t, err)
+	require.Equal(t, []int{4, 2, 2}, batches)
+}
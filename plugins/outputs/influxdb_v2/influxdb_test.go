@@ -0,0 +1,54 @@
+package influxdb_v2_test
+
+import (
+	"testing"
+
+	influxdb "github.com/influxdata/telegraf/plugins/outputs/influxdb_v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDefaultURL(t *testing.T) {
+	output := influxdb.InfluxDB{}
+	err := output.Connect()
+	require.NoError(t, err)
+	require.Equal(t, []string{"http://localhost:8086"}, output.URLs)
+}
+
+func TestConnect(t *testing.T) {
+	tests := []struct {
+		name string
+		urls []string
+		err  bool
+	}{
+		{
+			name: "http",
+			urls: []string{"http://localhost:8086"},
+		},
+		{
+			name: "https",
+			urls: []string{"https://localhost:8086"},
+		},
+		{
+			name: "unsupported scheme",
+			urls: []string{"udp://localhost:8089"},
+			err:  true,
+		},
+		{
+			name: "invalid url",
+			urls: []string{":localhost"},
+			err:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output := influxdb.InfluxDB{URLs: tt.urls}
+			err := output.Connect()
+			if tt.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -3,14 +3,22 @@ package kafka
 import (
 	"crypto/tls"
 	"fmt"
+	"net/http"
+	"sort"
 	"strings"
+	"text/template"
+	"time"
 
 	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/oauth"
+	"github.com/influxdata/telegraf/internal/proxy"
 	tlsint "github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers"
 
 	"github.com/Shopify/sarama"
+	"golang.org/x/oauth2"
 )
 
 var ValidTopicSuffixMethods = []string{
@@ -19,22 +27,39 @@ var ValidTopicSuffixMethods = []string{
 	"tags",
 }
 
+var ValidRoutingMethods = []string{
+	"",
+	"tag",
+	"measurement",
+	"random",
+}
+
 type (
 	Kafka struct {
 		// Kafka brokers to send metrics to
 		Brokers []string
-		// Kafka topic
+		// Kafka version
+		Version string `toml:"version"`
+		// Kafka topic, may be a template
 		Topic string
+		// Tag overriding the topic
+		TopicTag string `toml:"topic_tag"`
 		// Kafka topic suffix option
 		TopicSuffix TopicSuffix `toml:"topic_suffix"`
+		// Routing Method
+		RoutingMethod string `toml:"routing_method"`
 		// Routing Key Tag
 		RoutingTag string `toml:"routing_tag"`
+		// Record headers, may be templates
+		Headers map[string]string `toml:"headers"`
 		// Compression Codec Tag
 		CompressionCodec int
 		// RequiredAcks Tag
 		RequiredAcks int
 		// MaxRetry Tag
 		MaxRetry int
+		// Idempotent producer
+		IdempotentWrites bool `toml:"idempotent_writes"`
 
 		// Legacy TLS config options
 		// TLS client certificate
@@ -46,14 +71,26 @@ type (
 
 		tlsint.ClientConfig
 
+		// SOCKS5 proxy of the connections to the brokers
+		proxy.Socks5ProxyConfig
+
 		// SASL Username
 		SASLUsername string `toml:"sasl_username"`
 		// SASL Password
 		SASLPassword string `toml:"sasl_password"`
+		// SASL Mechanism
+		SASLMechanism string `toml:"sasl_mechanism"`
+		// SASL OAUTHBEARER static access token
+		SASLAccessToken string `toml:"sasl_access_token"`
+		// SASL OAUTHBEARER access tokens from the client credentials grant
+		oauth.OAuth2Config
 
 		tlsConfig tls.Config
 		producer  sarama.SyncProducer
 
+		topicTemplate   *template.Template
+		headerTemplates map[string]*template.Template
+
 		serializer serializers.Serializer
 	}
 	TopicSuffix struct {
@@ -66,9 +103,18 @@ type (
 var sampleConfig = `
   ## URLs of kafka brokers
   brokers = ["localhost:9092"]
-  ## Kafka topic for producer messages
+  ## Kafka topic for producer messages.  The topic may be a template using
+  ## the name and the tags of the metric, such as "telegraf_{{ .Name }}" or
+  ## "{{ .Tag \"team\" }}_metrics".
   topic = "telegraf"
 
+  ## Tag whose value is used as the topic, when the metric has this tag.
+  # topic_tag = ""
+
+  ## Kafka version of the brokers, required to enable the features of
+  ## newer versions which are not enabled by default.
+  # version = "2.1.0"
+
   ## Optional topic suffix configuration.
   ## If the section is omitted, no suffix is used.
   ## Following topic suffix methods are supported:
@@ -96,17 +142,36 @@ var sampleConfig = `
   #   keys = ["foo", "bar"]
   #   separator = "_"
 
+  ## Method of the routing key of the messages, determining their partition:
+  ##   tag         - value of the routing_tag tag, or no key if missing
+  ##   measurement - name of the metric
+  ##   random      - no key, the messages being written to random partitions
+  # routing_method = "tag"
+
   ## Telegraf tag to use as a routing key
   ##  ie, if this tag exists, its value will be used as the routing key
   routing_tag = "host"
 
+  ## Record headers of the messages, requiring Kafka 0.11 or later.  The
+  ## values may be templates using the name and the tags of the metric.
+  # [outputs.kafka.headers]
+  #   source = "telegraf"
+  #   datacenter = "{{ .Tag \"dc\" }}"
+
   ## CompressionCodec represents the various compression codecs recognized by
   ## Kafka in messages.
   ##  0 : No compression
   ##  1 : Gzip compression
   ##  2 : Snappy compression
+  ##  3 : LZ4 compression
+  ##  4 : ZSTD compression, requiring Kafka 2.1 or later
   # compression_codec = 0
 
+  ## Idempotent producer, preventing the duplication of the messages on
+  ## retries, requiring Kafka 0.11 or later.  The required_acks option is
+  ## ignored, all in-sync replicas acknowledging the messages.
+  # idempotent_writes = false
+
   ##  RequiredAcks is used in Produce Requests to tell the broker how many
   ##  replica acknowledgements it must see before responding
   ##   0 : the producer never waits for an acknowledgement from the broker.
@@ -134,10 +199,27 @@ var sampleConfig = `
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = false
 
+  ## Optional SOCKS5 proxy of the connections to the brokers.
+  # socks5_proxy_address = "127.0.0.1:1080"
+  # socks5_proxy_username = ""
+  # socks5_proxy_password = ""
+
   ## Optional SASL Config
   # sasl_username = "kafka"
   # sasl_password = "secret"
 
+  ## SASL mechanism, one of "PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512" or
+  ## "OAUTHBEARER".
+  # sasl_mechanism = "PLAIN"
+
+  ## Access token of the OAUTHBEARER mechanism, either static or requested
+  ## with the OAuth2 client credentials.
+  # sasl_access_token = ""
+  # client_id = "clientid"
+  # client_secret = "secret"
+  # token_url = "https://auth.example.com/oauth2/token"
+  # scopes = []
+
   ## Data format to output.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
@@ -154,14 +236,34 @@ func ValidateTopicSuffixMethod(method string) error {
 	return fmt.Errorf("Unknown topic suffix method provided: %s", method)
 }
 
-func (k *Kafka) GetTopicName(metric telegraf.Metric) string {
+func ValidateRoutingMethod(method string) error {
+	for _, validMethod := range ValidRoutingMethods {
+		if method == validMethod {
+			return nil
+		}
+	}
+	return fmt.Errorf("Unknown routing method provided: %s", method)
+}
+
+func (k *Kafka) GetTopicName(metric telegraf.Metric) (string, error) {
+	topic := k.Topic
+	if value, ok := metric.GetTag(k.TopicTag); ok && k.TopicTag != "" {
+		topic = value
+	} else if k.topicTemplate != nil {
+		var err error
+		topic, err = internal.ExecuteTemplate(k.topicTemplate, metric)
+		if err != nil {
+			return "", err
+		}
+	}
+
 	var topicName string
 	switch k.TopicSuffix.Method {
 	case "measurement":
-		topicName = k.Topic + k.TopicSuffix.Separator + metric.Name()
+		topicName = topic + k.TopicSuffix.Separator + metric.Name()
 	case "tags":
 		var topicNameComponents []string
-		topicNameComponents = append(topicNameComponents, k.Topic)
+		topicNameComponents = append(topicNameComponents, topic)
 		for _, tag := range k.TopicSuffix.Keys {
 			tagValue := metric.Tags()[tag]
 			if tagValue != "" {
@@ -170,9 +272,9 @@ func (k *Kafka) GetTopicName(metric telegraf.Metric) string {
 		}
 		topicName = strings.Join(topicNameComponents, k.TopicSuffix.Separator)
 	default:
-		topicName = k.Topic
+		topicName = topic
 	}
-	return topicName
+	return topicName, nil
 }
 
 func (k *Kafka) SetSerializer(serializer serializers.Serializer) {
@@ -180,10 +282,45 @@ func (k *Kafka) SetSerializer(serializer serializers.Serializer) {
 }
 
 func (k *Kafka) Connect() error {
-	err := ValidateTopicSuffixMethod(k.TopicSuffix.Method)
+	config, err := k.newConfig()
+	if err != nil {
+		return err
+	}
+
+	producer, err := sarama.NewSyncProducer(k.Brokers, config)
 	if err != nil {
 		return err
 	}
+	k.producer = producer
+	return nil
+}
+
+// newConfig validates the options, parses the templates and returns the
+// configuration of the producer.
+func (k *Kafka) newConfig() (*sarama.Config, error) {
+	err := ValidateTopicSuffixMethod(k.TopicSuffix.Method)
+	if err != nil {
+		return nil, err
+	}
+	err = ValidateRoutingMethod(k.RoutingMethod)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.Contains(k.Topic, "{{") {
+		k.topicTemplate, err = template.New("topic").Parse(k.Topic)
+		if err != nil {
+			return nil, fmt.Errorf("invalid topic template: %s", err)
+		}
+	}
+	k.headerTemplates = make(map[string]*template.Template, len(k.Headers))
+	for name, value := range k.Headers {
+		k.headerTemplates[name], err = template.New(name).Parse(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template of header %q: %s", name, err)
+		}
+	}
+
 	config := sarama.NewConfig()
 
 	config.Producer.RequiredAcks = sarama.RequiredAcks(k.RequiredAcks)
@@ -191,6 +328,20 @@ func (k *Kafka) Connect() error {
 	config.Producer.Retry.Max = k.MaxRetry
 	config.Producer.Return.Successes = true
 
+	if k.RoutingMethod == "random" {
+		config.Producer.Partitioner = sarama.NewRandomPartitioner
+	}
+
+	if k.IdempotentWrites {
+		config.Producer.Idempotent = true
+		config.Producer.RequiredAcks = sarama.WaitForAll
+		config.Net.MaxOpenRequests = 1
+	}
+
+	if err := k.setVersion(config); err != nil {
+		return nil, err
+	}
+
 	// Legacy support ssl config
 	if k.Certificate != "" {
 		k.TLSCert = k.Certificate
@@ -200,7 +351,7 @@ func (k *Kafka) Connect() error {
 
 	tlsConfig, err := k.ClientConfig.TLSConfig()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	if tlsConfig != nil {
@@ -208,17 +359,92 @@ func (k *Kafka) Connect() error {
 		config.Net.TLS.Enable = true
 	}
 
-	if k.SASLUsername != "" && k.SASLPassword != "" {
-		config.Net.SASL.User = k.SASLUsername
-		config.Net.SASL.Password = k.SASLPassword
-		config.Net.SASL.Enable = true
+	if k.Socks5ProxyConfig.Configured() {
+		dialer, err := k.Socks5ProxyConfig.Dialer(config.Net.DialTimeout)
+		if err != nil {
+			return nil, err
+		}
+		config.Net.Proxy.Enable = true
+		config.Net.Proxy.Dialer = dialer
 	}
 
-	producer, err := sarama.NewSyncProducer(k.Brokers, config)
-	if err != nil {
-		return err
+	if err := k.setSASL(config); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+// setVersion sets the Kafka version of the producer, which must be recent
+// enough for the enabled features.
+func (k *Kafka) setVersion(config *sarama.Config) error {
+	if k.Version != "" {
+		version, err := sarama.ParseKafkaVersion(k.Version)
+		if err != nil {
+			return err
+		}
+		config.Version = version
+	}
+
+	var feature string
+	minimum := config.Version
+	if k.IdempotentWrites || len(k.Headers) > 0 {
+		feature, minimum = "idempotent writes or headers", sarama.V0_11_0_0
+	}
+	switch config.Producer.Compression {
+	case sarama.CompressionLZ4:
+		if !minimum.IsAtLeast(sarama.V0_10_0_0) {
+			feature, minimum = "lz4 compression", sarama.V0_10_0_0
+		}
+	case sarama.CompressionZSTD:
+		feature, minimum = "zstd compression", sarama.V2_1_0_0
+	}
+
+	if !config.Version.IsAtLeast(minimum) {
+		if k.Version != "" {
+			return fmt.Errorf("%s requires Kafka %s or later", feature, minimum)
+		}
+		config.Version = minimum
 	}
-	k.producer = producer
+	return nil
+}
+
+func (k *Kafka) setSASL(config *sarama.Config) error {
+	switch strings.ToUpper(k.SASLMechanism) {
+	case "", "PLAIN":
+		if k.SASLUsername == "" || k.SASLPassword == "" {
+			return nil
+		}
+	case "SCRAM-SHA-256":
+		config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA256
+		config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &scramClient{HashGeneratorFcn: sha256Generator}
+		}
+	case "SCRAM-SHA-512":
+		config.Net.SASL.Mechanism = sarama.SASLTypeSCRAMSHA512
+		config.Net.SASL.SCRAMClientGeneratorFunc = func() sarama.SCRAMClient {
+			return &scramClient{HashGeneratorFcn: sha512Generator}
+		}
+	case "OAUTHBEARER":
+		provider := &tokenProvider{accessToken: k.SASLAccessToken}
+		if k.OAuth2Config.Configured() {
+			source, err := k.OAuth2Config.TokenSource(&http.Client{Timeout: 10 * time.Second})
+			if err != nil {
+				return err
+			}
+			provider.source = source
+		} else if k.SASLAccessToken == "" {
+			return fmt.Errorf("sasl_access_token or client_id and token_url are required by %s", sarama.SASLTypeOAuth)
+		}
+		config.Net.SASL.Mechanism = sarama.SASLTypeOAuth
+		config.Net.SASL.TokenProvider = provider
+	default:
+		return fmt.Errorf("Unknown SASL mechanism provided: %s", k.SASLMechanism)
+	}
+
+	config.Net.SASL.User = k.SASLUsername
+	config.Net.SASL.Password = k.SASLPassword
+	config.Net.SASL.Enable = true
 	return nil
 }
 
@@ -240,28 +466,83 @@ func (k *Kafka) Write(metrics []telegraf.Metric) error {
 	}
 
 	for _, metric := range metrics {
-		buf, err := k.serializer.Serialize(metric)
+		m, err := k.buildMessage(metric)
 		if err != nil {
 			return err
 		}
 
-		topicName := k.GetTopicName(metric)
+		_, _, err = k.producer.SendMessage(m)
 
-		m := &sarama.ProducerMessage{
-			Topic: topicName,
-			Value: sarama.ByteEncoder(buf),
+		if err != nil {
+			return fmt.Errorf("FAILED to send kafka message: %s\n", err)
 		}
+	}
+	return nil
+}
+
+func (k *Kafka) buildMessage(metric telegraf.Metric) (*sarama.ProducerMessage, error) {
+	buf, err := k.serializer.Serialize(metric)
+	if err != nil {
+		return nil, err
+	}
+
+	topicName, err := k.GetTopicName(metric)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &sarama.ProducerMessage{
+		Topic: topicName,
+		Value: sarama.ByteEncoder(buf),
+	}
+
+	switch k.RoutingMethod {
+	case "", "tag":
 		if h, ok := metric.Tags()[k.RoutingTag]; ok {
 			m.Key = sarama.StringEncoder(h)
 		}
+	case "measurement":
+		m.Key = sarama.StringEncoder(metric.Name())
+	}
 
-		_, _, err = k.producer.SendMessage(m)
-
+	names := make([]string, 0, len(k.headerTemplates))
+	for name := range k.headerTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value, err := internal.ExecuteTemplate(k.headerTemplates[name], metric)
 		if err != nil {
-			return fmt.Errorf("FAILED to send kafka message: %s\n", err)
+			return nil, err
 		}
+		// Headers templated from a missing tag are not sent.
+		if value == "" {
+			continue
+		}
+		m.Headers = append(m.Headers, sarama.RecordHeader{
+			Key:   []byte(name),
+			Value: []byte(value),
+		})
 	}
-	return nil
+
+	return m, nil
+}
+
+// tokenProvider provides the access tokens of the OAUTHBEARER mechanism.
+type tokenProvider struct {
+	accessToken string
+	source      oauth2.TokenSource
+}
+
+func (p *tokenProvider) Token() (*sarama.AccessToken, error) {
+	if p.source == nil {
+		return &sarama.AccessToken{Token: p.accessToken}, nil
+	}
+	token, err := p.source.Token()
+	if err != nil {
+		return nil, err
+	}
+	return &sarama.AccessToken{Token: token.AccessToken}, nil
 }
 
 func init() {
@@ -2,7 +2,10 @@ package kafka
 
 import (
 	"testing"
+	"time"
 
+	"github.com/Shopify/sarama"
+	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/plugins/serializers"
 	"github.com/influxdata/telegraf/testutil"
 	"github.com/stretchr/testify/require"
@@ -78,7 +81,8 @@ func TestTopicSuffixes(t *testing.T) {
 			TopicSuffix: topicSuffix,
 		}
 
-		topic := k.GetTopicName(metric)
+		topic, err := k.GetTopicName(metric)
+		require.NoError(t, err)
 		require.Equal(t, expectedTopic, topic)
 	}
 }
@@ -96,3 +100,167 @@ func TestValidateTopicSuffixMethod(t *testing.T) {
 		require.NoError(t, err, "Topic suffix method used should be valid.")
 	}
 }
+
+// fakeProducer records the messages sent.
+type fakeProducer struct {
+	messages []*sarama.ProducerMessage
+}
+
+func (p *fakeProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	p.messages = append(p.messages, msg)
+	return 0, int64(len(p.messages)), nil
+}
+
+func (p *fakeProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	p.messages = append(p.messages, msgs...)
+	return nil
+}
+
+func (p *fakeProducer) Close() error {
+	return nil
+}
+
+func TestWrite_topicAndHeaders(t *testing.T) {
+	s, _ := serializers.NewInfluxSerializer()
+	producer := &fakeProducer{}
+	k := &Kafka{
+		Topic:    "telegraf_{{ .Name }}",
+		TopicTag: "topic",
+		Headers: map[string]string{
+			"source":     "telegraf",
+			"datacenter": `{{ .Tag "dc" }}`,
+		},
+		RoutingTag: "host",
+		serializer: s,
+	}
+	_, err := k.newConfig()
+	require.NoError(t, err)
+	k.producer = producer
+
+	err = k.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"dc": "us-east", "host": "server01"}, map[string]interface{}{"value": 42.0}, time.Unix(0, 0)),
+		testutil.MustMetric("cpu", map[string]string{"topic": "special"}, map[string]interface{}{"value": 42.0}, time.Unix(0, 0)),
+	})
+	require.NoError(t, err)
+	require.Len(t, producer.messages, 2)
+
+	m := producer.messages[0]
+	require.Equal(t, "telegraf_cpu", m.Topic)
+	require.Equal(t, sarama.StringEncoder("server01"), m.Key)
+	require.Equal(t, []sarama.RecordHeader{
+		{Key: []byte("datacenter"), Value: []byte("us-east")},
+		{Key: []byte("source"), Value: []byte("telegraf")},
+	}, m.Headers)
+
+	m = producer.messages[1]
+	require.Equal(t, "special", m.Topic)
+	require.Nil(t, m.Key)
+	require.Equal(t, []sarama.RecordHeader{
+		{Key: []byte("source"), Value: []byte("telegraf")},
+	}, m.Headers)
+}
+
+func TestWrite_routingMethod(t *testing.T) {
+	s, _ := serializers.NewInfluxSerializer()
+	tests := []struct {
+		method string
+		key    sarama.Encoder
+	}{
+		{"", sarama.StringEncoder("server01")},
+		{"tag", sarama.StringEncoder("server01")},
+		{"measurement", sarama.StringEncoder("cpu")},
+		{"random", nil},
+	}
+
+	for _, tt := range tests {
+		producer := &fakeProducer{}
+		k := &Kafka{
+			Topic:         "telegraf",
+			RoutingMethod: tt.method,
+			RoutingTag:    "host",
+			serializer:    s,
+			producer:      producer,
+		}
+		_, err := k.newConfig()
+		require.NoError(t, err)
+
+		err = k.Write([]telegraf.Metric{testutil.MustMetric("cpu", map[string]string{"host": "server01"}, map[string]interface{}{"value": 42.0}, time.Unix(0, 0))})
+		require.NoError(t, err)
+		require.Equal(t, tt.key, producer.messages[0].Key, tt.method)
+	}
+
+	k := &Kafka{RoutingMethod: "hash"}
+	_, err := k.newConfig()
+	require.Error(t, err)
+}
+
+func TestNewConfig(t *testing.T) {
+	k := &Kafka{
+		MaxRetry:         3,
+		RequiredAcks:     1,
+		IdempotentWrites: true,
+		CompressionCodec: int(sarama.CompressionZSTD),
+	}
+	config, err := k.newConfig()
+	require.NoError(t, err)
+	require.NoError(t, config.Validate())
+	require.True(t, config.Producer.Idempotent)
+	require.Equal(t, sarama.WaitForAll, config.Producer.RequiredAcks)
+	require.Equal(t, sarama.V2_1_0_0, config.Version)
+
+	k = &Kafka{
+		Version:          "0.10.2.0",
+		CompressionCodec: int(sarama.CompressionZSTD),
+	}
+	_, err = k.newConfig()
+	require.Error(t, err)
+
+	k = &Kafka{
+		Version: "1.0.0",
+		Headers: map[string]string{"source": "telegraf"},
+	}
+	config, err = k.newConfig()
+	require.NoError(t, err)
+	require.Equal(t, sarama.V1_0_0_0, config.Version)
+}
+
+func TestNewConfig_proxy(t *testing.T) {
+	k := &Kafka{}
+	k.Socks5ProxyAddress = "127.0.0.1:1080"
+	config, err := k.newConfig()
+	require.NoError(t, err)
+	require.NoError(t, config.Validate())
+	require.True(t, config.Net.Proxy.Enable)
+	require.NotNil(t, config.Net.Proxy.Dialer)
+}
+
+func TestNewConfig_sasl(t *testing.T) {
+	k := &Kafka{
+		SASLUsername:  "kafka",
+		SASLPassword:  "secret",
+		SASLMechanism: "SCRAM-SHA-512",
+	}
+	config, err := k.newConfig()
+	require.NoError(t, err)
+	require.True(t, config.Net.SASL.Enable)
+	require.Equal(t, sarama.SASLMechanism(sarama.SASLTypeSCRAMSHA512), config.Net.SASL.Mechanism)
+	require.NotNil(t, config.Net.SASL.SCRAMClientGeneratorFunc())
+
+	k = &Kafka{
+		SASLMechanism:   "OAUTHBEARER",
+		SASLAccessToken: "token",
+	}
+	config, err = k.newConfig()
+	require.NoError(t, err)
+	token, err := config.Net.SASL.TokenProvider.Token()
+	require.NoError(t, err)
+	require.Equal(t, "token", token.Token)
+
+	k = &Kafka{SASLMechanism: "OAUTHBEARER"}
+	_, err = k.newConfig()
+	require.Error(t, err)
+
+	k = &Kafka{SASLMechanism: "GSSAPI"}
+	_, err = k.newConfig()
+	require.Error(t, err)
+}
@@ -0,0 +1,205 @@
+package loki
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/metric"
+	"github.com/stretchr/testify/require"
+)
+
+func getMetric(tags map[string]string, fields map[string]interface{}, ts int64) telegraf.Metric {
+	m, err := metric.New("cpu", tags, fields, time.Unix(0, ts))
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func newLoki(url string) *Loki {
+	return &Loki{
+		URL:            url,
+		MaxLabels:      defaultMaxLabels,
+		MaxLabelValues: 1000,
+	}
+}
+
+// pushServer decodes the push requests received.
+func pushServer(t *testing.T, requests *[]Request, status int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			gr, err := gzip.NewReader(r.Body)
+			require.NoError(t, err)
+			body = gr
+		}
+		var req Request
+		require.NoError(t, json.NewDecoder(body).Decode(&req))
+		*requests = append(*requests, req)
+		w.WriteHeader(status)
+	}))
+}
+
+func TestWrite(t *testing.T) {
+	var requests []Request
+	var header http.Header
+	ts := pushServer(t, &requests, http.StatusNoContent)
+	defer ts.Close()
+	handler := ts.Config.Handler
+	ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		header = r.Header
+		handler.ServeHTTP(w, r)
+	})
+
+	l := newLoki(ts.URL)
+	l.TenantID = "tenant"
+	l.Username = "user"
+	l.Password = "secret"
+	l.ContentEncoding = "gzip"
+	require.NoError(t, l.Connect())
+
+	err := l.Write([]telegraf.Metric{
+		getMetric(map[string]string{"host": "a"}, map[string]interface{}{"usage": 42.5}, 2),
+		getMetric(map[string]string{"host": "b"}, map[string]interface{}{"usage": 1.0}, 1),
+		getMetric(map[string]string{"host": "a"}, map[string]interface{}{"usage": 40.0, "name": "a b"}, 1),
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, "tenant", header.Get("X-Scope-OrgID"))
+	require.Equal(t, "gzip", header.Get("Content-Encoding"))
+	user, password, ok := (&http.Request{Header: header}).BasicAuth()
+	require.True(t, ok)
+	require.Equal(t, "user", user)
+	require.Equal(t, "secret", password)
+
+	require.Len(t, requests, 1)
+	require.Equal(t, []*Stream{
+		{
+			Labels: map[string]string{"measurement": "cpu", "host": "a"},
+			Values: [][2]string{{"1", `name="a b" usage=40`}, {"2", "usage=42.5"}},
+		},
+		{
+			Labels: map[string]string{"measurement": "cpu", "host": "b"},
+			Values: [][2]string{{"1", "usage=1"}},
+		},
+	}, requests[0].Streams)
+}
+
+func TestWriteJSONLine(t *testing.T) {
+	var requests []Request
+	ts := pushServer(t, &requests, http.StatusNoContent)
+	defer ts.Close()
+
+	l := newLoki(ts.URL)
+	l.LineFormat = "json"
+	l.LabelTags = []string{"host"}
+	require.NoError(t, l.Connect())
+
+	err := l.Write([]telegraf.Metric{
+		getMetric(map[string]string{"host": "a", "cpu": "cpu0"}, map[string]interface{}{"usage": 42.5}, 1),
+	})
+	require.NoError(t, err)
+
+	require.Len(t, requests, 1)
+	require.Len(t, requests[0].Streams, 1)
+	stream := requests[0].Streams[0]
+	require.Equal(t, map[string]string{"measurement": "cpu", "host": "a"}, stream.Labels)
+	require.JSONEq(t, `{"cpu":"cpu0","usage":42.5}`, stream.Values[0][1])
+}
+
+func TestLabelLimits(t *testing.T) {
+	l := newLoki("")
+	l.MaxLabels = 2
+	l.MaxLabelValues = 1
+	require.NoError(t, l.Connect())
+
+	tags := map[string]string{"a.b": "1", "c": "2", "d": "3"}
+	labels, lineTags := l.labels(getMetric(tags, map[string]interface{}{"v": 1}, 0))
+	require.Equal(t, map[string]string{"measurement": "cpu", "a_b": "1"}, labels)
+	require.Equal(t, map[string]string{"c": "2", "d": "3"}, lineTags)
+
+	// A new value of a label beyond max_label_values is written in the line.
+	tags = map[string]string{"a.b": "2"}
+	labels, lineTags = l.labels(getMetric(tags, map[string]interface{}{"v": 1}, 0))
+	require.Equal(t, map[string]string{"measurement": "cpu"}, labels)
+	require.Equal(t, map[string]string{"a.b": "2"}, lineTags)
+
+	require.Equal(t, "_1abc", labelName("1abc"))
+}
+
+func TestWriteBatchSize(t *testing.T) {
+	var requests []Request
+	ts := pushServer(t, &requests, http.StatusNoContent)
+	defer ts.Close()
+
+	l := newLoki(ts.URL)
+	l.BatchSize = 2
+	require.NoError(t, l.Connect())
+
+	var metrics []telegraf.Metric
+	for i := 0; i < 5; i++ {
+		metrics = append(metrics, getMetric(nil, map[string]interface{}{"v": i}, int64(i)))
+	}
+	require.NoError(t, l.Write(metrics))
+
+	require.Len(t, requests, 3)
+	require.Len(t, requests[0].Streams[0].Values, 2)
+	require.Len(t, requests[2].Streams[0].Values, 1)
+}
+
+func TestWriteMaxEntryAge(t *testing.T) {
+	var requests []Request
+	ts := pushServer(t, &requests, http.StatusNoContent)
+	defer ts.Close()
+
+	l := newLoki(ts.URL)
+	l.MaxEntryAge.Duration = time.Hour
+	require.NoError(t, l.Connect())
+
+	now := time.Now().UnixNano()
+	require.NoError(t, l.Write([]telegraf.Metric{
+		getMetric(nil, map[string]interface{}{"v": 1}, 0),
+		getMetric(nil, map[string]interface{}{"v": 2}, now),
+	}))
+
+	require.Len(t, requests, 1)
+	require.Len(t, requests[0].Streams[0].Values, 1)
+	require.Equal(t, "v=2", requests[0].Streams[0].Values[0][1])
+}
+
+func TestWriteStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		err    bool
+	}{
+		{name: "bad request is dropped", status: http.StatusBadRequest},
+		{name: "server error", status: http.StatusInternalServerError, err: true},
+		{name: "rate limited", status: http.StatusTooManyRequests, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests []Request
+			ts := pushServer(t, &requests, tt.status)
+			defer ts.Close()
+
+			l := newLoki(ts.URL)
+			l.Token = "token"
+			require.NoError(t, l.Connect())
+
+			err := l.Write([]telegraf.Metric{getMetric(nil, map[string]interface{}{"v": 1}, 0)})
+			if tt.err {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
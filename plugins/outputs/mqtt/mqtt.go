@@ -2,25 +2,33 @@ package mqtt
 
 import (
 	"fmt"
+	"log"
 	"strings"
 	"sync"
+	"text/template"
 	"time"
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/proxy"
 	"github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers"
-
-	paho "github.com/eclipse/paho.mqtt.golang"
 )
 
 var sampleConfig = `
   servers = ["localhost:1883"] # required.
 
-  ## MQTT outputs send metrics to this topic format
+  ## MQTT protocol version: "3.1.1" or "5".
+  # protocol = "3.1.1"
+
+  ## Template of the topics of the metrics, using the Go text/template syntax
+  ## with the .Name of the metric and its .Tag "name" values, such as
+  ## "telegraf/{{ .Tag \"host\" }}/{{ .Name }}".  When unset, the metrics are
+  ## sent to this topic format
   ##    "<topic_prefix>/<hostname>/<pluginname>/"
   ##   ex: prefix/web01.example.com/mem
+  # topic = ""
   topic_prefix = "telegraf"
 
   ## QoS policy for messages
@@ -29,6 +37,10 @@ var sampleConfig = `
   ##   2 = exactly once
   # qos = 2
 
+  ## Set the retain flag of the messages, for the brokers to send the last
+  ## message of the topics to the new subscribers.
+  # retain = false
+
   ## username and password to connect MQTT server.
   # username = "telegraf"
   # password = "metricsmetricsmetricsmetrics"
@@ -36,9 +48,19 @@ var sampleConfig = `
   ## client ID, if not set a random ID is generated
   # client_id = ""
 
+  ## Resume the session of the client_id when reconnecting, for the broker
+  ## to keep the messages in flight of the QoS 1 and 2.  With MQTT 5, the
+  ## broker keeps the session for the session_expiry_interval after the
+  ## connection is lost.
+  # persistent_session = false
+  # session_expiry_interval = "1h"
+
   ## Timeout for write operations. default: 5s
   # timeout = "5s"
 
+  ## Interval of the keep alive pings.
+  # keep_alive = "30s"
+
   ## Optional TLS Config
   # tls_ca = "/etc/telegraf/ca.pem"
   # tls_cert = "/etc/telegraf/cert.pem"
@@ -46,10 +68,23 @@ var sampleConfig = `
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = false
 
+  ## Optional SOCKS5 proxy of the connections to the brokers.
+  # socks5_proxy_address = "127.0.0.1:1080"
+  # socks5_proxy_username = ""
+  # socks5_proxy_password = ""
+
   ## When true, metrics will be sent in one MQTT message per flush.  Otherwise,
   ## metrics are written one metric per MQTT message.
   # batch = false
 
+  ## Properties of the messages, with MQTT 5.
+  # [outputs.mqtt.v5]
+  #   content_type = "text/plain"
+  #   response_topic = ""
+  #   message_expiry = "0s"
+  #   [outputs.mqtt.v5.user_properties]
+  #     source = "telegraf"
+
   ## Data format to output.
   ## Each data format has its own unique set of configuration options, read
   ## more about them here:
@@ -57,20 +92,48 @@ var sampleConfig = `
   data_format = "influx"
 `
 
+const (
+	protocolV311 = "3.1.1"
+	protocolV5   = "5"
+)
+
+// client publishes the messages to the brokers.
+type client interface {
+	connect() error
+	publish(topic string, body []byte) error
+	close() error
+}
+
+// V5Properties are the properties of the messages published with MQTT 5.
+type V5Properties struct {
+	ContentType    string            `toml:"content_type"`
+	ResponseTopic  string            `toml:"response_topic"`
+	MessageExpiry  internal.Duration `toml:"message_expiry"`
+	UserProperties map[string]string `toml:"user_properties"`
+}
+
 type MQTT struct {
-	Servers     []string `toml:"servers"`
-	Username    string
-	Password    string
-	Database    string
-	Timeout     internal.Duration
-	TopicPrefix string
-	QoS         int    `toml:"qos"`
-	ClientID    string `toml:"client_id"`
+	Servers           []string `toml:"servers"`
+	Protocol          string   `toml:"protocol"`
+	Username          string
+	Password          string
+	Database          string
+	Timeout           internal.Duration
+	KeepAlive         internal.Duration `toml:"keep_alive"`
+	Topic             string            `toml:"topic"`
+	TopicPrefix       string
+	QoS               int               `toml:"qos"`
+	Retain            bool              `toml:"retain"`
+	ClientID          string            `toml:"client_id"`
+	PersistentSession bool              `toml:"persistent_session"`
+	SessionExpiry     internal.Duration `toml:"session_expiry_interval"`
 	tls.ClientConfig
-	BatchMessage bool `toml:"batch"`
+	proxy.Socks5ProxyConfig
+	BatchMessage bool         `toml:"batch"`
+	V5           V5Properties `toml:"v5"`
 
-	client paho.Client
-	opts   *paho.ClientOptions
+	client        client
+	topicTemplate *template.Template
 
 	serializer serializers.Serializer
 
@@ -84,18 +147,50 @@ func (m *MQTT) Connect() error {
 	if m.QoS > 2 || m.QoS < 0 {
 		return fmt.Errorf("MQTT Output, invalid QoS value: %d", m.QoS)
 	}
+	if len(m.Servers) == 0 {
+		return fmt.Errorf("could not get host infomations")
+	}
+	if m.Timeout.Duration < time.Second {
+		m.Timeout.Duration = 5 * time.Second
+	}
+	if m.ClientID == "" {
+		if m.PersistentSession {
+			return fmt.Errorf("client_id is required with persistent_session")
+		}
+		m.ClientID = "Telegraf-Output-" + internal.RandomString(5)
+	}
 
-	m.opts, err = m.createOpts()
+	m.topicTemplate = nil
+	if m.Topic != "" {
+		m.topicTemplate, err = template.New("topic").Parse(m.Topic)
+		if err != nil {
+			return fmt.Errorf("invalid topic template: %v", err)
+		}
+	}
+
+	tlsCfg, err := m.ClientConfig.TLSConfig()
 	if err != nil {
 		return err
 	}
 
-	m.client = paho.NewClient(m.opts)
-	if token := m.client.Connect(); token.Wait() && token.Error() != nil {
-		return token.Error()
+	dialer, err := m.Socks5ProxyConfig.Dialer(m.Timeout.Duration)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	switch m.Protocol {
+	case "", protocolV311:
+		m.client = newV3Client(m, tlsCfg, dialer)
+	case protocolV5:
+		if m.KeepAlive.Duration < time.Second {
+			return fmt.Errorf("keep_alive must be at least 1s with MQTT 5")
+		}
+		m.client = newV5Client(m, tlsCfg, dialer)
+	default:
+		return fmt.Errorf("unknown protocol %q", m.Protocol)
+	}
+
+	return m.client.connect()
 }
 
 func (m *MQTT) SetSerializer(serializer serializers.Serializer) {
@@ -103,8 +198,8 @@ func (m *MQTT) SetSerializer(serializer serializers.Serializer) {
 }
 
 func (m *MQTT) Close() error {
-	if m.client.IsConnected() {
-		m.client.Disconnect(20)
+	if m.client != nil {
+		return m.client.close()
 	}
 	return nil
 }
@@ -128,21 +223,21 @@ func (m *MQTT) Write(metrics []telegraf.Metric) error {
 		hostname = ""
 	}
 
+	// The batches of the topics, in the order of the topics.
+	var topics []string
 	metricsmap := make(map[string][]telegraf.Metric)
 
 	for _, metric := range metrics {
-		var t []string
-		if m.TopicPrefix != "" {
-			t = append(t, m.TopicPrefix)
-		}
-		if hostname != "" {
-			t = append(t, hostname)
+		topic, err := m.topic(metric, hostname)
+		if err != nil {
+			log.Printf("E! [outputs.mqtt] dropping metric %s: %v", metric.Name(), err)
+			continue
 		}
 
-		t = append(t, metric.Name())
-		topic := strings.Join(t, "/")
-
 		if m.BatchMessage {
+			if _, ok := metricsmap[topic]; !ok {
+				topics = append(topics, topic)
+			}
 			metricsmap[topic] = append(metricsmap[topic], metric)
 		} else {
 			buf, err := m.serializer.Serialize(metric)
@@ -151,20 +246,20 @@ func (m *MQTT) Write(metrics []telegraf.Metric) error {
 				return err
 			}
 
-			err = m.publish(topic, buf)
+			err = m.client.publish(topic, buf)
 			if err != nil {
 				return fmt.Errorf("Could not write to MQTT server, %s", err)
 			}
 		}
 	}
 
-	for key := range metricsmap {
+	for _, key := range topics {
 		buf, err := m.serializer.SerializeBatch(metricsmap[key])
 
 		if err != nil {
 			return err
 		}
-		publisherr := m.publish(key, buf)
+		publisherr := m.client.publish(key, buf)
 		if publisherr != nil {
 			return fmt.Errorf("Could not write to MQTT server, %s", publisherr)
 		}
@@ -173,64 +268,40 @@ func (m *MQTT) Write(metrics []telegraf.Metric) error {
 	return nil
 }
 
-func (m *MQTT) publish(topic string, body []byte) error {
-	token := m.client.Publish(topic, byte(m.QoS), false, body)
-	token.WaitTimeout(m.Timeout.Duration)
-	if token.Error() != nil {
-		return token.Error()
-	}
-	return nil
-}
-
-func (m *MQTT) createOpts() (*paho.ClientOptions, error) {
-	opts := paho.NewClientOptions()
-	opts.KeepAlive = 0 * time.Second
-
-	if m.Timeout.Duration < time.Second {
-		m.Timeout.Duration = 5 * time.Second
-	}
-	opts.WriteTimeout = m.Timeout.Duration
+// topic returns the topic of a metric, rendered from the topic template, or
+// made of the topic prefix, the hostname and the metric name.
+func (m *MQTT) topic(metric telegraf.Metric, hostname string) (string, error) {
+	if m.topicTemplate == nil {
+		var t []string
+		if m.TopicPrefix != "" {
+			t = append(t, m.TopicPrefix)
+		}
+		if hostname != "" {
+			t = append(t, hostname)
+		}
 
-	if m.ClientID != "" {
-		opts.SetClientID(m.ClientID)
-	} else {
-		opts.SetClientID("Telegraf-Output-" + internal.RandomString(5))
+		t = append(t, metric.Name())
+		return strings.Join(t, "/"), nil
 	}
 
-	tlsCfg, err := m.ClientConfig.TLSConfig()
+	topic, err := internal.ExecuteTemplate(m.topicTemplate, metric)
 	if err != nil {
-		return nil, err
-	}
-
-	scheme := "tcp"
-	if tlsCfg != nil {
-		scheme = "ssl"
-		opts.SetTLSConfig(tlsCfg)
-	}
-
-	user := m.Username
-	if user != "" {
-		opts.SetUsername(user)
-	}
-	password := m.Password
-	if password != "" {
-		opts.SetPassword(password)
+		return "", err
 	}
-
-	if len(m.Servers) == 0 {
-		return opts, fmt.Errorf("could not get host infomations")
+	if topic == "" {
+		return "", fmt.Errorf("empty topic")
 	}
-	for _, host := range m.Servers {
-		server := fmt.Sprintf("%s://%s", scheme, host)
-
-		opts.AddBroker(server)
+	if strings.ContainsAny(topic, "+#") {
+		return "", fmt.Errorf("topic %q has wildcards", topic)
 	}
-	opts.SetAutoReconnect(true)
-	return opts, nil
+	return topic, nil
 }
 
 func init() {
 	outputs.Add("mqtt", func() telegraf.Output {
-		return &MQTT{}
+		return &MQTT{
+			KeepAlive:     internal.Duration{Duration: 30 * time.Second},
+			SessionExpiry: internal.Duration{Duration: time.Hour},
+		}
 	})
 }
@@ -1,8 +1,17 @@
 package mqtt
 
 import (
+	"io"
+	"io/ioutil"
+	"net"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/eclipse/paho.golang/packets"
+	packetsv3 "github.com/eclipse/paho.mqtt.golang/packets"
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
 	"github.com/influxdata/telegraf/plugins/serializers"
 	"github.com/influxdata/telegraf/testutil"
 
@@ -29,3 +38,268 @@ func TestConnectAndWrite(t *testing.T) {
 	err = m.Write(testutil.MockMetrics())
 	require.NoError(t, err)
 }
+
+// fakeBroker is a MQTT 5 broker recording the connections and messages,
+// dropping the connections after a message when drop is set.
+type fakeBroker struct {
+	listener net.Listener
+
+	sync.Mutex
+	connects []*packets.Connect
+	messages []*packets.Publish
+	drop     bool
+}
+
+func newFakeBroker(t *testing.T) *fakeBroker {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	b := &fakeBroker{listener: listener}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go b.serve(conn)
+		}
+	}()
+	return b
+}
+
+func (b *fakeBroker) serve(conn net.Conn) {
+	defer conn.Close()
+	for {
+		cp, err := packets.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+
+		switch p := cp.Content.(type) {
+		case *packets.Connect:
+			b.Lock()
+			b.connects = append(b.connects, p)
+			b.Unlock()
+			connack := &packets.Connack{Properties: &packets.Properties{}}
+			if _, err := connack.WriteTo(conn); err != nil {
+				return
+			}
+		case *packets.Publish:
+			// The retain flag is only in the fixed header
+			p.Retain = cp.Flags&0x1 == 1
+			b.Lock()
+			b.messages = append(b.messages, p)
+			drop := b.drop
+			b.Unlock()
+			if p.QoS == 1 {
+				puback := &packets.Puback{PacketID: p.PacketID, Properties: &packets.Properties{}}
+				if _, err := puback.WriteTo(conn); err != nil {
+					return
+				}
+			}
+			if drop {
+				return
+			}
+		case *packets.Pingreq:
+			if _, err := packets.NewControlPacket(packets.PINGRESP).WriteTo(conn); err != nil {
+				return
+			}
+		case *packets.Disconnect:
+			return
+		}
+	}
+}
+
+func newV5MQTT(b *fakeBroker) *MQTT {
+	s, _ := serializers.NewInfluxSerializer()
+	return &MQTT{
+		Servers:           []string{b.listener.Addr().String()},
+		Protocol:          protocolV5,
+		Timeout:           internal.Duration{Duration: 5 * time.Second},
+		KeepAlive:         internal.Duration{Duration: 30 * time.Second},
+		Topic:             `telegraf/{{ .Tag "host" }}/{{ .Name }}`,
+		QoS:               1,
+		ClientID:          "telegraf",
+		PersistentSession: true,
+		SessionExpiry:     internal.Duration{Duration: time.Hour},
+		serializer:        s,
+	}
+}
+
+func TestWriteV5(t *testing.T) {
+	b := newFakeBroker(t)
+	defer b.listener.Close()
+
+	m := newV5MQTT(b)
+	m.Retain = true
+	m.V5 = V5Properties{
+		ContentType:    "text/plain",
+		MessageExpiry:  internal.Duration{Duration: time.Minute},
+		UserProperties: map[string]string{"source": "telegraf"},
+	}
+	require.NoError(t, m.Connect())
+	defer m.Close()
+
+	require.NoError(t, m.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 0)),
+	}))
+
+	b.Lock()
+	defer b.Unlock()
+	require.Len(t, b.connects, 1)
+	require.Equal(t, "telegraf", b.connects[0].ClientID)
+	require.False(t, b.connects[0].CleanStart)
+	require.Equal(t, uint32(3600), *b.connects[0].Properties.SessionExpiryInterval)
+
+	require.Len(t, b.messages, 1)
+	message := b.messages[0]
+	require.Equal(t, "telegraf/a/cpu", message.Topic)
+	require.Equal(t, byte(1), message.QoS)
+	require.True(t, message.Retain)
+	require.Equal(t, "cpu,host=a usage=42.5 1500000000000000000\n", string(message.Payload))
+	require.Equal(t, "text/plain", message.Properties.ContentType)
+	require.Equal(t, uint32(60), *message.Properties.MessageExpiry)
+	require.Equal(t, []packets.User{{Key: "source", Value: "telegraf"}}, message.Properties.User)
+}
+
+func TestWriteV5Batch(t *testing.T) {
+	b := newFakeBroker(t)
+	defer b.listener.Close()
+
+	m := newV5MQTT(b)
+	m.BatchMessage = true
+	require.NoError(t, m.Connect())
+	defer m.Close()
+
+	require.NoError(t, m.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 0)),
+		testutil.MustMetric("mem", map[string]string{"host": "a"}, map[string]interface{}{"used": 1.5}, time.Unix(1500000000, 0)),
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 43.5}, time.Unix(1500000000, 0)),
+	}))
+
+	b.Lock()
+	defer b.Unlock()
+	require.Len(t, b.messages, 2)
+	require.Equal(t, "telegraf/a/cpu", b.messages[0].Topic)
+	require.Equal(t,
+		"cpu,host=a usage=42.5 1500000000000000000\ncpu,host=a usage=43.5 1500000000000000000\n",
+		string(b.messages[0].Payload))
+	require.Equal(t, "telegraf/a/mem", b.messages[1].Topic)
+}
+
+func TestWriteV5Reconnect(t *testing.T) {
+	b := newFakeBroker(t)
+	defer b.listener.Close()
+	b.drop = true
+
+	m := newV5MQTT(b)
+	require.NoError(t, m.Connect())
+	defer m.Close()
+
+	metrics := []telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 0)),
+	}
+	require.NoError(t, m.Write(metrics))
+
+	// Wait for the lost connection to be noticed
+	c := m.client.(*v5Client)
+	for i := 0; i < 100; i++ {
+		c.Lock()
+		lost := c.client == nil
+		c.Unlock()
+		if lost {
+			break
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	b.Lock()
+	b.drop = false
+	b.Unlock()
+	require.NoError(t, m.Write(metrics))
+
+	b.Lock()
+	defer b.Unlock()
+	require.Len(t, b.connects, 2)
+	require.Len(t, b.messages, 2)
+}
+
+func TestWriteV5Proxy(t *testing.T) {
+	b := newFakeBroker(t)
+	defer b.listener.Close()
+	proxy, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer proxy.Close()
+	go testutil.ServeSocks5(t, proxy, "", "")
+
+	m := newV5MQTT(b)
+	m.Socks5ProxyAddress = proxy.Addr().String()
+	require.NoError(t, m.Connect())
+	defer m.Close()
+
+	require.NoError(t, m.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 0)),
+	}))
+	b.Lock()
+	require.Len(t, b.messages, 1)
+	b.Unlock()
+}
+
+func TestConnectV3Proxy(t *testing.T) {
+	broker, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer broker.Close()
+	connects := make(chan *packetsv3.ConnectPacket, 1)
+	go func() {
+		conn, err := broker.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		cp, err := packetsv3.ReadPacket(conn)
+		require.NoError(t, err)
+		connects <- cp.(*packetsv3.ConnectPacket)
+		packetsv3.NewControlPacket(packetsv3.Connack).Write(conn)
+		io.Copy(ioutil.Discard, conn)
+	}()
+	proxy, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer proxy.Close()
+	go testutil.ServeSocks5(t, proxy, "", "")
+
+	s, _ := serializers.NewInfluxSerializer()
+	m := &MQTT{
+		Servers:    []string{broker.Addr().String()},
+		Timeout:    internal.Duration{Duration: 5 * time.Second},
+		ClientID:   "telegraf",
+		serializer: s,
+	}
+	m.Socks5ProxyAddress = proxy.Addr().String()
+	require.NoError(t, m.Connect())
+	defer m.Close()
+	require.Equal(t, "telegraf", (<-connects).ClientIdentifier)
+}
+
+func TestTopic(t *testing.T) {
+	m := &MQTT{
+		Servers:  []string{"localhost:1883"},
+		Protocol: "4",
+		Topic:    `sensors/{{ .Tag "site" }}/{{ .Name }}`,
+	}
+	require.Error(t, m.Connect())
+
+	metric := testutil.MustMetric("temperature", map[string]string{"site": "lab"}, map[string]interface{}{"value": 21.5}, time.Unix(1500000000, 0))
+	topic, err := m.topic(metric, "")
+	require.NoError(t, err)
+	require.Equal(t, "sensors/lab/temperature", topic)
+
+	// The wildcards are not allowed in the topics
+	metric = testutil.MustMetric("temperature", map[string]string{"site": "#"}, map[string]interface{}{"value": 21.5}, time.Unix(1500000000, 0))
+	_, err = m.topic(metric, "")
+	require.Error(t, err)
+
+	// Without template, the topic is made of the prefix, hostname and name
+	m = &MQTT{TopicPrefix: "telegraf"}
+	topic, err = m.topic(metric, "web01")
+	require.NoError(t, err)
+	require.Equal(t, "telegraf/web01/temperature", topic)
+}
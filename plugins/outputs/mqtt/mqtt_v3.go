@@ -0,0 +1,87 @@
+package mqtt
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/influxdata/telegraf/internal/proxy"
+	netproxy "golang.org/x/net/proxy"
+)
+
+// v3Client publishes with MQTT 3.1.1, reconnecting automatically.
+type v3Client struct {
+	client  paho.Client
+	qos     byte
+	retain  bool
+	timeout time.Duration
+}
+
+func newV3Client(m *MQTT, tlsCfg *tls.Config, dialer netproxy.Dialer) *v3Client {
+	opts := paho.NewClientOptions()
+	opts.SetKeepAlive(m.KeepAlive.Duration)
+	opts.WriteTimeout = m.Timeout.Duration
+	opts.SetClientID(m.ClientID)
+	// The messages in flight are sent again when the session is resumed.
+	opts.SetCleanSession(!m.PersistentSession)
+
+	scheme := "tcp"
+	if tlsCfg != nil {
+		scheme = "ssl"
+		opts.SetTLSConfig(tlsCfg)
+	}
+
+	user := m.Username
+	if user != "" {
+		opts.SetUsername(user)
+	}
+	password := m.Password
+	if password != "" {
+		opts.SetPassword(password)
+	}
+
+	for _, host := range m.Servers {
+		server := fmt.Sprintf("%s://%s", scheme, host)
+
+		opts.AddBroker(server)
+	}
+	opts.SetAutoReconnect(true)
+
+	if m.Socks5ProxyConfig.Configured() {
+		opts.SetCustomOpenConnectionFn(func(uri *url.URL, _ paho.ClientOptions) (net.Conn, error) {
+			return proxy.DialTLS(dialer, "tcp", uri.Host, tlsCfg, m.Timeout.Duration)
+		})
+	}
+
+	return &v3Client{
+		client:  paho.NewClient(opts),
+		qos:     byte(m.QoS),
+		retain:  m.Retain,
+		timeout: m.Timeout.Duration,
+	}
+}
+
+func (c *v3Client) connect() error {
+	if token := c.client.Connect(); token.Wait() && token.Error() != nil {
+		return token.Error()
+	}
+	return nil
+}
+
+func (c *v3Client) publish(topic string, body []byte) error {
+	token := c.client.Publish(topic, c.qos, c.retain, body)
+	if !token.WaitTimeout(c.timeout) {
+		return fmt.Errorf("timeout publishing to %s", topic)
+	}
+	return token.Error()
+}
+
+func (c *v3Client) close() error {
+	if c.client.IsConnected() {
+		c.client.Disconnect(20)
+	}
+	return nil
+}
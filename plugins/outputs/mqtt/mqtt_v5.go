@@ -0,0 +1,201 @@
+package mqtt
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	paho "github.com/eclipse/paho.golang/paho"
+	"github.com/influxdata/telegraf/internal/proxy"
+	netproxy "golang.org/x/net/proxy"
+)
+
+// v5Client publishes with MQTT 5, connecting again to the brokers with the
+// next message once the connection is lost.
+type v5Client struct {
+	servers   []string
+	tlsConfig *tls.Config
+	dialer    netproxy.Dialer
+	timeout   time.Duration
+	connect5  *paho.Connect
+	qos       byte
+	retain    bool
+	props     *paho.PublishProperties
+
+	sync.Mutex
+	client *paho.Client
+	conn   net.Conn
+}
+
+func newV5Client(m *MQTT, tlsCfg *tls.Config, dialer netproxy.Dialer) *v5Client {
+	cp := &paho.Connect{
+		ClientID:   m.ClientID,
+		KeepAlive:  uint16(m.KeepAlive.Duration.Seconds()),
+		CleanStart: !m.PersistentSession,
+		Properties: &paho.ConnectProperties{},
+	}
+	if m.Username != "" {
+		cp.Username = m.Username
+		cp.UsernameFlag = true
+	}
+	if m.Password != "" {
+		cp.Password = []byte(m.Password)
+		cp.PasswordFlag = true
+	}
+	if m.PersistentSession {
+		expiry := uint32(m.SessionExpiry.Duration.Seconds())
+		cp.Properties.SessionExpiryInterval = &expiry
+	}
+
+	props := &paho.PublishProperties{
+		ContentType:   m.V5.ContentType,
+		ResponseTopic: m.V5.ResponseTopic,
+	}
+	if m.V5.MessageExpiry.Duration > 0 {
+		expiry := uint32(m.V5.MessageExpiry.Duration.Seconds())
+		props.MessageExpiry = &expiry
+	}
+	keys := make([]string, 0, len(m.V5.UserProperties))
+	for key := range m.V5.UserProperties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		props.User.Add(key, m.V5.UserProperties[key])
+	}
+
+	return &v5Client{
+		servers:   m.Servers,
+		tlsConfig: tlsCfg,
+		dialer:    dialer,
+		timeout:   m.Timeout.Duration,
+		connect5:  cp,
+		qos:       byte(m.QoS),
+		retain:    m.Retain,
+		props:     props,
+	}
+}
+
+// connect connects to the first available broker.
+func (c *v5Client) connect() error {
+	c.Lock()
+	defer c.Unlock()
+
+	var err error
+	for _, server := range c.servers {
+		if err = c.connectServer(server); err == nil {
+			return nil
+		}
+		log.Printf("W! [outputs.mqtt] connecting to %s failed: %v", server, err)
+	}
+	return err
+}
+
+func (c *v5Client) connectServer(server string) error {
+	// The scheme of the server is optional, as with MQTT 3.1.1.
+	if i := strings.Index(server, "://"); i >= 0 {
+		server = server[i+3:]
+	}
+
+	conn, err := c.dial(server)
+	if err != nil {
+		return err
+	}
+
+	client := paho.NewClient(paho.ClientConfig{
+		ClientID: c.connect5.ClientID,
+		Conn:     conn,
+		OnClientError: func(err error) {
+			log.Printf("E! [outputs.mqtt] connection to %s lost: %v", server, err)
+			c.disconnected(conn)
+		},
+		OnServerDisconnect: func(d *paho.Disconnect) {
+			log.Printf("E! [outputs.mqtt] disconnected by %s with reason code %d", server, d.ReasonCode)
+			c.disconnected(conn)
+		},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	connack, err := client.Connect(ctx, c.connect5)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	if !c.connect5.CleanStart && !connack.SessionPresent {
+		log.Printf("I! [outputs.mqtt] no session of client %s on %s, starting a new one", c.connect5.ClientID, server)
+	}
+
+	c.client = client
+	c.conn = conn
+	return nil
+}
+
+// dial connects to the broker, directly or through the proxy of the dialer,
+// the TLS handshake being done once connected.
+func (c *v5Client) dial(server string) (net.Conn, error) {
+	return proxy.DialTLS(c.dialer, "tcp", server, c.tlsConfig, c.timeout)
+}
+
+// disconnected forgets the client of a lost connection, the next message
+// connecting again.
+func (c *v5Client) disconnected(conn net.Conn) {
+	c.Lock()
+	defer c.Unlock()
+	if c.conn == conn {
+		c.conn.Close()
+		c.client = nil
+		c.conn = nil
+	}
+}
+
+func (c *v5Client) publish(topic string, body []byte) error {
+	c.Lock()
+	client, conn := c.client, c.conn
+	c.Unlock()
+	if client == nil {
+		if err := c.connect(); err != nil {
+			return err
+		}
+		c.Lock()
+		client, conn = c.client, c.conn
+		c.Unlock()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	response, err := client.Publish(ctx, &paho.Publish{
+		Topic:      topic,
+		QoS:        c.qos,
+		Retain:     c.retain,
+		Payload:    body,
+		Properties: c.props,
+	})
+	if err != nil {
+		c.disconnected(conn)
+		return err
+	}
+	if response != nil && response.ReasonCode >= 0x80 {
+		return fmt.Errorf("message to %s rejected with reason code %d", topic, response.ReasonCode)
+	}
+	return nil
+}
+
+func (c *v5Client) close() error {
+	c.Lock()
+	defer c.Unlock()
+	if c.client == nil {
+		return nil
+	}
+	err := c.client.Disconnect(&paho.Disconnect{ReasonCode: 0})
+	c.conn.Close()
+	c.client = nil
+	c.conn = nil
+	return err
+}
@@ -0,0 +1,263 @@
+package opentelemetry
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/influxdata/telegraf"
+
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+)
+
+const scopeName = "telegraf"
+
+// resourceMetrics converts the metrics into the OTLP metrics of a single
+// resource and scope, the data points of the metrics with the same name and
+// kind being grouped.
+func (o *OpenTelemetry) resourceMetrics(metrics []telegraf.Metric) []*metricspb.ResourceMetrics {
+	c := &converter{
+		monotonic: func(name string) bool {
+			return o.monotonicFilter != nil && o.monotonicFilter.Match(name)
+		},
+		index: make(map[string]*metricspb.Metric),
+	}
+	for _, m := range metrics {
+		c.add(m)
+	}
+
+	return []*metricspb.ResourceMetrics{
+		{
+			Resource: &resourcepb.Resource{Attributes: resourceAttributes(o.ResourceAttributes)},
+			ScopeMetrics: []*metricspb.ScopeMetrics{
+				{
+					Scope:   &commonpb.InstrumentationScope{Name: scopeName},
+					Metrics: c.metrics,
+				},
+			},
+		},
+	}
+}
+
+type converter struct {
+	monotonic func(name string) bool
+
+	metrics []*metricspb.Metric
+	index   map[string]*metricspb.Metric
+}
+
+func (c *converter) add(m telegraf.Metric) {
+	attributes := attributes(m)
+	timestamp := uint64(m.Time().UnixNano())
+
+	switch m.Type() {
+	case telegraf.Histogram:
+		c.addHistogram(m, attributes, timestamp)
+		return
+	case telegraf.Summary:
+		c.addSummary(m, attributes, timestamp)
+		return
+	}
+
+	for _, field := range m.FieldList() {
+		dp := numberDataPoint(field.Value)
+		if dp == nil {
+			continue
+		}
+		dp.Attributes = attributes
+		dp.TimeUnixNano = timestamp
+
+		name := metricName(m.Name(), field.Key)
+		if m.Type() == telegraf.Counter || c.monotonic(name) {
+			sum := c.metric(name, "sum", func() *metricspb.Metric {
+				return &metricspb.Metric{Name: name, Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+					AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+					IsMonotonic:            true,
+				}}}
+			}).GetSum()
+			sum.DataPoints = append(sum.DataPoints, dp)
+		} else {
+			gauge := c.metric(name, "gauge", func() *metricspb.Metric {
+				return &metricspb.Metric{Name: name, Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{}}}
+			}).GetGauge()
+			gauge.DataPoints = append(gauge.DataPoints, dp)
+		}
+	}
+}
+
+// addHistogram converts the cumulative bucket fields of a histogram, named
+// after their upper bound, into the explicit bounds and the counts of the
+// buckets.
+func (c *converter) addHistogram(m telegraf.Metric, attributes []*commonpb.KeyValue, timestamp uint64) {
+	dp := &metricspb.HistogramDataPoint{Attributes: attributes, TimeUnixNano: timestamp}
+
+	type bucket struct {
+		bound float64
+		count uint64
+	}
+	var buckets []bucket
+	for _, field := range m.FieldList() {
+		value, ok := floatValue(field.Value)
+		if !ok {
+			continue
+		}
+		switch field.Key {
+		case "count":
+			dp.Count = uint64(value)
+		case "sum":
+			dp.Sum = &value
+		case "min":
+			dp.Min = &value
+		case "max":
+			dp.Max = &value
+		default:
+			bound, err := strconv.ParseFloat(field.Key, 64)
+			if err != nil || math.IsInf(bound, 1) {
+				continue
+			}
+			buckets = append(buckets, bucket{bound: bound, count: uint64(value)})
+		}
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i].bound < buckets[j].bound })
+
+	var previous uint64
+	for _, b := range buckets {
+		dp.ExplicitBounds = append(dp.ExplicitBounds, b.bound)
+		dp.BucketCounts = append(dp.BucketCounts, b.count-previous)
+		previous = b.count
+	}
+	// The last bucket, up to +Inf, holds the remaining observations.
+	var remaining uint64
+	if dp.Count > previous {
+		remaining = dp.Count - previous
+	}
+	dp.BucketCounts = append(dp.BucketCounts, remaining)
+
+	histogram := c.metric(m.Name(), "histogram", func() *metricspb.Metric {
+		return &metricspb.Metric{Name: m.Name(), Data: &metricspb.Metric_Histogram{Histogram: &metricspb.Histogram{
+			AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+		}}}
+	}).GetHistogram()
+	histogram.DataPoints = append(histogram.DataPoints, dp)
+}
+
+// addSummary converts the quantile fields of a summary, named after their
+// quantile, into the quantile values.
+func (c *converter) addSummary(m telegraf.Metric, attributes []*commonpb.KeyValue, timestamp uint64) {
+	dp := &metricspb.SummaryDataPoint{Attributes: attributes, TimeUnixNano: timestamp}
+	for _, field := range m.FieldList() {
+		value, ok := floatValue(field.Value)
+		if !ok {
+			continue
+		}
+		switch field.Key {
+		case "count":
+			dp.Count = uint64(value)
+		case "sum":
+			dp.Sum = value
+		default:
+			quantile, err := strconv.ParseFloat(field.Key, 64)
+			if err != nil || quantile < 0 || quantile > 1 {
+				continue
+			}
+			dp.QuantileValues = append(dp.QuantileValues, &metricspb.SummaryDataPoint_ValueAtQuantile{
+				Quantile: quantile,
+				Value:    value,
+			})
+		}
+	}
+	sort.Slice(dp.QuantileValues, func(i, j int) bool {
+		return dp.QuantileValues[i].Quantile < dp.QuantileValues[j].Quantile
+	})
+
+	summary := c.metric(m.Name(), "summary", func() *metricspb.Metric {
+		return &metricspb.Metric{Name: m.Name(), Data: &metricspb.Metric_Summary{Summary: &metricspb.Summary{}}}
+	}).GetSummary()
+	summary.DataPoints = append(summary.DataPoints, dp)
+}
+
+// metric returns the metric of the name and kind, created if missing.
+func (c *converter) metric(name, kind string, create func() *metricspb.Metric) *metricspb.Metric {
+	key := kind + "\n" + name
+	if m, ok := c.index[key]; ok {
+		return m
+	}
+	m := create()
+	c.index[key] = m
+	c.metrics = append(c.metrics, m)
+	return m
+}
+
+// metricName returns the name of the metric of a field, the measurement name
+// for the value, gauge and counter fields.
+func metricName(measurement, field string) string {
+	switch field {
+	case "value", "gauge", "counter":
+		return measurement
+	}
+	return measurement + "_" + field
+}
+
+func numberDataPoint(value interface{}) *metricspb.NumberDataPoint {
+	switch v := value.(type) {
+	case float64:
+		return &metricspb.NumberDataPoint{Value: &metricspb.NumberDataPoint_AsDouble{AsDouble: v}}
+	case int64:
+		return &metricspb.NumberDataPoint{Value: &metricspb.NumberDataPoint_AsInt{AsInt: v}}
+	case uint64:
+		if v > math.MaxInt64 {
+			v = math.MaxInt64
+		}
+		return &metricspb.NumberDataPoint{Value: &metricspb.NumberDataPoint_AsInt{AsInt: int64(v)}}
+	case bool:
+		var i int64
+		if v {
+			i = 1
+		}
+		return &metricspb.NumberDataPoint{Value: &metricspb.NumberDataPoint_AsInt{AsInt: i}}
+	}
+	return nil
+}
+
+func floatValue(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+func attributes(m telegraf.Metric) []*commonpb.KeyValue {
+	var attributes []*commonpb.KeyValue
+	for _, tag := range m.TagList() {
+		attributes = append(attributes, stringAttribute(tag.Key, tag.Value))
+	}
+	return attributes
+}
+
+func resourceAttributes(values map[string]string) []*commonpb.KeyValue {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var attributes []*commonpb.KeyValue
+	for _, k := range keys {
+		attributes = append(attributes, stringAttribute(k, values[k]))
+	}
+	return attributes
+}
+
+func stringAttribute(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{
+		Key:   key,
+		Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}},
+	}
+}
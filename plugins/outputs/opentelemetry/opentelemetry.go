@@ -0,0 +1,216 @@
+package opentelemetry
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"log"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/filter"
+	"github.com/influxdata/telegraf/internal"
+	tlsint "github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/outputs"
+)
+
+const defaultServiceAddress = "localhost:4317"
+
+var sampleConfig = `
+  ## Address and port of the OTLP gRPC endpoint, such as an OpenTelemetry
+  ## Collector.
+  # service_address = "localhost:4317"
+
+  ## Timeout of an export.
+  # timeout = "5s"
+
+  ## Compression of the exports: "gzip" or "none".
+  # compression = "gzip"
+
+  ## Additional gRPC request metadata, such as the authentication headers.
+  # [outputs.opentelemetry.headers]
+  #   authorization = "Bearer token"
+
+  ## Attributes of the resource of the metrics.
+  # [outputs.opentelemetry.resource_attributes]
+  #   "service.name" = "telegraf"
+
+  ## Names of the fields exported as monotonic sums, globs being supported,
+  ## the names being the measurement and field names joined by an underscore.
+  ## The fields of the counters are always monotonic sums, and the other
+  ## fields not matching are gauges.
+  # monotonic_fields = ["*_total"]
+
+  ## Number of retries of an export failing because the endpoint is
+  ## unavailable or overloaded, with a delay doubling from retry_delay
+  ## between the attempts.  The metrics of the failed exports are kept in the
+  ## buffer of the output and exported again with the next flush.
+  # max_retries = 3
+  # retry_delay = "1s"
+
+  ## Use TLS, with the system certificate authorities unless tls_ca is set.
+  # tls_enable = false
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+`
+
+type OpenTelemetry struct {
+	ServiceAddress     string            `toml:"service_address"`
+	Timeout            internal.Duration `toml:"timeout"`
+	Compression        string            `toml:"compression"`
+	Headers            map[string]string `toml:"headers"`
+	ResourceAttributes map[string]string `toml:"resource_attributes"`
+	MonotonicFields    []string          `toml:"monotonic_fields"`
+	MaxRetries         int               `toml:"max_retries"`
+	RetryDelay         internal.Duration `toml:"retry_delay"`
+	TLSEnable          bool              `toml:"tls_enable"`
+	tlsint.ClientConfig
+
+	conn            *grpc.ClientConn
+	client          colmetricspb.MetricsServiceClient
+	monotonicFilter filter.Filter
+	callOptions     []grpc.CallOption
+
+	sleep func(time.Duration)
+}
+
+func (o *OpenTelemetry) SampleConfig() string {
+	return sampleConfig
+}
+
+func (o *OpenTelemetry) Description() string {
+	return "Send metrics to an OpenTelemetry Collector or endpoint using OTLP"
+}
+
+func (o *OpenTelemetry) Connect() error {
+	if o.ServiceAddress == "" {
+		o.ServiceAddress = defaultServiceAddress
+	}
+
+	switch o.Compression {
+	case "gzip":
+		o.callOptions = []grpc.CallOption{grpc.UseCompressor(gzip.Name)}
+	case "", "none":
+		o.callOptions = nil
+	default:
+		return fmt.Errorf("unknown compression %q", o.Compression)
+	}
+
+	var err error
+	o.monotonicFilter, err = filter.Compile(o.MonotonicFields)
+	if err != nil {
+		return err
+	}
+
+	tlsCfg, err := o.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+	if tlsCfg == nil && o.TLSEnable {
+		tlsCfg = &tls.Config{}
+	}
+
+	var dialOption grpc.DialOption
+	if tlsCfg != nil {
+		dialOption = grpc.WithTransportCredentials(credentials.NewTLS(tlsCfg))
+	} else {
+		dialOption = grpc.WithInsecure()
+	}
+	conn, err := grpc.Dial(o.ServiceAddress, dialOption)
+	if err != nil {
+		return err
+	}
+	o.conn = conn
+	o.client = colmetricspb.NewMetricsServiceClient(conn)
+
+	if o.sleep == nil {
+		o.sleep = time.Sleep
+	}
+	return nil
+}
+
+func (o *OpenTelemetry) Close() error {
+	if o.conn != nil {
+		return o.conn.Close()
+	}
+	return nil
+}
+
+func (o *OpenTelemetry) Write(metrics []telegraf.Metric) error {
+	request := &colmetricspb.ExportMetricsServiceRequest{
+		ResourceMetrics: o.resourceMetrics(metrics),
+	}
+	if len(request.ResourceMetrics[0].ScopeMetrics[0].Metrics) == 0 {
+		return nil
+	}
+
+	delay := o.RetryDelay.Duration
+	for attempt := 0; ; attempt++ {
+		err := o.export(request)
+		if err == nil {
+			return nil
+		}
+		if attempt >= o.MaxRetries || !retryable(err) {
+			return fmt.Errorf("exporting to %s failed: %v", o.ServiceAddress, err)
+		}
+		log.Printf("W! [outputs.opentelemetry] exporting to %s failed, retrying in %s: %v", o.ServiceAddress, delay, err)
+		o.sleep(delay)
+		delay *= 2
+	}
+}
+
+func (o *OpenTelemetry) export(request *colmetricspb.ExportMetricsServiceRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), o.Timeout.Duration)
+	defer cancel()
+	if len(o.Headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(o.Headers))
+	}
+
+	response, err := o.client.Export(ctx, request, o.callOptions...)
+	if err != nil {
+		return err
+	}
+	// The data points rejected would be rejected again.
+	if partial := response.GetPartialSuccess(); partial != nil && partial.RejectedDataPoints > 0 {
+		log.Printf("E! [outputs.opentelemetry] %d data points rejected by %s: %s",
+			partial.RejectedDataPoints, o.ServiceAddress, partial.ErrorMessage)
+	}
+	return nil
+}
+
+// retryable returns whether the export failed because the endpoint is
+// unavailable or overloaded.
+func retryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.ResourceExhausted, codes.Aborted:
+		return true
+	}
+	return false
+}
+
+func init() {
+	outputs.Add("opentelemetry", func() telegraf.Output {
+		return &OpenTelemetry{
+			ServiceAddress:  defaultServiceAddress,
+			Timeout:         internal.Duration{Duration: 5 * time.Second},
+			Compression:     "gzip",
+			MonotonicFields: []string{"*_total"},
+			MaxRetries:      3,
+			RetryDelay:      internal.Duration{Duration: time.Second},
+		}
+	})
+}
@@ -0,0 +1,258 @@
+package opentelemetry
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+var (
+	ts     = time.Unix(1500000000, 0)
+	tsNano = uint64(ts.UnixNano())
+)
+
+// fakeCollector is a metrics service recording the export requests, failing
+// the first exports with the errors.
+type fakeCollector struct {
+	colmetricspb.UnimplementedMetricsServiceServer
+
+	requests []*colmetricspb.ExportMetricsServiceRequest
+	metadata []metadata.MD
+	errors   []error
+}
+
+func (c *fakeCollector) Export(ctx context.Context, request *colmetricspb.ExportMetricsServiceRequest) (*colmetricspb.ExportMetricsServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	c.metadata = append(c.metadata, md)
+	if len(c.errors) > 0 {
+		err := c.errors[0]
+		c.errors = c.errors[1:]
+		return nil, err
+	}
+	c.requests = append(c.requests, request)
+	return &colmetricspb.ExportMetricsServiceResponse{}, nil
+}
+
+func newTestOpenTelemetry(t *testing.T, c *fakeCollector) (*OpenTelemetry, func()) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := grpc.NewServer()
+	colmetricspb.RegisterMetricsServiceServer(server, c)
+	go server.Serve(listener)
+
+	o := &OpenTelemetry{
+		ServiceAddress:  listener.Addr().String(),
+		Timeout:         internal.Duration{Duration: 5 * time.Second},
+		Compression:     "gzip",
+		MonotonicFields: []string{"*_total"},
+		MaxRetries:      3,
+		RetryDelay:      internal.Duration{Duration: time.Second},
+		sleep:           func(time.Duration) {},
+	}
+	require.NoError(t, o.Connect())
+	return o, func() {
+		o.Close()
+		server.Stop()
+	}
+}
+
+func stringAttributes(kv ...string) []*commonpb.KeyValue {
+	var attributes []*commonpb.KeyValue
+	for i := 0; i < len(kv); i += 2 {
+		attributes = append(attributes, stringAttribute(kv[i], kv[i+1]))
+	}
+	return attributes
+}
+
+func doubleDataPoint(value float64, attributes []*commonpb.KeyValue) *metricspb.NumberDataPoint {
+	return &metricspb.NumberDataPoint{
+		Attributes:   attributes,
+		TimeUnixNano: tsNano,
+		Value:        &metricspb.NumberDataPoint_AsDouble{AsDouble: value},
+	}
+}
+
+func intDataPoint(value int64, attributes []*commonpb.KeyValue) *metricspb.NumberDataPoint {
+	return &metricspb.NumberDataPoint{
+		Attributes:   attributes,
+		TimeUnixNano: tsNano,
+		Value:        &metricspb.NumberDataPoint_AsInt{AsInt: value},
+	}
+}
+
+func requireMetrics(t *testing.T, expected []*metricspb.Metric, request *colmetricspb.ExportMetricsServiceRequest) {
+	actual := request.ResourceMetrics[0].ScopeMetrics[0].Metrics
+	require.Len(t, actual, len(expected))
+	for i := range expected {
+		require.True(t, proto.Equal(expected[i], actual[i]), "expected %v, got %v", expected[i], actual[i])
+	}
+}
+
+func TestWrite(t *testing.T) {
+	c := &fakeCollector{}
+	o, stop := newTestOpenTelemetry(t, c)
+	defer stop()
+	o.Headers = map[string]string{"authorization": "Bearer token"}
+	o.ResourceAttributes = map[string]string{"service.name": "telegraf", "host.name": "a"}
+
+	err := o.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"cpu": "cpu0"}, map[string]interface{}{"ok": true, "state": "up"}, ts),
+		testutil.MustMetric("cpu", map[string]string{"cpu": "cpu0"}, map[string]interface{}{"usage_idle": 42.5}, ts),
+		testutil.MustMetric("cpu", map[string]string{"cpu": "cpu1"}, map[string]interface{}{"usage_idle": 1.5}, ts),
+		testutil.MustMetric("http_requests", nil, map[string]interface{}{"counter": uint64(7)}, ts, telegraf.Counter),
+		testutil.MustMetric("net", nil, map[string]interface{}{"bytes_total": int64(1024)}, ts),
+	})
+	require.NoError(t, err)
+
+	require.Len(t, c.requests, 1)
+	require.Equal(t, []string{"Bearer token"}, c.metadata[0].Get("authorization"))
+
+	rm := c.requests[0].ResourceMetrics[0]
+	expected := stringAttributes("host.name", "a", "service.name", "telegraf")
+	require.Len(t, rm.Resource.Attributes, len(expected))
+	for i := range expected {
+		require.True(t, proto.Equal(expected[i], rm.Resource.Attributes[i]))
+	}
+	require.Equal(t, "telegraf", rm.ScopeMetrics[0].Scope.Name)
+
+	cumulative := metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE
+	requireMetrics(t, []*metricspb.Metric{
+		{
+			Name: "cpu_ok",
+			Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{DataPoints: []*metricspb.NumberDataPoint{
+				intDataPoint(1, stringAttributes("cpu", "cpu0")),
+			}}},
+		},
+		{
+			Name: "cpu_usage_idle",
+			Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{DataPoints: []*metricspb.NumberDataPoint{
+				doubleDataPoint(42.5, stringAttributes("cpu", "cpu0")),
+				doubleDataPoint(1.5, stringAttributes("cpu", "cpu1")),
+			}}},
+		},
+		{
+			Name: "http_requests",
+			Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+				AggregationTemporality: cumulative,
+				IsMonotonic:            true,
+				DataPoints:             []*metricspb.NumberDataPoint{intDataPoint(7, nil)},
+			}},
+		},
+		{
+			Name: "net_bytes_total",
+			Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+				AggregationTemporality: cumulative,
+				IsMonotonic:            true,
+				DataPoints:             []*metricspb.NumberDataPoint{intDataPoint(1024, nil)},
+			}},
+		},
+	}, c.requests[0])
+}
+
+func TestWriteHistogramAndSummary(t *testing.T) {
+	c := &fakeCollector{}
+	o, stop := newTestOpenTelemetry(t, c)
+	defer stop()
+
+	err := o.Write([]telegraf.Metric{
+		testutil.MustMetric("http_duration", nil, map[string]interface{}{
+			"0.1": uint64(2), "0.5": uint64(5), "+Inf": uint64(6), "count": uint64(6), "sum": 2.5,
+		}, ts, telegraf.Histogram),
+		testutil.MustMetric("rpc_duration", nil, map[string]interface{}{
+			"0.99": 0.3, "0.5": 0.1, "count": uint64(10), "sum": 1.5,
+		}, ts, telegraf.Summary),
+	})
+	require.NoError(t, err)
+
+	sum := 2.5
+	requireMetrics(t, []*metricspb.Metric{
+		{
+			Name: "http_duration",
+			Data: &metricspb.Metric_Histogram{Histogram: &metricspb.Histogram{
+				AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+				DataPoints: []*metricspb.HistogramDataPoint{{
+					TimeUnixNano:   tsNano,
+					Count:          6,
+					Sum:            &sum,
+					ExplicitBounds: []float64{0.1, 0.5},
+					BucketCounts:   []uint64{2, 3, 1},
+				}},
+			}},
+		},
+		{
+			Name: "rpc_duration",
+			Data: &metricspb.Metric_Summary{Summary: &metricspb.Summary{
+				DataPoints: []*metricspb.SummaryDataPoint{{
+					TimeUnixNano: tsNano,
+					Count:        10,
+					Sum:          1.5,
+					QuantileValues: []*metricspb.SummaryDataPoint_ValueAtQuantile{
+						{Quantile: 0.5, Value: 0.1},
+						{Quantile: 0.99, Value: 0.3},
+					},
+				}},
+			}},
+		},
+	}, c.requests[0])
+}
+
+func TestWriteRetries(t *testing.T) {
+	c := &fakeCollector{errors: []error{
+		status.Error(codes.Unavailable, "unavailable"),
+		status.Error(codes.ResourceExhausted, "overloaded"),
+	}}
+	o, stop := newTestOpenTelemetry(t, c)
+	defer stop()
+	var delays []time.Duration
+	o.sleep = func(d time.Duration) { delays = append(delays, d) }
+
+	m := testutil.MustMetric("load", nil, map[string]interface{}{"value": 1.0}, ts)
+	require.NoError(t, o.Write([]telegraf.Metric{m}))
+	require.Len(t, c.requests, 1)
+	require.Equal(t, []time.Duration{time.Second, 2 * time.Second}, delays)
+
+	// The invalid exports are not retried
+	c.errors = []error{status.Error(codes.InvalidArgument, "invalid")}
+	require.Error(t, o.Write([]telegraf.Metric{m}))
+	require.Len(t, c.metadata, 4)
+
+	// The export fails once the retries are exhausted
+	o.MaxRetries = 1
+	c.errors = []error{
+		status.Error(codes.Unavailable, "unavailable"),
+		status.Error(codes.Unavailable, "unavailable"),
+	}
+	require.Error(t, o.Write([]telegraf.Metric{m}))
+	require.Len(t, c.requests, 1)
+}
+
+func TestWriteNoData(t *testing.T) {
+	c := &fakeCollector{}
+	o, stop := newTestOpenTelemetry(t, c)
+	defer stop()
+
+	require.NoError(t, o.Write([]telegraf.Metric{
+		testutil.MustMetric("status", nil, map[string]interface{}{"state": "up"}, ts),
+	}))
+	require.Empty(t, c.metadata)
+}
+
+func TestConnectInvalidCompression(t *testing.T) {
+	o := &OpenTelemetry{Compression: "zstd"}
+	require.Error(t, o.Connect())
+}
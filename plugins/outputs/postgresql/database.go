@@ -0,0 +1,69 @@
+package postgresql
+
+import (
+	"context"
+
+	"github.com/jackc/pgx"
+)
+
+// poolDatabase runs the statements with a pool of connections.
+type poolDatabase struct {
+	pool *pgx.ConnPool
+}
+
+func newPoolDatabase(connection string, maxConnections int) (*poolDatabase, error) {
+	config, err := pgx.ParseConnectionString(connection)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err := pgx.NewConnPool(pgx.ConnPoolConfig{
+		ConnConfig:     config,
+		MaxConnections: maxConnections,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &poolDatabase{pool: pool}, nil
+}
+
+func (d *poolDatabase) exec(ctx context.Context, sql string, args ...interface{}) error {
+	_, err := d.pool.ExecEx(ctx, sql, nil, args...)
+	return err
+}
+
+func (d *poolDatabase) columns(ctx context.Context, schema, table string) (map[string]string, error) {
+	rows, err := d.pool.QueryEx(ctx,
+		"SELECT column_name, data_type FROM information_schema.columns WHERE table_schema = $1 AND table_name = $2",
+		nil, schema, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns := make(map[string]string)
+	for rows.Next() {
+		var name, typ string
+		if err := rows.Scan(&name, &typ); err != nil {
+			return nil, err
+		}
+		columns[name] = typ
+	}
+	return columns, rows.Err()
+}
+
+func (d *poolDatabase) hasExtension(ctx context.Context, name string) (bool, error) {
+	var exists bool
+	err := d.pool.QueryRowEx(ctx,
+		"SELECT EXISTS (SELECT 1 FROM pg_extension WHERE extname = $1)", nil, name).Scan(&exists)
+	return exists, err
+}
+
+func (d *poolDatabase) copyFrom(schema, table string, columns []string, rows [][]interface{}) error {
+	_, err := d.pool.CopyFrom(pgx.Identifier{schema, table}, columns, pgx.CopyFromRows(rows))
+	return err
+}
+
+func (d *poolDatabase) close() {
+	d.pool.Close()
+}
@@ -0,0 +1,177 @@
+package postgresql
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tables"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type copyCall struct {
+	table   string
+	columns []string
+	rows    [][]interface{}
+}
+
+// fakeDatabase records the statements and copies, returning the columns of
+// the tables created or altered.
+type fakeDatabase struct {
+	timescaleDB bool
+	tables      map[string]map[string]string
+	statements  []string
+	args        [][]interface{}
+	copies      []copyCall
+}
+
+func (d *fakeDatabase) exec(ctx context.Context, sql string, args ...interface{}) error {
+	d.statements = append(d.statements, sql)
+	d.args = append(d.args, args)
+	return nil
+}
+
+func (d *fakeDatabase) columns(ctx context.Context, schema, table string) (map[string]string, error) {
+	columns := make(map[string]string)
+	for k, v := range d.tables[table] {
+		columns[k] = v
+	}
+	return columns, nil
+}
+
+func (d *fakeDatabase) hasExtension(ctx context.Context, name string) (bool, error) {
+	return d.timescaleDB, nil
+}
+
+func (d *fakeDatabase) copyFrom(schema, table string, columns []string, rows [][]interface{}) error {
+	d.copies = append(d.copies, copyCall{table, columns, rows})
+	return nil
+}
+
+func (d *fakeDatabase) close() {}
+
+func newPostgresql() *Postgresql {
+	return &Postgresql{
+		Schema:                  "public",
+		CreateTables:            true,
+		TagTableSuffix:          "_tag",
+		CreateHypertables:       true,
+		HypertableChunkInterval: internal.Duration{Duration: 24 * time.Hour},
+		Timeout:                 internal.Duration{Duration: 5 * time.Second},
+	}
+}
+
+func TestWriteTagColumns(t *testing.T) {
+	db := &fakeDatabase{
+		timescaleDB: true,
+		tables: map[string]map[string]string{
+			"cpu": {"time": timeType, "host": tagType, "usage": floatType},
+		},
+	}
+	p := newPostgresql()
+	require.NoError(t, p.connect(db))
+
+	m := testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 42.5, "count": uint64(math.MaxUint64)}, time.Unix(1500000000, 0))
+	require.NoError(t, p.Write([]telegraf.Metric{m}))
+
+	require.Equal(t, []string{
+		`CREATE TABLE IF NOT EXISTS "public"."cpu" ("time" timestamp with time zone NOT NULL, "host" text, "count" bigint, "usage" double precision)`,
+		`SELECT create_hypertable($1, 'time', chunk_time_interval => $2::interval, if_not_exists => TRUE)`,
+		`ALTER TABLE "public"."cpu" ADD COLUMN IF NOT EXISTS "count" bigint`,
+	}, db.statements)
+	require.Equal(t, []interface{}{`"public"."cpu"`, "86400 seconds"}, db.args[1])
+
+	require.Equal(t, []copyCall{{
+		table:   "cpu",
+		columns: []string{"time", "host", "count", "usage"},
+		rows:    [][]interface{}{{m.Time().UTC(), "a", int64(math.MaxInt64), 42.5}},
+	}}, db.copies)
+
+	// The tables are only prepared once
+	require.NoError(t, p.Write([]telegraf.Metric{m}))
+	require.Len(t, db.statements, 3)
+	require.Len(t, db.copies, 2)
+}
+
+func TestWriteTagsAsForeignKeys(t *testing.T) {
+	db := &fakeDatabase{
+		tables: map[string]map[string]string{
+			"cpu_tag": {"tag_id": tagIDType, "host": tagType},
+			"cpu":     {"time": timeType, "tag_id": tagIDType, "usage": floatType},
+		},
+	}
+	p := newPostgresql()
+	p.TagsAsForeignKeys = true
+	require.NoError(t, p.connect(db))
+
+	a := testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 0))
+	b := testutil.MustMetric("cpu", map[string]string{"host": "b"}, map[string]interface{}{"usage": int64(1)}, time.Unix(1500000000, 0))
+	require.NoError(t, p.Write([]telegraf.Metric{a, b, a}))
+
+	require.Equal(t, []string{
+		`CREATE TABLE IF NOT EXISTS "public"."cpu_tag" ("tag_id" bigint PRIMARY KEY, "host" text)`,
+		`INSERT INTO "public"."cpu_tag" ("tag_id", "host") VALUES ($1, $2), ($3, $4) ON CONFLICT ("tag_id") DO NOTHING`,
+		`CREATE TABLE IF NOT EXISTS "public"."cpu" ("time" timestamp with time zone NOT NULL, "tag_id" bigint REFERENCES "public"."cpu_tag" ("tag_id"), "usage" double precision)`,
+	}, db.statements)
+	require.Equal(t, []interface{}{tagID(a), "a", tagID(b), "b"}, db.args[1])
+
+	require.Len(t, db.copies, 1)
+	require.Equal(t, []string{"time", "tag_id", "usage"}, db.copies[0].columns)
+	require.Equal(t, []interface{}{a.Time().UTC(), tagID(b), 1.0}, db.copies[0].rows[1])
+
+	// The known tag sets are not inserted again
+	require.NoError(t, p.Write([]telegraf.Metric{b}))
+	require.Len(t, db.statements, 3)
+}
+
+func TestWriteUnmanagedTable(t *testing.T) {
+	db := &fakeDatabase{
+		tables: map[string]map[string]string{
+			"cpu": {"time": timeType, "usage": "real"},
+		},
+	}
+	p := newPostgresql()
+	p.CreateTables = false
+	require.NoError(t, p.connect(db))
+
+	m := testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": int64(42)}, time.Unix(1500000000, 0))
+	require.NoError(t, p.Write([]telegraf.Metric{m}))
+
+	require.Empty(t, db.statements)
+	require.Equal(t, []string{"time", "usage"}, db.copies[0].columns)
+	require.Equal(t, []interface{}{m.Time().UTC(), 42.0}, db.copies[0].rows[0])
+
+	// Writing to a missing table fails
+	db.tables = nil
+	p.tables = tables.NewCache(p.CreateTables)
+	require.Error(t, p.Write([]telegraf.Metric{m}))
+}
+
+func TestConvertValue(t *testing.T) {
+	tests := []struct {
+		value    interface{}
+		typ      string
+		expected interface{}
+	}{
+		{42.5, "double precision", 42.5},
+		{int64(42), "double precision", 42.0},
+		{uint64(42), "bigint", int64(42)},
+		{42.0, "integer", int64(42)},
+		{true, "boolean", true},
+		{"a", "bigint", nil},
+		{int64(42), "text", "42"},
+		{42.0, "jsonb", nil},
+	}
+
+	for _, tt := range tests {
+		require.Equal(t, tt.expected, convertValue(tt.value, tt.typ), "%v as %s", tt.value, tt.typ)
+	}
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	require.Equal(t, `"a""b"`, quoteIdentifier(`a"b`))
+}
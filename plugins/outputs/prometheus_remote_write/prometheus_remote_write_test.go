@@ -0,0 +1,237 @@
+package prometheus_remote_write
+
+import (
+	"io/ioutil"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+// fakeServer is a remote write endpoint recording the write requests.
+type fakeServer struct {
+	*httptest.Server
+	requests []*http.Request
+	writes   []*prompb.WriteRequest
+	status   int
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	s := &fakeServer{status: http.StatusNoContent}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "snappy", r.Header.Get("Content-Encoding"))
+		require.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		require.Equal(t, "0.1.0", r.Header.Get("X-Prometheus-Remote-Write-Version"))
+
+		compressed, err := ioutil.ReadAll(r.Body)
+		require.NoError(t, err)
+		data, err := snappy.Decode(nil, compressed)
+		require.NoError(t, err)
+		var write prompb.WriteRequest
+		require.NoError(t, write.Unmarshal(data))
+
+		s.requests = append(s.requests, r)
+		s.writes = append(s.writes, &write)
+		w.WriteHeader(s.status)
+	}))
+	return s
+}
+
+func labels(kv ...string) []prompb.Label {
+	var result []prompb.Label
+	for i := 0; i < len(kv); i += 2 {
+		result = append(result, prompb.Label{Name: kv[i], Value: kv[i+1]})
+	}
+	return result
+}
+
+func TestWrite(t *testing.T) {
+	s := newFakeServer(t)
+	defer s.Close()
+
+	p := &PrometheusRemoteWrite{
+		URL:            s.URL,
+		BearerToken:    "token",
+		ExternalLabels: map[string]string{"cluster": "prod", "host": "ignored"},
+	}
+	require.NoError(t, p.Connect())
+
+	tm := time.Unix(1500000000, 0)
+	err := p.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a", "cpu-id": "0"}, map[string]interface{}{"usage_idle": 42.5, "value": int64(3), "ok": true, "state": "up"}, tm),
+	})
+	require.NoError(t, err)
+
+	require.Len(t, s.writes, 1)
+	require.Equal(t, "Bearer token", s.requests[0].Header.Get("Authorization"))
+	require.Equal(t, []prompb.TimeSeries{
+		{
+			Labels:  labels("__name__", "cpu", "cluster", "prod", "cpu_id", "0", "host", "a"),
+			Samples: []prompb.Sample{{Value: 3, Timestamp: 1500000000000}},
+		},
+		{
+			Labels:  labels("__name__", "cpu_ok", "cluster", "prod", "cpu_id", "0", "host", "a"),
+			Samples: []prompb.Sample{{Value: 1, Timestamp: 1500000000000}},
+		},
+		{
+			Labels:  labels("__name__", "cpu_usage_idle", "cluster", "prod", "cpu_id", "0", "host", "a"),
+			Samples: []prompb.Sample{{Value: 42.5, Timestamp: 1500000000000}},
+		},
+	}, s.writes[0].Timeseries)
+}
+
+func TestWriteHistogram(t *testing.T) {
+	s := newFakeServer(t)
+	defer s.Close()
+
+	p := &PrometheusRemoteWrite{URL: s.URL}
+	require.NoError(t, p.Connect())
+
+	tm := time.Unix(1500000000, 0)
+	err := p.Write([]telegraf.Metric{
+		testutil.MustMetric("http_duration", nil, map[string]interface{}{"0.5": uint64(3), "+Inf": uint64(5), "sum": 2.5, "count": uint64(5)}, tm, telegraf.Histogram),
+	})
+	require.NoError(t, err)
+
+	require.Equal(t, []prompb.TimeSeries{
+		{
+			Labels:  labels("__name__", "http_duration_bucket", "le", "+Inf"),
+			Samples: []prompb.Sample{{Value: 5, Timestamp: 1500000000000}},
+		},
+		{
+			Labels:  labels("__name__", "http_duration_bucket", "le", "0.5"),
+			Samples: []prompb.Sample{{Value: 3, Timestamp: 1500000000000}},
+		},
+		{
+			Labels:  labels("__name__", "http_duration_count"),
+			Samples: []prompb.Sample{{Value: 5, Timestamp: 1500000000000}},
+		},
+		{
+			Labels:  labels("__name__", "http_duration_sum"),
+			Samples: []prompb.Sample{{Value: 2.5, Timestamp: 1500000000000}},
+		},
+	}, s.writes[0].Timeseries)
+}
+
+func TestWriteOrdering(t *testing.T) {
+	s := newFakeServer(t)
+	defer s.Close()
+
+	p := &PrometheusRemoteWrite{URL: s.URL}
+	require.NoError(t, p.Connect())
+
+	t1 := time.Unix(1500000000, 0)
+	t2 := t1.Add(10 * time.Second)
+	err := p.Write([]telegraf.Metric{
+		testutil.MustMetric("load", nil, map[string]interface{}{"value": 2.0}, t2),
+		testutil.MustMetric("load", nil, map[string]interface{}{"value": 1.0}, t1),
+		testutil.MustMetric("load", nil, map[string]interface{}{"value": 3.0}, t2),
+	})
+	require.NoError(t, err)
+
+	// The samples are sorted, the last sample of a timestamp being kept
+	require.Equal(t, []prompb.Sample{
+		{Value: 1, Timestamp: 1500000000000},
+		{Value: 3, Timestamp: 1500000010000},
+	}, s.writes[0].Timeseries[0].Samples)
+
+	// The samples older than the last sample written are dropped
+	err = p.Write([]telegraf.Metric{
+		testutil.MustMetric("load", nil, map[string]interface{}{"value": 4.0}, t1),
+	})
+	require.NoError(t, err)
+	require.Len(t, s.writes, 1)
+}
+
+func TestWriteStalenessMarkers(t *testing.T) {
+	s := newFakeServer(t)
+	defer s.Close()
+
+	now := time.Unix(1500000000, 0)
+	p := &PrometheusRemoteWrite{
+		URL:                s.URL,
+		ExpirationInterval: internal.Duration{Duration: time.Minute},
+		now:                func() time.Time { return now },
+	}
+	require.NoError(t, p.Connect())
+
+	require.NoError(t, p.Write([]telegraf.Metric{
+		testutil.MustMetric("load", map[string]string{"host": "a"}, map[string]interface{}{"value": 1.0}, now),
+		testutil.MustMetric("load", map[string]string{"host": "b"}, map[string]interface{}{"value": 1.0}, now),
+	}))
+
+	now = now.Add(2 * time.Minute)
+	require.NoError(t, p.Write([]telegraf.Metric{
+		testutil.MustMetric("load", map[string]string{"host": "a"}, map[string]interface{}{"value": 2.0}, now),
+	}))
+
+	require.Len(t, s.writes, 2)
+	series := s.writes[1].Timeseries
+	require.Len(t, series, 2)
+	require.Equal(t, labels("__name__", "load", "host", "b"), series[1].Labels)
+	require.True(t, math.IsNaN(series[1].Samples[0].Value))
+	require.Equal(t, math.Float64bits(staleNaN), math.Float64bits(series[1].Samples[0].Value))
+	require.Equal(t, int64(1500000120000), series[1].Samples[0].Timestamp)
+
+	// The expired series are forgotten
+	require.Len(t, p.series, 1)
+}
+
+func TestWriteErrors(t *testing.T) {
+	s := newFakeServer(t)
+	defer s.Close()
+
+	p := &PrometheusRemoteWrite{URL: s.URL}
+	require.NoError(t, p.Connect())
+	m := testutil.MustMetric("load", nil, map[string]interface{}{"value": 1.0}, time.Unix(1500000000, 0))
+
+	// The rejected samples are dropped
+	s.status = http.StatusBadRequest
+	require.NoError(t, p.Write([]telegraf.Metric{m}))
+
+	s.status = http.StatusServiceUnavailable
+	m = testutil.MustMetric("load", nil, map[string]interface{}{"value": 1.0}, time.Unix(1500000010, 0))
+	require.Error(t, p.Write([]telegraf.Metric{m}))
+
+	// The samples of a failed write are sent again
+	s.status = http.StatusNoContent
+	require.NoError(t, p.Write([]telegraf.Metric{m}))
+	require.Len(t, s.writes, 3)
+}
+
+func TestWriteSigV4(t *testing.T) {
+	s := newFakeServer(t)
+	defer s.Close()
+
+	p := &PrometheusRemoteWrite{
+		URL:        s.URL,
+		AWSRegion:  "us-east-1",
+		AWSService: "aps",
+		AccessKey:  "AKID",
+		SecretKey:  "SECRET",
+	}
+	require.NoError(t, p.Connect())
+	require.NoError(t, p.Write([]telegraf.Metric{
+		testutil.MustMetric("load", nil, map[string]interface{}{"value": 1.0}, time.Unix(1500000000, 0)),
+	}))
+
+	auth := s.requests[0].Header.Get("Authorization")
+	require.True(t, strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/"), auth)
+	require.Contains(t, auth, "/us-east-1/aps/aws4_request")
+	require.NotEmpty(t, s.requests[0].Header.Get("X-Amz-Date"))
+}
+
+func TestSanitize(t *testing.T) {
+	require.Equal(t, "disk_io_time", sanitize("disk.io-time"))
+	require.Equal(t, "_1m_load", sanitize("1m_load"))
+}
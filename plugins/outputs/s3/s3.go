@@ -0,0 +1,310 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	internalaws "github.com/influxdata/telegraf/internal/config/aws"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+// The tag placeholders of the key templates, such as {{host}}.
+var tagPlaceholder = regexp.MustCompile(`\{\{\s*([^}]*?)\s*\}\}`)
+
+var sampleConfig = `
+  ## Amazon REGION
+  region = "us-east-1"
+
+  ## Amazon Credentials
+  ## Credentials are loaded in the following order
+  ## 1) Assumed credentials via STS if role_arn is specified
+  ## 2) explicit credentials from 'access_key' and 'secret_key'
+  ## 3) shared profile from 'profile'
+  ## 4) environment variables
+  ## 5) shared credentials file
+  ## 6) EC2 Instance Profile
+  #access_key = ""
+  #secret_key = ""
+  #token = ""
+  #role_arn = ""
+  #profile = ""
+  #shared_credential_file = ""
+
+  ## Endpoint of an S3 compatible service, such as MinIO, instead of AWS,
+  ## which usually requires the path style URLs of the objects.
+  # endpoint_url = ""
+  # force_path_style = false
+
+  ## Bucket of the objects, which must exist.
+  bucket = "telegraf"
+
+  ## Template of the prefix of the keys of the objects, the metrics being
+  ## written in the objects of their prefix.  The date specifiers below use
+  ## the time of the metric, in UTC.
+  # %Y - year (2016)
+  # %y - last two digits of year (00..99)
+  # %m - month (01..12)
+  # %d - day of month (e.g., 01)
+  # %H - hour (00..23)
+  # %M - minute (00..59)
+  ## Additionally, you can specify a tag name using the notation {{tag_name}},
+  ## the default_tag_value being used when the metric does not have the tag.
+  ## The slashes of the tag values are replaced by underscores.
+  # key_template = "metrics/dt=%Y-%m-%d/host={{host}}/"
+  # default_tag_value = "none"
+
+  ## Compression of the objects: "gzip", "zstd" or "none".
+  # compression = "gzip"
+
+  ## The metrics of a prefix are buffered, and uploaded in an object once the
+  ## serialized metrics reach max_object_size, or once the first metric of the
+  ## object has been buffered for max_object_age.  The buffered metrics are
+  ## uploaded when telegraf stops.
+  # max_object_size = "64MB"
+  # max_object_age = "10m"
+
+  ## Timeout of the uploads.
+  # timeout = "1m"
+
+  ## Data format to output.
+  ## Each data format has its own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_OUTPUT.md
+  data_format = "influx"
+`
+
+type S3 struct {
+	Region    string `toml:"region"`
+	AccessKey string `toml:"access_key"`
+	SecretKey string `toml:"secret_key"`
+	RoleARN   string `toml:"role_arn"`
+	Profile   string `toml:"profile"`
+	Filename  string `toml:"shared_credential_file"`
+	Token     string `toml:"token"`
+
+	EndpointURL     string            `toml:"endpoint_url"`
+	ForcePathStyle  bool              `toml:"force_path_style"`
+	Bucket          string            `toml:"bucket"`
+	KeyTemplate     string            `toml:"key_template"`
+	DefaultTagValue string            `toml:"default_tag_value"`
+	Compression     string            `toml:"compression"`
+	MaxObjectSize   internal.Size     `toml:"max_object_size"`
+	MaxObjectAge    internal.Duration `toml:"max_object_age"`
+	Timeout         internal.Duration `toml:"timeout"`
+
+	svc        s3iface.S3API
+	serializer serializers.Serializer
+
+	// The metrics buffered by prefix of the objects.
+	objects map[string]*object
+
+	now func() time.Time
+}
+
+// object holds the serialized metrics of an object to upload.
+type object struct {
+	buf     bytes.Buffer
+	created time.Time
+}
+
+func (s *S3) SampleConfig() string {
+	return sampleConfig
+}
+
+func (s *S3) Description() string {
+	return "Archive metrics in objects of an Amazon S3 bucket"
+}
+
+func (s *S3) SetSerializer(serializer serializers.Serializer) {
+	s.serializer = serializer
+}
+
+func (s *S3) Connect() error {
+	if s.Bucket == "" {
+		return fmt.Errorf("bucket is a required field for s3 output")
+	}
+	switch s.Compression {
+	case "gzip", "zstd", "none":
+	default:
+		return fmt.Errorf("unknown compression %q", s.Compression)
+	}
+
+	credentialConfig := &internalaws.CredentialConfig{
+		Region:    s.Region,
+		AccessKey: s.AccessKey,
+		SecretKey: s.SecretKey,
+		RoleARN:   s.RoleARN,
+		Profile:   s.Profile,
+		Filename:  s.Filename,
+		Token:     s.Token,
+	}
+	config := &aws.Config{S3ForcePathStyle: aws.Bool(s.ForcePathStyle)}
+	if s.EndpointURL != "" {
+		config.Endpoint = aws.String(s.EndpointURL)
+	}
+	return s.connect(s3.New(credentialConfig.Credentials(), config))
+}
+
+// connect sets the client of the service.
+func (s *S3) connect(svc s3iface.S3API) error {
+	s.svc = svc
+	s.objects = make(map[string]*object)
+	if s.now == nil {
+		s.now = time.Now
+	}
+	return nil
+}
+
+// Close uploads the buffered metrics.
+func (s *S3) Close() error {
+	var lastErr error
+	for prefix, o := range s.objects {
+		if err := s.upload(prefix, o); err != nil {
+			log.Printf("E! [outputs.s3] uploading the metrics of %s failed, dropping them: %v", prefix, err)
+			lastErr = err
+		}
+		delete(s.objects, prefix)
+	}
+	return lastErr
+}
+
+func (s *S3) Write(metrics []telegraf.Metric) error {
+	now := s.now()
+	for _, metric := range metrics {
+		b, err := s.serializer.Serialize(metric)
+		if err != nil {
+			return fmt.Errorf("failed to serialize message: %s", err)
+		}
+
+		prefix := s.prefix(metric)
+		o, ok := s.objects[prefix]
+		if !ok {
+			o = &object{created: now}
+			s.objects[prefix] = o
+		}
+		o.buf.Write(b)
+	}
+
+	// The objects failing to upload are kept, and uploaded again with the
+	// next write, as the metrics written are not written again.
+	for prefix, o := range s.objects {
+		if int64(o.buf.Len()) < s.MaxObjectSize.Size && now.Sub(o.created) < s.MaxObjectAge.Duration {
+			continue
+		}
+		if err := s.upload(prefix, o); err != nil {
+			log.Printf("W! [outputs.s3] uploading the metrics of %s failed, retrying with the next write: %v", prefix, err)
+			continue
+		}
+		delete(s.objects, prefix)
+	}
+	return nil
+}
+
+func (s *S3) upload(prefix string, o *object) error {
+	body, contentType, extension, err := s.compress(o.buf.Bytes())
+	if err != nil {
+		return err
+	}
+
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	key := prefix + s.now().UTC().Format("20060102T150405Z") + "_" + hex.EncodeToString(suffix) + extension
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.Timeout.Duration)
+	defer cancel()
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(s.Bucket),
+		Key:    aws.String(key),
+		Body:   bytes.NewReader(body),
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+	_, err = s.svc.PutObjectWithContext(ctx, input)
+	return err
+}
+
+// compress returns the compressed body of an object, with its content type
+// and the extension of its key.
+func (s *S3) compress(data []byte) ([]byte, string, string, error) {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	var contentType, extension string
+	switch s.Compression {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+		contentType, extension = "application/gzip", ".gz"
+	case "zstd":
+		enc, err := zstd.NewWriter(&buf)
+		if err != nil {
+			return nil, "", "", err
+		}
+		w = enc
+		contentType, extension = "application/zstd", ".zst"
+	default:
+		return data, "", "", nil
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return nil, "", "", err
+	}
+	if err := w.Close(); err != nil {
+		return nil, "", "", err
+	}
+	return buf.Bytes(), contentType, extension, nil
+}
+
+// prefix returns the prefix of the keys of the objects of a metric, rendered
+// from the key template.
+func (s *S3) prefix(metric telegraf.Metric) string {
+	t := metric.Time().UTC()
+	prefix := strings.NewReplacer(
+		"%Y", t.Format("2006"),
+		"%y", t.Format("06"),
+		"%m", t.Format("01"),
+		"%d", t.Format("02"),
+		"%H", t.Format("15"),
+		"%M", t.Format("04"),
+	).Replace(s.KeyTemplate)
+
+	return tagPlaceholder.ReplaceAllStringFunc(prefix, func(placeholder string) string {
+		key := tagPlaceholder.FindStringSubmatch(placeholder)[1]
+		value, ok := metric.GetTag(key)
+		if !ok {
+			value = s.DefaultTagValue
+		}
+		return strings.Replace(value, "/", "_", -1)
+	})
+}
+
+func init() {
+	outputs.Add("s3", func() telegraf.Output {
+		return &S3{
+			KeyTemplate:     "metrics/dt=%Y-%m-%d/host={{host}}/",
+			DefaultTagValue: "none",
+			Compression:     "gzip",
+			MaxObjectSize:   internal.Size{Size: 64 * 1000 * 1000},
+			MaxObjectAge:    internal.Duration{Duration: 10 * time.Minute},
+			Timeout:         internal.Duration{Duration: time.Minute},
+		}
+	})
+}
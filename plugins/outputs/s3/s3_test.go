@@ -0,0 +1,174 @@
+package s3
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+type upload struct {
+	key         string
+	contentType string
+	body        []byte
+}
+
+// fakeS3 records the objects put.
+type fakeS3 struct {
+	s3iface.S3API
+	uploads []upload
+	err     error
+}
+
+func (f *fakeS3) PutObjectWithContext(ctx aws.Context, input *s3.PutObjectInput, opts ...request.Option) (*s3.PutObjectOutput, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	body, err := ioutil.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.uploads = append(f.uploads, upload{
+		key:         aws.StringValue(input.Key),
+		contentType: aws.StringValue(input.ContentType),
+		body:        body,
+	})
+	return &s3.PutObjectOutput{}, nil
+}
+
+func newS3(svc *fakeS3, now *time.Time) *S3 {
+	s := &S3{
+		Bucket:          "telegraf",
+		KeyTemplate:     "metrics/dt=%Y-%m-%d/host={{host}}/",
+		DefaultTagValue: "none",
+		Compression:     "none",
+		MaxObjectSize:   internal.Size{Size: 1000},
+		MaxObjectAge:    internal.Duration{Duration: 10 * time.Minute},
+		Timeout:         internal.Duration{Duration: time.Minute},
+		now:             func() time.Time { return *now },
+	}
+	s.SetSerializer(influx.NewSerializer())
+	if err := s.connect(svc); err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func TestWriteAge(t *testing.T) {
+	svc := &fakeS3{}
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	s := newS3(svc, &now)
+
+	require.NoError(t, s.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 42.5}, now),
+		testutil.MustMetric("cpu", map[string]string{"host": "b"}, map[string]interface{}{"usage": 1.5}, now),
+		testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 2.5}, now.Add(-24*time.Hour)),
+	}))
+	require.Empty(t, svc.uploads)
+
+	now = now.Add(10 * time.Minute)
+	require.NoError(t, s.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 43.5}, now),
+	}))
+
+	require.Len(t, svc.uploads, 3)
+	sort.Slice(svc.uploads, func(i, j int) bool { return svc.uploads[i].key < svc.uploads[j].key })
+	require.Regexp(t, `^metrics/dt=2020-05-31/host=none/20200601T121000Z_[0-9a-f]{8}$`, svc.uploads[0].key)
+	require.Regexp(t, `^metrics/dt=2020-06-01/host=a/20200601T121000Z_[0-9a-f]{8}$`, svc.uploads[1].key)
+	require.Regexp(t, `^metrics/dt=2020-06-01/host=b/`, svc.uploads[2].key)
+	require.Equal(t,
+		"cpu,host=a usage=42.5 1591012800000000000\n"+
+			"cpu,host=a usage=43.5 1591013400000000000\n",
+		string(svc.uploads[1].body))
+	require.Empty(t, s.objects)
+}
+
+func TestWriteSize(t *testing.T) {
+	svc := &fakeS3{}
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	s := newS3(svc, &now)
+	s.KeyTemplate = "metrics/"
+
+	var metrics []telegraf.Metric
+	for i := 0; i < 60; i++ {
+		metrics = append(metrics, testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": float64(i)}, now))
+	}
+	require.NoError(t, s.Write(metrics[:10]))
+	require.Empty(t, svc.uploads)
+	require.NoError(t, s.Write(metrics[10:]))
+	require.Len(t, svc.uploads, 1)
+	require.True(t, len(svc.uploads[0].body) >= 1000)
+}
+
+func TestWriteCompression(t *testing.T) {
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	m := testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 42.5}, now)
+
+	for _, compression := range []string{"gzip", "zstd"} {
+		svc := &fakeS3{}
+		s := newS3(svc, &now)
+		s.Compression = compression
+		require.NoError(t, s.Write([]telegraf.Metric{m}))
+		require.NoError(t, s.Close())
+		require.Len(t, svc.uploads, 1)
+
+		var body []byte
+		switch compression {
+		case "gzip":
+			require.Regexp(t, `\.gz$`, svc.uploads[0].key)
+			require.Equal(t, "application/gzip", svc.uploads[0].contentType)
+			r, err := gzip.NewReader(bytes.NewReader(svc.uploads[0].body))
+			require.NoError(t, err)
+			body, err = ioutil.ReadAll(r)
+			require.NoError(t, err)
+		case "zstd":
+			require.Regexp(t, `\.zst$`, svc.uploads[0].key)
+			require.Equal(t, "application/zstd", svc.uploads[0].contentType)
+			r, err := zstd.NewReader(bytes.NewReader(svc.uploads[0].body))
+			require.NoError(t, err)
+			body, err = ioutil.ReadAll(r)
+			require.NoError(t, err)
+		}
+		require.Equal(t, "cpu usage=42.5 1591012800000000000\n", string(body))
+	}
+}
+
+func TestWriteUploadFailure(t *testing.T) {
+	svc := &fakeS3{err: errors.New("access denied")}
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	s := newS3(svc, &now)
+	s.MaxObjectAge = internal.Duration{}
+
+	m := testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 42.5}, now)
+	require.NoError(t, s.Write([]telegraf.Metric{m}))
+	require.Len(t, s.objects, 1)
+
+	// The object is uploaded again with the next write
+	svc.err = nil
+	require.NoError(t, s.Write(nil))
+	require.Len(t, svc.uploads, 1)
+	require.Empty(t, s.objects)
+}
+
+func TestConnectInvalidConfig(t *testing.T) {
+	s := &S3{Compression: "gzip"}
+	require.Error(t, s.Connect())
+
+	s = &S3{Bucket: "telegraf", Compression: "lz4"}
+	require.Error(t, s.Connect())
+}
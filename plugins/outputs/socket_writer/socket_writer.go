@@ -10,6 +10,7 @@ import (
 
 	"github.com/influxdata/telegraf"
 	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/proxy"
 	tlsint "github.com/influxdata/telegraf/internal/tls"
 	"github.com/influxdata/telegraf/plugins/outputs"
 	"github.com/influxdata/telegraf/plugins/serializers"
@@ -19,6 +20,7 @@ type SocketWriter struct {
 	Address         string
 	KeepAlivePeriod *internal.Duration
 	tlsint.ClientConfig
+	proxy.Socks5ProxyConfig
 
 	serializers.Serializer
 
@@ -50,6 +52,11 @@ func (sw *SocketWriter) SampleConfig() string {
   ## Use TLS but skip chain & host verification
   # insecure_skip_verify = false
 
+  ## Optional SOCKS5 proxy of the TCP connections, with its credentials.
+  # socks5_proxy_address = "127.0.0.1:1080"
+  # socks5_proxy_username = ""
+  # socks5_proxy_password = ""
+
   ## Period between keep alive probes.
   ## Only applies to TCP sockets.
   ## 0 disables keep alive probes.
@@ -80,7 +87,9 @@ func (sw *SocketWriter) Connect() error {
 	}
 
 	var c net.Conn
-	if tlsCfg == nil {
+	if sw.Socks5ProxyConfig.Configured() {
+		c, err = sw.dialProxy(spl[0], spl[1], tlsCfg)
+	} else if tlsCfg == nil {
 		c, err = net.Dial(spl[0], spl[1])
 	} else {
 		c, err = tls.Dial(spl[0], spl[1], tlsCfg)
@@ -97,6 +106,21 @@ func (sw *SocketWriter) Connect() error {
 	return nil
 }
 
+// dialProxy connects through the SOCKS5 proxy, which only proxies TCP.
+func (sw *SocketWriter) dialProxy(network, address string, tlsCfg *tls.Config) (net.Conn, error) {
+	switch network {
+	case "tcp", "tcp4", "tcp6":
+	default:
+		return nil, fmt.Errorf("socks5_proxy_address cannot be used with %s sockets", network)
+	}
+
+	dialer, err := sw.Socks5ProxyConfig.Dialer(0)
+	if err != nil {
+		return nil, err
+	}
+	return proxy.DialTLS(dialer, network, address, tlsCfg, 0)
+}
+
 func (sw *SocketWriter) setKeepAlive(c net.Conn) error {
 	if sw.KeepAlivePeriod == nil {
 		return nil
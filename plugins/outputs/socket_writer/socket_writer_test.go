@@ -30,6 +30,33 @@ func TestSocketWriter_tcp(t *testing.T) {
 	testSocketWriter_stream(t, sw, lconn)
 }
 
+func TestSocketWriter_tcpSocks5(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	proxy, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer proxy.Close()
+	go testutil.ServeSocks5(t, proxy, "", "")
+
+	sw := newSocketWriter()
+	sw.Address = "tcp://" + listener.Addr().String()
+	sw.Socks5ProxyAddress = proxy.Addr().String()
+
+	err = sw.Connect()
+	require.NoError(t, err)
+
+	lconn, err := listener.Accept()
+	require.NoError(t, err)
+
+	testSocketWriter_stream(t, sw, lconn)
+
+	// Only TCP is proxied
+	sw = newSocketWriter()
+	sw.Address = "udp://127.0.0.1:8094"
+	sw.Socks5ProxyAddress = proxy.Addr().String()
+	require.Error(t, sw.Connect())
+}
+
 func TestSocketWriter_udp(t *testing.T) {
 	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
 	require.NoError(t, err)
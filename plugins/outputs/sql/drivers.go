@@ -0,0 +1,9 @@
+package sql
+
+import (
+	// Register the drivers
+	_ "github.com/ClickHouse/clickhouse-go"
+	_ "github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/stdlib"
+	_ "github.com/zensqlmonitor/go-mssqldb"
+)
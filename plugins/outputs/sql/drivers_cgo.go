@@ -0,0 +1,8 @@
+// +build cgo
+
+package sql
+
+import (
+	// Register the drivers requiring cgo
+	_ "github.com/mattn/go-sqlite3"
+)
@@ -0,0 +1,95 @@
+// +build cgo
+
+package sql
+
+import (
+	dbsql "database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteSQLite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-sql")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	dsn := "file:" + filepath.Join(dir, "metrics.db")
+
+	s := &SQL{
+		Driver:          "sqlite",
+		DSN:             dsn,
+		TimestampColumn: "timestamp",
+		CreateTables:    true,
+		AddColumns:      true,
+		TableTemplates: map[string]string{
+			"mem": "CREATE TABLE {TABLE} ({COLUMNS}, note TEXT DEFAULT 'custom')",
+		},
+		Timeout: internal.Duration{Duration: 5 * time.Second},
+	}
+	require.NoError(t, s.Connect())
+	defer s.Close()
+
+	require.NoError(t, s.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 0)),
+		testutil.MustMetric("mem", map[string]string{"host": "a"}, map[string]interface{}{"used": int64(7)}, time.Unix(1500000000, 0)),
+	}))
+	// The new fields are added to the table
+	require.NoError(t, s.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "b"}, map[string]interface{}{"usage": 1.0, "ok": true}, time.Unix(1500000000, 0)),
+	}))
+
+	db, err := dbsql.Open("sqlite3", dsn)
+	require.NoError(t, err)
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT host, usage, ok FROM cpu ORDER BY host`)
+	require.NoError(t, err)
+	defer rows.Close()
+
+	type row struct {
+		host  string
+		usage float64
+		ok    dbsql.NullBool
+	}
+	var result []row
+	for rows.Next() {
+		var r row
+		require.NoError(t, rows.Scan(&r.host, &r.usage, &r.ok))
+		result = append(result, r)
+	}
+	require.Equal(t, []row{
+		{"a", 42.5, dbsql.NullBool{}},
+		{"b", 1.0, dbsql.NullBool{Bool: true, Valid: true}},
+	}, result)
+
+	var note string
+	var used int64
+	require.NoError(t, db.QueryRow(`SELECT note, used FROM mem`).Scan(&note, &used))
+	require.Equal(t, "custom", note)
+	require.Equal(t, int64(7), used)
+}
+
+func TestWriteSQLiteWithoutCreate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "telegraf-sql")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	s := &SQL{
+		Driver:          "sqlite",
+		DSN:             "file:" + filepath.Join(dir, "metrics.db"),
+		TimestampColumn: "timestamp",
+		Timeout:         internal.Duration{Duration: 5 * time.Second},
+	}
+	require.NoError(t, s.Connect())
+	defer s.Close()
+
+	err = s.Write([]telegraf.Metric{testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 0))})
+	require.Error(t, err)
+}
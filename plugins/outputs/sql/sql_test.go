@@ -0,0 +1,78 @@
+package sql
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableColumns(t *testing.T) {
+	s := &SQL{
+		TimestampColumn: "timestamp",
+		dialect:         dialects["postgres"],
+		types:           dialects["postgres"].types,
+	}
+
+	tbl := &table{
+		name: "cpu",
+		metrics: []telegraf.Metric{
+			testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 42.5, "host": "b"}, time.Unix(1500000000, 0)),
+			testutil.MustMetric("cpu", map[string]string{"cpu": "cpu0"}, map[string]interface{}{"count": uint64(1), "ok": true}, time.Unix(1500000000, 0)),
+		},
+	}
+	s.tableColumns(tbl)
+
+	require.Equal(t, []string{"timestamp", "cpu", "host", "count", "ok", "usage"}, tbl.columns)
+	require.Equal(t, map[string]string{
+		"timestamp": "TIMESTAMP WITH TIME ZONE",
+		"cpu":       "TEXT",
+		"host":      "TEXT",
+		"count":     "BIGINT",
+		"ok":        "BOOLEAN",
+		"usage":     "DOUBLE PRECISION",
+	}, tbl.types)
+
+	// Tags win over the fields of the same name
+	require.Equal(t, "a", s.value(tbl.metrics[0], "host"))
+}
+
+func TestValue(t *testing.T) {
+	s := &SQL{TimestampColumn: "time"}
+
+	m := testutil.MustMetric("cpu", nil, map[string]interface{}{"big": uint64(math.MaxUint64), "small": uint64(1)}, time.Unix(1500000000, 0))
+	require.Equal(t, m.Time().UTC(), s.value(m, "time"))
+	require.Equal(t, int64(math.MaxInt64), s.value(m, "big"))
+	require.Equal(t, uint64(1), s.value(m, "small"))
+	require.Nil(t, s.value(m, "missing"))
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	tests := []struct {
+		driver   string
+		expected string
+	}{
+		{"mysql", "`a``b`"},
+		{"postgres", `"a` + "`" + `b"`},
+		{"mssql", "[a`b]"},
+	}
+
+	for _, tt := range tests {
+		s := &SQL{dialect: dialects[tt.driver]}
+		require.Equal(t, tt.expected, s.quoteIdentifier("a`b"), tt.driver)
+	}
+
+	s := &SQL{dialect: dialects["mssql"]}
+	require.Equal(t, "[a]]b]", s.quoteIdentifier("a]b"))
+}
+
+func TestConnectUnknown(t *testing.T) {
+	s := &SQL{Driver: "oracle"}
+	require.Error(t, s.Connect())
+
+	s = &SQL{Driver: "mysql", Convert: map[string]string{"decimal": "DECIMAL"}}
+	require.Error(t, s.Connect())
+}
@@ -0,0 +1,402 @@
+package timestream
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/timestreamwrite"
+	"github.com/aws/aws-sdk-go/service/timestreamwrite/timestreamwriteiface"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	internalaws "github.com/influxdata/telegraf/internal/config/aws"
+	"github.com/influxdata/telegraf/plugins/outputs"
+)
+
+const (
+	mappingModeSingleTable = "single-table"
+	mappingModeMultiTable  = "multi-table"
+
+	// maxRecordsPerRequest is the maximum number of records of a request.
+	maxRecordsPerRequest = 100
+)
+
+var sampleConfig = `
+  ## Amazon REGION
+  region = "us-east-1"
+
+  ## Amazon Credentials
+  ## Credentials are loaded in the following order
+  ## 1) Assumed credentials via STS if role_arn is specified
+  ## 2) explicit credentials from 'access_key' and 'secret_key'
+  ## 3) shared profile from 'profile'
+  ## 4) environment variables
+  ## 5) shared credentials file
+  ## 6) EC2 Instance Profile
+  #access_key = ""
+  #secret_key = ""
+  #token = ""
+  #role_arn = ""
+  #profile = ""
+  #shared_credential_file = ""
+
+  ## Database of the tables.
+  database_name = "telegraf"
+
+  ## Tables the metrics are written in: "multi-table" for a table per
+  ## measurement, named after it, or "single-table" for the single_table_name
+  ## table, the measurement name being the value of the
+  ## single_table_dimension_name_for_telegraf_measurement_name dimension.
+  mapping_mode = "multi-table"
+  # single_table_name = "telegraf"
+  # single_table_dimension_name_for_telegraf_measurement_name = "namespace"
+
+  ## Write the fields of a metric in a multi-measure record, named after
+  ## measure_name_for_multi_measure_records or after the measurement when
+  ## empty, instead of a record per field, reducing the cost of the writes.
+  # use_multi_measure_records = true
+  # measure_name_for_multi_measure_records = ""
+
+  ## Create the database when it does not exist.
+  # create_database_if_not_exists = false
+
+  ## Create the tables when they do not exist, with the retention periods of
+  ## the memory and magnetic stores and the tags below.
+  # create_table_if_not_exists = true
+  # create_table_memory_store_retention_period_in_hours = 24
+  # create_table_magnetic_store_retention_period_in_days = 365
+  # [outputs.timestream.create_table_tags]
+  #   namespace = "telegraf"
+
+  ## Timeout of the requests.
+  # timeout = "30s"
+`
+
+type Timestream struct {
+	Region    string `toml:"region"`
+	AccessKey string `toml:"access_key"`
+	SecretKey string `toml:"secret_key"`
+	RoleARN   string `toml:"role_arn"`
+	Profile   string `toml:"profile"`
+	Filename  string `toml:"shared_credential_file"`
+	Token     string `toml:"token"`
+
+	DatabaseName                           string            `toml:"database_name"`
+	MappingMode                            string            `toml:"mapping_mode"`
+	SingleTableName                        string            `toml:"single_table_name"`
+	SingleTableDimensionNameForMeasurement string            `toml:"single_table_dimension_name_for_telegraf_measurement_name"`
+	UseMultiMeasureRecords                 bool              `toml:"use_multi_measure_records"`
+	MeasureNameForMultiMeasureRecords      string            `toml:"measure_name_for_multi_measure_records"`
+	CreateDatabaseIfNotExists              bool              `toml:"create_database_if_not_exists"`
+	CreateTableIfNotExists                 bool              `toml:"create_table_if_not_exists"`
+	CreateTableMemoryStoreRetentionHours   int64             `toml:"create_table_memory_store_retention_period_in_hours"`
+	CreateTableMagneticStoreRetentionDays  int64             `toml:"create_table_magnetic_store_retention_period_in_days"`
+	CreateTableTags                        map[string]string `toml:"create_table_tags"`
+	Timeout                                internal.Duration `toml:"timeout"`
+
+	svc timestreamwriteiface.TimestreamWriteAPI
+}
+
+func (t *Timestream) SampleConfig() string {
+	return sampleConfig
+}
+
+func (t *Timestream) Description() string {
+	return "Write metrics to Amazon Timestream"
+}
+
+func (t *Timestream) Connect() error {
+	if t.DatabaseName == "" {
+		return fmt.Errorf("database_name is a required field for timestream output")
+	}
+	switch t.MappingMode {
+	case mappingModeMultiTable:
+	case mappingModeSingleTable:
+		if t.SingleTableName == "" {
+			return fmt.Errorf("single_table_name is required with the %s mapping_mode", mappingModeSingleTable)
+		}
+		if t.SingleTableDimensionNameForMeasurement == "" {
+			return fmt.Errorf("single_table_dimension_name_for_telegraf_measurement_name is required with the %s mapping_mode",
+				mappingModeSingleTable)
+		}
+	default:
+		return fmt.Errorf("unknown mapping_mode %q", t.MappingMode)
+	}
+
+	credentialConfig := &internalaws.CredentialConfig{
+		Region:    t.Region,
+		AccessKey: t.AccessKey,
+		SecretKey: t.SecretKey,
+		RoleARN:   t.RoleARN,
+		Profile:   t.Profile,
+		Filename:  t.Filename,
+		Token:     t.Token,
+	}
+	return t.connect(timestreamwrite.New(credentialConfig.Credentials()))
+}
+
+// connect checks that the database exists, creating it if enabled, and sets
+// the client of the service.
+func (t *Timestream) connect(svc timestreamwriteiface.TimestreamWriteAPI) error {
+	t.svc = svc
+
+	ctx, cancel := context.WithTimeout(context.Background(), t.Timeout.Duration)
+	defer cancel()
+	_, err := svc.DescribeDatabaseWithContext(ctx, &timestreamwrite.DescribeDatabaseInput{
+		DatabaseName: aws.String(t.DatabaseName),
+	})
+	if err == nil {
+		return nil
+	}
+	if !isNotFound(err) || !t.CreateDatabaseIfNotExists {
+		return fmt.Errorf("describing database %s failed: %v", t.DatabaseName, err)
+	}
+
+	log.Printf("I! [outputs.timestream] creating database %s", t.DatabaseName)
+	_, err = svc.CreateDatabaseWithContext(ctx, &timestreamwrite.CreateDatabaseInput{
+		DatabaseName: aws.String(t.DatabaseName),
+	})
+	if err != nil {
+		return fmt.Errorf("creating database %s failed: %v", t.DatabaseName, err)
+	}
+	return nil
+}
+
+func (t *Timestream) Close() error {
+	return nil
+}
+
+func (t *Timestream) Write(metrics []telegraf.Metric) error {
+	// The records by table, in the order of the metrics.
+	var tables []string
+	records := make(map[string][]*timestreamwrite.Record)
+	for _, metric := range metrics {
+		table := metric.Name()
+		if t.MappingMode == mappingModeSingleTable {
+			table = t.SingleTableName
+		}
+
+		rs := t.records(metric)
+		if len(rs) == 0 {
+			continue
+		}
+		if _, ok := records[table]; !ok {
+			tables = append(tables, table)
+		}
+		records[table] = append(records[table], rs...)
+	}
+
+	for _, table := range tables {
+		rs := records[table]
+		for len(rs) > 0 {
+			n := len(rs)
+			if n > maxRecordsPerRequest {
+				n = maxRecordsPerRequest
+			}
+			if err := t.writeRecords(table, rs[:n]); err != nil {
+				return err
+			}
+			rs = rs[n:]
+		}
+	}
+	return nil
+}
+
+// records returns the records of a metric, with its tags as dimensions.
+func (t *Timestream) records(metric telegraf.Metric) []*timestreamwrite.Record {
+	var dimensions []*timestreamwrite.Dimension
+	for _, tag := range metric.TagList() {
+		dimensions = append(dimensions, &timestreamwrite.Dimension{
+			Name:  aws.String(tag.Key),
+			Value: aws.String(tag.Value),
+		})
+	}
+	if t.MappingMode == mappingModeSingleTable {
+		dimensions = append(dimensions, &timestreamwrite.Dimension{
+			Name:  aws.String(t.SingleTableDimensionNameForMeasurement),
+			Value: aws.String(metric.Name()),
+		})
+	}
+	if len(dimensions) == 0 {
+		log.Printf("W! [outputs.timestream] dropping metric %s without tags, a dimension being required", metric.Name())
+		return nil
+	}
+	sort.Slice(dimensions, func(i, j int) bool {
+		return *dimensions[i].Name < *dimensions[j].Name
+	})
+	timestamp := aws.String(strconv.FormatInt(metric.Time().UnixNano(), 10))
+
+	var values []*timestreamwrite.MeasureValue
+	for _, field := range metric.FieldList() {
+		value, valueType, ok := convertValue(field.Value)
+		if !ok {
+			continue
+		}
+		values = append(values, &timestreamwrite.MeasureValue{
+			Name:  aws.String(field.Key),
+			Value: aws.String(value),
+			Type:  aws.String(valueType),
+		})
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	if t.UseMultiMeasureRecords {
+		name := t.MeasureNameForMultiMeasureRecords
+		if name == "" {
+			name = metric.Name()
+		}
+		return []*timestreamwrite.Record{{
+			Dimensions:       dimensions,
+			MeasureName:      aws.String(name),
+			MeasureValueType: aws.String(timestreamwrite.MeasureValueTypeMulti),
+			MeasureValues:    values,
+			Time:             timestamp,
+			TimeUnit:         aws.String(timestreamwrite.TimeUnitNanoseconds),
+		}}
+	}
+
+	records := make([]*timestreamwrite.Record, 0, len(values))
+	for _, value := range values {
+		records = append(records, &timestreamwrite.Record{
+			Dimensions:       dimensions,
+			MeasureName:      value.Name,
+			MeasureValue:     value.Value,
+			MeasureValueType: value.Type,
+			Time:             timestamp,
+			TimeUnit:         aws.String(timestreamwrite.TimeUnitNanoseconds),
+		})
+	}
+	return records
+}
+
+func convertValue(v interface{}) (string, string, bool) {
+	switch v := v.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10), timestreamwrite.MeasureValueTypeBigint, true
+	case uint64:
+		return strconv.FormatUint(v, 10), timestreamwrite.MeasureValueTypeBigint, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), timestreamwrite.MeasureValueTypeDouble, true
+	case bool:
+		return strconv.FormatBool(v), timestreamwrite.MeasureValueTypeBoolean, true
+	case string:
+		return v, timestreamwrite.MeasureValueTypeVarchar, true
+	default:
+		return "", "", false
+	}
+}
+
+// writeRecords writes the records of a table, creating the table if it does
+// not exist and it is enabled.  The records rejected are logged and dropped.
+func (t *Timestream) writeRecords(table string, records []*timestreamwrite.Record) error {
+	err := t.write(table, records)
+	if isNotFound(err) && t.CreateTableIfNotExists {
+		if err := t.createTable(table); err != nil {
+			return err
+		}
+		err = t.write(table, records)
+	}
+	if err == nil {
+		return nil
+	}
+
+	switch e := err.(type) {
+	case *timestreamwrite.RejectedRecordsException:
+		// The records would be rejected again, such as the ones of a series
+		// written with another value at the same time.
+		for _, rejected := range e.RejectedRecords {
+			if rejected.RecordIndex == nil || int(*rejected.RecordIndex) >= len(records) {
+				continue
+			}
+			log.Printf("E! [outputs.timestream] record of %s rejected by table %s: %s",
+				series(records[*rejected.RecordIndex]), table, aws.StringValue(rejected.Reason))
+		}
+		return nil
+	case *timestreamwrite.ValidationException:
+		log.Printf("E! [outputs.timestream] dropping %d records invalid for table %s: %v", len(records), table, err)
+		return nil
+	}
+	return fmt.Errorf("writing to table %s failed: %v", table, err)
+}
+
+func (t *Timestream) write(table string, records []*timestreamwrite.Record) error {
+	ctx, cancel := context.WithTimeout(context.Background(), t.Timeout.Duration)
+	defer cancel()
+	_, err := t.svc.WriteRecordsWithContext(ctx, &timestreamwrite.WriteRecordsInput{
+		DatabaseName: aws.String(t.DatabaseName),
+		TableName:    aws.String(table),
+		Records:      records,
+	})
+	return err
+}
+
+func (t *Timestream) createTable(table string) error {
+	log.Printf("I! [outputs.timestream] creating table %s", table)
+	input := &timestreamwrite.CreateTableInput{
+		DatabaseName: aws.String(t.DatabaseName),
+		TableName:    aws.String(table),
+		RetentionProperties: &timestreamwrite.RetentionProperties{
+			MemoryStoreRetentionPeriodInHours:  aws.Int64(t.CreateTableMemoryStoreRetentionHours),
+			MagneticStoreRetentionPeriodInDays: aws.Int64(t.CreateTableMagneticStoreRetentionDays),
+		},
+	}
+	keys := make([]string, 0, len(t.CreateTableTags))
+	for key := range t.CreateTableTags {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		input.Tags = append(input.Tags, &timestreamwrite.Tag{
+			Key:   aws.String(key),
+			Value: aws.String(t.CreateTableTags[key]),
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), t.Timeout.Duration)
+	defer cancel()
+	_, err := t.svc.CreateTableWithContext(ctx, input)
+	if err != nil {
+		// The table may have been created in the meantime.
+		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == timestreamwrite.ErrCodeConflictException {
+			return nil
+		}
+		return fmt.Errorf("creating table %s failed: %v", table, err)
+	}
+	return nil
+}
+
+// series returns the description of the series of a record, for the logs.
+func series(record *timestreamwrite.Record) string {
+	dimensions := make([]string, 0, len(record.Dimensions))
+	for _, d := range record.Dimensions {
+		dimensions = append(dimensions, aws.StringValue(d.Name)+"="+aws.StringValue(d.Value))
+	}
+	return fmt.Sprintf("%s{%s}", aws.StringValue(record.MeasureName), strings.Join(dimensions, ","))
+}
+
+func isNotFound(err error) bool {
+	aerr, ok := err.(awserr.Error)
+	return ok && aerr.Code() == timestreamwrite.ErrCodeResourceNotFoundException
+}
+
+func init() {
+	outputs.Add("timestream", func() telegraf.Output {
+		return &Timestream{
+			MappingMode:                           mappingModeMultiTable,
+			UseMultiMeasureRecords:                true,
+			CreateTableIfNotExists:                true,
+			CreateTableMemoryStoreRetentionHours:  24,
+			CreateTableMagneticStoreRetentionDays: 365,
+			Timeout:                               internal.Duration{Duration: 30 * time.Second},
+		}
+	})
+}
@@ -0,0 +1,203 @@
+package timestream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/timestreamwrite"
+	"github.com/aws/aws-sdk-go/service/timestreamwrite/timestreamwriteiface"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+// fakeTimestream records the writes to the existing tables.
+type fakeTimestream struct {
+	timestreamwriteiface.TimestreamWriteAPI
+
+	databases []string
+	tables    map[string]*timestreamwrite.CreateTableInput
+	writes    []*timestreamwrite.WriteRecordsInput
+	writeErr  error
+}
+
+func newFakeTimestream(databases ...string) *fakeTimestream {
+	return &fakeTimestream{databases: databases, tables: make(map[string]*timestreamwrite.CreateTableInput)}
+}
+
+func notFound() error {
+	return awserr.New(timestreamwrite.ErrCodeResourceNotFoundException, "not found", nil)
+}
+
+func (f *fakeTimestream) DescribeDatabaseWithContext(ctx aws.Context, input *timestreamwrite.DescribeDatabaseInput, opts ...request.Option) (*timestreamwrite.DescribeDatabaseOutput, error) {
+	for _, database := range f.databases {
+		if database == *input.DatabaseName {
+			return &timestreamwrite.DescribeDatabaseOutput{}, nil
+		}
+	}
+	return nil, notFound()
+}
+
+func (f *fakeTimestream) CreateDatabaseWithContext(ctx aws.Context, input *timestreamwrite.CreateDatabaseInput, opts ...request.Option) (*timestreamwrite.CreateDatabaseOutput, error) {
+	f.databases = append(f.databases, *input.DatabaseName)
+	return &timestreamwrite.CreateDatabaseOutput{}, nil
+}
+
+func (f *fakeTimestream) CreateTableWithContext(ctx aws.Context, input *timestreamwrite.CreateTableInput, opts ...request.Option) (*timestreamwrite.CreateTableOutput, error) {
+	f.tables[*input.TableName] = input
+	return &timestreamwrite.CreateTableOutput{}, nil
+}
+
+func (f *fakeTimestream) WriteRecordsWithContext(ctx aws.Context, input *timestreamwrite.WriteRecordsInput, opts ...request.Option) (*timestreamwrite.WriteRecordsOutput, error) {
+	if _, ok := f.tables[*input.TableName]; !ok {
+		return nil, notFound()
+	}
+	if f.writeErr != nil {
+		return nil, f.writeErr
+	}
+	f.writes = append(f.writes, input)
+	return &timestreamwrite.WriteRecordsOutput{}, nil
+}
+
+func newTimestream() *Timestream {
+	return &Timestream{
+		DatabaseName:                          "telegraf",
+		MappingMode:                           mappingModeMultiTable,
+		UseMultiMeasureRecords:                true,
+		CreateTableIfNotExists:                true,
+		CreateTableMemoryStoreRetentionHours:  24,
+		CreateTableMagneticStoreRetentionDays: 365,
+		Timeout:                               internal.Duration{Duration: 5 * time.Second},
+	}
+}
+
+func dimension(name, value string) *timestreamwrite.Dimension {
+	return &timestreamwrite.Dimension{Name: aws.String(name), Value: aws.String(value)}
+}
+
+func TestWriteMultiTable(t *testing.T) {
+	f := newFakeTimestream("telegraf")
+	ts := newTimestream()
+	ts.CreateTableTags = map[string]string{"team": "ops"}
+	require.NoError(t, ts.connect(f))
+
+	require.NoError(t, ts.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a", "cpu": "cpu0"}, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 0)),
+		testutil.MustMetric("mem", map[string]string{"host": "a"}, map[string]interface{}{"used": int64(1024)}, time.Unix(1500000000, 0)),
+		testutil.MustMetric("cpu", map[string]string{"host": "b", "cpu": "cpu0"}, map[string]interface{}{"usage": 43.5}, time.Unix(1500000000, 0)),
+	}))
+
+	// The missing tables are created
+	require.Len(t, f.tables, 2)
+	require.Equal(t, int64(24), *f.tables["cpu"].RetentionProperties.MemoryStoreRetentionPeriodInHours)
+	require.Equal(t, []*timestreamwrite.Tag{{Key: aws.String("team"), Value: aws.String("ops")}}, f.tables["cpu"].Tags)
+
+	require.Len(t, f.writes, 2)
+	require.Equal(t, "cpu", *f.writes[0].TableName)
+	require.Len(t, f.writes[0].Records, 2)
+	require.Equal(t, &timestreamwrite.Record{
+		Dimensions:       []*timestreamwrite.Dimension{dimension("cpu", "cpu0"), dimension("host", "a")},
+		MeasureName:      aws.String("cpu"),
+		MeasureValueType: aws.String("MULTI"),
+		MeasureValues: []*timestreamwrite.MeasureValue{
+			{Name: aws.String("usage"), Value: aws.String("42.5"), Type: aws.String("DOUBLE")},
+		},
+		Time:     aws.String("1500000000000000000"),
+		TimeUnit: aws.String("NANOSECONDS"),
+	}, f.writes[0].Records[0])
+	require.Equal(t, "mem", *f.writes[1].TableName)
+}
+
+func TestWriteSingleTable(t *testing.T) {
+	f := newFakeTimestream("telegraf")
+	ts := newTimestream()
+	ts.MappingMode = mappingModeSingleTable
+	ts.SingleTableName = "metrics"
+	ts.SingleTableDimensionNameForMeasurement = "namespace"
+	ts.UseMultiMeasureRecords = false
+	require.NoError(t, ts.connect(f))
+
+	require.NoError(t, ts.Write([]telegraf.Metric{
+		testutil.MustMetric("mem", nil, map[string]interface{}{"available": true}, time.Unix(1500000000, 0)),
+	}))
+
+	require.Len(t, f.writes, 1)
+	require.Equal(t, "metrics", *f.writes[0].TableName)
+	require.Equal(t, []*timestreamwrite.Record{{
+		Dimensions:       []*timestreamwrite.Dimension{dimension("namespace", "mem")},
+		MeasureName:      aws.String("available"),
+		MeasureValue:     aws.String("true"),
+		MeasureValueType: aws.String("BOOLEAN"),
+		Time:             aws.String("1500000000000000000"),
+		TimeUnit:         aws.String("NANOSECONDS"),
+	}}, f.writes[0].Records)
+}
+
+func TestWriteSplit(t *testing.T) {
+	f := newFakeTimestream("telegraf")
+	ts := newTimestream()
+	require.NoError(t, ts.connect(f))
+
+	var metrics []telegraf.Metric
+	for i := 0; i < maxRecordsPerRequest+1; i++ {
+		metrics = append(metrics, testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": float64(i)}, time.Unix(1500000000, 0)))
+	}
+	require.NoError(t, ts.Write(metrics))
+
+	require.Len(t, f.writes, 2)
+	require.Len(t, f.writes[0].Records, maxRecordsPerRequest)
+	require.Len(t, f.writes[1].Records, 1)
+}
+
+func TestWriteErrors(t *testing.T) {
+	f := newFakeTimestream("telegraf")
+	f.tables["cpu"] = &timestreamwrite.CreateTableInput{}
+	ts := newTimestream()
+	require.NoError(t, ts.connect(f))
+	metrics := []telegraf.Metric{
+		testutil.MustMetric("cpu", map[string]string{"host": "a"}, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 0)),
+	}
+
+	// The rejected records are dropped
+	f.writeErr = &timestreamwrite.RejectedRecordsException{
+		Message_: aws.String("rejected"),
+		RejectedRecords: []*timestreamwrite.RejectedRecord{
+			{RecordIndex: aws.Int64(0), Reason: aws.String("duplicate")},
+		},
+	}
+	require.NoError(t, ts.Write(metrics))
+
+	// The throttled writes are retried with the next flush
+	f.writeErr = awserr.New(timestreamwrite.ErrCodeThrottlingException, "throttled", nil)
+	require.Error(t, ts.Write(metrics))
+
+	// The tables are only created if enabled
+	ts.CreateTableIfNotExists = false
+	f.writeErr = nil
+	require.Error(t, ts.Write([]telegraf.Metric{
+		testutil.MustMetric("mem", map[string]string{"host": "a"}, map[string]interface{}{"used": 1.5}, time.Unix(1500000000, 0)),
+	}))
+}
+
+func TestConnectDatabase(t *testing.T) {
+	ts := newTimestream()
+	require.Error(t, ts.connect(newFakeTimestream()))
+
+	f := newFakeTimestream()
+	ts.CreateDatabaseIfNotExists = true
+	require.NoError(t, ts.connect(f))
+	require.Equal(t, []string{"telegraf"}, f.databases)
+}
+
+func TestSeries(t *testing.T) {
+	record := &timestreamwrite.Record{
+		Dimensions:  []*timestreamwrite.Dimension{dimension("cpu", "cpu0"), dimension("host", "a")},
+		MeasureName: aws.String("cpu"),
+	}
+	require.Equal(t, "cpu{cpu=cpu0,host=a}", series(record))
+}
@@ -0,0 +1,244 @@
+package websocket
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+
+	ws "github.com/gorilla/websocket"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/internal/tls"
+	"github.com/influxdata/telegraf/plugins/outputs"
+	"github.com/influxdata/telegraf/plugins/serializers"
+)
+
+const (
+	// Delays between the reconnection attempts, doubling after each failed
+	// attempt.
+	minReconnectDelay = time.Second
+	maxReconnectDelay = time.Minute
+)
+
+var sampleConfig = `
+  ## URL is the address to send metrics to, with the ws or wss scheme.
+  url = "ws://127.0.0.1:8080/telegraf"
+
+  ## Timeouts of the connection and of the writes.
+  # connect_timeout = "30s"
+  # write_timeout = "30s"
+
+  ## Send the messages as text frames instead of binary frames.
+  # use_text_frames = false
+
+  ## When true, metrics will be sent in one message per flush.  Otherwise,
+  ## metrics are written one metric per message.
+  # batch = false
+
+  ## Additional HTTP headers of the handshake, such as for the authentication.
+  # [outputs.websocket.headers]
+  #   Authorization = "Bearer token"
+
+  ## Optional TLS Config
+  # tls_ca = "/etc/telegraf/ca.pem"
+  # tls_cert = "/etc/telegraf/cert.pem"
+  # tls_key = "/etc/telegraf/key.pem"
+  ## Use TLS but skip chain & host verification
+  # insecure_skip_verify = false
+
+  ## Data format to output.
+  ## Each data format has its own unique set of configuration options, read
+  ## more about them here:
+  ## https://github.com/influxdata/telegraf/blob/master/docs/DATA_FORMATS_OUTPUT.md
+  data_format = "influx"
+`
+
+type WebSocket struct {
+	URL            string            `toml:"url"`
+	ConnectTimeout internal.Duration `toml:"connect_timeout"`
+	WriteTimeout   internal.Duration `toml:"write_timeout"`
+	UseTextFrames  bool              `toml:"use_text_frames"`
+	BatchMessage   bool              `toml:"batch"`
+	Headers        map[string]string `toml:"headers"`
+	tls.ClientConfig
+
+	dialer     *ws.Dialer
+	serializer serializers.Serializer
+
+	conn *ws.Conn
+	// closed is closed once the connection is lost, when reading from it
+	// fails.
+	closed chan struct{}
+
+	reconnectDelay time.Duration
+	nextReconnect  time.Time
+	now            func() time.Time
+}
+
+func (w *WebSocket) SampleConfig() string {
+	return sampleConfig
+}
+
+func (w *WebSocket) Description() string {
+	return "Generic WebSocket output writer."
+}
+
+func (w *WebSocket) SetSerializer(serializer serializers.Serializer) {
+	w.serializer = serializer
+}
+
+func (w *WebSocket) Connect() error {
+	u, err := url.Parse(w.URL)
+	if err != nil {
+		return fmt.Errorf("invalid url %q: %v", w.URL, err)
+	}
+	if u.Scheme != "ws" && u.Scheme != "wss" {
+		return fmt.Errorf("unsupported scheme %q of url, expected ws or wss", u.Scheme)
+	}
+
+	tlsCfg, err := w.ClientConfig.TLSConfig()
+	if err != nil {
+		return err
+	}
+	w.dialer = &ws.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: w.ConnectTimeout.Duration,
+		TLSClientConfig:  tlsCfg,
+	}
+	if w.now == nil {
+		w.now = time.Now
+	}
+
+	return w.dial()
+}
+
+func (w *WebSocket) dial() error {
+	headers := http.Header{}
+	for k, v := range w.Headers {
+		headers.Set(k, v)
+	}
+
+	conn, resp, err := w.dialer.Dial(w.URL, headers)
+	if err != nil {
+		if resp != nil {
+			return fmt.Errorf("connecting to %s failed with status %s: %v", w.URL, resp.Status, err)
+		}
+		return fmt.Errorf("connecting to %s failed: %v", w.URL, err)
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		// The messages are discarded, the reads handling the pings and the
+		// close messages of the server.
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				close(closed)
+				return
+			}
+		}
+	}()
+
+	w.conn = conn
+	w.closed = closed
+	return nil
+}
+
+// connected returns whether the connection is open, connecting again if it
+// is not, after the reconnection delay.
+func (w *WebSocket) connected() error {
+	if w.conn != nil {
+		select {
+		case <-w.closed:
+			log.Printf("W! [outputs.websocket] connection to %s lost", w.URL)
+			w.conn.Close()
+			w.conn = nil
+		default:
+			return nil
+		}
+	}
+
+	now := w.now()
+	if now.Before(w.nextReconnect) {
+		return fmt.Errorf("not connected to %s, reconnecting in %s", w.URL, w.nextReconnect.Sub(now))
+	}
+	if err := w.dial(); err != nil {
+		if w.reconnectDelay == 0 {
+			w.reconnectDelay = minReconnectDelay
+		} else if w.reconnectDelay *= 2; w.reconnectDelay > maxReconnectDelay {
+			w.reconnectDelay = maxReconnectDelay
+		}
+		w.nextReconnect = now.Add(w.reconnectDelay)
+		return err
+	}
+	w.reconnectDelay = 0
+	return nil
+}
+
+func (w *WebSocket) Write(metrics []telegraf.Metric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+	if err := w.connected(); err != nil {
+		return err
+	}
+
+	if w.BatchMessage {
+		b, err := w.serializer.SerializeBatch(metrics)
+		if err != nil {
+			return fmt.Errorf("failed to serialize message: %s", err)
+		}
+		return w.write(b)
+	}
+
+	for _, metric := range metrics {
+		b, err := w.serializer.Serialize(metric)
+		if err != nil {
+			return fmt.Errorf("failed to serialize message: %s", err)
+		}
+		if err := w.write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *WebSocket) write(b []byte) error {
+	messageType := ws.BinaryMessage
+	if w.UseTextFrames {
+		messageType = ws.TextMessage
+	}
+
+	if w.WriteTimeout.Duration > 0 {
+		w.conn.SetWriteDeadline(time.Now().Add(w.WriteTimeout.Duration))
+	}
+	if err := w.conn.WriteMessage(messageType, b); err != nil {
+		// The connection is opened again with the next write.
+		w.conn.Close()
+		w.conn = nil
+		return fmt.Errorf("writing to %s failed: %v", w.URL, err)
+	}
+	return nil
+}
+
+func (w *WebSocket) Close() error {
+	if w.conn == nil {
+		return nil
+	}
+	message := ws.FormatCloseMessage(ws.CloseNormalClosure, "")
+	w.conn.WriteControl(ws.CloseMessage, message, time.Now().Add(time.Second))
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
+
+func init() {
+	outputs.Add("websocket", func() telegraf.Output {
+		return &WebSocket{
+			ConnectTimeout: internal.Duration{Duration: 30 * time.Second},
+			WriteTimeout:   internal.Duration{Duration: 30 * time.Second},
+		}
+	})
+}
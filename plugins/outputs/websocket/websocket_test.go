@@ -0,0 +1,199 @@
+package websocket
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	ws "github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/serializers/influx"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+type message struct {
+	messageType int
+	data        string
+}
+
+// fakeServer is a websocket server recording the handshakes and messages,
+// closing the connections after a message when drop is set.
+type fakeServer struct {
+	*httptest.Server
+
+	sync.Mutex
+	headers  []http.Header
+	messages chan message
+	drop     bool
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	s := &fakeServer{messages: make(chan message, 100)}
+	upgrader := ws.Upgrader{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		s.Lock()
+		s.headers = append(s.headers, r.Header)
+		s.Unlock()
+		for {
+			messageType, data, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+			s.messages <- message{messageType, string(data)}
+
+			s.Lock()
+			drop := s.drop
+			s.Unlock()
+			if drop {
+				return
+			}
+		}
+	}))
+	return s
+}
+
+func (s *fakeServer) url() string {
+	return "ws" + strings.TrimPrefix(s.URL, "http") + "/telegraf"
+}
+
+func (s *fakeServer) next(t *testing.T) message {
+	select {
+	case m := <-s.messages:
+		return m
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+	}
+	return message{}
+}
+
+func newWebSocket(url string) *WebSocket {
+	w := &WebSocket{
+		URL:            url,
+		ConnectTimeout: internal.Duration{Duration: 5 * time.Second},
+		WriteTimeout:   internal.Duration{Duration: 5 * time.Second},
+	}
+	w.SetSerializer(influx.NewSerializer())
+	return w
+}
+
+func TestWrite(t *testing.T) {
+	s := newFakeServer(t)
+	defer s.Close()
+
+	w := newWebSocket(s.url())
+	w.Headers = map[string]string{"Authorization": "Bearer token"}
+	require.NoError(t, w.Connect())
+	defer w.Close()
+
+	require.NoError(t, w.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 0)),
+		testutil.MustMetric("mem", nil, map[string]interface{}{"used": 1.5}, time.Unix(1500000000, 0)),
+	}))
+
+	require.Equal(t, message{ws.BinaryMessage, "cpu usage=42.5 1500000000000000000\n"}, s.next(t))
+	require.Equal(t, message{ws.BinaryMessage, "mem used=1.5 1500000000000000000\n"}, s.next(t))
+
+	s.Lock()
+	defer s.Unlock()
+	require.Equal(t, "Bearer token", s.headers[0].Get("Authorization"))
+}
+
+func TestWriteBatch(t *testing.T) {
+	s := newFakeServer(t)
+	defer s.Close()
+
+	w := newWebSocket(s.url())
+	w.BatchMessage = true
+	w.UseTextFrames = true
+	require.NoError(t, w.Connect())
+	defer w.Close()
+
+	require.NoError(t, w.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 0)),
+		testutil.MustMetric("mem", nil, map[string]interface{}{"used": 1.5}, time.Unix(1500000000, 0)),
+	}))
+
+	require.Equal(t, message{
+		ws.TextMessage,
+		"cpu usage=42.5 1500000000000000000\nmem used=1.5 1500000000000000000\n",
+	}, s.next(t))
+}
+
+func TestWriteReconnect(t *testing.T) {
+	s := newFakeServer(t)
+	defer s.Close()
+	s.drop = true
+
+	w := newWebSocket(s.url())
+	require.NoError(t, w.Connect())
+	defer w.Close()
+
+	metrics := []telegraf.Metric{testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 0))}
+	require.NoError(t, w.Write(metrics))
+	s.next(t)
+
+	// Wait for the lost connection to be noticed
+	select {
+	case <-w.closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("connection not closed")
+	}
+
+	s.Lock()
+	s.drop = false
+	s.Unlock()
+	require.NoError(t, w.Write(metrics))
+	s.next(t)
+
+	s.Lock()
+	defer s.Unlock()
+	require.Len(t, s.headers, 2)
+}
+
+func TestWriteReconnectBackoff(t *testing.T) {
+	s := newFakeServer(t)
+	w := newWebSocket(s.url())
+	now := time.Unix(1500000000, 0)
+	w.now = func() time.Time { return now }
+	require.NoError(t, w.Connect())
+	defer w.Close()
+
+	// The server goes down, the connection being attempted again after the
+	// doubling delays
+	s.Lock()
+	s.drop = true
+	s.Unlock()
+	metrics := []telegraf.Metric{testutil.MustMetric("cpu", nil, map[string]interface{}{"usage": 42.5}, time.Unix(1500000000, 0))}
+	require.NoError(t, w.Write(metrics))
+	s.next(t)
+	<-w.closed
+	s.Close()
+
+	require.Error(t, w.Write(metrics))
+	require.Equal(t, time.Second, w.reconnectDelay)
+
+	err := w.Write(metrics)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "reconnecting in 1s")
+
+	now = now.Add(time.Second)
+	require.Error(t, w.Write(metrics))
+	require.Equal(t, 2*time.Second, w.reconnectDelay)
+}
+
+func TestConnectInvalidURL(t *testing.T) {
+	w := newWebSocket("http://127.0.0.1:8080/telegraf")
+	require.Error(t, w.Connect())
+}
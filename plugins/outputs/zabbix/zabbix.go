@@ -0,0 +1,385 @@
+package zabbix
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/plugins/outputs"
+)
+
+var sampleConfig = `
+  ## Address of the Zabbix server or proxy, receiving the metrics with the
+  ## trapper protocol.
+  address = "localhost:10051"
+
+  ## Timeout of the connection and of the requests.
+  # timeout = "5s"
+
+  ## Prefix of the keys of the items.
+  # key_prefix = "telegraf."
+
+  ## Tag of the Zabbix host of the metrics, the hostname of Telegraf being
+  ## used when the metrics do not have the tag.
+  # host_tag = "host"
+
+  ## Leave the measurement name out of the keys of the items, which are made
+  ## of the field name and the tag values otherwise, such as
+  ## "telegraf.cpu.usage_idle[cpu0]" for a metric with the tag cpu=cpu0.
+  # skip_measurement_prefix = false
+
+  ## Send low-level discovery (LLD) data with the tag values of the series,
+  ## whenever a new series is seen and every lld_send_interval, such as the
+  ## "telegraf.lld.cpu.cpu" discovery key with the {#CPU} macro.
+  # lld = false
+  # lld_send_interval = "10m"
+`
+
+const (
+	// lldPrefix is the prefix of the LLD keys, after the key prefix.
+	lldPrefix = "lld."
+
+	// maxResponseSize is the maximum size of the responses of the server.
+	maxResponseSize = 1 << 20
+)
+
+// header is the header of the packets of the Zabbix protocol, followed by
+// the little-endian length of the data.
+var header = []byte("ZBXD\x01")
+
+// processedRegexp matches the information of the responses of the server,
+// such as "processed: 2; failed: 1; total: 3; seconds spent: 0.000055".
+var processedRegexp = regexp.MustCompile(`processed: (\d+); failed: (\d+); total: (\d+)`)
+
+type Zabbix struct {
+	Address               string            `toml:"address"`
+	Timeout               internal.Duration `toml:"timeout"`
+	KeyPrefix             string            `toml:"key_prefix"`
+	HostTag               string            `toml:"host_tag"`
+	SkipMeasurementPrefix bool              `toml:"skip_measurement_prefix"`
+	LLD                   bool              `toml:"lld"`
+	LLDSendInterval       internal.Duration `toml:"lld_send_interval"`
+
+	hostname string
+
+	// discovery has the tag values of the series of each host and LLD key,
+	// keyed by the tag values.
+	discovery map[lldKey]map[string]map[string]string
+	lldSent   time.Time
+	now       func() time.Time
+}
+
+// item is a value sent to the server.
+type item struct {
+	Host  string `json:"host"`
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Clock int64  `json:"clock,omitempty"`
+	NS    int64  `json:"ns,omitempty"`
+}
+
+type request struct {
+	Request string  `json:"request"`
+	Data    []*item `json:"data"`
+	Clock   int64   `json:"clock"`
+	NS      int64   `json:"ns"`
+}
+
+type response struct {
+	Response string `json:"response"`
+	Info     string `json:"info"`
+}
+
+type lldKey struct {
+	host string
+	key  string
+}
+
+func (z *Zabbix) SampleConfig() string {
+	return sampleConfig
+}
+
+func (z *Zabbix) Description() string {
+	return "Send metrics to Zabbix with the trapper protocol."
+}
+
+func (z *Zabbix) Connect() error {
+	if z.Address == "" {
+		return fmt.Errorf("address is required")
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return err
+	}
+	z.hostname = hostname
+	z.discovery = make(map[lldKey]map[string]map[string]string)
+	if z.now == nil {
+		z.now = time.Now
+	}
+	return nil
+}
+
+func (z *Zabbix) Close() error {
+	return nil
+}
+
+func (z *Zabbix) Write(metrics []telegraf.Metric) error {
+	var items []*item
+	var discovered bool
+	for _, metric := range metrics {
+		host, ok := metric.GetTag(z.HostTag)
+		if !ok {
+			host = z.hostname
+		}
+		tags := make(map[string]string)
+		for _, tag := range metric.TagList() {
+			if tag.Key != z.HostTag {
+				tags[tag.Key] = tag.Value
+			}
+		}
+		keys := make([]string, 0, len(tags))
+		for key := range tags {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		t := metric.Time()
+		for _, field := range metric.FieldList() {
+			value, ok := formatValue(field.Value)
+			if !ok {
+				continue
+			}
+			items = append(items, &item{
+				Host:  host,
+				Key:   z.key(metric.Name(), field.Key, keys, tags),
+				Value: value,
+				Clock: t.Unix(),
+				NS:    int64(t.Nanosecond()),
+			})
+		}
+
+		if z.LLD && len(keys) > 0 && z.discover(host, metric.Name(), keys, tags) {
+			discovered = true
+		}
+	}
+
+	if z.LLD && len(z.discovery) > 0 && (discovered || !z.now().Before(z.lldSent.Add(z.LLDSendInterval.Duration))) {
+		// The discovery data is sent first, for the items to exist when
+		// receiving their values.
+		if err := z.send(z.lldItems()); err != nil {
+			return err
+		}
+		z.lldSent = z.now()
+	}
+
+	if len(items) == 0 {
+		return nil
+	}
+	return z.send(items)
+}
+
+// key returns the key of the item of a field, with the tag values sorted by
+// the tag keys as parameters.
+func (z *Zabbix) key(name, field string, keys []string, tags map[string]string) string {
+	key := z.KeyPrefix
+	if !z.SkipMeasurementPrefix {
+		key += name + "."
+	}
+	key += field
+	if len(keys) == 0 {
+		return key
+	}
+
+	params := make([]string, 0, len(keys))
+	for _, k := range keys {
+		params = append(params, quoteParam(tags[k]))
+	}
+	return key + "[" + strings.Join(params, ",") + "]"
+}
+
+// quoteParam quotes a parameter of an item key when required, the quotes
+// being escaped.
+func quoteParam(param string) string {
+	if !strings.ContainsAny(param, `,]" `) && !strings.HasPrefix(param, "[") {
+		return param
+	}
+	return `"` + strings.Replace(param, `"`, `\"`, -1) + `"`
+}
+
+// discover records the tag values of a series, returning whether they are
+// new.
+func (z *Zabbix) discover(host, name string, keys []string, tags map[string]string) bool {
+	k := lldKey{host: host, key: z.KeyPrefix + lldPrefix + name + "." + strings.Join(keys, ".")}
+	series, ok := z.discovery[k]
+	if !ok {
+		series = make(map[string]map[string]string)
+		z.discovery[k] = series
+	}
+
+	var id strings.Builder
+	for _, key := range keys {
+		id.WriteString(key + "\x00" + tags[key] + "\x00")
+	}
+	if _, ok := series[id.String()]; ok {
+		return false
+	}
+	macros := make(map[string]string, len(keys))
+	for _, key := range keys {
+		macros["{#"+strings.ToUpper(key)+"}"] = tags[key]
+	}
+	series[id.String()] = macros
+	return true
+}
+
+// lldItems returns the discovery data of all the series seen, the server
+// replacing the previous data of the keys.
+func (z *Zabbix) lldItems() []*item {
+	keys := make([]lldKey, 0, len(z.discovery))
+	for k := range z.discovery {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].host != keys[j].host {
+			return keys[i].host < keys[j].host
+		}
+		return keys[i].key < keys[j].key
+	})
+
+	items := make([]*item, 0, len(keys))
+	for _, k := range keys {
+		series := z.discovery[k]
+		ids := make([]string, 0, len(series))
+		for id := range series {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		data := make([]map[string]string, 0, len(ids))
+		for _, id := range ids {
+			data = append(data, series[id])
+		}
+		value, err := json.Marshal(map[string]interface{}{"data": data})
+		if err != nil {
+			continue
+		}
+		items = append(items, &item{Host: k.host, Key: k.key, Value: string(value)})
+	}
+	return items
+}
+
+func formatValue(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case bool:
+		if v {
+			return "1", true
+		}
+		return "0", true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case uint64:
+		return strconv.FormatUint(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	default:
+		return "", false
+	}
+}
+
+// send sends the items in a request to the server.
+func (z *Zabbix) send(items []*item) error {
+	now := z.now()
+	data, err := json.Marshal(&request{
+		Request: "sender data",
+		Data:    items,
+		Clock:   now.Unix(),
+		NS:      int64(now.Nanosecond()),
+	})
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.DialTimeout("tcp", z.Address, z.Timeout.Duration)
+	if err != nil {
+		return fmt.Errorf("connecting to %s failed: %v", z.Address, err)
+	}
+	defer conn.Close()
+	if z.Timeout.Duration > 0 {
+		conn.SetDeadline(time.Now().Add(z.Timeout.Duration))
+	}
+
+	if _, err := conn.Write(frame(data)); err != nil {
+		return fmt.Errorf("writing to %s failed: %v", z.Address, err)
+	}
+	b, err := readPacket(conn)
+	if err != nil {
+		return fmt.Errorf("reading the response of %s failed: %v", z.Address, err)
+	}
+
+	var resp response
+	if err := json.Unmarshal(b, &resp); err != nil {
+		return fmt.Errorf("invalid response of %s: %v", z.Address, err)
+	}
+	if resp.Response != "success" {
+		return fmt.Errorf("request to %s failed: %s %s", z.Address, resp.Response, resp.Info)
+	}
+
+	// The failed items are rejected by the server, such as when they do not
+	// exist, sending them again not helping.
+	if m := processedRegexp.FindStringSubmatch(resp.Info); m != nil && m[2] != "0" {
+		log.Printf("W! [outputs.zabbix] %s of %s items not processed by %s", m[2], m[3], z.Address)
+	}
+	return nil
+}
+
+// frame returns the packet of the data, with the header and its length.
+func frame(data []byte) []byte {
+	packet := make([]byte, len(header)+8+len(data))
+	copy(packet, header)
+	binary.LittleEndian.PutUint64(packet[len(header):], uint64(len(data)))
+	copy(packet[len(header)+8:], data)
+	return packet
+}
+
+// readPacket reads a packet, returning its data.
+func readPacket(r io.Reader) ([]byte, error) {
+	h := make([]byte, len(header)+8)
+	if _, err := io.ReadFull(r, h); err != nil {
+		return nil, err
+	}
+	if string(h[:len(header)]) != string(header) {
+		return nil, fmt.Errorf("invalid header %q", h[:len(header)])
+	}
+	length := binary.LittleEndian.Uint64(h[len(header):])
+	if length > maxResponseSize {
+		return nil, fmt.Errorf("packet of %d bytes too large", length)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+func init() {
+	outputs.Add("zabbix", func() telegraf.Output {
+		return &Zabbix{
+			Timeout:         internal.Duration{Duration: 5 * time.Second},
+			KeyPrefix:       "telegraf.",
+			HostTag:         "host",
+			LLDSendInterval: internal.Duration{Duration: 10 * time.Minute},
+		}
+	})
+}
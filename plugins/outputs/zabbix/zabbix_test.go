@@ -0,0 +1,191 @@
+package zabbix
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/influxdata/telegraf"
+	"github.com/influxdata/telegraf/internal"
+	"github.com/influxdata/telegraf/testutil"
+)
+
+// fakeServer is a Zabbix server recording the requests, answering with the
+// response.
+type fakeServer struct {
+	listener net.Listener
+	requests chan request
+	response response
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &fakeServer{
+		listener: listener,
+		requests: make(chan request, 10),
+		response: response{Response: "success", Info: "processed: 1; failed: 0; total: 1; seconds spent: 0.000055"},
+	}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeServer) serve(conn net.Conn) {
+	defer conn.Close()
+	data, err := readPacket(conn)
+	if err != nil {
+		return
+	}
+	var req request
+	if err := json.Unmarshal(data, &req); err != nil {
+		return
+	}
+	s.requests <- req
+
+	data, _ = json.Marshal(s.response)
+	conn.Write(frame(data))
+}
+
+func (s *fakeServer) next(t *testing.T) request {
+	select {
+	case req := <-s.requests:
+		return req
+	case <-time.After(5 * time.Second):
+		t.Fatal("no request received")
+	}
+	return request{}
+}
+
+func newZabbix(s *fakeServer) *Zabbix {
+	return &Zabbix{
+		Address:         s.listener.Addr().String(),
+		Timeout:         internal.Duration{Duration: 5 * time.Second},
+		KeyPrefix:       "telegraf.",
+		HostTag:         "host",
+		LLDSendInterval: internal.Duration{Duration: 10 * time.Minute},
+	}
+}
+
+func TestWrite(t *testing.T) {
+	s := newFakeServer(t)
+	defer s.listener.Close()
+
+	z := newZabbix(s)
+	require.NoError(t, z.Connect())
+	require.NoError(t, z.Write([]telegraf.Metric{
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "web01", "cpu": "cpu0"},
+			map[string]interface{}{"online": true},
+			time.Unix(1500000000, 5)),
+		testutil.MustMetric("cpu",
+			map[string]string{"host": "web01", "cpu": "cpu0"},
+			map[string]interface{}{"usage_idle": 95.5},
+			time.Unix(1500000000, 5)),
+		testutil.MustMetric("disk",
+			map[string]string{"path": "/mnt/my disk", "fstype": "ext4"},
+			map[string]interface{}{"free": int64(42)},
+			time.Unix(1500000000, 5)),
+	}))
+
+	req := s.next(t)
+	require.Equal(t, "sender data", req.Request)
+	require.Equal(t, []*item{
+		{Host: "web01", Key: "telegraf.cpu.online[cpu0]", Value: "1", Clock: 1500000000, NS: 5},
+		{Host: "web01", Key: "telegraf.cpu.usage_idle[cpu0]", Value: "95.5", Clock: 1500000000, NS: 5},
+		{Host: z.hostname, Key: `telegraf.disk.free[ext4,"/mnt/my disk"]`, Value: "42", Clock: 1500000000, NS: 5},
+	}, req.Data)
+}
+
+func TestWriteSkipMeasurementPrefix(t *testing.T) {
+	s := newFakeServer(t)
+	defer s.listener.Close()
+
+	z := newZabbix(s)
+	z.SkipMeasurementPrefix = true
+	require.NoError(t, z.Connect())
+	require.NoError(t, z.Write([]telegraf.Metric{
+		testutil.MustMetric("mem", map[string]string{"host": "web01"}, map[string]interface{}{"used": 1.5}, time.Unix(1500000000, 5)),
+	}))
+
+	req := s.next(t)
+	require.Equal(t, []*item{
+		{Host: "web01", Key: "telegraf.used", Value: "1.5", Clock: 1500000000, NS: 5},
+	}, req.Data)
+}
+
+func TestWriteLLD(t *testing.T) {
+	s := newFakeServer(t)
+	defer s.listener.Close()
+
+	z := newZabbix(s)
+	z.LLD = true
+	now := time.Unix(1500000000, 0)
+	z.now = func() time.Time { return now }
+	require.NoError(t, z.Connect())
+
+	write := func(cpu string) {
+		require.NoError(t, z.Write([]telegraf.Metric{
+			testutil.MustMetric("cpu",
+				map[string]string{"host": "web01", "cpu": cpu},
+				map[string]interface{}{"usage_idle": 95.5},
+				time.Unix(1500000000, 5)),
+		}))
+	}
+
+	// The discovery data is sent before the values of a new series
+	write("cpu0")
+	req := s.next(t)
+	require.Equal(t, []*item{
+		{Host: "web01", Key: "telegraf.lld.cpu.cpu", Value: `{"data":[{"{#CPU}":"cpu0"}]}`},
+	}, req.Data)
+	require.Len(t, s.next(t).Data, 1)
+
+	// All the series of the key are sent with a new one
+	write("cpu1")
+	req = s.next(t)
+	require.Equal(t, []*item{
+		{Host: "web01", Key: "telegraf.lld.cpu.cpu", Value: `{"data":[{"{#CPU}":"cpu0"},{"{#CPU}":"cpu1"}]}`},
+	}, req.Data)
+	require.Len(t, s.next(t).Data, 1)
+
+	// The known series are only sent again after the interval
+	write("cpu0")
+	require.Equal(t, "telegraf.cpu.usage_idle[cpu0]", s.next(t).Data[0].Key)
+
+	now = now.Add(10 * time.Minute)
+	write("cpu0")
+	require.Equal(t, "telegraf.lld.cpu.cpu", s.next(t).Data[0].Key)
+	require.Equal(t, "telegraf.cpu.usage_idle[cpu0]", s.next(t).Data[0].Key)
+}
+
+func TestWriteFailure(t *testing.T) {
+	s := newFakeServer(t)
+	defer s.listener.Close()
+	s.response = response{Response: "failed", Info: "cannot process request"}
+
+	z := newZabbix(s)
+	require.NoError(t, z.Connect())
+	err := z.Write([]telegraf.Metric{
+		testutil.MustMetric("mem", nil, map[string]interface{}{"used": 1.5}, time.Unix(1500000000, 5)),
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "cannot process request")
+}
+
+func TestQuoteParam(t *testing.T) {
+	require.Equal(t, "cpu0", quoteParam("cpu0"))
+	require.Equal(t, `"a,b"`, quoteParam("a,b"))
+	require.Equal(t, `"[a]"`, quoteParam("[a]"))
+	require.Equal(t, `"say \"hi\""`, quoteParam(`say "hi"`))
+}
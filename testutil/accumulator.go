@@ -20,6 +20,7 @@ type Metric struct {
 	Tags        map[string]string
 	Fields      map[string]interface{}
 	Time        time.Time
+	Type        telegraf.ValueType
 }
 
 func (p *Metric) String() string {
@@ -62,6 +63,16 @@ func (a *Accumulator) AddFields(
 	fields map[string]interface{},
 	tags map[string]string,
 	timestamp ...time.Time,
+) {
+	a.addFields(measurement, fields, tags, telegraf.Untyped, timestamp...)
+}
+
+func (a *Accumulator) addFields(
+	measurement string,
+	fields map[string]interface{},
+	tags map[string]string,
+	tp telegraf.ValueType,
+	timestamp ...time.Time,
 ) {
 	a.Lock()
 	defer a.Unlock()
@@ -107,6 +118,7 @@ func (a *Accumulator) AddFields(
 		Fields:      fields,
 		Tags:        tagsCopy,
 		Time:        t,
+		Type:        tp,
 	}
 
 	a.Metrics = append(a.Metrics, p)
@@ -118,7 +130,7 @@ func (a *Accumulator) AddCounter(
 	tags map[string]string,
 	timestamp ...time.Time,
 ) {
-	a.AddFields(measurement, fields, tags, timestamp...)
+	a.addFields(measurement, fields, tags, telegraf.Counter, timestamp...)
 }
 
 func (a *Accumulator) AddGauge(
@@ -127,22 +139,26 @@ func (a *Accumulator) AddGauge(
 	tags map[string]string,
 	timestamp ...time.Time,
 ) {
-	a.AddFields(measurement, fields, tags, timestamp...)
+	a.addFields(measurement, fields, tags, telegraf.Gauge, timestamp...)
 }
 
 func (a *Accumulator) AddMetrics(metrics []telegraf.Metric) {
 	for _, m := range metrics {
-		a.AddFields(m.Name(), m.Fields(), m.Tags(), m.Time())
+		a.AddMetric(m)
 	}
 }
 
+func (a *Accumulator) AddMetric(m telegraf.Metric) {
+	a.addFields(m.Name(), m.Fields(), m.Tags(), m.Type(), m.Time())
+}
+
 func (a *Accumulator) AddSummary(
 	measurement string,
 	fields map[string]interface{},
 	tags map[string]string,
 	timestamp ...time.Time,
 ) {
-	a.AddFields(measurement, fields, tags, timestamp...)
+	a.addFields(measurement, fields, tags, telegraf.Summary, timestamp...)
 }
 
 func (a *Accumulator) AddHistogram(
@@ -151,7 +167,7 @@ func (a *Accumulator) AddHistogram(
 	tags map[string]string,
 	timestamp ...time.Time,
 ) {
-	a.AddFields(measurement, fields, tags, timestamp...)
+	a.addFields(measurement, fields, tags, telegraf.Histogram, timestamp...)
 }
 
 // AddError appends the given error to Accumulator.Errors.
@@ -0,0 +1,30 @@
+package testutil
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TempDir makes a temporary directory for a test, returning it with a
+// function removing it and its content.
+func TempDir(t *testing.T, prefix string) (string, func()) {
+	dir, err := ioutil.TempDir("", prefix)
+	require.NoError(t, err)
+	return dir, func() {
+		os.RemoveAll(dir)
+	}
+}
+
+// WriteFiles writes the files, the contents by path relative to the
+// directory, making the parent directories of the files as needed.
+func WriteFiles(t *testing.T, dir string, files map[string]string) {
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0644))
+	}
+}
@@ -73,4 +73,10 @@ openssl ca -config ./openssl.conf -in ./certs/servercsr.pem -out ./certs/serverc
 # Create client keypair
 openssl genrsa -out ./private/clientkey.pem 1024 &&
 openssl req -new -key ./private/clientkey.pem -out ./certs/clientcsr.pem -outform PEM -subj "/CN=client.localdomain/O=client/" &&
-openssl ca -config ./openssl.conf -in ./certs/clientcsr.pem -out ./certs/clientcert.pem -notext -batch -extensions client_ca_extensions
+openssl ca -config ./openssl.conf -in ./certs/clientcsr.pem -out ./certs/clientcert.pem -notext -batch -extensions client_ca_extensions &&
+
+# Create the passphrase protected keys and PKCS#12 bundles
+openssl rsa -in ./private/clientkey.pem -aes256 -traditional -passout pass:telegraf -out ./private/clientkeyenc.pem &&
+openssl pkcs8 -topk8 -in ./private/serverkey.pem -v2 aes-256-cbc -passout pass:telegraf -out ./private/serverkeyenc.pem &&
+openssl pkcs12 -export -in ./certs/clientcert.pem -inkey ./private/clientkey.pem -certfile ./certs/cacert.pem -passout pass:telegraf -out ./certs/client.p12 &&
+openssl pkcs12 -export -in ./certs/servercert.pem -inkey ./private/serverkey.pem -passout pass:telegraf -out ./certs/server.p12
@@ -0,0 +1,74 @@
+package testutil
+
+import (
+	"encoding/binary"
+	"io"
+	"net"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// ServeSocks5 accepts a connection on the listener as a SOCKS5 proxy, and
+// forwards it to the address requested.  The client must authenticate with
+// the username and password if the username is set.
+func ServeSocks5(t *testing.T, l net.Listener, username, password string) {
+	conn, err := l.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	read := func(n int) []byte {
+		buf := make([]byte, n)
+		_, err := io.ReadFull(conn, buf)
+		require.NoError(t, err)
+		return buf
+	}
+
+	// Greeting, with the authentication methods of the client
+	greeting := read(2)
+	read(int(greeting[1]))
+	if username == "" {
+		conn.Write([]byte{5, 0})
+	} else {
+		conn.Write([]byte{5, 2})
+
+		// Username/password authentication
+		u := read(int(read(2)[1]))
+		p := read(int(read(1)[0]))
+		if string(u) != username || string(p) != password {
+			conn.Write([]byte{1, 1})
+			return
+		}
+		conn.Write([]byte{1, 0})
+	}
+
+	// Connect request
+	var host string
+	switch typ := read(4)[3]; typ {
+	case 1:
+		host = net.IP(read(4)).String()
+	case 3:
+		host = string(read(int(read(1)[0])))
+	case 4:
+		host = net.IP(read(16)).String()
+	default:
+		t.Errorf("unknown SOCKS5 address type %d", typ)
+		return
+	}
+	port := binary.BigEndian.Uint16(read(2))
+
+	target, err := net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(int(port))))
+	if err != nil {
+		// Connection refused
+		conn.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer target.Close()
+	conn.Write([]byte{5, 0, 0, 1, 127, 0, 0, 1, 0, 0})
+
+	go io.Copy(target, conn)
+	io.Copy(conn, target)
+}
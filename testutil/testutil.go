@@ -63,3 +63,19 @@ func TestMetric(value interface{}, name ...string) telegraf.Metric {
 	)
 	return pt
 }
+
+// MustMetric returns a metric of the given values, it panics when the metric
+// can not be made.
+func MustMetric(
+	name string,
+	tags map[string]string,
+	fields map[string]interface{},
+	tm time.Time,
+	tp ...telegraf.ValueType,
+) telegraf.Metric {
+	m, err := metric.New(name, tags, fields, tm, tp...)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
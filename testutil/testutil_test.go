@@ -1,8 +1,14 @@
 package testutil
 
 import (
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/influxdata/telegraf"
+	"github.com/stretchr/testify/require"
 )
 
 func TestDockerHost(t *testing.T) {
@@ -32,3 +38,33 @@ func TestDockerHost(t *testing.T) {
 	}
 
 }
+
+func TestAccumulatorMetricType(t *testing.T) {
+	var acc Accumulator
+	acc.AddFields("untyped", map[string]interface{}{"value": 1.0}, nil)
+	acc.AddCounter("counter", map[string]interface{}{"value": 1.0}, nil)
+	acc.AddGauge("gauge", map[string]interface{}{"value": 1.0}, nil)
+	acc.AddMetric(MustMetric("summary", nil, map[string]interface{}{"value": 1.0}, time.Unix(0, 0), telegraf.Summary))
+
+	require.Len(t, acc.Metrics, 4)
+	require.Equal(t, telegraf.Untyped, acc.Metrics[0].Type)
+	require.Equal(t, telegraf.Counter, acc.Metrics[1].Type)
+	require.Equal(t, telegraf.Gauge, acc.Metrics[2].Type)
+	require.Equal(t, telegraf.Summary, acc.Metrics[3].Type)
+}
+
+func TestWriteFiles(t *testing.T) {
+	dir, cleanup := TempDir(t, "testutil")
+	WriteFiles(t, dir, map[string]string{
+		"a":     "1\n",
+		"b/c/d": "2\n",
+	})
+
+	content, err := ioutil.ReadFile(filepath.Join(dir, "b", "c", "d"))
+	require.NoError(t, err)
+	require.Equal(t, "2\n", string(content))
+
+	cleanup()
+	_, err = os.Stat(dir)
+	require.True(t, os.IsNotExist(err))
+}
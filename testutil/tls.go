@@ -57,6 +57,14 @@ func (p *pki) ClientKeyPath() string {
 	return path.Join(p.path, "clientkey.pem")
 }
 
+func (p *pki) ClientEncKeyPath() string {
+	return path.Join(p.path, "clientkeyenc.pem")
+}
+
+func (p *pki) ClientPKCS12Path() string {
+	return path.Join(p.path, "client.p12")
+}
+
 func (p *pki) ReadServerCert() string {
 	return readCertificate(p.ServerCertPath())
 }
@@ -73,6 +81,19 @@ func (p *pki) ServerKeyPath() string {
 	return path.Join(p.path, "serverkey.pem")
 }
 
+func (p *pki) ServerEncKeyPath() string {
+	return path.Join(p.path, "serverkeyenc.pem")
+}
+
+func (p *pki) ServerPKCS12Path() string {
+	return path.Join(p.path, "server.p12")
+}
+
+// KeyPwd is the passphrase of the encrypted keys and PKCS#12 bundles.
+func (p *pki) KeyPwd() string {
+	return "telegraf"
+}
+
 func readCertificate(filename string) string {
 	file, err := os.Open(filename)
 	if err != nil {